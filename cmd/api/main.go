@@ -2,15 +2,19 @@ package main
 
 import (
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/database"
 	httpHandlers "github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/http"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/logging"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
@@ -22,12 +26,18 @@ func main() {
 	if err := config.Load(); err != nil {
 		log.Fatal().Err(err).Msg("config load failed")
 	}
+	logging.Setup(config.LogLevel(), config.LogFormat())
 
-	db, err := database.Connect()
-	if err != nil {
-		log.Fatal().Err(err).Msg("db connect failed")
+	// The in-memory backend runs without Postgres
+	var db *sqlx.DB
+	if config.StorageBackend() != config.BackendMemory {
+		var err error
+		db, err = database.Connect()
+		if err != nil {
+			log.Fatal().Err(err).Msg("db connect failed")
+		}
+		defer db.Close()
 	}
-	defer db.Close()
 
 	svcs, err := service.New(db)
 	if err != nil {
@@ -35,7 +45,8 @@ func main() {
 	}
 
 	app := fiber.New(fiber.Config{
-		AppName: "Smart Energy Grid API v1.0",
+		AppName:   "Smart Energy Grid API v1.0",
+		BodyLimit: config.MaxUploadBytes(),
 	})
 
 	// Middleware
@@ -66,6 +77,20 @@ func main() {
 		addr = ":" + port
 	}
 
+	// Stop accepting requests on Ctrl+C or SIGTERM, then flush buffered work
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		if err := app.Shutdown(); err != nil {
+			log.Error().Err(err).Msg("shutdown failed")
+		}
+	}()
+
 	log.Info().Str("addr", addr).Msg("api listening")
-	log.Fatal().Err(app.Listen(addr)).Msg("server exit")
+	if err := app.Listen(addr); err != nil {
+		log.Fatal().Err(err).Msg("server exit")
+	}
+	svcs.Close()
+	log.Info().Msg("api stopped")
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// healthMonitor tracks broker connectivity and message flow for /healthz
+type healthMonitor struct {
+	connected  func() bool
+	staleAfter time.Duration // 0 disables the staleness check
+	started    time.Time
+	metrics    *ingestMetrics
+	lastMsg    atomic.Int64 // unix nanos of the last received message; 0 before the first
+}
+
+func newHealthMonitor(connected func() bool, staleAfter time.Duration, metrics *ingestMetrics) *healthMonitor {
+	return &healthMonitor{connected: connected, staleAfter: staleAfter, started: time.Now(), metrics: metrics}
+}
+
+// Touch records that a message was just received
+func (h *healthMonitor) Touch() { h.lastMsg.Store(time.Now().UnixNano()) }
+
+// ServeHTTP reports 200 when connected and receiving messages, and 503 when
+// disconnected or nothing arrived within staleAfter (measured from startup
+// until the first message)
+func (h *healthMonitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	connected := h.connected()
+
+	body := map[string]interface{}{"connected": connected, "ingest": h.metrics.Summary()}
+	since := h.started
+	if n := h.lastMsg.Load(); n != 0 {
+		since = time.Unix(0, n)
+		body["last_message_at"] = since.UTC().Format(time.RFC3339)
+	}
+	stale := h.staleAfter > 0 && now.Sub(since) > h.staleAfter
+
+	status := http.StatusOK
+	body["status"] = "ok"
+	switch {
+	case !connected:
+		status = http.StatusServiceUnavailable
+		body["status"] = "disconnected"
+	case stale:
+		status = http.StatusServiceUnavailable
+		body["status"] = "stale"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
+)
+
+func noQueue() (service.AnomalyQueueStats, bool) { return service.AnomalyQueueStats{}, false }
+
+func TestHealthMonitor(t *testing.T) {
+	tests := []struct {
+		name       string
+		connected  bool
+		staleAfter time.Duration
+		started    time.Duration // how long ago the ingestor started
+		lastMsg    time.Duration // how long ago the last message arrived; 0 for none
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "connected and receiving", connected: true, staleAfter: time.Minute, started: time.Hour, lastMsg: 10 * time.Second, wantCode: 200, wantStatus: "ok"},
+		{name: "disconnected", staleAfter: time.Minute, started: time.Hour, lastMsg: 10 * time.Second, wantCode: 503, wantStatus: "disconnected"},
+		{name: "connected but stale", connected: true, staleAfter: time.Minute, started: time.Hour, lastMsg: 5 * time.Minute, wantCode: 503, wantStatus: "stale"},
+		{name: "just started, nothing yet", connected: true, staleAfter: time.Minute, started: 10 * time.Second, wantCode: 200, wantStatus: "ok"},
+		{name: "nothing since startup", connected: true, staleAfter: time.Minute, started: time.Hour, wantCode: 503, wantStatus: "stale"},
+		{name: "staleness check disabled", connected: true, started: time.Hour, lastMsg: 24 * time.Hour, wantCode: 200, wantStatus: "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHealthMonitor(func() bool { return tt.connected }, tt.staleAfter, newIngestMetrics(noQueue))
+			h.started = time.Now().Add(-tt.started)
+			if tt.lastMsg > 0 {
+				h.lastMsg.Store(time.Now().Add(-tt.lastMsg).UnixNano())
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			var body struct {
+				Status        string `json:"status"`
+				Connected     bool   `json:"connected"`
+				LastMessageAt string `json:"last_message_at"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.wantCode || body.Status != tt.wantStatus || body.Connected != tt.connected {
+				t.Errorf("%d %+v, want %d %s", rec.Code, body, tt.wantCode, tt.wantStatus)
+			}
+			if (body.LastMessageAt != "") != (tt.lastMsg > 0) {
+				t.Errorf("last_message_at = %q", body.LastMessageAt)
+			}
+		})
+	}
+}
+
+func TestHealthMonitorTouch(t *testing.T) {
+	h := newHealthMonitor(func() bool { return true }, time.Minute, newIngestMetrics(noQueue))
+	h.started = time.Now().Add(-time.Hour)
+
+	for _, want := range []int{503, 200} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != want {
+			t.Errorf("status %d, want %d", rec.Code, want)
+		}
+		h.Touch()
+	}
+}
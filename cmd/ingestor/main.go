@@ -1,50 +1,187 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/database"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/logging"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 )
 
+// clientOptions builds a persistent-session client: with a stable client ID
+// and clean session off, the broker queues QoS 1/2 messages published while
+// the ingestor is down or reconnecting and delivers them when it's back.
+// Delivery is at-least-once; a redelivered reading overwrites its DynamoDB
+// item, and postgres skips it by the unique (meter_id, timestamp) index.
+func clientOptions(broker, clientID string) *mqtt.ClientOptions {
+	return mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetCleanSession(false).
+		SetAutoReconnect(true)
+}
+
+// subscribeQoS clamps MQTT_QOS to the valid 0-2 range
+func subscribeQoS(qos int) byte {
+	if qos < 0 || qos > 2 {
+		log.Warn().Int("qos", qos).Msg("MQTT_QOS must be 0, 1 or 2; using 1")
+		return 1
+	}
+	return byte(qos)
+}
+
 func main() {
 	if err := config.Load(); err != nil {
 		log.Fatal().Err(err).Msg("config load failed")
 	}
+	logging.Setup(config.LogLevel(), config.LogFormat())
 
-	db, err := database.Connect()
-	if err != nil {
-		log.Fatal().Err(err).Msg("db connect failed")
+	// The in-memory backend runs without Postgres
+	var db *sqlx.DB
+	if config.StorageBackend() != config.BackendMemory {
+		var err error
+		db, err = database.Connect()
+		if err != nil {
+			log.Fatal().Err(err).Msg("db connect failed")
+		}
+		defer db.Close()
 	}
-	defer db.Close()
 
 	svcs, err := service.New(db)
 	if err != nil {
 		log.Fatal().Err(err).Msg("service initialization failed")
 	}
 
-	opts := mqtt.NewClientOptions().AddBroker(config.MQTTBroker())
-	client := mqtt.NewClient(opts)
+	// Each source connects first so /healthz can report its state
+	var connected func() bool
+	var run func(ingestFunc)
+	switch config.IngestSource() {
+	case config.IngestMQTT:
+		connected, run = connectMQTT(svcs)
+	case config.IngestKinesis:
+		connected, run = connectKinesis(svcs)
+	default:
+		log.Fatal().Str("source", config.IngestSource()).Msg("unknown INGEST_SOURCE: expected mqtt or kinesis")
+	}
+
+	metrics := newIngestMetrics(svcs.Readings.AnomalyQueueStats)
+	health := newHealthMonitor(connected, config.IngestorStaleAfter(), metrics)
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", health)
+	mux.Handle("/metrics", metrics)
+	go func() {
+		if err := http.ListenAndServe(config.IngestorHealthAddr(), mux); err != nil {
+			log.Error().Err(err).Msg("health server stopped")
+		}
+	}()
+
+	// Flush buffered work, such as the readings archive, on Ctrl+C or SIGTERM
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		log.Info().Msg("ingestor stopping")
+		svcs.Close()
+		os.Exit(0)
+	}()
+
+	lagWarn := config.IngestorLagWarn()
+	run(func(rd *domain.Reading, err error, received time.Time) {
+		health.Touch()
+		if rd != nil && !rd.Timestamp.IsZero() {
+			lag := ingestionLag(rd.Timestamp.Time, received)
+			metrics.ObserveLag(lag)
+			if lagWarn > 0 && lag > lagWarn {
+				log.Warn().Dur("lag", lag).Int64("meter_id", rd.MeterID).Msg("ingestion lag above threshold")
+			}
+		}
+		if err != nil {
+			metrics.Failed()
+			log.Error().Err(err).Msg("ingest failed")
+			return
+		}
+		metrics.Processed()
+	})
+}
+
+// ingestFunc records the outcome of one message: the parsed reading (nil when
+// invalid), the store error and when the message was received
+type ingestFunc func(rd *domain.Reading, err error, received time.Time)
+
+// connectMQTT connects to the broker; run subscribes to the reading topics
+// and processes messages until the process exits
+func connectMQTT(svcs *service.Services) (connected func() bool, run func(ingestFunc)) {
+	qos := subscribeQoS(config.MQTTQoS())
+	client := mqtt.NewClient(clientOptions(config.MQTTBroker(), config.MQTTClientID()))
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
 		log.Fatal().Err(token.Error()).Msg("mqtt connect")
 	}
-	defer client.Disconnect(250)
 
-	handler := func(_ mqtt.Client, msg mqtt.Message) {
-		if err := svcs.Readings.FromMQTT(msg.Topic(), msg.Payload()); err != nil {
-			log.Error().Err(err).Msg("ingest failed")
+	run = func(ingest ingestFunc) {
+		defer client.Disconnect(250)
+
+		handler := func(_ mqtt.Client, msg mqtt.Message) {
+			received := time.Now()
+			rd, err := svcs.Readings.FromMQTT(msg.Topic(), msg.Payload())
+			ingest(rd, err, received)
+		}
+
+		// JSON readings, plus protobuf readings from gateways that publish them on readings.pb topics
+		topics := map[string]byte{
+			"energy/readings":      qos,
+			"energy/readings.pb":   qos,
+			"energy/+/readings.pb": qos,
+			// JSON readings in a vendor's schema; see VENDOR_MAPPINGS_FILE
+			"energy/vendor/+/readings": qos,
+		}
+		if token := client.SubscribeMultiple(topics, handler); token.Wait() && token.Error() != nil {
+			log.Fatal().Err(token.Error()).Msg("subscribe failed")
+		}
+
+		log.Info().Str("health", config.IngestorHealthAddr()).Uint8("qos", qos).Str("client_id", config.MQTTClientID()).Msg("ingestor running; Ctrl+C to stop")
+		for {
+			time.Sleep(10 * time.Second)
 		}
 	}
+	return client.IsConnected, run
+}
 
-	if token := client.Subscribe("energy/readings", 0, handler); token.Wait() && token.Error() != nil {
-		log.Fatal().Err(token.Error()).Msg("subscribe failed")
+// connectKinesis sets up the stream consumer; run polls every shard until
+// the shards close or a read fails. Records are decoded like MQTT payloads.
+func connectKinesis(svcs *service.Services) (connected func() bool, run func(ingestFunc)) {
+	consumer, err := cloud.NewKinesisConsumer(config.AWSRegion(), config.KinesisStreamName(),
+		config.KinesisIteratorType(), config.KinesisPollInterval())
+	if err != nil {
+		log.Fatal().Err(err).Msg("kinesis init failed")
 	}
 
-	log.Info().Msg("ingestor running; Ctrl+C to stop")
-	for {
-		time.Sleep(10 * time.Second)
+	run = func(ingest ingestFunc) {
+		facilityID := config.KinesisFacilityID()
+		log.Info().Str("health", config.IngestorHealthAddr()).Str("stream", config.KinesisStreamName()).Str("iterator", config.KinesisIteratorType()).Msg("ingestor running; Ctrl+C to stop")
+
+		err := consumer.Run(func(r cloud.KinesisRecord) {
+			received := time.Now()
+			rd, err := svcs.Readings.FromKinesis(facilityID, r.Data)
+			if err != nil {
+				err = fmt.Errorf("shard %s sequence %s: %w", r.ShardID, r.SequenceNumber, err)
+			}
+			ingest(rd, err, received)
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("kinesis consumer stopped")
+		}
+		log.Info().Msg("all kinesis shards closed")
 	}
+	return consumer.Connected, run
 }
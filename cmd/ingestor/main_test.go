@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
+)
+
+func TestClientOptionsFromConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantBroker   string
+		wantClientID string
+		wantQoS      byte
+	}{
+		{name: "defaults", wantBroker: "tcp://localhost:1883", wantClientID: "energy-grid-ingestor", wantQoS: 1},
+		{
+			name:       "configured",
+			env:        map[string]string{"MQTT_BROKER": "tcp://broker.internal:1883", "MQTT_CLIENT_ID": "ingestor-b", "MQTT_QOS": "2"},
+			wantBroker: "tcp://broker.internal:1883", wantClientID: "ingestor-b", wantQoS: 2,
+		},
+		{name: "QoS 0", env: map[string]string{"MQTT_QOS": "0"}, wantBroker: "tcp://localhost:1883", wantClientID: "energy-grid-ingestor", wantQoS: 0},
+		{name: "invalid QoS", env: map[string]string{"MQTT_QOS": "5"}, wantBroker: "tcp://localhost:1883", wantClientID: "energy-grid-ingestor", wantQoS: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"MQTT_BROKER", "MQTT_CLIENT_ID", "MQTT_QOS"} {
+				t.Setenv(k, tt.env[k])
+			}
+			if err := config.Load(); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := clientOptions(config.MQTTBroker(), config.MQTTClientID())
+			if len(opts.Servers) != 1 || opts.Servers[0].String() != tt.wantBroker {
+				t.Errorf("servers = %v, want %s", opts.Servers, tt.wantBroker)
+			}
+			if opts.ClientID != tt.wantClientID {
+				t.Errorf("client ID = %q, want %q", opts.ClientID, tt.wantClientID)
+			}
+			// A persistent session is what lets the broker queue messages during restarts
+			if opts.CleanSession || !opts.AutoReconnect {
+				t.Errorf("clean session %v, auto reconnect %v; want a persistent, reconnecting session", opts.CleanSession, opts.AutoReconnect)
+			}
+			if got := subscribeQoS(config.MQTTQoS()); got != tt.wantQoS {
+				t.Errorf("QoS = %d, want %d", got, tt.wantQoS)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
+)
+
+// lagBuckets are the upper bounds, in seconds, of the ingestion lag histogram
+var lagBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}
+
+// ingestMetrics counts processed and failed messages and keeps a histogram of
+// ingestion lag (receive time minus reading timestamp). It's exposed in the
+// Prometheus text format at /metrics and summarized in /healthz.
+type ingestMetrics struct {
+	mu        sync.Mutex
+	processed uint64
+	failed    uint64
+	buckets   []uint64 // cumulative counts per lagBuckets bound
+	lagSum    float64
+	lagCount  uint64
+	lastLag   float64
+
+	// queue reports the anomaly invoke queue; ok is false without the Lambda
+	queue func() (service.AnomalyQueueStats, bool)
+}
+
+func newIngestMetrics(queue func() (service.AnomalyQueueStats, bool)) *ingestMetrics {
+	return &ingestMetrics{buckets: make([]uint64, len(lagBuckets)), queue: queue}
+}
+
+// ingestionLag is how long after its timestamp a reading was received.
+// Readings stamped in the future (device clock skew) count as zero lag.
+func ingestionLag(readingTime, received time.Time) time.Duration {
+	lag := received.Sub(readingTime)
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// ObserveLag adds one lag sample to the histogram
+func (m *ingestMetrics) ObserveLag(lag time.Duration) {
+	secs := lag.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, le := range lagBuckets {
+		if secs <= le {
+			m.buckets[i]++
+		}
+	}
+	m.lagSum += secs
+	m.lagCount++
+	m.lastLag = secs
+}
+
+// Processed counts a stored message; Failed counts one that couldn't be parsed or stored
+func (m *ingestMetrics) Processed() { m.mu.Lock(); m.processed++; m.mu.Unlock() }
+func (m *ingestMetrics) Failed()    { m.mu.Lock(); m.failed++; m.mu.Unlock() }
+
+// Summary returns the counters and lag figures reported by /healthz
+func (m *ingestMetrics) Summary() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := map[string]interface{}{
+		"processed":        m.processed,
+		"failed":           m.failed,
+		"last_lag_seconds": m.lastLag,
+	}
+	if m.lagCount > 0 {
+		out["avg_lag_seconds"] = m.lagSum / float64(m.lagCount)
+	}
+	if q, ok := m.queue(); ok {
+		out["anomaly_queue"] = q
+	}
+	return out
+}
+
+func (m *ingestMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP ingestor_messages_total MQTT messages handled, by result.")
+	fmt.Fprintln(w, "# TYPE ingestor_messages_total counter")
+	fmt.Fprintf(w, "ingestor_messages_total{result=\"processed\"} %d\n", m.processed)
+	fmt.Fprintf(w, "ingestor_messages_total{result=\"failed\"} %d\n", m.failed)
+
+	fmt.Fprintln(w, "# HELP ingestor_lag_seconds Delay between a reading's timestamp and its receipt.")
+	fmt.Fprintln(w, "# TYPE ingestor_lag_seconds histogram")
+	for i, le := range lagBuckets {
+		fmt.Fprintf(w, "ingestor_lag_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(le, 'g', -1, 64), m.buckets[i])
+	}
+	fmt.Fprintf(w, "ingestor_lag_seconds_bucket{le=\"+Inf\"} %d\n", m.lagCount)
+	fmt.Fprintf(w, "ingestor_lag_seconds_sum %g\n", m.lagSum)
+	fmt.Fprintf(w, "ingestor_lag_seconds_count %d\n", m.lagCount)
+
+	q, ok := m.queue()
+	if !ok {
+		return
+	}
+	fmt.Fprintln(w, "# HELP ingestor_anomaly_queue_depth Readings waiting for an anomaly Lambda invocation.")
+	fmt.Fprintln(w, "# TYPE ingestor_anomaly_queue_depth gauge")
+	fmt.Fprintf(w, "ingestor_anomaly_queue_depth %d\n", q.Depth)
+	fmt.Fprintln(w, "# HELP ingestor_anomaly_invocations_total Anomaly Lambda invocations, by result; dropped readings found the queue full.")
+	fmt.Fprintln(w, "# TYPE ingestor_anomaly_invocations_total counter")
+	fmt.Fprintf(w, "ingestor_anomaly_invocations_total{result=\"invoked\"} %d\n", q.Invoked)
+	fmt.Fprintf(w, "ingestor_anomaly_invocations_total{result=\"failed\"} %d\n", q.Failed)
+	fmt.Fprintf(w, "ingestor_anomaly_invocations_total{result=\"dropped\"} %d\n", q.Dropped)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIngestionLag(t *testing.T) {
+	received := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		reading time.Time
+		want    time.Duration
+	}{
+		{name: "on time", reading: received, want: 0},
+		{name: "two seconds late", reading: received.Add(-2 * time.Second), want: 2 * time.Second},
+		{name: "replayed backlog", reading: received.Add(-90 * time.Minute), want: 90 * time.Minute},
+		{name: "device clock ahead", reading: received.Add(5 * time.Second), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ingestionLag(tt.reading, received); got != tt.want {
+				t.Errorf("ingestionLag = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestMetrics(t *testing.T) {
+	received := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := newIngestMetrics(noQueue)
+	// Messages whose readings were stamped 0.2s, 3s and 120s before receipt
+	for _, age := range []time.Duration{200 * time.Millisecond, 3 * time.Second, 2 * time.Minute} {
+		m.ObserveLag(ingestionLag(received.Add(-age), received))
+		m.Processed()
+	}
+	m.Failed()
+
+	sum := m.Summary()
+	if sum["processed"] != uint64(3) || sum["failed"] != uint64(1) {
+		t.Errorf("processed, failed = %v, %v; want 3, 1", sum["processed"], sum["failed"])
+	}
+	if sum["last_lag_seconds"] != 120.0 || sum["avg_lag_seconds"] != (0.2+3+120)/3 {
+		t.Errorf("last, avg lag = %v, %v", sum["last_lag_seconds"], sum["avg_lag_seconds"])
+	}
+	if _, ok := sum["anomaly_queue"]; ok {
+		t.Error("anomaly queue reported without the Lambda")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range []string{
+		`ingestor_messages_total{result="processed"} 3`,
+		`ingestor_messages_total{result="failed"} 1`,
+		`ingestor_lag_seconds_bucket{le="0.1"} 0`,
+		`ingestor_lag_seconds_bucket{le="0.5"} 1`,
+		`ingestor_lag_seconds_bucket{le="5"} 2`,
+		`ingestor_lag_seconds_bucket{le="60"} 2`,
+		`ingestor_lag_seconds_bucket{le="300"} 3`,
+		`ingestor_lag_seconds_bucket{le="+Inf"} 3`,
+		`ingestor_lag_seconds_sum 123.2`,
+		`ingestor_lag_seconds_count 3`,
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("metrics lack %q:\n%s", line, rec.Body.String())
+		}
+	}
+}
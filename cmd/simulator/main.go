@@ -6,16 +6,18 @@ import (
 	"time"
 
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/logging"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/rs/zerolog/log"
 )
 
 type Reading struct {
-	MeterID   string    `json:"meter_id"`
-	Timestamp time.Time `json:"timestamp"`
-	Voltage   float64   `json:"voltage"`
-	Current   float64   `json:"current"`
-	PowerKW   float64   `json:"power_kw"`
+	MeterID   string             `json:"meter_id"`
+	Timestamp timeutil.Timestamp `json:"timestamp"`
+	Voltage   float64            `json:"voltage"`
+	Current   float64            `json:"current"`
+	PowerKW   float64            `json:"power_kw"`
 }
 
 func main() {
@@ -23,6 +25,7 @@ func main() {
 	if err := config.Load(); err != nil {
 		log.Fatal().Err(err).Msg("config load failed")
 	}
+	logging.Setup(config.LogLevel(), config.LogFormat())
 	opts := mqtt.NewClientOptions().AddBroker(config.MQTTBroker())
 	client := mqtt.NewClient(opts)
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
@@ -32,8 +35,8 @@ func main() {
 
 	for i := 0; i < 100; i++ {
 		r := Reading{
-			MeterID:   "meter-001",
-			Timestamp: time.Now(),
+			MeterID:   "1",
+			Timestamp: timeutil.Now(),
 			Voltage:   220 + rand.Float64()*10,
 			Current:   5 + rand.Float64()*2,
 			PowerKW:   1 + rand.Float64(),
@@ -4,10 +4,12 @@ go 1.24.0
 
 require (
 	github.com/ANIKETSHETTY47/energy-grid-analytics-go v1.0.0
+	github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg v0.0.0
 	github.com/aws/aws-sdk-go-v2 v1.39.6
 	github.com/aws/aws-sdk-go-v2/config v1.31.17
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.20.21
 	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.52.4
+	github.com/aws/aws-sdk-go-v2/service/kinesis v1.42.1
 	github.com/aws/aws-sdk-go-v2/service/lambda v1.81.1
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.89.2
 	github.com/aws/aws-sdk-go-v2/service/sns v1.39.3
@@ -17,6 +19,7 @@ require (
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/rs/zerolog v1.34.0
 	github.com/spf13/viper v1.21.0
+	google.golang.org/protobuf v1.36.6
 )
 
 require (
@@ -67,3 +70,5 @@ require (
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
 )
+
+replace github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg => ./pkg
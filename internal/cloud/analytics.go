@@ -0,0 +1,77 @@
+package cloud
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// AnalyticsSummary is a daily summary item written by the analytics Lambda
+// to the AnalyticsSummaries table (hourly data is left out)
+type AnalyticsSummary struct {
+	FacilityID       string             `dynamodbav:"facilityId" json:"facility_id"`
+	Date             string             `dynamodbav:"date" json:"date"`
+	Timezone         string             `dynamodbav:"timezone" json:"timezone,omitempty"`
+	ReadingCount     int                `dynamodbav:"readingCount" json:"reading_count"`
+	TotalConsumption float64            `dynamodbav:"totalConsumption" json:"total_consumption"`
+	AveragePower     float64            `dynamodbav:"averagePower" json:"average_power"`
+	PeakPower        float64            `dynamodbav:"peakPower" json:"peak_power"`
+	MinPower         float64            `dynamodbav:"minPower" json:"min_power"`
+	AvgVoltage       float64            `dynamodbav:"avgVoltage" json:"avg_voltage"`
+	PowerFactor      float64            `dynamodbav:"powerFactor" json:"power_factor"`
+	PeakHour         string             `dynamodbav:"peakHour" json:"peak_hour"`
+	EstimatedCost    float64            `dynamodbav:"estimatedCost" json:"estimated_cost"`
+	CostBreakdown    map[string]float64 `dynamodbav:"costBreakdown" json:"cost_breakdown,omitempty"`
+	CoveragePercent  float64            `dynamodbav:"coveragePercent" json:"coverage_percent"`
+	Confidence       string             `dynamodbav:"confidence" json:"confidence"`
+	CreatedAt        int64              `dynamodbav:"createdAt" json:"created_at"`
+
+	// Energy (kWh) the Lambda interpolated into short gaps; billing can subtract it
+	EstimatedConsumption float64 `dynamodbav:"estimatedConsumption" json:"estimated_consumption,omitempty"`
+}
+
+// analyticsSummaryMaxPages bounds a range query; a page holds many days
+const analyticsSummaryMaxPages = 10
+
+// GetAnalyticsSummaries returns the facility's stored summaries with dates in
+// [from, to] (YYYY-MM-DD, inclusive), oldest first
+func (c *DynamoDBClient) GetAnalyticsSummaries(facilityID, from, to string) ([]AnalyticsSummary, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String("AnalyticsSummaries"),
+		KeyConditionExpression: aws.String("facilityId = :fid AND #date BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#date": "date",
+			"#tz":   "timezone",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fid":  &types.AttributeValueMemberS{Value: facilityID},
+			":from": &types.AttributeValueMemberS{Value: from},
+			":to":   &types.AttributeValueMemberS{Value: to},
+		},
+		ProjectionExpression: aws.String("facilityId, #date, #tz, readingCount, totalConsumption, averagePower, peakPower, minPower, avgVoltage, powerFactor, peakHour, estimatedCost, costBreakdown, coveragePercent, confidence, estimatedConsumption, createdAt"),
+		ScanIndexForward:     aws.Bool(true),
+	}
+
+	var summaries []AnalyticsSummary
+	for page := 0; page < analyticsSummaryMaxPages; page++ {
+		result, err := c.query(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query analytics summaries: %w", err)
+		}
+
+		var items []AnalyticsSummary
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal analytics summaries: %w", err)
+		}
+		summaries = append(summaries, items...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+	return summaries, nil
+}
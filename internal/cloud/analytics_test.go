@@ -0,0 +1,97 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetAnalyticsSummaries(t *testing.T) {
+	summary := func(date string, kwh float64) string {
+		return fmt.Sprintf(`{"facilityId":{"S":"facility-001"},"date":{"S":%q},"timezone":{"S":"Europe/Berlin"},"totalConsumption":{"N":"%g"},"readingCount":{"N":"96"}}`, date, kwh)
+	}
+	lastKey := func(date string) string {
+		return fmt.Sprintf(`{"facilityId":{"S":"facility-001"},"date":{"S":%q}}`, date)
+	}
+
+	tests := []struct {
+		name  string
+		pages []string // response bodies in the order they are requested
+		want  []string
+		// wantStarts is the date each query after the first resumes from
+		wantStarts []string
+	}{
+		{name: "no summaries", pages: []string{`{"Items":[]}`}},
+		{
+			name:  "one page",
+			pages: []string{`{"Items":[` + summary("2024-03-01", 410.5) + `,` + summary("2024-03-02", 398) + `]}`},
+			want:  []string{"2024-03-01", "2024-03-02"},
+		},
+		{
+			name: "over several pages",
+			pages: []string{
+				`{"Items":[` + summary("2024-03-01", 410.5) + `,` + summary("2024-03-02", 398) + `],"LastEvaluatedKey":` + lastKey("2024-03-02") + `}`,
+				`{"Items":[` + summary("2024-03-03", 402.25) + `],"LastEvaluatedKey":` + lastKey("2024-03-03") + `}`,
+				`{"Items":[` + summary("2024-03-05", 388) + `]}`,
+			},
+			want:       []string{"2024-03-01", "2024-03-02", "2024-03-03", "2024-03-05"},
+			wantStarts: []string{"2024-03-02", "2024-03-03"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type query struct {
+				TableName                 string
+				KeyConditionExpression    string
+				ExpressionAttributeValues map[string]map[string]string
+				ExclusiveStartKey         map[string]map[string]string
+				ScanIndexForward          bool
+			}
+			var got []query
+			c := &DynamoDBClient{ctx: context.Background(), region: "us-east-1", precision: DefaultPrecision}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				var q query
+				if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+					t.Error(err)
+				}
+				got = append(got, q)
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.Write([]byte(tt.pages[len(got)-1]))
+			})
+
+			summaries, err := c.GetAnalyticsSummaries("facility-001", "2024-03-01", "2024-03-07")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var dates []string
+			for _, s := range summaries {
+				dates = append(dates, s.Date)
+				if s.FacilityID != "facility-001" || s.Timezone != "Europe/Berlin" || s.ReadingCount != 96 || s.TotalConsumption == 0 {
+					t.Errorf("summary %+v not decoded", s)
+				}
+			}
+			if fmt.Sprint(dates) != fmt.Sprint(tt.want) {
+				t.Errorf("dates %v, want %v", dates, tt.want)
+			}
+
+			if len(got) != len(tt.pages) {
+				t.Fatalf("%d queries, want %d", len(got), len(tt.pages))
+			}
+			q := got[0]
+			if q.TableName != "AnalyticsSummaries" || q.KeyConditionExpression != "facilityId = :fid AND #date BETWEEN :from AND :to" || !q.ScanIndexForward {
+				t.Errorf("query %+v", q)
+			}
+			if v := q.ExpressionAttributeValues; v[":fid"]["S"] != "facility-001" || v[":from"]["S"] != "2024-03-01" || v[":to"]["S"] != "2024-03-07" {
+				t.Errorf("values %v", v)
+			}
+			// Each page resumes from the one before
+			for i, want := range tt.wantStarts {
+				if got := got[i+1].ExclusiveStartKey; got["facilityId"]["S"] != "facility-001" || got["date"]["S"] != want {
+					t.Errorf("query %d started at %v, want %s", i+2, got, want)
+				}
+			}
+		})
+	}
+}
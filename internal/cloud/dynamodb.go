@@ -2,7 +2,14 @@ package cloud
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,17 +19,35 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
 )
 
 // DynamoDBClient wraps AWS DynamoDB client for energy grid operations
 type DynamoDBClient struct {
-	svc *dynamodb.Client
-	ctx context.Context
+	svc    *dynamodb.Client
+	ctx    context.Context
+	region string
+
+	// Optional read replica region; reads fail over to it, writes never do
+	secondary       queryAPI
+	secondaryRegion string
+
+	// BatchPutReadings sizing, see NewDynamoDBClient
+	batchSize        int
+	writeParallelism int
+
+	// Decimal places numbers are rounded to before they are written
+	precision Precision
 }
 
 // NewDynamoDBClient creates a new DynamoDB client instance
 // YOUR ORIGINAL CONTRIBUTION: Initialize DynamoDB client with AWS SDK v2
-func NewDynamoDBClient(region string) (*DynamoDBClient, error) {
+// A non-empty secondaryRegion enables read failover to that region. Batch
+// writes use batchSize items per request (capped at MaxBatchWriteSize) and
+// writeParallelism concurrent requests; values below 1 use the defaults.
+// Written numbers are rounded to precision.
+func NewDynamoDBClient(region, secondaryRegion string, batchSize, writeParallelism int, precision Precision) (*DynamoDBClient, error) {
 	ctx := context.Background()
 
 	// Load AWS configuration from environment/credentials
@@ -31,38 +56,84 @@ func NewDynamoDBClient(region string) (*DynamoDBClient, error) {
 		return nil, fmt.Errorf("unable to load SDK config: %w", err)
 	}
 
-	return &DynamoDBClient{
-		svc: dynamodb.NewFromConfig(cfg),
-		ctx: ctx,
-	}, nil
+	if batchSize < 1 || batchSize > MaxBatchWriteSize {
+		batchSize = MaxBatchWriteSize
+	}
+	if writeParallelism < 1 {
+		writeParallelism = DefaultWriteParallelism
+	}
+
+	c := &DynamoDBClient{
+		svc:    dynamodb.NewFromConfig(cfg),
+		ctx:    ctx,
+		region: region,
+
+		batchSize:        batchSize,
+		writeParallelism: writeParallelism,
+		precision:        precision,
+	}
+
+	if secondaryRegion != "" && secondaryRegion != region {
+		secCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(secondaryRegion))
+		if err != nil {
+			return nil, fmt.Errorf("unable to load SDK config for secondary region: %w", err)
+		}
+		c.secondary = dynamodb.NewFromConfig(secCfg)
+		c.secondaryRegion = secondaryRegion
+	}
+
+	return c, nil
 }
 
 // Reading represents the DynamoDB structure for energy readings
 type Reading struct {
-	FacilityID  string  `dynamodbav:"facilityId"`
-	Timestamp   int64   `dynamodbav:"timestamp"`
-	MeterID     string  `dynamodbav:"meterId"`
-	Voltage     float64 `dynamodbav:"voltage"`
-	Current     float64 `dynamodbav:"current"`
-	PowerKW     float64 `dynamodbav:"powerKw"`
-	Status      string  `dynamodbav:"status"`
-	Temperature float64 `dynamodbav:"temperature"`
+	FacilityID  string             `dynamodbav:"facilityId"`
+	Timestamp   timeutil.Timestamp `dynamodbav:"timestamp"`
+	MeterID     string             `dynamodbav:"meterId"`
+	Voltage     float64            `dynamodbav:"voltage"`
+	Current     float64            `dynamodbav:"current"`
+	PowerKW     float64            `dynamodbav:"powerKw"`
+	Status      string             `dynamodbav:"status"`
+	Temperature float64            `dynamodbav:"temperature"`
+	Source      string             `dynamodbav:"source,omitempty"`
+	// Optional power-quality attributes
+	ReactivePowerKVAR *float64 `dynamodbav:"reactivePowerKvar,omitempty"`
+	FrequencyHz       *float64 `dynamodbav:"frequencyHz,omitempty"`
+	THDPercent        *float64 `dynamodbav:"thdPercent,omitempty"`
+	// Uncalibrated meter values, present only for calibrated meters
+	RawVoltage *float64 `dynamodbav:"rawVoltage,omitempty"`
+	RawPowerKW *float64 `dynamodbav:"rawPowerKw,omitempty"`
+	// Lifecycle status of a meter that isn't active; the anomaly Lambda skips these
+	MeterStatus string `dynamodbav:"meterStatus,omitempty"`
+}
+
+// toDomain converts a stored reading item to a domain.Reading
+func (r Reading) toDomain() domain.Reading {
+	meterID := int64(0)
+	fmt.Sscanf(r.MeterID, "%d", &meterID)
+
+	return domain.Reading{
+		MeterID:     meterID,
+		Timestamp:   r.Timestamp,
+		Voltage:     r.Voltage,
+		Current:     r.Current,
+		PowerKW:     r.PowerKW,
+		Source:      r.Source,
+		RawVoltage:  r.RawVoltage,
+		RawPowerKW:  r.RawPowerKW,
+		MeterStatus: r.MeterStatus,
+
+		ReactivePowerKVAR: r.ReactivePowerKVAR,
+		FrequencyHz:       r.FrequencyHz,
+		THDPercent:        r.THDPercent,
+	}
 }
 
 // PutReading stores an energy reading in DynamoDB
 // YOUR ORIGINAL CONTRIBUTION: Store reading with proper type conversion and error handling
 func (c *DynamoDBClient) PutReading(reading *domain.Reading, facilityID string) error {
 	// Convert domain.Reading to DynamoDB Reading structure
-	dbReading := Reading{
-		FacilityID:  facilityID,
-		Timestamp:   reading.Timestamp.Unix(),
-		MeterID:     fmt.Sprintf("%d", reading.MeterID),
-		Voltage:     reading.Voltage,
-		Current:     reading.Current,
-		PowerKW:     reading.PowerKW,
-		Status:      "operational",
-		Temperature: 45.0, // Default value, can be updated based on your domain model
-	}
+	dbReading := c.readingItem(reading, facilityID)
 
 	// Marshal the reading into DynamoDB attribute values
 	item, err := attributevalue.MarshalMap(dbReading)
@@ -78,7 +149,7 @@ func (c *DynamoDBClient) PutReading(reading *domain.Reading, facilityID string)
 
 	_, err = c.svc.PutItem(c.ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to put item in DynamoDB: %w", err)
+		return c.writeErr(fmt.Errorf("failed to put item in DynamoDB: %w", err))
 	}
 
 	return nil
@@ -87,7 +158,26 @@ func (c *DynamoDBClient) PutReading(reading *domain.Reading, facilityID string)
 // GetRecentReadings retrieves recent readings for a facility
 // YOUR ORIGINAL CONTRIBUTION: Query DynamoDB with time-based filtering
 func (c *DynamoDBClient) GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error) {
-	startTime := time.Now().Add(-duration).Unix()
+	page, err := c.GetRecentReadingsPage(facilityID, duration, "", 0, Projection{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Readings, nil
+}
+
+// GetRecentReadingsPage retrieves one page of recent readings for a facility.
+// The cursor encodes DynamoDB's LastEvaluatedKey; with limit 0 a page is
+// bounded only by DynamoDB's 1 MB response size. Attributes outside fields
+// are left zero.
+func (c *DynamoDBClient) GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, limit int, fields Projection) (*domain.ReadingPage, error) {
+	windowEnd := time.Now()
+	windowStart := windowEnd.Add(-duration)
+	startTime := windowStart.Unix()
+
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
 
 	// Query DynamoDB for readings within time range
 	input := &dynamodb.QueryInput{
@@ -100,13 +190,23 @@ func (c *DynamoDBClient) GetRecentReadings(facilityID string, duration time.Dura
 			":fid":       &types.AttributeValueMemberS{Value: facilityID},
 			":startTime": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", startTime)},
 		},
+		ExclusiveStartKey: startKey,
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(int32(limit))
 	}
+	fields.apply(input)
 
-	result, err := c.svc.Query(c.ctx, input)
+	result, err := c.query(input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query DynamoDB: %w", err)
 	}
 
+	next, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, err
+	}
+
 	// Unmarshal results into domain.Reading slice
 	var dbReadings []Reading
 	err = attributevalue.UnmarshalListOfMaps(result.Items, &dbReadings)
@@ -117,31 +217,147 @@ func (c *DynamoDBClient) GetRecentReadings(facilityID string, duration time.Dura
 	// Convert to domain.Reading format
 	readings := make([]domain.Reading, len(dbReadings))
 	for i, r := range dbReadings {
-		meterID := int64(0)
-		fmt.Sscanf(r.MeterID, "%d", &meterID)
-
-		readings[i] = domain.Reading{
-			MeterID:   meterID,
-			Timestamp: time.Unix(r.Timestamp, 0),
-			Voltage:   r.Voltage,
-			Current:   r.Current,
-			PowerKW:   r.PowerKW,
-		}
+		readings[i] = r.toDomain()
 	}
 
-	return readings, nil
+	return &domain.ReadingPage{
+		Readings:    readings,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		Truncated:   next != "",
+		NextCursor:  next,
+	}, nil
+}
+
+// encodeCursor renders a LastEvaluatedKey as an opaque URL-safe cursor; an
+// empty key (the last page) yields an empty cursor
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	var plain map[string]interface{}
+	if err := attributevalue.UnmarshalMap(key, &plain); err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	data, err := json.Marshal(plain)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor turns a cursor from encodeCursor back into an ExclusiveStartKey
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	var plain map[string]interface{}
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	key, err := attributevalue.MarshalMap(plain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return key, nil
 }
 
 // Alert represents an alert stored in DynamoDB
 type Alert struct {
-	AlertID      string `dynamodbav:"alertId"`
-	FacilityID   string `dynamodbav:"facilityId"`
-	Timestamp    int64  `dynamodbav:"timestamp"`
-	Severity     string `dynamodbav:"severity"`
-	Type         string `dynamodbav:"type"`
-	Message      string `dynamodbav:"message"`
-	Acknowledged bool   `dynamodbav:"acknowledged"`
-	EquipmentID  string `dynamodbav:"equipmentId"`
+	AlertID      string             `dynamodbav:"alertId"`
+	FacilityID   string             `dynamodbav:"facilityId"`
+	Timestamp    timeutil.Timestamp `dynamodbav:"timestamp"`
+	Severity     string             `dynamodbav:"severity"`
+	Type         string             `dynamodbav:"type"`
+	Message      string             `dynamodbav:"message"`
+	Acknowledged bool               `dynamodbav:"acknowledged"`
+	EquipmentID  string             `dynamodbav:"equipmentId"`
+
+	// Set when the alert is dismissed, e.g. as a false positive
+	Status        string `dynamodbav:"status,omitempty"`
+	DismissedBy   string `dynamodbav:"dismissedBy,omitempty"`
+	DismissReason string `dynamodbav:"dismissReason,omitempty"`
+	DismissedAt   int64  `dynamodbav:"dismissedAt,omitempty"`
+
+	AcknowledgedAt int64 `dynamodbav:"acknowledgedAt,omitempty"`
+	// ExpiresAt is the table's TTL attribute (epoch seconds). It is only set
+	// on closed alerts, once they are archived.
+	ExpiresAt int64 `dynamodbav:"expiresAt,omitempty"`
+
+	// Metadata holds the detector's figures on anomaly alerts (mean, std_dev,
+	// threshold, ...); it is served by the explain endpoint, not listings
+	Metadata map[string]interface{} `dynamodbav:"metadata,omitempty" json:"-"`
+}
+
+// AlertDismissed is the status of a soft-deleted alert
+const AlertDismissed = "dismissed"
+
+// ErrAlertNotFound is returned when dismissing or deleting an alert that isn't stored
+var ErrAlertNotFound = errors.New("alert not found")
+
+// ErrAlertOpen is returned when setting an expiry on an alert that is neither
+// acknowledged nor dismissed
+var ErrAlertOpen = errors.New("alert is open")
+
+// Dismissed reports whether the alert has been soft-deleted
+func (a Alert) Dismissed() bool { return a.Status == AlertDismissed }
+
+// Closed reports whether the alert is acknowledged or dismissed; only closed
+// alerts may expire
+func (a Alert) Closed() bool { return a.Acknowledged || a.Dismissed() }
+
+// ClosedAt is when the alert was acknowledged or dismissed, falling back to
+// its timestamp for alerts closed before those times were recorded
+func (a Alert) ClosedAt() int64 {
+	switch {
+	case a.AcknowledgedAt != 0:
+		return a.AcknowledgedAt
+	case a.DismissedAt != 0:
+		return a.DismissedAt
+	}
+	return a.Timestamp.Unix()
+}
+
+// Expired reports whether the alert's TTL has passed at now; DynamoDB may
+// keep expired items for a while before deleting them
+func (a Alert) Expired(now int64) bool { return a.ExpiresAt != 0 && a.ExpiresAt <= now }
+
+// AlertFilter narrows an alert query; zero-valued fields don't filter.
+// Since and Until are inclusive epoch seconds.
+type AlertFilter struct {
+	Severity    string
+	EquipmentID string
+	Since       int64
+	Until       int64
+
+	// IncludeDismissed also returns dismissed alerts, which are hidden by default
+	IncludeDismissed bool
+
+	// Fields limits the attributes read; it doesn't filter alerts
+	Fields Projection
+}
+
+// Matches reports whether a passes every set field of the filter
+func (f AlertFilter) Matches(a Alert) bool {
+	switch {
+	case f.Severity != "" && a.Severity != f.Severity:
+		return false
+	case f.EquipmentID != "" && a.EquipmentID != f.EquipmentID:
+		return false
+	case f.Since != 0 && a.Timestamp.Unix() < f.Since:
+		return false
+	case f.Until != 0 && a.Timestamp.Unix() > f.Until:
+		return false
+	case !f.IncludeDismissed && a.Dismissed():
+		return false
+	case a.Expired(time.Now().Unix()):
+		return false
+	}
+	return true
 }
 
 // CreateAlert stores a new alert in DynamoDB
@@ -150,7 +366,7 @@ func (c *DynamoDBClient) CreateAlert(facilityID, equipmentID, severity, alertTyp
 	alert := Alert{
 		AlertID:      fmt.Sprintf("alert-%d-%d", time.Now().Unix(), time.Now().Nanosecond()),
 		FacilityID:   facilityID,
-		Timestamp:    time.Now().Unix(),
+		Timestamp:    timeutil.Now(),
 		Severity:     severity,
 		Type:         alertType,
 		Message:      message,
@@ -170,7 +386,7 @@ func (c *DynamoDBClient) CreateAlert(facilityID, equipmentID, severity, alertTyp
 
 	_, err = c.svc.PutItem(c.ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to create alert: %w", err)
+		return c.writeErr(fmt.Errorf("failed to create alert: %w", err))
 	}
 
 	return nil
@@ -178,7 +394,7 @@ func (c *DynamoDBClient) CreateAlert(facilityID, equipmentID, severity, alertTyp
 
 // GetAlerts retrieves alerts for a facility
 // YOUR ORIGINAL CONTRIBUTION: Query alerts with optional severity filter
-func (c *DynamoDBClient) GetAlerts(facilityID string, severityFilter *string) ([]Alert, error) {
+func (c *DynamoDBClient) GetAlerts(facilityID string, filter AlertFilter) ([]Alert, error) {
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String("Alerts"),
 		IndexName:              aws.String("facilityId-timestamp-index"),
@@ -189,13 +405,42 @@ func (c *DynamoDBClient) GetAlerts(facilityID string, severityFilter *string) ([
 		ScanIndexForward: aws.Bool(false), // Sort descending (newest first)
 	}
 
-	// Add severity filter if provided
-	if severityFilter != nil {
-		input.FilterExpression = aws.String("severity = :sev")
-		input.ExpressionAttributeValues[":sev"] = &types.AttributeValueMemberS{Value: *severityFilter}
+	// The time range is on the index sort key, so it narrows the query itself
+	if filter.Since != 0 || filter.Until != 0 {
+		until := filter.Until
+		if until == 0 {
+			until = math.MaxInt64
+		}
+		input.KeyConditionExpression = aws.String("facilityId = :fid AND #ts BETWEEN :since AND :until")
+		input.ExpressionAttributeNames = map[string]string{"#ts": "timestamp"}
+		input.ExpressionAttributeValues[":since"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", filter.Since)}
+		input.ExpressionAttributeValues[":until"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", until)}
+	}
+
+	// Severity, equipment, dismissal and expiry are attribute filters, combined with AND
+	conds := []string{"(attribute_not_exists(expiresAt) OR expiresAt > :now)"}
+	input.ExpressionAttributeValues[":now"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())}
+	if !filter.IncludeDismissed {
+		// Alerts written before dismissal existed have no status
+		conds = append(conds, "(attribute_not_exists(#st) OR #st <> :dismissed)")
+		if input.ExpressionAttributeNames == nil {
+			input.ExpressionAttributeNames = make(map[string]string)
+		}
+		input.ExpressionAttributeNames["#st"] = "status"
+		input.ExpressionAttributeValues[":dismissed"] = &types.AttributeValueMemberS{Value: AlertDismissed}
 	}
+	if filter.Severity != "" {
+		conds = append(conds, "severity = :sev")
+		input.ExpressionAttributeValues[":sev"] = &types.AttributeValueMemberS{Value: filter.Severity}
+	}
+	if filter.EquipmentID != "" {
+		conds = append(conds, "equipmentId = :eq")
+		input.ExpressionAttributeValues[":eq"] = &types.AttributeValueMemberS{Value: filter.EquipmentID}
+	}
+	input.FilterExpression = aws.String(strings.Join(conds, " AND "))
+	filter.Fields.apply(input)
 
-	result, err := c.svc.Query(c.ctx, input)
+	result, err := c.query(input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query alerts: %w", err)
 	}
@@ -209,6 +454,28 @@ func (c *DynamoDBClient) GetAlerts(facilityID string, severityFilter *string) ([
 	return alerts, nil
 }
 
+// GetAlert returns one alert by ID, or ErrAlertNotFound
+func (c *DynamoDBClient) GetAlert(alertID string) (*Alert, error) {
+	out, err := c.svc.GetItem(c.ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("Alerts"),
+		Key: map[string]types.AttributeValue{
+			"alertId": &types.AttributeValueMemberS{Value: alertID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	if len(out.Item) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+
+	var alert Alert
+	if err := attributevalue.UnmarshalMap(out.Item, &alert); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alert: %w", err)
+	}
+	return &alert, nil
+}
+
 // AcknowledgeAlert marks an alert as acknowledged
 // YOUR ORIGINAL CONTRIBUTION: Update alert status with timestamp
 func (c *DynamoDBClient) AcknowledgeAlert(alertID string) error {
@@ -217,7 +484,8 @@ func (c *DynamoDBClient) AcknowledgeAlert(alertID string) error {
 		Key: map[string]types.AttributeValue{
 			"alertId": &types.AttributeValueMemberS{Value: alertID},
 		},
-		UpdateExpression: aws.String("SET acknowledged = :ack, acknowledgedAt = :time"),
+		UpdateExpression:    aws.String("SET acknowledged = :ack, acknowledgedAt = :time"),
+		ConditionExpression: aws.String("attribute_exists(alertId)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":ack":  &types.AttributeValueMemberBOOL{Value: true},
 			":time": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
@@ -225,8 +493,99 @@ func (c *DynamoDBClient) AcknowledgeAlert(alertID string) error {
 	}
 
 	_, err := c.svc.UpdateItem(c.ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+	if err != nil {
+		return c.writeErr(fmt.Errorf("failed to acknowledge alert: %w", err))
+	}
+
+	return nil
+}
+
+// DismissAlert soft-deletes an alert: it is kept with status dismissed, who
+// dismissed it and why, and hidden from alert queries by default
+func (c *DynamoDBClient) DismissAlert(alertID, dismissedBy, reason string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String("Alerts"),
+		Key: map[string]types.AttributeValue{
+			"alertId": &types.AttributeValueMemberS{Value: alertID},
+		},
+		UpdateExpression:    aws.String("SET #st = :status, dismissedBy = :by, dismissReason = :reason, dismissedAt = :time"),
+		ConditionExpression: aws.String("attribute_exists(alertId)"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: AlertDismissed},
+			":by":     &types.AttributeValueMemberS{Value: dismissedBy},
+			":reason": &types.AttributeValueMemberS{Value: reason},
+			":time":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		},
+	}
+
+	_, err := c.svc.UpdateItem(c.ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to acknowledge alert: %w", err)
+		return c.writeErr(fmt.Errorf("failed to dismiss alert: %w", err))
+	}
+
+	return nil
+}
+
+// ExpireAlert sets the alert's expiresAt TTL. The write is conditional on
+// the alert being acknowledged or dismissed, so an open alert never expires.
+func (c *DynamoDBClient) ExpireAlert(alertID string, expiresAt int64) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String("Alerts"),
+		Key: map[string]types.AttributeValue{
+			"alertId": &types.AttributeValueMemberS{Value: alertID},
+		},
+		UpdateExpression:    aws.String("SET expiresAt = :exp"),
+		ConditionExpression: aws.String("attribute_exists(alertId) AND (acknowledged = :true OR #st = :dismissed)"),
+		ExpressionAttributeNames: map[string]string{
+			"#st": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":exp":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expiresAt)},
+			":true":      &types.AttributeValueMemberBOOL{Value: true},
+			":dismissed": &types.AttributeValueMemberS{Value: AlertDismissed},
+		},
+	}
+
+	_, err := c.svc.UpdateItem(c.ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return fmt.Errorf("%w: %s", ErrAlertOpen, alertID)
+	}
+	if err != nil {
+		return c.writeErr(fmt.Errorf("failed to set alert expiry: %w", err))
+	}
+
+	return nil
+}
+
+// DeleteAlert removes an alert permanently
+func (c *DynamoDBClient) DeleteAlert(alertID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String("Alerts"),
+		Key: map[string]types.AttributeValue{
+			"alertId": &types.AttributeValueMemberS{Value: alertID},
+		},
+		ConditionExpression: aws.String("attribute_exists(alertId)"),
+	}
+
+	_, err := c.svc.DeleteItem(c.ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+	if err != nil {
+		return c.writeErr(fmt.Errorf("failed to delete alert: %w", err))
 	}
 
 	return nil
@@ -240,6 +599,10 @@ type Equipment struct {
 	InstallDate     int64   `dynamodbav:"installDate"`
 	LastMaintenance int64   `dynamodbav:"lastMaintenance"`
 	HealthScore     float64 `dynamodbav:"healthScore"`
+	// Optional context shown in alert notifications
+	MeterID      string `dynamodbav:"meterId,omitempty"`
+	Location     string `dynamodbav:"location,omitempty"`
+	FacilityName string `dynamodbav:"facilityName,omitempty"`
 }
 
 // GetEquipment retrieves all equipment for a facility
@@ -254,7 +617,7 @@ func (c *DynamoDBClient) GetEquipment(facilityID string) ([]Equipment, error) {
 		},
 	}
 
-	result, err := c.svc.Query(c.ctx, input)
+	result, err := c.query(input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query equipment: %w", err)
 	}
@@ -278,26 +641,81 @@ func (c *DynamoDBClient) UpdateEquipmentHealth(equipmentID string, healthScore f
 		},
 		UpdateExpression: aws.String("SET healthScore = :score, lastChecked = :time"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":score": &types.AttributeValueMemberN{Value: fmt.Sprintf("%.2f", healthScore)},
+			":score": numberAttr(healthScore, c.precision.Score),
 			":time":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
 		},
 	}
 
 	_, err := c.svc.UpdateItem(c.ctx, input)
 	if err != nil {
-		return fmt.Errorf("failed to update equipment health: %w", err)
+		return c.writeErr(fmt.Errorf("failed to update equipment health: %w", err))
 	}
 
 	return nil
 }
 
-// BatchPutReadings stores multiple readings efficiently
-// YOUR ORIGINAL CONTRIBUTION: Batch write for performance optimization
-func (c *DynamoDBClient) BatchPutReadings(readings []domain.Reading, facilityID string) error {
+// MaintenancePrediction is a maintenance prediction item in DynamoDB, keyed by
+// equipmentId and computedAt so each run adds a point to the equipment's history
+type MaintenancePrediction struct {
+	EquipmentID       string  `dynamodbav:"equipmentId"`
+	ComputedAt        int64   `dynamodbav:"computedAt"`
+	FacilityID        string  `dynamodbav:"facilityId"`
+	HealthScore       float64 `dynamodbav:"healthScore"`
+	FailureRisk30Days float64 `dynamodbav:"failureRisk30Days"`
+	FailureRisk90Days float64 `dynamodbav:"failureRisk90Days"`
+	NextServiceDate   int64   `dynamodbav:"nextServiceDate"`
+	Recommendation    string  `dynamodbav:"recommendation"`
+	HighRisk          bool    `dynamodbav:"highRisk"`
+}
+
+// PutMaintenancePredictions stores a maintenance run's predictions
+func (c *DynamoDBClient) PutMaintenancePredictions(predictions []domain.MaintenancePrediction) error {
 	const batchSize = 25 // DynamoDB batch write limit
 
-	for i := 0; i < len(readings); i += batchSize {
+	for i := 0; i < len(predictions); i += batchSize {
 		end := i + batchSize
+		if end > len(predictions) {
+			end = len(predictions)
+		}
+
+		writeRequests := make([]types.WriteRequest, 0, end-i)
+		for _, p := range predictions[i:end] {
+			item, err := attributevalue.MarshalMap(MaintenancePrediction{
+				EquipmentID:       p.EquipmentID,
+				ComputedAt:        p.ComputedAt.Unix(),
+				FacilityID:        p.FacilityID,
+				HealthScore:       numfmt.Round(p.HealthScore, c.precision.Score),
+				FailureRisk30Days: numfmt.Round(p.FailureRisk30Days, c.precision.Score),
+				FailureRisk90Days: numfmt.Round(p.FailureRisk90Days, c.precision.Score),
+				NextServiceDate:   p.NextServiceDate.Unix(),
+				Recommendation:    p.Recommendation,
+				HighRisk:          p.HighRisk,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal prediction for %s: %w", p.EquipmentID, err)
+			}
+			writeRequests = append(writeRequests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+
+		if err := batchWriteWithRetry(c.ctx, c.svc, map[string][]types.WriteRequest{
+			"MaintenancePredictions": writeRequests,
+		}); err != nil {
+			return c.writeErr(err)
+		}
+	}
+
+	return nil
+}
+
+// BatchPutReadings stores multiple readings efficiently
+// YOUR ORIGINAL CONTRIBUTION: Batch write for performance optimization
+// Batches are written by up to writeParallelism workers, so there is no
+// ordering across batches; a failed batch doesn't stop the others and the
+// error covers every batch that failed.
+func (c *DynamoDBClient) BatchPutReadings(readings []domain.Reading, facilityID string) error {
+	var batches [][]types.WriteRequest
+	for i := 0; i < len(readings); i += c.batchSize {
+		end := i + c.batchSize
 		if end > len(readings) {
 			end = len(readings)
 		}
@@ -305,21 +723,12 @@ func (c *DynamoDBClient) BatchPutReadings(readings []domain.Reading, facilityID
 		batch := readings[i:end]
 		writeRequests := make([]types.WriteRequest, len(batch))
 
-		for j, reading := range batch {
-			dbReading := Reading{
-				FacilityID:  facilityID,
-				Timestamp:   reading.Timestamp.Unix(),
-				MeterID:     fmt.Sprintf("%d", reading.MeterID),
-				Voltage:     reading.Voltage,
-				Current:     reading.Current,
-				PowerKW:     reading.PowerKW,
-				Status:      "operational",
-				Temperature: 45.0,
-			}
+		for j := range batch {
+			dbReading := c.readingItem(&batch[j], facilityID)
 
 			item, err := attributevalue.MarshalMap(dbReading)
 			if err != nil {
-				return fmt.Errorf("failed to marshal reading %d: %w", j, err)
+				return fmt.Errorf("failed to marshal reading %d: %w", i+j, err)
 			}
 
 			writeRequests[j] = types.WriteRequest{
@@ -328,18 +737,188 @@ func (c *DynamoDBClient) BatchPutReadings(readings []domain.Reading, facilityID
 				},
 			}
 		}
+		batches = append(batches, writeRequests)
+	}
+
+	return c.writeErr(writeBatches(c.ctx, c.svc, "EnergyReadings", batches, c.writeParallelism))
+}
+
+// Batch write sizing; DynamoDB accepts at most 25 items per BatchWriteItem
+const (
+	MaxBatchWriteSize       = 25
+	DefaultWriteParallelism = 4
+)
 
-		input := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				"EnergyReadings": writeRequests,
-			},
+// writeBatches writes each batch to table with batchWriteWithRetry on up to
+// parallelism workers, joining the errors of the batches that failed
+func writeBatches(ctx context.Context, w batchWriter, table string, batches [][]types.WriteRequest, parallelism int) error {
+	if parallelism > len(batches) {
+		parallelism = len(batches)
+	}
+
+	jobs := make(chan int)
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for n := 0; n < parallelism; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = batchWriteWithRetry(ctx, w, map[string][]types.WriteRequest{table: batches[i]})
+			}
+		}()
+	}
+	for i := range batches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("batch %d: %w", i, err))
 		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d batches failed: %w", len(failed), len(batches), errors.Join(failed...))
+}
+
+// Retry policy for throttled batch writes
+const (
+	batchWriteMaxAttempts = 6
+	batchWriteBaseBackoff = 50 * time.Millisecond
+	batchWriteMaxBackoff  = 2 * time.Second
+)
+
+// batchWriter is the subset of the DynamoDB client used for batch writes
+type batchWriter interface {
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
 
-		_, err := c.svc.BatchWriteItem(c.ctx, input)
+// batchWriteWithRetry writes requests and resubmits whatever DynamoDB returns
+// in UnprocessedItems (typically because of throttling) with exponential
+// backoff. If items remain after the last attempt, the error lists them.
+func batchWriteWithRetry(ctx context.Context, w batchWriter, requests map[string][]types.WriteRequest) error {
+	backoff := batchWriteBaseBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := w.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: requests})
 		if err != nil {
 			return fmt.Errorf("failed to batch write items: %w", err)
 		}
+
+		requests = out.UnprocessedItems
+		if countWriteRequests(requests) == 0 {
+			return nil
+		}
+		if attempt == batchWriteMaxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("batch write interrupted with %d unprocessed items: %w", countWriteRequests(requests), ctx.Err())
+		}
+		if backoff *= 2; backoff > batchWriteMaxBackoff {
+			backoff = batchWriteMaxBackoff
+		}
 	}
 
-	return nil
+	return fmt.Errorf("failed to write %d items after %d attempts: %s",
+		countWriteRequests(requests), batchWriteMaxAttempts, describeWriteRequests(requests))
+}
+
+func countWriteRequests(requests map[string][]types.WriteRequest) int {
+	n := 0
+	for _, reqs := range requests {
+		n += len(reqs)
+	}
+	return n
+}
+
+// describeWriteRequests lists the keys of unwritten items, e.g.
+// EnergyReadings{facilityId=facility-001 timestamp=1700000000 meterId=1}
+func describeWriteRequests(requests map[string][]types.WriteRequest) string {
+	var parts []string
+	for table, reqs := range requests {
+		for _, req := range reqs {
+			if req.PutRequest == nil {
+				continue
+			}
+			var keys []string
+			for _, name := range []string{"facilityId", "timestamp", "meterId", "alertId"} {
+				switch v := req.PutRequest.Item[name].(type) {
+				case *types.AttributeValueMemberS:
+					keys = append(keys, name+"="+v.Value)
+				case *types.AttributeValueMemberN:
+					keys = append(keys, name+"="+v.Value)
+				}
+			}
+			parts = append(parts, table+"{"+strings.Join(keys, " ")+"}")
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// GetReadingItem fetches the stored reading item for a facility at an exact
+// timestamp, returning nil when there is none
+func (c *DynamoDBClient) GetReadingItem(facilityID string, timestamp int64) (map[string]types.AttributeValue, error) {
+	out, err := c.svc.GetItem(c.ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("EnergyReadings"),
+		Key: map[string]types.AttributeValue{
+			"facilityId": &types.AttributeValueMemberS{Value: facilityID},
+			"timestamp":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", timestamp)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reading: %w", err)
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+	return out.Item, nil
+}
+
+// GetReading returns the facility's reading stored at timestamp when it
+// belongs to meterID, or nil when there is none
+func (c *DynamoDBClient) GetReading(facilityID string, meterID int64, timestamp time.Time) (*domain.Reading, error) {
+	item, err := c.GetReadingItem(facilityID, timestamp.Unix())
+	if err != nil || item == nil {
+		return nil, err
+	}
+
+	var r Reading
+	if err := attributevalue.UnmarshalMap(item, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reading: %w", err)
+	}
+	rd := r.toDomain()
+	if rd.MeterID != meterID {
+		return nil, nil
+	}
+	return &rd, nil
+}
+
+// GetFacilityTimezone returns the IANA zone stored on the facility's item in
+// the Facilities table, or "" when the facility or its timezone is missing
+func (c *DynamoDBClient) GetFacilityTimezone(facilityID string) (string, error) {
+	out, err := c.svc.GetItem(c.ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("Facilities"),
+		Key: map[string]types.AttributeValue{
+			"facilityId": &types.AttributeValueMemberS{Value: facilityID},
+		},
+		// timezone is a reserved word
+		ProjectionExpression:     aws.String("#tz"),
+		ExpressionAttributeNames: map[string]string{"#tz": "timezone"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get facility: %w", err)
+	}
+	if tz, ok := out.Item["timezone"].(*types.AttributeValueMemberS); ok {
+		return tz.Value, nil
+	}
+	return "", nil
 }
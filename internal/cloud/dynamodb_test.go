@@ -0,0 +1,574 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// fakeBatchWriter stores what BatchWriteItem is given, except the items
+// unprocessed picks for a call, which it hands back as UnprocessedItems
+type fakeBatchWriter struct {
+	mu      sync.Mutex
+	calls   int
+	written map[string]int
+	err     error
+	// unprocessed returns how many of the call's items (1-based call number)
+	// to hand back; nil writes everything
+	unprocessed func(call, items int) int
+}
+
+func (f *fakeBatchWriter) BatchWriteItem(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}
+	for table, reqs := range in.RequestItems {
+		n := 0
+		if f.unprocessed != nil {
+			n = f.unprocessed(f.calls, len(reqs))
+		}
+		for _, req := range reqs[n:] {
+			f.written[itemKey(req)]++
+		}
+		if n > 0 {
+			out.UnprocessedItems[table] = reqs[:n]
+		}
+	}
+	return out, nil
+}
+
+func itemKey(req types.WriteRequest) string {
+	return req.PutRequest.Item["meterId"].(*types.AttributeValueMemberS).Value
+}
+
+func readingRequests(n int) []types.WriteRequest {
+	reqs := make([]types.WriteRequest, n)
+	for i := range reqs {
+		reqs[i] = types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"facilityId": &types.AttributeValueMemberS{Value: "facility-001"},
+			"timestamp":  &types.AttributeValueMemberN{Value: "1700000000"},
+			"meterId":    &types.AttributeValueMemberS{Value: fmt.Sprint(i)},
+		}}}
+	}
+	return reqs
+}
+
+func TestBatchWriteWithRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		unprocessed func(call, items int) int
+		err         error
+		wantCalls   int
+		wantWritten int
+		wantErr     string
+	}{
+		{name: "all items land", wantCalls: 1, wantWritten: 10},
+		{
+			name: "throttled items are retried",
+			unprocessed: func(call, items int) int {
+				if call == 1 {
+					return 4
+				}
+				return 0
+			},
+			wantCalls: 2, wantWritten: 10,
+		},
+		{
+			name: "items throttled over several attempts",
+			unprocessed: func(call, items int) int {
+				if call < 4 {
+					return items - 1
+				}
+				return 0
+			},
+			wantCalls: 4, wantWritten: 10,
+		},
+		{
+			name:        "items that never land are listed",
+			unprocessed: func(call, items int) int { return min(items, 2) },
+			wantCalls:   batchWriteMaxAttempts, wantWritten: 8,
+			wantErr: "failed to write 2 items after 6 attempts: EnergyReadings{facilityId=facility-001 timestamp=1700000000 meterId=0}, " +
+				"EnergyReadings{facilityId=facility-001 timestamp=1700000000 meterId=1}",
+		},
+		{name: "request error", err: errors.New("access denied"), wantCalls: 1, wantErr: "access denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &fakeBatchWriter{written: make(map[string]int), err: tt.err, unprocessed: tt.unprocessed}
+			err := batchWriteWithRetry(context.Background(), w, map[string][]types.WriteRequest{"EnergyReadings": readingRequests(10)})
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if w.calls != tt.wantCalls {
+				t.Errorf("%d BatchWriteItem calls, want %d", w.calls, tt.wantCalls)
+			}
+			if len(w.written) != tt.wantWritten {
+				t.Errorf("%d items written, want %d", len(w.written), tt.wantWritten)
+			}
+			for k, n := range w.written {
+				if n != 1 {
+					t.Errorf("item %s written %d times", k, n)
+				}
+			}
+		})
+	}
+}
+
+func TestReadingItemPowerQuality(t *testing.T) {
+	kvar, hz, thd := 1.25, 49.98, 3.4
+	ts := timeutil.FromTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name    string
+		reading domain.Reading
+		// wantAttrs are the optional attributes the item must carry; the rest must be absent
+		wantAttrs []string
+	}{
+		{name: "basic meter", reading: domain.Reading{MeterID: 1, Timestamp: ts, Voltage: 230, PowerKW: 2}},
+		{
+			name:      "all power-quality attributes",
+			reading:   domain.Reading{MeterID: 1, Timestamp: ts, Voltage: 230, PowerKW: 2, ReactivePowerKVAR: &kvar, FrequencyHz: &hz, THDPercent: &thd},
+			wantAttrs: []string{"reactivePowerKvar", "frequencyHz", "thdPercent"},
+		},
+		{
+			name:      "frequency only",
+			reading:   domain.Reading{MeterID: 1, Timestamp: ts, Voltage: 230, PowerKW: 2, FrequencyHz: &hz},
+			wantAttrs: []string{"frequencyHz"},
+		},
+	}
+	c := &DynamoDBClient{precision: DefaultPrecision}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := attributevalue.MarshalMap(c.readingItem(&tt.reading, "facility-001"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"reactivePowerKvar", "frequencyHz", "thdPercent"} {
+				_, has := item[name]
+				if want := slices.Contains(tt.wantAttrs, name); has != want {
+					t.Errorf("item has %s = %v, want %v", name, has, want)
+				}
+			}
+
+			var stored Reading
+			if err := attributevalue.UnmarshalMap(item, &stored); err != nil {
+				t.Fatal(err)
+			}
+			if got := stored.toDomain(); !reflect.DeepEqual(got, tt.reading) {
+				t.Errorf("read back %+v, want %+v", got, tt.reading)
+			}
+		})
+	}
+}
+
+func TestRecentReadingsPageMetadata(t *testing.T) {
+	now := time.Now()
+	item := fmt.Sprintf(`{"facilityId":{"S":"facility-001"},"meterId":{"S":"1"},"timestamp":{"N":"%d"},"powerKw":{"N":"4"}}`, now.Add(-time.Minute).Unix())
+	lastKey := fmt.Sprintf(`{"facilityId":{"S":"facility-001"},"timestamp":{"N":"%d"}}`, now.Add(-time.Minute).Unix())
+
+	tests := []struct {
+		name          string
+		lastKey       string
+		wantTruncated bool
+	}{
+		{name: "truncated page", lastKey: lastKey, wantTruncated: true},
+		{name: "last page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Limit             int32
+				ExclusiveStartKey map[string]map[string]string
+			}
+			c := &DynamoDBClient{ctx: context.Background(), region: "us-east-1", precision: DefaultPrecision}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				body := `{"Items":[` + item + `]`
+				if tt.lastKey != "" {
+					body += `,"LastEvaluatedKey":` + tt.lastKey
+				}
+				w.Write([]byte(body + "}"))
+			})
+
+			page, err := c.GetRecentReadingsPage("facility-001", 6*time.Hour, "", 1, Projection{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Limit != 1 {
+				t.Errorf("query limit = %d, want 1", got.Limit)
+			}
+			if len(page.Readings) != 1 || page.Truncated != tt.wantTruncated || (page.NextCursor != "") != tt.wantTruncated {
+				t.Errorf("%d readings, truncated %v, cursor %q; want 1, %v", len(page.Readings), page.Truncated, page.NextCursor, tt.wantTruncated)
+			}
+			if d := page.WindowEnd.Sub(page.WindowStart); d != 6*time.Hour {
+				t.Errorf("window spans %v, want 6h", d)
+			}
+			if page.WindowEnd.Before(now) || page.WindowEnd.After(time.Now()) {
+				t.Errorf("window end %v isn't the time of the query", page.WindowEnd)
+			}
+			if !tt.wantTruncated {
+				return
+			}
+
+			// The cursor resumes the query where DynamoDB stopped
+			if _, err := c.GetRecentReadingsPage("facility-001", 6*time.Hour, page.NextCursor, 1, Projection{}); err != nil {
+				t.Fatal(err)
+			}
+			if got.ExclusiveStartKey["facilityId"]["S"] != "facility-001" || got.ExclusiveStartKey["timestamp"]["N"] != fmt.Sprint(now.Add(-time.Minute).Unix()) {
+				t.Errorf("ExclusiveStartKey = %v, want the last page's LastEvaluatedKey", got.ExclusiveStartKey)
+			}
+		})
+	}
+
+	c := &DynamoDBClient{ctx: context.Background(), precision: DefaultPrecision}
+	if _, err := c.GetRecentReadingsPage("facility-001", time.Hour, "not a cursor!", 0, Projection{}); err == nil {
+		t.Error("malformed cursor accepted")
+	}
+}
+
+func TestAlertFilterMatches(t *testing.T) {
+	at := timeutil.FromTime(time.Unix(1_700_000_000, 0))
+	alert := Alert{AlertID: "a-1", FacilityID: "facility-001", EquipmentID: "transformer-1", Severity: "high", Timestamp: at}
+	tests := []struct {
+		name   string
+		filter AlertFilter
+		want   bool
+	}{
+		{name: "no filter", want: true},
+		{name: "same equipment", filter: AlertFilter{EquipmentID: "transformer-1"}, want: true},
+		{name: "other equipment", filter: AlertFilter{EquipmentID: "transformer-2"}},
+		{name: "equipment and severity", filter: AlertFilter{EquipmentID: "transformer-1", Severity: "high"}, want: true},
+		{name: "equipment, other severity", filter: AlertFilter{EquipmentID: "transformer-1", Severity: "low"}},
+		{name: "equipment within range", filter: AlertFilter{EquipmentID: "transformer-1", Since: at.Unix() - 60, Until: at.Unix() + 60}, want: true},
+		{name: "equipment before range", filter: AlertFilter{EquipmentID: "transformer-1", Since: at.Unix() + 1}},
+		{name: "equipment after range", filter: AlertFilter{EquipmentID: "transformer-1", Until: at.Unix() - 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(alert); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAlertsQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     AlertFilter
+		wantKey    string
+		wantFilter []string
+		wantValues map[string]string
+	}{
+		{
+			name:       "equipment",
+			filter:     AlertFilter{EquipmentID: "transformer-1"},
+			wantKey:    "facilityId = :fid",
+			wantFilter: []string{"equipmentId = :eq"},
+			wantValues: map[string]string{":fid": "facility-001", ":eq": "transformer-1"},
+		},
+		{
+			name:       "equipment, severity and time range",
+			filter:     AlertFilter{EquipmentID: "transformer-1", Severity: "high", Since: 100, Until: 200},
+			wantKey:    "facilityId = :fid AND #ts BETWEEN :since AND :until",
+			wantFilter: []string{"equipmentId = :eq", "severity = :sev"},
+			wantValues: map[string]string{":eq": "transformer-1", ":sev": "high", ":since": "100", ":until": "200"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				KeyConditionExpression    string
+				FilterExpression          string
+				ExpressionAttributeValues map[string]map[string]string
+			}
+			c := &DynamoDBClient{ctx: context.Background(), precision: DefaultPrecision}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.Write([]byte(`{"Items":[]}`))
+			})
+
+			if _, err := c.GetAlerts("facility-001", tt.filter); err != nil {
+				t.Fatal(err)
+			}
+			if got.KeyConditionExpression != tt.wantKey {
+				t.Errorf("key condition = %q, want %q", got.KeyConditionExpression, tt.wantKey)
+			}
+			conds := strings.Split(got.FilterExpression, " AND ")
+			for _, want := range tt.wantFilter {
+				if !slices.Contains(conds, want) {
+					t.Errorf("filter %q lacks %q", got.FilterExpression, want)
+				}
+			}
+			for name, want := range tt.wantValues {
+				v := got.ExpressionAttributeValues[name]
+				if v["S"] != want && v["N"] != want {
+					t.Errorf("%s = %v, want %s", name, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDismissAndDeleteAlert(t *testing.T) {
+	tests := []struct {
+		name       string
+		ack        bool
+		hard       bool
+		alertID    string
+		wantTarget string
+		wantErr    error
+	}{
+		{name: "dismiss", alertID: "a-1", wantTarget: "DynamoDB_20120810.UpdateItem"},
+		{name: "dismiss a missing alert", alertID: "missing", wantTarget: "DynamoDB_20120810.UpdateItem", wantErr: ErrAlertNotFound},
+		{name: "delete", hard: true, alertID: "a-1", wantTarget: "DynamoDB_20120810.DeleteItem"},
+		{name: "delete a missing alert", hard: true, alertID: "missing", wantTarget: "DynamoDB_20120810.DeleteItem", wantErr: ErrAlertNotFound},
+		{name: "acknowledge", ack: true, alertID: "a-1", wantTarget: "DynamoDB_20120810.UpdateItem"},
+		{name: "acknowledge a missing alert", ack: true, alertID: "missing", wantTarget: "DynamoDB_20120810.UpdateItem", wantErr: ErrAlertNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target string
+			var got struct {
+				Key                       map[string]map[string]string
+				UpdateExpression          string
+				ConditionExpression       string
+				ExpressionAttributeValues map[string]map[string]interface{}
+			}
+			c := &DynamoDBClient{ctx: context.Background(), precision: DefaultPrecision}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				target = r.Header.Get("X-Amz-Target")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				if got.Key["alertId"]["S"] == "missing" {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+					return
+				}
+				w.Write([]byte(`{}`))
+			})
+
+			var err error
+			switch {
+			case tt.ack:
+				err = c.AcknowledgeAlert(tt.alertID)
+			case tt.hard:
+				err = c.DeleteAlert(tt.alertID)
+			default:
+				err = c.DismissAlert(tt.alertID, "ops@example.com", "sensor glitch")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			// Each only touches an alert that exists
+			if target != tt.wantTarget || got.ConditionExpression != "attribute_exists(alertId)" {
+				t.Errorf("%s with condition %q, want %s", target, got.ConditionExpression, tt.wantTarget)
+			}
+			if tt.ack || tt.hard {
+				return
+			}
+			// A dismissal keeps the alert and records who dismissed it and why
+			v := got.ExpressionAttributeValues
+			if v[":status"]["S"] != AlertDismissed || v[":by"]["S"] != "ops@example.com" || v[":reason"]["S"] != "sensor glitch" || v[":time"]["N"] == "" {
+				t.Errorf("dismissal sets %q with %v", got.UpdateExpression, v)
+			}
+		})
+	}
+}
+
+func TestGetAlert(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		wantMeta map[string]interface{}
+		wantErr  error
+	}{
+		{
+			name: "anomaly with metadata",
+			item: `{"alertId":{"S":"a-1"},"type":{"S":"anomaly"},"timestamp":{"N":"1700000000"},"metadata":{"M":{
+				"current_power":{"N":"42.5"},"average_power":{"N":"20"},"window":{"N":"20"},"method":{"S":"zscore"}}}}`,
+			wantMeta: map[string]interface{}{"current_power": 42.5, "average_power": 20.0, "window": 20.0, "method": "zscore"},
+		},
+		{name: "no metadata", item: `{"alertId":{"S":"a-1"},"type":{"S":"maintenance"},"timestamp":{"N":"1700000000"}}`},
+		{name: "missing", wantErr: ErrAlertNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				TableName string
+				Key       map[string]map[string]string
+			}
+			c := &DynamoDBClient{ctx: context.Background(), precision: DefaultPrecision}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				if tt.item == "" {
+					w.Write([]byte(`{}`))
+					return
+				}
+				w.Write([]byte(`{"Item":` + tt.item + `}`))
+			})
+
+			alert, err := c.GetAlert("a-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got.TableName != "Alerts" || got.Key["alertId"]["S"] != "a-1" {
+				t.Errorf("got item %v from %q", got.Key, got.TableName)
+			}
+			if err != nil {
+				return
+			}
+			// Numbers come back as float64, as the explain endpoint reads them
+			if alert.AlertID != "a-1" || alert.Timestamp.Unix() != 1_700_000_000 || !reflect.DeepEqual(alert.Metadata, tt.wantMeta) {
+				t.Errorf("alert %+v", alert)
+			}
+		})
+	}
+}
+
+func TestBatchPutReadingsConcurrently(t *testing.T) {
+	tests := []struct {
+		name        string
+		batchSize   int
+		parallelism int
+		wantBatches int
+	}{
+		{name: "sequential", batchSize: 25, parallelism: 1, wantBatches: 40},
+		{name: "four workers", batchSize: 25, parallelism: 4, wantBatches: 40},
+		{name: "small batches", batchSize: 10, parallelism: 8, wantBatches: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			written := make(map[string]int)
+			var batches, inFlight, maxInFlight int
+			c := &DynamoDBClient{ctx: context.Background(), region: "us-east-1", precision: DefaultPrecision,
+				batchSize: tt.batchSize, writeParallelism: tt.parallelism}
+			c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+				var in struct {
+					RequestItems map[string][]struct {
+						PutRequest struct{ Item map[string]map[string]string }
+					}
+				}
+				if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				batches++
+				inFlight++
+				maxInFlight = max(maxInFlight, inFlight)
+				for _, req := range in.RequestItems["EnergyReadings"] {
+					written[req.PutRequest.Item["meterId"]["S"]+"@"+req.PutRequest.Item["timestamp"]["N"]]++
+				}
+				if n := len(in.RequestItems["EnergyReadings"]); n > tt.batchSize {
+					t.Errorf("batch of %d items, want at most %d", n, tt.batchSize)
+				}
+				mu.Unlock()
+
+				// Hold the request so the other workers' batches overlap it
+				time.Sleep(2 * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.Write([]byte(`{"UnprocessedItems":{}}`))
+			})
+
+			// 1000 readings from 10 meters over 100 minutes
+			t0 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			readings := make([]domain.Reading, 1000)
+			for i := range readings {
+				readings[i] = domain.Reading{MeterID: int64(i%10 + 1), Timestamp: timeutil.FromTime(t0.Add(time.Duration(i/10) * time.Minute)), PowerKW: 4}
+			}
+			if err := c.BatchPutReadings(readings, "facility-001"); err != nil {
+				t.Fatal(err)
+			}
+
+			if batches != tt.wantBatches {
+				t.Errorf("%d BatchWriteItem requests, want %d", batches, tt.wantBatches)
+			}
+			if len(written) != 1000 {
+				t.Errorf("%d distinct readings written, want 1000", len(written))
+			}
+			for k, n := range written {
+				if n != 1 {
+					t.Errorf("%s written %d times", k, n)
+				}
+			}
+			if maxInFlight > tt.parallelism || tt.parallelism > 1 && maxInFlight < 2 {
+				t.Errorf("%d batches in flight at once with %d workers", maxInFlight, tt.parallelism)
+			}
+		})
+	}
+}
+
+func TestWriteBatchesCollectsFailures(t *testing.T) {
+	// Every batch holding meter 30 or 80 fails; the rest are still written
+	w := &failingBatchWriter{fakeBatchWriter: fakeBatchWriter{written: make(map[string]int)}, failOn: map[string]bool{"30": true, "80": true}}
+	reqs := readingRequests(100)
+	var batches [][]types.WriteRequest
+	for i := 0; i < len(reqs); i += 25 {
+		batches = append(batches, reqs[i:i+25])
+	}
+
+	err := writeBatches(context.Background(), w, "EnergyReadings", batches, 3)
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 batches failed") ||
+		!strings.Contains(err.Error(), "batch 1:") || !strings.Contains(err.Error(), "batch 3:") {
+		t.Fatalf("err = %v, want batches 1 and 3 reported", err)
+	}
+	if !errors.Is(err, errThrottled) {
+		t.Errorf("err = %v doesn't wrap the batch errors", err)
+	}
+	if len(w.written) != 50 {
+		t.Errorf("%d items written, want the 50 of the two good batches", len(w.written))
+	}
+}
+
+var errThrottled = errors.New("throttled")
+
+// failingBatchWriter fails any call carrying one of the failOn items
+type failingBatchWriter struct {
+	fakeBatchWriter
+	failOn map[string]bool
+}
+
+func (f *failingBatchWriter) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, req := range in.RequestItems["EnergyReadings"] {
+		if f.failOn[itemKey(req)] {
+			return nil, errThrottled
+		}
+	}
+	return f.fakeBatchWriter.BatchWriteItem(ctx, in, opts...)
+}
@@ -0,0 +1,59 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// queryAPI is the subset of the DynamoDB client used for failover reads
+type queryAPI interface {
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// isRegionalFailure reports whether err indicates the region itself is
+// degraded (5xx responses or network failures reaching the endpoint) rather
+// than a problem with the request, which a second region wouldn't fix
+func isRegionalFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// Requests that never got a response are wrapped with status 0
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() != 0 {
+		return respErr.HTTPStatusCode() >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// query runs a read-only Query against the primary region and, when a
+// secondary region is configured, retries it there on a regional failure
+func (c *DynamoDBClient) query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	out, err := c.svc.Query(c.ctx, input)
+	if err == nil || c.secondary == nil || !isRegionalFailure(err) {
+		return out, err
+	}
+
+	fmt.Printf("DynamoDB primary region %s failed, reading from %s: %v\n", c.region, c.secondaryRegion, err)
+	out, secErr := c.secondary.Query(c.ctx, input)
+	if secErr != nil {
+		return nil, fmt.Errorf("primary region %s: %v; secondary region %s: %w", c.region, err, c.secondaryRegion, secErr)
+	}
+	return out, nil
+}
+
+// writeErr marks write failures caused by a degraded primary region when
+// failover is configured, since writes are never sent to the secondary region
+func (c *DynamoDBClient) writeErr(err error) error {
+	if err == nil || c.secondary == nil || !isRegionalFailure(err) {
+		return err
+	}
+	return fmt.Errorf("primary region %s unavailable (writes do not fail over to %s): %w", c.region, c.secondaryRegion, err)
+}
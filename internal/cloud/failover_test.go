@@ -0,0 +1,149 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// fakeQuery answers every Query with items, or err
+type fakeQuery struct {
+	calls int
+	items []map[string]types.AttributeValue
+	err   error
+}
+
+func (f *fakeQuery) Query(_ context.Context, _ *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.QueryOutput{Items: f.items}, nil
+}
+
+// endpointClient returns a DynamoDB client sending its requests to handler
+func endpointClient(t *testing.T, handler http.HandlerFunc) *dynamodb.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return dynamoClientFor(srv.URL)
+}
+
+func dynamoClientFor(url string) *dynamodb.Client {
+	return dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(url),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+// primaryClient returns a DynamoDB client for an endpoint answering every
+// request with status; status 0 is an endpoint that can't be reached
+func primaryClient(t *testing.T, status int) *dynamodb.Client {
+	t.Helper()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"degraded"}`))
+	}
+	if status == 0 {
+		srv := httptest.NewServer(http.HandlerFunc(handler))
+		srv.Close()
+		return dynamoClientFor(srv.URL)
+	}
+	return endpointClient(t, handler)
+}
+
+func TestReadFailover(t *testing.T) {
+	reading, err := attributevalue.MarshalMap(Reading{
+		FacilityID: "facility-001", MeterID: "1", Timestamp: timeutil.FromTime(time.Now().Add(-time.Minute)), PowerKW: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert, err := attributevalue.MarshalMap(Alert{AlertID: "a-1", FacilityID: "facility-001", Severity: "high", Timestamp: timeutil.FromTime(time.Now())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		status        int
+		secondary     bool
+		secondaryErr  error
+		wantSecondary bool
+		wantErr       string
+	}{
+		{name: "primary 5xx fails over", status: http.StatusInternalServerError, secondary: true, wantSecondary: true},
+		{name: "primary unreachable fails over", status: 0, secondary: true, wantSecondary: true},
+		{name: "request error doesn't fail over", status: http.StatusBadRequest, secondary: true, wantErr: "StatusCode: 400"},
+		{name: "no secondary configured", status: http.StatusInternalServerError, wantErr: "StatusCode: 500"},
+		{
+			name: "both regions down", status: http.StatusInternalServerError, secondary: true, secondaryErr: errors.New("secondary degraded"),
+			wantSecondary: true, wantErr: "secondary region us-west-2: secondary degraded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sec := &fakeQuery{err: tt.secondaryErr}
+			c := &DynamoDBClient{svc: primaryClient(t, tt.status), ctx: context.Background(), region: "us-east-1", precision: DefaultPrecision}
+			if tt.secondary {
+				c.secondary, c.secondaryRegion = sec, "us-west-2"
+			}
+
+			sec.items = []map[string]types.AttributeValue{reading}
+			readings, err := c.GetRecentReadings("facility-001", time.Hour)
+			checkFailover(t, "GetRecentReadings", err, len(readings), sec, tt.wantSecondary, tt.wantErr)
+
+			sec.calls, sec.items = 0, []map[string]types.AttributeValue{alert}
+			alerts, err := c.GetAlerts("facility-001", AlertFilter{})
+			checkFailover(t, "GetAlerts", err, len(alerts), sec, tt.wantSecondary, tt.wantErr)
+		})
+	}
+}
+
+func checkFailover(t *testing.T, op string, err error, got int, sec *fakeQuery, wantSecondary bool, wantErr string) {
+	t.Helper()
+	if (sec.calls > 0) != wantSecondary {
+		t.Errorf("%s: secondary queried %d times, want used %v", op, sec.calls, wantSecondary)
+	}
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: err = %v, want it to contain %q", op, err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s: %v", op, err)
+	} else if got != 1 {
+		t.Errorf("%s returned %d items, want the secondary's 1", op, got)
+	}
+}
+
+func TestWritesDoNotFailOver(t *testing.T) {
+	sec := &fakeQuery{}
+	c := &DynamoDBClient{
+		svc: primaryClient(t, http.StatusServiceUnavailable), ctx: context.Background(), region: "us-east-1",
+		secondary: sec, secondaryRegion: "us-west-2", precision: DefaultPrecision,
+	}
+	err := c.PutReading(&domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(time.Now()), PowerKW: 2}, "facility-001")
+	if err == nil || !strings.Contains(err.Error(), "primary region us-east-1 unavailable (writes do not fail over to us-west-2)") {
+		t.Errorf("err = %v, want the primary-only write error", err)
+	}
+	if sec.calls != 0 {
+		t.Errorf("secondary used %d times for a write", sec.calls)
+	}
+}
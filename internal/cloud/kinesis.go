@@ -0,0 +1,162 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// kinesisRecordLimit is the most records fetched per GetRecords call
+const kinesisRecordLimit = 500
+
+// KinesisRecord is one record read from a stream shard
+type KinesisRecord struct {
+	ShardID        string
+	PartitionKey   string
+	SequenceNumber string
+	Data           []byte
+	ArrivedAt      time.Time
+}
+
+// KinesisConsumer polls every shard of a stream and hands each record to a
+// handler. Iterators start at LATEST or TRIM_HORIZON and aren't checkpointed,
+// so a restart re-reads from that position.
+type KinesisConsumer struct {
+	svc          *kinesis.Client
+	ctx          context.Context
+	stream       string
+	iteratorType types.ShardIteratorType
+	pollInterval time.Duration
+	polling      atomic.Int32 // shards with a live iterator
+}
+
+// NewKinesisConsumer creates a consumer for stream. iteratorType is LATEST or
+// TRIM_HORIZON; pollInterval is the wait between GetRecords calls per shard.
+func NewKinesisConsumer(region, stream, iteratorType string, pollInterval time.Duration) (*KinesisConsumer, error) {
+	if stream == "" {
+		return nil, fmt.Errorf("KINESIS_STREAM_NAME is required for kinesis ingestion")
+	}
+	it := types.ShardIteratorType(iteratorType)
+	if it != types.ShardIteratorTypeLatest && it != types.ShardIteratorTypeTrimHorizon {
+		return nil, fmt.Errorf("unknown Kinesis iterator type %q: expected LATEST or TRIM_HORIZON", iteratorType)
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %w", err)
+	}
+
+	return &KinesisConsumer{
+		svc:          kinesis.NewFromConfig(cfg),
+		ctx:          ctx,
+		stream:       stream,
+		iteratorType: it,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// Connected reports whether at least one shard is being polled
+func (c *KinesisConsumer) Connected() bool { return c.polling.Load() > 0 }
+
+// Run lists the stream's shards and polls each until its iterator ends (the
+// shard was closed by a reshard) or a call fails. handle is called serially
+// within a shard and concurrently across shards.
+func (c *KinesisConsumer) Run(handle func(KinesisRecord)) error {
+	shards, err := c.listShards()
+	if err != nil {
+		return err
+	}
+	if len(shards) == 0 {
+		return fmt.Errorf("stream %s has no shards", c.stream)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(shards))
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard string) {
+			defer wg.Done()
+			errs[i] = c.pollShard(shard, handle)
+		}(i, shard)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *KinesisConsumer) listShards() ([]string, error) {
+	var shards []string
+	input := &kinesis.ListShardsInput{StreamName: aws.String(c.stream)}
+	for {
+		out, err := c.svc.ListShards(c.ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list shards of %s: %w", c.stream, err)
+		}
+		for _, s := range out.Shards {
+			shards = append(shards, aws.ToString(s.ShardId))
+		}
+		if out.NextToken == nil {
+			return shards, nil
+		}
+		// Paging requests carry only the token
+		input = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+func (c *KinesisConsumer) pollShard(shard string, handle func(KinesisRecord)) error {
+	it, err := c.svc.GetShardIterator(c.ctx, &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(c.stream),
+		ShardId:           aws.String(shard),
+		ShardIteratorType: c.iteratorType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get iterator for shard %s: %w", shard, err)
+	}
+
+	c.polling.Add(1)
+	defer c.polling.Add(-1)
+
+	iterator := it.ShardIterator
+	for iterator != nil {
+		out, err := c.svc.GetRecords(c.ctx, &kinesis.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         aws.Int32(kinesisRecordLimit),
+		})
+		if err != nil {
+			// Read throughput is shared by all consumers of a shard; back off and retry
+			var throttled *types.ProvisionedThroughputExceededException
+			if errors.As(err, &throttled) {
+				time.Sleep(2 * c.pollInterval)
+				continue
+			}
+			return fmt.Errorf("failed to get records from shard %s: %w", shard, err)
+		}
+
+		for _, r := range out.Records {
+			handle(KinesisRecord{
+				ShardID:        shard,
+				PartitionKey:   aws.ToString(r.PartitionKey),
+				SequenceNumber: aws.ToString(r.SequenceNumber),
+				Data:           r.Data,
+				ArrivedAt:      aws.ToTime(r.ApproximateArrivalTimestamp),
+			})
+		}
+
+		iterator = out.NextShardIterator
+		if len(out.Records) == 0 {
+			time.Sleep(c.pollInterval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// kinesisConsumer returns a consumer of a stream whose shards shard-0 and
+// shard-1 are listed over two pages; getRecords answers the call'th
+// GetRecords call for a shard
+func kinesisConsumer(t *testing.T, getRecords func(w http.ResponseWriter, shard string, call int)) *KinesisConsumer {
+	t.Helper()
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			NextToken     string
+			ShardId       string
+			ShardIterator string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Kinesis_20131202."); op {
+		case "ListShards":
+			if in.NextToken == "" {
+				w.Write([]byte(`{"Shards":[{"ShardId":"shard-0"}],"NextToken":"page-2"}`))
+			} else {
+				w.Write([]byte(`{"Shards":[{"ShardId":"shard-1"}]}`))
+			}
+		case "GetShardIterator":
+			fmt.Fprintf(w, `{"ShardIterator":%q}`, in.ShardId)
+		case "GetRecords":
+			mu.Lock()
+			call := calls[in.ShardIterator]
+			calls[in.ShardIterator]++
+			mu.Unlock()
+			getRecords(w, in.ShardIterator, call)
+		default:
+			t.Errorf("unexpected %s", op)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &KinesisConsumer{
+		svc: kinesis.New(kinesis.Options{
+			Region:           "us-east-1",
+			BaseEndpoint:     aws.String(srv.URL),
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		}),
+		ctx:          context.Background(),
+		stream:       "readings",
+		iteratorType: types.ShardIteratorTypeTrimHorizon,
+		pollInterval: time.Millisecond,
+	}
+}
+
+// kinesisRecords writes a GetRecords page holding data, one record per
+// payload; the shard closes after it unless more is set
+func kinesisRecords(w http.ResponseWriter, shard string, more bool, data ...string) {
+	var records []string
+	for i, d := range data {
+		records = append(records, fmt.Sprintf(`{"PartitionKey":"meter-%d","SequenceNumber":"%d","Data":%q,"ApproximateArrivalTimestamp":1709294400}`,
+			i, i, base64.StdEncoding.EncodeToString([]byte(d))))
+	}
+	next := ""
+	if more {
+		next = fmt.Sprintf(`,"NextShardIterator":%q`, shard)
+	}
+	fmt.Fprintf(w, `{"Records":[%s]%s}`, strings.Join(records, ","), next)
+}
+
+func TestKinesisConsumerRun(t *testing.T) {
+	throttled := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ProvisionedThroughputExceededException","message":"Rate exceeded"}`))
+	}
+	tests := []struct {
+		name       string
+		getRecords func(w http.ResponseWriter, shard string, call int)
+		want       []string // shard/data of each record handled
+		wantErr    bool
+	}{
+		{
+			name: "every shard until it closes",
+			getRecords: func(w http.ResponseWriter, shard string, call int) {
+				switch call {
+				case 0:
+					kinesisRecords(w, shard, true, shard+" a", shard+" b")
+				case 1:
+					kinesisRecords(w, shard, true) // nothing new yet
+				default:
+					kinesisRecords(w, shard, false, shard+" c")
+				}
+			},
+			want: []string{"shard-0/shard-0 a", "shard-0/shard-0 b", "shard-0/shard-0 c", "shard-1/shard-1 a", "shard-1/shard-1 b", "shard-1/shard-1 c"},
+		},
+		{
+			name: "throttled reads are retried",
+			getRecords: func(w http.ResponseWriter, shard string, call int) {
+				if call == 0 {
+					throttled(w)
+					return
+				}
+				kinesisRecords(w, shard, false, shard+" a")
+			},
+			want: []string{"shard-0/shard-0 a", "shard-1/shard-1 a"},
+		},
+		{
+			name: "a failing shard stops",
+			getRecords: func(w http.ResponseWriter, shard string, call int) {
+				if shard == "shard-1" {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"__type":"ExpiredIteratorException","message":"Iterator expired"}`))
+					return
+				}
+				kinesisRecords(w, shard, false, shard+" a")
+			},
+			want:    []string{"shard-0/shard-0 a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := kinesisConsumer(t, tt.getRecords)
+			var mu sync.Mutex
+			var got []string
+			err := c.Run(func(r KinesisRecord) {
+				if r.PartitionKey == "" || r.SequenceNumber == "" || !r.ArrivedAt.Equal(time.Unix(1709294400, 0)) {
+					t.Errorf("record %+v", r)
+				}
+				mu.Lock()
+				got = append(got, r.ShardID+"/"+string(r.Data))
+				mu.Unlock()
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "shard-1") {
+				t.Errorf("error %q doesn't name the shard", err)
+			}
+			// Records are handled in order within a shard
+			sort.SliceStable(got, func(i, j int) bool { return strings.Split(got[i], "/")[0] < strings.Split(got[j], "/")[0] })
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("handled %v, want %v", got, tt.want)
+			}
+			if c.Connected() {
+				t.Error("still connected after every shard stopped")
+			}
+		})
+	}
+}
+
+func TestNewKinesisConsumer(t *testing.T) {
+	tests := []struct {
+		stream   string
+		iterator string
+		wantErr  bool
+	}{
+		{stream: "readings", iterator: "LATEST"},
+		{stream: "readings", iterator: "TRIM_HORIZON"},
+		{stream: "readings", iterator: "AT_TIMESTAMP", wantErr: true},
+		{stream: "", iterator: "LATEST", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := NewKinesisConsumer("us-east-1", tt.stream, tt.iterator, 0)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewKinesisConsumer(%q, %q): err = %v, wantErr %v", tt.stream, tt.iterator, err, tt.wantErr)
+		}
+	}
+}
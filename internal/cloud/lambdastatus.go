@@ -0,0 +1,51 @@
+package cloud
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// FunctionStatus is the deployed configuration of one of the API's Lambda
+// functions. Error is set instead when the configuration couldn't be read,
+// e.g. because the function isn't deployed.
+type FunctionStatus struct {
+	Name             string `json:"name"`
+	Version          string `json:"version,omitempty"`
+	State            string `json:"state,omitempty"`
+	StateReason      string `json:"state_reason,omitempty"`
+	LastUpdateStatus string `json:"last_update_status,omitempty"`
+	LastModified     string `json:"last_modified,omitempty"`
+	Runtime          string `json:"runtime,omitempty"`
+	MemoryMB         int32  `json:"memory_mb,omitempty"`
+	TimeoutSeconds   int32  `json:"timeout_seconds,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// FunctionStatuses reads the configuration of every Lambda function the API
+// invokes. A function that can't be read gets its error in its status rather
+// than failing the others.
+func (c *LambdaClient) FunctionStatuses() []FunctionStatus {
+	names := []string{AnomalyDetectionFunction, AnalyticsProcessingFunction}
+	out := make([]FunctionStatus, 0, len(names))
+	for _, name := range names {
+		st := FunctionStatus{Name: name}
+		cfg, err := c.svc.GetFunctionConfiguration(c.ctx, &lambda.GetFunctionConfigurationInput{
+			FunctionName: aws.String(name),
+		})
+		if err != nil {
+			st.Error = err.Error()
+			out = append(out, st)
+			continue
+		}
+		st.Version = aws.ToString(cfg.Version)
+		st.State = string(cfg.State)
+		st.StateReason = aws.ToString(cfg.StateReason)
+		st.LastUpdateStatus = string(cfg.LastUpdateStatus)
+		st.LastModified = aws.ToString(cfg.LastModified)
+		st.Runtime = string(cfg.Runtime)
+		st.MemoryMB = aws.ToInt32(cfg.MemorySize)
+		st.TimeoutSeconds = aws.ToInt32(cfg.Timeout)
+		out = append(out, st)
+	}
+	return out
+}
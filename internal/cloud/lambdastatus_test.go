@@ -0,0 +1,82 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestFunctionStatuses(t *testing.T) {
+	anomaly := `{"FunctionName":"anomaly-detection","Version":"$LATEST","State":"Active","LastUpdateStatus":"Successful",` +
+		`"LastModified":"2024-03-01T12:00:00.000+0000","Runtime":"provided.al2023","MemorySize":256,"Timeout":30}`
+	analytics := `{"FunctionName":"analytics-processing","Version":"7","State":"Pending","StateReason":"The function is being created.",` +
+		`"LastUpdateStatus":"InProgress","LastModified":"2024-03-02T08:30:00.000+0000","Runtime":"provided.al2023","MemorySize":1024,"Timeout":300}`
+	wantAnomaly := FunctionStatus{
+		Name: AnomalyDetectionFunction, Version: "$LATEST", State: "Active", LastUpdateStatus: "Successful",
+		LastModified: "2024-03-01T12:00:00.000+0000", Runtime: "provided.al2023", MemoryMB: 256, TimeoutSeconds: 30,
+	}
+
+	tests := []struct {
+		name    string
+		configs map[string]string // function name to its configuration; absent isn't deployed
+		want    []FunctionStatus
+		// wantErrFor is the function whose status carries an error instead
+		wantErrFor string
+	}{
+		{
+			name:    "both deployed",
+			configs: map[string]string{AnomalyDetectionFunction: anomaly, AnalyticsProcessingFunction: analytics},
+			want: []FunctionStatus{wantAnomaly, {
+				Name: AnalyticsProcessingFunction, Version: "7", State: "Pending", StateReason: "The function is being created.",
+				LastUpdateStatus: "InProgress", LastModified: "2024-03-02T08:30:00.000+0000", Runtime: "provided.al2023", MemoryMB: 1024, TimeoutSeconds: 300,
+			}},
+		},
+		{
+			name:       "one not deployed",
+			configs:    map[string]string{AnomalyDetectionFunction: anomaly},
+			want:       []FunctionStatus{wantAnomaly, {Name: AnalyticsProcessingFunction}},
+			wantErrFor: AnalyticsProcessingFunction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// GET /2015-03-31/functions/<name>/configuration
+				name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/2015-03-31/functions/"), "/configuration")
+				w.Header().Set("Content-Type", "application/json")
+				cfg, ok := tt.configs[name]
+				if !ok {
+					w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException")
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"Type":"User","Message":"Function not found: ` + name + `"}`))
+					return
+				}
+				w.Write([]byte(cfg))
+			}))
+			defer srv.Close()
+			c := &LambdaClient{ctx: context.Background(), svc: lambda.New(lambda.Options{
+				Region: "us-east-1", BaseEndpoint: aws.String(srv.URL), Credentials: aws.AnonymousCredentials{}, RetryMaxAttempts: 1,
+			})}
+
+			got := c.FunctionStatuses()
+			for i := range got {
+				if (got[i].Error != "") != (got[i].Name == tt.wantErrFor) {
+					t.Errorf("%s: error %q", got[i].Name, got[i].Error)
+				}
+				if got[i].Error != "" && !strings.Contains(got[i].Error, "Function not found") {
+					t.Errorf("%s: error %q doesn't say why", got[i].Name, got[i].Error)
+				}
+				got[i].Error = ""
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statuses\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
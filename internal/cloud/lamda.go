@@ -8,6 +8,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// Names of the Lambda functions the API invokes
+const (
+	AnomalyDetectionFunction    = "anomaly-detection"
+	AnalyticsProcessingFunction = "analytics-processing"
 )
 
 // LambdaClient wraps AWS Lambda client for serverless function invocation
@@ -34,18 +42,22 @@ func NewLambdaClient(region string) (*LambdaClient, error) {
 
 // AnomalyDetectionPayload represents the input for anomaly detection Lambda
 type AnomalyDetectionPayload struct {
-	FacilityID string  `json:"facility_id"`
-	MeterID    string  `json:"meter_id"`
-	Timestamp  int64   `json:"timestamp"`
-	Voltage    float64 `json:"voltage"`
-	Current    float64 `json:"current"`
-	PowerKW    float64 `json:"power_kw"`
+	FacilityID string             `json:"facility_id"`
+	MeterID    string             `json:"meter_id"`
+	Timestamp  timeutil.Timestamp `json:"timestamp"`
+	Voltage    float64            `json:"voltage"`
+	Current    float64            `json:"current"`
+	PowerKW    float64            `json:"power_kw"`
 }
 
 // AnalyticsProcessingPayload represents the input for analytics processing Lambda
 type AnalyticsProcessingPayload struct {
 	Date       string `json:"date"`
 	FacilityID string `json:"facility_id"`
+	Format     string `json:"format,omitempty"` // report format: json (default) or pdf
+
+	// Bucket interval of the analytics: 15, 30 or 60; 0 uses the Lambda's default
+	GranularityMinutes int `json:"granularity_minutes,omitempty"`
 }
 
 // InvokeAnomalyDetection invokes the anomaly detection Lambda function
@@ -57,7 +69,7 @@ func (c *LambdaClient) InvokeAnomalyDetection(payload AnomalyDetectionPayload) (
 	}
 
 	input := &lambda.InvokeInput{
-		FunctionName: aws.String("anomaly-detection"),
+		FunctionName: aws.String(AnomalyDetectionFunction),
 		Payload:      payloadBytes,
 	}
 
@@ -82,10 +94,13 @@ func (c *LambdaClient) InvokeAnomalyDetection(payload AnomalyDetectionPayload) (
 
 // InvokeAnalyticsProcessing invokes the analytics processing Lambda function
 // YOUR ORIGINAL CONTRIBUTION: Trigger serverless daily analytics generation
-func (c *LambdaClient) InvokeAnalyticsProcessing(date, facilityID string) (map[string]interface{}, error) {
+func (c *LambdaClient) InvokeAnalyticsProcessing(date, facilityID, format string, granularityMinutes int) (map[string]interface{}, error) {
 	payload := AnalyticsProcessingPayload{
 		Date:       date,
 		FacilityID: facilityID,
+		Format:     format,
+
+		GranularityMinutes: granularityMinutes,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -94,7 +109,7 @@ func (c *LambdaClient) InvokeAnalyticsProcessing(date, facilityID string) (map[s
 	}
 
 	input := &lambda.InvokeInput{
-		FunctionName:   aws.String("analytics-processing"),
+		FunctionName:   aws.String(AnalyticsProcessingFunction),
 		Payload:        payloadBytes,
 		InvocationType: "RequestResponse", // Synchronous invocation
 	}
@@ -130,7 +145,7 @@ func (c *LambdaClient) InvokeAnalyticsAsync(date, facilityID string) error {
 	}
 
 	input := &lambda.InvokeInput{
-		FunctionName:   aws.String("analytics-processing"),
+		FunctionName:   aws.String(AnalyticsProcessingFunction),
 		Payload:        payloadBytes,
 		InvocationType: "Event", // Asynchronous invocation
 	}
@@ -0,0 +1,134 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// MessageFormat selects how a notification body is rendered for SNS subscribers
+type MessageFormat string
+
+const (
+	// FormatText publishes the readable text rendering to every subscriber
+	FormatText MessageFormat = "text"
+	// FormatJSON publishes with MessageStructure=json: machine subscribers
+	// (Lambda, SQS, HTTP/S) get the JSON rendering, email and SMS get the text one
+	FormatJSON MessageFormat = "json"
+)
+
+// ParseMessageFormat validates a configured format; empty means text
+func ParseMessageFormat(s string) (MessageFormat, error) {
+	switch f := MessageFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid SNS message format %q: expected text or json", s)
+	}
+}
+
+// Notification is a channel-agnostic alert notification delivered by the
+// SNS and webhook notifiers
+type Notification struct {
+	FacilityID  string    `json:"facility_id"`
+	EquipmentID string    `json:"equipment_id,omitempty"`
+	Severity    string    `json:"severity"`
+	Type        string    `json:"type"`
+	Message     string    `json:"message"`
+	Timestamp   time.Time `json:"timestamp"`
+
+	// Format overrides the SNS client's default format; empty uses the default
+	Format MessageFormat `json:"-"`
+}
+
+// Fields returns the subject template placeholders for the notification
+func (n Notification) Fields() map[string]string {
+	return map[string]string{
+		"facility":  n.FacilityID,
+		"equipment": n.EquipmentID,
+		"severity":  n.Severity,
+		"type":      n.Type,
+	}
+}
+
+// RenderText renders the notification for human readers such as email subscribers
+func (n Notification) RenderText() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", n.Message)
+	fmt.Fprintf(&b, "Facility: %s\n", n.FacilityID)
+	if n.EquipmentID != "" {
+		fmt.Fprintf(&b, "Equipment: %s\n", n.EquipmentID)
+	}
+	fmt.Fprintf(&b, "Severity: %s\nType: %s\nTime: %s\n", n.Severity, n.Type, n.Timestamp.Format(time.RFC3339))
+	return b.String()
+}
+
+// RenderJSON renders the notification as the JSON document machine subscribers consume
+func (n Notification) RenderJSON() (string, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return string(data), nil
+}
+
+// structuredMessage builds the MessageStructure=json body carrying both renderings
+func (n Notification) structuredMessage() (string, error) {
+	doc, err := n.RenderJSON()
+	if err != nil {
+		return "", err
+	}
+	text := n.RenderText()
+
+	data, err := json.Marshal(map[string]string{
+		"default": doc,
+		"email":   text,
+		"sms":     text,
+		"lambda":  doc,
+		"sqs":     doc,
+		"http":    doc,
+		"https":   doc,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode structured message: %w", err)
+	}
+	return string(data), nil
+}
+
+// Notify publishes the notification to the SNS topic using the configured subject
+// template, rendered in the notification's format or the client default
+func (c *SNSClient) Notify(n Notification) error {
+	subject := c.SubjectFor(DefaultAlertSubject, n.Fields())
+
+	format := n.Format
+	if format == "" {
+		format = c.format
+	}
+	if format != FormatJSON {
+		return c.SendAlert(subject, n.RenderText())
+	}
+
+	message, err := n.structuredMessage()
+	if err != nil {
+		return err
+	}
+
+	result, err := c.svc.Publish(c.ctx, &sns.PublishInput{
+		TopicArn:         aws.String(c.topicArn),
+		Subject:          aws.String(truncateSubject(subject)),
+		Message:          aws.String(message),
+		MessageStructure: aws.String("json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to SNS: %w", err)
+	}
+
+	fmt.Printf("Alert sent successfully. MessageID: %s\n", aws.ToString(result.MessageId))
+	return nil
+}
@@ -0,0 +1,134 @@
+package cloud
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMessageFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    MessageFormat
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{" JSON ", FormatJSON, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMessageFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMessageFormat(%q) = %q, %v; want %q, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNotificationRenderings(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		n         Notification
+		wantLines []string
+		absent    []string
+	}{
+		{
+			name:      "with equipment",
+			n:         Notification{FacilityID: "facility-001", EquipmentID: "pump-1", Severity: "critical", Type: "anomaly", Message: "Power 40 kW", Timestamp: at},
+			wantLines: []string{"Power 40 kW\n", "Facility: facility-001\n", "Equipment: pump-1\n", "Severity: critical\n", "Type: anomaly\n", "Time: 2024-03-01T12:00:00Z\n"},
+		},
+		{
+			name:      "facility-wide",
+			n:         Notification{FacilityID: "facility-001", Severity: "low", Type: "capacity", Message: "Load at 85%", Timestamp: at},
+			wantLines: []string{"Load at 85%\n", "Facility: facility-001\n", "Severity: low\n", "Type: capacity\n"},
+			absent:    []string{"Equipment:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := tt.n.RenderText()
+			for _, line := range tt.wantLines {
+				if !strings.Contains(text, line) {
+					t.Errorf("text lacks %q:\n%s", line, text)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(text, s) {
+					t.Errorf("text has %q:\n%s", s, text)
+				}
+			}
+
+			doc, err := tt.n.RenderJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Notification
+			if err := json.Unmarshal([]byte(doc), &got); err != nil {
+				t.Fatalf("JSON rendering doesn't parse: %v", err)
+			}
+			if !got.Timestamp.Equal(tt.n.Timestamp) || got.FacilityID != tt.n.FacilityID || got.EquipmentID != tt.n.EquipmentID ||
+				got.Severity != tt.n.Severity || got.Type != tt.n.Type || got.Message != tt.n.Message {
+				t.Errorf("JSON rendering = %+v, want %+v", got, tt.n)
+			}
+
+			// MessageStructure=json needs a default and gives machines the
+			// document and people the text
+			structured, err := tt.n.structuredMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var byProtocol map[string]string
+			if err := json.Unmarshal([]byte(structured), &byProtocol); err != nil {
+				t.Fatalf("structured message doesn't parse: %v", err)
+			}
+			for _, p := range []string{"default", "lambda", "sqs", "http", "https"} {
+				if byProtocol[p] != doc {
+					t.Errorf("%s body = %q, want the JSON rendering", p, byProtocol[p])
+				}
+			}
+			for _, p := range []string{"email", "sms"} {
+				if byProtocol[p] != text {
+					t.Errorf("%s body = %q, want the text rendering", p, byProtocol[p])
+				}
+			}
+		})
+	}
+}
+
+func TestAlertDigest(t *testing.T) {
+	tests := []struct {
+		name        string
+		bySeverity  map[string][]string
+		wantOK      bool
+		wantSubject string
+		wantMessage string
+	}{
+		{name: "nothing buffered", bySeverity: map[string][]string{"high": nil}},
+		{
+			name: "most urgent first, unknown severities last",
+			bySeverity: map[string][]string{
+				"low":      {"14:15 facility-001 capacity: Load at 85%"},
+				"info":     {"14:20 facility-001 note: test"},
+				"critical": {"14:35 facility-001/meter-3 frequency_excursion: 47.9 Hz"},
+				"high":     {"14:05 facility-001/pump-1 anomaly: Power 40 kW", "14:25 facility-002/fan-2 anomaly: Power 9 kW"},
+			},
+			wantOK:      true,
+			wantSubject: "Energy Grid Digest: 5 Alerts",
+			wantMessage: "Alert Digest:\n" +
+				"\nCRITICAL (1):\n1. 14:35 facility-001/meter-3 frequency_excursion: 47.9 Hz\n" +
+				"\nHIGH (2):\n1. 14:05 facility-001/pump-1 anomaly: Power 40 kW\n2. 14:25 facility-002/fan-2 anomaly: Power 9 kW\n" +
+				"\nLOW (1):\n1. 14:15 facility-001 capacity: Load at 85%\n" +
+				"\nINFO (1):\n1. 14:20 facility-001 note: test\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, message, ok := alertDigest(tt.bySeverity)
+			if ok != tt.wantOK || subject != tt.wantSubject || message != tt.wantMessage {
+				t.Errorf("alertDigest = %q, %q, %v\nwant %q, %q, %v", subject, message, ok, tt.wantSubject, tt.wantMessage, tt.wantOK)
+			}
+		})
+	}
+}
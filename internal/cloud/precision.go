@@ -0,0 +1,101 @@
+package cloud
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// Precision is the decimal places numbers are rounded to before they are
+// written to DynamoDB, by kind of value, so a stored value reads back as what
+// was meant (230.1, not 230.10000000000002) and the same kind is always
+// stored alike
+type Precision struct {
+	// Measurement covers reading values: voltage, current, power, reactive
+	// power, frequency and THD
+	Measurement int
+	// Score covers equipment health scores and failure risks
+	Score int
+}
+
+// DefaultPrecision keeps a thousandth of a unit for measurements and two
+// places for scores
+var DefaultPrecision = Precision{Measurement: 3, Score: 2}
+
+// maxPrecision bounds the places that can be configured; float64 holds
+// about 15 significant digits
+const maxPrecision = 10
+
+// ParsePrecision reads DYNAMODB_PRECISION ("measurement=3,score=2"). Kinds it
+// doesn't name keep their defaults.
+func ParsePrecision(spec string) (Precision, error) {
+	p := DefaultPrecision
+	if strings.TrimSpace(spec) == "" {
+		return p, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		kind, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || err != nil || n < 0 || n > maxPrecision {
+			return p, fmt.Errorf("invalid DynamoDB precision %q: expected kind=places (0-%d)", entry, maxPrecision)
+		}
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "measurement":
+			p.Measurement = n
+		case "score":
+			p.Score = n
+		default:
+			return p, fmt.Errorf("unknown DynamoDB precision kind %q: expected measurement or score", kind)
+		}
+	}
+	return p, nil
+}
+
+// formatNumber renders x rounded to places in its shortest form, as the SDK
+// marshals floats ("87.5", not "87.50")
+func formatNumber(x float64, places int) string {
+	return strconv.FormatFloat(numfmt.Round(x, places), 'f', -1, 64)
+}
+
+// numberAttr is x as a DynamoDB number rounded to places
+func numberAttr(x float64, places int) *types.AttributeValueMemberN {
+	return &types.AttributeValueMemberN{Value: formatNumber(x, places)}
+}
+
+// roundPtr rounds an optional value; nil stays nil
+func roundPtr(x *float64, places int) *float64 {
+	if x == nil {
+		return nil
+	}
+	v := numfmt.Round(*x, places)
+	return &v
+}
+
+// readingItem converts a reading to its DynamoDB item with measurements
+// rounded to the client's precision
+func (c *DynamoDBClient) readingItem(reading *domain.Reading, facilityID string) Reading {
+	places := c.precision.Measurement
+	return Reading{
+		FacilityID:  facilityID,
+		Timestamp:   reading.Timestamp,
+		MeterID:     fmt.Sprintf("%d", reading.MeterID),
+		Voltage:     numfmt.Round(reading.Voltage, places),
+		Current:     numfmt.Round(reading.Current, places),
+		PowerKW:     numfmt.Round(reading.PowerKW, places),
+		Status:      "operational",
+		Temperature: 45.0, // Default value, can be updated based on your domain model
+		Source:      reading.Source,
+		RawVoltage:  roundPtr(reading.RawVoltage, places),
+		RawPowerKW:  roundPtr(reading.RawPowerKW, places),
+
+		ReactivePowerKVAR: roundPtr(reading.ReactivePowerKVAR, places),
+		FrequencyHz:       roundPtr(reading.FrequencyHz, places),
+		THDPercent:        roundPtr(reading.THDPercent, places),
+		MeterStatus:       reading.MeterStatus,
+	}
+}
@@ -0,0 +1,137 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestParsePrecision(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Precision
+		wantErr bool
+	}{
+		{spec: "", want: DefaultPrecision},
+		{spec: "measurement=1", want: Precision{Measurement: 1, Score: 2}},
+		{spec: " Score = 0 , measurement=4", want: Precision{Measurement: 4, Score: 0}},
+		{spec: "measurement=10", want: Precision{Measurement: 10, Score: 2}},
+		{spec: "measurement=11", wantErr: true},
+		{spec: "measurement=-1", wantErr: true},
+		{spec: "measurement", wantErr: true},
+		{spec: "cost=2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrecision(tt.spec)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParsePrecision(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+// storedNumbers records the number attributes of the item or update values
+// a DynamoDB write sends
+func storedNumbers(t *testing.T, c *DynamoDBClient) map[string]string {
+	got := make(map[string]string)
+	c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Item                      map[string]map[string]interface{}
+			ExpressionAttributeValues map[string]map[string]interface{}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		for _, attrs := range []map[string]map[string]interface{}{in.Item, in.ExpressionAttributeValues} {
+			for name, v := range attrs {
+				if n, ok := v["N"].(string); ok {
+					got[name] = n
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{}`))
+	})
+	return got
+}
+
+func TestStoredPrecision(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	noisy := 0.1 + 0.2 // 0.30000000000000004
+	hz := 49.99951
+
+	tests := []struct {
+		name      string
+		precision Precision
+		write     func(c *DynamoDBClient) error
+		want      map[string]string
+		// wantNow are the attributes holding the time of the write, in epoch seconds
+		wantNow []string
+	}{
+		{
+			name: "reading", precision: DefaultPrecision,
+			write: func(c *DynamoDBClient) error {
+				return c.PutReading(&domain.Reading{MeterID: 7, Timestamp: timeutil.FromTime(at), Voltage: 230.1, Current: 2.2 * 3, PowerKW: 1.23456, ReactivePowerKVAR: &noisy, FrequencyHz: &hz}, "facility-001")
+			},
+			want: map[string]string{"timestamp": "1709294400", "voltage": "230.1", "current": "6.6", "powerKw": "1.235", "reactivePowerKvar": "0.3", "frequencyHz": "50", "temperature": "45"},
+		},
+		{
+			name: "reading at one place", precision: Precision{Measurement: 1, Score: 2},
+			write: func(c *DynamoDBClient) error {
+				return c.PutReading(&domain.Reading{MeterID: 7, Timestamp: timeutil.FromTime(at), Voltage: 230.06, Current: 10, PowerKW: 1.23456}, "facility-001")
+			},
+			want: map[string]string{"timestamp": "1709294400", "voltage": "230.1", "current": "10", "powerKw": "1.2", "temperature": "45"},
+		},
+		{
+			name: "health score", precision: DefaultPrecision,
+			write: func(c *DynamoDBClient) error { return c.UpdateEquipmentHealth("eq-1", 87.456) },
+			want:  map[string]string{":score": "87.46"}, wantNow: []string{":time"},
+		},
+		{
+			name: "health score kept short", precision: DefaultPrecision,
+			write: func(c *DynamoDBClient) error { return c.UpdateEquipmentHealth("eq-1", 87.5) },
+			want:  map[string]string{":score": "87.5"}, wantNow: []string{":time"},
+		},
+		{
+			name: "whole health score", precision: Precision{Measurement: 3, Score: 0},
+			write: func(c *DynamoDBClient) error { return c.UpdateEquipmentHealth("eq-1", 87.5) },
+			want:  map[string]string{":score": "88"}, wantNow: []string{":time"},
+		},
+		{
+			name: "alert", precision: DefaultPrecision,
+			write: func(c *DynamoDBClient) error {
+				return c.CreateAlert("facility-001", "eq-1", "high", "anomaly", "power spike")
+			},
+			want: map[string]string{}, wantNow: []string{"timestamp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &DynamoDBClient{ctx: context.Background(), precision: tt.precision}
+			got := storedNumbers(t, c)
+			if err := tt.write(c); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.wantNow {
+				secs, err := strconv.ParseInt(got[name], 10, 64)
+				if err != nil || time.Since(time.Unix(secs, 0)).Abs() > time.Minute {
+					t.Errorf("%s stored as %q, want the epoch seconds now", name, got[name])
+				}
+				delete(got, name)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("stored numbers %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s stored as %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
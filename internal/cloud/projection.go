@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ErrUnknownField is returned for a ?fields= name the endpoint doesn't have
+var ErrUnknownField = errors.New("unknown field")
+
+// projectedField is a selectable response field: the DynamoDB attribute it
+// is read from and its key in the JSON response
+type projectedField struct {
+	attr string
+	key  string
+}
+
+// readingFields are the ?fields= names of /readings/recent
+var readingFields = map[string]projectedField{
+	"meter_id":            {"meterId", "meter_id"},
+	"timestamp":           {"timestamp", "timestamp"},
+	"voltage":             {"voltage", "voltage"},
+	"current":             {"current", "current"},
+	"power_kw":            {"powerKw", "power_kw"},
+	"source":              {"source", "source"},
+	"reactive_power_kvar": {"reactivePowerKvar", "reactive_power_kvar"},
+	"frequency_hz":        {"frequencyHz", "frequency_hz"},
+	"thd_percent":         {"thdPercent", "thd_percent"},
+	"raw_voltage":         {"rawVoltage", "raw_voltage"},
+	"raw_power_kw":        {"rawPowerKw", "raw_power_kw"},
+}
+
+// alertFields are the ?fields= names of /alerts; alerts are rendered with
+// their Go field names
+var alertFields = map[string]projectedField{
+	"alert_id":     {"alertId", "AlertID"},
+	"facility_id":  {"facilityId", "FacilityID"},
+	"timestamp":    {"timestamp", "Timestamp"},
+	"severity":     {"severity", "Severity"},
+	"type":         {"type", "Type"},
+	"message":      {"message", "Message"},
+	"acknowledged": {"acknowledged", "Acknowledged"},
+	"equipment_id": {"equipmentId", "EquipmentID"},
+	"status":       {"status", "Status"},
+}
+
+// Projection limits the attributes a query reads. DynamoDB still charges read
+// capacity for whole items; a projection shrinks the response and what is
+// unmarshalled. The zero value reads whole items.
+type Projection struct {
+	attrs []string
+	keys  []string
+}
+
+// ReadingProjection parses a comma-separated ?fields= list for readings
+func ReadingProjection(fields string) (Projection, error) {
+	return parseProjection(fields, readingFields)
+}
+
+// AlertProjection parses a comma-separated ?fields= list for alerts
+func AlertProjection(fields string) (Projection, error) {
+	return parseProjection(fields, alertFields)
+}
+
+func parseProjection(fields string, known map[string]projectedField) (Projection, error) {
+	var p Projection
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		f, ok := known[name]
+		if !ok {
+			names := make([]string, 0, len(known))
+			for n := range known {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return Projection{}, fmt.Errorf("%w %q (valid: %s)", ErrUnknownField, name, strings.Join(names, ", "))
+		}
+		seen[name] = true
+		p.attrs = append(p.attrs, f.attr)
+		p.keys = append(p.keys, f.key)
+	}
+	return p, nil
+}
+
+// Empty reports whether the projection reads whole items
+func (p Projection) Empty() bool { return len(p.attrs) == 0 }
+
+// Keys returns the JSON response keys of the selected fields
+func (p Projection) Keys() []string { return p.keys }
+
+// apply sets the query's ProjectionExpression. Every attribute goes through a
+// #pN placeholder so reserved words (timestamp, type, source, current) are safe.
+func (p Projection) apply(input *dynamodb.QueryInput) {
+	if p.Empty() {
+		return
+	}
+	if input.ExpressionAttributeNames == nil {
+		input.ExpressionAttributeNames = make(map[string]string, len(p.attrs))
+	}
+	placeholders := make([]string, len(p.attrs))
+	for i, attr := range p.attrs {
+		placeholders[i] = fmt.Sprintf("#p%d", i)
+		input.ExpressionAttributeNames[placeholders[i]] = attr
+	}
+	input.ProjectionExpression = aws.String(strings.Join(placeholders, ", "))
+}
@@ -0,0 +1,175 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestReadingProjection(t *testing.T) {
+	tests := []struct {
+		fields   string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{fields: "", wantKeys: nil},
+		{fields: "meter_id,power_kw", wantKeys: []string{"meter_id", "power_kw"}},
+		{fields: " Timestamp , power_kw,timestamp,", wantKeys: []string{"timestamp", "power_kw"}},
+		{fields: "meter_id,watts", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fields, func(t *testing.T) {
+			p, err := ReadingProjection(tt.fields)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownField) || !strings.Contains(err.Error(), "power_kw") {
+					t.Fatalf("err = %v, want ErrUnknownField listing the valid fields", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.Keys(), tt.wantKeys) || p.Empty() != (tt.wantKeys == nil) {
+				t.Errorf("keys %v (empty %v), want %v", p.Keys(), p.Empty(), tt.wantKeys)
+			}
+		})
+	}
+}
+
+// projectingClient answers every query with item cut down to the attributes
+// of the request's ProjectionExpression, as DynamoDB does; got is the request
+func projectingClient(t *testing.T, item map[string]json.RawMessage, got *projectedQuery) *DynamoDBClient {
+	t.Helper()
+	c := &DynamoDBClient{ctx: context.Background(), region: "us-east-1", precision: DefaultPrecision}
+	c.svc = endpointClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+		out := item
+		if got.ProjectionExpression != "" {
+			out = make(map[string]json.RawMessage)
+			for _, p := range strings.Split(got.ProjectionExpression, ", ") {
+				attr := got.ExpressionAttributeNames[p]
+				if v, ok := item[attr]; ok {
+					out[attr] = v
+				}
+			}
+		}
+		body, _ := json.Marshal(map[string]interface{}{"Items": []interface{}{out}})
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write(body)
+	})
+	return c
+}
+
+type projectedQuery struct {
+	ProjectionExpression     string
+	ExpressionAttributeNames map[string]string
+}
+
+func TestProjectedReadings(t *testing.T) {
+	ts := time.Now().Add(-time.Minute).Unix()
+	item := map[string]json.RawMessage{
+		"facilityId": json.RawMessage(`{"S":"facility-001"}`),
+		"meterId":    json.RawMessage(`{"S":"7"}`),
+		"timestamp":  json.RawMessage(fmt.Sprintf(`{"N":"%d"}`, ts)),
+		"voltage":    json.RawMessage(`{"N":"231"}`),
+		"current":    json.RawMessage(`{"N":"12"}`),
+		"powerKw":    json.RawMessage(`{"N":"2.75"}`),
+		"source":     json.RawMessage(`{"S":"mqtt"}`),
+	}
+	full := domain.Reading{MeterID: 7, Voltage: 231, Current: 12, PowerKW: 2.75, Source: "mqtt"}
+
+	tests := []struct {
+		name      string
+		fields    string
+		wantNames []string // attributes projected, nil for whole items
+		want      domain.Reading
+	}{
+		{name: "whole items", want: full},
+		{name: "power only", fields: "meter_id,power_kw", wantNames: []string{"meterId", "powerKw"}, want: domain.Reading{MeterID: 7, PowerKW: 2.75}},
+		{
+			name: "reserved words", fields: "timestamp,current,source",
+			wantNames: []string{"timestamp", "current", "source"}, want: domain.Reading{Current: 12, Source: "mqtt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ReadingProjection(tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got projectedQuery
+			c := projectingClient(t, item, &got)
+			page, err := c.GetRecentReadingsPage("facility-001", time.Hour, "", 0, fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every projected attribute goes through a placeholder
+			var names []string
+			if got.ProjectionExpression != "" {
+				for _, p := range strings.Split(got.ProjectionExpression, ", ") {
+					if !strings.HasPrefix(p, "#p") {
+						t.Errorf("projection names %q directly", p)
+					}
+					names = append(names, got.ExpressionAttributeNames[p])
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("projected %v, want %v", names, tt.wantNames)
+			}
+
+			if len(page.Readings) != 1 {
+				t.Fatalf("%d readings", len(page.Readings))
+			}
+			rd := page.Readings[0]
+			if wantTS := tt.wantNames == nil || strings.Contains(tt.fields, "timestamp"); wantTS != (rd.Timestamp.Unix() == ts) {
+				t.Errorf("timestamp %v read, want it %v", rd.Timestamp.Time, wantTS)
+			}
+			rd.Timestamp = timeutil.Timestamp{}
+			if !reflect.DeepEqual(rd, tt.want) {
+				t.Errorf("unmarshalled %+v, want %+v", rd, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectedAlerts(t *testing.T) {
+	item := map[string]json.RawMessage{
+		"alertId":     json.RawMessage(`{"S":"a-1"}`),
+		"facilityId":  json.RawMessage(`{"S":"facility-001"}`),
+		"timestamp":   json.RawMessage(`{"N":"1700000000"}`),
+		"severity":    json.RawMessage(`{"S":"high"}`),
+		"type":        json.RawMessage(`{"S":"anomaly"}`),
+		"message":     json.RawMessage(`{"S":"Power 40 kW"}`),
+		"equipmentId": json.RawMessage(`{"S":"meter-1"}`),
+	}
+	fields, err := AlertProjection("severity,type,timestamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got projectedQuery
+	c := projectingClient(t, item, &got)
+	alerts, err := c.GetAlerts("facility-001", AlertFilter{Severity: "high", Since: 1, Until: 2_000_000_000, Fields: fields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The key condition's #ts survives alongside the projection's placeholders
+	if got.ExpressionAttributeNames["#ts"] != "timestamp" || got.ProjectionExpression != "#p0, #p1, #p2" {
+		t.Errorf("projection %q with names %v", got.ProjectionExpression, got.ExpressionAttributeNames)
+	}
+	want := Alert{Severity: "high", Type: "anomaly", Timestamp: timeutil.FromUnix(1_700_000_000)}
+	if len(alerts) != 1 || !reflect.DeepEqual(alerts[0], want) {
+		t.Errorf("unmarshalled %+v, want [%+v]", alerts, want)
+	}
+}
@@ -0,0 +1,98 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func TestReplayReadingToAnomalyDetection(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"facilityId": &types.AttributeValueMemberS{Value: "facility-001"},
+		"meterId":    &types.AttributeValueMemberS{Value: "1"},
+		"timestamp":  &types.AttributeValueMemberN{Value: "1700000000"},
+		"powerKw":    &types.AttributeValueMemberN{Value: "60"},
+		"estimated":  &types.AttributeValueMemberBOOL{Value: true},
+		"tags":       &types.AttributeValueMemberSS{Value: []string{"a"}},
+	}
+	tests := []struct {
+		name          string
+		functionError string
+		wantErr       string
+	}{
+		{name: "replayed"},
+		{name: "function error", functionError: "Unhandled", wantErr: "Lambda function error: Unhandled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			var payload struct {
+				Records []struct {
+					EventName   string `json:"eventName"`
+					EventSource string `json:"eventSource"`
+					DynamoDB    struct {
+						NewImage       map[string]map[string]interface{}
+						StreamViewType string
+					} `json:"dynamodb"`
+				}
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &payload); err != nil {
+					t.Error(err)
+				}
+				if tt.functionError != "" {
+					w.Header().Set("X-Amz-Function-Error", tt.functionError)
+				}
+				w.Write([]byte("null"))
+			}))
+			defer srv.Close()
+			c := &LambdaClient{ctx: context.Background(), svc: lambda.New(lambda.Options{
+				Region: "us-east-1", BaseEndpoint: aws.String(srv.URL), Credentials: aws.AnonymousCredentials{}, RetryMaxAttempts: 1,
+			})}
+
+			err := c.ReplayReadingToAnomalyDetection(item)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+
+			if !strings.Contains(path, AnomalyDetectionFunction) {
+				t.Errorf("invoked %s, want the anomaly detection function", path)
+			}
+			if len(payload.Records) != 1 {
+				t.Fatalf("%d records, want 1", len(payload.Records))
+			}
+			rec := payload.Records[0]
+			if rec.EventName != "INSERT" || rec.EventSource != "aws:dynamodb" || rec.DynamoDB.StreamViewType != "NEW_IMAGE" {
+				t.Errorf("record = %+v, want a stream INSERT", rec)
+			}
+			want := map[string]map[string]interface{}{
+				"facilityId": {"S": "facility-001"},
+				"meterId":    {"S": "1"},
+				"timestamp":  {"N": "1700000000"},
+				"powerKw":    {"N": "60"},
+				"estimated":  {"BOOL": true},
+			}
+			if !reflect.DeepEqual(rec.DynamoDB.NewImage, want) {
+				t.Errorf("NewImage = %v, want %v", rec.DynamoDB.NewImage, want)
+			}
+		})
+	}
+
+	if _, err := streamImage(map[string]types.AttributeValue{"tags": &types.AttributeValueMemberSS{Value: []string{"a"}}}); err == nil {
+		t.Error("item without scalar attributes accepted")
+	}
+}
@@ -3,23 +3,61 @@ package cloud
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// maxSubjectLen is the longest Subject SNS accepts; it must be under 100 characters
+const maxSubjectLen = 99
+
+// Default subject templates, used when no ALERT_SUBJECT_TEMPLATE is configured
+const (
+	DefaultAlertSubject       = "[{severity}] {type} Alert"
+	DefaultAnomalySubject     = "Energy Grid Alert: Anomaly Detected at {facility}"
+	DefaultMaintenanceSubject = "Predictive Maintenance Alert"
 )
 
+// DefaultMaintenanceMessage is the maintenance alert body used when no
+// MAINTENANCE_MESSAGE_TEMPLATE is configured; see MaintenanceFields
+const DefaultMaintenanceMessage = "Equipment Maintenance Required\n\n" +
+	"Equipment ID: {equipment}\n" +
+	"Current Health Score: {health}%\n" +
+	"30-Day Failure Risk: {risk_30d}%\n" +
+	"90-Day Failure Risk: {risk_90d}%\n" +
+	"Next Service Date: {service_date} (in {days_until_service} days)\n" +
+	"Recommendation: {recommendation}\n\n" +
+	"Please schedule maintenance to prevent failures."
+
+var placeholderRe = regexp.MustCompile(`\{(\w+)\}`)
+
 // SNSClient wraps AWS SNS client for notification operations
 type SNSClient struct {
-	svc      *sns.Client
-	topicArn string
-	ctx      context.Context
+	svc             *sns.Client
+	topicArn        string
+	subjectTemplate string
+	format          MessageFormat
+	ctx             context.Context
+
+	// maintenanceTemplate overrides DefaultMaintenanceMessage when non-empty
+	maintenanceTemplate string
 }
 
 // NewSNSClient creates a new SNS client instance
 // YOUR ORIGINAL CONTRIBUTION: Initialize SNS client for alert notifications
-func NewSNSClient(region, topicArn string) (*SNSClient, error) {
+// subjectTemplate overrides the per-alert default subjects when non-empty;
+// format is the default rendering for Notify; maintenanceTemplate overrides
+// the maintenance alert body when non-empty
+func NewSNSClient(region, topicArn, subjectTemplate string, format MessageFormat, maintenanceTemplate string) (*SNSClient, error) {
 	ctx := context.Background()
 
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -28,18 +66,83 @@ func NewSNSClient(region, topicArn string) (*SNSClient, error) {
 	}
 
 	return &SNSClient{
-		svc:      sns.NewFromConfig(cfg),
-		topicArn: topicArn,
-		ctx:      ctx,
+		svc:             sns.NewFromConfig(cfg),
+		topicArn:        topicArn,
+		subjectTemplate: subjectTemplate,
+		format:          format,
+		ctx:             ctx,
+
+		maintenanceTemplate: maintenanceTemplate,
 	}, nil
 }
 
+// RenderSubject expands {placeholder} tokens such as {severity}, {facility} and
+// {type} in tmpl, then truncates the result to fit the SNS subject limit.
+// Placeholders without a value in fields render as empty strings.
+func RenderSubject(tmpl string, fields map[string]string) (string, error) {
+	subject, err := renderPlaceholders("subject", tmpl, fields)
+	if err != nil {
+		return "", err
+	}
+	return truncateSubject(subject), nil
+}
+
+// renderPlaceholders expands {placeholder} tokens in tmpl from fields;
+// placeholders without a value render as empty strings
+func renderPlaceholders(name, tmpl string, fields map[string]string) (string, error) {
+	t, err := template.New(name).Parse(placeholderRe.ReplaceAllString(tmpl, `{{index . "$1"}}`))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// truncateSubject collapses whitespace (SNS rejects line breaks in subjects) and
+// cuts at the last word boundary that keeps the subject within maxSubjectLen
+func truncateSubject(subject string) string {
+	subject = strings.Join(strings.Fields(subject), " ")
+	runes := []rune(subject)
+	if len(runes) <= maxSubjectLen {
+		return subject
+	}
+
+	const ellipsis = "..."
+	cut := string(runes[:maxSubjectLen-len(ellipsis)])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " -:[(") + ellipsis
+}
+
+// SubjectFor renders the configured subject template, or def when none is set.
+// An invalid configured template falls back to def so notifications still go out.
+func (c *SNSClient) SubjectFor(def string, fields map[string]string) string {
+	if c.subjectTemplate != "" {
+		subject, err := RenderSubject(c.subjectTemplate, fields)
+		if err == nil {
+			return subject
+		}
+		fmt.Printf("Falling back to default SNS subject: %v\n", err)
+	}
+
+	subject, err := RenderSubject(def, fields)
+	if err != nil {
+		return truncateSubject(def)
+	}
+	return subject
+}
+
 // SendAlert sends an alert notification via SNS
 // YOUR ORIGINAL CONTRIBUTION: Publish alert messages to SNS topic
 func (c *SNSClient) SendAlert(subject, message string) error {
 	input := &sns.PublishInput{
 		TopicArn: aws.String(c.topicArn),
-		Subject:  aws.String(subject),
+		Subject:  aws.String(truncateSubject(subject)),
 		Message:  aws.String(message),
 	}
 
@@ -55,7 +158,12 @@ func (c *SNSClient) SendAlert(subject, message string) error {
 // SendAnomalyAlert sends a specific alert for detected anomalies
 // YOUR ORIGINAL CONTRIBUTION: Format and send anomaly detection alerts
 func (c *SNSClient) SendAnomalyAlert(facilityID, meterID string, consumption, deviation float64) error {
-	subject := fmt.Sprintf("Energy Grid Alert: Anomaly Detected at %s", facilityID)
+	subject := c.SubjectFor(DefaultAnomalySubject, map[string]string{
+		"facility":  facilityID,
+		"equipment": meterID,
+		"severity":  "high",
+		"type":      "anomaly",
+	})
 	message := fmt.Sprintf(
 		"Anomaly Detection Alert\n\n"+
 			"Facility: %s\n"+
@@ -74,20 +182,67 @@ func (c *SNSClient) SendAnomalyAlert(facilityID, meterID string, consumption, de
 	return c.SendAlert(subject, message)
 }
 
-// SendMaintenanceAlert sends a predictive maintenance alert
+// MaintenanceFields are the placeholders of maintenance subjects and bodies:
+// {equipment}, {facility}, {severity}, {type}, {health}, {risk_30d},
+// {risk_90d} (percentages to two places), {service_date},
+// {days_until_service} (from when the prediction was computed) and
+// {recommendation}
+func MaintenanceFields(p domain.MaintenancePrediction) map[string]string {
+	days := int(p.NextServiceDate.Sub(p.ComputedAt).Hours() / 24)
+	return map[string]string{
+		"equipment":          p.EquipmentID,
+		"facility":           p.FacilityID,
+		"severity":           "high",
+		"type":               "maintenance",
+		"health":             fmt.Sprintf("%.2f", p.HealthScore),
+		"risk_30d":           fmt.Sprintf("%.2f", p.FailureRisk30Days),
+		"risk_90d":           fmt.Sprintf("%.2f", p.FailureRisk90Days),
+		"service_date":       p.NextServiceDate.Format("2006-01-02"),
+		"days_until_service": fmt.Sprintf("%d", days),
+		"recommendation":     p.Recommendation,
+	}
+}
+
+// MaintenanceMessage renders the maintenance alert body for p from the
+// configured template, or DefaultMaintenanceMessage when none is set or it
+// doesn't render
+func (c *SNSClient) MaintenanceMessage(p domain.MaintenancePrediction) string {
+	fields := MaintenanceFields(p)
+	if c.maintenanceTemplate != "" {
+		message, err := renderPlaceholders("maintenance message", c.maintenanceTemplate, fields)
+		if err == nil {
+			return message
+		}
+		fmt.Printf("Falling back to default maintenance message: %v\n", err)
+	}
+	message, _ := renderPlaceholders("maintenance message", DefaultMaintenanceMessage, fields)
+	return message
+}
+
+// SendMaintenanceAlert sends a predictive maintenance alert with the
+// prediction's failure risks, service date and recommendation
 // YOUR ORIGINAL CONTRIBUTION: Notify about equipment maintenance needs
-func (c *SNSClient) SendMaintenanceAlert(equipmentID string, healthScore float64, predictedDate time.Time) error {
-	subject := "Predictive Maintenance Alert"
-	message := fmt.Sprintf(
-		"Equipment Maintenance Required\n\n"+
-			"Equipment ID: %s\n"+
-			"Current Health Score: %.2f%%\n"+
-			"Predicted Maintenance Date: %s\n\n"+
-			"Please schedule maintenance to prevent failures.",
-		equipmentID,
-		healthScore,
-		predictedDate.Format("2006-01-02"),
-	)
+func (c *SNSClient) SendMaintenanceAlert(p domain.MaintenancePrediction) error {
+	subject := c.SubjectFor(DefaultMaintenanceSubject, MaintenanceFields(p))
+	return c.SendAlert(subject, c.MaintenanceMessage(p))
+}
+
+// SendMaintenanceDigest sends one notification listing a facility's high-risk equipment
+func (c *SNSClient) SendMaintenanceDigest(facilityID string, items []string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	subject := c.SubjectFor(DefaultMaintenanceSubject, map[string]string{
+		"facility": facilityID,
+		"severity": "high",
+		"type":     "maintenance",
+	})
+	message := fmt.Sprintf("Maintenance Digest for %s\n\n%d equipment item(s) need attention:\n\n", facilityID, len(items))
+	for i, item := range items {
+		message += fmt.Sprintf("%d. %s\n", i+1, item)
+	}
+	message += "\nPlease schedule maintenance to prevent failures."
 
 	return c.SendAlert(subject, message)
 }
@@ -100,11 +255,64 @@ func (c *SNSClient) SendBatchAlerts(alerts []string) error {
 	}
 
 	subject := fmt.Sprintf("Energy Grid: %d Alerts", len(alerts))
-	message := "Multiple Alerts Detected:\n\n"
+	message := "Multiple Alerts Detected:\n\n" + batchAlertList(alerts)
 
-	for i, alert := range alerts {
-		message += fmt.Sprintf("%d. %s\n", i+1, alert)
-	}
+	return c.SendAlert(subject, message)
+}
+
+// digestSeverityOrder lists digest sections most urgent first; other
+// severities follow alphabetically
+var digestSeverityOrder = []string{"critical", "high", "medium", "low"}
 
+// SendAlertDigest sends one notification summarizing buffered alerts, with a
+// numbered section per severity
+func (c *SNSClient) SendAlertDigest(bySeverity map[string][]string) error {
+	subject, message, ok := alertDigest(bySeverity)
+	if !ok {
+		return nil
+	}
 	return c.SendAlert(subject, message)
 }
+
+// alertDigest renders the digest subject and message; ok is false when there
+// are no alerts to send
+func alertDigest(bySeverity map[string][]string) (subject, message string, ok bool) {
+	total := 0
+	for _, alerts := range bySeverity {
+		total += len(alerts)
+	}
+	if total == 0 {
+		return "", "", false
+	}
+
+	var severities []string
+	for _, sev := range digestSeverityOrder {
+		if len(bySeverity[sev]) > 0 {
+			severities = append(severities, sev)
+		}
+	}
+	var rest []string
+	for sev, alerts := range bySeverity {
+		if len(alerts) > 0 && !slices.Contains(digestSeverityOrder, sev) {
+			rest = append(rest, sev)
+		}
+	}
+	sort.Strings(rest)
+	severities = append(severities, rest...)
+
+	subject = fmt.Sprintf("Energy Grid Digest: %d Alerts", total)
+	message = "Alert Digest:\n"
+	for _, sev := range severities {
+		message += fmt.Sprintf("\n%s (%d):\n", strings.ToUpper(sev), len(bySeverity[sev])) + batchAlertList(bySeverity[sev])
+	}
+	return subject, message, true
+}
+
+// batchAlertList renders alerts as a numbered list, one per line
+func batchAlertList(alerts []string) string {
+	var b strings.Builder
+	for i, alert := range alerts {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, alert)
+	}
+	return b.String()
+}
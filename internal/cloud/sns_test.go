@@ -0,0 +1,119 @@
+package cloud
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+func TestRenderSubject(t *testing.T) {
+	fields := map[string]string{"severity": "critical", "facility": "facility-001", "type": "anomaly"}
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{name: "placeholders", tmpl: "[{severity}] {type} at {facility}", want: "[critical] anomaly at facility-001"},
+		{name: "missing placeholder renders empty", tmpl: "{severity} {meter} alert", want: "critical alert"},
+		{name: "line breaks collapsed", tmpl: "{severity}\nalert\r\n{facility}", want: "critical alert facility-001"},
+		{
+			name: "truncated at a word boundary",
+			tmpl: "[{severity}] " + strings.Repeat("overload ", 20),
+			want: "[critical] " + strings.TrimSpace(strings.Repeat("overload ", 9)) + "...",
+		},
+		{name: "99 characters kept", tmpl: strings.Repeat("x", 99), want: strings.Repeat("x", 99)},
+		{name: "100 characters truncated", tmpl: strings.Repeat("x", 100), want: strings.Repeat("x", 96) + "..."},
+		{name: "invalid template", tmpl: "{{.Broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderSubject(tt.tmpl, fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderSubject = %q, want %q", got, tt.want)
+			}
+			if len([]rune(got)) >= 100 {
+				t.Errorf("subject is %d characters, over the SNS limit", len([]rune(got)))
+			}
+		})
+	}
+}
+
+func TestSubjectFor(t *testing.T) {
+	fields := map[string]string{"severity": "high", "type": "anomaly", "facility": "facility-001"}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "default", want: "[high] anomaly Alert"},
+		{name: "configured", template: "{facility}: {type}", want: "facility-001: anomaly"},
+		{name: "invalid configured template falls back", template: "{{.Broken", want: "[high] anomaly Alert"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &SNSClient{subjectTemplate: tt.template}
+			if got := c.SubjectFor(DefaultAlertSubject, fields); got != tt.want {
+				t.Errorf("SubjectFor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceMessage(t *testing.T) {
+	computed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := domain.MaintenancePrediction{
+		FacilityID: "facility-001", EquipmentID: "transformer-7", ComputedAt: computed,
+		HealthScore: 41.256, FailureRisk30Days: 37.5, FailureRisk90Days: 68.333,
+		NextServiceDate: computed.AddDate(0, 0, 12), Recommendation: "Schedule maintenance within 2 weeks",
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+		exact    string
+	}{
+		{
+			name: "default",
+			want: []string{"Equipment ID: transformer-7", "Current Health Score: 41.26%", "30-Day Failure Risk: 37.50%",
+				"90-Day Failure Risk: 68.33%", "Next Service Date: 2024-03-13 (in 12 days)", "Recommendation: Schedule maintenance within 2 weeks"},
+		},
+		{
+			name:     "configured",
+			template: "{equipment} at {facility}: {risk_30d}% / {risk_90d}%, service in {days_until_service} days. {recommendation}",
+			exact:    "transformer-7 at facility-001: 37.50% / 68.33%, service in 12 days. Schedule maintenance within 2 weeks",
+		},
+		{
+			name:     "invalid configured template falls back",
+			template: "{{.Broken",
+			want:     []string{"30-Day Failure Risk: 37.50%", "90-Day Failure Risk: 68.33%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &SNSClient{maintenanceTemplate: tt.template}
+			got := c.MaintenanceMessage(p)
+			if tt.exact != "" && got != tt.exact {
+				t.Errorf("message %q, want %q", got, tt.exact)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("message %q lacks %q", got, want)
+				}
+			}
+		})
+	}
+
+	// Maintenance alerts keep their high-severity subject
+	if got := (&SNSClient{}).SubjectFor(DefaultMaintenanceSubject, MaintenanceFields(p)); got != DefaultMaintenanceSubject {
+		t.Errorf("subject %q", got)
+	}
+	if got := (&SNSClient{subjectTemplate: "[{severity}] {type}: {equipment}"}).SubjectFor(DefaultMaintenanceSubject, MaintenanceFields(p)); got != "[high] maintenance: transformer-7" {
+		t.Errorf("configured subject %q", got)
+	}
+}
@@ -1,23 +1,158 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
 
 type Facility struct {
 	ID   int64  `db:"id" json:"id"`
 	Name string `db:"name" json:"name"`
+	// Timezone is an IANA zone name ("Europe/Berlin"); empty means UTC
+	Timezone string `db:"timezone" json:"timezone,omitempty"`
+}
+
+// Location returns the facility's time zone, falling back to UTC when it has
+// none or the name isn't a known zone
+func (f Facility) Location() *time.Location {
+	if f.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Meter lifecycle statuses. Only active meters are checked for anomalies and
+// reporting gaps; a commissioned meter is installed but not yet in service.
+const (
+	MeterActive         = "active"
+	MeterCommissioned   = "commissioned"
+	MeterDecommissioned = "decommissioned"
+)
+
+// meterTransitions lists the statuses each status may move to
+var meterTransitions = map[string][]string{
+	MeterCommissioned:   {MeterActive, MeterDecommissioned},
+	MeterActive:         {MeterDecommissioned},
+	MeterDecommissioned: {MeterCommissioned},
 }
 
 type Meter struct {
 	ID         int64  `db:"id" json:"id"`
 	FacilityID int64  `db:"facility_id" json:"facility_id"`
 	Serial     string `db:"serial" json:"serial"`
+	Zone       string `db:"zone" json:"zone,omitempty"`
+	Status     string `db:"status" json:"status"`
+
+	// Calibration applied to incoming readings; the defaults (gain 1, offset 0) leave them unchanged
+	VoltageGain float64 `db:"voltage_gain" json:"voltage_gain"`
+	PowerGain   float64 `db:"power_gain" json:"power_gain"`
+	PowerOffset float64 `db:"power_offset" json:"power_offset"`
+}
+
+// Active reports whether the meter is in service; meters without a status
+// predate the lifecycle and count as active
+func (m Meter) Active() bool {
+	return m.Status == "" || m.Status == MeterActive
+}
+
+// CanTransition reports whether the meter may move to status
+func (m Meter) CanTransition(status string) bool {
+	from := m.Status
+	if from == "" {
+		from = MeterActive
+	}
+	for _, to := range meterTransitions[from] {
+		if to == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Calibrated reports whether the meter has a non-identity calibration
+func (m Meter) Calibrated() bool {
+	return m.VoltageGain != 1 || m.PowerGain != 1 || m.PowerOffset != 0
 }
 
+// HourlyAggregate summarizes one hour of a facility's readings
+type HourlyAggregate struct {
+	Hour         time.Time `db:"hour" json:"hour"`
+	ReadingCount int       `db:"reading_count" json:"reading_count"`
+	TotalPower   float64   `db:"total_power" json:"total_power"`
+	AveragePower float64   `db:"avg_power" json:"average_power"`
+	PeakPower    float64   `db:"peak_power" json:"peak_power"`
+}
+
+// DailyAggregate summarizes a facility's readings for one day
+type DailyAggregate struct {
+	Date         time.Time         `json:"date"`
+	ReadingCount int               `json:"reading_count"`
+	TotalPower   float64           `json:"total_power"`
+	AveragePower float64           `json:"average_power"`
+	PeakPower    float64           `json:"peak_power"`
+	Hourly       []HourlyAggregate `json:"hourly"`
+	// MeterPeaks is each reporting meter's highest kW sample
+	MeterPeaks map[int64]float64 `json:"-"`
+}
+
+// MaintenancePrediction is a stored prediction from a maintenance run, kept
+// with the time it was computed so equipment risk can be trended
+type MaintenancePrediction struct {
+	FacilityID        string    `db:"facility_id" json:"facility_id"`
+	EquipmentID       string    `db:"equipment_id" json:"equipment_id"`
+	ComputedAt        time.Time `db:"computed_at" json:"computed_at"`
+	HealthScore       float64   `db:"health_score" json:"health_score"`
+	FailureRisk30Days float64   `db:"failure_risk_30_days" json:"failure_risk_30_days"`
+	FailureRisk90Days float64   `db:"failure_risk_90_days" json:"failure_risk_90_days"`
+	NextServiceDate   time.Time `db:"next_service_date" json:"next_service_date"`
+	Recommendation    string    `db:"recommendation" json:"recommendation"`
+	HighRisk          bool      `db:"high_risk" json:"high_risk"`
+}
+
+// ReadingPage is one page of a facility's readings within a query window.
+// NextCursor is empty on the last page; Truncated reports that more readings
+// exist in the window than were returned.
+type ReadingPage struct {
+	Readings    []Reading
+	WindowStart time.Time
+	WindowEnd   time.Time
+	Truncated   bool
+	NextCursor  string
+}
+
+// Reading sources, recording how a reading entered the system
+const (
+	SourceMQTT    = "mqtt"
+	SourceHTTP    = "http"
+	SourceBatch   = "batch"
+	SourceImport  = "import"
+	SourceKinesis = "kinesis"
+)
+
 type Reading struct {
-	ID        int64     `db:"id" json:"id"`
-	MeterID   int64     `db:"meter_id" json:"meter_id"`
-	Timestamp time.Time `db:"timestamp" json:"timestamp"`
-	Voltage   float64   `db:"voltage" json:"voltage"`
-	Current   float64   `db:"current" json:"current"`
-	PowerKW   float64   `db:"power_kw" json:"power_kw"`
+	ID        int64              `db:"id" json:"id"`
+	MeterID   int64              `db:"meter_id" json:"meter_id"`
+	Timestamp timeutil.Timestamp `db:"timestamp" json:"timestamp"`
+	Voltage   float64            `db:"voltage" json:"voltage"`
+	Current   float64            `db:"current" json:"current"`
+	PowerKW   float64            `db:"power_kw" json:"power_kw"`
+	Source    string             `db:"source" json:"source,omitempty"`
+
+	// Optional power-quality attributes reported by newer meters
+	ReactivePowerKVAR *float64 `db:"reactive_power_kvar" json:"reactive_power_kvar,omitempty"`
+	FrequencyHz       *float64 `db:"frequency_hz" json:"frequency_hz,omitempty"`
+	THDPercent        *float64 `db:"thd_percent" json:"thd_percent,omitempty"`
+
+	// Uncalibrated values as reported by the meter, set only when calibration changed them
+	RawVoltage *float64 `db:"raw_voltage" json:"raw_voltage,omitempty"`
+	RawPowerKW *float64 `db:"raw_power_kw" json:"raw_power_kw,omitempty"`
+
+	// MeterStatus is the meter's lifecycle status when it isn't active, so
+	// downstream checks can skip the reading; it isn't stored in Postgres
+	MeterStatus string `db:"-" json:"-"`
 }
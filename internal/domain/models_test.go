@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestMeterLifecycle(t *testing.T) {
+	tests := []struct {
+		from       string
+		to         string
+		wantActive bool // whether a meter in from is checked
+		want       bool
+	}{
+		{from: "", to: MeterDecommissioned, wantActive: true, want: true},
+		{from: "", to: MeterCommissioned, wantActive: true},
+		{from: MeterCommissioned, to: MeterActive, want: true},
+		{from: MeterCommissioned, to: MeterDecommissioned, want: true},
+		{from: MeterActive, to: MeterDecommissioned, wantActive: true, want: true},
+		{from: MeterActive, to: MeterCommissioned, wantActive: true},
+		{from: MeterActive, to: MeterActive, wantActive: true},
+		{from: MeterDecommissioned, to: MeterCommissioned, want: true},
+		{from: MeterDecommissioned, to: MeterActive},
+		{from: MeterActive, to: "retired", wantActive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			m := Meter{ID: 1, Status: tt.from}
+			if got := m.Active(); got != tt.wantActive {
+				t.Errorf("Active() = %v, want %v", got, tt.wantActive)
+			}
+			if got := m.CanTransition(tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q) = %v, want %v", tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,30 @@
 package http
 
 import (
+	"bufio"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
 	"github.com/gofiber/fiber/v2"
 )
 
 func Register(app *fiber.App, svcs *service.Services) {
 	g := app.Group("/")
+	// Confine facility API keys to their facility; a no-op without API_KEYS_FILE
+	g.Use(facilityScope(svcs.APIKeys, config.AdminAPIToken()))
 
 	// NEW: Root + health
 	g.Get("/", func(c *fiber.Ctx) error {
@@ -18,52 +33,164 @@ func Register(app *fiber.App, svcs *service.Services) {
 			"status":  "ok",
 			"endpoints": []string{
 				"/health",
+				"/health/ready",
 				"/facilities",
 				"/meters",
-				"/readings/recent?facility_id=facility-001&hours=24",
-				"/alerts?facility_id=facility-001",
+				"POST /meters/:id/commission | activate | decommission",
+				"/readings/recent?facility_id=facility-001&hours=24&expand=meter,facility&fields=timestamp,power_kw",
+				"/readings/one?facility_id=facility-001&meter_id=1&timestamp=",
+				"POST /readings?facility_id=facility-001",
+				"POST /readings/batch?facility_id=facility-001 (JSON array)",
+				"/metrics/readings",
+				"/metrics/cloud",
+				"POST /readings/import?facility_id=facility-001 (multipart CSV field \"file\")",
+				"/alerts?facility_id=facility-001&severity=&equipment_id=&from=&to=&fields=&limit=&cursor=",
+				"POST /alerts",
+				"/alerts/stream?facility_id=facility-001 (text/event-stream)",
 				"/alerts/:alert_id/acknowledge",
+				"/alerts/:alert_id/explain",
+				"DELETE /alerts/:alert_id",
 				"/analytics/generate",
+				"/analytics?facility_id=facility-001&from=YYYY-MM-DD&to=YYYY-MM-DD",
+				"/analytics/range?facility_id=facility-001&from=YYYY-MM-DD&to=YYYY-MM-DD",
+				"/analytics/trends?facility_id=facility-001&from=YYYY-MM-DD&to=YYYY-MM-DD&window=7&sigma=3",
+				"/analytics/summary?facility_id=facility-001&date=YYYY-MM-DD&meter_id=",
+				"/analytics/zones?facility_id=facility-001&date=YYYY-MM-DD",
+				"/analytics/chart?facility_id=facility-001&date=YYYY-MM-DD",
+				"POST /analytics/simulate-cost",
+				"POST /analytics/monthly-bill",
 				"/readings/check-anomaly",
+				"/equipment/:id/maintenance",
+				"POST /facilities/:id/maintenance/run",
+				"POST /facilities/:id/alerts/archive",
+				"POST /admin/anomaly/reprocess (Authorization: Bearer ADMIN_API_TOKEN)",
+				"DELETE /admin/alerts/:alert_id (Authorization: Bearer ADMIN_API_TOKEN)",
+				"/admin/lambda/status (Authorization: Bearer ADMIN_API_TOKEN)",
+				"POST /admin/lambda/invoke (Authorization: Bearer ADMIN_API_TOKEN)",
 			},
 		})
 	})
 	g.Get("health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok", "time": time.Now().UTC()})
 	})
+	// Readiness: 503 while the cloud store's circuit breaker is open
+	g.Get("health/ready", func(c *fiber.Ctx) error {
+		stats, ok := svcs.BreakerStats()
+		if !ok {
+			return c.JSON(fiber.Map{"status": "ready", "time": time.Now().UTC()})
+		}
+		if stats.State == service.BreakerOpen {
+			return c.Status(503).JSON(fiber.Map{"status": "unavailable", "time": time.Now().UTC(), "breaker": stats})
+		}
+		return c.JSON(fiber.Map{"status": "ready", "time": time.Now().UTC(), "breaker": stats})
+	})
 	// NEW: Predictive maintenance endpoint
 	g.Get("equipment/:id/maintenance", func(c *fiber.Ctx) error {
 		equipmentID := c.Params("id")
 
-		prediction, err := svcs.Maintenance.PredictMaintenanceNeeds(equipmentID)
+		var prediction *service.MaintenancePrediction
+		var err error
+		if scope, ok := c.Locals(scopeLocal).(string); ok {
+			prediction, err = svcs.Maintenance.PredictFacilityMaintenance(scope, equipmentID)
+		} else {
+			prediction, err = svcs.Maintenance.PredictMaintenanceNeeds(equipmentID)
+		}
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		return c.JSON(prediction)
 	})
+	// Recompute predictions for a facility, store them and send one digest of
+	// high-risk equipment; suitable for a nightly scheduled trigger
+	g.Post("facilities/:id/maintenance/run", func(c *fiber.Ctx) error {
+		run, err := svcs.Maintenance.RunFacility(c.Params("id"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(run)
+	})
+	// Archive the facility's closed alerts to S3 and start their retention
+	// countdown; suitable for a nightly scheduled trigger
+	g.Post("facilities/:id/alerts/archive", func(c *fiber.Ctx) error {
+		run, err := svcs.Alerts.ArchiveAlerts(c.Params("id"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(run)
+	})
 	// Existing handlers
+	// Facilities and meters are paged by id: pass ?limit=N and the previous
+	// response's "next" value as ?cursor= to fetch the following page
 	g.Get("facilities", func(c *fiber.Ctx) error {
-		items, err := svcs.Repos.ListFacilities()
+		cursor, err := pageCursor(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		items, next, err := svcs.Repos.ListFacilities(cursor, c.QueryInt("limit", repository.DefaultPageSize))
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
-		return c.JSON(items)
+		return c.JSON(fiber.Map{
+			"count":      len(items),
+			"facilities": items,
+			"next":       nextCursor(next),
+		})
 	})
 
 	g.Get("meters", func(c *fiber.Ctx) error {
-		items, err := svcs.Repos.ListMeters()
+		cursor, err := pageCursor(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		items, next, err := svcs.Repos.ListMeters(cursor, c.QueryInt("limit", repository.DefaultPageSize))
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
-		return c.JSON(items)
+		return c.JSON(fiber.Map{
+			"count":  len(items),
+			"meters": items,
+			"next":   nextCursor(next),
+		})
 	})
 
+	// Meter lifecycle: only active meters are checked for anomalies and
+	// reporting gaps
+	for action, status := range map[string]string{
+		"commission":   domain.MeterCommissioned,
+		"activate":     domain.MeterActive,
+		"decommission": domain.MeterDecommissioned,
+	} {
+		g.Post("meters/:id/"+action, func(c *fiber.Ctx) error {
+			id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "meter id must be an integer"})
+			}
+
+			meter, err := svcs.SetMeterStatus(id, status)
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(404).JSON(fiber.Map{"error": "meter not found"})
+			}
+			if errors.Is(err, service.ErrInvalidMeterTransition) {
+				return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+			}
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.JSON(meter)
+		})
+	}
+
 	// Trigger daily analytics via Lambda
 	g.Post("analytics/generate", func(c *fiber.Ctx) error {
 		type Request struct {
 			FacilityID string `json:"facility_id"`
-			Date       string `json:"date"` // YYYY-MM-DD (UTC)
+			Date       string `json:"date"`   // YYYY-MM-DD (UTC)
+			Format     string `json:"format"` // json (default) or pdf
+
+			GranularityMinutes int `json:"granularity_minutes"` // 15, 30 or 60 (default)
 		}
 
 		var req Request
@@ -71,6 +198,11 @@ func Register(app *fiber.App, svcs *service.Services) {
 			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
 		}
 
+		facilityID, err := scopedFacility(c, req.FacilityID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.FacilityID = facilityID
 		if req.FacilityID == "" {
 			req.FacilityID = "facility-001"
 		}
@@ -79,13 +211,16 @@ func Register(app *fiber.App, svcs *service.Services) {
 			req.Date = time.Now().UTC().Format("2006-01-02")
 		}
 
-		reportURL, err := svcs.Analytics.GenerateDailyReport(req.FacilityID, req.Date)
+		report, err := svcs.Analytics.GenerateDailyAnalytics(req.FacilityID, req.Date, strings.ToLower(req.Format), req.GranularityMinutes)
+		if errors.Is(err, service.ErrInvalidReportFormat) || errors.Is(err, service.ErrInvalidGranularity) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error(), "date": req.Date})
 		}
 
 		// If Lambda returned no URL, surface a helpful message
-		if reportURL == "" {
+		if report.ReportURL == "" {
 			return c.Status(200).JSON(fiber.Map{
 				"message":  "Analytics processed, but no report URL returned (likely no data for the date).",
 				"date":     req.Date,
@@ -95,59 +230,530 @@ func Register(app *fiber.App, svcs *service.Services) {
 
 		return c.JSON(fiber.Map{
 			"message":    "Analytics generated successfully",
-			"report_url": reportURL,
+			"report_url": report.ReportURL,
 			"date":       req.Date,
 			"facility":   req.FacilityID,
+			"analytics":  report.Analytics,
 		})
 	})
 
+	// Stored daily summaries from the analytics Lambda, for trend charts
+	g.Get("analytics", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		// Defaults to the 30 days ending yesterday, the latest day the Lambda has run for
+		today := time.Now().In(svcs.Timezones.Location(facilityID))
+		to := time.Date(today.Year(), today.Month(), today.Day()-1, 0, 0, 0, 0, time.UTC)
+		if raw := c.Query("to"); raw != "" {
+			d, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "to must be YYYY-MM-DD"})
+			}
+			to = d
+		}
+		from := to.AddDate(0, 0, -29)
+		if raw := c.Query("from"); raw != "" {
+			d, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "from must be YYYY-MM-DD"})
+			}
+			from = d
+		}
+
+		series, err := svcs.Analytics.GetStoredSummaries(facilityID, from, to)
+		if errors.Is(err, service.ErrInvalidSummaryRange) || errors.Is(err, service.ErrQueryRangeTooLarge) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(series)
+	})
+
+	// Every day of from..to with its summary, generating missing past days on demand
+	g.Get("analytics/range", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		from, err := time.Parse("2006-01-02", c.Query("from"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "from must be YYYY-MM-DD"})
+		}
+		to, err := time.Parse("2006-01-02", c.Query("to"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "to must be YYYY-MM-DD"})
+		}
+
+		series, err := svcs.Analytics.GetSummaryRange(facilityID, from, to)
+		if errors.Is(err, service.ErrInvalidSummaryRange) || errors.Is(err, service.ErrQueryRangeTooLarge) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(series)
+	})
+
+	// Days in the stored summaries whose consumption or cost breaks from the
+	// trailing window; same from/to defaults as /analytics
+	g.Get("analytics/trends", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		today := time.Now().In(svcs.Timezones.Location(facilityID))
+		to := time.Date(today.Year(), today.Month(), today.Day()-1, 0, 0, 0, 0, time.UTC)
+		if raw := c.Query("to"); raw != "" {
+			d, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "to must be YYYY-MM-DD"})
+			}
+			to = d
+		}
+		from := to.AddDate(0, 0, -29)
+		if raw := c.Query("from"); raw != "" {
+			d, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "from must be YYYY-MM-DD"})
+			}
+			from = d
+		}
+		window := c.QueryInt("window", service.DefaultTrendWindow)
+		sigma := c.QueryFloat("sigma", service.DefaultTrendSigma)
+
+		report, err := svcs.Analytics.GetTrendAnomalies(facilityID, from, to, window, sigma)
+		if errors.Is(err, service.ErrInvalidSummaryRange) || errors.Is(err, service.ErrQueryRangeTooLarge) || errors.Is(err, service.ErrInvalidTrendQuery) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(report)
+	})
+
+	// Daily consumption summary (aggregated in SQL on the postgres backend)
+	g.Get("analytics/summary", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		date, err := queryDate(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var meterID int64
+		if raw := c.Query("meter_id"); raw != "" {
+			if meterID, err = strconv.ParseInt(raw, 10, 64); err != nil || meterID <= 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "meter_id must be a positive integer"})
+			}
+		}
+
+		summary, err := svcs.Analytics.GetDailySummary(facilityID, meterID, date)
+		if err != nil {
+			if errors.Is(err, service.ErrMeterRequired) {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		setCacheControl(c, dayEnd(svcs, facilityID, date))
+		return c.JSON(summary)
+	})
+
+	// Zone-level rollup of a day's readings (meters grouped by their zone)
+	g.Get("analytics/zones", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		date, err := queryDate(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		report, err := svcs.Analytics.GetZoneSummaries(facilityID, date)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		setCacheControl(c, dayEnd(svcs, facilityID, date))
+		return c.JSON(report)
+	})
+
+	// Chart.js-ready series ({labels, datasets}) for the dashboard analytics page
+	g.Get("analytics/chart", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+		date, err := queryDate(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		chart, err := svcs.Analytics.GetChartData(facilityID, date)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		setCacheControl(c, dayEnd(svcs, facilityID, date))
+		return c.JSON(chart)
+	})
+
+	// What-if: recompute a day's cost with part of the peak load moved off-peak
+	g.Post("analytics/simulate-cost", func(c *fiber.Ctx) error {
+		type Request struct {
+			FacilityID string              `json:"facility_id"`
+			Date       string              `json:"date"` // YYYY-MM-DD
+			Schedule   *costmodel.Schedule `json:"schedule"`
+			Shift      service.LoadShift   `json:"shift"`
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		facilityID, err := scopedFacility(c, req.FacilityID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.FacilityID = facilityID
+		if req.FacilityID == "" {
+			req.FacilityID = "facility-001"
+		}
+		date := time.Now().UTC().Truncate(24 * time.Hour)
+		if req.Date != "" {
+			d, err := time.Parse("2006-01-02", req.Date)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "date must be YYYY-MM-DD"})
+			}
+			date = d
+		}
+		sim, err := svcs.Analytics.SimulateCost(req.FacilityID, date, req.Schedule, req.Shift)
+		if errors.Is(err, service.ErrInvalidSimulation) || errors.Is(err, costmodel.ErrInvalidModel) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(sim)
+	})
+
 	// Get recent readings from DynamoDB
+	// Invoice-style bill for a facility-local month, stored in S3 when configured
+	g.Post("analytics/monthly-bill", func(c *fiber.Ctx) error {
+		type Request struct {
+			FacilityID string `json:"facility_id"`
+			Month      string `json:"month"` // YYYY-MM
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		facilityID, err := scopedFacility(c, req.FacilityID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.FacilityID = facilityID
+		if req.FacilityID == "" {
+			req.FacilityID = "facility-001"
+		}
+		if req.Month == "" {
+			req.Month = time.Now().In(svcs.Timezones.Location(req.FacilityID)).Format("2006-01")
+		}
+
+		bill, err := svcs.Analytics.GenerateMonthlyBill(req.FacilityID, req.Month)
+		if errors.Is(err, service.ErrInvalidBillMonth) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(bill)
+	})
+
 	g.Get("readings/recent", func(c *fiber.Ctx) error {
 		facilityID := c.Query("facility_id", "facility-001")
 		hours := c.QueryInt("hours", 24)
+		// ?limit= caps the page size; without it a page is bounded by the backend
+		limit := 0
+		if c.Query("limit") != "" {
+			limit = repository.ClampPageSize(c.QueryInt("limit"))
+		}
 
-		readings, err := svcs.Readings.GetRecentReadings(facilityID, time.Duration(hours)*time.Hour)
+		// ?fields=timestamp,power_kw reads and returns only those fields
+		fields, err := cloud.ReadingProjection(c.Query("fields"))
 		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		page, err := svcs.Readings.GetRecentReadingsPage(facilityID, time.Duration(hours)*time.Hour, c.Query("cursor"), limit, fields)
+		if errors.Is(err, service.ErrQueryRangeTooLarge) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(storeErrStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		readings := page.Readings
+		setCacheControl(c, page.WindowEnd)
+
+		// ?expand=meter,facility joins names and quality adds each reading's
+		// power-quality score; the default response stays lean
+		var body interface{} = readings
+		if expand := service.ParseExpand(c.Query("expand")); expand.Any() {
+			body = svcs.Enricher.Readings(facilityID, readings, expand)
+		}
+		if body, err = selectFields(body, fields); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		return c.JSON(fiber.Map{
+			"facility_id":  facilityID,
+			"hours":        hours,
+			"count":        len(readings),
+			"readings":     body,
+			"window_start": page.WindowStart.UTC(),
+			"window_end":   page.WindowEnd.UTC(),
+			"timezone":     svcs.Timezones.Location(facilityID).String(),
+			"truncated":    page.Truncated,
+			"next_cursor":  page.NextCursor,
+		})
+	})
+
+	// One stored reading with derived apparent power, power factor and quality
+	g.Get("readings/one", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id")
+		meterID, err := strconv.ParseInt(c.Query("meter_id"), 10, 64)
+		if facilityID == "" || err != nil || c.Query("timestamp") == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "facility_id, numeric meter_id and timestamp are required"})
+		}
+		ts, err := timeutil.ParseTimestamp(c.Query("timestamp"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		detail, err := svcs.Readings.GetReading(facilityID, meterID, ts)
+		if errors.Is(err, service.ErrReadingNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(storeErrStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		return c.JSON(fiber.Map{
 			"facility_id": facilityID,
-			"hours":       hours,
-			"count":       len(readings),
-			"readings":    readings,
+			"reading":     detail,
 		})
 	})
 
+	// Ingest a single reading (same JSON payload as MQTT)
+	g.Post("readings", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+
+		if err := svcs.Readings.FromHTTP(facilityID, c.Body()); errors.Is(err, service.ErrCircuitOpen) {
+			return c.Status(503).JSON(fiber.Map{"error": err.Error()})
+		} else if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"message":     "Reading stored",
+			"facility_id": facilityID,
+		})
+	})
+
+	// Store a JSON array of readings in one request
+	g.Post("readings/batch", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+
+		n, err := svcs.Readings.FromHTTPBatch(facilityID, c.Body())
+		if errors.Is(err, service.ErrCircuitOpen) {
+			return c.Status(503).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"message":     "Readings stored",
+			"facility_id": facilityID,
+			"count":       n,
+		})
+	})
+
+	// Readings stored per ingestion source since the process started
+	g.Get("metrics/readings", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"readings_by_source": svcs.Readings.SourceCounts()})
+	})
+
+	// Circuit breaker state and counters of the cloud store; null without one
+	g.Get("metrics/cloud", func(c *fiber.Ctx) error {
+		stats, ok := svcs.BreakerStats()
+		if !ok {
+			return c.JSON(fiber.Map{"breaker": nil})
+		}
+		return c.JSON(fiber.Map{"breaker": stats})
+	})
+
+	// Import readings from a CSV upload (multipart field "file") with a header
+	// row timestamp,meter_id,voltage,current,power_kw
+	g.Post("readings/import", func(c *fiber.Ctx) error {
+		facilityID := c.Query("facility_id", "facility-001")
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "multipart field \"file\" is required"})
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		defer f.Close()
+
+		result, err := svcs.Readings.ImportCSV(facilityID, f)
+		if err != nil {
+			status := 400
+			if result != nil {
+				// Rows before the failure were stored; report them with the error
+				status = 500
+			}
+			return c.Status(status).JSON(fiber.Map{"error": err.Error(), "result": result})
+		}
+
+		return c.JSON(fiber.Map{
+			"facility_id": facilityID,
+			"imported":    result.Imported,
+			"rejected":    result.Rejected,
+			"errors":      result.Errors,
+		})
+	})
+
+	// Create an alert
+	g.Post("alerts", func(c *fiber.Ctx) error {
+		type Request struct {
+			FacilityID  string `json:"facility_id"`
+			EquipmentID string `json:"equipment_id"`
+			Severity    string `json:"severity"`
+			Type        string `json:"type"`
+			Message     string `json:"message"`
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+
+		facilityID, err := scopedFacility(c, req.FacilityID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.FacilityID = facilityID
+		if req.FacilityID == "" {
+			req.FacilityID = "facility-001"
+		}
+		if req.Severity == "" || req.Type == "" || req.Message == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "severity, type and message are required"})
+		}
+
+		if err := svcs.Alerts.CreateAlert(req.FacilityID, req.EquipmentID, req.Severity, req.Type, req.Message); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"message":     "Alert created",
+			"facility_id": req.FacilityID,
+		})
+	})
+
+	// Server-Sent Events stream of the alerts this API creates for a facility;
+	// a heartbeat comment keeps idle connections (and proxies) open
+	g.Get("alerts/stream", func(c *fiber.Ctx) error {
+		const heartbeat = 15 * time.Second
+		facilityID := c.Query("facility_id", "facility-001")
+
+		alerts, cancel := svcs.Alerts.SubscribeAlerts(facilityID)
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+
+			fmt.Fprintf(w, ": streaming alerts for %s\n\n", facilityID)
+			if w.Flush() != nil {
+				return
+			}
+			for {
+				select {
+				case n := <-alerts:
+					data, err := json.Marshal(n)
+					if err != nil {
+						continue
+					}
+					fmt.Fprintf(w, "event: alert\ndata: %s\n\n", data)
+				case <-ticker.C:
+					fmt.Fprint(w, ": heartbeat\n\n")
+				}
+				// A failed flush means the client has gone away
+				if w.Flush() != nil {
+					return
+				}
+			}
+		})
+		return nil
+	})
+
 	// Get alerts from DynamoDB
 	g.Get("alerts", func(c *fiber.Ctx) error {
 		facilityID := c.Query("facility_id", "facility-001")
 		severity := c.Query("severity", "")
+		equipmentID := c.Query("equipment_id", "")
 
-		var severityPtr *string
-		if severity != "" {
-			severityPtr = &severity
+		filter := cloud.AlertFilter{Severity: severity, EquipmentID: equipmentID, IncludeDismissed: c.QueryBool("include_dismissed")}
+		var err error
+		if filter.Since, err = queryEpoch(c, "from"); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if filter.Until, err = queryEpoch(c, "to"); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if filter.Fields, err = cloud.AlertProjection(c.Query("fields")); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		alerts, err := svcs.Alerts.GetAlerts(facilityID, severityPtr)
+		alerts, err := svcs.Alerts.GetAlerts(facilityID, filter)
 		if err != nil {
+			return c.Status(storeErrStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// ?limit= pages the listing; pass the response's next_cursor as ?cursor=
+		// for the following page
+		next := ""
+		if c.Query("limit") != "" || c.Query("cursor") != "" {
+			alerts, next, err = service.PageAlerts(alerts, c.Query("cursor"), repository.ClampPageSize(c.QueryInt("limit")))
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		var body interface{} = alerts
+		if expand := service.ParseExpand(c.Query("expand")); expand.Any() {
+			body = svcs.Enricher.Alerts(alerts, expand)
+		}
+		if body, err = selectFields(body, filter.Fields); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		return c.JSON(fiber.Map{
-			"facility_id": facilityID,
-			"severity":    severity,
-			"count":       len(alerts),
-			"alerts":      alerts,
+			"facility_id":  facilityID,
+			"severity":     severity,
+			"equipment_id": equipmentID,
+			"count":        len(alerts),
+			"alerts":       body,
+			"next_cursor":  next,
 		})
 	})
 
 	// Acknowledge an alert
 	g.Post("alerts/:alert_id/acknowledge", func(c *fiber.Ctx) error {
 		alertID := c.Params("alert_id")
+		if status, err := alertInScope(c, svcs, alertID); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 
-		if err := svcs.Alerts.AcknowledgeAlert(alertID); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		err := svcs.Alerts.AcknowledgeAlert(alertID)
+		if errors.Is(err, cloud.ErrAlertNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(storeErrStatus(err)).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		return c.JSON(fiber.Map{
@@ -156,6 +762,56 @@ func Register(app *fiber.App, svcs *service.Services) {
 		})
 	})
 
+	// Why an alert fired: the detector's figures for anomaly alerts, with the
+	// meter's readings around it
+	g.Get("alerts/:alert_id/explain", func(c *fiber.Ctx) error {
+		if status, err := alertInScope(c, svcs, c.Params("alert_id")); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		ex, err := svcs.ExplainAlert(c.Params("alert_id"))
+		if errors.Is(err, cloud.ErrAlertNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(ex)
+	})
+
+	// Dismiss an alert (soft delete); it stays stored but is hidden from GET /alerts
+	g.Delete("alerts/:alert_id", func(c *fiber.Ctx) error {
+		type Request struct {
+			DismissedBy string `json:"dismissed_by"`
+			Reason      string `json:"reason"`
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		if req.DismissedBy == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "dismissed_by is required"})
+		}
+
+		alertID := c.Params("alert_id")
+		if status, err := alertInScope(c, svcs, alertID); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		err := svcs.Alerts.DismissAlert(alertID, req.DismissedBy, req.Reason)
+		if errors.Is(err, cloud.ErrAlertNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":      "Alert dismissed",
+			"alert_id":     alertID,
+			"dismissed_by": req.DismissedBy,
+		})
+	})
+
 	// Trigger anomaly detection manually
 	g.Post("readings/check-anomaly", func(c *fiber.Ctx) error {
 		type Request struct {
@@ -171,14 +827,28 @@ func Register(app *fiber.App, svcs *service.Services) {
 			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
 		}
 
+		facilityID, err := scopedFacility(c, req.FacilityID)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		req.FacilityID = facilityID
+
 		if !svcs.UseCloud || svcs.Lambda == nil {
 			return c.Status(503).JSON(fiber.Map{"error": "Cloud services not enabled"})
 		}
 
+		if id, err := strconv.ParseInt(req.MeterID, 10, 64); err == nil {
+			if status := svcs.Readings.MeterStatus(id); status != "" {
+				return c.JSON(fiber.Map{
+					"message": fmt.Sprintf("Meter is %s; anomaly detection skipped", status),
+				})
+			}
+		}
+
 		payload := cloud.AnomalyDetectionPayload{
 			FacilityID: req.FacilityID,
 			MeterID:    req.MeterID,
-			Timestamp:  time.Now().Unix(),
+			Timestamp:  timeutil.Now(),
 			Voltage:    req.Voltage,
 			Current:    req.Current,
 			PowerKW:    req.PowerKW,
@@ -195,6 +865,118 @@ func Register(app *fiber.App, svcs *service.Services) {
 		})
 	})
 
+	// Admin: rerun anomaly detection for a stored reading (backfill after a fix)
+	admin := g.Group("admin", requireAdminToken(config.AdminAPIToken()))
+	admin.Post("anomaly/reprocess", func(c *fiber.Ctx) error {
+		type Request struct {
+			FacilityID string `json:"facility_id"`
+			MeterID    string `json:"meter_id"`
+			Timestamp  int64  `json:"timestamp"` // epoch seconds, as stored
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		if req.FacilityID == "" || req.MeterID == "" || req.Timestamp == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "facility_id, meter_id and timestamp are required"})
+		}
+
+		err := svcs.ReprocessAnomaly(req.FacilityID, req.MeterID, req.Timestamp)
+		if errors.Is(err, service.ErrReadingNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":     "Reading reprocessed",
+			"facility_id": req.FacilityID,
+			"meter_id":    req.MeterID,
+			"timestamp":   req.Timestamp,
+		})
+	})
+
+	// Admin: delete an alert permanently instead of dismissing it
+	admin.Delete("alerts/:alert_id", func(c *fiber.Ctx) error {
+		alertID := c.Params("alert_id")
+		err := svcs.Alerts.DeleteAlert(alertID)
+		if errors.Is(err, cloud.ErrAlertNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":  "Alert deleted",
+			"alert_id": alertID,
+		})
+	})
+
+	// Admin: deployed version and state of the API's Lambda functions
+	admin.Get("lambda/status", func(c *fiber.Ctx) error {
+		if !svcs.UseCloud || svcs.Lambda == nil {
+			return c.Status(503).JSON(fiber.Map{"error": "Cloud services not enabled"})
+		}
+		functions, err := svcs.LambdaStatus()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"functions": functions})
+	})
+
+	// Admin: run the analytics Lambda for a facility and date by hand
+	admin.Post("lambda/invoke", func(c *fiber.Ctx) error {
+		type Request struct {
+			FacilityID string `json:"facility_id"`
+			Date       string `json:"date"`  // YYYY-MM-DD; empty is the Lambda's default (yesterday)
+			Async      bool   `json:"async"` // return once the run is queued
+		}
+
+		var req Request
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		}
+		if req.FacilityID == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "facility_id is required"})
+		}
+		if req.Date != "" {
+			if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "date must be YYYY-MM-DD"})
+			}
+		}
+		if !svcs.UseCloud || svcs.Lambda == nil {
+			return c.Status(503).JSON(fiber.Map{"error": "Cloud services not enabled"})
+		}
+
+		if req.Async {
+			if err := svcs.Analytics.TriggerDailyAnalytics(req.FacilityID, req.Date); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(202).JSON(fiber.Map{
+				"message":     "Analytics run queued",
+				"function":    cloud.AnalyticsProcessingFunction,
+				"facility_id": req.FacilityID,
+				"date":        req.Date,
+			})
+		}
+
+		report, err := svcs.Analytics.GenerateDailyAnalytics(req.FacilityID, req.Date, "", 0)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"message":     "Analytics run completed",
+			"function":    cloud.AnalyticsProcessingFunction,
+			"facility_id": req.FacilityID,
+			"date":        req.Date,
+			"report_url":  report.ReportURL,
+			"analytics":   report.Analytics,
+		})
+	})
+
 	// NEW: Friendly 404
 	app.Use(func(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{
@@ -203,3 +985,130 @@ func Register(app *fiber.App, svcs *service.Services) {
 		})
 	})
 }
+
+// requireAdminToken only lets requests carrying "Authorization: Bearer <token>"
+// through. With no token configured the admin endpoints are disabled.
+func requireAdminToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Status(403).JSON(fiber.Map{"error": "admin endpoints are disabled (ADMIN_API_TOKEN not set)"})
+		}
+		got := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		return c.Next()
+	}
+}
+
+// storeErrStatus is 503 for a store call refused by the open circuit breaker, 500 otherwise
+func storeErrStatus(err error) int {
+	if errors.Is(err, service.ErrCircuitOpen) {
+		return 503
+	}
+	return 500
+}
+
+// Cache policies: live windows may change on the next reading, while a day
+// that ended more than cacheSettleAfter ago (leaving room for late and
+// redelivered readings) only changes through a CSV or batch import. Imports can
+// backfill any day, so no window is immutable. Responses are scoped by API key,
+// so only the client's own cache may keep them.
+const (
+	liveCacheControl    = "private, max-age=30"
+	settledCacheControl = "private, max-age=3600"
+	cacheSettleAfter    = time.Hour
+	cacheVary           = apiKeyHeader + ", " + fiber.HeaderAuthorization
+)
+
+// setCacheControl sets Cache-Control for a response covering data up to windowEnd
+func setCacheControl(c *fiber.Ctx, windowEnd time.Time) {
+	c.Set(fiber.HeaderVary, cacheVary)
+	if time.Since(windowEnd) > cacheSettleAfter {
+		c.Set(fiber.HeaderCacheControl, settledCacheControl)
+		return
+	}
+	c.Set(fiber.HeaderCacheControl, liveCacheControl)
+}
+
+// dayEnd is when the facility-local day of date ends
+func dayEnd(svcs *service.Services, facilityID string, date time.Time) time.Time {
+	_, end := svcs.Timezones.DayWindow(facilityID, date)
+	return end
+}
+
+// queryDate parses the ?date=YYYY-MM-DD query param, defaulting to today (UTC)
+func queryDate(c *fiber.Ctx) (time.Time, error) {
+	raw := c.Query("date")
+	if raw == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// queryEpoch parses an optional RFC3339 or epoch timestamp query param into
+// epoch seconds; absent yields 0
+func queryEpoch(c *fiber.Ctx, key string) (int64, error) {
+	t, err := timeutil.ParseTimestamp(c.Query(key))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if t.IsZero() {
+		return 0, nil
+	}
+	return t.Unix(), nil
+}
+
+// pageCursor parses the ?cursor= query param of a paged listing
+func pageCursor(c *fiber.Ctx) (int64, error) {
+	raw := c.Query("cursor")
+	if raw == "" {
+		return 0, nil
+	}
+	cursor, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || cursor < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", raw)
+	}
+	return cursor, nil
+}
+
+// nextCursor renders the cursor for the following page, empty on the last page
+func nextCursor(next int64) string {
+	if next == 0 {
+		return ""
+	}
+	return strconv.FormatInt(next, 10)
+}
+
+// selectFields trims a list response to the projection's keys, keeping the
+// names ?expand= joined in. With an empty projection items are returned as-is.
+func selectFields(items interface{}, fields cloud.Projection) (interface{}, error) {
+	if fields.Empty() {
+		return items, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{"meter_serial": true, "facility_name": true, "quality_score": true, "quality_flags": true}
+	for _, k := range fields.Keys() {
+		keep[k] = true
+	}
+	for _, row := range rows {
+		for k := range row {
+			if !keep[k] {
+				delete(row, k)
+			}
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,579 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
+)
+
+func TestSetCacheControl(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		windowEnd time.Time
+		want      string
+	}{
+		{name: "current day", windowEnd: now.Add(6 * time.Hour), want: liveCacheControl},
+		{name: "ended within the settle margin", windowEnd: now.Add(-30 * time.Minute), want: liveCacheControl},
+		{name: "past day", windowEnd: now.Add(-48 * time.Hour), want: settledCacheControl},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				setCacheControl(c, tt.windowEnd)
+				return c.SendStatus(200)
+			})
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Header.Get(fiber.HeaderCacheControl); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get(fiber.HeaderVary); got != "X-API-Key, Authorization" {
+				t.Errorf("Vary = %q", got)
+			}
+		})
+	}
+
+	if liveCacheControl == settledCacheControl {
+		t.Error("past and current days share a cache policy")
+	}
+	for _, policy := range []string{liveCacheControl, settledCacheControl} {
+		if !containsDirective(policy, "private") || containsDirective(policy, "immutable") || containsDirective(policy, "public") {
+			t.Errorf("policy %q must be private and not immutable", policy)
+		}
+	}
+}
+
+func containsDirective(policy, directive string) bool {
+	for _, d := range strings.Split(policy, ",") {
+		if strings.TrimSpace(d) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryServer serves the API over the in-memory backend on a local port
+func memoryServer(t *testing.T) (*service.Services, string) {
+	t.Helper()
+	t.Setenv("STORAGE_BACKEND", config.BackendMemory)
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	svcs, err := service.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	Register(app, svcs)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	// Streams only notice a closed client on their next heartbeat; don't wait for it
+	t.Cleanup(func() { app.ShutdownWithTimeout(100 * time.Millisecond) })
+	return svcs, "http://" + ln.Addr().String()
+}
+
+func TestAlertStream(t *testing.T) {
+	svcs, url := memoryServer(t)
+	resp, err := http.Get(url + "/alerts/stream?facility_id=facility-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		sc := bufio.NewScanner(resp.Body)
+		var event []string
+		for sc.Scan() {
+			if sc.Text() != "" {
+				event = append(event, sc.Text())
+				continue
+			}
+			events <- strings.Join(event, "\n")
+			event = nil
+		}
+	}()
+	next := func() string {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event within 5s")
+			return ""
+		}
+	}
+	// The opening comment means the subscription is in place
+	if got := next(); got != ": streaming alerts for facility-001" {
+		t.Fatalf("first event %q", got)
+	}
+
+	// Only the subscribed facility's alerts are streamed
+	if err := svcs.Alerts.CreateAlert("facility-002", "meter-9", "low", "anomaly", "elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svcs.Alerts.CreateAlert("facility-001", "meter-1", "high", "anomaly", "Power 40 kW"); err != nil {
+		t.Fatal(err)
+	}
+	got := next()
+	data, ok := strings.CutPrefix(got, "event: alert\ndata: ")
+	if !ok {
+		t.Fatalf("event %q, want an alert", got)
+	}
+	var n cloud.Notification
+	if err := json.Unmarshal([]byte(data), &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.FacilityID != "facility-001" || n.EquipmentID != "meter-1" || n.Severity != "high" || n.Message != "Power 40 kW" {
+		t.Errorf("streamed %+v", n)
+	}
+}
+
+func TestDeleteAlert(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "secret")
+	svcs, url := memoryServer(t)
+	alertIDs := func(includeDismissed bool) map[string]cloud.Alert {
+		alerts, err := svcs.Alerts.GetAlerts("facility-001", cloud.AlertFilter{IncludeDismissed: includeDismissed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[string]cloud.Alert)
+		for _, a := range alerts {
+			ids[a.AlertID] = a
+		}
+		return ids
+	}
+	for _, msg := range []string{"false positive", "real fault"} {
+		if err := svcs.Alerts.CreateAlert("facility-001", "meter-1", "high", "anomaly", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var soft, hard string
+	for id, a := range alertIDs(false) {
+		if a.Message == "false positive" {
+			soft = id
+		} else {
+			hard = id
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+	}{
+		{name: "dismiss without a dismisser", path: "/alerts/" + soft, body: `{"reason": "noise"}`, wantStatus: 400},
+		{name: "dismiss", path: "/alerts/" + soft, body: `{"dismissed_by": "ops@example.com", "reason": "sensor glitch"}`, wantStatus: 200},
+		{name: "dismiss an unknown alert", path: "/alerts/nope", body: `{"dismissed_by": "ops@example.com"}`, wantStatus: 404},
+		{name: "hard delete without the admin token", path: "/admin/alerts/" + hard, wantStatus: 401},
+		{name: "hard delete", path: "/admin/alerts/" + hard, token: "secret", wantStatus: 200},
+		{name: "hard delete twice", path: "/admin/alerts/" + hard, token: "secret", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodDelete, url+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	// The dismissed alert is kept, with who dismissed it and why, but hidden by default
+	if _, ok := alertIDs(false)[soft]; ok {
+		t.Error("dismissed alert still listed")
+	}
+	all := alertIDs(true)
+	if a, ok := all[soft]; !ok || !a.Dismissed() || a.DismissedBy != "ops@example.com" || a.DismissReason != "sensor glitch" || a.DismissedAt == 0 {
+		t.Errorf("dismissed alert %+v", a)
+	}
+	if _, ok := all[hard]; ok || len(all) != 1 {
+		t.Errorf("hard-deleted alert still stored: %v", all)
+	}
+}
+
+func TestAlertsPaging(t *testing.T) {
+	svcs, url := memoryServer(t)
+	for i := 0; i < 7; i++ {
+		severity := "high"
+		if i%3 == 0 {
+			severity = "low"
+		}
+		if err := svcs.Alerts.CreateAlert("facility-001", "meter-1", severity, "anomaly", fmt.Sprintf("alert %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	type page struct {
+		Count      int           `json:"count"`
+		Alerts     []cloud.Alert `json:"alerts"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	get := func(query string) (int, page) {
+		resp, err := http.Get(url + "/alerts?facility_id=facility-001&" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var p page
+		if resp.StatusCode == 200 {
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, p
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantPages []int // alerts per page
+		wantTotal int
+	}{
+		{name: "unpaged", wantPages: []int{7}, wantTotal: 7},
+		{name: "pages of three", query: "limit=3", wantPages: []int{3, 3, 1}, wantTotal: 7},
+		{name: "pages of seven", query: "limit=7", wantPages: []int{7}, wantTotal: 7},
+		{name: "severity and paging", query: "severity=high&limit=2", wantPages: []int{2, 2}, wantTotal: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			seen := make(map[string]bool)
+			cursor := ""
+			for len(sizes) <= tt.wantTotal {
+				query := tt.query
+				if cursor != "" {
+					query += "&cursor=" + cursor
+				}
+				status, p := get(query)
+				if status != 200 {
+					t.Fatalf("status %d for %q", status, query)
+				}
+				sizes = append(sizes, p.Count)
+				for _, a := range p.Alerts {
+					if seen[a.AlertID] {
+						t.Errorf("%s listed twice", a.AlertID)
+					}
+					seen[a.AlertID] = true
+				}
+				if cursor = p.NextCursor; cursor == "" {
+					break
+				}
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantPages) || len(seen) != tt.wantTotal {
+				t.Errorf("pages of %v with %d alerts, want %v with %d", sizes, len(seen), tt.wantPages, tt.wantTotal)
+			}
+		})
+	}
+
+	if status, _ := get("limit=3&cursor=not-a-cursor"); status != 400 {
+		t.Errorf("bad cursor: status %d, want 400", status)
+	}
+}
+
+func TestExplainAlert(t *testing.T) {
+	svcs, url := memoryServer(t)
+	if err := svcs.Alerts.CreateAlert("facility-001", "pump-2", "medium", "maintenance", "Bearing wear predicted"); err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := svcs.Alerts.GetAlerts("facility-001", cloud.AlertFilter{})
+	if err != nil || len(alerts) != 1 {
+		t.Fatal(alerts, err)
+	}
+
+	tests := []struct {
+		name       string
+		alertID    string
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name: "maintenance alert", alertID: alerts[0].AlertID, wantStatus: 200,
+			wantBody: map[string]interface{}{
+				"alert_id": alerts[0].AlertID, "type": "maintenance", "severity": "medium",
+				"timestamp": alerts[0].Timestamp.UTC().Format(time.RFC3339Nano), "explained": false, "summary": "Bearing wear predicted",
+			},
+		},
+		{name: "unknown alert", alertID: "nope", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(url + "/alerts/" + tt.alertID + "/explain")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(body) != fmt.Sprint(tt.wantBody) {
+				t.Errorf("body %v\nwant %v", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReadingOne(t *testing.T) {
+	svcs, url := memoryServer(t)
+	rd, err := svcs.Readings.FromMQTT("energy/readings", []byte(`{"meter_id": "7", "timestamp": "2024-03-01T12:00:00Z", "voltage": 230, "current": 10, "power_kw": 2.2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := rd.Timestamp.Unix()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "found", query: fmt.Sprintf("facility_id=facility-001&meter_id=7&timestamp=%d", ts), wantStatus: 200},
+		{name: "found by RFC 3339 time", query: "facility_id=facility-001&meter_id=7&timestamp=2024-03-01T12:00:00Z", wantStatus: 200},
+		{name: "not found", query: fmt.Sprintf("facility_id=facility-001&meter_id=8&timestamp=%d", ts), wantStatus: 404},
+		{name: "no timestamp", query: "facility_id=facility-001&meter_id=7", wantStatus: 400},
+		{name: "meter not numeric", query: fmt.Sprintf("facility_id=facility-001&meter_id=meter-7&timestamp=%d", ts), wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(url + "/readings/one?" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+			var body struct {
+				Reading map[string]interface{} `json:"reading"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			// The stored fields and the derived ones side by side
+			r := body.Reading
+			if r["power_kw"] != 2.2 || r["apparent_power_kva"] != 2.3 || r["power_factor"] != 0.96 || r["quality_score"] != 100.0 || r["status"] != "ok" {
+				t.Errorf("reading %v", r)
+			}
+		})
+	}
+}
+
+func TestRecentReadingsRange(t *testing.T) {
+	t.Setenv("MAX_QUERY_RANGE_HOURS", "48")
+	_, url := memoryServer(t)
+	tests := []struct {
+		hours      int
+		wantStatus int
+	}{
+		{hours: 24, wantStatus: 200},
+		{hours: 48, wantStatus: 200},
+		{hours: 49, wantStatus: 400},
+		{hours: 100_000, wantStatus: 400},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(fmt.Sprintf("%s/readings/recent?facility_id=facility-001&hours=%d", url, tt.hours))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%d hours: status %d, want %d", tt.hours, resp.StatusCode, tt.wantStatus)
+		}
+		if tt.wantStatus == 400 && !strings.Contains(body.Error, "MAX_QUERY_RANGE_HOURS") {
+			t.Errorf("%d hours: error %q doesn't name the limit", tt.hours, body.Error)
+		}
+	}
+}
+
+// A reading posted over HTTP comes back from the recent readings listing
+func TestReadingsRoundTrip(t *testing.T) {
+	_, url := memoryServer(t)
+	ts := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "reading", body: fmt.Sprintf(`{"meter_id": "7", "timestamp": %q, "voltage": 230, "current": 10, "power_kw": 2.2}`, ts.Format(time.RFC3339)), wantStatus: 201},
+		{name: "not JSON", body: `{"meter_id": `, wantStatus: 400},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(url+"/readings?facility_id=facility-002", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	resp, err := http.Get(url + "/readings/recent?facility_id=facility-002&hours=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Count    int `json:"count"`
+		Readings []struct {
+			MeterID   int64   `json:"meter_id"`
+			Timestamp int64   `json:"timestamp"`
+			PowerKW   float64 `json:"power_kw"`
+		} `json:"readings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Count != 1 || len(body.Readings) != 1 {
+		t.Fatalf("got %d readings, want the one posted: %+v", body.Count, body.Readings)
+	}
+	if r := body.Readings[0]; r.MeterID != 7 || r.Timestamp != ts.Unix() || r.PowerKW != 2.2 {
+		t.Errorf("reading %+v", r)
+	}
+}
+
+// An alert created over HTTP can be acknowledged over HTTP; an unknown one is a 404
+func TestAcknowledgeAlertRoundTrip(t *testing.T) {
+	svcs, url := memoryServer(t)
+	resp, err := http.Post(url+"/alerts", "application/json", strings.NewReader(`{"facility_id": "facility-001", "equipment_id": "meter-1", "severity": "high", "type": "anomaly", "message": "spike"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 201 {
+		t.Fatalf("create: status %d, want 201", resp.StatusCode)
+	}
+	alerts, err := svcs.Alerts.GetAlerts("facility-001", cloud.AlertFilter{})
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("alerts %v, %v", alerts, err)
+	}
+	id := alerts[0].AlertID
+
+	tests := []struct {
+		name       string
+		alertID    string
+		wantStatus int
+	}{
+		{name: "acknowledge", alertID: id, wantStatus: 200},
+		{name: "acknowledge again", alertID: id, wantStatus: 200},
+		{name: "unknown alert", alertID: "nope", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(url+"/alerts/"+tt.alertID+"/acknowledge", "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	resp, err = http.Get(url + "/alerts?facility_id=facility-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Alerts []cloud.Alert `json:"alerts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Alerts) != 1 || body.Alerts[0].AlertID != id || !body.Alerts[0].Acknowledged {
+		t.Errorf("alerts after acknowledging %+v", body.Alerts)
+	}
+}
+
+func TestAdminLambda(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "secret")
+	_, url := memoryServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "status without the admin token", method: http.MethodGet, path: "/admin/lambda/status", wantStatus: 401},
+		{name: "status without cloud services", method: http.MethodGet, path: "/admin/lambda/status", token: "secret", wantStatus: 503, wantError: "Cloud services not enabled"},
+		{name: "invoke without the admin token", method: http.MethodPost, path: "/admin/lambda/invoke", body: `{"facility_id": "facility-001"}`, wantStatus: 401},
+		{name: "invoke without a facility", method: http.MethodPost, path: "/admin/lambda/invoke", token: "secret", body: `{"date": "2024-03-01"}`, wantStatus: 400, wantError: "facility_id is required"},
+		{name: "invoke with a bad date", method: http.MethodPost, path: "/admin/lambda/invoke", token: "secret", body: `{"facility_id": "facility-001", "date": "03/01/2024"}`, wantStatus: 400, wantError: "date must be YYYY-MM-DD"},
+		{name: "invoke without cloud services", method: http.MethodPost, path: "/admin/lambda/invoke", token: "secret", body: `{"facility_id": "facility-001", "date": "2024-03-01", "async": true}`, wantStatus: 503, wantError: "Cloud services not enabled"},
+		{name: "synchronous invoke without cloud services", method: http.MethodPost, path: "/admin/lambda/invoke", token: "secret", body: `{"facility_id": "facility-001"}`, wantStatus: 503, wantError: "Cloud services not enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, url+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var body struct {
+				Error string `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&body)
+			if resp.StatusCode != tt.wantStatus || tt.wantError != "" && body.Error != tt.wantError {
+				t.Errorf("status %d, error %q; want %d, %q", resp.StatusCode, body.Error, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// scopedApp serves every route the scope middleware treats specially, echoing
+// the facility_id query param so tests can see what the handler was given
+func scopedApp(t *testing.T) *fiber.App {
+	t.Helper()
+	keys, err := service.NewAPIKeys(map[string][]string{
+		"facility-001": {digest("key-001")},
+		"facility-002": {digest("key-002")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	g := app.Group("/")
+	g.Use(facilityScope(keys, "admin-token"))
+	echo := func(c *fiber.Ctx) error { return c.SendString(c.Query("facility_id")) }
+	g.Get("/", echo)
+	g.Get("health", echo)
+	g.Get("facilities", echo)
+	g.Get("meters", echo)
+	g.Get("metrics/readings", echo)
+	g.Get("readings/recent", echo)
+	g.Post("facilities/:id/alerts/archive", echo)
+	g.Get("admin/status", echo)
+	return app
+}
+
+func TestFacilityScope(t *testing.T) {
+	app := scopedApp(t)
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		apiKey   string
+		bearer   string
+		wantCode int
+		wantBody string
+	}{
+		{"root needs no key", "GET", "/", "", "", 200, ""},
+		{"health needs no key", "GET", "/health", "", "", 200, ""},
+		{"admin checks its own token", "GET", "/admin/status", "", "", 200, ""},
+		{"missing key", "GET", "/readings/recent", "", "", 401, ""},
+		{"unknown key", "GET", "/readings/recent", "nope", "", 401, ""},
+		{"facility set when absent", "GET", "/readings/recent", "key-001", "", 200, "facility-001"},
+		{"own facility", "GET", "/readings/recent?facility_id=facility-001", "key-001", "", 200, "facility-001"},
+		{"other facility query", "GET", "/readings/recent?facility_id=facility-002", "key-001", "", 403, ""},
+		{"own facility path", "POST", "/facilities/facility-001/alerts/archive", "key-001", "", 200, "facility-001"},
+		{"other facility path", "POST", "/facilities/facility-002/alerts/archive", "key-001", "", 403, ""},
+		{"facility listing", "GET", "/facilities", "key-001", "", 403, ""},
+		{"meter listing", "GET", "/meters", "key-001", "", 403, ""},
+		{"metrics", "GET", "/metrics/readings", "key-001", "", 403, ""},
+		{"admin token is unscoped", "GET", "/meters", "", "admin-token", 200, ""},
+		{"wrong admin token", "GET", "/meters", "", "nope", 401, ""},
+
+		// Routes match regardless of case; scoping must too
+		{"upper-case facility listing", "GET", "/FACILITIES", "key-001", "", 403, ""},
+		{"mixed-case facility listing", "GET", "/Facilities", "key-001", "", 403, ""},
+		{"upper-case meter listing", "GET", "/METERS", "key-001", "", 403, ""},
+		{"mixed-case meter listing", "GET", "/Meters", "key-001", "", 403, ""},
+		{"mixed-case metrics", "GET", "/Metrics/readings", "key-001", "", 403, ""},
+		{"mixed-case other facility path", "POST", "/Facilities/facility-002/alerts/archive", "key-001", "", 403, ""},
+		{"mixed-case other facility query", "GET", "/Readings/recent?facility_id=facility-002", "key-001", "", 403, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode == 200 {
+				body := make([]byte, 64)
+				n, _ := resp.Body.Read(body)
+				if got := string(body[:n]); got != tt.wantBody {
+					t.Errorf("facility_id = %q, want %q", got, tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestFacilityScopeDisabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(facilityScope(nil, ""))
+	app.Get("/meters", func(c *fiber.Ctx) error { return c.SendStatus(200) })
+	resp, err := app.Test(httptest.NewRequest("GET", "/meters", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, want 200 without API keys", resp.StatusCode)
+	}
+}
+
+func TestScopedFacility(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"unscoped keeps body", "", "facility-002", "facility-002", false},
+		{"unscoped keeps empty", "", "", "", false},
+		{"scoped fills empty", "facility-001", "", "facility-001", false},
+		{"scoped own", "facility-001", "facility-001", "facility-001", false},
+		{"scoped other", "facility-001", "facility-002", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var got string
+			var gotErr error
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.scope != "" {
+					c.Locals(scopeLocal, tt.scope)
+				}
+				got, gotErr = scopedFacility(c, tt.body)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+				t.Fatal(err)
+			}
+			if (gotErr != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("scopedFacility = %q, %v; want %q, err %v", got, gotErr, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Package logging configures the global zerolog logger from LOG_LEVEL and
+// LOG_FORMAT, so every binary starts with the same setup.
+package logging
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Output formats: JSON lines for production, a readable console for development
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// ParseLevel parses a zerolog level name (trace, debug, info, warn, error,
+// fatal, panic, disabled) case-insensitively. An empty or unknown name yields
+// info and ok false.
+func ParseLevel(s string) (zerolog.Level, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return zerolog.InfoLevel, false
+	}
+	// zerolog also takes any number as a level; only names are accepted here
+	if _, err := strconv.Atoi(s); err == nil {
+		return zerolog.InfoLevel, false
+	}
+	level, err := zerolog.ParseLevel(s)
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.InfoLevel, false
+	}
+	return level, true
+}
+
+// Setup sets the global level and replaces log.Logger with a JSON or console
+// logger on stderr. An invalid level or format falls back to info or JSON with
+// a warning rather than stopping the binary.
+func Setup(level, format string) {
+	lvl, ok := ParseLevel(level)
+	zerolog.SetGlobalLevel(lvl)
+
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case FormatConsole:
+		log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).With().Timestamp().Logger()
+	default:
+		log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+	}
+
+	if !ok && level != "" {
+		log.Warn().Str("log_level", level).Msg("unknown LOG_LEVEL; using info")
+	}
+	if format != FormatJSON && format != FormatConsole && format != "" {
+		log.Warn().Str("log_format", format).Msg("unknown LOG_FORMAT; using json")
+	}
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   zerolog.Level
+		wantOK bool
+	}{
+		{raw: "debug", want: zerolog.DebugLevel, wantOK: true},
+		{raw: " WARN ", want: zerolog.WarnLevel, wantOK: true},
+		{raw: "disabled", want: zerolog.Disabled, wantOK: true},
+		{raw: "", want: zerolog.InfoLevel},
+		{raw: "verbose", want: zerolog.InfoLevel},
+		{raw: "42", want: zerolog.InfoLevel},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLevel(tt.raw)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { zerolog.SetGlobalLevel(zerolog.TraceLevel) })
+	tests := []struct {
+		level  string
+		format string
+		want   zerolog.Level
+	}{
+		{level: "debug", format: FormatConsole, want: zerolog.DebugLevel},
+		{level: "error", format: FormatJSON, want: zerolog.ErrorLevel},
+		{level: "loud", format: "xml", want: zerolog.InfoLevel},
+	}
+	for _, tt := range tests {
+		Setup(tt.level, tt.format)
+		if got := zerolog.GlobalLevel(); got != tt.want {
+			t.Errorf("Setup(%q, %q) set level %v, want %v", tt.level, tt.format, got, tt.want)
+		}
+	}
+}
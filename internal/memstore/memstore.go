@@ -0,0 +1,297 @@
+package memstore
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// Store is an in-memory reading, alert and equipment store with the same
+// method set as cloud.DynamoDBClient. Data only lives for the process lifetime,
+// which makes it suitable for demos and local runs without Postgres or AWS.
+type Store struct {
+	mu        sync.RWMutex
+	nextID    int64
+	readings  map[string][]domain.Reading
+	alerts    map[string]*cloud.Alert
+	equipment map[string]*cloud.Equipment
+
+	predictions []domain.MaintenancePrediction
+}
+
+// New creates an empty in-memory store
+func New() *Store {
+	return &Store{
+		readings:  make(map[string][]domain.Reading),
+		alerts:    make(map[string]*cloud.Alert),
+		equipment: make(map[string]*cloud.Equipment),
+	}
+}
+
+// PutReading stores an energy reading for a facility
+func (s *Store) PutReading(reading *domain.Reading, facilityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	rd := *reading
+	rd.ID = s.nextID
+	s.readings[facilityID] = append(s.readings[facilityID], rd)
+	return nil
+}
+
+// BatchPutReadings stores multiple readings for a facility
+func (s *Store) BatchPutReadings(readings []domain.Reading, facilityID string) error {
+	for i := range readings {
+		if err := s.PutReading(&readings[i], facilityID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRecentReadings returns a facility's readings newer than now-duration, oldest first
+func (s *Store) GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := time.Now().Add(-duration)
+	out := []domain.Reading{}
+	for _, r := range s.readings[facilityID] {
+		if r.Timestamp.After(start) {
+			out = append(out, r)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp.Time) })
+	return out, nil
+}
+
+// GetReading returns the meter's reading at timestamp (to the second, as
+// DynamoDB keys it), or nil when there is none
+func (s *Store) GetReading(facilityID string, meterID int64, timestamp time.Time) (*domain.Reading, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.readings[facilityID] {
+		if r.MeterID == meterID && r.Timestamp.Unix() == timestamp.Unix() {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetRecentReadingsPage returns one page of GetRecentReadings; the cursor is
+// the offset of the next reading and limit 0 returns the rest of the window.
+// Readings are held in memory, so they are returned whole whatever the fields.
+func (s *Store) GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, limit int, _ cloud.Projection) (*domain.ReadingPage, error) {
+	offset := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		offset = n
+	}
+
+	end := time.Now()
+	all, err := s.GetRecentReadings(facilityID, duration)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.ReadingPage{WindowStart: end.Add(-duration), WindowEnd: end, Readings: []domain.Reading{}}
+	if offset >= len(all) {
+		return page, nil
+	}
+
+	all = all[offset:]
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+		page.Truncated = true
+		page.NextCursor = strconv.Itoa(offset + limit)
+	}
+	page.Readings = all
+	return page, nil
+}
+
+// CreateAlert stores a new unacknowledged alert
+func (s *Store) CreateAlert(facilityID, equipmentID, severity, alertType, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	alert := &cloud.Alert{
+		AlertID:     fmt.Sprintf("alert-%d-%d", now.Unix(), now.Nanosecond()),
+		FacilityID:  facilityID,
+		Timestamp:   timeutil.FromTime(now),
+		Severity:    severity,
+		Type:        alertType,
+		Message:     message,
+		EquipmentID: equipmentID,
+	}
+	s.alerts[alert.AlertID] = alert
+	return nil
+}
+
+// GetAlerts returns a facility's alerts newest first, narrowed by filter
+func (s *Store) GetAlerts(facilityID string, filter cloud.AlertFilter) ([]cloud.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []cloud.Alert{}
+	for _, a := range s.alerts {
+		if a.FacilityID != facilityID {
+			continue
+		}
+		if !filter.Matches(*a) {
+			continue
+		}
+		out = append(out, *a)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Timestamp.Equal(out[j].Timestamp.Time) {
+			return out[i].AlertID > out[j].AlertID
+		}
+		return out[i].Timestamp.After(out[j].Timestamp.Time)
+	})
+	return out, nil
+}
+
+// GetAlert returns a copy of one alert
+func (s *Store) GetAlert(alertID string) (*cloud.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", cloud.ErrAlertNotFound, alertID)
+	}
+	out := *alert
+	return &out, nil
+}
+
+// AcknowledgeAlert marks an alert as acknowledged
+func (s *Store) AcknowledgeAlert(alertID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return fmt.Errorf("%w: %s", cloud.ErrAlertNotFound, alertID)
+	}
+	alert.Acknowledged = true
+	alert.AcknowledgedAt = time.Now().Unix()
+	return nil
+}
+
+// DismissAlert marks an alert dismissed, recording who dismissed it and why
+func (s *Store) DismissAlert(alertID, dismissedBy, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return fmt.Errorf("%w: %s", cloud.ErrAlertNotFound, alertID)
+	}
+	alert.Status = cloud.AlertDismissed
+	alert.DismissedBy = dismissedBy
+	alert.DismissReason = reason
+	alert.DismissedAt = time.Now().Unix()
+	return nil
+}
+
+// ExpireAlert sets a closed alert's expiry; the store hides it from then on
+// rather than deleting it
+func (s *Store) ExpireAlert(alertID string, expiresAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return fmt.Errorf("%w: %s", cloud.ErrAlertNotFound, alertID)
+	}
+	if !alert.Closed() {
+		return fmt.Errorf("%w: %s", cloud.ErrAlertOpen, alertID)
+	}
+	alert.ExpiresAt = expiresAt
+	return nil
+}
+
+// DeleteAlert removes an alert
+func (s *Store) DeleteAlert(alertID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.alerts[alertID]; !ok {
+		return fmt.Errorf("%w: %s", cloud.ErrAlertNotFound, alertID)
+	}
+	delete(s.alerts, alertID)
+	return nil
+}
+
+// GetEquipment returns all equipment registered for a facility
+func (s *Store) GetEquipment(facilityID string) ([]cloud.Equipment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []cloud.Equipment{}
+	for _, eq := range s.equipment {
+		if eq.FacilityID == facilityID {
+			out = append(out, *eq)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].EquipmentID < out[j].EquipmentID })
+	return out, nil
+}
+
+// UpdateEquipmentHealth sets the health score of equipment
+func (s *Store) UpdateEquipmentHealth(equipmentID string, healthScore float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eq, ok := s.equipment[equipmentID]
+	if !ok {
+		return fmt.Errorf("equipment %s not found", equipmentID)
+	}
+	eq.HealthScore = healthScore
+	return nil
+}
+
+// PutEquipment registers or replaces an equipment record, e.g. to seed demo data
+func (s *Store) PutEquipment(eq cloud.Equipment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.equipment[eq.EquipmentID] = &eq
+}
+
+// PutMaintenancePredictions appends a maintenance run's predictions to the history
+func (s *Store) PutMaintenancePredictions(predictions []domain.MaintenancePrediction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.predictions = append(s.predictions, predictions...)
+	return nil
+}
+
+// MaintenancePredictions returns the stored prediction history for equipment, oldest first
+func (s *Store) MaintenancePredictions(equipmentID string) []domain.MaintenancePrediction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []domain.MaintenancePrediction{}
+	for _, p := range s.predictions {
+		if p.EquipmentID == equipmentID {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package memstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestRecentReadingsPage(t *testing.T) {
+	s := New()
+	now := time.Now()
+	for i, age := range []time.Duration{3 * time.Hour, 40 * time.Minute, 30 * time.Minute, 20 * time.Minute, 10 * time.Minute} {
+		rd := domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(now.Add(-age)), PowerKW: float64(i)}
+		if err := s.PutReading(&rd, "facility-001"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := domain.Reading{MeterID: 2, Timestamp: timeutil.FromTime(now), PowerKW: 9}
+	if err := s.PutReading(&other, "facility-002"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cursor     string
+		limit      int
+		wantPower  []float64
+		wantCursor string
+		wantErr    bool
+	}{
+		{name: "whole window oldest first", wantPower: []float64{1, 2, 3, 4}},
+		{name: "first page", limit: 3, wantPower: []float64{1, 2, 3}, wantCursor: "3"},
+		{name: "last page", cursor: "3", limit: 3, wantPower: []float64{4}},
+		{name: "past the end", cursor: "10", wantPower: []float64{}},
+		{name: "bad cursor", cursor: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.GetRecentReadingsPage("facility-001", time.Hour, tt.cursor, tt.limit, cloud.Projection{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := make([]float64, len(page.Readings))
+			for i, r := range page.Readings {
+				got[i] = r.PowerKW
+			}
+			if len(got) != len(tt.wantPower) {
+				t.Fatalf("powers = %v, want %v", got, tt.wantPower)
+			}
+			for i := range got {
+				if got[i] != tt.wantPower[i] {
+					t.Fatalf("powers = %v, want %v", got, tt.wantPower)
+				}
+			}
+			if page.NextCursor != tt.wantCursor || page.Truncated != (tt.wantCursor != "") {
+				t.Errorf("next cursor = %q (truncated %v), want %q", page.NextCursor, page.Truncated, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestAlertLifecycle(t *testing.T) {
+	s := New()
+	for _, sev := range []string{"high", "low"} {
+		if err := s.CreateAlert("facility-001", "eq-1", sev, "anomaly", sev+" alert"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CreateAlert("facility-002", "eq-9", "high", "anomaly", "elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := s.GetAlerts("facility-001", cloud.AlertFilter{})
+	if err != nil || len(all) != 2 {
+		t.Fatalf("GetAlerts = %d alerts, %v; want 2", len(all), err)
+	}
+	high, _ := s.GetAlerts("facility-001", cloud.AlertFilter{Severity: "high"})
+	if len(high) != 1 || high[0].Severity != "high" {
+		t.Fatalf("high alerts = %+v", high)
+	}
+	id := high[0].AlertID
+
+	steps := []struct {
+		name    string
+		do      func() error
+		wantErr error
+		check   func(a *cloud.Alert) bool
+	}{
+		{name: "open alert can't expire", do: func() error { return s.ExpireAlert(id, time.Now().Unix()) }, wantErr: cloud.ErrAlertOpen},
+		{name: "acknowledge", do: func() error { return s.AcknowledgeAlert(id) }, check: func(a *cloud.Alert) bool { return a.Acknowledged }},
+		{name: "dismiss", do: func() error { return s.DismissAlert(id, "ops", "planned work") }, check: func(a *cloud.Alert) bool {
+			return a.Status == cloud.AlertDismissed && a.DismissedBy == "ops"
+		}},
+		{name: "delete", do: func() error { return s.DeleteAlert(id) }},
+		{name: "deleted alert is gone", do: func() error { _, err := s.GetAlert(id); return err }, wantErr: cloud.ErrAlertNotFound},
+	}
+	for _, st := range steps {
+		if err := st.do(); !errors.Is(err, st.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", st.name, err, st.wantErr)
+		}
+		if st.check != nil {
+			a, err := s.GetAlert(id)
+			if err != nil || !st.check(a) {
+				t.Errorf("%s: alert = %+v, %v", st.name, a, err)
+			}
+		}
+	}
+}
+
+func TestGetAlertsByEquipment(t *testing.T) {
+	s := New()
+	for _, a := range []struct{ facility, equipment, severity string }{
+		{"facility-001", "transformer-1", "high"},
+		{"facility-001", "transformer-1", "low"},
+		{"facility-001", "transformer-2", "high"},
+		{"facility-002", "transformer-1", "high"},
+	} {
+		if err := s.CreateAlert(a.facility, a.equipment, a.severity, "anomaly", "alert"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter cloud.AlertFilter
+		want   int
+	}{
+		{name: "one equipment", filter: cloud.AlertFilter{EquipmentID: "transformer-1"}, want: 2},
+		{name: "with severity", filter: cloud.AlertFilter{EquipmentID: "transformer-1", Severity: "high"}, want: 1},
+		{name: "with time range", filter: cloud.AlertFilter{EquipmentID: "transformer-1", Since: time.Now().Add(-time.Hour).Unix()}, want: 2},
+		{name: "range before the alerts", filter: cloud.AlertFilter{EquipmentID: "transformer-1", Until: time.Now().Add(-time.Hour).Unix()}},
+		{name: "unknown equipment", filter: cloud.AlertFilter{EquipmentID: "transformer-9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := s.GetAlerts("facility-001", tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(alerts) != tt.want {
+				t.Fatalf("%d alerts, want %d", len(alerts), tt.want)
+			}
+			for _, a := range alerts {
+				if a.EquipmentID != tt.filter.EquipmentID || a.FacilityID != "facility-001" {
+					t.Errorf("alert of %s/%s returned", a.FacilityID, a.EquipmentID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Binary reading payload for constrained gateways, published to MQTT topics
+// ending in readings.pb (e.g. energy/readings.pb, energy/site-a/readings.pb).
+//
+// Regenerate reading.pb.go with:
+//   protoc --go_out=. --go_opt=paths=source_relative internal/readingpb/reading.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: internal/readingpb/reading.proto
+
+package readingpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Reading mirrors the v1 JSON payload in base units (V, A, kW); there are no
+// unit fields, gateways convert before encoding.
+type Reading struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	MeterId string                 `protobuf:"bytes,1,opt,name=meter_id,json=meterId,proto3" json:"meter_id,omitempty"`
+	// Epoch milliseconds; 0 leaves the timestamp unset
+	TimestampMs int64   `protobuf:"varint,2,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	Voltage     float64 `protobuf:"fixed64,3,opt,name=voltage,proto3" json:"voltage,omitempty"`
+	Current     float64 `protobuf:"fixed64,4,opt,name=current,proto3" json:"current,omitempty"`
+	PowerKw     float64 `protobuf:"fixed64,5,opt,name=power_kw,json=powerKw,proto3" json:"power_kw,omitempty"`
+	// Optional power-quality attributes
+	ReactivePowerKvar *float64 `protobuf:"fixed64,6,opt,name=reactive_power_kvar,json=reactivePowerKvar,proto3,oneof" json:"reactive_power_kvar,omitempty"`
+	FrequencyHz       *float64 `protobuf:"fixed64,7,opt,name=frequency_hz,json=frequencyHz,proto3,oneof" json:"frequency_hz,omitempty"`
+	ThdPercent        *float64 `protobuf:"fixed64,8,opt,name=thd_percent,json=thdPercent,proto3,oneof" json:"thd_percent,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Reading) Reset() {
+	*x = Reading{}
+	mi := &file_internal_readingpb_reading_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reading) ProtoMessage() {}
+
+func (x *Reading) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_readingpb_reading_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reading.ProtoReflect.Descriptor instead.
+func (*Reading) Descriptor() ([]byte, []int) {
+	return file_internal_readingpb_reading_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Reading) GetMeterId() string {
+	if x != nil {
+		return x.MeterId
+	}
+	return ""
+}
+
+func (x *Reading) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *Reading) GetVoltage() float64 {
+	if x != nil {
+		return x.Voltage
+	}
+	return 0
+}
+
+func (x *Reading) GetCurrent() float64 {
+	if x != nil {
+		return x.Current
+	}
+	return 0
+}
+
+func (x *Reading) GetPowerKw() float64 {
+	if x != nil {
+		return x.PowerKw
+	}
+	return 0
+}
+
+func (x *Reading) GetReactivePowerKvar() float64 {
+	if x != nil && x.ReactivePowerKvar != nil {
+		return *x.ReactivePowerKvar
+	}
+	return 0
+}
+
+func (x *Reading) GetFrequencyHz() float64 {
+	if x != nil && x.FrequencyHz != nil {
+		return *x.FrequencyHz
+	}
+	return 0
+}
+
+func (x *Reading) GetThdPercent() float64 {
+	if x != nil && x.ThdPercent != nil {
+		return *x.ThdPercent
+	}
+	return 0
+}
+
+var File_internal_readingpb_reading_proto protoreflect.FileDescriptor
+
+const file_internal_readingpb_reading_proto_rawDesc = "" +
+	"\n" +
+	" internal/readingpb/reading.proto\x12\renergygrid.v1\"\xd2\x02\n" +
+	"\aReading\x12\x19\n" +
+	"\bmeter_id\x18\x01 \x01(\tR\ameterId\x12!\n" +
+	"\ftimestamp_ms\x18\x02 \x01(\x03R\vtimestampMs\x12\x18\n" +
+	"\avoltage\x18\x03 \x01(\x01R\avoltage\x12\x18\n" +
+	"\acurrent\x18\x04 \x01(\x01R\acurrent\x12\x19\n" +
+	"\bpower_kw\x18\x05 \x01(\x01R\apowerKw\x123\n" +
+	"\x13reactive_power_kvar\x18\x06 \x01(\x01H\x00R\x11reactivePowerKvar\x88\x01\x01\x12&\n" +
+	"\ffrequency_hz\x18\a \x01(\x01H\x01R\vfrequencyHz\x88\x01\x01\x12$\n" +
+	"\vthd_percent\x18\b \x01(\x01H\x02R\n" +
+	"thdPercent\x88\x01\x01B\x16\n" +
+	"\x14_reactive_power_kvarB\x0f\n" +
+	"\r_frequency_hzB\x0e\n" +
+	"\f_thd_percentBRZPgithub.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/readingpbb\x06proto3"
+
+var (
+	file_internal_readingpb_reading_proto_rawDescOnce sync.Once
+	file_internal_readingpb_reading_proto_rawDescData []byte
+)
+
+func file_internal_readingpb_reading_proto_rawDescGZIP() []byte {
+	file_internal_readingpb_reading_proto_rawDescOnce.Do(func() {
+		file_internal_readingpb_reading_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_readingpb_reading_proto_rawDesc), len(file_internal_readingpb_reading_proto_rawDesc)))
+	})
+	return file_internal_readingpb_reading_proto_rawDescData
+}
+
+var file_internal_readingpb_reading_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_internal_readingpb_reading_proto_goTypes = []any{
+	(*Reading)(nil), // 0: energygrid.v1.Reading
+}
+var file_internal_readingpb_reading_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_internal_readingpb_reading_proto_init() }
+func file_internal_readingpb_reading_proto_init() {
+	if File_internal_readingpb_reading_proto != nil {
+		return
+	}
+	file_internal_readingpb_reading_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_readingpb_reading_proto_rawDesc), len(file_internal_readingpb_reading_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_readingpb_reading_proto_goTypes,
+		DependencyIndexes: file_internal_readingpb_reading_proto_depIdxs,
+		MessageInfos:      file_internal_readingpb_reading_proto_msgTypes,
+	}.Build()
+	File_internal_readingpb_reading_proto = out.File
+	file_internal_readingpb_reading_proto_goTypes = nil
+	file_internal_readingpb_reading_proto_depIdxs = nil
+}
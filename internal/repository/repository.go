@@ -1,30 +1,223 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrNoDatabase is returned when the repository was created without a connection
+// (e.g. when running with the in-memory storage backend)
+var ErrNoDatabase = errors.New("no database configured")
+
 type Repos struct {
 	db *sqlx.DB
 }
 
 func New(db *sqlx.DB) *Repos { return &Repos{db: db} }
 
-func (r *Repos) ListFacilities() ([]domain.Facility, error) {
-	var out []domain.Facility
-	err := r.db.Select(&out, `SELECT id, name FROM facilities ORDER BY id`)
-	return out, err
+// Page size bounds for list queries
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ClampPageSize applies the default page size to non-positive limits and caps large ones
+func ClampPageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+// ListFacilities returns up to limit facilities with id greater than afterID.
+// next is the cursor for the following page, or 0 when this is the last page.
+func (r *Repos) ListFacilities(afterID int64, limit int) (out []domain.Facility, next int64, err error) {
+	if r.db == nil {
+		return nil, 0, ErrNoDatabase
+	}
+	limit = ClampPageSize(limit)
+	// Fetch one extra row to tell whether another page exists
+	err = r.db.Select(&out, `SELECT id, name, timezone FROM facilities WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(out) > limit {
+		out = out[:limit]
+		next = out[limit-1].ID
+	}
+	return out, next, nil
 }
 
-func (r *Repos) ListMeters() ([]domain.Meter, error) {
-	var out []domain.Meter
-	err := r.db.Select(&out, `SELECT id, facility_id, serial FROM meters ORDER BY id`)
-	return out, err
+// ListMeters returns up to limit meters with id greater than afterID.
+// next is the cursor for the following page, or 0 when this is the last page.
+func (r *Repos) ListMeters(afterID int64, limit int) (out []domain.Meter, next int64, err error) {
+	if r.db == nil {
+		return nil, 0, ErrNoDatabase
+	}
+	limit = ClampPageSize(limit)
+	err = r.db.Select(&out, `SELECT id, facility_id, serial, zone, voltage_gain, power_gain, power_offset, status FROM meters WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(out) > limit {
+		out = out[:limit]
+		next = out[limit-1].ID
+	}
+	return out, next, nil
 }
 
-func (r *Repos) InsertReading(rd *domain.Reading) error {
-	_, err := r.db.Exec(`INSERT INTO readings(meter_id, timestamp, voltage, current, power_kw) VALUES ($1,$2,$3,$4,$5)`,
-		rd.MeterID, rd.Timestamp, rd.Voltage, rd.Current, rd.PowerKW)
+// GetFacility returns the facility with the given id
+func (r *Repos) GetFacility(id int64) (*domain.Facility, error) {
+	if r.db == nil {
+		return nil, ErrNoDatabase
+	}
+	var out domain.Facility
+	if err := r.db.Get(&out, `SELECT id, name, timezone FROM facilities WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMeter returns the meter with the given id
+func (r *Repos) GetMeter(id int64) (*domain.Meter, error) {
+	if r.db == nil {
+		return nil, ErrNoDatabase
+	}
+	var out domain.Meter
+	if err := r.db.Get(&out, `SELECT id, facility_id, serial, zone, voltage_gain, power_gain, power_offset, status FROM meters WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetMeterStatus updates the meter's lifecycle status
+func (r *Repos) SetMeterStatus(id int64, status string) error {
+	if r.db == nil {
+		return ErrNoDatabase
+	}
+	_, err := r.db.Exec(`UPDATE meters SET status = $2 WHERE id = $1`, id, status)
 	return err
 }
+
+// MeterZones returns the zone of every meter, keyed by meter id. Meters
+// without a zone are omitted.
+func (r *Repos) MeterZones() (map[int64]string, error) {
+	if r.db == nil {
+		return nil, ErrNoDatabase
+	}
+	var rows []domain.Meter
+	if err := r.db.Select(&rows, `SELECT id, facility_id, serial, zone FROM meters WHERE zone <> ''`); err != nil {
+		return nil, err
+	}
+	zones := make(map[int64]string, len(rows))
+	for _, m := range rows {
+		zones[m.ID] = m.Zone
+	}
+	return zones, nil
+}
+
+// DailyAggregate computes hourly and whole-day power aggregates for a
+// facility's readings on the day of date's calendar date in loc. Hours are
+// truncated in loc, so zones with a non-whole-hour offset bucket correctly.
+func (r *Repos) DailyAggregate(facilityID, meterID int64, date time.Time, loc *time.Location) (*domain.DailyAggregate, error) {
+	if r.db == nil {
+		return nil, ErrNoDatabase
+	}
+
+	// meterID 0 aggregates every meter of the facility
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	var hourly []domain.HourlyAggregate
+	err := r.db.Select(&hourly, `
+		SELECT date_trunc('hour', r.timestamp AT TIME ZONE $4) AT TIME ZONE $4 AS hour,
+		       COUNT(*) AS reading_count,
+		       SUM(r.power_kw) AS total_power,
+		       AVG(r.power_kw) AS avg_power,
+		       MAX(r.power_kw) AS peak_power
+		FROM readings r
+		JOIN meters m ON m.id = r.meter_id
+		WHERE m.facility_id = $1 AND r.timestamp >= $2 AND r.timestamp < $3 AND ($5 = 0 OR r.meter_id = $5)
+		GROUP BY 1
+		ORDER BY 1`, facilityID, start, start.AddDate(0, 0, 1), loc.String(), meterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var peaks []struct {
+		MeterID int64   `db:"meter_id"`
+		Peak    float64 `db:"peak_power"`
+	}
+	err = r.db.Select(&peaks, `
+		SELECT r.meter_id, MAX(r.power_kw) AS peak_power
+		FROM readings r
+		JOIN meters m ON m.id = r.meter_id
+		WHERE m.facility_id = $1 AND r.timestamp >= $2 AND r.timestamp < $3 AND ($4 = 0 OR r.meter_id = $4)
+		GROUP BY r.meter_id`, facilityID, start, start.AddDate(0, 0, 1), meterID)
+	if err != nil {
+		return nil, err
+	}
+
+	agg := &domain.DailyAggregate{Date: start, Hourly: hourly, MeterPeaks: make(map[int64]float64, len(peaks))}
+	for _, p := range peaks {
+		agg.MeterPeaks[p.MeterID] = p.Peak
+	}
+	for _, h := range hourly {
+		agg.ReadingCount += h.ReadingCount
+		agg.TotalPower += h.TotalPower
+		if h.PeakPower > agg.PeakPower {
+			agg.PeakPower = h.PeakPower
+		}
+	}
+	if agg.ReadingCount > 0 {
+		agg.AveragePower = agg.TotalPower / float64(agg.ReadingCount)
+	}
+	return agg, nil
+}
+
+// InsertReading stores a reading unless the meter already has one at its
+// timestamp, as after an MQTT redelivery or a retried import; inserted reports
+// which
+func (r *Repos) InsertReading(rd *domain.Reading) (inserted bool, err error) {
+	if r.db == nil {
+		return false, ErrNoDatabase
+	}
+	res, err := r.db.Exec(`INSERT INTO readings(meter_id, timestamp, voltage, current, power_kw, reactive_power_kvar, frequency_hz, thd_percent, raw_voltage, raw_power_kw, source)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		ON CONFLICT (meter_id, timestamp) DO NOTHING`,
+		rd.MeterID, rd.Timestamp, rd.Voltage, rd.Current, rd.PowerKW, rd.ReactivePowerKVAR, rd.FrequencyHz, rd.THDPercent, rd.RawVoltage, rd.RawPowerKW, rd.Source)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// InsertMaintenancePredictions stores a maintenance run's predictions in one transaction
+func (r *Repos) InsertMaintenancePredictions(predictions []domain.MaintenancePrediction) error {
+	if r.db == nil {
+		return ErrNoDatabase
+	}
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range predictions {
+		if _, err := tx.NamedExec(`INSERT INTO maintenance_predictions(facility_id, equipment_id, computed_at, health_score,
+			failure_risk_30_days, failure_risk_90_days, next_service_date, recommendation, high_risk)
+			VALUES (:facility_id, :equipment_id, :computed_at, :health_score,
+			:failure_risk_30_days, :failure_risk_90_days, :next_service_date, :recommendation, :high_risk)`, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+	"github.com/jmoiron/sqlx"
+)
+
+// conflictDriver behaves like postgres with the unique (meter_id, timestamp)
+// index for statements that say ON CONFLICT DO NOTHING: a second insert of a
+// key affects no rows. Other inserts of a stored key fail.
+type conflictDriver struct {
+	stored map[string]bool
+}
+
+func (d *conflictDriver) Connect(context.Context) (driver.Conn, error) { return conflictConn{d}, nil }
+func (d *conflictDriver) Driver() driver.Driver                        { return nil }
+
+type conflictConn struct{ d *conflictDriver }
+
+func (c conflictConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c conflictConn) Close() error                        { return nil }
+func (c conflictConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c conflictConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	k := fmt.Sprintf("%v|%v", args[0].Value, args[1].Value)
+	if c.d.stored[k] {
+		if strings.Contains(query, "ON CONFLICT (meter_id, timestamp) DO NOTHING") {
+			return driver.RowsAffected(0), nil
+		}
+		return nil, errors.New(`duplicate key value violates unique constraint "readings_meter_timestamp_idx"`)
+	}
+	c.d.stored[k] = true
+	return driver.RowsAffected(1), nil
+}
+
+// queryDriver answers queries with the rows query returns, standing in for
+// postgres in read tests
+type queryDriver struct {
+	query func(query string, args []driver.NamedValue) (columns []string, rows [][]driver.Value)
+}
+
+func (d *queryDriver) Connect(context.Context) (driver.Conn, error) { return queryConn{d}, nil }
+func (d *queryDriver) Driver() driver.Driver                        { return nil }
+
+type queryConn struct{ d *queryDriver }
+
+func (c queryConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c queryConn) Close() error                        { return nil }
+func (c queryConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c queryConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	columns, rows := c.d.query(query, args)
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestInsertReadingSkipsDuplicates(t *testing.T) {
+	d := &conflictDriver{stored: make(map[string]bool)}
+	repos := New(sqlx.NewDb(sql.OpenDB(d), "postgres"))
+
+	ts := timeutil.FromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name    string
+		reading domain.Reading
+		want    bool
+	}{
+		{name: "new reading", reading: domain.Reading{MeterID: 1, Timestamp: ts, PowerKW: 1.2}, want: true},
+		{name: "redelivered reading", reading: domain.Reading{MeterID: 1, Timestamp: ts, PowerKW: 1.2}, want: false},
+		{name: "same instant, other meter", reading: domain.Reading{MeterID: 2, Timestamp: ts, PowerKW: 3.4}, want: true},
+		{name: "same meter, next instant", reading: domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(ts.Add(time.Minute)), PowerKW: 1.3}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repos.InsertReading(&tt.reading)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("inserted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertReadingWithoutDatabase(t *testing.T) {
+	if _, err := New(nil).InsertReading(&domain.Reading{}); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("err = %v, want ErrNoDatabase", err)
+	}
+}
+
+func TestSchemaHasReadingKey(t *testing.T) {
+	schema, err := os.ReadFile("../../scripts/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(schema), "CREATE UNIQUE INDEX IF NOT EXISTS readings_meter_timestamp_idx ON readings(meter_id, timestamp)") {
+		t.Error("schema.sql lacks the unique (meter_id, timestamp) index InsertReading relies on")
+	}
+}
+
+func TestListFacilitiesPages(t *testing.T) {
+	// Five facilities, ids 1..5; the fake applies "id > $1 ORDER BY id LIMIT $2"
+	d := &queryDriver{query: func(_ string, args []driver.NamedValue) ([]string, [][]driver.Value) {
+		after, limit := args[0].Value.(int64), args[1].Value.(int64)
+		var rows [][]driver.Value
+		for id := after + 1; id <= 5 && int64(len(rows)) < limit; id++ {
+			rows = append(rows, []driver.Value{id, fmt.Sprintf("Facility %d", id), "UTC"})
+		}
+		return []string{"id", "name", "timezone"}, rows
+	}}
+	repos := New(sqlx.NewDb(sql.OpenDB(d), "postgres"))
+
+	tests := []struct {
+		name     string
+		after    int64
+		limit    int
+		wantIDs  []int64
+		wantNext int64
+	}{
+		{name: "first page", limit: 2, wantIDs: []int64{1, 2}, wantNext: 2},
+		{name: "middle page", after: 2, limit: 2, wantIDs: []int64{3, 4}, wantNext: 4},
+		{name: "last page", after: 4, limit: 2, wantIDs: []int64{5}},
+		{name: "exact fit has no next page", limit: 5, wantIDs: []int64{1, 2, 3, 4, 5}},
+		{name: "default page size", wantIDs: []int64{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, next, err := repos.ListFacilities(tt.after, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int64, len(items))
+			for i, f := range items {
+				ids[i] = f.ID
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) || next != tt.wantNext {
+				t.Errorf("ids, next = %v, %d; want %v, %d", ids, next, tt.wantIDs, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestListMetersPages(t *testing.T) {
+	// Meters with gaps in their ids, as deletes leave them; the fake applies
+	// "id > $1 ORDER BY id LIMIT $2"
+	ids := []int64{3, 7, 8, 12, 20}
+	d := &queryDriver{query: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value) {
+		if !strings.Contains(query, "FROM meters WHERE id > $1 ORDER BY id LIMIT $2") {
+			t.Errorf("query %q", query)
+		}
+		after, limit := args[0].Value.(int64), args[1].Value.(int64)
+		var rows [][]driver.Value
+		for _, id := range ids {
+			if id > after && int64(len(rows)) < limit {
+				rows = append(rows, []driver.Value{id, int64(1), fmt.Sprintf("SN-%d", id), "", 1.0, 1.0, 0.0, "active"})
+			}
+		}
+		return []string{"id", "facility_id", "serial", "zone", "voltage_gain", "power_gain", "power_offset", "status"}, rows
+	}}
+	repos := New(sqlx.NewDb(sql.OpenDB(d), "postgres"))
+
+	tests := []struct {
+		name     string
+		after    int64
+		limit    int
+		wantIDs  []int64
+		wantNext int64
+	}{
+		{name: "first page", limit: 2, wantIDs: []int64{3, 7}, wantNext: 7},
+		{name: "next page from the cursor", after: 7, limit: 2, wantIDs: []int64{8, 12}, wantNext: 12},
+		{name: "last page", after: 12, limit: 2, wantIDs: []int64{20}},
+		{name: "cursor between ids", after: 9, limit: 2, wantIDs: []int64{12, 20}},
+		{name: "past the end", after: 20, limit: 2, wantIDs: []int64{}},
+		{name: "default page size", wantIDs: ids},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, next, err := repos.ListMeters(tt.after, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int64, len(items))
+			for i, m := range items {
+				got[i] = m.ID
+				if m.Serial != fmt.Sprintf("SN-%d", m.ID) {
+					t.Errorf("meter %d serial %q", m.ID, m.Serial)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) || next != tt.wantNext {
+				t.Errorf("ids, next = %v, %d; want %v, %d", got, next, tt.wantIDs, tt.wantNext)
+			}
+		})
+	}
+
+	// Following next cursors visits every meter once and ends with 0
+	var seen []int64
+	for after, pages := int64(0), 0; ; pages++ {
+		if pages > len(ids) {
+			t.Fatal("paging doesn't end")
+		}
+		items, next, err := repos.ListMeters(after, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range items {
+			seen = append(seen, m.ID)
+		}
+		if next == 0 {
+			break
+		}
+		after = next
+	}
+	if fmt.Sprint(seen) != fmt.Sprint(ids) {
+		t.Errorf("walked %v, want %v", seen, ids)
+	}
+}
+
+func TestClampPageSize(t *testing.T) {
+	tests := []struct {
+		limit, want int
+	}{
+		{0, DefaultPageSize},
+		{-5, DefaultPageSize},
+		{50, 50},
+		{MaxPageSize + 1, MaxPageSize},
+	}
+	for _, tt := range tests {
+		if got := ClampPageSize(tt.limit); got != tt.want {
+			t.Errorf("ClampPageSize(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+// sampleReading is a row of the readings table joined to its meter's facility
+type sampleReading struct {
+	facilityID, meterID int64
+	at                  time.Time
+	powerKW             float64
+}
+
+// aggregateDriver answers DailyAggregate's two queries by grouping rows the
+// way postgres would
+func aggregateDriver(rows []sampleReading) *queryDriver {
+	return &queryDriver{query: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value) {
+		facility, start, end := args[0].Value.(int64), args[1].Value.(time.Time), args[2].Value.(time.Time)
+		hourly := strings.Contains(query, "date_trunc")
+		var loc *time.Location
+		var meter int64
+		if hourly {
+			loc, _ = time.LoadLocation(args[3].Value.(string))
+			meter = args[4].Value.(int64)
+		} else {
+			meter = args[3].Value.(int64)
+		}
+
+		type group struct {
+			key        time.Time
+			meter      int64
+			n          int64
+			sum, maxKW float64
+		}
+		var groups []*group
+		find := func(key time.Time, meterID int64) *group {
+			for _, g := range groups {
+				if g.key.Equal(key) && g.meter == meterID {
+					return g
+				}
+			}
+			g := &group{key: key, meter: meterID}
+			groups = append(groups, g)
+			return g
+		}
+		for _, r := range rows {
+			if r.facilityID != facility || r.at.Before(start) || !r.at.Before(end) || meter != 0 && r.meterID != meter {
+				continue
+			}
+			var g *group
+			if hourly {
+				local := r.at.In(loc)
+				g = find(time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, loc), 0)
+			} else {
+				g = find(time.Time{}, r.meterID)
+			}
+			g.n++
+			g.sum += r.powerKW
+			g.maxKW = max(g.maxKW, r.powerKW)
+		}
+
+		var out [][]driver.Value
+		for _, g := range groups {
+			if hourly {
+				out = append(out, []driver.Value{g.key, g.n, g.sum, g.sum / float64(g.n), g.maxKW})
+			} else {
+				out = append(out, []driver.Value{g.meter, g.maxKW})
+			}
+		}
+		if hourly {
+			return []string{"hour", "reading_count", "total_power", "avg_power", "peak_power"}, out
+		}
+		return []string{"meter_id", "peak_power"}, out
+	}}
+}
+
+func TestDailyAggregate(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	rows := []sampleReading{
+		{1, 10, at(0, 0), 2},
+		{1, 10, at(0, 30), 4},
+		{1, 11, at(0, 15), 3},
+		{1, 10, at(13, 0), 9},
+		{1, 11, at(13, 45), 5},
+		{1, 10, at(24, 0), 50}, // next day
+		{2, 20, at(13, 0), 70}, // other facility
+	}
+
+	tests := []struct {
+		name       string
+		meter      int64
+		wantCount  int
+		wantTotal  float64
+		wantPeak   float64
+		wantHours  int
+		wantMeters map[int64]float64
+	}{
+		{name: "whole facility", wantCount: 5, wantTotal: 23, wantPeak: 9, wantHours: 2, wantMeters: map[int64]float64{10: 9, 11: 5}},
+		{name: "one meter", meter: 11, wantCount: 2, wantTotal: 8, wantPeak: 5, wantHours: 2, wantMeters: map[int64]float64{11: 5}},
+		{name: "meter without readings", meter: 12, wantMeters: map[int64]float64{}},
+	}
+	repos := New(sqlx.NewDb(sql.OpenDB(aggregateDriver(rows)), "postgres"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg, err := repos.DailyAggregate(1, tt.meter, day, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if agg.ReadingCount != tt.wantCount || agg.TotalPower != tt.wantTotal || agg.PeakPower != tt.wantPeak || len(agg.Hourly) != tt.wantHours {
+				t.Errorf("count, total, peak, hours = %d, %v, %v, %d; want %d, %v, %v, %d",
+					agg.ReadingCount, agg.TotalPower, agg.PeakPower, len(agg.Hourly), tt.wantCount, tt.wantTotal, tt.wantPeak, tt.wantHours)
+			}
+			if tt.wantCount > 0 && agg.AveragePower != tt.wantTotal/float64(tt.wantCount) {
+				t.Errorf("average = %v, want %v", agg.AveragePower, tt.wantTotal/float64(tt.wantCount))
+			}
+			if fmt.Sprint(agg.MeterPeaks) != fmt.Sprint(tt.wantMeters) {
+				t.Errorf("meter peaks = %v, want %v", agg.MeterPeaks, tt.wantMeters)
+			}
+		})
+	}
+}
+
+func TestDailyAggregateHoursInZone(t *testing.T) {
+	// +05:30 has no whole-hour boundary in UTC, so hours must be cut locally
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, kolkata)
+	rows := []sampleReading{
+		{1, 10, day.Add(10 * time.Minute), 2},
+		{1, 10, day.Add(50 * time.Minute), 4},
+		{1, 10, day.Add(70 * time.Minute), 6},
+		{1, 10, day.Add(-10 * time.Minute), 80}, // previous local day
+	}
+	repos := New(sqlx.NewDb(sql.OpenDB(aggregateDriver(rows)), "postgres"))
+	agg, err := repos.DailyAggregate(1, 0, day, kolkata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agg.Hourly) != 2 || agg.Hourly[0].ReadingCount != 2 || agg.Hourly[1].ReadingCount != 1 || agg.PeakPower != 6 {
+		t.Errorf("hourly = %+v, peak %v; want 2 then 1 readings, peak 6", agg.Hourly, agg.PeakPower)
+	}
+	if !agg.Date.Equal(day) {
+		t.Errorf("date = %v, want local midnight %v", agg.Date, day)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// Aggregate policies: how a daily summary without a meter_id combines the
+// facility's meters (AGGREGATE_POLICY)
+const (
+	// AggregateSum adds up every meter's consumption; peak and average power
+	// are over individual readings
+	AggregateSum = "sum"
+	// AggregateAverage reports the figures of the facility's average meter
+	AggregateAverage = "average"
+	// AggregatePerMeterRequired rejects facility-wide summaries
+	AggregatePerMeterRequired = "per_meter_required"
+)
+
+// ErrMeterRequired is returned for a facility-wide summary under AggregatePerMeterRequired
+var ErrMeterRequired = errors.New("meter_id is required")
+
+// ParseAggregatePolicy validates AGGREGATE_POLICY; empty means AggregateSum
+func ParseAggregatePolicy(s string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(s)); p {
+	case "":
+		return AggregateSum, nil
+	case AggregateSum, AggregateAverage, AggregatePerMeterRequired:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown AGGREGATE_POLICY %q (want %s, %s or %s)",
+			s, AggregateSum, AggregateAverage, AggregatePerMeterRequired)
+	}
+}
+
+// meterPeaks is each meter's highest kW sample in readings
+func meterPeaks(readings []domain.Reading) map[int64]float64 {
+	peaks := make(map[int64]float64)
+	for _, r := range readings {
+		if p, ok := peaks[r.MeterID]; !ok || r.PowerKW > p {
+			peaks[r.MeterID] = r.PowerKW
+		}
+	}
+	return peaks
+}
+
+// applyAggregatePolicy turns a facility-wide summary into the policy's view.
+// Under AggregateAverage consumption is divided by the meter count and the
+// peak is the mean of the meters' own peaks; average power and efficiency are
+// per reading and already meter-average. The hourly breakdown stays facility-wide.
+func applyAggregatePolicy(summary *DailySummary, peaks map[int64]float64, policy string) {
+	summary.MeterCount = len(peaks)
+	summary.Aggregate = policy
+	if policy != AggregateAverage || len(peaks) == 0 {
+		return
+	}
+
+	n := float64(len(peaks))
+	summary.TotalConsumption /= n
+	summary.TotalConsumptionMWh /= n
+	var sum float64
+	for _, p := range peaks {
+		sum += p
+	}
+	summary.PeakPower = sum / n
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestParseAggregatePolicy(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: AggregateSum},
+		{raw: "sum", want: AggregateSum},
+		{raw: " Average ", want: AggregateAverage},
+		{raw: "per_meter_required", want: AggregatePerMeterRequired},
+		{raw: "max", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAggregatePolicy(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAggregatePolicy(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetDailySummaryAggregatePolicy(t *testing.T) {
+	// Yesterday, hourly: meter 1 at 10 kW peaking at 30, meter 2 at 4 kW peaking at 6
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	store := memstore.New()
+	for h := 0; h < 24; h++ {
+		ts := timeutil.FromTime(day.Add(time.Duration(h) * time.Hour))
+		p1, p2 := 10.0, 4.0
+		if h == 12 {
+			p1, p2 = 30, 6
+		}
+		for _, rd := range []domain.Reading{{MeterID: 1, Timestamp: ts, PowerKW: p1}, {MeterID: 2, Timestamp: ts, PowerKW: p2}} {
+			if err := store.PutReading(&rd, "facility-001"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	summary := func(policy string, meterID int64) (*DailySummary, error) {
+		s := &AnalyticsService{store: store, zones: newFacilityTimezones(repository.New(nil), nil), aggregate: policy}
+		return s.GetDailySummary("facility-001", meterID, day)
+	}
+	// Consumption of the meters together and apart, as the sum policy reports them
+	both, err := summary(AggregateSum, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, err := summary(AggregateSum, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	two, err := summary(AggregateSum, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(both.TotalConsumption-(one.TotalConsumption+two.TotalConsumption)) > 1e-9 || both.TotalConsumption == 0 {
+		t.Fatalf("facility consumption %v, meters %v and %v", both.TotalConsumption, one.TotalConsumption, two.TotalConsumption)
+	}
+
+	tests := []struct {
+		name            string
+		policy          string
+		meterID         int64
+		wantConsumption float64
+		wantPeak        float64
+		wantMeters      int
+		wantAggregate   string
+		wantErr         error
+	}{
+		{name: "sum", policy: AggregateSum, wantConsumption: both.TotalConsumption, wantPeak: 30, wantMeters: 2, wantAggregate: AggregateSum},
+		{name: "average", policy: AggregateAverage, wantConsumption: both.TotalConsumption / 2, wantPeak: 18, wantMeters: 2, wantAggregate: AggregateAverage},
+		{name: "per meter required", policy: AggregatePerMeterRequired, wantErr: ErrMeterRequired},
+		{name: "per meter required with a meter", policy: AggregatePerMeterRequired, meterID: 2, wantConsumption: two.TotalConsumption, wantPeak: 6, wantMeters: 1},
+		{name: "average ignores a meter's own summary", policy: AggregateAverage, meterID: 1, wantConsumption: one.TotalConsumption, wantPeak: 30, wantMeters: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := summary(tt.policy, tt.meterID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if math.Abs(got.TotalConsumption-tt.wantConsumption) > 1e-9 || got.PeakPower != tt.wantPeak {
+				t.Errorf("consumption %v, peak %v; want %v, %v", got.TotalConsumption, got.PeakPower, tt.wantConsumption, tt.wantPeak)
+			}
+			if got.MeterID != tt.meterID || got.MeterCount != tt.wantMeters || got.Aggregate != tt.wantAggregate {
+				t.Errorf("meter %d, %d meters, aggregate %q; want %d, %d, %q", got.MeterID, got.MeterCount, got.Aggregate, tt.meterID, tt.wantMeters, tt.wantAggregate)
+			}
+		})
+	}
+}
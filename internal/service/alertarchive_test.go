@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+)
+
+func TestParseAlertRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		days     int
+		spec     string
+		facility string
+		want     int
+		wantErr  bool
+	}{
+		{name: "default", days: 90, facility: "facility-001", want: 90},
+		{name: "facility override", days: 90, spec: "facility-001=30, facility-002=365", facility: "facility-002", want: 365},
+		{name: "other facility", days: 90, spec: "facility-001=30", facility: "facility-003", want: 90},
+		{name: "no default", days: 0, wantErr: true},
+		{name: "missing days", days: 90, spec: "facility-001", wantErr: true},
+		{name: "negative days", days: 90, spec: "facility-001=-5", wantErr: true},
+		{name: "missing facility", days: 90, spec: "=30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseAlertRetention(tt.days, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && r.daysFor(tt.facility) != tt.want {
+				t.Errorf("daysFor(%s) = %d, want %d", tt.facility, r.daysFor(tt.facility), tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveAlerts(t *testing.T) {
+	store := memstore.New()
+	for _, msg := range []string{"open", "acknowledged", "dismissed"} {
+		if err := store.CreateAlert("facility-001", "meter-1", "high", "anomaly", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts := func() map[string]cloud.Alert {
+		all, err := store.GetAlerts("facility-001", cloud.AlertFilter{IncludeDismissed: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		byMessage := make(map[string]cloud.Alert)
+		for _, a := range all {
+			byMessage[a.Message] = a
+		}
+		return byMessage
+	}
+	if err := store.AcknowledgeAlert(alerts()["acknowledged"].AlertID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DismissAlert(alerts()["dismissed"].AlertID, "ops@example.com", "noise"); err != nil {
+		t.Fatal(err)
+	}
+
+	var uploads map[string][]byte
+	var uploadErr error
+	retention, err := parseAlertRetention(90, "facility-001=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &AlertService{store: store, retention: retention, archive: func(key string, data []byte) error {
+		if uploadErr != nil {
+			return uploadErr
+		}
+		uploads[key] = data
+		return nil
+	}}
+
+	tests := []struct {
+		name         string
+		uploadErr    error
+		wantArchived []string // messages of the alerts archived
+		wantExpiring []string // messages of the alerts with a TTL afterwards
+	}{
+		{name: "upload fails", uploadErr: errors.New("bucket unavailable")},
+		{name: "closed alerts", wantArchived: []string{"acknowledged", "dismissed"}, wantExpiring: []string{"acknowledged", "dismissed"}},
+		{name: "already archived", wantExpiring: []string{"acknowledged", "dismissed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads, uploadErr = make(map[string][]byte), tt.uploadErr
+			run, err := s.ArchiveAlerts("facility-001")
+			if tt.uploadErr != nil {
+				if !errors.Is(err, tt.uploadErr) {
+					t.Fatalf("err = %v, want %v", err, tt.uploadErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if run.Open != 1 || run.Archived != len(tt.wantArchived) || run.RetentionDays != 30 {
+				t.Errorf("run %+v", run)
+			}
+
+			var archived []string
+			for key, data := range uploads {
+				if !strings.HasPrefix(key, "alerts/archive/facility-001/") || !strings.HasSuffix(key, ".ndjson") {
+					t.Errorf("archived to %s", key)
+				}
+				sc := bufio.NewScanner(bytes.NewReader(data))
+				for sc.Scan() {
+					var a cloud.Alert
+					if err := json.Unmarshal(sc.Bytes(), &a); err != nil {
+						t.Fatal(err)
+					}
+					archived = append(archived, a.Message)
+				}
+			}
+			sort.Strings(archived)
+			if strings.Join(archived, ",") != strings.Join(tt.wantArchived, ",") {
+				t.Errorf("archived %v, want %v", archived, tt.wantArchived)
+			}
+
+			// Closed alerts expire 30 days after closing; the open one never does
+			var expiring []string
+			for _, msg := range []string{"open", "acknowledged", "dismissed"} {
+				a := alerts()[msg]
+				if a.ExpiresAt == 0 {
+					continue
+				}
+				expiring = append(expiring, msg)
+				if a.ExpiresAt != a.ClosedAt()+30*24*3600 {
+					t.Errorf("%s alert expires at %d, closed at %d", msg, a.ExpiresAt, a.ClosedAt())
+				}
+			}
+			if strings.Join(expiring, ",") != strings.Join(tt.wantExpiring, ",") {
+				t.Errorf("expiring %v, want %v", expiring, tt.wantExpiring)
+			}
+		})
+	}
+
+	// Nothing sets an expiry on an open alert
+	if err := store.ExpireAlert(alerts()["open"].AlertID, 1); !errors.Is(err, cloud.ErrAlertOpen) {
+		t.Errorf("expiring an open alert: err = %v, want ErrAlertOpen", err)
+	}
+}
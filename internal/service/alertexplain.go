@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// Context readings for an explanation span this long either side of the
+// alert, and are only fetched for alerts younger than explainContextMaxAge
+// since the store can only read back from now
+const (
+	explainContextSpan   = 30 * time.Minute
+	explainContextMaxAge = 48 * time.Hour
+)
+
+// AnomalyBreakdown is the detector's view of an anomaly alert, from the
+// metadata the anomaly Lambda stores with it
+type AnomalyBreakdown struct {
+	ObservedKW       float64 `json:"observed_kw"`
+	MeanKW           float64 `json:"mean_kw"`
+	StdDevKW         float64 `json:"std_dev_kw"`
+	ThresholdKW      float64 `json:"threshold_kw"`
+	DeviationPercent float64 `json:"deviation_percent"`
+	// Sigmas is how many standard deviations the reading was from the mean
+	Sigmas float64 `json:"sigmas"`
+
+	// Older alerts were stored without these
+	Method string  `json:"method,omitempty"`
+	Window int     `json:"window,omitempty"`
+	Sigma  float64 `json:"sigma,omitempty"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// AlertExplanation says why an alert fired. Only anomaly alerts carry the
+// detector's figures; other alerts get their message as the summary.
+type AlertExplanation struct {
+	AlertID   string            `json:"alert_id"`
+	Type      string            `json:"type"`
+	Severity  string            `json:"severity"`
+	Timestamp time.Time         `json:"timestamp"`
+	Explained bool              `json:"explained"`
+	Summary   string            `json:"summary"`
+	Steps     []string          `json:"steps,omitempty"`
+	Anomaly   *AnomalyBreakdown `json:"anomaly,omitempty"`
+	// Context holds the meter's readings around the alert, when still recent
+	// enough to fetch
+	Context []domain.Reading `json:"context_readings,omitempty"`
+}
+
+// ExplainAlert builds the explanation of one alert. It returns
+// cloud.ErrAlertNotFound for unknown alerts.
+func (s *Services) ExplainAlert(alertID string) (*AlertExplanation, error) {
+	if s.Store == nil {
+		return nil, fmt.Errorf("alert explanations need the dynamodb or memory backend")
+	}
+	alert, err := s.Store.GetAlert(alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	ex := &AlertExplanation{
+		AlertID:   alert.AlertID,
+		Type:      alert.Type,
+		Severity:  alert.Severity,
+		Timestamp: alert.Timestamp.UTC(),
+		Summary:   alert.Message,
+	}
+
+	an := anomalyBreakdown(alert.Metadata)
+	if alert.Type != "anomaly" || an == nil {
+		return ex, nil
+	}
+	ex.Explained = true
+	ex.Anomaly = an
+	ex.Summary, ex.Steps = describeAnomaly(an)
+
+	// The reading's own time when stored, else the alert's
+	at := alert.Timestamp.Unix()
+	if ts, ok := metaFloat(alert.Metadata, "reading_timestamp"); ok && ts > 0 {
+		at = int64(ts)
+	}
+	ctx, err := s.contextReadings(alert, at)
+	if err != nil {
+		return nil, err
+	}
+	ex.Context = ctx
+	return ex, nil
+}
+
+// anomalyBreakdown reads the detector's figures from alert metadata, or nil
+// when the alert carries none
+func anomalyBreakdown(meta map[string]interface{}) *AnomalyBreakdown {
+	observed, okObs := metaFloat(meta, "current_power")
+	mean, okMean := metaFloat(meta, "average_power")
+	if !okObs || !okMean {
+		return nil
+	}
+
+	an := &AnomalyBreakdown{ObservedKW: observed, MeanKW: mean}
+	an.StdDevKW, _ = metaFloat(meta, "std_dev")
+	an.ThresholdKW, _ = metaFloat(meta, "threshold")
+	an.DeviationPercent, _ = metaFloat(meta, "deviation_percent")
+	an.Sigma, _ = metaFloat(meta, "sigma")
+	if w, ok := metaFloat(meta, "window"); ok {
+		an.Window = int(w)
+	}
+	an.Method, _ = meta["method"].(string)
+	an.Reason, _ = meta["reason"].(string)
+	if an.StdDevKW > 0 {
+		an.Sigmas = numfmt.Round((observed-mean)/an.StdDevKW, 2)
+	}
+	return an
+}
+
+// describeAnomaly renders the breakdown as a sentence and the steps of the
+// threshold check
+func describeAnomaly(an *AnomalyBreakdown) (string, []string) {
+	direction := "above"
+	if an.ObservedKW < an.MeanKW {
+		direction = "below"
+	}
+	summary := fmt.Sprintf("Power of %.2f kW was %.1f%% %s the recent average of %.2f kW",
+		an.ObservedKW, math.Abs(an.DeviationPercent), direction, an.MeanKW)
+
+	var steps []string
+	window := "recent readings"
+	if an.Window > 0 {
+		window = fmt.Sprintf("the last %d readings", an.Window)
+	}
+	steps = append(steps, fmt.Sprintf("Baseline over %s: mean %.2f kW, standard deviation %.2f kW", window, an.MeanKW, an.StdDevKW))
+	if an.ThresholdKW > 0 {
+		limit := "the mean plus the configured number of standard deviations"
+		if an.Sigma > 0 {
+			limit = fmt.Sprintf("the mean plus %g standard deviations", an.Sigma)
+		}
+		steps = append(steps, fmt.Sprintf("Alert threshold: %.2f kW (%s)", an.ThresholdKW, limit))
+	}
+	if an.StdDevKW > 0 {
+		steps = append(steps, fmt.Sprintf("Observed %.2f kW, %.2f standard deviations from the mean", an.ObservedKW, an.Sigmas))
+	}
+	if an.Method != "" {
+		steps = append(steps, "Detection method: "+an.Method)
+	}
+	if an.Reason != "" {
+		steps = append(steps, "Detector reason: "+an.Reason)
+	}
+	return summary, steps
+}
+
+// contextReadings returns the alert meter's readings within
+// explainContextSpan of at, oldest first; none for alerts too old to fetch
+func (s *Services) contextReadings(alert *cloud.Alert, at int64) ([]domain.Reading, error) {
+	age := time.Since(time.Unix(at, 0))
+	if age > explainContextMaxAge {
+		return nil, nil
+	}
+	// A minute to spare, since the store reads back from its own now; the span
+	// check below is exact
+	readings, err := s.Store.GetRecentReadings(alert.FacilityID, age+explainContextSpan+time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context readings: %w", err)
+	}
+
+	// Anomaly alerts name the meter as their equipment
+	meterID, meterErr := strconv.ParseInt(alert.EquipmentID, 10, 64)
+	span := int64(explainContextSpan / time.Second)
+	var out []domain.Reading
+	for _, r := range readings {
+		ts := r.Timestamp.Unix()
+		if ts < at-span || ts > at+span {
+			continue
+		}
+		if meterErr == nil && r.MeterID != meterID {
+			continue
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp.Time) })
+	return out, nil
+}
+
+// metaFloat reads a numeric metadata value
+func metaFloat(meta map[string]interface{}, key string) (float64, bool) {
+	switch v := meta[key].(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
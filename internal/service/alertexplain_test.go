@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// metadataStore serves memstore alerts with the metadata the anomaly Lambda
+// would have stored with them
+type metadataStore struct {
+	*memstore.Store
+	meta map[string]map[string]interface{} // by alert message
+}
+
+func (s *metadataStore) GetAlert(alertID string) (*cloud.Alert, error) {
+	alert, err := s.Store.GetAlert(alertID)
+	if err == nil {
+		alert.Metadata = s.meta[alert.Message]
+	}
+	return alert, err
+}
+
+func TestExplainAlert(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	store := &metadataStore{Store: memstore.New(), meta: map[string]map[string]interface{}{
+		"spike": {
+			"current_power": 42.0, "average_power": 20.0, "std_dev": 5.0, "threshold": 35.0,
+			"deviation_percent": 110.0, "method": "zscore", "window": int64(20), "sigma": 3.0,
+			"reason": "power above threshold", "reading_timestamp": float64(now.Add(-time.Hour).Unix()),
+		},
+		"legacy spike": {"current_power": 42.0, "average_power": 20.0},
+		"no figures":   {"threshold": 35.0},
+	}}
+	// Meter 7's readings every 10 minutes over the last two hours, and meter 8's
+	for m := 0; m <= 120; m += 10 {
+		for _, meter := range []int64{7, 8} {
+			rd := domain.Reading{MeterID: meter, Timestamp: timeutil.FromTime(now.Add(-time.Duration(m) * time.Minute)), PowerKW: float64(m)}
+			if err := store.PutReading(&rd, "facility-001"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, a := range []struct{ equipment, typ, msg string }{
+		{"7", "anomaly", "spike"},
+		{"7", "anomaly", "legacy spike"},
+		{"7", "anomaly", "no figures"},
+		{"pump-2", "maintenance", "Bearing wear predicted within 14 days"},
+	} {
+		if err := store.CreateAlert("facility-001", a.equipment, "high", a.typ, a.msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts, err := store.GetAlerts("facility-001", cloud.AlertFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]string)
+	for _, a := range alerts {
+		ids[a.Message] = a.AlertID
+	}
+	s := &Services{Store: store}
+
+	tests := []struct {
+		name          string
+		message       string
+		wantExplained bool
+		wantSummary   string
+		wantSteps     []string
+		wantSigmas    float64
+		wantContext   []float64 // context readings' power, i.e. minutes ago
+	}{
+		{
+			name: "anomaly", message: "spike", wantExplained: true,
+			wantSummary: "Power of 42.00 kW was 110.0% above the recent average of 20.00 kW",
+			wantSteps: []string{
+				"Baseline over the last 20 readings: mean 20.00 kW, standard deviation 5.00 kW",
+				"Alert threshold: 35.00 kW (the mean plus 3 standard deviations)",
+				"Observed 42.00 kW, 4.40 standard deviations from the mean",
+				"Detection method: zscore",
+				"Detector reason: power above threshold",
+			},
+			wantSigmas:  4.4,
+			wantContext: []float64{90, 80, 70, 60, 50, 40, 30},
+		},
+		{
+			name: "anomaly stored before the detector's figures", message: "legacy spike", wantExplained: true,
+			wantSummary: "Power of 42.00 kW was 0.0% above the recent average of 20.00 kW",
+			wantSteps:   []string{"Baseline over recent readings: mean 20.00 kW, standard deviation 0.00 kW"},
+			wantContext: []float64{30, 20, 10, 0},
+		},
+		{name: "anomaly without figures", message: "no figures", wantSummary: "no figures"},
+		{name: "maintenance", message: "Bearing wear predicted within 14 days", wantSummary: "Bearing wear predicted within 14 days"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex, err := s.ExplainAlert(ids[tt.message])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ex.AlertID != ids[tt.message] || ex.Explained != tt.wantExplained || ex.Summary != tt.wantSummary {
+				t.Errorf("alert %s explained %v: %q; want %v: %q", ex.AlertID, ex.Explained, ex.Summary, tt.wantExplained, tt.wantSummary)
+			}
+			if !reflect.DeepEqual(ex.Steps, tt.wantSteps) {
+				t.Errorf("steps %q\nwant  %q", ex.Steps, tt.wantSteps)
+			}
+			if (ex.Anomaly != nil) != tt.wantExplained || ex.Anomaly != nil && ex.Anomaly.Sigmas != tt.wantSigmas {
+				t.Errorf("breakdown %+v", ex.Anomaly)
+			}
+			var power []float64
+			for _, r := range ex.Context {
+				if r.MeterID != 7 {
+					t.Errorf("context reading of meter %d", r.MeterID)
+				}
+				power = append(power, r.PowerKW)
+			}
+			if !reflect.DeepEqual(power, tt.wantContext) {
+				t.Errorf("context readings %v, want %v", power, tt.wantContext)
+			}
+		})
+	}
+
+	if _, err := s.ExplainAlert("nope"); !errors.Is(err, cloud.ErrAlertNotFound) {
+		t.Errorf("unknown alert: err = %v, want ErrAlertNotFound", err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// ErrInvalidAlertCursor is returned for a cursor PageAlerts didn't issue
+var ErrInvalidAlertCursor = errors.New("invalid alert cursor")
+
+// PageAlerts returns up to limit alerts of a newest-first listing, starting
+// after the alert cursor points at, and the cursor of the following page
+// (empty on the last). The cursor names the last alert of the previous page
+// and its timestamp, so paging stays in place as new alerts arrive; if that
+// alert is gone the page resumes at the first older alert.
+func PageAlerts(alerts []cloud.Alert, cursor string, limit int) ([]cloud.Alert, string, error) {
+	start := 0
+	if cursor != "" {
+		ts, id, err := decodeAlertCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = len(alerts)
+		for i, a := range alerts {
+			if a.AlertID == id {
+				start = i + 1
+				break
+			}
+			if a.Timestamp.Unix() < ts {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := start + limit
+	if end >= len(alerts) {
+		return alerts[start:], "", nil
+	}
+	last := alerts[end-1]
+	return alerts[start:end], encodeAlertCursor(last.Timestamp.Unix(), last.AlertID), nil
+}
+
+func encodeAlertCursor(ts int64, alertID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ts, 10) + ":" + alertID))
+}
+
+func decodeAlertCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidAlertCursor
+	}
+	tsPart, id, ok := strings.Cut(string(raw), ":")
+	ts, err := strconv.ParseInt(tsPart, 10, 64)
+	if !ok || err != nil || id == "" {
+		return 0, "", ErrInvalidAlertCursor
+	}
+	return ts, id, nil
+}
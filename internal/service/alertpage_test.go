@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// newestFirst returns alerts a-<ts> for the timestamps, as the listing orders them
+func newestFirst(timestamps ...int64) []cloud.Alert {
+	alerts := make([]cloud.Alert, len(timestamps))
+	for i, ts := range timestamps {
+		alerts[i] = cloud.Alert{AlertID: fmt.Sprintf("a-%d", ts), Timestamp: timeutil.FromUnix(ts)}
+	}
+	return alerts
+}
+
+func alertIDsOf(alerts []cloud.Alert) []string {
+	ids := make([]string, len(alerts))
+	for i, a := range alerts {
+		ids[i] = a.AlertID
+	}
+	return ids
+}
+
+func TestPageAlerts(t *testing.T) {
+	alerts := newestFirst(107, 106, 105, 104, 103, 102, 101)
+	tests := []struct {
+		name      string
+		limit     int
+		wantPages [][]string
+	}{
+		{name: "even pages", limit: 7, wantPages: [][]string{{"a-107", "a-106", "a-105", "a-104", "a-103", "a-102", "a-101"}}},
+		{name: "short last page", limit: 3, wantPages: [][]string{{"a-107", "a-106", "a-105"}, {"a-104", "a-103", "a-102"}, {"a-101"}}},
+		{name: "one per page", limit: 1, wantPages: [][]string{{"a-107"}, {"a-106"}, {"a-105"}, {"a-104"}, {"a-103"}, {"a-102"}, {"a-101"}}},
+		{name: "more than there are", limit: 50, wantPages: [][]string{alertIDsOf(alerts)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages [][]string
+			cursor := ""
+			for i := 0; i <= len(alerts); i++ {
+				page, next, err := PageAlerts(alerts, cursor, tt.limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, alertIDsOf(page))
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if !reflect.DeepEqual(pages, tt.wantPages) {
+				t.Errorf("pages %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestPageAlertsCursor(t *testing.T) {
+	alerts := newestFirst(107, 106, 105, 104, 103)
+	_, cursor, err := PageAlerts(alerts, "", 2) // after a-106
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		alerts  []cloud.Alert
+		cursor  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unchanged", alerts: alerts, cursor: cursor, want: []string{"a-105", "a-104"}},
+		{name: "new alerts arrived", alerts: newestFirst(109, 108, 107, 106, 105, 104, 103), cursor: cursor, want: []string{"a-105", "a-104"}},
+		{name: "cursor alert gone", alerts: newestFirst(107, 105, 104, 103), cursor: cursor, want: []string{"a-105", "a-104"}},
+		{name: "everything newer", alerts: newestFirst(109, 108), cursor: cursor, want: []string{}},
+		{name: "not base64", alerts: alerts, cursor: "%%%", wantErr: true},
+		{name: "no alert id", alerts: alerts, cursor: encodeAlertCursor(106, ""), wantErr: true},
+		{name: "no timestamp", alerts: alerts, cursor: "YS0xMDY", wantErr: true}, // "a-106"
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, _, err := PageAlerts(tt.alerts, tt.cursor, 2)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAlertCursor) {
+					t.Fatalf("err = %v, want ErrInvalidAlertCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := alertIDsOf(page); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("page %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
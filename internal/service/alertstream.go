@@ -0,0 +1,66 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// alertStreamBuffer is how many alerts a subscriber may fall behind before
+// further alerts are dropped for it
+const alertStreamBuffer = 16
+
+// alertHub fans alerts created by this process out to the facility's
+// subscribers. Alerts stored by the anomaly Lambda don't pass through it.
+type alertHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan cloud.Notification]struct{}
+}
+
+func newAlertHub() *alertHub {
+	return &alertHub{subs: make(map[string]map[chan cloud.Notification]struct{})}
+}
+
+// subscribe registers a subscriber for facilityID; cancel unregisters it and
+// closes the channel
+func (h *alertHub) subscribe(facilityID string) (<-chan cloud.Notification, func()) {
+	ch := make(chan cloud.Notification, alertStreamBuffer)
+	h.mu.Lock()
+	if h.subs[facilityID] == nil {
+		h.subs[facilityID] = make(map[chan cloud.Notification]struct{})
+	}
+	h.subs[facilityID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[facilityID], ch)
+			if len(h.subs[facilityID]) == 0 {
+				delete(h.subs, facilityID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish delivers n to the facility's subscribers without blocking; a
+// subscriber with a full buffer misses it
+func (h *alertHub) publish(n cloud.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[n.FacilityID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// SubscribeAlerts streams the alerts created for facilityID from now on;
+// call cancel when done
+func (s *AlertService) SubscribeAlerts(facilityID string) (<-chan cloud.Notification, func()) {
+	return s.hub.subscribe(facilityID)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// APIKeys maps facility-scoped API keys to the facility they may access.
+// Keys are held as SHA-256 digests so the file never contains a usable key.
+type APIKeys struct {
+	byDigest map[string]string // hex digest -> facility ID
+}
+
+// loadAPIKeys reads the JSON facility→key digests file at path
+// ({"facility-001": ["<sha256 hex>", ...]}); an empty path disables API keys
+func loadAPIKeys(path string) (*APIKeys, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var parsed map[string][]string
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys: %w", err)
+	}
+	keys, err := NewAPIKeys(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("API keys file %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// NewAPIKeys builds API keys from SHA-256 hex digests of the keys, listed per
+// facility ID
+func NewAPIKeys(digestsByFacility map[string][]string) (*APIKeys, error) {
+	keys := &APIKeys{byDigest: make(map[string]string)}
+	for facilityID, digests := range digestsByFacility {
+		if strings.TrimSpace(facilityID) == "" {
+			return nil, fmt.Errorf("empty facility ID")
+		}
+		for _, d := range digests {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if b, err := hex.DecodeString(d); err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("facility %q: API key digest %q is not a SHA-256 hex digest", facilityID, d)
+			}
+			if other, ok := keys.byDigest[d]; ok && other != facilityID {
+				return nil, fmt.Errorf("API key digest %q is listed for both %q and %q", d, other, facilityID)
+			}
+			keys.byDigest[d] = facilityID
+		}
+	}
+	if len(keys.byDigest) == 0 {
+		return nil, fmt.Errorf("no API keys listed")
+	}
+	return keys, nil
+}
+
+// Enabled reports whether requests must carry a facility API key
+func (k *APIKeys) Enabled() bool { return k != nil }
+
+// Facility returns the facility key belongs to; ok is false for unknown keys
+func (k *APIKeys) Facility(key string) (facilityID string, ok bool) {
+	if k == nil || key == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(key))
+	facilityID, ok = k.byDigest[hex.EncodeToString(sum[:])]
+	return facilityID, ok
+}
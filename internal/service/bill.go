@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// ErrInvalidBillMonth is returned for a billing month that isn't YYYY-MM or hasn't started
+var ErrInvalidBillMonth = errors.New("invalid billing month")
+
+// BillDay is one facility-local day's line on a monthly bill
+type BillDay struct {
+	Date string              `json:"date"`
+	Cost costmodel.Breakdown `json:"cost"`
+}
+
+// MonthlyBill is an invoice-style rollup of a facility's month. Each day is
+// priced with the cost model and the month's figures are the sums of the
+// rounded daily figures, so the lines add up to the total as printed.
+type MonthlyBill struct {
+	FacilityID string `json:"facility_id"`
+	Month      string `json:"month"`
+	Timezone   string `json:"timezone"`
+	// Partial is set for the current month, which is billed through today
+	Partial bool `json:"partial"`
+
+	TotalKWh     float64            `json:"total_kwh"`
+	PeakKWh      float64            `json:"peak_kwh"`
+	OffPeakKWh   float64            `json:"offpeak_kwh"`
+	PeakCost     float64            `json:"peak_cost"`
+	OffPeakCost  float64            `json:"offpeak_cost"`
+	EnergyCost   float64            `json:"energy_cost"`
+	PeakDemandKW float64            `json:"peak_demand_kw"`
+	DemandCharge float64            `json:"demand_charge"`
+	FixedCharges []costmodel.Charge `json:"fixed_charges"`
+	Subtotal     float64            `json:"subtotal"`
+	Taxes        []costmodel.Charge `json:"taxes"`
+	TotalCost    float64            `json:"total_cost"`
+	Days         []BillDay          `json:"days"`
+
+	ReportURL string `json:"report_url,omitempty"`
+}
+
+// GenerateMonthlyBill prices each day of yearMonth (YYYY-MM, facility-local)
+// and, when S3 is configured, stores the bill as JSON under bills/
+func (s *AnalyticsService) GenerateMonthlyBill(facilityID, yearMonth string) (*MonthlyBill, error) {
+	month, err := time.Parse("2006-01", yearMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidBillMonth)
+	}
+
+	loc := s.zones.Location(facilityID)
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 1, 0)
+	now := time.Now()
+	if !start.Before(now) {
+		return nil, fmt.Errorf("%w: %s has not started", ErrInvalidBillMonth, yearMonth)
+	}
+
+	readings, err := s.getReadingsForWindow(facilityID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings: %w", err)
+	}
+
+	bill, err := buildMonthlyBill(facilityID, start, end, now, readings, loc, s.costs)
+	if err != nil {
+		return nil, err
+	}
+	if s.s3 == nil {
+		return bill, nil
+	}
+
+	data, err := json.MarshalIndent(bill, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bill: %w", err)
+	}
+	key := fmt.Sprintf("bills/%s/%s.json", facilityID, yearMonth)
+	bill.ReportURL, err = s.s3.UploadReport(key, data, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload bill: %w", err)
+	}
+	return bill, nil
+}
+
+// buildMonthlyBill prices the local days from start up to end, stopping at
+// now for a month in progress
+func buildMonthlyBill(facilityID string, start, end, now time.Time, readings []domain.Reading, loc *time.Location, model costmodel.Model) (*MonthlyBill, error) {
+	bill := &MonthlyBill{
+		FacilityID:   facilityID,
+		Month:        start.Format("2006-01"),
+		Timezone:     loc.String(),
+		Partial:      now.Before(end),
+		FixedCharges: make([]costmodel.Charge, len(model.FixedCharges)),
+		Taxes:        make([]costmodel.Charge, len(model.Taxes)),
+		Days:         []BillDay{},
+	}
+	for i, c := range model.FixedCharges {
+		bill.FixedCharges[i].Name = c.Name
+	}
+	for i, t := range model.Taxes {
+		bill.Taxes[i].Name = t.Name
+	}
+
+	byDay := make(map[string][]domain.Reading)
+	for _, r := range readings {
+		day := r.Timestamp.In(loc).Format("2006-01-02")
+		byDay[day] = append(byDay[day], r)
+	}
+
+	// Money is summed in cents so the month reconciles with its days exactly
+	var peakCost, offPeakCost, energyCost, demandCharge, subtotal, totalCost costmodel.Cents
+	fixed := make([]costmodel.Cents, len(bill.FixedCharges))
+	taxes := make([]costmodel.Cents, len(bill.Taxes))
+	for day := start; day.Before(end) && day.Before(now); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		cost, err := model.Cost(costSamples(byDay[date]), loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", date, err)
+		}
+		bill.Days = append(bill.Days, BillDay{Date: date, Cost: cost})
+
+		bill.TotalKWh += cost.TotalKWh
+		bill.PeakKWh += cost.PeakKWh
+		bill.OffPeakKWh += cost.OffPeakKWh
+		peakCost += costmodel.ToCents(cost.PeakCost)
+		offPeakCost += costmodel.ToCents(cost.OffPeakCost)
+		energyCost += costmodel.ToCents(cost.EnergyCost)
+		demandCharge += costmodel.ToCents(cost.DemandCharge)
+		subtotal += costmodel.ToCents(cost.Subtotal)
+		totalCost += costmodel.ToCents(cost.TotalCost)
+		if cost.PeakDemandKW > bill.PeakDemandKW {
+			bill.PeakDemandKW = cost.PeakDemandKW
+		}
+		for i, c := range cost.FixedCharges {
+			fixed[i] += costmodel.ToCents(c.Amount)
+		}
+		for i, t := range cost.Taxes {
+			taxes[i] += costmodel.ToCents(t.Amount)
+		}
+	}
+
+	// Sums of 2-decimal figures pick up float noise; round them back
+	bill.TotalKWh = numfmt.Round(bill.TotalKWh, 2)
+	bill.PeakKWh = numfmt.Round(bill.PeakKWh, 2)
+	bill.OffPeakKWh = numfmt.Round(bill.OffPeakKWh, 2)
+	bill.PeakCost = peakCost.Float64()
+	bill.OffPeakCost = offPeakCost.Float64()
+	bill.EnergyCost = energyCost.Float64()
+	bill.DemandCharge = demandCharge.Float64()
+	bill.Subtotal = subtotal.Float64()
+	bill.TotalCost = totalCost.Float64()
+	for i := range bill.FixedCharges {
+		bill.FixedCharges[i].Amount = fixed[i].Float64()
+	}
+	for i := range bill.Taxes {
+		bill.Taxes[i].Amount = taxes[i].Float64()
+	}
+	return bill, nil
+}
+
+// costSamples is the readings as the cost model prices them
+func costSamples(readings []domain.Reading) []costmodel.Sample {
+	samples := make([]costmodel.Sample, len(readings))
+	for i, r := range readings {
+		samples[i] = costmodel.Sample{Time: r.Timestamp.Time, PowerKW: r.PowerKW}
+	}
+	return samples
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestBuildMonthlyBill(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	model := costmodel.Default(0.137, 3600)
+	model.DemandChargePerKW = 0.35
+	model.FixedCharges = []costmodel.Charge{{Name: "service", Amount: 1.1}}
+	model.Taxes = []costmodel.Tax{{Name: "vat", Percent: 19}}
+
+	// March 2024 crosses the DST change; hourly readings whose load varies by day and hour
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 1, 0)
+	var readings []domain.Reading
+	for ts := start; ts.Before(end); ts = ts.Add(time.Hour) {
+		kw := 5 + float64(ts.Day()%7) + float64(ts.Hour()%5)*0.37
+		readings = append(readings, domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(ts), PowerKW: kw})
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		wantDays    int
+		wantPartial bool
+	}{
+		{name: "closed month", now: time.Date(2024, 4, 2, 9, 0, 0, 0, loc), wantDays: 31},
+		{name: "month in progress", now: time.Date(2024, 3, 10, 9, 0, 0, 0, loc), wantDays: 10, wantPartial: true},
+		{name: "first morning", now: time.Date(2024, 3, 1, 0, 30, 0, 0, loc), wantDays: 1, wantPartial: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upTo []domain.Reading
+			for _, r := range readings {
+				if r.Timestamp.Before(tt.now) {
+					upTo = append(upTo, r)
+				}
+			}
+			bill, err := buildMonthlyBill("facility-001", start, end, tt.now, upTo, loc, model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bill.Month != "2024-03" || bill.Timezone != "Europe/Berlin" || bill.Partial != tt.wantPartial || len(bill.Days) != tt.wantDays {
+				t.Fatalf("month %s in %s, partial %v, %d days; want 2024-03, partial %v, %d days",
+					bill.Month, bill.Timezone, bill.Partial, len(bill.Days), tt.wantPartial, tt.wantDays)
+			}
+
+			// The bill is the sum of its days, to the cent
+			var kWh float64
+			var total, demand, fixed, tax costmodel.Cents
+			var peakKW float64
+			for i, d := range bill.Days {
+				if want := start.AddDate(0, 0, i).Format("2006-01-02"); d.Date != want {
+					t.Errorf("day %d is %s, want %s", i, d.Date, want)
+				}
+				kWh += d.Cost.TotalKWh
+				total += costmodel.ToCents(d.Cost.TotalCost)
+				demand += costmodel.ToCents(d.Cost.DemandCharge)
+				fixed += costmodel.ToCents(d.Cost.FixedCharges[0].Amount)
+				tax += costmodel.ToCents(d.Cost.Taxes[0].Amount)
+				peakKW = math.Max(peakKW, d.Cost.PeakDemandKW)
+			}
+			if bill.TotalCost != total.Float64() || bill.DemandCharge != demand.Float64() || bill.PeakDemandKW != peakKW {
+				t.Errorf("total %v, demand %v at %v kW; days sum to %v, %v at %v kW",
+					bill.TotalCost, bill.DemandCharge, bill.PeakDemandKW, total.Float64(), demand.Float64(), peakKW)
+			}
+			if bill.FixedCharges[0] != (costmodel.Charge{Name: "service", Amount: fixed.Float64()}) || bill.Taxes[0] != (costmodel.Charge{Name: "vat", Amount: tax.Float64()}) {
+				t.Errorf("fixed %+v, taxes %+v", bill.FixedCharges, bill.Taxes)
+			}
+			if math.Abs(bill.TotalKWh-kWh) > 0.005 || math.Abs(bill.PeakKWh+bill.OffPeakKWh-bill.TotalKWh) > 0.01 {
+				t.Errorf("%v kWh (%v peak, %v off-peak), days sum to %v", bill.TotalKWh, bill.PeakKWh, bill.OffPeakKWh, kWh)
+			}
+			// Every hourly reading billed up to now, 23 on the day the clocks go forward
+			var want float64
+			for _, r := range upTo {
+				want += r.PowerKW
+			}
+			if math.Abs(bill.TotalKWh-want) > 0.01*float64(len(bill.Days)) {
+				t.Errorf("%v kWh billed, %v read", bill.TotalKWh, want)
+			}
+		})
+	}
+}
+
+// pagedStore serves readings pageSize at a time whatever limit is asked for,
+// as DynamoDB stops a query at 1 MB, and counts the pages read
+type pagedStore struct {
+	*memstore.Store
+	pageSize int
+	pages    int
+}
+
+func (s *pagedStore) GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, _ int, fields cloud.Projection) (*domain.ReadingPage, error) {
+	s.pages++
+	return s.Store.GetRecentReadingsPage(facilityID, duration, cursor, s.pageSize, fields)
+}
+
+func TestGenerateMonthlyBillPages(t *testing.T) {
+	// Last month hourly, and this month so far, which the bill must leave out
+	thisMonth := time.Now().UTC()
+	thisMonth = time.Date(thisMonth.Year(), thisMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := thisMonth.AddDate(0, -1, 0)
+	store := memstore.New()
+	var month []domain.Reading
+	for ts := start; ts.Before(time.Now()); ts = ts.Add(time.Hour) {
+		rd := domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(ts), PowerKW: 5 + float64(ts.Day()%7)}
+		if err := store.PutReading(&rd, "facility-001"); err != nil {
+			t.Fatal(err)
+		}
+		if ts.Before(thisMonth) {
+			month = append(month, rd)
+		}
+	}
+	model := costmodel.Default(0.137, 3600)
+	want, err := buildMonthlyBill("facility-001", start, thisMonth, time.Now(), month, time.UTC, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pageSize int
+		maxPages int
+	}{
+		{name: "one page", pageSize: 0, maxPages: 1},
+		{name: "many pages", pageSize: 100, maxPages: len(month)/100 + 1},
+		{name: "small pages", pageSize: 7, maxPages: len(month)/7 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paged := &pagedStore{Store: store, pageSize: tt.pageSize}
+			s := &AnalyticsService{store: paged, zones: newFacilityTimezones(repository.New(nil), nil), costs: model}
+			bill, err := s.GenerateMonthlyBill("facility-001", start.Format("2006-01"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Every day of the month is priced, and nothing after it
+			if !reflect.DeepEqual(bill, want) {
+				t.Errorf("bill for %d days totalling %v, want %d days totalling %v", len(bill.Days), bill.TotalCost, len(want.Days), want.TotalCost)
+			}
+			// Paging stops once it passes the month
+			if paged.pages > tt.maxPages {
+				t.Errorf("read %d pages, want at most %d", paged.pages, tt.maxPages)
+			}
+		})
+	}
+}
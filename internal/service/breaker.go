@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// ErrCircuitOpen is returned without calling the store while its breaker is
+// open after repeated failures
+var ErrCircuitOpen = errors.New("cloud store unavailable (circuit open)")
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// breakerCacheSize bounds the reads kept for fallback while the breaker is open
+const breakerCacheSize = 256
+
+// BreakerStats is a snapshot of the cloud store's circuit breaker
+type BreakerStats struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Threshold           int        `json:"threshold"`
+	CooldownSeconds     int        `json:"cooldown_seconds"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+	Opened              uint64     `json:"opened"`
+	Rejected            uint64     `json:"rejected"`
+	FallbackServed      uint64     `json:"fallback_served"`
+}
+
+// breaker opens after threshold consecutive failures and rejects calls for
+// cooldown; then one trial call is let through, closing it on success and
+// reopening it on failure
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial call is in flight
+
+	opened   atomic.Uint64
+	rejected atomic.Uint64
+	fallback atomic.Uint64
+}
+
+func (b *breaker) state(now time.Time) string {
+	switch {
+	case b.failures < b.threshold:
+		return BreakerClosed
+	case now.Sub(b.openedAt) < b.cooldown:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// allow reports whether a call may go through now
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state(time.Now()) {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if !b.trial {
+			b.trial = true
+			return true
+		}
+	}
+	b.rejected.Add(1)
+	return false
+}
+
+// record counts a call's outcome
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		// Opening, or reopening after a failed trial, restarts the cooldown
+		b.openedAt = time.Now()
+		b.opened.Add(1)
+	}
+}
+
+func (b *breaker) stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	st := BreakerStats{
+		State:               b.state(now),
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+		CooldownSeconds:     int(b.cooldown / time.Second),
+		Opened:              b.opened.Load(),
+		Rejected:            b.rejected.Load(),
+		FallbackServed:      b.fallback.Load(),
+	}
+	if st.State == BreakerOpen {
+		until := b.openedAt.Add(b.cooldown)
+		st.OpenUntil = &until
+	}
+	return st
+}
+
+// storeFailure reports whether err means the store is unhealthy; lookups of
+// missing items and refused state changes are answers, not failures
+func storeFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, cloud.ErrAlertNotFound) &&
+		!errors.Is(err, cloud.ErrAlertOpen)
+}
+
+// breakerStore guards a Store with a circuit breaker. While it is open, calls
+// fail fast with ErrCircuitOpen, except reads of recent readings, alerts and
+// equipment, which are answered from their last successful result when there
+// is one.
+type breakerStore struct {
+	Store
+	b *breaker
+
+	mu    sync.Mutex
+	cache map[string]interface{}
+}
+
+// newBreakerStore wraps store; a non-positive threshold returns store unwrapped
+func newBreakerStore(store Store, threshold int, cooldown time.Duration) Store {
+	if threshold <= 0 {
+		return store
+	}
+	bs := &breakerStore{
+		Store: store,
+		b:     &breaker{threshold: threshold, cooldown: cooldown},
+		cache: make(map[string]interface{}),
+	}
+	// Keep PredictionStore visible through the wrapper
+	if ps, ok := store.(PredictionStore); ok {
+		return &breakerPredictionStore{breakerStore: bs, predictions: ps}
+	}
+	return bs
+}
+
+// BreakerStats reports the breaker around the cloud store; ok is false when
+// the store has none (postgres or memory backend, or the breaker is disabled)
+func (s *Services) BreakerStats() (BreakerStats, bool) {
+	bs, ok := s.Store.(interface{ breakerStats() BreakerStats })
+	if !ok {
+		return BreakerStats{}, false
+	}
+	return bs.breakerStats(), true
+}
+
+func (s *breakerStore) breakerStats() BreakerStats { return s.b.stats() }
+
+// do runs call through the breaker
+func (s *breakerStore) do(call func() error) error {
+	if !s.b.allow() {
+		return ErrCircuitOpen
+	}
+	err := call()
+	s.b.record(storeFailure(err))
+	return err
+}
+
+// read runs a cacheable read through the breaker, remembering its result
+// under key and serving the remembered one while the breaker rejects calls
+func read[T any](s *breakerStore, key string, call func() (T, error)) (T, error) {
+	var out T
+	err := s.do(func() error {
+		var err error
+		out, err = call()
+		return err
+	})
+	switch {
+	case err == nil:
+		s.mu.Lock()
+		if _, ok := s.cache[key]; ok || len(s.cache) < breakerCacheSize {
+			s.cache[key] = out
+		}
+		s.mu.Unlock()
+	case errors.Is(err, ErrCircuitOpen):
+		s.mu.Lock()
+		cached, ok := s.cache[key]
+		s.mu.Unlock()
+		if ok {
+			s.b.fallback.Add(1)
+			return cached.(T), nil
+		}
+	}
+	return out, err
+}
+
+func (s *breakerStore) PutReading(reading *domain.Reading, facilityID string) error {
+	return s.do(func() error { return s.Store.PutReading(reading, facilityID) })
+}
+
+func (s *breakerStore) BatchPutReadings(readings []domain.Reading, facilityID string) error {
+	return s.do(func() error { return s.Store.BatchPutReadings(readings, facilityID) })
+}
+
+func (s *breakerStore) GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error) {
+	key := fmt.Sprintf("readings|%s|%d", facilityID, duration)
+	return read(s, key, func() ([]domain.Reading, error) {
+		return s.Store.GetRecentReadings(facilityID, duration)
+	})
+}
+
+func (s *breakerStore) GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, limit int, fields cloud.Projection) (*domain.ReadingPage, error) {
+	call := func() (*domain.ReadingPage, error) {
+		return s.Store.GetRecentReadingsPage(facilityID, duration, cursor, limit, fields)
+	}
+	// Only first pages are kept; a later page is meaningless without its cursor's store
+	if cursor != "" {
+		var page *domain.ReadingPage
+		err := s.do(func() error {
+			var err error
+			page, err = call()
+			return err
+		})
+		return page, err
+	}
+	key := fmt.Sprintf("readings-page|%s|%d|%d|%v", facilityID, duration, limit, fields)
+	return read(s, key, call)
+}
+
+func (s *breakerStore) GetReading(facilityID string, meterID int64, timestamp time.Time) (*domain.Reading, error) {
+	var rd *domain.Reading
+	err := s.do(func() error {
+		var err error
+		rd, err = s.Store.GetReading(facilityID, meterID, timestamp)
+		return err
+	})
+	return rd, err
+}
+
+func (s *breakerStore) CreateAlert(facilityID, equipmentID, severity, alertType, message string) error {
+	return s.do(func() error { return s.Store.CreateAlert(facilityID, equipmentID, severity, alertType, message) })
+}
+
+func (s *breakerStore) GetAlerts(facilityID string, filter cloud.AlertFilter) ([]cloud.Alert, error) {
+	key := fmt.Sprintf("alerts|%s|%+v", facilityID, filter)
+	return read(s, key, func() ([]cloud.Alert, error) {
+		return s.Store.GetAlerts(facilityID, filter)
+	})
+}
+
+func (s *breakerStore) GetAlert(alertID string) (*cloud.Alert, error) {
+	var a *cloud.Alert
+	err := s.do(func() error {
+		var err error
+		a, err = s.Store.GetAlert(alertID)
+		return err
+	})
+	return a, err
+}
+
+func (s *breakerStore) AcknowledgeAlert(alertID string) error {
+	return s.do(func() error { return s.Store.AcknowledgeAlert(alertID) })
+}
+
+func (s *breakerStore) DismissAlert(alertID, dismissedBy, reason string) error {
+	return s.do(func() error { return s.Store.DismissAlert(alertID, dismissedBy, reason) })
+}
+
+func (s *breakerStore) DeleteAlert(alertID string) error {
+	return s.do(func() error { return s.Store.DeleteAlert(alertID) })
+}
+
+func (s *breakerStore) ExpireAlert(alertID string, expiresAt int64) error {
+	return s.do(func() error { return s.Store.ExpireAlert(alertID, expiresAt) })
+}
+
+func (s *breakerStore) GetEquipment(facilityID string) ([]cloud.Equipment, error) {
+	return read(s, "equipment|"+facilityID, func() ([]cloud.Equipment, error) {
+		return s.Store.GetEquipment(facilityID)
+	})
+}
+
+func (s *breakerStore) UpdateEquipmentHealth(equipmentID string, healthScore float64) error {
+	return s.do(func() error { return s.Store.UpdateEquipmentHealth(equipmentID, healthScore) })
+}
+
+// breakerPredictionStore is a breakerStore over a store that also keeps predictions
+type breakerPredictionStore struct {
+	*breakerStore
+	predictions PredictionStore
+}
+
+func (s *breakerPredictionStore) PutMaintenancePredictions(predictions []domain.MaintenancePrediction) error {
+	return s.do(func() error { return s.predictions.PutMaintenancePredictions(predictions) })
+}
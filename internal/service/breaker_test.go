@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+var errDegraded = errors.New("dynamodb: service unavailable")
+
+// flakyStore is a memory store whose readings and alert lookups fail while
+// down, counting the calls that reach it
+type flakyStore struct {
+	*memstore.Store
+	down  bool
+	calls int
+}
+
+func (s *flakyStore) PutReading(reading *domain.Reading, facilityID string) error {
+	s.calls++
+	if s.down {
+		return errDegraded
+	}
+	return s.Store.PutReading(reading, facilityID)
+}
+
+func (s *flakyStore) GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error) {
+	s.calls++
+	if s.down {
+		return nil, errDegraded
+	}
+	return s.Store.GetRecentReadings(facilityID, duration)
+}
+
+func (s *flakyStore) GetAlert(alertID string) (*cloud.Alert, error) {
+	s.calls++
+	if s.down {
+		return nil, errDegraded
+	}
+	return s.Store.GetAlert(alertID)
+}
+
+// cooledDown moves the breaker's opening back past its cooldown
+func cooledDown(bs *breakerStore) {
+	bs.b.mu.Lock()
+	defer bs.b.mu.Unlock()
+	bs.b.openedAt = bs.b.openedAt.Add(-bs.b.cooldown)
+}
+
+func TestBreakerStore(t *testing.T) {
+	reading := &domain.Reading{MeterID: 1, Timestamp: timeutil.Now(), Voltage: 230, PowerKW: 2}
+	type step struct {
+		down     bool
+		cooldown bool // let the cooldown pass first
+		missing  bool // look up an alert that doesn't exist instead of writing
+		wantErr  error
+	}
+	tests := []struct {
+		name         string
+		steps        []step
+		wantCalls    int
+		wantState    string
+		wantOpened   uint64
+		wantRejected uint64
+	}{
+		{
+			name:      "opens after the threshold",
+			steps:     []step{{down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {wantErr: ErrCircuitOpen}, {wantErr: ErrCircuitOpen}},
+			wantCalls: 3, wantState: BreakerOpen, wantOpened: 1, wantRejected: 2,
+		},
+		{
+			name:      "a success resets the count",
+			steps:     []step{{down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {}, {down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}},
+			wantCalls: 5, wantState: BreakerClosed,
+		},
+		{
+			name:      "missing items aren't failures",
+			steps:     []step{{missing: true, wantErr: cloud.ErrAlertNotFound}, {missing: true, wantErr: cloud.ErrAlertNotFound}, {missing: true, wantErr: cloud.ErrAlertNotFound}, {}},
+			wantCalls: 4, wantState: BreakerClosed,
+		},
+		{
+			name:      "recovers after the cooldown",
+			steps:     []step{{down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {wantErr: ErrCircuitOpen}, {cooldown: true}, {}},
+			wantCalls: 5, wantState: BreakerClosed, wantOpened: 1, wantRejected: 1,
+		},
+		{
+			name:      "a failed trial reopens",
+			steps:     []step{{down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {down: true, wantErr: errDegraded}, {cooldown: true, down: true, wantErr: errDegraded}, {wantErr: ErrCircuitOpen}},
+			wantCalls: 4, wantState: BreakerOpen, wantOpened: 2, wantRejected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &flakyStore{Store: memstore.New()}
+			bs := newBreakerStore(store, 3, time.Minute).(*breakerPredictionStore).breakerStore
+			for i, st := range tt.steps {
+				if st.cooldown {
+					cooledDown(bs)
+				}
+				store.down = st.down
+				var err error
+				if st.missing {
+					_, err = bs.GetAlert("missing")
+				} else {
+					err = bs.PutReading(reading, "facility-001")
+				}
+				if !errors.Is(err, st.wantErr) || (err != nil) != (st.wantErr != nil) {
+					t.Fatalf("step %d: err = %v, want %v", i, err, st.wantErr)
+				}
+			}
+			got := bs.breakerStats()
+			if store.calls != tt.wantCalls || got.State != tt.wantState || got.Opened != tt.wantOpened || got.Rejected != tt.wantRejected {
+				t.Errorf("%d calls, stats %+v; want %d calls, %s, opened %d, rejected %d",
+					store.calls, got, tt.wantCalls, tt.wantState, tt.wantOpened, tt.wantRejected)
+			}
+			if (got.OpenUntil != nil) != (tt.wantState == BreakerOpen) {
+				t.Errorf("open until %v in state %s", got.OpenUntil, got.State)
+			}
+		})
+	}
+}
+
+func TestBreakerStoreFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		readFirst    bool // read while the store is healthy
+		wantReadings int
+		wantErr      error
+		wantFallback uint64
+	}{
+		{name: "last good result", readFirst: true, wantReadings: 1, wantFallback: 1},
+		{name: "nothing cached", wantErr: ErrCircuitOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &flakyStore{Store: memstore.New()}
+			if err := store.Store.PutReading(&domain.Reading{MeterID: 1, Timestamp: timeutil.Now(), PowerKW: 2}, "facility-001"); err != nil {
+				t.Fatal(err)
+			}
+			bs := newBreakerStore(store, 1, time.Minute).(*breakerPredictionStore).breakerStore
+			if tt.readFirst {
+				if _, err := bs.GetRecentReadings("facility-001", time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			}
+			store.down = true
+			if err := bs.PutReading(&domain.Reading{MeterID: 1}, "facility-001"); !errors.Is(err, errDegraded) {
+				t.Fatalf("err = %v, want the store's", err)
+			}
+			calls := store.calls
+
+			readings, err := bs.GetRecentReadings("facility-001", time.Hour)
+			if !errors.Is(err, tt.wantErr) || len(readings) != tt.wantReadings {
+				t.Fatalf("%d readings, err = %v; want %d, %v", len(readings), err, tt.wantReadings, tt.wantErr)
+			}
+			// The open breaker answers without the store
+			if store.calls != calls || bs.breakerStats().FallbackServed != tt.wantFallback {
+				t.Errorf("%d store calls while open, %d served from cache", store.calls-calls, bs.breakerStats().FallbackServed)
+			}
+		})
+	}
+}
+
+func TestNewBreakerStore(t *testing.T) {
+	store := memstore.New()
+	if got := newBreakerStore(store, 0, time.Minute); got != Store(store) {
+		t.Errorf("threshold 0 wrapped the store in %T", got)
+	}
+	wrapped := newBreakerStore(store, 5, time.Minute)
+	if _, ok := wrapped.(PredictionStore); !ok {
+		t.Errorf("%T hides PredictionStore", wrapped)
+	}
+	stats, ok := (&Services{Store: wrapped}).BreakerStats()
+	if !ok || stats.State != BreakerClosed || stats.Threshold != 5 || stats.CooldownSeconds != 60 {
+		t.Errorf("stats %+v, %v", stats, ok)
+	}
+	if _, ok := (&Services{Store: store}).BreakerStats(); ok {
+		t.Error("a store without a breaker reports stats")
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// calibrationCacheTTL bounds how long a meter's calibration is reused before
+// it is re-read, so recalibrating a meter takes effect without a restart
+const calibrationCacheTTL = 5 * time.Minute
+
+type cachedCalibration struct {
+	meter   domain.Meter
+	found   bool
+	expires time.Time
+}
+
+// calibrator applies per-meter calibration from the meters table to readings
+type calibrator struct {
+	repos *repository.Repos
+
+	mu    sync.Mutex
+	cache map[int64]cachedCalibration
+}
+
+func newCalibrator(repos *repository.Repos) *calibrator {
+	return &calibrator{repos: repos, cache: make(map[int64]cachedCalibration)}
+}
+
+// Apply calibrates rd in place (voltage*gain, power*gain + offset) and keeps
+// the reported values in RawVoltage/RawPowerKW. Meters without calibration, or
+// that can't be looked up (e.g. no database), are left untouched. Readings of
+// meters that aren't active get the meter's status in MeterStatus.
+func (c *calibrator) Apply(rd *domain.Reading) {
+	m, ok := c.lookup(rd.MeterID)
+	if !ok {
+		return
+	}
+	if !m.Active() {
+		rd.MeterStatus = m.Status
+	}
+	if m.Calibrated() {
+		applyCalibration(rd, m)
+	}
+}
+
+func applyCalibration(rd *domain.Reading, m domain.Meter) {
+	rawV, rawP := rd.Voltage, rd.PowerKW
+	rd.RawVoltage = &rawV
+	rd.RawPowerKW = &rawP
+	rd.Voltage = rawV * m.VoltageGain
+	rd.PowerKW = rawP*m.PowerGain + m.PowerOffset
+}
+
+// forget drops the cached meter so a change to it applies to the next reading
+func (c *calibrator) forget(meterID int64) {
+	c.mu.Lock()
+	delete(c.cache, meterID)
+	c.mu.Unlock()
+}
+
+func (c *calibrator) lookup(meterID int64) (domain.Meter, bool) {
+	c.mu.Lock()
+	if e, ok := c.cache[meterID]; ok && time.Now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.meter, e.found
+	}
+	c.mu.Unlock()
+
+	// Missing meters are cached too so an unknown meter doesn't cost a query per reading
+	var e cachedCalibration
+	if m, err := c.repos.GetMeter(meterID); err == nil {
+		e.meter, e.found = *m, true
+	}
+	e.expires = time.Now().Add(calibrationCacheTTL)
+
+	c.mu.Lock()
+	c.cache[meterID] = e
+	c.mu.Unlock()
+	return e.meter, e.found
+}
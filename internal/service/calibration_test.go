@@ -0,0 +1,92 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+func TestCalibratorApply(t *testing.T) {
+	identity := domain.Meter{ID: 1, VoltageGain: 1, PowerGain: 1}
+	tests := []struct {
+		name        string
+		meter       *domain.Meter // nil: the meter can't be looked up
+		wantVoltage float64
+		wantPower   float64
+		wantRaw     bool
+		wantStatus  string
+	}{
+		{name: "unknown meter is untouched", wantVoltage: 230, wantPower: 2},
+		{name: "identity calibration is untouched", meter: &identity, wantVoltage: 230, wantPower: 2},
+		{
+			name:        "gain and offset",
+			meter:       &domain.Meter{ID: 1, VoltageGain: 1.02, PowerGain: 0.95, PowerOffset: -0.1},
+			wantVoltage: 234.6, wantPower: 1.8, wantRaw: true,
+		},
+		{
+			name:        "offset only",
+			meter:       &domain.Meter{ID: 1, VoltageGain: 1, PowerGain: 1, PowerOffset: 0.25},
+			wantVoltage: 230, wantPower: 2.25, wantRaw: true,
+		},
+		{
+			name:        "inactive meter is flagged",
+			meter:       &domain.Meter{ID: 1, VoltageGain: 1, PowerGain: 1, Status: domain.MeterDecommissioned},
+			wantVoltage: 230, wantPower: 2, wantStatus: domain.MeterDecommissioned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCalibrator(repository.New(nil))
+			if tt.meter != nil {
+				c.cache[1] = cachedCalibration{meter: *tt.meter, found: true, expires: time.Now().Add(time.Minute)}
+			}
+
+			rd := domain.Reading{MeterID: 1, Voltage: 230, PowerKW: 2}
+			c.Apply(&rd)
+			if math.Abs(rd.Voltage-tt.wantVoltage) > 1e-9 || math.Abs(rd.PowerKW-tt.wantPower) > 1e-9 {
+				t.Errorf("voltage, power = %v, %v; want %v, %v", rd.Voltage, rd.PowerKW, tt.wantVoltage, tt.wantPower)
+			}
+			if tt.wantRaw {
+				if rd.RawVoltage == nil || rd.RawPowerKW == nil || *rd.RawVoltage != 230 || *rd.RawPowerKW != 2 {
+					t.Errorf("raw values not preserved: %v, %v", rd.RawVoltage, rd.RawPowerKW)
+				}
+			} else if rd.RawVoltage != nil || rd.RawPowerKW != nil {
+				t.Errorf("uncalibrated reading has raw values %v, %v", rd.RawVoltage, rd.RawPowerKW)
+			}
+			if rd.MeterStatus != tt.wantStatus {
+				t.Errorf("meter status = %q, want %q", rd.MeterStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCalibratorCachesMissingMeters(t *testing.T) {
+	c := newCalibrator(repository.New(nil))
+	c.Apply(&domain.Reading{MeterID: 7})
+	e, ok := c.cache[7]
+	if !ok || e.found {
+		t.Fatalf("cache entry = %+v, %v; want a cached miss", e, ok)
+	}
+
+	c.forget(7)
+	if _, ok := c.cache[7]; ok {
+		t.Error("forget left the meter cached")
+	}
+}
+
+func TestStoreBatchCalibrates(t *testing.T) {
+	store := &batchStore{}
+	s := newImportService(store)
+	s.calibrator.cache[1] = cachedCalibration{meter: domain.Meter{ID: 1, VoltageGain: 1, PowerGain: 2}, found: true, expires: time.Now().Add(time.Minute)}
+
+	if err := s.storeBatch("facility-001", []domain.Reading{{MeterID: 1, PowerKW: 1.5}, {MeterID: 2, PowerKW: 1.5}}); err != nil {
+		t.Fatal(err)
+	}
+	got := store.stored()
+	if got[0].PowerKW != 3 || *got[0].RawPowerKW != 1.5 || got[1].PowerKW != 1.5 {
+		t.Errorf("stored power = %v, %v; want 3 (raw 1.5), 1.5", got[0].PowerKW, got[1].PowerKW)
+	}
+}
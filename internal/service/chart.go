@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// chartMovingWindow is the trailing window of the moving-average series, in hours
+const chartMovingWindow = 3
+
+// Dataset is one Chart.js dataset; Data is aligned index-for-index with the series labels
+type Dataset struct {
+	Label string    `json:"label"`
+	Data  []float64 `json:"data"`
+}
+
+// ChartSeries is the {labels, datasets} shape Chart.js consumes directly
+type ChartSeries struct {
+	Labels   []string  `json:"labels"`
+	Datasets []Dataset `json:"datasets"`
+}
+
+// ChartData holds the chart-ready series for a facility's day. Hour labels
+// are in the facility's Timezone.
+type ChartData struct {
+	FacilityID    string      `json:"facility_id"`
+	Date          string      `json:"date"`
+	Timezone      string      `json:"timezone"`
+	PowerCurve    ChartSeries `json:"power_curve"`
+	CostBreakdown ChartSeries `json:"cost_breakdown"`
+}
+
+// GetChartData builds the hourly power curve (average, peak and moving
+// average) and the peak/off-peak cost breakdown for a day
+func (s *AnalyticsService) GetChartData(facilityID string, date time.Time) (*ChartData, error) {
+	readings, err := s.getReadingsForDate(facilityID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings: %w", err)
+	}
+	loc := s.zones.Location(facilityID)
+	return buildChartData(facilityID, date, readings, loc, s.costs)
+}
+
+func buildChartData(facilityID string, date time.Time, readings []domain.Reading, loc *time.Location, model costmodel.Model) (*ChartData, error) {
+	var sum, peak [24]float64
+	var count [24]int
+	for _, r := range readings {
+		h := r.Timestamp.In(loc).Hour()
+		sum[h] += r.PowerKW
+		count[h]++
+		if r.PowerKW > peak[h] {
+			peak[h] = r.PowerKW
+		}
+	}
+
+	labels := make([]string, 24)
+	avg := make([]float64, 24)
+	for h := 0; h < 24; h++ {
+		labels[h] = fmt.Sprintf("%02d:00", h)
+		if count[h] > 0 {
+			avg[h] = numfmt.Round(sum[h]/float64(count[h]), 2)
+		}
+	}
+	cost, err := model.Cost(costSamples(readings), loc)
+	if err != nil {
+		return nil, err
+	}
+
+	peaks := make([]float64, 24)
+	for h := range peak {
+		peaks[h] = numfmt.Round(peak[h], 2)
+	}
+
+	return &ChartData{
+		FacilityID: facilityID,
+		Date:       date.Format("2006-01-02"),
+		Timezone:   loc.String(),
+		PowerCurve: ChartSeries{
+			Labels: labels,
+			Datasets: []Dataset{
+				{Label: "Average power (kW)", Data: avg},
+				{Label: "Peak power (kW)", Data: peaks},
+				{Label: fmt.Sprintf("%dh moving average (kW)", chartMovingWindow), Data: trailingAverage(avg, chartMovingWindow)},
+			},
+		},
+		CostBreakdown: ChartSeries{
+			Labels:   []string{"peak", "offpeak"},
+			Datasets: []Dataset{{Label: "Estimated cost", Data: []float64{cost.PeakCost, cost.OffPeakCost}}},
+		},
+	}, nil
+}
+
+// trailingAverage returns a moving average the same length as values; leading
+// points average over the values seen so far so the series stays aligned with its labels
+func trailingAverage(values []float64, window int) []float64 {
+	out := make([]float64, len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		n := i + 1
+		if n > window {
+			n = window
+		}
+		out[i] = numfmt.Round(sum/float64(n), 2)
+	}
+	return out
+}
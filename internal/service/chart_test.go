@@ -0,0 +1,105 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestBuildChartData(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	reading := func(hour, minute int, kw float64) domain.Reading {
+		return domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)), PowerKW: kw}
+	}
+	readings := []domain.Reading{reading(2, 0, 4), reading(2, 30, 6), reading(3, 0, 9), reading(18, 0, 12)}
+
+	tests := []struct {
+		name     string
+		readings []domain.Reading
+		loc      *time.Location
+		// wantAvg and wantPeak list the non-zero hours
+		wantAvg  map[int]float64
+		wantPeak map[int]float64
+	}{
+		{name: "no readings", loc: time.UTC},
+		{
+			name:     "UTC hours",
+			readings: readings,
+			loc:      time.UTC,
+			wantAvg:  map[int]float64{2: 5, 3: 9, 18: 12},
+			wantPeak: map[int]float64{2: 6, 3: 9, 18: 12},
+		},
+		{
+			name:     "facility-local hours",
+			readings: readings,
+			loc:      berlin,
+			wantAvg:  map[int]float64{3: 5, 4: 9, 19: 12},
+			wantPeak: map[int]float64{3: 6, 4: 9, 19: 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := buildChartData("facility-001", day, tt.readings, tt.loc, costmodel.Default(0.12, 3600))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.Timezone != tt.loc.String() || data.Date != "2024-03-01" {
+				t.Errorf("date, timezone = %s, %s", data.Date, data.Timezone)
+			}
+
+			curve := data.PowerCurve
+			if len(curve.Labels) != 24 || curve.Labels[0] != "00:00" || curve.Labels[23] != "23:00" {
+				t.Fatalf("labels = %v, want 00:00..23:00", curve.Labels)
+			}
+			if len(curve.Datasets) != 3 {
+				t.Fatalf("%d power datasets, want 3", len(curve.Datasets))
+			}
+			for _, ds := range curve.Datasets {
+				if len(ds.Data) != len(curve.Labels) {
+					t.Errorf("%s has %d points for %d labels", ds.Label, len(ds.Data), len(curve.Labels))
+				}
+			}
+			for i, want := range []map[int]float64{tt.wantAvg, tt.wantPeak} {
+				for h, got := range curve.Datasets[i].Data {
+					if got != want[h] {
+						t.Errorf("%s at %s = %v, want %v", curve.Datasets[i].Label, curve.Labels[h], got, want[h])
+					}
+				}
+			}
+
+			costs := data.CostBreakdown
+			if !reflect.DeepEqual(costs.Labels, []string{"peak", "offpeak"}) || len(costs.Datasets) != 1 || len(costs.Datasets[0].Data) != 2 {
+				t.Fatalf("cost breakdown = %+v, want one peak/offpeak dataset", costs)
+			}
+		})
+	}
+}
+
+func TestTrailingAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		want   []float64
+	}{
+		{name: "empty", values: []float64{}, window: 3, want: []float64{}},
+		{name: "leading points average what's seen", values: []float64{3, 6, 9, 12, 0}, window: 3, want: []float64{3, 4.5, 6, 9, 7}},
+		{name: "window of one", values: []float64{1, 2, 3}, window: 1, want: []float64{1, 2, 3}},
+		{name: "window longer than series", values: []float64{2, 4}, window: 5, want: []float64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trailingAverage(tt.values, tt.window); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trailingAverage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// batchStore records BatchPutReadings calls; other ReadingStore methods aren't used
+type batchStore struct {
+	ReadingStore
+	batches [][]domain.Reading
+	err     error
+}
+
+func (b *batchStore) BatchPutReadings(readings []domain.Reading, facilityID string) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.batches = append(b.batches, append([]domain.Reading(nil), readings...))
+	return nil
+}
+
+func (b *batchStore) stored() []domain.Reading {
+	var all []domain.Reading
+	for _, batch := range b.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func newImportService(store ReadingStore) *ReadingService {
+	repos := repository.New(nil)
+	return &ReadingService{repos: repos, store: store, calibrator: newCalibrator(repos)}
+}
+
+func TestImportCSV(t *testing.T) {
+	tests := []struct {
+		name         string
+		csv          string
+		wantImported int
+		wantRejected int
+		wantLines    []int
+		wantMeters   []int64
+		wantErr      bool
+	}{
+		{
+			name: "well-formed file",
+			csv: "timestamp,meter_id,voltage,current,power_kw\n" +
+				"2024-03-01T00:00:00Z,1,230,10,2.3\n" +
+				"1709251260,2,231,11,2.5\n" +
+				"1709251320000,1,229,9,2.1\n",
+			wantImported: 3,
+			wantLines:    []int{},
+			wantMeters:   []int64{1, 2, 1},
+		},
+		{
+			name: "columns in any order, BOM and padding",
+			csv: "\ufeffpower_kw, Meter_ID ,current,voltage,timestamp\n" +
+				"2.3, 7, 10, 230, 2024-03-01T00:00:00Z\n",
+			wantImported: 1,
+			wantLines:    []int{},
+			wantMeters:   []int64{7},
+		},
+		{
+			name: "bad rows are rejected by line",
+			csv: "timestamp,meter_id,voltage,current,power_kw\n" +
+				"2024-03-01T00:00:00Z,1,230,10,2.3\n" +
+				"yesterday,1,230,10,2.3\n" +
+				"2024-03-01T00:01:00Z,x,230,10,2.3\n" +
+				"2024-03-01T00:02:00Z,2,230,10,lots\n" +
+				",3,230,10,2.3\n" +
+				"2024-03-01T00:03:00Z,4,\"230,10,2.3\n",
+			wantImported: 1,
+			wantRejected: 5,
+			wantLines:    []int{3, 4, 5, 6, 7},
+			wantMeters:   []int64{1},
+		},
+		{
+			name:    "missing columns",
+			csv:     "timestamp,meter_id,power_kw\n2024-03-01T00:00:00Z,1,2.3\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &batchStore{}
+			res, err := newImportService(store).ImportCSV("facility-001", strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if res.Imported != tt.wantImported || res.Rejected != tt.wantRejected {
+				t.Errorf("imported, rejected = %d, %d; want %d, %d", res.Imported, res.Rejected, tt.wantImported, tt.wantRejected)
+			}
+			lines := []int{}
+			for _, e := range res.Errors {
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("error lines = %v, want %v (%+v)", lines, tt.wantLines, res.Errors)
+			}
+
+			var meters []int64
+			for _, rd := range store.stored() {
+				meters = append(meters, rd.MeterID)
+				if rd.Source != domain.SourceImport {
+					t.Errorf("source = %q, want %q", rd.Source, domain.SourceImport)
+				}
+			}
+			if !reflect.DeepEqual(meters, tt.wantMeters) {
+				t.Errorf("stored meters = %v, want %v", meters, tt.wantMeters)
+			}
+		})
+	}
+}
+
+func TestImportCSVBatches(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("timestamp,meter_id,voltage,current,power_kw\n")
+	rows := 2*importBatchSize + 7
+	for i := 0; i < rows; i++ {
+		b.WriteString("1709251200,1,230,10,2.3\n")
+	}
+
+	store := &batchStore{}
+	res, err := newImportService(store).ImportCSV("facility-001", strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != rows || len(store.batches) != 3 || len(store.batches[2]) != 7 {
+		t.Errorf("imported %d in %d batches, want %d in 3", res.Imported, len(store.batches), rows)
+	}
+
+	store.err = errors.New("throttled")
+	if _, err := newImportService(store).ImportCSV("facility-001", strings.NewReader(b.String())); err == nil {
+		t.Error("store failure wasn't reported")
+	}
+}
+
+func TestImportCSVCapsErrors(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("timestamp,meter_id,voltage,current,power_kw\n")
+	for i := 0; i < maxImportRowErrors+20; i++ {
+		b.WriteString("bad,1,230,10,2.3\n")
+	}
+
+	res, err := newImportService(&batchStore{}).ImportCSV("facility-001", strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rejected != maxImportRowErrors+20 || len(res.Errors) != maxImportRowErrors {
+		t.Errorf("rejected %d with %d errors listed, want %d with %d", res.Rejected, len(res.Errors), maxImportRowErrors+20, maxImportRowErrors)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// digestSender delivers a digest of alert lines grouped by severity. It is
+// implemented by cloud.SNSClient.
+type digestSender interface {
+	SendAlertDigest(bySeverity map[string][]string) error
+}
+
+// digestNotifier buffers notifications in memory and sends them as one
+// digest per flush. The buffer is per process, so with several API instances
+// each sends its own digest, and alerts buffered at shutdown are lost.
+type digestNotifier struct {
+	sender digestSender
+
+	mu      sync.Mutex
+	pending []cloud.Notification
+}
+
+func newDigestNotifier(sender digestSender) *digestNotifier {
+	return &digestNotifier{sender: sender}
+}
+
+// Notify adds n to the next digest
+func (d *digestNotifier) Notify(n cloud.Notification) error {
+	d.mu.Lock()
+	d.pending = append(d.pending, n)
+	d.mu.Unlock()
+	return nil
+}
+
+// Flush sends the buffered notifications grouped by severity and clears the
+// buffer. On failure they are kept for the next flush.
+func (d *digestNotifier) Flush() error {
+	d.mu.Lock()
+	batch := d.pending
+	d.pending = nil
+	d.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	bySeverity := make(map[string][]string)
+	for _, n := range batch {
+		bySeverity[n.Severity] = append(bySeverity[n.Severity], digestLine(n))
+	}
+	if err := d.sender.SendAlertDigest(bySeverity); err != nil {
+		d.mu.Lock()
+		d.pending = append(batch, d.pending...)
+		d.mu.Unlock()
+		return fmt.Errorf("failed to send alert digest: %w", err)
+	}
+	return nil
+}
+
+// run flushes every interval for the life of the process
+func (d *digestNotifier) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := d.Flush(); err != nil {
+			fmt.Printf("Failed to send alert digest: %v\n", err)
+		}
+	}
+}
+
+// digestLine renders one notification as a digest list entry
+func digestLine(n cloud.Notification) string {
+	source := n.FacilityID
+	if n.EquipmentID != "" {
+		source += "/" + n.EquipmentID
+	}
+	return fmt.Sprintf("%s %s %s: %s", n.Timestamp.UTC().Format("15:04"), source, n.Type, n.Message)
+}
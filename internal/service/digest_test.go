@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// fakeDigestSender records each digest it's given
+type fakeDigestSender struct {
+	digests []map[string][]string
+	err     error
+}
+
+func (f *fakeDigestSender) SendAlertDigest(bySeverity map[string][]string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.digests = append(f.digests, bySeverity)
+	return nil
+}
+
+func TestDigestNotifier(t *testing.T) {
+	at := time.Date(2024, 3, 1, 14, 5, 0, 0, time.UTC)
+	alerts := []cloud.Notification{
+		{FacilityID: "facility-001", EquipmentID: "pump-1", Severity: "high", Type: "anomaly", Message: "Power 40 kW", Timestamp: at},
+		{FacilityID: "facility-001", Severity: "low", Type: "capacity", Message: "Load at 85%", Timestamp: at.Add(10 * time.Minute)},
+		{FacilityID: "facility-002", EquipmentID: "fan-2", Severity: "high", Type: "anomaly", Message: "Power 9 kW", Timestamp: at.Add(20 * time.Minute)},
+		{FacilityID: "facility-001", EquipmentID: "meter-3", Severity: "critical", Type: "frequency_excursion", Message: "47.9 Hz", Timestamp: at.Add(30 * time.Minute)},
+	}
+	want := map[string][]string{
+		"critical": {"14:35 facility-001/meter-3 frequency_excursion: 47.9 Hz"},
+		"high":     {"14:05 facility-001/pump-1 anomaly: Power 40 kW", "14:25 facility-002/fan-2 anomaly: Power 9 kW"},
+		"low":      {"14:15 facility-001 capacity: Load at 85%"},
+	}
+
+	sender := &fakeDigestSender{}
+	d := newDigestNotifier(sender)
+	for _, n := range alerts {
+		if err := d.Notify(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sender.digests) != 0 {
+		t.Fatal("digest sent before the flush")
+	}
+
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.digests) != 1 || !reflect.DeepEqual(sender.digests[0], want) {
+		t.Fatalf("digests = %v, want one of %v", sender.digests, want)
+	}
+
+	// The buffer is cleared, so an idle period sends nothing
+	if err := d.Flush(); err != nil || len(sender.digests) != 1 {
+		t.Errorf("empty flush: %v, %d digests", err, len(sender.digests))
+	}
+
+	// A failed send keeps the alerts for the next flush
+	sender.err = errors.New("throttled")
+	d.Notify(alerts[0])
+	if err := d.Flush(); err == nil {
+		t.Fatal("failed send not reported")
+	}
+	sender.err = nil
+	d.Notify(alerts[1])
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sender.digests[len(sender.digests)-1]; len(got["high"]) != 1 || len(got["low"]) != 1 {
+		t.Errorf("digest after a failed send = %v, want the retained and the new alert", got)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// enrichCacheTTL bounds how long looked-up names are reused
+const enrichCacheTTL = 5 * time.Minute
+
+// Expand selects which names are joined into responses
+// (?expand=meter,facility); quality adds readings' power-quality score
+type Expand struct {
+	Meter    bool
+	Facility bool
+	Quality  bool
+}
+
+// ParseExpand parses a comma-separated expand query value; unknown entries are ignored
+func ParseExpand(raw string) Expand {
+	var e Expand
+	for _, part := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(strings.ToLower(part)) {
+		case "meter":
+			e.Meter = true
+		case "facility":
+			e.Facility = true
+		case "quality":
+			e.Quality = true
+		}
+	}
+	return e
+}
+
+// Any reports whether any expansion was requested
+func (e Expand) Any() bool { return e.Meter || e.Facility || e.Quality }
+
+// EnrichedReading is a reading with optional meter/facility names joined in
+// and, with expand=quality, the score and flags of deriveReading
+type EnrichedReading struct {
+	domain.Reading
+	MeterSerial  string   `json:"meter_serial,omitempty"`
+	FacilityName string   `json:"facility_name,omitempty"`
+	QualityScore *int     `json:"quality_score,omitempty"`
+	QualityFlags []string `json:"quality_flags,omitempty"`
+}
+
+// EnrichedAlert is an alert with optional meter/facility names joined in
+type EnrichedAlert struct {
+	cloud.Alert
+	MeterSerial  string `json:"meter_serial,omitempty"`
+	FacilityName string `json:"facility_name,omitempty"`
+}
+
+type cachedName struct {
+	name    string
+	expires time.Time
+}
+
+// Enricher joins meter serials and facility names from the repository into
+// reading and alert responses. Lookups are cached so a response with many
+// readings from the same meter costs one query.
+type Enricher struct {
+	repos *repository.Repos
+
+	mu         sync.Mutex
+	meters     map[int64]cachedName
+	facilities map[int64]cachedName
+}
+
+// NewEnricher creates an Enricher backed by repos
+func NewEnricher(repos *repository.Repos) *Enricher {
+	return &Enricher{
+		repos:      repos,
+		meters:     make(map[int64]cachedName),
+		facilities: make(map[int64]cachedName),
+	}
+}
+
+// Readings joins the requested names into readings of the given facility
+func (e *Enricher) Readings(facilityID string, readings []domain.Reading, expand Expand) []EnrichedReading {
+	out := make([]EnrichedReading, len(readings))
+	facilityName := ""
+	if expand.Facility {
+		facilityName = e.facilityName(facilityID)
+	}
+
+	for i, r := range readings {
+		out[i] = EnrichedReading{Reading: r, FacilityName: facilityName}
+		if expand.Meter {
+			out[i].MeterSerial = e.meterSerial(r.MeterID)
+		}
+		if expand.Quality {
+			d := deriveReading(r)
+			out[i].QualityScore, out[i].QualityFlags = &d.QualityScore, d.QualityFlags
+		}
+	}
+	return out
+}
+
+// Alerts joins the requested names into alerts
+func (e *Enricher) Alerts(alerts []cloud.Alert, expand Expand) []EnrichedAlert {
+	out := make([]EnrichedAlert, len(alerts))
+	for i, a := range alerts {
+		out[i] = EnrichedAlert{Alert: a}
+		if expand.Facility {
+			out[i].FacilityName = e.facilityName(a.FacilityID)
+		}
+		if expand.Meter {
+			// Only meter equipment has a serial; "pump-2" isn't meter 2
+			if id, ok := parseNumericID(a.EquipmentID, "meter"); ok {
+				out[i].MeterSerial = e.meterSerial(id)
+			}
+		}
+	}
+	return out
+}
+
+func (e *Enricher) facilityName(facilityID string) string {
+	id, ok := parseNumericID(facilityID, "facility")
+	if !ok {
+		return ""
+	}
+	return e.lookup(e.facilities, id, func() (string, error) {
+		f, err := e.repos.GetFacility(id)
+		if err != nil {
+			return "", err
+		}
+		return f.Name, nil
+	})
+}
+
+func (e *Enricher) meterSerial(meterID int64) string {
+	return e.lookup(e.meters, meterID, func() (string, error) {
+		m, err := e.repos.GetMeter(meterID)
+		if err != nil {
+			return "", err
+		}
+		return m.Serial, nil
+	})
+}
+
+// lookup returns a cached name or fetches it. A missing row is cached as
+// empty so it doesn't cost a query per reading; other failures, such as a
+// timeout, are retried on the next lookup.
+func (e *Enricher) lookup(cache map[int64]cachedName, id int64, fetch func() (string, error)) string {
+	e.mu.Lock()
+	if c, ok := cache[id]; ok && time.Now().Before(c.expires) {
+		e.mu.Unlock()
+		return c.name
+	}
+	e.mu.Unlock()
+
+	name, err := fetch()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ""
+	}
+
+	e.mu.Lock()
+	cache[id] = cachedName{name: name, expires: time.Now().Add(enrichCacheTTL)}
+	e.mu.Unlock()
+	return name
+}
+
+// parseNumericID maps string IDs used by the cloud stores to the numeric IDs
+// of the relational tables: a plain "42", or kind and number ("facility-001"
+// or "meter-7" for kind facility or meter). Other IDs, such as "pump-2" for a
+// meter, have no relational ID.
+func parseNumericID(s, kind string) (int64, bool) {
+	s = strings.TrimPrefix(s, kind+"-")
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+func TestParseExpand(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Expand
+	}{
+		{"", Expand{}},
+		{"meter", Expand{Meter: true}},
+		{" Facility , METER ", Expand{Meter: true, Facility: true}},
+		{"quality,bogus", Expand{Quality: true}},
+	}
+	for _, tt := range tests {
+		if got := ParseExpand(tt.raw); got != tt.want {
+			t.Errorf("ParseExpand(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseNumericID(t *testing.T) {
+	tests := []struct {
+		raw    string
+		kind   string
+		want   int64
+		wantOK bool
+	}{
+		{"facility-001", "facility", 1, true},
+		{"meter-7", "meter", 7, true},
+		{"42", "meter", 42, true},
+		{"facility-abc", "facility", 0, false},
+		{"0", "meter", 0, false},
+		{"pump-2", "meter", 0, false},
+		{"meter-7", "facility", 0, false},
+		{"meter-meter-7", "meter", 0, false},
+		{"meter--7", "meter", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseNumericID(tt.raw, tt.kind); got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseNumericID(%q, %q) = %d, %v; want %d, %v", tt.raw, tt.kind, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestEnricherJoinsCachedNames(t *testing.T) {
+	e := NewEnricher(repository.New(nil))
+	later := time.Now().Add(time.Hour)
+	e.meters[7] = cachedName{name: "SN-0007", expires: later}
+	e.facilities[1] = cachedName{name: "Plant A", expires: later}
+
+	readings := []domain.Reading{{MeterID: 7}, {MeterID: 8}}
+	tests := []struct {
+		name       string
+		expand     Expand
+		wantSerial []string
+		wantName   string
+	}{
+		{name: "nothing expanded", wantSerial: []string{"", ""}},
+		{name: "meter", expand: Expand{Meter: true}, wantSerial: []string{"SN-0007", ""}},
+		{name: "facility", expand: Expand{Facility: true}, wantSerial: []string{"", ""}, wantName: "Plant A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := e.Readings("facility-001", readings, tt.expand)
+			for i, r := range out {
+				if r.MeterSerial != tt.wantSerial[i] || r.FacilityName != tt.wantName {
+					t.Errorf("reading %d: serial %q, facility %q; want %q, %q", i, r.MeterSerial, r.FacilityName, tt.wantSerial[i], tt.wantName)
+				}
+			}
+		})
+	}
+
+	alerts := e.Alerts([]cloud.Alert{
+		{FacilityID: "facility-001", EquipmentID: "meter-7"},
+		{FacilityID: "facility-001", EquipmentID: "7"},
+		// Equipment that isn't a meter gets no meter's serial
+		{FacilityID: "facility-001", EquipmentID: "pump-7"},
+	}, Expand{Meter: true, Facility: true})
+	for i, want := range []string{"SN-0007", "SN-0007", ""} {
+		if alerts[i].MeterSerial != want || alerts[i].FacilityName != "Plant A" {
+			t.Errorf("alert %d = %+v, want serial %q", i, alerts[i], want)
+		}
+	}
+}
+
+func TestEnricherLookupCaches(t *testing.T) {
+	e := NewEnricher(repository.New(nil))
+	tests := []struct {
+		name      string
+		fetchErr  error
+		wantName  string
+		wantFetch int
+	}{
+		{name: "found", wantName: "SN-1", wantFetch: 1},
+		{name: "missing row cached as empty", fetchErr: fmt.Errorf("get meter: %w", sql.ErrNoRows), wantFetch: 1},
+		{name: "timeout retried", fetchErr: errors.New("i/o timeout"), wantFetch: 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := 0
+			fetch := func() (string, error) {
+				fetches++
+				if tt.fetchErr != nil {
+					return "", tt.fetchErr
+				}
+				return "SN-1", nil
+			}
+			id := int64(i + 1)
+			for n := 0; n < 3; n++ {
+				if got := e.lookup(e.meters, id, fetch); got != tt.wantName {
+					t.Errorf("lookup = %q, want %q", got, tt.wantName)
+				}
+			}
+			if fetches != tt.wantFetch {
+				t.Errorf("fetched %d times, want %d", fetches, tt.wantFetch)
+			}
+		})
+	}
+}
+
+func TestEnricherQuality(t *testing.T) {
+	e := NewEnricher(repository.New(nil))
+	readings := []domain.Reading{
+		{Voltage: 230, Current: 10, PowerKW: 2.2},
+		{Voltage: 230, Current: 10, PowerKW: 2.2, THDPercent: ptr(9.5), FrequencyHz: ptr(50.8)},
+	}
+	tests := []struct {
+		name       string
+		expand     Expand
+		wantScores []int // -1 for no score
+	}{
+		{name: "not expanded", expand: Expand{Meter: true}, wantScores: []int{-1, -1}},
+		{name: "quality", expand: Expand{Quality: true}, wantScores: []int{100, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, r := range e.Readings("facility-001", readings, tt.expand) {
+				score := -1
+				if r.QualityScore != nil {
+					score = *r.QualityScore
+				}
+				// The score and flags are deriveReading's, as GET /readings/one reports them
+				want := deriveReading(readings[i])
+				if score != tt.wantScores[i] || score >= 0 && len(r.QualityFlags) != len(want.QualityFlags) {
+					t.Errorf("reading %d: score %d, flags %v; want %d, %v", i, score, r.QualityFlags, tt.wantScores[i], want.QualityFlags)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// What a full anomaly invoke queue does with the next reading (ANOMALY_QUEUE_OVERFLOW)
+const (
+	// OverflowDrop skips the reading's anomaly check and counts it as dropped
+	OverflowDrop = "drop"
+	// OverflowBlock makes the storing caller wait for room, pushing back on the source
+	OverflowBlock = "block"
+)
+
+// ParseOverflowPolicy validates ANOMALY_QUEUE_OVERFLOW; empty means OverflowDrop
+func ParseOverflowPolicy(s string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(s)); p {
+	case "":
+		return OverflowDrop, nil
+	case OverflowDrop, OverflowBlock:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown ANOMALY_QUEUE_OVERFLOW %q (want %s or %s)", s, OverflowDrop, OverflowBlock)
+	}
+}
+
+// AnomalyQueueStats is a snapshot of the anomaly invoke queue
+type AnomalyQueueStats struct {
+	Workers  int    `json:"workers"`
+	Capacity int    `json:"capacity"`
+	Depth    int    `json:"depth"`
+	Invoked  uint64 `json:"invoked"`
+	Failed   uint64 `json:"failed"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// invokeQueue runs anomaly Lambda invocations on a fixed pool of workers fed
+// by a bounded queue, so a burst of readings can't spawn unbounded goroutines
+type invokeQueue struct {
+	jobs    chan cloud.AnomalyDetectionPayload
+	workers int
+	block   bool
+	invoke  func(cloud.AnomalyDetectionPayload) error
+
+	invoked atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// newInvokeQueue starts workers goroutines draining a queue of size payloads;
+// non-positive values are raised to 1
+func newInvokeQueue(workers, size int, overflow string, invoke func(cloud.AnomalyDetectionPayload) error) *invokeQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 1 {
+		size = 1
+	}
+	q := &invokeQueue{
+		jobs:    make(chan cloud.AnomalyDetectionPayload, size),
+		workers: workers,
+		block:   overflow == OverflowBlock,
+		invoke:  invoke,
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+func (q *invokeQueue) work() {
+	for p := range q.jobs {
+		if err := q.invoke(p); err != nil {
+			q.failed.Add(1)
+			fmt.Printf("Failed to invoke anomaly detection: %v\n", err)
+			continue
+		}
+		q.invoked.Add(1)
+	}
+}
+
+// submit queues p, blocking or dropping when the queue is full; it reports
+// whether p was queued
+func (q *invokeQueue) submit(p cloud.AnomalyDetectionPayload) bool {
+	if q.block {
+		q.jobs <- p
+		return true
+	}
+	select {
+	case q.jobs <- p:
+		return true
+	default:
+		q.dropped.Add(1)
+		return false
+	}
+}
+
+func (q *invokeQueue) stats() AnomalyQueueStats {
+	return AnomalyQueueStats{
+		Workers:  q.workers,
+		Capacity: cap(q.jobs),
+		Depth:    len(q.jobs),
+		Invoked:  q.invoked.Load(),
+		Failed:   q.failed.Load(),
+		Dropped:  q.dropped.Load(),
+	}
+}
+
+// AnomalyQueueStats reports the anomaly invoke queue; ok is false when readings
+// aren't sent to the Lambda
+func (s *ReadingService) AnomalyQueueStats() (AnomalyQueueStats, bool) {
+	if s.anomalies == nil {
+		return AnomalyQueueStats{}, false
+	}
+	return s.anomalies.stats(), true
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestParseOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: OverflowDrop},
+		{raw: "drop", want: OverflowDrop},
+		{raw: " BLOCK ", want: OverflowBlock},
+		{raw: "spill", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOverflowPolicy(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseOverflowPolicy(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+// waitFor polls cond for up to 5s
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestInvokeQueueFlood(t *testing.T) {
+	const (
+		workers  = 4
+		size     = 16
+		readings = 5000
+	)
+	tests := []struct {
+		overflow    string
+		wantDropped bool
+	}{
+		{overflow: OverflowDrop, wantDropped: true},
+		{overflow: OverflowBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overflow, func(t *testing.T) {
+			baseline := runtime.NumGoroutine()
+			// The Lambda hangs until released, as under a burst it can't keep up with
+			release := make(chan struct{})
+			s := newImportService(memstore.New())
+			s.anomalies = newInvokeQueue(workers, size, tt.overflow, func(cloud.AnomalyDetectionPayload) error {
+				<-release
+				return nil
+			})
+			defer close(s.anomalies.jobs)
+
+			start := time.Now().Add(-time.Hour)
+			flooded := make(chan struct{})
+			go func() {
+				defer close(flooded)
+				for i := 0; i < readings; i++ {
+					rd := domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(start.Add(time.Duration(i) * time.Second)), PowerKW: 2}
+					if err := s.StoreReading("facility-001", &rd); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+
+			if tt.overflow == OverflowBlock {
+				// The flood waits for room once the workers and queue are full
+				waitFor(t, "a full queue", func() bool { return s.anomalies.stats().Depth == size })
+				select {
+				case <-flooded:
+					t.Fatal("flood wasn't held back by a full queue")
+				case <-time.After(50 * time.Millisecond):
+				}
+			} else {
+				<-flooded
+			}
+			// Workers plus the flooding goroutine, not one per reading
+			if n := runtime.NumGoroutine(); n > baseline+workers+1+2 {
+				t.Errorf("%d goroutines under a flood of %d readings, baseline %d", n, readings, baseline)
+			}
+
+			close(release)
+			<-flooded
+			waitFor(t, "the queue to drain", func() bool {
+				st := s.anomalies.stats()
+				return st.Depth == 0 && st.Invoked+st.Dropped == readings
+			})
+			st := s.anomalies.stats()
+			if (st.Dropped > 0) != tt.wantDropped || st.Failed != 0 {
+				t.Errorf("stats %+v", st)
+			}
+			if tt.wantDropped && st.Invoked > workers+size {
+				t.Errorf("%d invoked, want at most the %d workers and queue hold", st.Invoked, workers+size)
+			}
+		})
+	}
+}
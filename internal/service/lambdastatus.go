@@ -0,0 +1,16 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// LambdaStatus reports the deployed configuration of the Lambda functions the
+// API invokes, for operators checking what is running
+func (s *Services) LambdaStatus() ([]cloud.FunctionStatus, error) {
+	if s.Lambda == nil {
+		return nil, fmt.Errorf("cloud services not enabled")
+	}
+	return s.Lambda.FunctionStatuses(), nil
+}
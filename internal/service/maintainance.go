@@ -6,24 +6,41 @@ import (
 
 	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/maintenance"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
 )
 
 // MaintenanceService handles predictive maintenance operations
 type MaintenanceService struct {
-	dynamoDB *cloud.DynamoDBClient
-	sns      *cloud.SNSClient
-	useCloud bool
+	repos       *repository.Repos
+	equipment   EquipmentStore
+	predictions PredictionStore
+	sns         MaintenanceNotifier
+	useCloud    bool
+}
+
+// MaintenanceNotifier sends maintenance notifications. It is implemented by
+// cloud.SNSClient.
+type MaintenanceNotifier interface {
+	SendMaintenanceAlert(p domain.MaintenancePrediction) error
+	SendMaintenanceDigest(facilityID string, items []string) error
 }
 
 // PredictMaintenanceNeeds analyzes equipment health and predicts maintenance requirements
 // YOUR ORIGINAL CONTRIBUTION: Uses custom library for maintenance prediction
 func (s *MaintenanceService) PredictMaintenanceNeeds(equipmentID string) (*MaintenancePrediction, error) {
-	if !s.useCloud || s.dynamoDB == nil {
-		return nil, fmt.Errorf("cloud services not enabled")
+	return s.PredictFacilityMaintenance("facility-001", equipmentID)
+}
+
+// PredictFacilityMaintenance is PredictMaintenanceNeeds for equipment of
+// facilityID; equipment of other facilities is not found
+func (s *MaintenanceService) PredictFacilityMaintenance(facilityID, equipmentID string) (*MaintenancePrediction, error) {
+	if s.equipment == nil {
+		return nil, fmt.Errorf("equipment store not configured")
 	}
 
 	// Get equipment data
-	equipment, err := s.dynamoDB.GetEquipment("facility-001")
+	equipment, err := s.equipment.GetEquipment(facilityID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get equipment: %w", err)
 	}
@@ -40,6 +57,18 @@ func (s *MaintenanceService) PredictMaintenanceNeeds(equipmentID string) (*Maint
 		return nil, fmt.Errorf("equipment not found")
 	}
 
+	prediction := predictMaintenance(targetEquipment)
+
+	// Send alert if high risk
+	if prediction.HighRisk() {
+		s.sendMaintenanceAlert(prediction.record(targetEquipment.FacilityID, time.Now().UTC()))
+	}
+
+	return prediction, nil
+}
+
+// predictMaintenance computes the failure risk and next service date for equipment
+func predictMaintenance(targetEquipment *cloud.Equipment) *MaintenancePrediction {
 	// YOUR ORIGINAL CONTRIBUTION: Create AssetHealth profile
 	assetHealth := maintenance.AssetHealth{
 		HoursRun:           calculateHoursRun(targetEquipment),
@@ -55,8 +84,8 @@ func (s *MaintenanceService) PredictMaintenanceNeeds(equipmentID string) (*Maint
 	// YOUR ORIGINAL CONTRIBUTION: Predict next service date using library
 	nextService := maintenance.NextServiceDate(assetHealth)
 
-	prediction := &MaintenancePrediction{
-		EquipmentID:       equipmentID,
+	return &MaintenancePrediction{
+		EquipmentID:       targetEquipment.EquipmentID,
 		CurrentHealth:     targetEquipment.HealthScore,
 		FailureRisk30Days: riskNext30Days * 100, // Convert to percentage
 		FailureRisk90Days: riskNext90Days * 100,
@@ -64,13 +93,6 @@ func (s *MaintenanceService) PredictMaintenanceNeeds(equipmentID string) (*Maint
 		DaysUntilService:  int(time.Until(nextService).Hours() / 24),
 		Recommendation:    generateRecommendation(riskNext30Days, targetEquipment.HealthScore),
 	}
-
-	// Send alert if high risk
-	if riskNext30Days > 0.5 || targetEquipment.HealthScore < 75 {
-		s.sendMaintenanceAlert(prediction)
-	}
-
-	return prediction, nil
 }
 
 type MaintenancePrediction struct {
@@ -83,6 +105,93 @@ type MaintenancePrediction struct {
 	Recommendation    string    `json:"recommendation"`
 }
 
+// HighRisk reports whether the prediction warrants a maintenance notification
+func (p *MaintenancePrediction) HighRisk() bool {
+	return p.FailureRisk30Days > 50 || p.CurrentHealth < 75
+}
+
+// MaintenanceRun is the result of recomputing predictions for a facility
+type MaintenanceRun struct {
+	FacilityID    string                   `json:"facility_id"`
+	ComputedAt    time.Time                `json:"computed_at"`
+	Predictions   []*MaintenancePrediction `json:"predictions"`
+	HighRiskCount int                      `json:"high_risk_count"`
+	DigestSent    bool                     `json:"digest_sent"`
+}
+
+// RunFacility recomputes predictions for all of a facility's equipment, stores
+// them for trending and sends one SNS digest covering every high-risk item.
+// It is intended for a scheduled (e.g. nightly) trigger.
+func (s *MaintenanceService) RunFacility(facilityID string) (*MaintenanceRun, error) {
+	if s.equipment == nil {
+		return nil, fmt.Errorf("equipment store not configured")
+	}
+
+	equipment, err := s.equipment.GetEquipment(facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipment: %w", err)
+	}
+
+	run := &MaintenanceRun{
+		FacilityID:  facilityID,
+		ComputedAt:  time.Now().UTC(),
+		Predictions: make([]*MaintenancePrediction, 0, len(equipment)),
+	}
+	records := make([]domain.MaintenancePrediction, 0, len(equipment))
+	var digest []string
+	for i := range equipment {
+		p := predictMaintenance(&equipment[i])
+		run.Predictions = append(run.Predictions, p)
+		records = append(records, p.record(facilityID, run.ComputedAt))
+		if p.HighRisk() {
+			run.HighRiskCount++
+			digest = append(digest, fmt.Sprintf("%s: health %.2f%%, 30-day failure risk %.2f%%, service by %s - %s",
+				p.EquipmentID, p.CurrentHealth, p.FailureRisk30Days, p.NextServiceDate.Format("2006-01-02"), p.Recommendation))
+		}
+	}
+
+	if err := s.storePredictions(records); err != nil {
+		return nil, fmt.Errorf("failed to store predictions: %w", err)
+	}
+
+	if len(digest) > 0 && s.sns != nil {
+		if err := s.sns.SendMaintenanceDigest(facilityID, digest); err != nil {
+			// Log error but don't fail - predictions are already stored
+			fmt.Printf("Failed to send maintenance digest: %v\n", err)
+		} else {
+			run.DigestSent = true
+		}
+	}
+
+	return run, nil
+}
+
+// storePredictions writes to the storage backend's store, or Postgres when the
+// backend has none
+func (s *MaintenanceService) storePredictions(records []domain.MaintenancePrediction) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if s.predictions != nil {
+		return s.predictions.PutMaintenancePredictions(records)
+	}
+	return s.repos.InsertMaintenancePredictions(records)
+}
+
+func (p *MaintenancePrediction) record(facilityID string, computedAt time.Time) domain.MaintenancePrediction {
+	return domain.MaintenancePrediction{
+		FacilityID:        facilityID,
+		EquipmentID:       p.EquipmentID,
+		ComputedAt:        computedAt,
+		HealthScore:       p.CurrentHealth,
+		FailureRisk30Days: p.FailureRisk30Days,
+		FailureRisk90Days: p.FailureRisk90Days,
+		NextServiceDate:   p.NextServiceDate,
+		Recommendation:    p.Recommendation,
+		HighRisk:          p.HighRisk(),
+	}
+}
+
 func calculateHoursRun(equipment *cloud.Equipment) float64 {
 	daysSinceInstall := time.Since(time.Unix(equipment.InstallDate, 0)).Hours() / 24
 	// Assume 20 hours/day operation
@@ -100,14 +209,12 @@ func generateRecommendation(risk float64, health float64) string {
 	return "Equipment operating normally"
 }
 
-func (s *MaintenanceService) sendMaintenanceAlert(prediction *MaintenancePrediction) {
+func (s *MaintenanceService) sendMaintenanceAlert(prediction domain.MaintenancePrediction) {
 	if s.sns == nil {
 		return
 	}
 
-	s.sns.SendMaintenanceAlert(
-		prediction.EquipmentID,
-		prediction.CurrentHealth,
-		prediction.NextServiceDate,
-	)
+	if err := s.sns.SendMaintenanceAlert(prediction); err != nil {
+		fmt.Printf("Failed to send maintenance alert: %v\n", err)
+	}
 }
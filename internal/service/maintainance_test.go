@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+type fakeEquipmentStore struct {
+	items []cloud.Equipment
+}
+
+func (f *fakeEquipmentStore) GetEquipment(facilityID string) ([]cloud.Equipment, error) {
+	var out []cloud.Equipment
+	for _, eq := range f.items {
+		if eq.FacilityID == facilityID {
+			out = append(out, eq)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEquipmentStore) UpdateEquipmentHealth(string, float64) error { return nil }
+
+type fakePredictionStore struct {
+	stored [][]domain.MaintenancePrediction
+}
+
+func (f *fakePredictionStore) PutMaintenancePredictions(p []domain.MaintenancePrediction) error {
+	f.stored = append(f.stored, p)
+	return nil
+}
+
+// fakeMaintenanceNotifier records digests; alerts sent one by one are counted
+type fakeMaintenanceNotifier struct {
+	alerts  int
+	digests map[string][]string
+	err     error
+}
+
+func (f *fakeMaintenanceNotifier) SendMaintenanceAlert(domain.MaintenancePrediction) error {
+	f.alerts++
+	return f.err
+}
+
+func (f *fakeMaintenanceNotifier) SendMaintenanceDigest(facilityID string, items []string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.digests[facilityID] = append(f.digests[facilityID], strings.Join(items, "\n"))
+	return nil
+}
+
+func TestRunFacility(t *testing.T) {
+	now := time.Now()
+	eq := func(id string, health float64) cloud.Equipment {
+		return cloud.Equipment{
+			EquipmentID: id, FacilityID: "facility-001", HealthScore: health,
+			InstallDate: now.AddDate(-2, 0, 0).Unix(), LastMaintenance: now.AddDate(0, -3, 0).Unix(),
+		}
+	}
+	tests := []struct {
+		name         string
+		equipment    []cloud.Equipment
+		sendErr      error
+		wantHighRisk []string
+		wantDigest   bool
+	}{
+		{
+			name:      "all healthy",
+			equipment: []cloud.Equipment{eq("pump-1", 95), eq("fan-1", 88)},
+		},
+		{
+			name:         "one digest for several high-risk items",
+			equipment:    []cloud.Equipment{eq("pump-1", 60), eq("fan-1", 92), eq("chiller-1", 40), eq("boiler-1", 70)},
+			wantHighRisk: []string{"pump-1", "chiller-1", "boiler-1"},
+			wantDigest:   true,
+		},
+		{
+			name:         "digest failure still stores",
+			equipment:    []cloud.Equipment{eq("pump-1", 50)},
+			sendErr:      errors.New("sns unavailable"),
+			wantHighRisk: []string{"pump-1"},
+		},
+		{name: "no equipment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Equipment of another facility is never part of the run
+			other := cloud.Equipment{EquipmentID: "pump-9", FacilityID: "facility-002", HealthScore: 10}
+			store := &fakePredictionStore{}
+			notifier := &fakeMaintenanceNotifier{digests: make(map[string][]string), err: tt.sendErr}
+			s := &MaintenanceService{
+				equipment:   &fakeEquipmentStore{items: append(tt.equipment, other)},
+				predictions: store,
+				sns:         notifier,
+			}
+
+			run, err := s.RunFacility("facility-001")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(run.Predictions) != len(tt.equipment) {
+				t.Errorf("%d predictions, want %d", len(run.Predictions), len(tt.equipment))
+			}
+			if run.HighRiskCount != len(tt.wantHighRisk) || run.DigestSent != tt.wantDigest {
+				t.Errorf("high risk %d, digest sent %v; want %d, %v", run.HighRiskCount, run.DigestSent, len(tt.wantHighRisk), tt.wantDigest)
+			}
+
+			if len(tt.equipment) == 0 {
+				if len(store.stored) != 0 {
+					t.Errorf("%d store calls for no equipment, want none", len(store.stored))
+				}
+			} else {
+				if len(store.stored) != 1 {
+					t.Fatalf("%d store calls, want 1", len(store.stored))
+				}
+				highRisk := 0
+				for _, rec := range store.stored[0] {
+					if rec.FacilityID != "facility-001" || !rec.ComputedAt.Equal(run.ComputedAt) {
+						t.Errorf("record %+v not stamped with the run", rec)
+					}
+					if rec.HighRisk {
+						highRisk++
+					}
+				}
+				if len(store.stored[0]) != len(tt.equipment) || highRisk != len(tt.wantHighRisk) {
+					t.Errorf("stored %d records, %d high risk; want %d, %d", len(store.stored[0]), highRisk, len(tt.equipment), len(tt.wantHighRisk))
+				}
+			}
+
+			if notifier.alerts != 0 {
+				t.Errorf("%d individual alerts sent, want only the digest", notifier.alerts)
+			}
+			digests := notifier.digests["facility-001"]
+			if !tt.wantDigest {
+				if len(digests) != 0 {
+					t.Errorf("%d digests sent, want none", len(digests))
+				}
+				return
+			}
+			if len(digests) != 1 || len(notifier.digests) != 1 {
+				t.Fatalf("digests = %v, want one for facility-001", notifier.digests)
+			}
+			for _, id := range tt.wantHighRisk {
+				if !strings.Contains(digests[0], id+":") {
+					t.Errorf("digest lacks %s:\n%s", id, digests[0])
+				}
+			}
+			if strings.Contains(digests[0], "fan-1") {
+				t.Errorf("digest lists healthy equipment:\n%s", digests[0])
+			}
+		})
+	}
+}
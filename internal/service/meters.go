@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// ErrInvalidMeterTransition is returned for a lifecycle change the meter's status doesn't allow
+var ErrInvalidMeterTransition = errors.New("invalid meter status transition")
+
+// SetMeterStatus moves a meter through its lifecycle (commissioned -> active
+// -> decommissioned, and decommissioned -> commissioned for a reinstall). The
+// change applies to the meter's next reading.
+func (s *Services) SetMeterStatus(id int64, status string) (*domain.Meter, error) {
+	m, err := s.Repos.GetMeter(id)
+	if err != nil {
+		return nil, err
+	}
+	if !m.CanTransition(status) {
+		from := m.Status
+		if from == "" {
+			from = domain.MeterActive
+		}
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidMeterTransition, from, status)
+	}
+
+	if err := s.Repos.SetMeterStatus(id, status); err != nil {
+		return nil, fmt.Errorf("failed to update meter status: %w", err)
+	}
+	s.Readings.calibrator.forget(id)
+	m.Status = status
+	return m, nil
+}
+
+// MeterStatus returns the lifecycle status of a meter, or "" when it is active
+// or can't be looked up
+func (s *ReadingService) MeterStatus(id int64) string {
+	m, ok := s.calibrator.lookup(id)
+	if !ok || m.Active() {
+		return ""
+	}
+	return m.Status
+}
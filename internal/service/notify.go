@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// Notifier delivers alert notifications. It is implemented by cloud.SNSClient
+// and webhook.Notifier.
+type Notifier interface {
+	Notify(n cloud.Notification) error
+}
+
+// defaultRoute is the ALERT_ROUTES key used for severities without their own route
+const defaultRoute = "default"
+
+// severityRouter fans a notification out to the notifiers routed for its severity
+type severityRouter struct {
+	routes map[string][]string
+	byName map[string]Notifier
+}
+
+// newSeverityRouter builds a router over the named notifiers. spec has the form
+// "critical=sns+webhook,high=webhook,default=sns"; an empty spec sends every
+// severity to every notifier. Routes naming unconfigured notifiers are an error
+// so a typo doesn't silently drop alerts.
+func newSeverityRouter(byName map[string]Notifier, spec string) (*severityRouter, error) {
+	r := &severityRouter{routes: make(map[string][]string), byName: byName}
+
+	if strings.TrimSpace(spec) == "" {
+		var all []string
+		for name := range byName {
+			all = append(all, name)
+		}
+		sort.Strings(all)
+		r.routes[defaultRoute] = all
+		return r, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		severity, targets, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid alert route %q: expected severity=notifier[+notifier]", entry)
+		}
+		severity = strings.ToLower(strings.TrimSpace(severity))
+		for _, name := range strings.Split(targets, "+") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || name == "none" {
+				continue
+			}
+			if _, ok := byName[name]; !ok {
+				return nil, fmt.Errorf("alert route %q uses notifier %q, which is not configured", entry, name)
+			}
+			r.routes[severity] = append(r.routes[severity], name)
+		}
+		if _, ok := r.routes[severity]; !ok {
+			r.routes[severity] = nil
+		}
+	}
+	return r, nil
+}
+
+// Notify delivers to every notifier routed for the severity, attempting all of
+// them even if one fails
+func (r *severityRouter) Notify(n cloud.Notification) error {
+	targets, ok := r.routes[strings.ToLower(n.Severity)]
+	if !ok {
+		targets = r.routes[defaultRoute]
+	}
+
+	var errs []error
+	for _, name := range targets {
+		if err := r.byName[name].Notify(n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+)
+
+// recordingNotifier records the severities it was asked to deliver
+type recordingNotifier struct {
+	got []string
+	err error
+}
+
+func (r *recordingNotifier) Notify(n cloud.Notification) error {
+	r.got = append(r.got, n.Severity)
+	return r.err
+}
+
+func TestSeverityRouter(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		snsErr      error
+		wantSNS     []string
+		wantWebhook []string
+		wantErr     bool
+	}{
+		{
+			name:        "no routes sends everything everywhere",
+			wantSNS:     []string{"critical", "high", "low"},
+			wantWebhook: []string{"critical", "high", "low"},
+		},
+		{
+			name:        "per-severity routes with a default",
+			spec:        "critical=sns+webhook, high=webhook, default=sns",
+			wantSNS:     []string{"critical", "low"},
+			wantWebhook: []string{"critical", "high"},
+		},
+		{
+			name:        "severity routed to none is dropped",
+			spec:        "low=none,default=webhook",
+			wantWebhook: []string{"critical", "high"},
+		},
+		{
+			name:    "severity without a route and no default",
+			spec:    "CRITICAL=sns",
+			wantSNS: []string{"critical"},
+		},
+		{
+			name:        "one notifier failing doesn't stop the other",
+			spec:        "default=sns+webhook",
+			snsErr:      errors.New("sns unavailable"),
+			wantSNS:     []string{"critical", "high", "low"},
+			wantWebhook: []string{"critical", "high", "low"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sns, hook := &recordingNotifier{err: tt.snsErr}, &recordingNotifier{}
+			r, err := newSeverityRouter(map[string]Notifier{"sns": sns, "webhook": hook}, tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var errs []error
+			for _, sev := range []string{"critical", "high", "low"} {
+				if err := r.Notify(cloud.Notification{Severity: sev}); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if !reflect.DeepEqual(sns.got, tt.wantSNS) || !reflect.DeepEqual(hook.got, tt.wantWebhook) {
+				t.Errorf("sns got %v, webhook got %v; want %v, %v", sns.got, hook.got, tt.wantSNS, tt.wantWebhook)
+			}
+		})
+	}
+}
+
+func TestSeverityRouterRejectsBadRoutes(t *testing.T) {
+	for _, spec := range []string{"critical", "critical=pagerduty", "high=sns+slack"} {
+		if _, err := newSeverityRouter(map[string]Notifier{"sns": &recordingNotifier{}}, spec); err == nil {
+			t.Errorf("route %q accepted", spec)
+		}
+	}
+}
+
+// formatNotifier records the format each notification asked for
+type formatNotifier struct{ formats []cloud.MessageFormat }
+
+func (f *formatNotifier) Notify(n cloud.Notification) error {
+	f.formats = append(f.formats, n.Format)
+	return nil
+}
+
+func TestCreateAlertFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format cloud.MessageFormat
+	}{
+		{name: "client default", format: ""},
+		{name: "text", format: cloud.FormatText},
+		{name: "json", format: cloud.FormatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &formatNotifier{}
+			s := &AlertService{store: memstore.New(), notifier: notifier, hub: newAlertHub()}
+			if err := s.CreateAlertWithFormat(tt.format, "facility-001", "meter-1", "high", "anomaly", "Power 40 kW"); err != nil {
+				t.Fatal(err)
+			}
+			if len(notifier.formats) != 1 || notifier.formats[0] != tt.format {
+				t.Errorf("notified with formats %q, want [%q]", notifier.formats, tt.format)
+			}
+		})
+	}
+}
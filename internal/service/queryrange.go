@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryRangeTooLarge is returned for a query spanning more than MAX_QUERY_RANGE_HOURS
+var ErrQueryRangeTooLarge = errors.New("query range too large")
+
+// queryRange is the longest time span a reading or summary query may cover,
+// so one request can't scan the whole table; 0 disables the limit
+type queryRange time.Duration
+
+func (m queryRange) check(span time.Duration) error {
+	if m > 0 && span > time.Duration(m) {
+		return fmt.Errorf("%w: %.0f hours requested, at most %.0f allowed (MAX_QUERY_RANGE_HOURS)",
+			ErrQueryRangeTooLarge, span.Hours(), time.Duration(m).Hours())
+	}
+	return nil
+}
+
+// dayRange is the span of the inclusive date range from..to
+func dayRange(from, to time.Time) time.Duration {
+	return to.Sub(from) + 24*time.Hour
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+)
+
+func TestQueryRangeCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     time.Duration
+		span    time.Duration
+		wantErr bool
+	}{
+		{name: "within", max: 48 * time.Hour, span: 24 * time.Hour},
+		{name: "at the limit", max: 48 * time.Hour, span: 48 * time.Hour},
+		{name: "beyond", max: 48 * time.Hour, span: 48*time.Hour + time.Second, wantErr: true},
+		{name: "no limit", span: 100_000 * time.Hour},
+		{name: "a year of days", max: 8784 * time.Hour, span: dayRange(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))},
+		{name: "a year and a day", max: 8784 * time.Hour, span: dayRange(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := queryRange(tt.max).check(tt.span)
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrQueryRangeTooLarge) {
+				t.Errorf("check(%v) = %v, wantErr %v", tt.span, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetRecentReadingsPageRange(t *testing.T) {
+	s := newImportService(memstore.New())
+	s.maxRange = queryRange(48 * time.Hour)
+	tests := []struct {
+		hours   int
+		wantErr bool
+	}{
+		{hours: 48},
+		{hours: 49, wantErr: true},
+		{hours: 100_000, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := s.GetRecentReadingsPage("facility-001", time.Duration(tt.hours)*time.Hour, "", 0, cloud.Projection{})
+		if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrQueryRangeTooLarge) {
+			t.Errorf("%d hours: err = %v, wantErr %v", tt.hours, err, tt.wantErr)
+		}
+	}
+}
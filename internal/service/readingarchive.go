@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// archiveBufferBatches bounds the readings an archiver holds, in batches of
+// READINGS_ARCHIVE_MAX_BATCH; past it new readings aren't archived until a
+// flush succeeds
+const archiveBufferBatches = 20
+
+// archivedReading is one line of the readings archive, in the layout the
+// analytics Lambda's archive fallback reads
+type archivedReading struct {
+	FacilityID        string             `json:"facility_id"`
+	MeterID           string             `json:"meter_id"`
+	Timestamp         timeutil.Timestamp `json:"timestamp"`
+	Voltage           float64            `json:"voltage"`
+	Current           float64            `json:"current"`
+	PowerKW           float64            `json:"power_kw"`
+	ReactivePowerKVAR *float64           `json:"reactive_power_kvar,omitempty"`
+	FrequencyHz       *float64           `json:"frequency_hz,omitempty"`
+	THDPercent        *float64           `json:"thd_percent,omitempty"`
+}
+
+// archiveWindow is the facility and UTC hour an archive object covers
+type archiveWindow struct {
+	facilityID string
+	hour       time.Time
+}
+
+// key names a new part of the window's object:
+// <prefix>/<facility>/date=YYYY-MM-DD/hour=HH/part-<nanos>.ndjson
+func (w archiveWindow) key(prefix string, now time.Time) string {
+	return fmt.Sprintf("%s/%s/date=%s/hour=%s/part-%d.ndjson", prefix, url.PathEscape(w.facilityID),
+		w.hour.Format("2006-01-02"), w.hour.Format("15"), now.UnixNano())
+}
+
+// readingArchiver buffers stored readings and writes them to S3 as one NDJSON
+// object per facility per UTC hour instead of one object per reading. A
+// window is written once its hour has passed, or early when it holds
+// maxBatch readings (the rest of the hour then goes to a further part);
+// Close writes whatever is left. Readings that fail to upload are kept for
+// the next flush.
+type readingArchiver struct {
+	upload   func(key string, data []byte) error
+	prefix   string
+	maxBatch int
+
+	mu       sync.Mutex
+	buffers  map[archiveWindow][]archivedReading
+	buffered int
+	dropped  int
+
+	kick      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newReadingArchiver starts an archiver that checks for windows to write
+// every interval; non-positive maxBatch and interval are raised to 1
+func newReadingArchiver(upload func(key string, data []byte) error, prefix string, maxBatch int, interval time.Duration) *readingArchiver {
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	a := &readingArchiver{
+		upload:   upload,
+		prefix:   prefix,
+		maxBatch: maxBatch,
+		buffers:  make(map[archiveWindow][]archivedReading),
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go a.run(interval)
+	return a
+}
+
+// add buffers readings stored for facilityID; a nil archiver ignores them
+func (a *readingArchiver) add(facilityID string, readings ...domain.Reading) {
+	if a == nil {
+		return
+	}
+	full := false
+	a.mu.Lock()
+	for _, rd := range readings {
+		if a.buffered >= a.maxBatch*archiveBufferBatches {
+			a.dropped++
+			continue
+		}
+		w := archiveWindow{facilityID: facilityID, hour: rd.Timestamp.UTC().Truncate(time.Hour)}
+		a.buffers[w] = append(a.buffers[w], archivedReading{
+			FacilityID:        facilityID,
+			MeterID:           fmt.Sprintf("%d", rd.MeterID),
+			Timestamp:         rd.Timestamp,
+			Voltage:           rd.Voltage,
+			Current:           rd.Current,
+			PowerKW:           rd.PowerKW,
+			ReactivePowerKVAR: rd.ReactivePowerKVAR,
+			FrequencyHz:       rd.FrequencyHz,
+			THDPercent:        rd.THDPercent,
+		})
+		a.buffered++
+		full = full || len(a.buffers[w]) >= a.maxBatch
+	}
+	a.mu.Unlock()
+
+	// Full windows are written by the archiver's goroutine, off the storing path
+	if full {
+		select {
+		case a.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *readingArchiver) run(interval time.Duration) {
+	defer close(a.done)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			a.flush(time.Now(), false)
+		case <-a.kick:
+			a.flush(time.Now(), false)
+		case <-a.stop:
+			a.flush(time.Now(), true)
+			return
+		}
+	}
+}
+
+// flush writes the windows that are full or whose hour has passed by now, or
+// every window when all is set
+func (a *readingArchiver) flush(now time.Time, all bool) {
+	a.mu.Lock()
+	due := make(map[archiveWindow][]archivedReading)
+	for w, rs := range a.buffers {
+		if all || len(rs) >= a.maxBatch || !now.Before(w.hour.Add(time.Hour)) {
+			due[w] = rs
+			delete(a.buffers, w)
+			a.buffered -= len(rs)
+		}
+	}
+	dropped := a.dropped
+	a.dropped = 0
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		fmt.Printf("Readings archive buffer full; %d readings were not archived\n", dropped)
+	}
+	for w, rs := range due {
+		if err := a.write(w, rs, now); err != nil {
+			fmt.Printf("Failed to archive %d readings of %s for %s: %v\n",
+				len(rs), w.facilityID, w.hour.Format("2006-01-02T15"), err)
+			if !all {
+				a.requeue(w, rs)
+			}
+		}
+	}
+}
+
+// write uploads rs as one NDJSON object of window w
+func (a *readingArchiver) write(w archiveWindow, rs []archivedReading, now time.Time) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range rs {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return a.upload(w.key(a.prefix, now), buf.Bytes())
+}
+
+// requeue puts readings that failed to upload back ahead of newer ones
+func (a *readingArchiver) requeue(w archiveWindow, rs []archivedReading) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.buffers[w] = append(rs, a.buffers[w]...)
+	a.buffered += len(rs)
+}
+
+// close writes every buffered window and stops the archiver; a nil archiver
+// has nothing to write
+func (a *readingArchiver) close() {
+	if a == nil {
+		return
+	}
+	a.closeOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
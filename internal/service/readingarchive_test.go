@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+// archiveBucket records the objects an archiver uploads, failing the first
+// failures uploads
+type archiveBucket struct {
+	mu       sync.Mutex
+	objects  map[string][]archivedReading
+	failures int
+}
+
+var partRe = regexp.MustCompile(`/part-\d+\.ndjson$`)
+
+func (b *archiveBucket) upload(key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("s3: slow down")
+	}
+	if !partRe.MatchString(key) {
+		return fmt.Errorf("unexpected key %q", key)
+	}
+	var lines []archivedReading
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var r archivedReading
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return err
+		}
+		lines = append(lines, r)
+	}
+	b.objects[key] = lines
+	return nil
+}
+
+// windows lists the uploaded objects as "<window> x<lines>", without part names
+func (b *archiveBucket) windows() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for key, lines := range b.objects {
+		out = append(out, fmt.Sprintf("%s x%d", partRe.ReplaceAllString(key, ""), len(lines)))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestReadingArchiverFlush(t *testing.T) {
+	hour := func(h, m int) time.Time { return time.Date(2024, 3, 1, h, m, 0, 0, time.UTC) }
+	type batch struct {
+		facility string
+		at       []time.Time
+	}
+	tests := []struct {
+		name        string
+		maxBatch    int
+		batches     []batch
+		failures    int
+		flushes     []time.Time
+		all         bool // the last flush is the one on shutdown
+		wantObjects []string
+		wantLeft    int
+	}{
+		{
+			name:     "one object per facility per hour",
+			maxBatch: 100,
+			batches: []batch{
+				{facility: "facility-001", at: []time.Time{hour(10, 0), hour(10, 20), hour(10, 59), hour(11, 5), hour(11, 10)}},
+				{facility: "facility-002", at: []time.Time{hour(10, 30)}},
+			},
+			flushes: []time.Time{hour(12, 0)},
+			wantObjects: []string{
+				"raw/facility-001/date=2024-03-01/hour=10 x3",
+				"raw/facility-001/date=2024-03-01/hour=11 x2",
+				"raw/facility-002/date=2024-03-01/hour=10 x1",
+			},
+		},
+		{
+			name:        "the current hour waits",
+			maxBatch:    100,
+			batches:     []batch{{facility: "facility-001", at: []time.Time{hour(10, 0), hour(10, 30), hour(11, 5)}}},
+			flushes:     []time.Time{hour(11, 30)},
+			wantObjects: []string{"raw/facility-001/date=2024-03-01/hour=10 x2"},
+			wantLeft:    1,
+		},
+		{
+			name:        "a full window is written early",
+			maxBatch:    3,
+			batches:     []batch{{facility: "facility-001", at: []time.Time{hour(11, 0), hour(11, 1), hour(11, 2)}}},
+			flushes:     []time.Time{hour(11, 3)},
+			wantObjects: []string{"raw/facility-001/date=2024-03-01/hour=11 x3"},
+		},
+		{
+			name:        "a failed upload is retried",
+			maxBatch:    100,
+			batches:     []batch{{facility: "facility-001", at: []time.Time{hour(10, 0), hour(10, 30)}}},
+			failures:    1,
+			flushes:     []time.Time{hour(11, 0), hour(11, 1)},
+			wantObjects: []string{"raw/facility-001/date=2024-03-01/hour=10 x2"},
+		},
+		{
+			name:        "shutdown writes the current hour",
+			maxBatch:    100,
+			batches:     []batch{{facility: "facility-001", at: []time.Time{hour(11, 5), hour(11, 10)}}},
+			flushes:     []time.Time{hour(11, 30)},
+			all:         true,
+			wantObjects: []string{"raw/facility-001/date=2024-03-01/hour=11 x2"},
+		},
+		{
+			name:        "a full buffer drops readings",
+			maxBatch:    1,
+			batches:     []batch{{facility: "facility-001", at: make([]time.Time, archiveBufferBatches+5)}},
+			flushes:     []time.Time{hour(11, 0)},
+			wantObjects: []string{"raw/facility-001/date=0001-01-01/hour=00 x20"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &archiveBucket{objects: make(map[string][]archivedReading), failures: tt.failures}
+			// Flushed by hand rather than by the archiver's goroutine
+			a := &readingArchiver{upload: bucket.upload, prefix: "raw", maxBatch: tt.maxBatch, buffers: make(map[archiveWindow][]archivedReading)}
+			for _, b := range tt.batches {
+				var readings []domain.Reading
+				for i, at := range b.at {
+					readings = append(readings, domain.Reading{MeterID: int64(i + 1), Timestamp: timeutil.FromTime(at), PowerKW: 2})
+				}
+				a.add(b.facility, readings...)
+			}
+			for i, now := range tt.flushes {
+				a.flush(now, tt.all && i == len(tt.flushes)-1)
+			}
+
+			if got := bucket.windows(); fmt.Sprint(got) != fmt.Sprint(tt.wantObjects) {
+				t.Errorf("objects %v, want %v", got, tt.wantObjects)
+			}
+			if a.buffered != tt.wantLeft {
+				t.Errorf("%d readings left buffered, want %d", a.buffered, tt.wantLeft)
+			}
+			// Every line belongs to its object's window and keeps the reading's values
+			for key, lines := range bucket.objects {
+				for _, r := range lines {
+					window := fmt.Sprintf("raw/%s/date=%s/", r.FacilityID, r.Timestamp.UTC().Format("2006-01-02/hour=15"))
+					if !strings.HasPrefix(key, window) || r.MeterID == "" || r.PowerKW != 2 {
+						t.Errorf("%s holds %+v", key, r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadingArchiverClose(t *testing.T) {
+	bucket := &archiveBucket{objects: make(map[string][]archivedReading)}
+	a := newReadingArchiver(bucket.upload, "raw", 2, time.Hour)
+	now := timeutil.Now()
+
+	// A full window is written without waiting for the interval
+	a.add("facility-001", domain.Reading{MeterID: 1, Timestamp: now}, domain.Reading{MeterID: 2, Timestamp: now})
+	waitFor(t, "the full window", func() bool { return len(bucket.windows()) == 1 })
+
+	// Close writes what's left, once
+	a.add("facility-001", domain.Reading{MeterID: 3, Timestamp: now})
+	a.close()
+	a.close()
+	if got := bucket.windows(); len(got) != 2 {
+		t.Errorf("objects %v after close, want 2", got)
+	}
+
+	var nilArchiver *readingArchiver
+	nilArchiver.add("facility-001", domain.Reading{MeterID: 1})
+	nilArchiver.close()
+}
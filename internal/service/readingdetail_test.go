@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestDeriveReading(t *testing.T) {
+	tests := []struct {
+		name       string
+		rd         domain.Reading
+		wantKVA    float64
+		wantPF     float64
+		wantScore  int
+		wantFlags  []string
+		wantStatus string
+	}{
+		{
+			name: "from voltage and current", rd: domain.Reading{Voltage: 230, Current: 10, PowerKW: 2.2},
+			wantKVA: 2.3, wantPF: 0.96, wantScore: 100, wantFlags: []string{}, wantStatus: readingOK,
+		},
+		{
+			name: "from reactive power", rd: domain.Reading{Voltage: 230, Current: 50, PowerKW: 3, ReactivePowerKVAR: ptr(4)},
+			wantKVA: 5, wantPF: 0.6, wantScore: 70, wantFlags: []string{"low_power_factor"}, wantStatus: readingDegraded,
+		},
+		{
+			name: "distorted and off frequency", rd: domain.Reading{Voltage: 230, Current: 10, PowerKW: 2.2, THDPercent: ptr(9.5), FrequencyHz: ptr(50.8)},
+			wantKVA: 2.3, wantPF: 0.96, wantScore: 30, wantFlags: []string{"high_thd", "frequency_excursion"}, wantStatus: readingDegraded,
+		},
+		{
+			name: "inactive meter", rd: domain.Reading{Voltage: 230, Current: 10, PowerKW: 2.2, MeterStatus: "commissioned"},
+			wantKVA: 2.3, wantPF: 0.96, wantScore: 100, wantFlags: []string{}, wantStatus: "commissioned",
+		},
+		{
+			name: "negative power", rd: domain.Reading{Voltage: 230, Current: 10, PowerKW: -1},
+			wantFlags: []string{"negative_values"}, wantStatus: readingInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := deriveReading(tt.rd)
+			if d.ApparentPowerKVA != tt.wantKVA || d.PowerFactor != tt.wantPF || d.QualityScore != tt.wantScore {
+				t.Errorf("%v kVA, PF %v, score %d; want %v, %v, %d", d.ApparentPowerKVA, d.PowerFactor, d.QualityScore, tt.wantKVA, tt.wantPF, tt.wantScore)
+			}
+			if !reflect.DeepEqual(d.QualityFlags, tt.wantFlags) || d.Status != tt.wantStatus {
+				t.Errorf("flags %v, status %q; want %v, %q", d.QualityFlags, d.Status, tt.wantFlags, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetReading(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := memstore.New()
+	rd := domain.Reading{MeterID: 7, Timestamp: timeutil.FromTime(at), Voltage: 230, Current: 10, PowerKW: 2.2}
+	if err := store.PutReading(&rd, "facility-001"); err != nil {
+		t.Fatal(err)
+	}
+	s := newImportService(store)
+
+	tests := []struct {
+		name      string
+		facility  string
+		meter     int64
+		at        time.Time
+		wantFound bool
+	}{
+		{name: "exact", facility: "facility-001", meter: 7, at: at, wantFound: true},
+		{name: "same second", facility: "facility-001", meter: 7, at: at.Add(400 * time.Millisecond), wantFound: true},
+		{name: "other meter", facility: "facility-001", meter: 8, at: at},
+		{name: "other time", facility: "facility-001", meter: 7, at: at.Add(time.Minute)},
+		{name: "other facility", facility: "facility-002", meter: 7, at: at},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := s.GetReading(tt.facility, tt.meter, tt.at)
+			if !tt.wantFound {
+				if !errors.Is(err, ErrReadingNotFound) {
+					t.Fatalf("err = %v, want ErrReadingNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.MeterID != 7 || d.ApparentPowerKVA != 2.3 || d.Status != readingOK {
+				t.Errorf("detail %+v", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/readingpb"
+)
+
+// protoTopicSuffix marks MQTT topics that carry readingpb.Reading payloads
+const protoTopicSuffix = "readings.pb"
+
+// isProtoReading reports whether an MQTT payload is a protobuf reading: always
+// on a topic ending in readings.pb, and otherwise when the payload doesn't
+// look like JSON (a JSON reading starts with '{')
+func isProtoReading(topic string, payload []byte) bool {
+	if strings.HasSuffix(topic, protoTopicSuffix) {
+		return true
+	}
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] != '{'
+}
+
+// parseProtoReading decodes a readingpb.Reading; its values are already in
+// base units, so it goes through the same conversion as a v1 JSON payload
+// without units
+func parseProtoReading(payload []byte) (*domain.Reading, error) {
+	var m readingpb.Reading
+	if err := proto.Unmarshal(payload, &m); err != nil {
+		return nil, fmt.Errorf("invalid protobuf reading: %w", err)
+	}
+
+	var ts time.Time
+	if m.GetTimestampMs() != 0 {
+		ts = time.UnixMilli(m.GetTimestampMs()).UTC()
+	}
+	return readingFromPayload(readingPayload{
+		MeterID: m.GetMeterId(),
+		Voltage: m.GetVoltage(),
+		Current: m.GetCurrent(),
+		PowerKW: m.GetPowerKw(),
+
+		ReactivePowerKVAR: m.ReactivePowerKvar,
+		FrequencyHz:       m.FrequencyHz,
+		THDPercent:        m.ThdPercent,
+	}, ts)
+}
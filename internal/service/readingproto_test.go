@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/readingpb"
+)
+
+func TestFromMQTTProtobuf(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	encode := func(m *readingpb.Reading) []byte {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	full := encode(&readingpb.Reading{
+		MeterId: "7", TimestampMs: ts.UnixMilli(), Voltage: 231.5, Current: 12.25, PowerKw: 2.75,
+		ReactivePowerKvar: proto.Float64(0.8), FrequencyHz: proto.Float64(49.98), ThdPercent: proto.Float64(3.1),
+	})
+
+	tests := []struct {
+		name        string
+		topic       string
+		payload     []byte
+		wantMeter   int64
+		wantPowerKW float64
+		wantFreq    float64 // 0 for none
+		wantErr     bool
+	}{
+		{name: "protobuf on a readings.pb topic", topic: "energy/facility-001/readings.pb", payload: full, wantMeter: 7, wantPowerKW: 2.75, wantFreq: 49.98},
+		{name: "protobuf detected by content", topic: "energy/readings", payload: full, wantMeter: 7, wantPowerKW: 2.75, wantFreq: 49.98},
+		{
+			name:      "protobuf without optional fields",
+			topic:     "energy/readings.pb",
+			payload:   encode(&readingpb.Reading{MeterId: "8", TimestampMs: ts.UnixMilli(), Voltage: 230, PowerKw: 1.5}),
+			wantMeter: 8, wantPowerKW: 1.5,
+		},
+		{
+			name:      "JSON stays the default",
+			topic:     "energy/readings",
+			payload:   []byte(` {"meter_id": "7", "timestamp": "2024-03-01T12:00:00Z", "voltage": 231.5, "current": 12.25, "power_kw": 2.75}`),
+			wantMeter: 7, wantPowerKW: 2.75,
+		},
+		{name: "JSON on a readings.pb topic", topic: "energy/readings.pb", payload: []byte(`{"meter_id": "7", "power_kw": 2.75}`), wantErr: true},
+		{name: "truncated protobuf", topic: "energy/readings.pb", payload: full[:len(full)-3], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memstore.New()
+			s := newImportService(store)
+			rd, err := s.FromMQTT(tt.topic, tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("stored %+v, want an error", rd)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rd.MeterID != tt.wantMeter || rd.PowerKW != tt.wantPowerKW || !rd.Timestamp.Equal(ts) {
+				t.Errorf("meter %d, %v kW at %v; want %d, %v kW at %v", rd.MeterID, rd.PowerKW, rd.Timestamp.Time, tt.wantMeter, tt.wantPowerKW, ts)
+			}
+			if got := rd.FrequencyHz; (got == nil) != (tt.wantFreq == 0) || got != nil && *got != tt.wantFreq {
+				t.Errorf("frequency = %v, want %v", got, tt.wantFreq)
+			}
+
+			stored, err := store.GetRecentReadings("facility-001", 100*365*24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 1 || stored[0].MeterID != tt.wantMeter || stored[0].PowerKW != tt.wantPowerKW {
+				t.Errorf("stored %+v", stored)
+			}
+		})
+	}
+}
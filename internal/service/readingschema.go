@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reading payload schema versions. Payloads without schema_version (or with
+// one that isn't a positive integer) are parsed as v1, so existing producers
+// keep working; versions newer than latestReadingSchema are rejected rather
+// than half-parsed.
+const (
+	readingSchemaV1     = 1
+	readingSchemaV2     = 2
+	latestReadingSchema = readingSchemaV2
+)
+
+// ErrUnsupportedSchema is returned for payloads from a newer producer than this build understands
+var ErrUnsupportedSchema = errors.New("unsupported reading schema_version")
+
+// readingPayload is the v1 (flat) reading payload; later versions are
+// converted to it so normalization happens in one place
+type readingPayload struct {
+	MeterID     string          `json:"meter_id"`
+	Timestamp   json.RawMessage `json:"timestamp"`
+	Voltage     float64         `json:"voltage"`
+	Current     float64         `json:"current"`
+	PowerKW     float64         `json:"power_kw"`
+	PowerUnit   string          `json:"power_unit"`
+	VoltageUnit string          `json:"voltage_unit"`
+
+	ReactivePowerKVAR *float64 `json:"reactive_power_kvar"`
+	FrequencyHz       *float64 `json:"frequency_hz"`
+	THDPercent        *float64 `json:"thd_percent"`
+}
+
+// readingPayloadV2 groups measurements and their units, and carries free-form
+// producer metadata (firmware, site tags) that is accepted but not stored
+type readingPayloadV2 struct {
+	MeterID      string          `json:"meter_id"`
+	Timestamp    json.RawMessage `json:"timestamp"`
+	Measurements struct {
+		Voltage           float64  `json:"voltage"`
+		Current           float64  `json:"current"`
+		Power             float64  `json:"power"`
+		ReactivePowerKVAR *float64 `json:"reactive_power_kvar"`
+		FrequencyHz       *float64 `json:"frequency_hz"`
+		THDPercent        *float64 `json:"thd_percent"`
+	} `json:"measurements"`
+	Units struct {
+		Power   string `json:"power"`
+		Voltage string `json:"voltage"`
+	} `json:"units"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// decodeReadingPayload decodes payload according to its schema_version
+func decodeReadingPayload(payload []byte) (readingPayload, error) {
+	var r readingPayload
+	version, err := payloadSchemaVersion(payload)
+	if err != nil {
+		return r, err
+	}
+
+	if version == readingSchemaV1 {
+		err = json.Unmarshal(payload, &r)
+		return r, err
+	}
+
+	var v2 readingPayloadV2
+	if err := json.Unmarshal(payload, &v2); err != nil {
+		return r, err
+	}
+	m := v2.Measurements
+	return readingPayload{
+		MeterID:     v2.MeterID,
+		Timestamp:   v2.Timestamp,
+		Voltage:     m.Voltage,
+		Current:     m.Current,
+		PowerKW:     m.Power,
+		PowerUnit:   v2.Units.Power,
+		VoltageUnit: v2.Units.Voltage,
+
+		ReactivePowerKVAR: m.ReactivePowerKVAR,
+		FrequencyHz:       m.FrequencyHz,
+		THDPercent:        m.THDPercent,
+	}, nil
+}
+
+// payloadSchemaVersion reads schema_version, given as a number or a numeric
+// string. Absent, malformed and non-positive values mean v1.
+func payloadSchemaVersion(payload []byte) (int, error) {
+	var head struct {
+		SchemaVersion json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(payload, &head); err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.Atoi(strings.Trim(string(head.SchemaVersion), `"`))
+	if err != nil || v < readingSchemaV1 {
+		return readingSchemaV1, nil
+	}
+	if v > latestReadingSchema {
+		return 0, fmt.Errorf("%w %d (this build supports up to %d)", ErrUnsupportedSchema, v, latestReadingSchema)
+	}
+	return v, nil
+}
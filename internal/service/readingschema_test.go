@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseReadingPayloadSchemaVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantMeter   int64
+		wantPowerKW float64
+		wantVoltage float64
+		wantFreq    float64 // 0 for none
+		wantErr     error
+	}{
+		{
+			name:      "v1 without schema_version",
+			payload:   `{"meter_id": "3", "voltage": 230, "current": 10, "power_kw": 2.3}`,
+			wantMeter: 3, wantPowerKW: 2.3, wantVoltage: 230,
+		},
+		{
+			name:      "explicit v1",
+			payload:   `{"schema_version": 1, "meter_id": "3", "voltage": 230, "power_kw": 2500, "power_unit": "W"}`,
+			wantMeter: 3, wantPowerKW: 2.5, wantVoltage: 230,
+		},
+		{
+			name: "v2",
+			payload: `{"schema_version": 2, "meter_id": "4", "measurements": {"voltage": 0.4, "current": 12, "power": 4500, "frequency_hz": 50.02},
+				"units": {"power": "W", "voltage": "kV"}, "metadata": {"firmware": "2.1.0"}}`,
+			wantMeter: 4, wantPowerKW: 4.5, wantVoltage: 400, wantFreq: 50.02,
+		},
+		{
+			name:      "v2 as a string",
+			payload:   `{"schema_version": "2", "meter_id": "4", "measurements": {"voltage": 230, "power": 1.5}}`,
+			wantMeter: 4, wantPowerKW: 1.5, wantVoltage: 230,
+		},
+		{
+			name:      "malformed version parsed as v1",
+			payload:   `{"schema_version": "beta", "meter_id": "3", "voltage": 230, "power_kw": 2.3}`,
+			wantMeter: 3, wantPowerKW: 2.3, wantVoltage: 230,
+		},
+		{
+			name:    "future version",
+			payload: `{"schema_version": 3, "meter_id": "3", "readings": [{"power": 1}]}`,
+			wantErr: ErrUnsupportedSchema,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd, err := parseReadingPayload([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rd.MeterID != tt.wantMeter || rd.PowerKW != tt.wantPowerKW || rd.Voltage != tt.wantVoltage {
+				t.Errorf("meter %d, power %v kW, voltage %v V; want %d, %v, %v", rd.MeterID, rd.PowerKW, rd.Voltage, tt.wantMeter, tt.wantPowerKW, tt.wantVoltage)
+			}
+			if got := rd.FrequencyHz; (got == nil) != (tt.wantFreq == 0) || got != nil && *got != tt.wantFreq {
+				t.Errorf("frequency = %v, want %v", got, tt.wantFreq)
+			}
+		})
+	}
+}
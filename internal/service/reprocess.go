@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrReadingNotFound is returned when the reading to reprocess isn't stored
+var ErrReadingNotFound = errors.New("reading not found")
+
+// ReprocessAnomaly reruns anomaly detection for one stored reading by
+// replaying it to the anomaly Lambda as a stream record, e.g. to backfill
+// alerts after a detection fix. Cooldowns still apply, so a reading whose
+// alert was already sent recently won't notify again.
+func (s *Services) ReprocessAnomaly(facilityID, meterID string, timestamp int64) error {
+	if s.DynamoDB == nil || s.Lambda == nil {
+		return fmt.Errorf("cloud services not enabled")
+	}
+
+	item, err := s.DynamoDB.GetReadingItem(facilityID, timestamp)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return ErrReadingNotFound
+	}
+	if m, ok := item["meterId"].(*types.AttributeValueMemberS); !ok || m.Value != meterID {
+		return ErrReadingNotFound
+	}
+
+	if err := s.Lambda.ReplayReadingToAnomalyDetection(item); err != nil {
+		return fmt.Errorf("failed to reprocess reading: %w", err)
+	}
+	return nil
+}
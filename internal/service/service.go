@@ -2,8 +2,10 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/aggregator"
@@ -12,7 +14,11 @@ import (
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/config"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
 	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/webhook"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -22,6 +28,8 @@ type Services struct {
 	Analytics   *AnalyticsService
 	Alerts      *AlertService
 	Maintenance *MaintenanceService // NEW
+	Enricher    *Enricher
+	Timezones   *FacilityTimezones
 
 	// Cloud clients
 	DynamoDB *cloud.DynamoDBClient
@@ -29,6 +37,12 @@ type Services struct {
 	SNS      *cloud.SNSClient
 	Lambda   *cloud.LambdaClient
 	UseCloud bool
+
+	// Store backs readings, alerts and equipment; nil for the postgres backend
+	Store Store
+
+	// APIKeys scopes clients to one facility; nil when API_KEYS_FILE is unset
+	APIKeys *APIKeys
 }
 
 // New creates a new Services instance with cloud integration
@@ -37,24 +51,40 @@ func New(db *sqlx.DB) (*Services, error) {
 
 	svcs := &Services{
 		Repos:    repos,
+		Enricher: NewEnricher(repos),
 		UseCloud: config.UseCloudServices(),
 	}
 
+	backend := config.StorageBackend()
+
 	// Initialize cloud clients if enabled
-	if svcs.UseCloud {
-		var err error
+	if svcs.UseCloud || backend == config.BackendDynamoDB {
+		precision, err := cloud.ParsePrecision(config.DynamoDBPrecision())
+		if err != nil {
+			return nil, err
+		}
 
-		svcs.DynamoDB, err = cloud.NewDynamoDBClient(config.AWSRegion())
+		svcs.DynamoDB, err = cloud.NewDynamoDBClient(config.AWSRegion(), config.AWSSecondaryRegion(),
+			config.DynamoDBBatchSize(), config.DynamoDBWriteParallelism(), precision)
 		if err != nil {
 			return nil, fmt.Errorf("failed to init DynamoDB: %w", err)
 		}
+	}
+
+	if svcs.UseCloud {
+		var err error
 
 		svcs.S3, err = cloud.NewS3Client(config.AWSRegion(), config.S3Bucket())
 		if err != nil {
 			return nil, fmt.Errorf("failed to init S3: %w", err)
 		}
 
-		svcs.SNS, err = cloud.NewSNSClient(config.AWSRegion(), config.SNSTopicArn())
+		format, err := cloud.ParseMessageFormat(config.SNSMessageFormat())
+		if err != nil {
+			return nil, err
+		}
+		svcs.SNS, err = cloud.NewSNSClient(config.AWSRegion(), config.SNSTopicArn(), config.AlertSubjectTemplate(), format,
+			config.MaintenanceMessageTemplate())
 		if err != nil {
 			return nil, fmt.Errorf("failed to init SNS: %w", err)
 		}
@@ -66,55 +96,277 @@ func New(db *sqlx.DB) (*Services, error) {
 		}
 	}
 
+	switch backend {
+	case config.BackendDynamoDB:
+		// Fails fast while DynamoDB is failing instead of piling up latency
+		svcs.Store = newBreakerStore(svcs.DynamoDB, config.CloudBreakerThreshold(), config.CloudBreakerCooldown())
+	case config.BackendMemory:
+		svcs.Store = memstore.New()
+	case config.BackendPostgres:
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+
+	vendors, err := loadVendorMappings(config.VendorMappingsFile())
+	if err != nil {
+		return nil, err
+	}
+	if svcs.APIKeys, err = loadAPIKeys(config.APIKeysFile()); err != nil {
+		return nil, err
+	}
+
+	maxRange := queryRange(config.MaxQueryRange())
 	svcs.Readings = &ReadingService{
-		repos:    repos,
-		dynamoDB: svcs.DynamoDB,
-		lambda:   svcs.Lambda,
-		useCloud: svcs.UseCloud,
+		repos:      repos,
+		calibrator: newCalibrator(repos),
+		vendors:    vendors,
+		useCloud:   svcs.UseCloud,
+		maxRange:   maxRange,
+	}
+	if svcs.Lambda != nil {
+		overflow, err := ParseOverflowPolicy(config.AnomalyQueueOverflow())
+		if err != nil {
+			return nil, err
+		}
+		svcs.Readings.anomalies = newInvokeQueue(config.AnomalyQueueWorkers(), config.AnomalyQueueSize(), overflow,
+			func(p cloud.AnomalyDetectionPayload) error {
+				_, err := svcs.Lambda.InvokeAnomalyDetection(p)
+				return err
+			})
+	}
+	if config.ReadingsArchiveEnabled() {
+		if svcs.S3 == nil {
+			return nil, fmt.Errorf("READINGS_ARCHIVE_ENABLED needs S3; enable cloud services")
+		}
+		svcs.Readings.archive = newReadingArchiver(svcs.S3.UploadDataFile, strings.TrimSuffix(config.ReadingsArchivePrefix(), "/"),
+			config.ReadingsArchiveMaxBatch(), config.ReadingsArchiveFlushInterval())
+	}
+
+	// Only pass a non-nil client so the resolver sees a nil interface otherwise
+	var zoneStore FacilityTimezoneStore
+	if svcs.DynamoDB != nil {
+		zoneStore = svcs.DynamoDB
+	}
+	svcs.Timezones = newFacilityTimezones(repos, zoneStore)
+
+	costs, err := costmodel.Load(config.CostModelFile(), costmodel.Default(config.EnergyRatePerKWh(), config.SamplingIntervalSeconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cost model: %w", err)
+	}
+	aggregate, err := ParseAggregatePolicy(config.AggregatePolicy())
+	if err != nil {
+		return nil, err
 	}
 
 	svcs.Analytics = &AnalyticsService{
-		repos:    repos,
-		dynamoDB: svcs.DynamoDB,
-		s3:       svcs.S3,
-		lambda:   svcs.Lambda,
-		useCloud: svcs.UseCloud,
+		repos:     repos,
+		s3:        svcs.S3,
+		lambda:    svcs.Lambda,
+		useCloud:  svcs.UseCloud,
+		zones:     svcs.Timezones,
+		costs:     costs,
+		aggregate: aggregate,
+		maxRange:  maxRange,
+
+		rangeMaxDays:     config.AnalyticsRangeMaxDays(),
+		rangeGenerateMax: config.AnalyticsRangeGenerateMax(),
+	}
+	if svcs.Lambda != nil {
+		svcs.Analytics.generator = svcs.Lambda
+	}
+	if svcs.DynamoDB != nil {
+		svcs.Analytics.summaries = svcs.DynamoDB
 	}
 
+	retention, err := parseAlertRetention(config.AlertRetentionDays(), config.AlertRetentionByFacility())
+	if err != nil {
+		return nil, err
+	}
 	svcs.Alerts = &AlertService{
-		repos:    repos,
-		dynamoDB: svcs.DynamoDB,
-		sns:      svcs.SNS,
-		useCloud: svcs.UseCloud,
+		repos:     repos,
+		hub:       newAlertHub(),
+		sns:       svcs.SNS,
+		useCloud:  svcs.UseCloud,
+		retention: retention,
+	}
+	if svcs.S3 != nil {
+		svcs.Alerts.archive = svcs.S3.UploadDataFile
+	}
+
+	// Alert notifications go to SNS and/or a webhook, routed by severity
+	notifiers := make(map[string]Notifier)
+	if svcs.SNS != nil {
+		notifiers["sns"] = svcs.SNS
+	}
+	switch config.NotifyMode() {
+	case config.NotifyImmediate:
+	case config.NotifyDigest:
+		// Only SNS is batched; webhook consumers still get each alert as it happens
+		if svcs.SNS != nil {
+			interval := config.NotifyDigestInterval()
+			if interval <= 0 {
+				return nil, fmt.Errorf("NOTIFY_DIGEST_MINUTES must be positive in digest mode")
+			}
+			digest := newDigestNotifier(svcs.SNS)
+			go digest.run(interval)
+			notifiers["sns"] = digest
+		}
+	default:
+		return nil, fmt.Errorf("unknown notify mode %q: expected immediate or digest", config.NotifyMode())
+	}
+	if url := config.AlertWebhookURL(); url != "" {
+		notifiers["webhook"] = webhook.New(url, config.AlertWebhookSecret())
+	}
+	if len(notifiers) > 0 {
+		router, err := newSeverityRouter(notifiers, config.AlertRoutes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure alert routing: %w", err)
+		}
+		svcs.Alerts.notifier = router
 	}
+
 	svcs.Maintenance = &MaintenanceService{
-		dynamoDB: svcs.DynamoDB,
-		sns:      svcs.SNS,
+		repos:    repos,
 		useCloud: svcs.UseCloud,
 	}
+	if svcs.SNS != nil {
+		svcs.Maintenance.sns = svcs.SNS
+	}
+
+	// Assign stores only when set so the services see a nil interface otherwise
+	if svcs.Store != nil {
+		svcs.Readings.store = svcs.Store
+		svcs.Analytics.store = svcs.Store
+		svcs.Alerts.store = svcs.Store
+		svcs.Maintenance.equipment = svcs.Store
+		if ps, ok := svcs.Store.(PredictionStore); ok {
+			svcs.Maintenance.predictions = ps
+		}
+	}
 	return svcs, nil
 }
 
+// Close writes out buffered work, such as archived readings not yet in S3;
+// call it on shutdown
+func (s *Services) Close() {
+	s.Readings.archive.close()
+}
+
 // ReadingService handles energy reading operations
 type ReadingService struct {
-	repos    *repository.Repos
-	dynamoDB *cloud.DynamoDBClient
-	lambda   *cloud.LambdaClient
-	useCloud bool
+	repos      *repository.Repos
+	store      ReadingStore
+	calibrator *calibrator
+	sources    sourceCounter
+	vendors    vendorMappings
+	useCloud   bool
+
+	// anomalies queues readings for the anomaly Lambda; nil without Lambda
+	anomalies *invokeQueue
+	// archive batches stored readings into S3; nil unless READINGS_ARCHIVE_ENABLED
+	archive *readingArchiver
+
+	maxRange queryRange
+}
+
+// powerUnitFactors converts a payload power_unit to kW (matched case-insensitively)
+var powerUnitFactors = map[string]float64{
+	"w":  0.001,
+	"kw": 1,
+	"mw": 1000,
 }
 
-// FromMQTT processes MQTT message and stores in appropriate backend
-func (s *ReadingService) FromMQTT(topic string, payload []byte) error {
-	var r struct {
-		MeterID   string    `json:"meter_id"`
-		Timestamp time.Time `json:"timestamp"`
-		Voltage   float64   `json:"voltage"`
-		Current   float64   `json:"current"`
-		PowerKW   float64   `json:"power_kw"`
+// voltageUnitFactors converts a payload voltage_unit to volts (matched case-insensitively)
+var voltageUnitFactors = map[string]float64{
+	"v":  1,
+	"kv": 1000,
+}
+
+// unitFactor looks up the conversion factor for unit, treating an empty unit as the base unit
+func unitFactor(factors map[string]float64, unit string) (float64, error) {
+	if unit == "" {
+		return 1, nil
 	}
-	if err := json.Unmarshal(payload, &r); err != nil {
-		return err
+	f, ok := factors[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		return 0, fmt.Errorf("unsupported unit %q", unit)
+	}
+	return f, nil
+}
+
+// FromMQTT processes MQTT message and stores in appropriate backend. The
+// parsed reading is returned (nil when the payload is invalid) so the caller
+// can track ingestion lag. Payloads are JSON unless they are protobuf; see
+// isProtoReading. JSON from a configured vendor is normalized first; see
+// vendorMappings.normalize.
+func (s *ReadingService) FromMQTT(topic string, payload []byte) (*domain.Reading, error) {
+	payload, err := s.vendors.normalize(topic, payload)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := parseStreamReading(topic, payload)
+	if err != nil {
+		return nil, err
+	}
+	rd.Source = domain.SourceMQTT
+	return rd, s.StoreReading("facility-001", rd)
+}
+
+// FromKinesis processes a Kinesis record's data the same way as an MQTT
+// payload and stores it for facilityID. Records have no topic, so protobuf is
+// detected from the payload alone.
+func (s *ReadingService) FromKinesis(facilityID string, data []byte) (*domain.Reading, error) {
+	data, err := s.vendors.normalize("", data)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := parseStreamReading("", data)
+	if err != nil {
+		return nil, err
 	}
+	rd.Source = domain.SourceKinesis
+	return rd, s.StoreReading(facilityID, rd)
+}
+
+// parseStreamReading decodes a streamed reading as protobuf or JSON; see isProtoReading
+func parseStreamReading(topic string, payload []byte) (*domain.Reading, error) {
+	if isProtoReading(topic, payload) {
+		return parseProtoReading(payload)
+	}
+	return parseReadingPayload(payload)
+}
+
+// parseReadingPayload decodes a JSON reading payload into a domain.Reading.
+// The payload's power_kw field may be reported in W, kW or MW when tagged with
+// power_unit, and voltage in V or kV via voltage_unit; both default to the base
+// unit (kW, V) and are normalized before storage. timestamp may be RFC3339 or
+// epoch seconds/milliseconds. The layout depends on schema_version; see
+// decodeReadingPayload.
+func parseReadingPayload(payload []byte) (*domain.Reading, error) {
+	r, err := decodeReadingPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := timeutil.ParseJSONTimestamp(r.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return readingFromPayload(r, ts)
+}
+
+// readingFromPayload converts a decoded payload to base units and a domain.Reading
+func readingFromPayload(r readingPayload, ts time.Time) (*domain.Reading, error) {
+	powerFactor, err := unitFactor(powerUnitFactors, r.PowerUnit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid power_unit: %w", err)
+	}
+	voltageFactor, err := unitFactor(voltageUnitFactors, r.VoltageUnit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid voltage_unit: %w", err)
+	}
+	r.PowerKW *= powerFactor
+	r.Voltage *= voltageFactor
 
 	// Parse meter ID to int64
 	var meterIDInt int64 = 1
@@ -127,83 +379,207 @@ func (s *ReadingService) FromMQTT(topic string, payload []byte) error {
 
 	rd := &domain.Reading{
 		MeterID:   meterIDInt,
-		Timestamp: r.Timestamp,
+		Timestamp: timeutil.FromTime(ts),
 		Voltage:   r.Voltage,
 		Current:   r.Current,
 		PowerKW:   r.PowerKW,
+
+		ReactivePowerKVAR: r.ReactivePowerKVAR,
+		FrequencyHz:       r.FrequencyHz,
+		THDPercent:        r.THDPercent,
+	}
+
+	return rd, nil
+}
+
+// FromHTTP processes a reading posted to the API, using the same payload format as MQTT
+func (s *ReadingService) FromHTTP(facilityID string, payload []byte) error {
+	payload, err := s.vendors.normalize("", payload)
+	if err != nil {
+		return err
+	}
+	rd, err := parseReadingPayload(payload)
+	if err != nil {
+		return err
+	}
+	rd.Source = domain.SourceHTTP
+	return s.StoreReading(facilityID, rd)
+}
+
+// FromHTTPBatch processes a JSON array of reading payloads posted to the API
+// and stores them together. The whole batch is rejected if any entry is
+// invalid, naming the offending index.
+func (s *ReadingService) FromHTTPBatch(facilityID string, payload []byte) (int, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return 0, fmt.Errorf("expected a JSON array of readings: %w", err)
+	}
+	if len(raw) == 0 {
+		return 0, fmt.Errorf("empty batch")
+	}
+
+	readings := make([]domain.Reading, len(raw))
+	for i, p := range raw {
+		p, err := s.vendors.normalize("", p)
+		if err != nil {
+			return 0, fmt.Errorf("reading %d: %w", i, err)
+		}
+		rd, err := parseReadingPayload(p)
+		if err != nil {
+			return 0, fmt.Errorf("reading %d: %w", i, err)
+		}
+		rd.Source = domain.SourceBatch
+		readings[i] = *rd
+	}
+
+	if err := s.storeBatch(facilityID, readings); err != nil {
+		return 0, err
 	}
+	return len(readings), nil
+}
 
-	// Store in cloud if enabled
-	if s.useCloud && s.dynamoDB != nil {
-		if err := s.dynamoDB.PutReading(rd, "facility-001"); err != nil {
+// StoreReading calibrates a parsed reading for its meter and stores it in the configured backend
+func (s *ReadingService) StoreReading(facilityID string, rd *domain.Reading) error {
+	s.calibrator.Apply(rd)
+
+	if s.store != nil {
+		if err := s.store.PutReading(rd, facilityID); err != nil {
 			return err
 		}
+		s.sources.add(rd.Source, 1)
+		s.archive.add(facilityID, *rd)
 
-		// Optionally invoke Lambda for immediate anomaly detection
-		if s.lambda != nil {
+		// Optionally invoke Lambda for immediate anomaly detection; meters
+		// that aren't active are stored but not checked
+		if s.anomalies != nil && rd.MeterStatus == "" {
 			payload := cloud.AnomalyDetectionPayload{
-				FacilityID: "facility-001",
-				MeterID:    r.MeterID,
-				Timestamp:  r.Timestamp.Unix(),
-				Voltage:    r.Voltage,
-				Current:    r.Current,
-				PowerKW:    r.PowerKW,
+				FacilityID: facilityID,
+				MeterID:    strconv.FormatInt(rd.MeterID, 10),
+				Timestamp:  rd.Timestamp,
+				Voltage:    rd.Voltage,
+				Current:    rd.Current,
+				PowerKW:    rd.PowerKW,
 			}
 
-			// Invoke asynchronously (fire and forget)
-			go func() {
-				_, err := s.lambda.InvokeAnomalyDetection(payload)
-				if err != nil {
-					fmt.Printf("Failed to invoke anomaly detection: %v\n", err)
-				}
-			}()
+			// Invoked asynchronously by the queue's workers; a full queue
+			// drops the check or blocks per ANOMALY_QUEUE_OVERFLOW
+			s.anomalies.submit(payload)
 		}
 
 		return nil
 	}
 
-	return s.repos.InsertReading(rd)
+	inserted, err := s.repos.InsertReading(rd)
+	if err != nil || !inserted {
+		// A reading already stored (e.g. redelivered by the broker) isn't counted again
+		return err
+	}
+	s.sources.add(rd.Source, 1)
+	s.archive.add(facilityID, *rd)
+	return nil
 }
 
 // GetRecentReadings retrieves recent readings for a meter
 func (s *ReadingService) GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error) {
-	if s.useCloud && s.dynamoDB != nil {
-		return s.dynamoDB.GetRecentReadings(facilityID, duration)
+	if s.store != nil {
+		return s.store.GetRecentReadings(facilityID, duration)
 	}
 
 	// Fallback to local DB (implement this in repository if needed)
 	return []domain.Reading{}, fmt.Errorf("local DB reading retrieval not implemented")
 }
 
+// GetRecentReadingsPage retrieves one page of a facility's recent readings with
+// the query window and a cursor for the next page
+func (s *ReadingService) GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, limit int, fields cloud.Projection) (*domain.ReadingPage, error) {
+	if err := s.maxRange.check(duration); err != nil {
+		return nil, err
+	}
+	if s.store != nil {
+		return s.store.GetRecentReadingsPage(facilityID, duration, cursor, limit, fields)
+	}
+
+	// Fallback to local DB (implement this in repository if needed)
+	return nil, fmt.Errorf("local DB reading retrieval not implemented")
+}
+
 // AnalyticsService handles analytics and reporting operations
 type AnalyticsService struct {
 	repos    *repository.Repos
-	dynamoDB *cloud.DynamoDBClient
+	store    ReadingStore
 	s3       *cloud.S3Client
 	lambda   *cloud.LambdaClient
 	useCloud bool
+	zones    *FacilityTimezones
+	costs    costmodel.Model
+
+	// aggregate is the AGGREGATE_POLICY for summaries without a meter
+	aggregate string
+
+	// summaries reads the Lambda's stored daily summaries; nil without DynamoDB
+	summaries AnalyticsSummaryStore
+
+	maxRange queryRange
+
+	// Bounds of GetSummaryRange: days per request and summaries generated on
+	// demand by generator, nil without Lambda
+	rangeMaxDays     int
+	rangeGenerateMax int
+	generator        summaryGenerator
 }
 
 // DailySummary represents daily energy consumption summary
 type DailySummary struct {
 	Date                time.Time `json:"date"`
+	Timezone            string    `json:"timezone"`
 	TotalConsumption    float64   `json:"total_consumption"`
 	TotalConsumptionMWh float64   `json:"total_consumption_mwh"`
 	PeakPower           float64   `json:"peak_power"`
 	AveragePower        float64   `json:"average_power"`
 	Efficiency          float64   `json:"efficiency"`
 	ReadingCount        int       `json:"reading_count"`
+
+	// MeterID is set for a single meter's summary; otherwise Aggregate is the
+	// policy that combined MeterCount meters
+	MeterID    int64  `json:"meter_id,omitempty"`
+	MeterCount int    `json:"meter_count"`
+	Aggregate  string `json:"aggregate,omitempty"`
+
+	// Hourly breakdown, present when aggregated in SQL
+	Hourly []domain.HourlyAggregate `json:"hourly,omitempty"`
 }
 
-// GetDailySummary calculates daily consumption summary
-func (s *AnalyticsService) GetDailySummary(facilityID string, date time.Time) (*DailySummary, error) {
+// GetDailySummary calculates daily consumption summary for one meter, or for
+// the whole facility under the aggregate policy when meterID is 0
+func (s *AnalyticsService) GetDailySummary(facilityID string, meterID int64, date time.Time) (*DailySummary, error) {
+	if meterID == 0 && s.aggregate == AggregatePerMeterRequired {
+		return nil, fmt.Errorf("%w: facility-wide summaries are disabled by AGGREGATE_POLICY", ErrMeterRequired)
+	}
+	if s.store == nil {
+		return s.dailySummaryFromSQL(facilityID, meterID, date)
+	}
+
 	readings, err := s.getReadingsForDate(facilityID, date)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get readings: %w", err)
 	}
+	if meterID != 0 {
+		mine := readings[:0]
+		for _, r := range readings {
+			if r.MeterID == meterID {
+				mine = append(mine, r)
+			}
+		}
+		readings = mine
+	}
 
+	start, _ := s.zones.DayWindow(facilityID, date)
 	if len(readings) == 0 {
-		return &DailySummary{Date: date}, nil
+		summary := &DailySummary{Date: start, Timezone: start.Location().String(), MeterID: meterID}
+		if meterID == 0 {
+			summary.Aggregate = s.aggregate
+		}
+		return summary, nil
 	}
 
 	// YOUR ORIGINAL CONTRIBUTION: Convert readings to aggregator points
@@ -211,12 +587,13 @@ func (s *AnalyticsService) GetDailySummary(facilityID string, date time.Time) (*
 	for i, r := range readings {
 		points[i] = aggregator.Point{
 			Value:     r.PowerKW,
-			Timestamp: r.Timestamp,
+			Timestamp: r.Timestamp.Time,
 		}
 	}
 
 	// YOUR ORIGINAL CONTRIBUTION: Use library's aggregation functions
-	totalConsumption := aggregator.Sum(points)
+	powerSum := aggregator.Sum(points)
+	totalConsumption := samplesToKWh(powerSum, config.SamplingIntervalSeconds())
 	averagePower := aggregator.Average(points)
 
 	// YOUR ORIGINAL CONTRIBUTION: Use converter for unit conversions
@@ -224,21 +601,71 @@ func (s *AnalyticsService) GetDailySummary(facilityID string, date time.Time) (*
 	totalConsumptionMWh := conv.KWhToMWh(totalConsumption)
 
 	// YOUR ORIGINAL CONTRIBUTION: Calculate efficiency
-	efficiency := conv.CalculateEfficiency(totalConsumption, averagePower*float64(len(readings)))
+	efficiency := conv.CalculateEfficiency(powerSum, averagePower*float64(len(readings)))
 
 	summary := &DailySummary{
-		Date:                date,
+		Date:                start,
+		Timezone:            start.Location().String(),
 		ReadingCount:        len(readings),
 		TotalConsumption:    totalConsumption,
 		TotalConsumptionMWh: totalConsumptionMWh,
 		AveragePower:        averagePower,
 		Efficiency:          efficiency,
 		PeakPower:           s.findPeakPower(points),
+		MeterID:             meterID,
+		MeterCount:          1,
+	}
+	if meterID == 0 {
+		applyAggregatePolicy(summary, meterPeaks(readings), s.aggregate)
 	}
 
 	return summary, nil
 }
 
+// dailySummaryFromSQL aggregates the day in Postgres for the local (non-cloud) backend
+func (s *AnalyticsService) dailySummaryFromSQL(facilityID string, meterID int64, date time.Time) (*DailySummary, error) {
+	id, ok := parseNumericID(facilityID, "facility")
+	if !ok {
+		return nil, fmt.Errorf("invalid facility id %q", facilityID)
+	}
+
+	loc := s.zones.Location(facilityID)
+	agg, err := s.repos.DailyAggregate(id, meterID, date, loc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate readings: %w", err)
+	}
+
+	conv := &converter.EnergyConverter{}
+	totalConsumption := samplesToKWh(agg.TotalPower, config.SamplingIntervalSeconds())
+	summary := &DailySummary{
+		Date:                agg.Date,
+		Timezone:            loc.String(),
+		ReadingCount:        agg.ReadingCount,
+		TotalConsumption:    totalConsumption,
+		TotalConsumptionMWh: conv.KWhToMWh(totalConsumption),
+		AveragePower:        agg.AveragePower,
+		Efficiency:          conv.CalculateEfficiency(agg.TotalPower, agg.AveragePower*float64(agg.ReadingCount)),
+		PeakPower:           agg.PeakPower,
+		MeterID:             meterID,
+		MeterCount:          len(agg.MeterPeaks),
+		Hourly:              agg.Hourly,
+	}
+	if meterID == 0 {
+		applyAggregatePolicy(summary, agg.MeterPeaks, s.aggregate)
+	}
+	return summary, nil
+}
+
+// samplesToKWh converts a sum of kW samples to kWh, assuming each reading
+// represents intervalSeconds of constant power. Non-positive intervals are
+// treated as one hour, where the sum already equals the energy.
+func samplesToKWh(sum, intervalSeconds float64) float64 {
+	if intervalSeconds <= 0 {
+		return sum
+	}
+	return sum * intervalSeconds / 3600
+}
+
 func (s *AnalyticsService) findPeakPower(points []aggregator.Point) float64 {
 	peak := 0.0
 	for _, p := range points {
@@ -248,36 +675,109 @@ func (s *AnalyticsService) findPeakPower(points []aggregator.Point) float64 {
 	}
 	return peak
 }
+
+// getReadingsForDate returns the readings of the facility-local day of date
 func (s *AnalyticsService) getReadingsForDate(facilityID string, date time.Time) ([]domain.Reading, error) {
-	if s.useCloud && s.dynamoDB != nil {
-		return s.dynamoDB.GetRecentReadings(facilityID, 24*time.Hour)
+	start, end := s.zones.DayWindow(facilityID, date)
+	return s.getReadingsForWindow(facilityID, start, end)
+}
+
+// getReadingsForWindow returns the readings in [start, end). Stores only query
+// back from now, so the window is read from start a page at a time, oldest
+// first, until the pages pass end or run out; a single query stops at the
+// backend's page size (1 MB on DynamoDB), far short of a month.
+func (s *AnalyticsService) getReadingsForWindow(facilityID string, start, end time.Time) ([]domain.Reading, error) {
+	if s.store == nil {
+		// Fallback to local DB
+		return []domain.Reading{}, nil
 	}
 
-	// Fallback to local DB
-	return []domain.Reading{}, nil
+	// A minute to spare, since the store reads back from its own now; the
+	// window check below is exact
+	duration := time.Since(start) + time.Minute
+	window := []domain.Reading{}
+	cursor := ""
+	for {
+		page, err := s.store.GetRecentReadingsPage(facilityID, duration, cursor, 0, cloud.Projection{})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.Readings {
+			if !r.Timestamp.Before(start) && r.Timestamp.Before(end) {
+				window = append(window, r)
+			}
+		}
+		n := len(page.Readings)
+		if page.NextCursor == "" || n > 0 && !page.Readings[n-1].Timestamp.Before(end) {
+			return window, nil
+		}
+		cursor = page.NextCursor
+	}
 }
 
 // GenerateDailyReport generates daily analytics report using Lambda
 // YOUR ORIGINAL CONTRIBUTION: Leverage serverless computing for report generation
 func (s *AnalyticsService) GenerateDailyReport(facilityID, date string) (string, error) {
+	report, err := s.GenerateDailyAnalytics(facilityID, date, ReportFormatJSON, 0)
+	if err != nil {
+		return "", err
+	}
+	return report.ReportURL, nil
+}
+
+// GeneratedReport is the outcome of a Lambda analytics run
+type GeneratedReport struct {
+	ReportURL string
+	// Analytics is the Lambda's daily analytics, including its coverage and
+	// confidence; nil when the Lambda had no data for the date
+	Analytics map[string]interface{}
+}
+
+// Report formats the analytics Lambda can render
+const (
+	ReportFormatJSON = "json"
+	ReportFormatPDF  = "pdf"
+)
+
+// ErrInvalidReportFormat is returned for a report format the Lambda can't render
+var ErrInvalidReportFormat = errors.New("invalid report format")
+
+// ErrInvalidGranularity is returned for a bucket interval the Lambda doesn't compute
+var ErrInvalidGranularity = errors.New("invalid analytics granularity")
+
+// GenerateDailyAnalytics runs the analytics Lambda and returns the report URL
+// together with the computed analytics. format is ReportFormatJSON (or empty)
+// or ReportFormatPDF; granularityMinutes is 15, 30, 60 or 0 for the Lambda's
+// default.
+func (s *AnalyticsService) GenerateDailyAnalytics(facilityID, date, format string, granularityMinutes int) (*GeneratedReport, error) {
+	if format != "" && format != ReportFormatJSON && format != ReportFormatPDF {
+		return nil, fmt.Errorf("%w: %q (want json or pdf)", ErrInvalidReportFormat, format)
+	}
+	switch granularityMinutes {
+	case 0, 15, 30, 60:
+	default:
+		return nil, fmt.Errorf("%w: %d minutes (want 15, 30 or 60)", ErrInvalidGranularity, granularityMinutes)
+	}
 	if !s.useCloud || s.lambda == nil {
-		return "", fmt.Errorf("cloud services not enabled")
+		return nil, fmt.Errorf("cloud services not enabled")
 	}
 
 	// Invoke Lambda function to process analytics
-	result, err := s.lambda.InvokeAnalyticsProcessing(date, facilityID)
+	result, err := s.lambda.InvokeAnalyticsProcessing(date, facilityID, format, granularityMinutes)
 	if err != nil {
-		return "", fmt.Errorf("failed to invoke analytics Lambda: %w", err)
+		return nil, fmt.Errorf("failed to invoke analytics Lambda: %w", err)
 	}
 
 	// Extract report URL from response
 	if body, ok := result["body"].(map[string]interface{}); ok {
 		if reportURL, ok := body["report_url"].(string); ok {
-			return reportURL, nil
+			report := &GeneratedReport{ReportURL: reportURL}
+			report.Analytics, _ = body["analytics"].(map[string]interface{})
+			return report, nil
 		}
 	}
 
-	return "", fmt.Errorf("no report URL in response")
+	return nil, fmt.Errorf("no report URL in response")
 }
 
 // ScheduleDailyAnalytics triggers daily analytics processing asynchronously
@@ -294,6 +794,16 @@ func (s *AnalyticsService) ScheduleDailyAnalytics(facilityID string) error {
 	return s.lambda.InvokeAnalyticsAsync(yesterday, facilityID)
 }
 
+// TriggerDailyAnalytics starts an analytics run for the facility and date
+// without waiting for it; an empty date is the Lambda's default, yesterday in
+// the facility's time zone
+func (s *AnalyticsService) TriggerDailyAnalytics(facilityID, date string) error {
+	if !s.useCloud || s.lambda == nil {
+		return fmt.Errorf("cloud services not enabled")
+	}
+	return s.lambda.InvokeAnalyticsAsync(date, facilityID)
+}
+
 // GenerateReport generates and stores a report (using S3 directly)
 func (s *AnalyticsService) GenerateReport(facilityID string, startDate, endDate time.Time) (string, error) {
 	if !s.useCloud || s.s3 == nil {
@@ -317,24 +827,46 @@ func (s *AnalyticsService) GenerateReport(facilityID string, startDate, endDate
 // AlertService handles alert operations
 type AlertService struct {
 	repos    *repository.Repos
-	dynamoDB *cloud.DynamoDBClient
+	store    AlertStore
+	notifier Notifier
+	hub      *alertHub
 	sns      *cloud.SNSClient
 	useCloud bool
+
+	// archive uploads archived alerts to S3; retention sets their TTL
+	archive   func(key string, data []byte) error
+	retention alertRetention
 }
 
-// CreateAlert creates a new alert
+// CreateAlert creates a new alert, notifying in the SNS client's default format
 func (s *AlertService) CreateAlert(facilityID, equipmentID, severity, alertType, message string) error {
-	if s.useCloud && s.dynamoDB != nil {
-		if err := s.dynamoDB.CreateAlert(facilityID, equipmentID, severity, alertType, message); err != nil {
-			return fmt.Errorf("failed to create alert in DynamoDB: %w", err)
+	return s.CreateAlertWithFormat("", facilityID, equipmentID, severity, alertType, message)
+}
+
+// CreateAlertWithFormat creates a new alert and requests a specific SNS message
+// format for its notification; an empty format uses the client default
+func (s *AlertService) CreateAlertWithFormat(format cloud.MessageFormat, facilityID, equipmentID, severity, alertType, message string) error {
+	if s.store != nil {
+		if err := s.store.CreateAlert(facilityID, equipmentID, severity, alertType, message); err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
+
+		n := cloud.Notification{
+			FacilityID:  facilityID,
+			EquipmentID: equipmentID,
+			Severity:    severity,
+			Type:        alertType,
+			Message:     message,
+			Timestamp:   time.Now().UTC(),
+			Format:      format,
 		}
+		s.hub.publish(n)
 
-		// Send notification if SNS is available
-		if s.sns != nil {
-			subject := fmt.Sprintf("[%s] %s Alert", severity, alertType)
-			if err := s.sns.SendAlert(subject, message); err != nil {
+		// Send notification if SNS or a webhook is configured
+		if s.notifier != nil {
+			if err := s.notifier.Notify(n); err != nil {
 				// Log error but don't fail - alert is already stored
-				fmt.Printf("Failed to send SNS notification: %v\n", err)
+				fmt.Printf("Failed to send alert notification: %v\n", err)
 			}
 		}
 
@@ -345,24 +877,52 @@ func (s *AlertService) CreateAlert(facilityID, equipmentID, severity, alertType,
 	return fmt.Errorf("local alert storage not implemented")
 }
 
-// GetAlerts retrieves alerts for a facility
-func (s *AlertService) GetAlerts(facilityID string, severityFilter *string) ([]cloud.Alert, error) {
-	if s.useCloud && s.dynamoDB != nil {
-		return s.dynamoDB.GetAlerts(facilityID, severityFilter)
+// GetAlerts retrieves alerts for a facility, narrowed by filter
+func (s *AlertService) GetAlerts(facilityID string, filter cloud.AlertFilter) ([]cloud.Alert, error) {
+	if s.store != nil {
+		return s.store.GetAlerts(facilityID, filter)
 	}
 
 	return []cloud.Alert{}, fmt.Errorf("local alert retrieval not implemented")
 }
 
+// GetAlert retrieves one alert by ID
+func (s *AlertService) GetAlert(alertID string) (*cloud.Alert, error) {
+	if s.store != nil {
+		return s.store.GetAlert(alertID)
+	}
+
+	return nil, fmt.Errorf("local alert retrieval not implemented")
+}
+
 // AcknowledgeAlert marks an alert as acknowledged
 func (s *AlertService) AcknowledgeAlert(alertID string) error {
-	if s.useCloud && s.dynamoDB != nil {
-		return s.dynamoDB.AcknowledgeAlert(alertID)
+	if s.store != nil {
+		return s.store.AcknowledgeAlert(alertID)
 	}
 
 	return fmt.Errorf("local alert acknowledgment not implemented")
 }
 
+// DismissAlert soft-deletes an alert, e.g. a false positive, recording who
+// dismissed it and why
+func (s *AlertService) DismissAlert(alertID, dismissedBy, reason string) error {
+	if s.store != nil {
+		return s.store.DismissAlert(alertID, dismissedBy, reason)
+	}
+
+	return fmt.Errorf("local alert dismissal not implemented")
+}
+
+// DeleteAlert removes an alert permanently
+func (s *AlertService) DeleteAlert(alertID string) error {
+	if s.store != nil {
+		return s.store.DeleteAlert(alertID)
+	}
+
+	return fmt.Errorf("local alert deletion not implemented")
+}
+
 // DetectAnomalies analyzes readings and creates alerts for anomalies
 func (s *AlertService) DetectAnomalies(facilityID string, readings []domain.Reading) error {
 	// Simple anomaly detection: flag readings with unusual power consumption
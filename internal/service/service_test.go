@@ -0,0 +1,186 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/readingpb"
+)
+
+func TestParseReadingPayloadUnits(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantPowerKW float64
+		wantVoltage float64
+		wantErr     bool
+	}{
+		{name: "base units by default", payload: `{"meter_id": "1", "voltage": 230, "power_kw": 1.2}`, wantPowerKW: 1.2, wantVoltage: 230},
+		{name: "watts", payload: `{"meter_id": "1", "voltage": 230, "power_kw": 1500, "power_unit": "W"}`, wantPowerKW: 1.5, wantVoltage: 230},
+		{name: "megawatts", payload: `{"meter_id": "1", "voltage": 230, "power_kw": 2, "power_unit": "MW"}`, wantPowerKW: 2000, wantVoltage: 230},
+		{name: "kilovolts", payload: `{"meter_id": "1", "voltage": 11, "voltage_unit": "kV", "power_kw": 1}`, wantPowerKW: 1, wantVoltage: 11000},
+		{name: "unit case and space ignored", payload: `{"meter_id": "1", "voltage": 230, "power_kw": 500, "power_unit": " w "}`, wantPowerKW: 0.5, wantVoltage: 230},
+		{name: "unknown power unit", payload: `{"meter_id": "1", "power_kw": 1, "power_unit": "hp"}`, wantErr: true},
+		{name: "unknown voltage unit", payload: `{"meter_id": "1", "voltage": 1, "voltage_unit": "mV"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd, err := parseReadingPayload([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rd.PowerKW != tt.wantPowerKW || rd.Voltage != tt.wantVoltage {
+				t.Errorf("power, voltage = %v kW, %v V; want %v kW, %v V", rd.PowerKW, rd.Voltage, tt.wantPowerKW, tt.wantVoltage)
+			}
+		})
+	}
+}
+
+func TestParseReadingPayloadTimestamps(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		timestamp string
+		want      time.Time
+		wantErr   bool
+	}{
+		{name: "RFC3339", timestamp: `"2024-03-01T12:00:00Z"`, want: want},
+		{name: "epoch seconds", timestamp: `1709294400`, want: want},
+		{name: "epoch millis", timestamp: `1709294400250`, want: want.Add(250 * time.Millisecond)},
+		{name: "quoted epoch millis", timestamp: `"1709294400000"`, want: want},
+		{name: "not a timestamp", timestamp: `"noon"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd, err := parseReadingPayload([]byte(`{"meter_id": "1", "power_kw": 1, "timestamp": ` + tt.timestamp + `}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !rd.Timestamp.Time.Equal(tt.want) {
+				t.Errorf("timestamp = %v, want %v", rd.Timestamp.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReadingPayloadPowerQuality(t *testing.T) {
+	tests := []struct {
+		name             string
+		payload          string
+		wantKVAR, wantHz *float64
+		wantTHD          *float64
+	}{
+		{name: "absent", payload: `{"meter_id": "1", "power_kw": 2}`},
+		{
+			name:     "all present",
+			payload:  `{"meter_id": "1", "power_kw": 2, "reactive_power_kvar": 0.8, "frequency_hz": 50.02, "thd_percent": 4.1}`,
+			wantKVAR: ptr(0.8), wantHz: ptr(50.02), wantTHD: ptr(4.1),
+		},
+		{
+			name:     "zero is kept, not dropped",
+			payload:  `{"meter_id": "1", "power_kw": 2, "reactive_power_kvar": 0}`,
+			wantKVAR: ptr(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd, err := parseReadingPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range []struct {
+				name      string
+				got, want *float64
+			}{
+				{"reactive_power_kvar", rd.ReactivePowerKVAR, tt.wantKVAR},
+				{"frequency_hz", rd.FrequencyHz, tt.wantHz},
+				{"thd_percent", rd.THDPercent, tt.wantTHD},
+			} {
+				if (f.got == nil) != (f.want == nil) || f.got != nil && *f.got != *f.want {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
+
+func ptr(x float64) *float64 { return &x }
+
+func TestSamplesToKWh(t *testing.T) {
+	tests := []struct {
+		name     string
+		sum      float64
+		interval float64
+		want     float64
+	}{
+		// A day of steady 10 kW is 240 kWh whatever the cadence
+		{"hourly", 24 * 10, 3600, 240},
+		{"1-minute", 1440 * 10, 60, 240},
+		{"15-minute", 96 * 10, 900, 240},
+		{"non-positive interval treated as hourly", 240, -1, 240},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := samplesToKWh(tt.sum, tt.interval); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("samplesToKWh(%v, %v) = %v, want %v", tt.sum, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromKinesis(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	pb, err := proto.Marshal(&readingpb.Reading{MeterId: "7", TimestampMs: ts.UnixMilli(), Voltage: 231.5, PowerKw: 2.75})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		data      []byte
+		wantMeter int64
+		wantErr   bool
+	}{
+		{name: "JSON record", data: []byte(`{"meter_id": "7", "timestamp": "2024-03-01T12:00:00Z", "voltage": 231.5, "power_kw": 2.75}`), wantMeter: 7},
+		{name: "protobuf record", data: pb, wantMeter: 7},
+		{name: "kW from W", data: []byte(`{"meter_id": "3", "timestamp": "2024-03-01T12:00:00Z", "voltage": 230, "power_kw": 2750, "power_unit": "W"}`), wantMeter: 3},
+		{name: "not a reading", data: []byte(`meter 7: 2.75 kW`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memstore.New()
+			s := newImportService(store)
+			rd, err := s.FromKinesis("facility-002", tt.data)
+			stored, serr := store.GetRecentReadings("facility-002", 100*365*24*time.Hour)
+			if serr != nil {
+				t.Fatal(serr)
+			}
+			if tt.wantErr {
+				if err == nil || len(stored) != 0 {
+					t.Fatalf("stored %+v, want an error", stored)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rd.Source != domain.SourceKinesis || rd.MeterID != tt.wantMeter || rd.PowerKW != 2.75 || !rd.Timestamp.Equal(ts) {
+				t.Errorf("read %+v", rd)
+			}
+			// Stored for the configured facility, as MQTT readings are
+			if len(stored) != 1 || stored[0].MeterID != tt.wantMeter || stored[0].Source != domain.SourceKinesis {
+				t.Errorf("stored %+v", stored)
+			}
+		})
+	}
+}
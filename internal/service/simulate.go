@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// ErrInvalidSimulation is returned for a load shift that can't be simulated
+var ErrInvalidSimulation = errors.New("invalid cost simulation")
+
+// LoadShift moves Percent of every peak hour's kWh into ToHours, split
+// evenly. An empty ToHours spreads it over all off-peak hours.
+type LoadShift struct {
+	Percent float64 `json:"percent"`
+	ToHours []int   `json:"to_hours"`
+}
+
+func (ls LoadShift) targets(rs costmodel.Schedule) ([]int, error) {
+	if ls.Percent < 0 || ls.Percent > 100 {
+		return nil, fmt.Errorf("%w: percent must be between 0 and 100", ErrInvalidSimulation)
+	}
+
+	if len(ls.ToHours) == 0 {
+		var hours []int
+		for h := 0; h < 24; h++ {
+			if !rs.IsPeak(h) {
+				hours = append(hours, h)
+			}
+		}
+		if len(hours) == 0 {
+			return nil, fmt.Errorf("%w: schedule has no off-peak hours", ErrInvalidSimulation)
+		}
+		return hours, nil
+	}
+
+	seen := make(map[int]bool, len(ls.ToHours))
+	for _, h := range ls.ToHours {
+		switch {
+		case h < 0 || h > 23:
+			return nil, fmt.Errorf("%w: to_hours entry %d is not an hour of the day", ErrInvalidSimulation, h)
+		case rs.IsPeak(h):
+			return nil, fmt.Errorf("%w: to_hours entry %d is a peak hour", ErrInvalidSimulation, h)
+		case seen[h]:
+			return nil, fmt.Errorf("%w: to_hours entry %d is repeated", ErrInvalidSimulation, h)
+		}
+		seen[h] = true
+	}
+	return ls.ToHours, nil
+}
+
+// CostSimulation compares a day's cost as metered with the cost after a load shift
+type CostSimulation struct {
+	FacilityID     string              `json:"facility_id"`
+	Date           string              `json:"date"`
+	Timezone       string              `json:"timezone"`
+	Schedule       costmodel.Schedule  `json:"schedule"`
+	Shift          LoadShift           `json:"shift"`
+	ShiftedKWh     float64             `json:"shifted_kwh"`
+	Before         costmodel.Breakdown `json:"before"`
+	After          costmodel.Breakdown `json:"after"`
+	Savings        float64             `json:"savings"`
+	SavingsPercent float64             `json:"savings_percent"`
+}
+
+// SimulateCost recomputes a day's cost from stored readings as if shift had
+// been applied, without changing any data. A non-nil schedule replaces the
+// configured cost model's; its demand, fixed charges and taxes still apply.
+func (s *AnalyticsService) SimulateCost(facilityID string, date time.Time, schedule *costmodel.Schedule, shift LoadShift) (*CostSimulation, error) {
+	model := s.costs
+	if schedule != nil {
+		model.Schedule = *schedule
+	}
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	targets, err := shift.targets(model.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	readings, err := s.getReadingsForDate(facilityID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings: %w", err)
+	}
+	loc := s.zones.Location(facilityID)
+	return simulateCost(facilityID, date, readings, loc, model, shift, targets)
+}
+
+func simulateCost(facilityID string, date time.Time, readings []domain.Reading, loc *time.Location, model costmodel.Model, shift LoadShift, targets []int) (*CostSimulation, error) {
+	before := model.HourlyKWh(costSamples(readings), loc)
+
+	// Shifted energy leaves peak hours and lands in the targets, so the total is conserved
+	after := append([]float64(nil), before...)
+	var shifted float64
+	for h := range after {
+		if model.Schedule.IsPeak(h) {
+			moved := after[h] * shift.Percent / 100
+			after[h] -= moved
+			shifted += moved
+		}
+	}
+	for _, h := range targets {
+		after[h] += shifted / float64(len(targets))
+	}
+
+	beforeCost, err := model.CostHourly(before)
+	if err != nil {
+		return nil, err
+	}
+	afterCost, err := model.CostHourly(after)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &CostSimulation{
+		FacilityID: facilityID,
+		Date:       date.Format("2006-01-02"),
+		Timezone:   loc.String(),
+		Schedule:   model.Schedule,
+		Shift:      LoadShift{Percent: shift.Percent, ToHours: targets},
+		ShiftedKWh: numfmt.Round(shifted, 2),
+		Before:     beforeCost,
+		After:      afterCost,
+	}
+	sim.Savings = (costmodel.ToCents(sim.Before.TotalCost) - costmodel.ToCents(sim.After.TotalCost)).Float64()
+	if sim.Before.TotalCost > 0 {
+		sim.SavingsPercent = numfmt.Round(sim.Savings/sim.Before.TotalCost*100, 2)
+	}
+	return sim, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestSimulateCost(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// A flat 10 kW day in hourly samples: 240 kWh, 40 of them in the 17:00-20:59 peak
+	var readings []domain.Reading
+	for h := 0; h < 24; h++ {
+		readings = append(readings, domain.Reading{MeterID: 1, Timestamp: timeutil.FromTime(day.Add(time.Duration(h) * time.Hour)), PowerKW: 10})
+	}
+	model := costmodel.Default(0.12, 3600)
+	model.Schedule = costmodel.Schedule{RatePerKWh: 0.12, PeakStartHour: 17, PeakEndHour: 20}
+
+	tests := []struct {
+		name        string
+		shift       LoadShift
+		wantShifted float64
+		wantHours   map[int]float64 // expected after-shift kWh of some hours
+		wantErr     bool
+	}{
+		{
+			name:        "half the peak into two night hours",
+			shift:       LoadShift{Percent: 50, ToHours: []int{2, 3}},
+			wantShifted: 20,
+			wantHours:   map[int]float64{2: 20, 3: 20, 4: 10, 17: 5, 20: 5},
+		},
+		{
+			name:        "all of it spread over every off-peak hour",
+			shift:       LoadShift{Percent: 100},
+			wantShifted: 40,
+			wantHours:   map[int]float64{0: 12, 16: 12, 18: 0, 21: 12},
+		},
+		{name: "no shift", shift: LoadShift{}, wantHours: map[int]float64{2: 10, 18: 10}},
+		{name: "into a peak hour", shift: LoadShift{Percent: 50, ToHours: []int{18}}, wantErr: true},
+		{name: "not an hour", shift: LoadShift{Percent: 50, ToHours: []int{24}}, wantErr: true},
+		{name: "repeated hour", shift: LoadShift{Percent: 50, ToHours: []int{2, 2}}, wantErr: true},
+		{name: "over 100 percent", shift: LoadShift{Percent: 120}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := tt.shift.targets(model.Schedule)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSimulation) {
+					t.Fatalf("err = %v, want ErrInvalidSimulation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sim, err := simulateCost("facility-001", day, readings, time.UTC, model, tt.shift, targets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sim.ShiftedKWh != tt.wantShifted {
+				t.Errorf("shifted %v kWh, want %v", sim.ShiftedKWh, tt.wantShifted)
+			}
+			// Shifting moves energy, it doesn't remove it
+			if math.Abs(sim.After.TotalKWh-sim.Before.TotalKWh) > 1e-9 || math.Abs(sim.Before.TotalKWh-240) > 1e-9 {
+				t.Errorf("total kWh before %v, after %v; want 240 both", sim.Before.TotalKWh, sim.After.TotalKWh)
+			}
+			for h, want := range tt.wantHours {
+				if math.Abs(sim.After.HourlyKWh[h]-want) > 1e-9 {
+					t.Errorf("hour %d after the shift = %v kWh, want %v", h, sim.After.HourlyKWh[h], want)
+				}
+			}
+
+			if tt.wantShifted == 0 {
+				if sim.Savings != 0 || sim.After.TotalCost != sim.Before.TotalCost {
+					t.Errorf("savings %v without a shift", sim.Savings)
+				}
+				return
+			}
+			if sim.After.PeakCost >= sim.Before.PeakCost || sim.After.PeakKWh != sim.Before.PeakKWh-tt.wantShifted {
+				t.Errorf("peak %v kWh / %v before, %v kWh / %v after", sim.Before.PeakKWh, sim.Before.PeakCost, sim.After.PeakKWh, sim.After.PeakCost)
+			}
+			if sim.Savings <= 0 || math.Abs(sim.Savings-(sim.Before.TotalCost-sim.After.TotalCost)) > 0.005 || sim.SavingsPercent <= 0 {
+				t.Errorf("savings %v (%v%%), before %v, after %v", sim.Savings, sim.SavingsPercent, sim.Before.TotalCost, sim.After.TotalCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// sourceUnknown groups readings stored without a source
+const sourceUnknown = "unknown"
+
+// sourceCounter counts stored readings per ingestion source for the process lifetime
+type sourceCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *sourceCounter) add(source string, n int64) {
+	if source == "" {
+		source = sourceUnknown
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[source] += n
+}
+
+func (c *sourceCounter) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int64, len(c.counts))
+	for k, v := range c.counts {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *ReadingService) countSources(readings []domain.Reading) {
+	for i := range readings {
+		s.sources.add(readings[i].Source, 1)
+	}
+}
+
+// SourceCounts returns the number of readings stored per source (mqtt, http,
+// batch, import, kinesis) since the process started
+func (s *ReadingService) SourceCounts() map[string]int64 {
+	return s.sources.snapshot()
+}
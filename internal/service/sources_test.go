@@ -0,0 +1,96 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+)
+
+func TestReadingSourceTagged(t *testing.T) {
+	payload := `{"meter_id": "1", "timestamp": "2024-03-01T00:00:00Z", "voltage": 230, "current": 10, "power_kw": 2.3}`
+	tests := []struct {
+		name   string
+		ingest func(s *ReadingService) error
+		want   string
+		count  int
+	}{
+		{
+			name: "mqtt",
+			ingest: func(s *ReadingService) error {
+				_, err := s.FromMQTT("energy/facility-001/meter/1", []byte(payload))
+				return err
+			},
+			want: domain.SourceMQTT, count: 1,
+		},
+		{
+			name:   "http",
+			ingest: func(s *ReadingService) error { return s.FromHTTP("facility-001", []byte(payload)) },
+			want:   domain.SourceHTTP, count: 1,
+		},
+		{
+			name: "batch",
+			ingest: func(s *ReadingService) error {
+				_, err := s.FromHTTPBatch("facility-001", []byte("["+payload+","+payload+"]"))
+				return err
+			},
+			want: domain.SourceBatch, count: 2,
+		},
+		{
+			name: "import",
+			ingest: func(s *ReadingService) error {
+				_, err := s.ImportCSV("facility-001", strings.NewReader("timestamp,meter_id,voltage,current,power_kw\n"+
+					"2024-03-01T00:00:00Z,1,230,10,2.3\n2024-03-01T00:01:00Z,1,230,10,2.4\n2024-03-01T00:02:00Z,2,230,10,2.5\n"))
+				return err
+			},
+			want: domain.SourceImport, count: 3,
+		},
+		{
+			name: "kinesis",
+			ingest: func(s *ReadingService) error {
+				_, err := s.FromKinesis("facility-001", []byte(payload))
+				return err
+			},
+			want: domain.SourceKinesis, count: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memstore.New()
+			s := newImportService(store)
+			if err := tt.ingest(s); err != nil {
+				t.Fatal(err)
+			}
+
+			readings, err := store.GetRecentReadings("facility-001", 100*365*24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(readings) != tt.count {
+				t.Fatalf("%d readings stored, want %d", len(readings), tt.count)
+			}
+			for _, r := range readings {
+				if r.Source != tt.want {
+					t.Errorf("reading source = %q, want %q", r.Source, tt.want)
+				}
+			}
+			if got := s.SourceCounts(); !reflect.DeepEqual(got, map[string]int64{tt.want: int64(tt.count)}) {
+				t.Errorf("SourceCounts = %v, want %s: %d", got, tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestSourceCounterUnknown(t *testing.T) {
+	var c sourceCounter
+	c.add("", 2)
+	c.add(domain.SourceHTTP, 1)
+	c.add(domain.SourceHTTP, 3)
+	want := map[string]int64{sourceUnknown: 2, domain.SourceHTTP: 4}
+	if got := c.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot = %v, want %v", got, want)
+	}
+}
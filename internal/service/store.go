@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+)
+
+// ReadingStore persists and queries energy readings
+type ReadingStore interface {
+	PutReading(reading *domain.Reading, facilityID string) error
+	BatchPutReadings(readings []domain.Reading, facilityID string) error
+	GetRecentReadings(facilityID string, duration time.Duration) ([]domain.Reading, error)
+	// GetRecentReadingsPage returns up to limit readings (0 means the backend's
+	// default page size) after cursor, an opaque value from a previous page.
+	// Backends may read only the attributes in fields.
+	GetRecentReadingsPage(facilityID string, duration time.Duration, cursor string, limit int, fields cloud.Projection) (*domain.ReadingPage, error)
+	// GetReading returns the meter's reading at timestamp, or nil when there is none
+	GetReading(facilityID string, meterID int64, timestamp time.Time) (*domain.Reading, error)
+}
+
+// AlertStore persists and queries alerts
+type AlertStore interface {
+	CreateAlert(facilityID, equipmentID, severity, alertType, message string) error
+	GetAlerts(facilityID string, filter cloud.AlertFilter) ([]cloud.Alert, error)
+	// GetAlert returns one alert by ID, or cloud.ErrAlertNotFound
+	GetAlert(alertID string) (*cloud.Alert, error)
+	AcknowledgeAlert(alertID string) error
+	DismissAlert(alertID, dismissedBy, reason string) error
+	DeleteAlert(alertID string) error
+	// ExpireAlert sets a closed alert's TTL; open alerts are refused with cloud.ErrAlertOpen
+	ExpireAlert(alertID string, expiresAt int64) error
+}
+
+// EquipmentStore queries and updates equipment records
+type EquipmentStore interface {
+	GetEquipment(facilityID string) ([]cloud.Equipment, error)
+	UpdateEquipmentHealth(equipmentID string, healthScore float64) error
+}
+
+// PredictionStore keeps maintenance prediction history
+type PredictionStore interface {
+	PutMaintenancePredictions(predictions []domain.MaintenancePrediction) error
+}
+
+// Store groups the stores backing the services. It is implemented by
+// cloud.DynamoDBClient and memstore.Store.
+type Store interface {
+	ReadingStore
+	AlertStore
+	EquipmentStore
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// ErrInvalidSummaryRange is returned for a from/to range that is reversed
+var ErrInvalidSummaryRange = errors.New("invalid summary range")
+
+// AnalyticsSummaryStore reads the daily summaries the analytics Lambda stores
+type AnalyticsSummaryStore interface {
+	GetAnalyticsSummaries(facilityID, from, to string) ([]cloud.AnalyticsSummary, error)
+}
+
+// SummarySeries is the stored daily summaries of a date range, oldest first,
+// with a Chart.js trend series of the headline figures. Days without a stored
+// summary are absent rather than zero.
+type SummarySeries struct {
+	FacilityID string                   `json:"facility_id"`
+	From       string                   `json:"from"`
+	To         string                   `json:"to"`
+	Summaries  []cloud.AnalyticsSummary `json:"summaries"`
+	Trend      ChartSeries              `json:"trend"`
+}
+
+// GetStoredSummaries returns the summaries stored for dates from..to (inclusive)
+func (s *AnalyticsService) GetStoredSummaries(facilityID string, from, to time.Time) (*SummarySeries, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidSummaryRange)
+	}
+	if err := s.maxRange.check(dayRange(from, to)); err != nil {
+		return nil, err
+	}
+	if s.summaries == nil {
+		return nil, fmt.Errorf("cloud services not enabled")
+	}
+
+	series := &SummarySeries{
+		FacilityID: facilityID,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+	}
+	summaries, err := s.summaries.GetAnalyticsSummaries(facilityID, series.From, series.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics summaries: %w", err)
+	}
+	series.Summaries = summaries
+	if series.Summaries == nil {
+		series.Summaries = []cloud.AnalyticsSummary{}
+	}
+	series.Trend = summaryTrend(series.Summaries)
+	return series, nil
+}
+
+func summaryTrend(summaries []cloud.AnalyticsSummary) ChartSeries {
+	labels := make([]string, len(summaries))
+	consumption := make([]float64, len(summaries))
+	peak := make([]float64, len(summaries))
+	cost := make([]float64, len(summaries))
+	for i, sm := range summaries {
+		labels[i] = sm.Date
+		consumption[i] = sm.TotalConsumption
+		peak[i] = sm.PeakPower
+		cost[i] = sm.EstimatedCost
+	}
+	return ChartSeries{
+		Labels: labels,
+		Datasets: []Dataset{
+			{Label: "Consumption (kWh)", Data: consumption},
+			{Label: "Peak power (kW)", Data: peak},
+			{Label: "Estimated cost", Data: cost},
+		},
+	}
+}
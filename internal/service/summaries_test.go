@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// summaryStore answers with the stored summaries dated within the range
+type summaryStore struct {
+	stored []cloud.AnalyticsSummary
+	asked  [3]string
+}
+
+func (s *summaryStore) GetAnalyticsSummaries(facilityID, from, to string) ([]cloud.AnalyticsSummary, error) {
+	s.asked = [3]string{facilityID, from, to}
+	var out []cloud.AnalyticsSummary
+	for _, sm := range s.stored {
+		if sm.FacilityID == facilityID && sm.Date >= from && sm.Date <= to {
+			out = append(out, sm)
+		}
+	}
+	return out, nil
+}
+
+func TestGetStoredSummaries(t *testing.T) {
+	store := &summaryStore{}
+	for _, d := range []int{1, 2, 3, 5, 6} {
+		store.stored = append(store.stored, cloud.AnalyticsSummary{
+			FacilityID: "facility-001", Date: fmt.Sprintf("2024-03-%02d", d),
+			TotalConsumption: 400 + float64(d), PeakPower: 50 + float64(d), EstimatedCost: 48 + float64(d),
+		})
+	}
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		maxRange  time.Duration
+		wantDates []string
+		wantErr   error
+	}{
+		{name: "several days with a gap", from: day(2), to: day(6), wantDates: []string{"2024-03-02", "2024-03-03", "2024-03-05", "2024-03-06"}},
+		{name: "one day", from: day(3), to: day(3), wantDates: []string{"2024-03-03"}},
+		{name: "nothing stored", from: day(20), to: day(25), wantDates: []string{}},
+		{name: "reversed", from: day(6), to: day(2), wantErr: ErrInvalidSummaryRange},
+		{name: "as long as allowed", from: day(2), to: day(6), maxRange: 5 * 24 * time.Hour, wantDates: []string{"2024-03-02", "2024-03-03", "2024-03-05", "2024-03-06"}},
+		{name: "longer than allowed", from: day(1), to: day(6), maxRange: 5 * 24 * time.Hour, wantErr: ErrQueryRangeTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AnalyticsService{summaries: store, maxRange: queryRange(tt.maxRange)}
+			series, err := s.GetStoredSummaries("facility-001", tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if want := [3]string{"facility-001", tt.from.Format("2006-01-02"), tt.to.Format("2006-01-02")}; store.asked != want || series.From != want[1] || series.To != want[2] {
+				t.Errorf("asked for %v, series %s..%s; want %v", store.asked, series.From, series.To, want)
+			}
+			dates := make([]string, len(series.Summaries))
+			for i, sm := range series.Summaries {
+				dates[i] = sm.Date
+			}
+			if fmt.Sprint(dates) != fmt.Sprint(tt.wantDates) || series.Summaries == nil {
+				t.Errorf("summaries for %v, want %v", dates, tt.wantDates)
+			}
+
+			// The trend is the same days, in order, one dataset per headline figure
+			if fmt.Sprint(series.Trend.Labels) != fmt.Sprint(tt.wantDates) || len(series.Trend.Datasets) != 3 {
+				t.Fatalf("trend labels %v with %d datasets", series.Trend.Labels, len(series.Trend.Datasets))
+			}
+			for i, sm := range series.Summaries {
+				if got := series.Trend.Datasets; got[0].Data[i] != sm.TotalConsumption || got[1].Data[i] != sm.PeakPower || got[2].Data[i] != sm.EstimatedCost {
+					t.Errorf("trend point %d = %v, %v, %v; want %+v", i, got[0].Data[i], got[1].Data[i], got[2].Data[i], sm)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+// Statuses of a day in a SummaryRange
+const (
+	rangeDayStored    = "stored"    // summary already stored
+	rangeDayGenerated = "generated" // the Lambda was run for the day by this request
+	rangeDayMissing   = "missing"   // no summary; not generated (today or later, or over the cap)
+	rangeDayNoData    = "no_data"   // the Lambda ran but stored no summary
+)
+
+// summaryGenerator runs the analytics Lambda for a day; it is implemented by
+// cloud.LambdaClient
+type summaryGenerator interface {
+	InvokeAnalyticsProcessing(date, facilityID, format string, granularityMinutes int) (map[string]interface{}, error)
+}
+
+// RangeDay is one day of a SummaryRange; Summary is nil unless the day is
+// stored or generated
+type RangeDay struct {
+	Date    string                  `json:"date"`
+	Status  string                  `json:"status"`
+	Summary *cloud.AnalyticsSummary `json:"summary,omitempty"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// SummaryRange is every day from..to with its stored summary, generating
+// missing past days on demand, plus a trend series of the days that have one.
+// Generated counts the summaries generated, Missing the days without one.
+type SummaryRange struct {
+	FacilityID string      `json:"facility_id"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Days       []RangeDay  `json:"days"`
+	Generated  int         `json:"generated"`
+	Missing    int         `json:"missing"`
+	Trend      ChartSeries `json:"trend"`
+}
+
+// GetSummaryRange returns a day-by-day series of summaries for from..to
+// (inclusive). The range may cover at most ANALYTICS_RANGE_MAX_DAYS days.
+// Past days without a stored summary are generated through the analytics
+// Lambda, oldest first, up to ANALYTICS_RANGE_GENERATE_MAX per request;
+// the rest are reported missing so the caller can ask again.
+func (s *AnalyticsService) GetSummaryRange(facilityID string, from, to time.Time) (*SummaryRange, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidSummaryRange)
+	}
+	days := int(dayRange(from, to).Hours() / 24)
+	if s.rangeMaxDays > 0 && days > s.rangeMaxDays {
+		return nil, fmt.Errorf("%w: %d days requested, at most %d allowed (ANALYTICS_RANGE_MAX_DAYS)",
+			ErrQueryRangeTooLarge, days, s.rangeMaxDays)
+	}
+	if s.summaries == nil {
+		return nil, fmt.Errorf("cloud services not enabled")
+	}
+
+	out := &SummaryRange{
+		FacilityID: facilityID,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Days:       make([]RangeDay, 0, days),
+	}
+	stored, err := s.summaries.GetAnalyticsSummaries(facilityID, out.From, out.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics summaries: %w", err)
+	}
+	byDate := make(map[string]cloud.AnalyticsSummary, len(stored))
+	for _, sm := range stored {
+		byDate[sm.Date] = sm
+	}
+
+	// Only complete days are generated; today's readings are still arriving
+	today := time.Now().In(s.zones.Location(facilityID)).Format("2006-01-02")
+	var present []cloud.AnalyticsSummary
+	// The cap counts Lambda runs, including those that stored nothing
+	attempts := 0
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := RangeDay{Date: d.Format("2006-01-02")}
+		if sm, ok := byDate[day.Date]; ok {
+			day.Status, day.Summary = rangeDayStored, &sm
+		} else if day.Date < today && s.generator != nil && attempts < s.rangeGenerateMax {
+			attempts++
+			day.Summary, err = s.generateSummary(facilityID, day.Date)
+			switch {
+			case err != nil:
+				day.Status, day.Error = rangeDayMissing, err.Error()
+			case day.Summary == nil:
+				day.Status = rangeDayNoData
+			default:
+				day.Status = rangeDayGenerated
+				out.Generated++
+			}
+		} else {
+			day.Status = rangeDayMissing
+		}
+
+		if day.Summary != nil {
+			present = append(present, *day.Summary)
+		} else {
+			out.Missing++
+		}
+		out.Days = append(out.Days, day)
+	}
+	out.Trend = summaryTrend(present)
+	return out, nil
+}
+
+// generateSummary runs the analytics Lambda for date and reads back the
+// summary it stored; nil means the Lambda had no readings for the day
+func (s *AnalyticsService) generateSummary(facilityID, date string) (*cloud.AnalyticsSummary, error) {
+	if _, err := s.generator.InvokeAnalyticsProcessing(date, facilityID, "", 0); err != nil {
+		return nil, fmt.Errorf("failed to invoke analytics Lambda: %w", err)
+	}
+	summaries, err := s.summaries.GetAnalyticsSummaries(facilityID, date, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics summaries: %w", err)
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	return &summaries[0], nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// fakeGenerator stands in for the analytics Lambda, storing a summary for
+// each day it runs unless the day has no readings or fails
+type fakeGenerator struct {
+	store  *summaryStore
+	noData map[string]bool
+	fail   map[string]bool
+	ran    []string
+}
+
+func (g *fakeGenerator) InvokeAnalyticsProcessing(date, facilityID, format string, granularityMinutes int) (map[string]interface{}, error) {
+	g.ran = append(g.ran, date)
+	if g.fail[date] {
+		return nil, errors.New("function timed out")
+	}
+	if !g.noData[date] {
+		g.store.stored = append(g.store.stored, cloud.AnalyticsSummary{FacilityID: facilityID, Date: date, TotalConsumption: 1000})
+	}
+	return map[string]interface{}{"statusCode": 200}, nil
+}
+
+func TestGetSummaryRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	date := func(d int) string { return day(d).Format("2006-01-02") }
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Truncate(24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		from, to      time.Time
+		generateMax   int
+		noGenerator   bool
+		noData, fail  []string
+		wantStatuses  string
+		wantRan       []string
+		wantGenerated int
+		wantMissing   int
+		wantErr       error
+	}{
+		{
+			name: "stored and generated", from: day(1), to: day(7), generateMax: 7,
+			wantStatuses: "stored stored stored generated stored stored generated", wantRan: []string{date(4), date(7)}, wantGenerated: 2,
+		},
+		{
+			name: "over the cap", from: day(1), to: day(7), generateMax: 1,
+			wantStatuses: "stored stored stored generated stored stored missing", wantRan: []string{date(4)}, wantGenerated: 1, wantMissing: 1,
+		},
+		{
+			name: "without Lambda", from: day(3), to: day(7), generateMax: 7, noGenerator: true,
+			wantStatuses: "stored missing stored stored missing", wantMissing: 2,
+		},
+		{
+			name: "no readings and a failure", from: day(3), to: day(7), generateMax: 7, noData: []string{date(4)}, fail: []string{date(7)},
+			wantStatuses: "stored no_data stored stored missing", wantRan: []string{date(4), date(7)}, wantMissing: 2,
+		},
+		{
+			name: "failures count toward the cap", from: day(4), to: day(8), generateMax: 2, fail: []string{date(4)},
+			wantStatuses: "missing stored stored generated missing", wantRan: []string{date(4), date(7)}, wantGenerated: 1, wantMissing: 2,
+		},
+		{
+			name: "today isn't generated", from: yesterday, to: yesterday.AddDate(0, 0, 2), generateMax: 7,
+			wantStatuses: "generated missing missing", wantRan: []string{yesterday.Format("2006-01-02")}, wantGenerated: 1, wantMissing: 2,
+		},
+		{name: "reversed", from: day(7), to: day(1), generateMax: 7, wantErr: ErrInvalidSummaryRange},
+		{name: "longer than allowed", from: day(1), to: day(31), generateMax: 7, wantErr: ErrQueryRangeTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &summaryStore{}
+			for _, d := range []int{1, 2, 3, 5, 6} {
+				store.stored = append(store.stored, cloud.AnalyticsSummary{FacilityID: "facility-001", Date: date(d), TotalConsumption: 400 + float64(d)})
+			}
+			gen := &fakeGenerator{store: store, noData: make(map[string]bool), fail: make(map[string]bool)}
+			for _, d := range tt.noData {
+				gen.noData[d] = true
+			}
+			for _, d := range tt.fail {
+				gen.fail[d] = true
+			}
+			s := &AnalyticsService{
+				summaries: store, zones: newFacilityTimezones(repository.New(nil), nil),
+				rangeMaxDays: 30, rangeGenerateMax: tt.generateMax, generator: gen,
+			}
+			if tt.noGenerator {
+				s.generator = nil
+			}
+
+			got, err := s.GetSummaryRange("facility-001", tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var statuses, labels []string
+			for _, d := range got.Days {
+				statuses = append(statuses, d.Status)
+				if (d.Summary != nil) != (d.Status == rangeDayStored || d.Status == rangeDayGenerated) || (d.Error != "") != gen.fail[d.Date] {
+					t.Errorf("%s: %s with summary %v, error %q", d.Date, d.Status, d.Summary, d.Error)
+				}
+				if d.Summary != nil {
+					labels = append(labels, d.Date)
+				}
+			}
+			if fmt.Sprint(statuses) != "["+tt.wantStatuses+"]" {
+				t.Errorf("statuses %v, want [%s]", statuses, tt.wantStatuses)
+			}
+			if fmt.Sprint(gen.ran) != fmt.Sprint(tt.wantRan) {
+				t.Errorf("Lambda ran for %v, want %v", gen.ran, tt.wantRan)
+			}
+			if got.Generated != tt.wantGenerated || got.Missing != tt.wantMissing {
+				t.Errorf("%d generated, %d missing; want %d, %d", got.Generated, got.Missing, tt.wantGenerated, tt.wantMissing)
+			}
+			// The trend charts the days that have a summary
+			if fmt.Sprint(got.Trend.Labels) != fmt.Sprint(labels) {
+				t.Errorf("trend labels %v, want %v", got.Trend.Labels, labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// facilityZoneCacheTTL bounds how long a facility's time zone is reused
+// before it is re-read
+const facilityZoneCacheTTL = 10 * time.Minute
+
+// FacilityTimezoneStore looks up a facility's IANA zone name. It is
+// implemented by cloud.DynamoDBClient.
+type FacilityTimezoneStore interface {
+	GetFacilityTimezone(facilityID string) (string, error)
+}
+
+type cachedZone struct {
+	loc     *time.Location
+	expires time.Time
+}
+
+// FacilityTimezones resolves the time zone that local days and hours are
+// computed in for a facility
+type FacilityTimezones struct {
+	repos  *repository.Repos
+	dynamo FacilityTimezoneStore
+
+	mu    sync.Mutex
+	cache map[string]cachedZone
+}
+
+func newFacilityTimezones(repos *repository.Repos, dynamo FacilityTimezoneStore) *FacilityTimezones {
+	return &FacilityTimezones{repos: repos, dynamo: dynamo, cache: make(map[string]cachedZone)}
+}
+
+// Location returns the facility's zone from the facilities table, or from the
+// DynamoDB Facilities table when Postgres has none. Facilities without a
+// (valid) zone, and lookups that fail, use UTC. Failed lookups aren't cached,
+// so the next call retries them.
+func (z *FacilityTimezones) Location(facilityID string) *time.Location {
+	if z == nil {
+		return time.UTC
+	}
+
+	z.mu.Lock()
+	if e, ok := z.cache[facilityID]; ok && time.Now().Before(e.expires) {
+		z.mu.Unlock()
+		return e.loc
+	}
+	z.mu.Unlock()
+
+	var name string
+	failed := false
+	if id, ok := parseNumericID(facilityID, "facility"); ok {
+		f, err := z.repos.GetFacility(id)
+		switch {
+		case err == nil:
+			name = f.Timezone
+		case !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, repository.ErrNoDatabase):
+			fmt.Printf("Failed to look up timezone of facility %s: %v\n", facilityID, err)
+			failed = true
+		}
+	}
+	if name == "" && z.dynamo != nil {
+		tz, err := z.dynamo.GetFacilityTimezone(facilityID)
+		if err != nil {
+			fmt.Printf("Failed to look up timezone of facility %s; using UTC: %v\n", facilityID, err)
+			failed = true
+		}
+		name = tz
+	}
+	loc := domain.Facility{Timezone: name}.Location()
+	if failed && name == "" {
+		return loc
+	}
+
+	z.mu.Lock()
+	z.cache[facilityID] = cachedZone{loc: loc, expires: time.Now().Add(facilityZoneCacheTTL)}
+	z.mu.Unlock()
+	return loc
+}
+
+// DayWindow returns the start of the facility-local calendar day of date
+// (its year, month and day, whatever its zone) and the start of the next day.
+// Across DST changes the day is 23 or 25 hours long.
+func (z *FacilityTimezones) DayWindow(facilityID string, date time.Time) (time.Time, time.Time) {
+	loc := z.Location(facilityID)
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
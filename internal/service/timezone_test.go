@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+)
+
+// scriptedZones answers GetFacilityTimezone from a script of replies, one per call
+type scriptedZones struct {
+	replies []zoneReply
+	calls   int
+}
+
+type zoneReply struct {
+	name string
+	err  error
+}
+
+func (s *scriptedZones) GetFacilityTimezone(facilityID string) (string, error) {
+	r := s.replies[s.calls]
+	s.calls++
+	return r.name, r.err
+}
+
+func TestFacilityTimezonesLocation(t *testing.T) {
+	down := errors.New("dynamodb unavailable")
+	tests := []struct {
+		name      string
+		replies   []zoneReply
+		want      []string
+		wantCalls int
+	}{
+		{
+			name:      "zone is cached",
+			replies:   []zoneReply{{name: "America/Chicago"}},
+			want:      []string{"America/Chicago", "America/Chicago", "America/Chicago"},
+			wantCalls: 1,
+		},
+		{
+			name:      "facility without a zone is cached as UTC",
+			replies:   []zoneReply{{}},
+			want:      []string{"UTC", "UTC"},
+			wantCalls: 1,
+		},
+		{
+			name:      "failed lookup is retried",
+			replies:   []zoneReply{{err: down}, {err: down}, {name: "Europe/Berlin"}},
+			want:      []string{"UTC", "UTC", "Europe/Berlin", "Europe/Berlin"},
+			wantCalls: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &scriptedZones{replies: tt.replies}
+			z := newFacilityTimezones(repository.New(nil), store)
+			for i, want := range tt.want {
+				if got := z.Location("facility-001").String(); got != want {
+					t.Errorf("call %d: Location = %s, want %s", i+1, got, want)
+				}
+			}
+			if store.calls != tt.wantCalls {
+				t.Errorf("store called %d times, want %d", store.calls, tt.wantCalls)
+			}
+		})
+	}
+}
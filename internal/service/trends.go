@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/anomaly"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// Trend detection defaults: a week of trailing days and 3 standard deviations
+const (
+	DefaultTrendWindow = 7
+	DefaultTrendSigma  = 3.0
+
+	maxTrendWindow = 90
+)
+
+// ErrInvalidTrendQuery is returned for a trend window or sigma that can't be used
+var ErrInvalidTrendQuery = errors.New("invalid trend query")
+
+// trendMetrics are the summary figures checked for day-level anomalies
+var trendMetrics = []struct {
+	name  string
+	value func(cloud.AnalyticsSummary) float64
+}{
+	{"total_consumption", func(s cloud.AnalyticsSummary) float64 { return s.TotalConsumption }},
+	{"estimated_cost", func(s cloud.AnalyticsSummary) float64 { return s.EstimatedCost }},
+}
+
+// TrendAnomaly is a day whose metric deviates from its trailing window
+type TrendAnomaly struct {
+	Date             string  `json:"date"`
+	Metric           string  `json:"metric"`
+	Value            float64 `json:"value"`
+	TrailingMean     float64 `json:"trailing_mean"`
+	TrailingStdDev   float64 `json:"trailing_stddev"`
+	DeviationSigma   float64 `json:"deviation_sigma"`
+	DeviationPercent float64 `json:"deviation_percent"`
+}
+
+// TrendReport lists the days from..to flagged against the Window stored
+// summaries before each, oldest first
+type TrendReport struct {
+	FacilityID string         `json:"facility_id"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Window     int            `json:"window"`
+	Sigma      float64        `json:"sigma"`
+	Flagged    []TrendAnomaly `json:"flagged"`
+}
+
+// GetTrendAnomalies flags days from..to whose consumption or cost is more than
+// sigma standard deviations from the mean of the window stored days before
+// them. Summaries before from are read as the first days' baseline.
+func (s *AnalyticsService) GetTrendAnomalies(facilityID string, from, to time.Time, window int, sigma float64) (*TrendReport, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("%w: window must be at least 2 days", ErrInvalidTrendQuery)
+	}
+	if window > maxTrendWindow {
+		return nil, fmt.Errorf("%w: window must be at most %d days", ErrInvalidTrendQuery, maxTrendWindow)
+	}
+	if sigma <= 0 {
+		return nil, fmt.Errorf("%w: sigma must be positive", ErrInvalidTrendQuery)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidSummaryRange)
+	}
+	if err := s.maxRange.check(dayRange(from, to)); err != nil {
+		return nil, err
+	}
+	if s.summaries == nil {
+		return nil, fmt.Errorf("cloud services not enabled")
+	}
+
+	// Days without a summary are absent, so reach back twice the window to
+	// give the first days in range a full baseline
+	summaries, err := s.summaries.GetAnalyticsSummaries(facilityID,
+		from.AddDate(0, 0, -2*window).Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics summaries: %w", err)
+	}
+
+	report := &TrendReport{
+		FacilityID: facilityID,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Window:     window,
+		Sigma:      sigma,
+	}
+	report.Flagged = detectTrendAnomalies(summaries, report.From, window, sigma)
+	return report, nil
+}
+
+// detectTrendAnomalies runs the analytics library's rolling spike detector over
+// each metric and reports the flagged days on or after from
+func detectTrendAnomalies(summaries []cloud.AnalyticsSummary, from string, window int, sigma float64) []TrendAnomaly {
+	flagged := []TrendAnomaly{}
+	detector := &anomaly.AnomalyDetector{Threshold: sigma, WindowSize: window}
+	for _, m := range trendMetrics {
+		// Timestamp carries the summary's index so spikes map back to their day
+		points := make([]anomaly.Reading, len(summaries))
+		for i, sm := range summaries {
+			points[i] = anomaly.Reading{Consumption: m.value(sm), Timestamp: int64(i)}
+		}
+
+		for _, spike := range detector.DetectSpikes(points) {
+			i := int(spike.Timestamp)
+			if summaries[i].Date < from {
+				continue
+			}
+			mean, std := trailingStats(points[i-window : i])
+			ta := TrendAnomaly{
+				Date:           summaries[i].Date,
+				Metric:         m.name,
+				Value:          spike.Consumption,
+				TrailingMean:   numfmt.Round(mean, 2),
+				TrailingStdDev: numfmt.Round(std, 2),
+				DeviationSigma: numfmt.Round((spike.Consumption-mean)/std, 2),
+			}
+			if mean != 0 {
+				ta.DeviationPercent = numfmt.Round((spike.Consumption-mean)/mean*100, 1)
+			}
+			flagged = append(flagged, ta)
+		}
+	}
+
+	// Metrics were scanned one after the other; interleave them by day
+	sortTrendAnomalies(flagged)
+	return flagged
+}
+
+// trailingStats is the population mean and standard deviation the spike
+// detector compares against
+func trailingStats(points []anomaly.Reading) (float64, float64) {
+	var sum float64
+	for _, p := range points {
+		sum += p.Consumption
+	}
+	mean := sum / float64(len(points))
+	var variance float64
+	for _, p := range points {
+		variance += (p.Consumption - mean) * (p.Consumption - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(points)))
+}
+
+func sortTrendAnomalies(a []TrendAnomaly) {
+	// Insertion sort: stable and the list is short
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j].Date < a[j-1].Date; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+}
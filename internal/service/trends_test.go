@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+func TestGetTrendAnomalies(t *testing.T) {
+	// March 2024 around 400 kWh and 48.00 a day, with the 5th and 20th far above
+	store := &summaryStore{}
+	for d := 1; d <= 31; d++ {
+		kwh := 400 + float64(d%3)*6 - 6
+		if d == 5 || d == 20 {
+			kwh = 620
+		}
+		store.stored = append(store.stored, cloud.AnalyticsSummary{
+			FacilityID: "facility-001", Date: fmt.Sprintf("2024-03-%02d", d),
+			TotalConsumption: kwh, EstimatedCost: kwh * 0.12,
+		})
+	}
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		window    int
+		sigma     float64
+		wantDates []string // flagged days, once per metric
+		wantErr   error
+	}{
+		{name: "one outlier in range", from: day(15), to: day(31), window: 7, sigma: 3, wantDates: []string{"2024-03-20", "2024-03-20"}},
+		{name: "both outliers", from: day(1), to: day(31), window: 3, sigma: 3, wantDates: []string{"2024-03-05", "2024-03-05", "2024-03-20", "2024-03-20"}},
+		{name: "outlier only in the baseline", from: day(22), to: day(31), window: 7, sigma: 3, wantDates: []string{}},
+		{name: "window too short", from: day(1), to: day(31), window: 1, sigma: 3, wantErr: ErrInvalidTrendQuery},
+		{name: "window too long", from: day(1), to: day(31), window: 91, sigma: 3, wantErr: ErrInvalidTrendQuery},
+		{name: "no sigma", from: day(1), to: day(31), window: 7, wantErr: ErrInvalidTrendQuery},
+		{name: "reversed", from: day(31), to: day(1), window: 7, sigma: 3, wantErr: ErrInvalidSummaryRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AnalyticsService{summaries: store}
+			report, err := s.GetTrendAnomalies("facility-001", tt.from, tt.to, tt.window, tt.sigma)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// The baseline for the first days in range is read too
+			if want := tt.from.AddDate(0, 0, -2*tt.window).Format("2006-01-02"); store.asked[1] != want {
+				t.Errorf("summaries read from %s, want %s", store.asked[1], want)
+			}
+
+			dates := make([]string, len(report.Flagged))
+			for i, f := range report.Flagged {
+				dates[i] = f.Date
+			}
+			if fmt.Sprint(dates) != fmt.Sprint(tt.wantDates) {
+				t.Fatalf("flagged %v, want %v", dates, tt.wantDates)
+			}
+			for i, f := range report.Flagged {
+				if want := trendMetrics[i%2].name; f.Metric != want {
+					t.Errorf("flag %d is for %s, want %s", i, f.Metric, want)
+				}
+				if f.DeviationSigma <= tt.sigma || f.Value <= f.TrailingMean || f.DeviationPercent < 50 {
+					t.Errorf("%s %s = %v against %v±%v is %vσ, %v%%", f.Date, f.Metric, f.Value, f.TrailingMean, f.TrailingStdDev, f.DeviationSigma, f.DeviationPercent)
+				}
+			}
+		})
+	}
+}
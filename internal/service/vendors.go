@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnknownVendor is returned for a reading tagged with a vendor that has no mapping
+var ErrUnknownVendor = errors.New("unknown meter vendor")
+
+// Canonical fields of a v1 reading payload a vendor mapping can fill
+var canonicalReadingFields = []string{
+	"meter_id", "timestamp", "voltage", "current", "power_kw", "power_unit", "voltage_unit",
+	"reactive_power_kvar", "frequency_hz", "thd_percent",
+}
+
+// vendorMapping describes how one vendor's payload maps onto the canonical
+// schema. Fields maps a canonical field to the vendor's field, as a dotted
+// path into nested objects; canonical fields it doesn't list are read from the
+// same name. Scale multiplies a canonical numeric field after renaming (e.g.
+// current in mA is 0.001). PowerUnit and VoltageUnit are used when the payload
+// has no unit of its own.
+type vendorMapping struct {
+	Fields      map[string]string  `json:"fields"`
+	Scale       map[string]float64 `json:"scale"`
+	PowerUnit   string             `json:"power_unit"`
+	VoltageUnit string             `json:"voltage_unit"`
+}
+
+// vendorMappings holds the mappings by lower-case vendor name
+type vendorMappings map[string]vendorMapping
+
+// loadVendorMappings reads the JSON vendor→mapping file at path; an empty
+// path means no vendors, leaving every payload in the canonical schema
+func loadVendorMappings(path string) (vendorMappings, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendor mappings: %w", err)
+	}
+	var parsed map[string]vendorMapping
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse vendor mappings: %w", err)
+	}
+
+	known := make(map[string]bool, len(canonicalReadingFields))
+	for _, f := range canonicalReadingFields {
+		known[f] = true
+	}
+	out := make(vendorMappings, len(parsed))
+	for name, m := range parsed {
+		for f := range m.Fields {
+			if !known[f] {
+				return nil, fmt.Errorf("vendor %q maps unknown field %q", name, f)
+			}
+		}
+		for f := range m.Scale {
+			if !known[f] || f == "meter_id" || f == "timestamp" || strings.HasSuffix(f, "_unit") {
+				return nil, fmt.Errorf("vendor %q scales non-numeric or unknown field %q", name, f)
+			}
+		}
+		out[strings.ToLower(name)] = m
+	}
+	return out, nil
+}
+
+// vendorFromTopic returns the vendor of an energy/vendor/<vendor>/readings topic
+func vendorFromTopic(topic string) string {
+	parts := strings.Split(topic, "/")
+	if len(parts) == 4 && parts[0] == "energy" && parts[1] == "vendor" && parts[3] == "readings" {
+		return parts[2]
+	}
+	return ""
+}
+
+// normalize rewrites a vendor's JSON payload into a canonical v1 payload. The
+// vendor comes from the topic or, failing that, the payload's "vendor" field;
+// payloads without one, protobuf payloads, and every payload when no vendors
+// are configured are returned unchanged.
+func (v vendorMappings) normalize(topic string, payload []byte) ([]byte, error) {
+	if len(v) == 0 || isProtoReading(topic, payload) {
+		return payload, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	vendor := vendorFromTopic(topic)
+	if vendor == "" {
+		vendor, _ = doc["vendor"].(string)
+	}
+	if vendor == "" {
+		return payload, nil
+	}
+	m, ok := v[strings.ToLower(vendor)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownVendor, vendor)
+	}
+
+	out := make(map[string]interface{}, len(canonicalReadingFields))
+	for _, field := range canonicalReadingFields {
+		path := field
+		if p, ok := m.Fields[field]; ok {
+			path = p
+		}
+		val, ok := lookupPath(doc, path)
+		if !ok {
+			continue
+		}
+		if field == "meter_id" {
+			// Vendors often send numeric IDs; the canonical field is a string
+			if n, isNum := val.(json.Number); isNum {
+				val = n.String()
+			}
+		}
+		if factor, ok := m.Scale[field]; ok {
+			n, isNum := val.(json.Number)
+			if !isNum {
+				return nil, fmt.Errorf("vendor %q field %s is not a number", vendor, path)
+			}
+			f, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("vendor %q field %s: %w", vendor, path, err)
+			}
+			val = f * factor
+		}
+		out[field] = val
+	}
+	if _, ok := out["power_unit"]; !ok && m.PowerUnit != "" {
+		out["power_unit"] = m.PowerUnit
+	}
+	if _, ok := out["voltage_unit"]; !ok && m.VoltageUnit != "" {
+		out["voltage_unit"] = m.VoltageUnit
+	}
+	return json.Marshal(out)
+}
+
+// lookupPath follows a dotted path ("data.power.value") through nested objects
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
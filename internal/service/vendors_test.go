@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/memstore"
+)
+
+// The README's example: acme nests its values and reports W and mA, volta
+// renames flat fields
+const testVendorMappings = `{
+  "acme": {"fields": {"meter_id": "device", "timestamp": "ts", "power_kw": "data.watts",
+                      "voltage": "data.volts", "current": "data.milliamps"},
+           "scale": {"current": 0.001}, "power_unit": "W"},
+  "Volta": {"fields": {"meter_id": "serial", "timestamp": "time", "power_kw": "p",
+                       "voltage": "u", "current": "i"}}
+}`
+
+func writeVendorMappings(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vendors.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadVendorMappings(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string // "" for no file configured
+		wantVendors []string
+		wantErr     bool
+	}{
+		{name: "no file"},
+		{name: "README example", body: testVendorMappings, wantVendors: []string{"acme", "volta"}},
+		{name: "unknown field", body: `{"acme": {"fields": {"watts": "w"}}}`, wantErr: true},
+		{name: "scaled meter id", body: `{"acme": {"scale": {"meter_id": 2}}}`, wantErr: true},
+		{name: "scaled unit", body: `{"acme": {"scale": {"power_unit": 2}}}`, wantErr: true},
+		{name: "not JSON", body: `acme: {}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.body != "" {
+				path = writeVendorMappings(t, tt.body)
+			}
+			got, err := loadVendorMappings(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantVendors) {
+				t.Errorf("vendors %v, want %v", got, tt.wantVendors)
+			}
+			for _, v := range tt.wantVendors {
+				if _, ok := got[v]; !ok {
+					t.Errorf("no mapping for %q", v)
+				}
+			}
+		})
+	}
+	if _, err := loadVendorMappings(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file loaded")
+	}
+}
+
+func TestVendorReadings(t *testing.T) {
+	vendors, err := loadVendorMappings(writeVendorMappings(t, testVendorMappings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var canonical *domain.Reading // as stored from the canonical payload
+
+	tests := []struct {
+		name    string
+		topic   string
+		payload string
+		wantErr bool
+		wantIs  error
+	}{
+		{name: "canonical", topic: "energy/readings", payload: `{"meter_id": "7", "timestamp": "2024-03-01T12:00:00Z", "voltage": 231.5, "current": 12.5, "power_kw": 2.75}`},
+		{
+			name: "acme by topic", topic: "energy/vendor/acme/readings",
+			payload: `{"device": 7, "ts": 1709294400, "data": {"watts": 2750, "volts": 231.5, "milliamps": 12500}}`,
+		},
+		{
+			name: "acme by payload", topic: "energy/readings",
+			payload: `{"vendor": "ACME", "device": "7", "ts": "2024-03-01T12:00:00Z", "data": {"watts": 2750, "volts": 231.5, "milliamps": 12500}}`,
+		},
+		{name: "volta", topic: "energy/vendor/volta/readings", payload: `{"serial": "7", "time": 1709294400000, "p": 2.75, "u": 231.5, "i": 12.5}`},
+		{name: "unknown vendor", topic: "energy/vendor/zeta/readings", payload: `{"serial": "7"}`, wantErr: true, wantIs: ErrUnknownVendor},
+		{name: "scaled field not a number", topic: "energy/vendor/acme/readings", payload: `{"device": 7, "ts": 1709294400, "data": {"milliamps": "lots"}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memstore.New()
+			s := newImportService(store)
+			s.vendors = vendors
+			rd, err := s.FromMQTT(tt.topic, []byte(tt.payload))
+			if tt.wantErr {
+				if err == nil || tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+					t.Fatalf("err = %v, want %v", err, tt.wantIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Every schema lands as the same canonical reading
+			if rd.MeterID != 7 || !rd.Timestamp.Equal(ts) || rd.Voltage != 231.5 || rd.Current != 12.5 || rd.PowerKW != 2.75 {
+				t.Errorf("meter %d at %v: %v V, %v A, %v kW", rd.MeterID, rd.Timestamp.Time, rd.Voltage, rd.Current, rd.PowerKW)
+			}
+			stored, err := store.GetRecentReadings("facility-001", 100*365*24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 1 {
+				t.Fatalf("stored %+v", stored)
+			}
+			if canonical == nil {
+				canonical = &stored[0]
+			} else if !reflect.DeepEqual(stored[0], *canonical) {
+				t.Errorf("stored %+v\nwant   %+v", stored[0], *canonical)
+			}
+		})
+	}
+}
+
+func TestVendorNormalizePassthrough(t *testing.T) {
+	vendors, err := loadVendorMappings(writeVendorMappings(t, testVendorMappings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := `{"vendor": "acme", "device": 7, "data": {"watts": 2750}}`
+	tests := []struct {
+		name    string
+		vendors vendorMappings
+		topic   string
+		payload string
+	}{
+		{name: "no vendors configured", topic: "energy/vendor/acme/readings", payload: acme},
+		{name: "no vendor named", vendors: vendors, topic: "energy/readings", payload: `{"meter_id": "7", "power_kw": 2.75}`},
+		{name: "protobuf", vendors: vendors, topic: "energy/readings.pb", payload: "\x0a\x017"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.vendors.normalize(tt.topic, []byte(tt.payload))
+			if err != nil || string(got) != tt.payload {
+				t.Errorf("normalized to %s, %v; want it unchanged", got, err)
+			}
+		})
+	}
+}
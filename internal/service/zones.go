@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/aggregator"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/repository"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+)
+
+// UnassignedZone collects readings from meters that have no zone
+const UnassignedZone = "unassigned"
+
+// ZoneSummary is the daily rollup of one zone's meters
+type ZoneSummary struct {
+	Zone             string  `json:"zone"`
+	MeterCount       int     `json:"meter_count"`
+	ReadingCount     int     `json:"reading_count"`
+	TotalConsumption float64 `json:"total_consumption"`
+	PeakPower        float64 `json:"peak_power"`
+	AveragePower     float64 `json:"average_power"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+}
+
+// ZoneReport breaks a facility's day down by zone. The zone consumption and
+// energy cost figures sum to the facility totals.
+type ZoneReport struct {
+	FacilityID       string        `json:"facility_id"`
+	Date             time.Time     `json:"date"`
+	Zones            []ZoneSummary `json:"zones"`
+	TotalConsumption float64       `json:"total_consumption"`
+	EstimatedCost    float64       `json:"estimated_cost"`
+}
+
+// GetZoneSummaries groups the day's readings by the zone of their meter and
+// rolls each zone up independently
+func (s *AnalyticsService) GetZoneSummaries(facilityID string, date time.Time) (*ZoneReport, error) {
+	readings, err := s.getReadingsForDate(facilityID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings: %w", err)
+	}
+
+	zones, err := s.repos.MeterZones()
+	if err != nil && !errors.Is(err, repository.ErrNoDatabase) {
+		return nil, fmt.Errorf("failed to get meter zones: %w", err)
+	}
+
+	return buildZoneReport(facilityID, date, readings, zones, s.zones.Location(facilityID), s.costs)
+}
+
+func buildZoneReport(facilityID string, date time.Time, readings []domain.Reading, zones map[int64]string, loc *time.Location, model costmodel.Model) (*ZoneReport, error) {
+	byZone := make(map[string][]aggregator.Point)
+	zoneReadings := make(map[string][]domain.Reading)
+	meters := make(map[string]map[int64]bool)
+	for _, r := range readings {
+		zone := zones[r.MeterID]
+		if zone == "" {
+			zone = UnassignedZone
+		}
+		byZone[zone] = append(byZone[zone], aggregator.Point{Value: r.PowerKW, Timestamp: r.Timestamp.Time})
+		zoneReadings[zone] = append(zoneReadings[zone], r)
+		if meters[zone] == nil {
+			meters[zone] = make(map[int64]bool)
+		}
+		meters[zone][r.MeterID] = true
+	}
+
+	report := &ZoneReport{FacilityID: facilityID, Date: date, Zones: []ZoneSummary{}}
+	zoneCosts := make([]costmodel.Cents, 0, len(byZone))
+	var totalCost costmodel.Cents
+	for zone, points := range byZone {
+		zs := ZoneSummary{
+			Zone:             zone,
+			MeterCount:       len(meters[zone]),
+			ReadingCount:     len(points),
+			TotalConsumption: samplesToKWh(aggregator.Sum(points), model.IntervalSeconds),
+			AveragePower:     aggregator.Average(points),
+		}
+		for _, p := range points {
+			if p.Value > zs.PeakPower {
+				zs.PeakPower = p.Value
+			}
+		}
+		// Zones carry only their energy cost; demand, fixed charges and taxes
+		// are billed to the facility as a whole
+		cost, err := model.Cost(costSamples(zoneReadings[zone]), loc)
+		if err != nil {
+			return nil, fmt.Errorf("zone %s: %w", zone, err)
+		}
+
+		report.Zones = append(report.Zones, zs)
+		zoneCosts = append(zoneCosts, costmodel.ToCents(cost.EnergyCost))
+		report.TotalConsumption += zs.TotalConsumption
+	}
+
+	// Each zone is rounded to cents on its own, which can leave the zones a
+	// cent or two off the facility's energy cost; hand the difference to the
+	// costliest zones so the rollups sum to what the facility is billed
+	facility, err := model.Cost(costSamples(readings), loc)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(zoneCosts))
+	for i := range order {
+		order[i] = i
+		totalCost += zoneCosts[i]
+	}
+	sort.SliceStable(order, func(i, j int) bool { return zoneCosts[order[i]] > zoneCosts[order[j]] })
+	for diff, i := costmodel.ToCents(facility.EnergyCost)-totalCost, 0; diff != 0 && len(order) > 0; i++ {
+		step := costmodel.Cents(1)
+		if diff < 0 {
+			step = -1
+		}
+		zoneCosts[order[i%len(order)]] += step
+		diff -= step
+	}
+	for i := range report.Zones {
+		report.Zones[i].EstimatedCost = zoneCosts[i].Float64()
+	}
+	report.EstimatedCost = facility.EnergyCost
+
+	sort.Slice(report.Zones, func(i, j int) bool { return report.Zones[i].Zone < report.Zones[j].Zone })
+	return report, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/domain"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
+)
+
+func TestBuildZoneReport(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(meter int64, hour int, kw float64) domain.Reading {
+		return domain.Reading{MeterID: meter, Timestamp: timeutil.FromTime(day.Add(time.Duration(hour) * time.Hour)), PowerKW: kw}
+	}
+	// Hourly samples, so each reading is its kW in kWh
+	model := costmodel.Default(0.12, 3600)
+
+	tests := []struct {
+		name     string
+		readings []domain.Reading
+		zones    map[int64]string
+		want     map[string]ZoneSummary
+	}{
+		{
+			name: "two zones roll up independently",
+			readings: []domain.Reading{
+				at(1, 2, 10), at(1, 3, 12), at(2, 2, 4),
+				at(3, 18, 20), at(3, 19, 6),
+			},
+			zones: map[int64]string{1: "building-a", 2: "building-a", 3: "building-b"},
+			want: map[string]ZoneSummary{
+				"building-a": {MeterCount: 2, ReadingCount: 3, TotalConsumption: 26, PeakPower: 12},
+				"building-b": {MeterCount: 1, ReadingCount: 2, TotalConsumption: 26, PeakPower: 20},
+			},
+		},
+		{
+			name:     "meter without a zone is unassigned",
+			readings: []domain.Reading{at(1, 2, 10), at(4, 2, 3)},
+			zones:    map[int64]string{1: "building-a"},
+			want: map[string]ZoneSummary{
+				"building-a":   {MeterCount: 1, ReadingCount: 1, TotalConsumption: 10, PeakPower: 10},
+				UnassignedZone: {MeterCount: 1, ReadingCount: 1, TotalConsumption: 3, PeakPower: 3},
+			},
+		},
+		{
+			name: "no readings",
+			want: map[string]ZoneSummary{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := buildZoneReport("facility-001", day, tt.readings, tt.zones, time.UTC, model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Zones) != len(tt.want) {
+				t.Fatalf("got %d zones, want %d", len(report.Zones), len(tt.want))
+			}
+			var kwh, cost float64
+			for _, zs := range report.Zones {
+				want, ok := tt.want[zs.Zone]
+				if !ok {
+					t.Errorf("unexpected zone %q", zs.Zone)
+					continue
+				}
+				if zs.MeterCount != want.MeterCount || zs.ReadingCount != want.ReadingCount ||
+					zs.TotalConsumption != want.TotalConsumption || zs.PeakPower != want.PeakPower {
+					t.Errorf("zone %s = %+v, want %+v", zs.Zone, zs, want)
+				}
+				kwh += zs.TotalConsumption
+				cost += zs.EstimatedCost
+			}
+
+			facility, err := model.Cost(costSamples(tt.readings), time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.TotalConsumption != kwh || math.Abs(report.EstimatedCost-cost) > 1e-9 {
+				t.Errorf("report totals %v kWh, %v; zones sum to %v kWh, %v", report.TotalConsumption, report.EstimatedCost, kwh, cost)
+			}
+			if math.Abs(report.EstimatedCost-facility.EnergyCost) > 1e-9 {
+				t.Errorf("zone costs sum to %v, facility energy cost is %v", report.EstimatedCost, facility.EnergyCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/internal/cloud"
+)
+
+func TestNotify(t *testing.T) {
+	n := cloud.Notification{
+		FacilityID: "facility-001",
+		Severity:   "critical",
+		Type:       "voltage_anomaly",
+		Message:    "Voltage 260V exceeds the upper band",
+		Timestamp:  time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name         string
+		secret       string
+		statuses     []int // replies in order; the last repeats
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "delivered and signed", secret: "s3cret", statuses: []int{http.StatusOK}, wantAttempts: 1},
+		{name: "unsigned without a secret", statuses: []int{http.StatusNoContent}, wantAttempts: 1},
+		{name: "5xx is retried", secret: "s3cret", statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, wantAttempts: 3},
+		{name: "429 is retried", secret: "s3cret", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, wantAttempts: 2},
+		{name: "gives up after max attempts", statuses: []int{http.StatusInternalServerError}, wantAttempts: defaultMaxAttempts, wantErr: true},
+		{name: "4xx is not retried", statuses: []int{http.StatusBadRequest}, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				body, _ := io.ReadAll(r.Body)
+
+				sig := r.Header.Get(SignatureHeader)
+				if tt.secret == "" && sig != "" {
+					t.Errorf("unsigned notifier sent %s %q", SignatureHeader, sig)
+				}
+				if want := Sign([]byte(tt.secret), body); tt.secret != "" && sig != want {
+					t.Errorf("%s = %q, want %q", SignatureHeader, sig, want)
+				}
+				var got cloud.Notification
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("payload isn't a notification: %v", err)
+				} else if got != n {
+					t.Errorf("payload = %+v, want %+v", got, n)
+				}
+
+				w.WriteHeader(tt.statuses[min(attempts, len(tt.statuses))-1])
+			}))
+			defer srv.Close()
+
+			w := New(srv.URL, tt.secret)
+			w.backoff = time.Millisecond
+			err := w.Notify(n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// sourceArchive marks analytics computed from the S3 readings archive
+const sourceArchive = "s3_archive"
+
+// Default days DynamoDB keeps readings before TTL expires them (READINGS_RETENTION_DAYS)
+const defaultRetentionDays = 90
+
+// archivedReading is one line of the readings archive
+type archivedReading struct {
+	FacilityID        string   `json:"facility_id"`
+	MeterID           string   `json:"meter_id"`
+	Timestamp         int64    `json:"timestamp"`
+	Voltage           float64  `json:"voltage"`
+	Current           float64  `json:"current"`
+	PowerKW           float64  `json:"power_kw"`
+	ReactivePowerKVAR *float64 `json:"reactive_power_kvar,omitempty"`
+	FrequencyHz       *float64 `json:"frequency_hz,omitempty"`
+	THDPercent        *float64 `json:"thd_percent,omitempty"`
+}
+
+// archiveFallbackEnabled reports whether ANALYTICS_ARCHIVE_FALLBACK=true
+func archiveFallbackEnabled() bool {
+	return strings.EqualFold(getenv("ANALYTICS_ARCHIVE_FALLBACK", "false"), "true")
+}
+
+// beyondRetention reports whether the day starting at dayStart is older than
+// READINGS_RETENTION_DAYS, so DynamoDB may have expired its readings
+func beyondRetention(dayStart, now time.Time) bool {
+	days := envInt("READINGS_RETENTION_DAYS", defaultRetentionDays)
+	return dayStart.Before(now.AddDate(0, 0, -days))
+}
+
+// archivePrefixes returns the key prefixes holding a facility's archived
+// readings for [dayStart, dayEnd). The archive is partitioned by UTC date,
+// so a local day can span two partitions.
+func archivePrefixes(prefix, facilityID string, dayStart, dayEnd time.Time) []string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	var out []string
+	last := dayEnd.Add(-time.Second).UTC().Format("2006-01-02")
+	for d := dayStart.UTC(); ; d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		out = append(out, fmt.Sprintf("%s/%s/date=%s/", prefix, safePath(facilityID), date))
+		if date >= last {
+			return out
+		}
+	}
+}
+
+// getArchivedReadings reads the facility's readings within [dayStart, dayEnd)
+// from the NDJSON archive under READINGS_ARCHIVE_BUCKET (default S3_BUCKET)
+// and READINGS_ARCHIVE_PREFIX, oldest first. Lines that don't parse are
+// skipped with a warning.
+func getArchivedReadings(ctx context.Context, facilityID string, dayStart, dayEnd time.Time) ([]Reading, error) {
+	bucket := getenv("READINGS_ARCHIVE_BUCKET", s3Bucket)
+	prefix := getenv("READINGS_ARCHIVE_PREFIX", "readings/archive")
+	start, end := dayStart.Unix(), dayEnd.Unix()
+
+	var all []Reading
+	for _, p := range archivePrefixes(prefix, facilityID, dayStart, dayEnd) {
+		pages := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(p),
+		})
+		for pages.HasMorePages() {
+			page, err := pages.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("list archive %s: %w", p, err)
+			}
+			for _, obj := range page.Contents {
+				key := aws.ToString(obj.Key)
+				if !strings.HasSuffix(key, ".ndjson") {
+					continue
+				}
+				readings, err := readArchiveObject(ctx, bucket, key, facilityID, start, end)
+				if err != nil {
+					return nil, err
+				}
+				all = append(all, readings...)
+			}
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Timestamp < all[j].Timestamp })
+	return all, nil
+}
+
+// readArchiveObject decodes one archive object, keeping the facility's
+// readings with start <= timestamp < end
+func readArchiveObject(ctx context.Context, bucket, key, facilityID string, start, end int64) ([]Reading, error) {
+	obj, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get archive object %s: %w", key, err)
+	}
+	defer obj.Body.Close()
+
+	var out []Reading
+	sc := bufio.NewScanner(obj.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line, skipped := 0, 0
+	for sc.Scan() {
+		line++
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var ar archivedReading
+		if err := json.Unmarshal(sc.Bytes(), &ar); err != nil {
+			skipped++
+			continue
+		}
+		if ar.FacilityID != facilityID || ar.Timestamp < start || ar.Timestamp >= end {
+			continue
+		}
+		out = append(out, Reading{
+			FacilityID:        ar.FacilityID,
+			MeterID:           ar.MeterID,
+			Timestamp:         ar.Timestamp,
+			Voltage:           ar.Voltage,
+			Current:           ar.Current,
+			PowerKW:           ar.PowerKW,
+			ReactivePowerKVAR: ar.ReactivePowerKVAR,
+			FrequencyHz:       ar.FrequencyHz,
+			THDPercent:        ar.THDPercent,
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read archive object %s: %w", key, err)
+	}
+	if skipped > 0 {
+		fmt.Printf("WARN: skipped %d of %d unparseable lines in %s\n", skipped, line, key)
+	}
+	return out, nil
+}
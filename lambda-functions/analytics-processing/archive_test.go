@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestArchivePrefixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		loc    *time.Location
+		want   []string
+	}{
+		{name: "UTC day", prefix: "readings/archive", loc: time.UTC, want: []string{"readings/archive/facility-001/date=2024-01-10/"}},
+		{name: "trailing slash", prefix: "readings/archive/", loc: time.UTC, want: []string{"readings/archive/facility-001/date=2024-01-10/"}},
+		{
+			name: "local day across two UTC dates", prefix: "readings/archive", loc: time.FixedZone("UTC+2", 2*3600),
+			want: []string{"readings/archive/facility-001/date=2024-01-09/", "readings/archive/facility-001/date=2024-01-10/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 10, 0, 0, 0, 0, tt.loc)
+			if got := archivePrefixes(tt.prefix, "facility-001", start, start.AddDate(0, 0, 1)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prefixes %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeyondRetention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		env  string
+		day  time.Time
+		want bool
+	}{
+		{name: "yesterday", day: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "within 90 days", day: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "past 90 days", day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), want: true},
+		{name: "past a shorter retention", env: "30", day: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), want: true},
+		{name: "within a longer retention", env: "365", day: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("READINGS_RETENTION_DAYS", tt.env)
+			if got := beyondRetention(tt.day, now); got != tt.want {
+				t.Errorf("beyondRetention(%s) = %v, want %v", tt.day.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeAWS stands in for DynamoDB and S3: readings queries answer with
+// dynamoItems, the readings archive holds archive (key to NDJSON), and
+// summaries and objects stored are recorded
+type fakeAWS struct {
+	dynamoItems []map[string]interface{}
+	archive     map[string]string
+
+	mu      sync.Mutex
+	listed  []string
+	summary map[string]map[string]interface{}
+	put     map[string][]byte
+}
+
+func (f *fakeAWS) dynamo(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	var in struct {
+		Item map[string]map[string]interface{}
+	}
+	json.NewDecoder(r.Body).Decode(&in)
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.Query":
+		body, _ := json.Marshal(map[string]interface{}{"Items": f.dynamoItems, "Count": len(f.dynamoItems)})
+		w.Write(body)
+	case "DynamoDB_20120810.PutItem":
+		f.summary = in.Item
+		w.Write([]byte(`{}`))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (f *fakeAWS) s3(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Path style: /<bucket>/<key>
+	_, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if f.put == nil {
+			f.put = make(map[string][]byte)
+		}
+		f.put[key] = body
+	case r.URL.Query().Get("list-type") == "2":
+		prefix := r.URL.Query().Get("prefix")
+		f.listed = append(f.listed, prefix)
+		var contents strings.Builder
+		for k := range f.archive {
+			if strings.HasPrefix(k, prefix) {
+				fmt.Fprintf(&contents, "<Contents><Key>%s</Key></Contents>", k)
+			}
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>energy-grid-reports</Name><Prefix>%s</Prefix><IsTruncated>false</IsTruncated>%s</ListBucketResult>`, prefix, contents.String())
+	default:
+		body, ok := f.archive[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+// useFakeAWS points the DynamoDB and S3 clients at f for the test
+func useFakeAWS(t *testing.T, f *fakeAWS) {
+	t.Helper()
+	ddb := httptest.NewServer(http.HandlerFunc(f.dynamo))
+	objects := httptest.NewServer(http.HandlerFunc(f.s3))
+	prevDynamo, prevS3 := dynamoClient, s3Client
+	t.Cleanup(func() {
+		dynamoClient, s3Client = prevDynamo, prevS3
+		ddb.Close()
+		objects.Close()
+	})
+	dynamoClient = dynamodb.New(dynamodb.Options{
+		Region: "us-east-1", BaseEndpoint: aws.String(ddb.URL), Credentials: aws.AnonymousCredentials{}, RetryMaxAttempts: 1,
+	})
+	s3Client = s3.New(s3.Options{
+		Region: "us-east-1", BaseEndpoint: aws.String(objects.URL), UsePathStyle: true, Credentials: aws.AnonymousCredentials{}, RetryMaxAttempts: 1,
+	})
+}
+
+func TestHandlerArchiveFallback(t *testing.T) {
+	// A day long past retention, archived as 24 hourly 10 kW readings, and a
+	// recent day that DynamoDB should still have
+	old := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	recent := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	var lines []string
+	for h := 0; h < 24; h++ {
+		lines = append(lines, fmt.Sprintf(`{"facility_id":"facility-001","meter_id":"1","timestamp":%d,"voltage":230,"current":43.5,"power_kw":10}`, old.Add(time.Duration(h)*time.Hour).Unix()))
+	}
+	lines = append(lines,
+		`{"facility_id":"facility-002","meter_id":"9","timestamp":`+fmt.Sprint(old.Add(time.Hour).Unix())+`,"power_kw":99}`,
+		`{"facility_id":"facility-001","meter_id":"1","timestamp":`+fmt.Sprint(old.AddDate(0, 0, 1).Unix())+`,"power_kw":99}`,
+		`not json`,
+	)
+	archive := map[string]string{
+		"readings/archive/facility-001/date=2024-01-10/part-0001.ndjson": strings.Join(lines, "\n"),
+		"readings/archive/facility-001/date=2024-01-10/_manifest.json":   `{}`,
+	}
+	dynamoItems := []map[string]interface{}{{
+		"facilityId": map[string]string{"S": "facility-001"}, "meterId": map[string]string{"S": "1"},
+		"timestamp": map[string]string{"N": fmt.Sprint(old.Add(time.Hour).Unix())},
+		"voltage":   map[string]string{"N": "230"}, "current": map[string]string{"N": "20"}, "powerKw": map[string]string{"N": "4.5"},
+	}}
+
+	tests := []struct {
+		name        string
+		fallback    string
+		date        string
+		dynamo      []map[string]interface{}
+		wantListed  bool
+		wantReads   int // 0 for no analytics
+		wantPeak    float64
+		wantSource  string
+		wantMessage string
+	}{
+		{name: "empty DynamoDB, archived day", fallback: "true", date: "2024-01-10", wantListed: true, wantReads: 24, wantPeak: 10, wantSource: sourceArchive},
+		{name: "fallback off", fallback: "false", date: "2024-01-10", wantMessage: "No data to process"},
+		{name: "within retention", fallback: "true", date: recent, wantMessage: "No data to process"},
+		{name: "DynamoDB still has the day", fallback: "true", date: "2024-01-10", dynamo: dynamoItems, wantReads: 1, wantPeak: 4.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANALYTICS_ARCHIVE_FALLBACK", tt.fallback)
+			f := &fakeAWS{dynamoItems: tt.dynamo, archive: archive}
+			useFakeAWS(t, f)
+
+			resp, err := Handler(context.Background(), LambdaEvent{FacilityID: "facility-001", Date: tt.date})
+			if err != nil || resp.StatusCode != 200 {
+				t.Fatalf("status %d, %v: %v", resp.StatusCode, err, resp.Body)
+			}
+			if (len(f.listed) > 0) != tt.wantListed {
+				t.Errorf("archive listed at %q, want listed %v", f.listed, tt.wantListed)
+			}
+			if tt.wantReads == 0 {
+				if resp.Body["message"] != tt.wantMessage {
+					t.Errorf("message %v, want %q", resp.Body["message"], tt.wantMessage)
+				}
+				return
+			}
+
+			a, ok := resp.Body["analytics"].(DailyAnalytics)
+			if !ok {
+				t.Fatalf("no analytics: %v", resp.Body)
+			}
+			if a.ReadingCount != tt.wantReads || a.PeakPower != tt.wantPeak || a.Source != tt.wantSource {
+				t.Errorf("%d readings, peak %v, source %q; want %d, %v, %q", a.ReadingCount, a.PeakPower, a.Source, tt.wantReads, tt.wantPeak, tt.wantSource)
+			}
+			// The stored summary says where it came from
+			var stored string
+			if s, ok := f.summary["source"]; ok {
+				stored, _ = s["S"].(string)
+			}
+			if stored != tt.wantSource {
+				t.Errorf("stored summary with source %q, want %q", stored, tt.wantSource)
+			}
+		})
+	}
+}
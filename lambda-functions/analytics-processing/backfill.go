@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// gapFill summarizes the readings estimated to fill a day's short gaps
+type gapFill struct {
+	Gaps     int     // gaps filled
+	Readings int     // estimated readings added
+	KWh      float64 // energy attributed to the estimated readings
+}
+
+// gapFillMax reads GAP_FILL_MAX_MINUTES, the longest gap filled with
+// estimates; 0 (the default) leaves every gap missing
+func gapFillMax() time.Duration {
+	return time.Duration(envInt("GAP_FILL_MAX_MINUTES", 0)) * time.Minute
+}
+
+// fillGaps interpolates readings into each meter's gaps that are no longer
+// than maxGap, one every intervalSeconds, so charts and energy integrals don't
+// show holes. A gap is more than two intervals between consecutive readings,
+// as counted by applyDataQuality; longer gaps are left missing. Estimated
+// readings are marked and interpolate power, voltage and current linearly
+// between the readings around the gap. The result is oldest first.
+func fillGaps(readings []Reading, intervalSeconds float64, maxGap time.Duration) ([]Reading, gapFill) {
+	var fill gapFill
+	if intervalSeconds <= 0 || maxGap <= 0 {
+		return readings, fill
+	}
+	interval := int64(intervalSeconds)
+	if interval < 1 {
+		interval = 1
+	}
+
+	// Interpolating between two different meters would invent a trend
+	byMeter := make(map[string][]Reading)
+	for _, r := range readings {
+		byMeter[r.MeterID] = append(byMeter[r.MeterID], r)
+	}
+
+	out := make([]Reading, 0, len(readings))
+	out = append(out, readings...)
+	for _, rs := range byMeter {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].Timestamp < rs[j].Timestamp })
+		for i := 1; i < len(rs); i++ {
+			prev, next := rs[i-1], rs[i]
+			span := next.Timestamp - prev.Timestamp
+			if float64(span) <= 2*intervalSeconds || time.Duration(span)*time.Second > maxGap {
+				continue
+			}
+
+			fill.Gaps++
+			for ts := prev.Timestamp + interval; ts < next.Timestamp; ts += interval {
+				f := float64(ts-prev.Timestamp) / float64(span)
+				est := Reading{
+					FacilityID: prev.FacilityID,
+					MeterID:    prev.MeterID,
+					Timestamp:  ts,
+					Voltage:    lerp(prev.Voltage, next.Voltage, f),
+					Current:    lerp(prev.Current, next.Current, f),
+					PowerKW:    lerp(prev.PowerKW, next.PowerKW, f),
+					Estimated:  true,
+				}
+				out = append(out, est)
+				fill.Readings++
+				fill.KWh += SampledEnergy(est.PowerKW, intervalSeconds)
+			}
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	return out, fill
+}
+
+func lerp(a, b, f float64) float64 { return a + (b-a)*f }
+
+// applyGapFill records the estimates on the analytics and takes them back
+// out of the reading count, which stays a count of measured readings.
+// EstimatedKWh lets billing exclude energy that wasn't measured.
+func applyGapFill(a *DailyAnalytics, fill gapFill) {
+	if fill.Readings == 0 {
+		return
+	}
+	a.ReadingCount -= fill.Readings
+	a.FilledGaps = fill.Gaps
+	a.EstimatedReadings = fill.Readings
+	a.EstimatedKWh = numfmt.SanitizeFloat(fill.KWh)
+}
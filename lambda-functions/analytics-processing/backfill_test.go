@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFillGaps(t *testing.T) {
+	const t0 = int64(1_709_280_000)
+	at := func(meter string, minute int64, kw float64) Reading {
+		return Reading{FacilityID: "facility-001", MeterID: meter, Timestamp: t0 + minute*60, PowerKW: kw, Voltage: 230}
+	}
+	// Minute readings for m1 with a 4-minute and a 20-minute gap; m2 reads
+	// every other minute, which is on schedule for two intervals
+	readings := []Reading{
+		at("m1", 0, 10), at("m2", 0, 50), at("m1", 1, 10), at("m2", 2, 50),
+		at("m2", 4, 50), at("m1", 5, 18), at("m1", 6, 18), at("m1", 26, 4),
+	}
+
+	tests := []struct {
+		name        string
+		maxGap      time.Duration
+		wantGaps    int
+		wantMinutes []int64 // of the estimated readings
+		wantPower   []float64
+	}{
+		{name: "disabled", maxGap: 0},
+		{name: "short gap filled, long gap left", maxGap: 10 * time.Minute, wantGaps: 1, wantMinutes: []int64{2, 3, 4}, wantPower: []float64{12, 14, 16}},
+		{name: "both gaps filled", maxGap: 30 * time.Minute, wantGaps: 2, wantMinutes: []int64{2, 3, 4, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25}},
+		{name: "gap just too long", maxGap: 3 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, fill := fillGaps(append([]Reading(nil), readings...), 60, tt.maxGap)
+			if fill.Gaps != tt.wantGaps || fill.Readings != len(tt.wantMinutes) || len(out) != len(readings)+len(tt.wantMinutes) {
+				t.Fatalf("%d gaps, %d estimates, %d readings out; want %d gaps, %d estimates", fill.Gaps, fill.Readings, len(out), tt.wantGaps, len(tt.wantMinutes))
+			}
+
+			var minutes []int64
+			var power []float64
+			var kwh float64
+			for i, r := range out {
+				if i > 0 && r.Timestamp < out[i-1].Timestamp {
+					t.Fatal("readings out of order")
+				}
+				if !r.Estimated {
+					continue
+				}
+				// Estimates only ever fill m1's gaps, never across meters
+				if r.MeterID != "m1" || r.Voltage != 230 {
+					t.Errorf("estimated %+v", r)
+				}
+				minutes = append(minutes, (r.Timestamp-t0)/60)
+				power = append(power, r.PowerKW)
+				kwh += SampledEnergy(r.PowerKW, 60)
+			}
+			if len(minutes) != len(tt.wantMinutes) {
+				t.Fatalf("estimated at minutes %v, want %v", minutes, tt.wantMinutes)
+			}
+			for i := range minutes {
+				if minutes[i] != tt.wantMinutes[i] {
+					t.Fatalf("estimated at minutes %v, want %v", minutes, tt.wantMinutes)
+				}
+			}
+			for i, want := range tt.wantPower {
+				if math.Abs(power[i]-want) > 1e-9 {
+					t.Errorf("estimated power %v, want %v", power, tt.wantPower)
+				}
+			}
+			if math.Abs(fill.KWh-kwh) > 1e-9 {
+				t.Errorf("estimated %v kWh, readings add up to %v", fill.KWh, kwh)
+			}
+		})
+	}
+}
+
+func TestApplyGapFill(t *testing.T) {
+	tests := []struct {
+		name      string
+		fill      gapFill
+		wantCount int
+	}{
+		{name: "nothing filled", wantCount: 100},
+		{name: "filled", fill: gapFill{Gaps: 2, Readings: 7, KWh: 1.25}, wantCount: 93},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := DailyAnalytics{ReadingCount: 100}
+			applyGapFill(&a, tt.fill)
+			// The count stays one of measured readings; the estimate is kept apart for billing
+			if a.ReadingCount != tt.wantCount || a.FilledGaps != tt.fill.Gaps || a.EstimatedReadings != tt.fill.Readings || a.EstimatedKWh != tt.fill.KWh {
+				t.Errorf("analytics %+v after %+v", a, tt.fill)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bucket intervals the analytics can be computed at; hourly data is always
+// produced as well
+var validGranularities = map[int]bool{15: true, 30: true, 60: true}
+
+// BucketStats summarizes the readings of one interval; it has the same fields
+// as an hour of HourlyData
+type BucketStats = HourlyData
+
+// parseGranularity returns the event's granularity_minutes, or
+// ANALYTICS_GRANULARITY_MINUTES (default 60) when the event has none
+func parseGranularity(minutes int) (int, error) {
+	if minutes == 0 {
+		minutes = envInt("ANALYTICS_GRANULARITY_MINUTES", 60)
+	}
+	if !validGranularities[minutes] {
+		return 0, fmt.Errorf("granularity_minutes must be 15, 30 or 60, got %d", minutes)
+	}
+	return minutes, nil
+}
+
+// calculateBucketData buckets readings into minutes-long local intervals of
+// the day, keyed by the interval's local start time as "HH:MM". The keys sort
+// in time order; on a day where clocks go back the repeated hour shares its
+// buckets, as it does in the hourly data.
+func calculateBucketData(readings []Reading, loc *time.Location, minutes int) map[string]BucketStats {
+	return bucketReadings(readings, func(t time.Time) string {
+		lt := t.In(loc)
+		return fmt.Sprintf("%02d:%02d", lt.Hour(), lt.Minute()/minutes*minutes)
+	})
+}
+
+// bucketReadings accumulates count, sum, max and average power per key
+func bucketReadings(readings []Reading, key func(time.Time) string) map[string]BucketStats {
+	buckets := make(map[string]BucketStats)
+	for _, r := range readings {
+		k := key(time.Unix(r.Timestamp, 0))
+		data := buckets[k]
+		data.Count++
+		data.TotalPower += r.PowerKW
+		if r.PowerKW > data.MaxPower {
+			data.MaxPower = r.PowerKW
+		}
+		buckets[k] = data
+	}
+	for k, d := range buckets {
+		if d.Count > 0 {
+			d.AvgPower = d.TotalPower / float64(d.Count)
+			buckets[k] = d
+		}
+	}
+	return buckets
+}
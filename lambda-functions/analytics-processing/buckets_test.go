@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseGranularity(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes int
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 60},
+		{name: "from the environment", env: "15", want: 15},
+		{name: "event overrides the environment", minutes: 30, env: "15", want: 30},
+		{name: "unsupported", minutes: 20, wantErr: true},
+		{name: "unsupported in the environment", env: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANALYTICS_GRANULARITY_MINUTES", tt.env)
+			got, err := parseGranularity(tt.minutes)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseGranularity(%d) = %d, %v; want %d", tt.minutes, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateBucketData(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	// Three hours from 10:00 local, a reading every 5 minutes whose power is
+	// the minute of the hour, so each bucket's stats are predictable
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, berlin)
+	var readings []Reading
+	for ts := start; ts.Before(start.Add(3 * time.Hour)); ts = ts.Add(5 * time.Minute) {
+		readings = append(readings, Reading{MeterID: "m1", Timestamp: ts.Unix(), PowerKW: float64(ts.Minute())})
+	}
+
+	tests := []struct {
+		minutes   int
+		wantKeys  int
+		wantFirst string
+		wantLast  string
+		wantCount int
+	}{
+		{minutes: 15, wantKeys: 12, wantFirst: "10:00", wantLast: "12:45", wantCount: 3},
+		{minutes: 30, wantKeys: 6, wantFirst: "10:00", wantLast: "12:30", wantCount: 6},
+		{minutes: 60, wantKeys: 3, wantFirst: "10:00", wantLast: "12:00", wantCount: 12},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d minutes", tt.minutes), func(t *testing.T) {
+			buckets := calculateBucketData(readings, berlin, tt.minutes)
+			keys := make([]string, 0, len(buckets))
+			for k := range buckets {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if len(keys) != tt.wantKeys || keys[0] != tt.wantFirst || keys[len(keys)-1] != tt.wantLast {
+				t.Fatalf("buckets %v, want %d from %s to %s", keys, tt.wantKeys, tt.wantFirst, tt.wantLast)
+			}
+
+			// Sorted keys are consecutive interval starts
+			for i, k := range keys {
+				if want := start.Add(time.Duration(i*tt.minutes) * time.Minute).Format("15:04"); k != want {
+					t.Errorf("bucket %d is %s, want %s", i, k, want)
+				}
+				d := buckets[k]
+				// Minutes m..m+minutes-5 in steps of 5
+				m := float64(start.Add(time.Duration(i*tt.minutes) * time.Minute).Minute())
+				wantMax := m + float64(tt.minutes) - 5
+				if d.Count != tt.wantCount || d.MaxPower != wantMax || d.AvgPower != (m+wantMax)/2 {
+					t.Errorf("%s: count %d, max %v, avg %v; want %d, %v, %v", k, d.Count, d.MaxPower, d.AvgPower, tt.wantCount, wantMax, (m+wantMax)/2)
+				}
+			}
+		})
+	}
+
+	// The hourly data is unchanged alongside the buckets
+	hourly := calculateHourlyData(readings, berlin)
+	quarters := calculateBucketData(readings, berlin, 15)
+	for _, h := range []string{"10", "11", "12"} {
+		var count int
+		var total float64
+		for _, q := range []string{"00", "15", "30", "45"} {
+			count += quarters[h+":"+q].Count
+			total += quarters[h+":"+q].TotalPower
+		}
+		if hourly[h].Count != count || hourly[h].TotalPower != total {
+			t.Errorf("hour %s: %d readings, %v kW; quarters sum to %d, %v", h, hourly[h].Count, hourly[h].TotalPower, count, total)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/capacity"
+)
+
+// applyCapacity fills in the headroom between the day's peak facility load and
+// the facility capacity; negative headroom means the load exceeded capacity.
+// The peak load sums every meter's concurrent reading (see capacity.Peak), so
+// it can be well above PeakPower, the highest single reading.
+func applyCapacity(a *DailyAnalytics, readings []Reading, capacityKW float64, stale time.Duration) {
+	if capacityKW <= 0 {
+		return
+	}
+	samples := make([]capacity.Sample, len(readings))
+	for i, r := range readings {
+		samples[i] = capacity.Sample{MeterID: r.MeterID, Time: time.Unix(r.Timestamp, 0), PowerKW: r.PowerKW}
+	}
+	peakLoad, _ := capacity.Peak(samples, stale)
+
+	a.CapacityKW = capacityKW
+	a.PeakLoadKW = peakLoad
+	a.HeadroomKW = capacityKW - a.PeakLoadKW
+	a.HeadroomPercent = a.HeadroomKW / capacityKW * 100
+	a.PeakUtilization = a.PeakLoadKW / capacityKW * 100
+}
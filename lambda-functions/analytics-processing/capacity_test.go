@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyCapacity(t *testing.T) {
+	reading := func(meter string, ts int64, kw float64) Reading {
+		return Reading{MeterID: meter, Timestamp: ts, PowerKW: kw}
+	}
+	tests := []struct {
+		name         string
+		readings     []Reading
+		capacityKW   float64
+		wantPeak     float64
+		wantHeadroom float64
+	}{
+		{
+			name:         "below capacity",
+			readings:     []Reading{reading("m1", 0, 300), reading("m2", 30, 200), reading("m1", 60, 250)},
+			capacityKW:   1000,
+			wantPeak:     500,
+			wantHeadroom: 500,
+		},
+		{
+			// No reading is above 600 kW, but the two meters together reach 950
+			name:         "approaching capacity",
+			readings:     []Reading{reading("m1", 0, 600), reading("m2", 30, 350), reading("m1", 60, 100)},
+			capacityKW:   1000,
+			wantPeak:     950,
+			wantHeadroom: 50,
+		},
+		{
+			name:         "over capacity",
+			readings:     []Reading{reading("m1", 0, 700), reading("m2", 0, 500)},
+			capacityKW:   1000,
+			wantPeak:     1200,
+			wantHeadroom: -200,
+		},
+		{
+			name:         "stale meter not counted",
+			readings:     []Reading{reading("m1", 0, 700), reading("m2", 3600, 500)},
+			capacityKW:   1000,
+			wantPeak:     700,
+			wantHeadroom: 300,
+		},
+		{
+			name:     "capacity unknown",
+			readings: []Reading{reading("m1", 0, 700)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a DailyAnalytics
+			applyCapacity(&a, tt.readings, tt.capacityKW, 2*time.Minute)
+			if a.PeakLoadKW != tt.wantPeak || a.HeadroomKW != tt.wantHeadroom {
+				t.Errorf("peak load, headroom = %v, %v; want %v, %v", a.PeakLoadKW, a.HeadroomKW, tt.wantPeak, tt.wantHeadroom)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+)
+
+// loadCostModel reads COST_MODEL_JSON over the energy-only default
+// (ENERGY_RATE_PER_KWH, peak 09-17). A model that doesn't parse or validate
+// is ignored with a warning so reports keep their energy cost.
+func loadCostModel() costmodel.Model {
+	def := costmodel.Default(envFloat("ENERGY_RATE_PER_KWH", 0.20), 0)
+	raw := os.Getenv("COST_MODEL_JSON")
+	if raw == "" {
+		return def
+	}
+	m, err := costmodel.Parse([]byte(raw), def)
+	if err != nil {
+		fmt.Printf("WARN: ignoring COST_MODEL_JSON: %v\n", err)
+		return def
+	}
+	return m
+}
+
+// priceDay prices 24 local-hour kWh buckets. An hour the converter can't
+// price (negative or non-finite energy) is left out with a warning.
+func priceDay(m costmodel.Model, hourlyKWh []float64) costmodel.Breakdown {
+	priced := make([]float64, len(hourlyKWh))
+	for h, kwh := range hourlyKWh {
+		if kwh < 0 || math.IsNaN(kwh) || math.IsInf(kwh, 0) {
+			fmt.Printf("WARN: not pricing hour %02d: invalid energy quantity %g\n", h, kwh)
+			continue
+		}
+		priced[h] = kwh
+	}
+	b, err := m.CostHourly(priced)
+	if err != nil {
+		fmt.Printf("WARN: failed to price day: %v\n", err)
+		return costmodel.Breakdown{}
+	}
+	return b
+}
+
+// hourlyEnergy spreads the day's time-weighted kWh over local hours in
+// proportion to each hour's summed kW samples
+func hourlyEnergy(hourly map[string]HourlyData, totalKWh float64) []float64 {
+	out := make([]float64, 24)
+	var sampleSum float64
+	for _, d := range hourly {
+		sampleSum += d.TotalPower
+	}
+	if sampleSum <= 0 {
+		return out
+	}
+	for h := 0; h < 24; h++ {
+		out[h] = totalKWh * hourly[fmt.Sprintf("%02d", h)].TotalPower / sampleSum
+	}
+	return out
+}
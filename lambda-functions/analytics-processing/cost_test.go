@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceDay(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		hourly    map[int]float64
+		wantTotal float64
+	}{
+		// 10 kWh at 09:00 is peak: 10 * 0.2 * 1.5
+		{name: "default model", hourly: map[int]float64{9: 10}, wantTotal: 3},
+		{name: "negative hour left out", hourly: map[int]float64{9: 10, 2: -4}, wantTotal: 3},
+		{name: "non-finite hour left out", hourly: map[int]float64{9: 10, 2: math.NaN()}, wantTotal: 3},
+		{name: "COST_MODEL_JSON fixed charge", env: `{"fixed_charges": [{"name": "meter", "amount": 1.25}]}`, hourly: map[int]float64{9: 10}, wantTotal: 4.25},
+		{name: "invalid COST_MODEL_JSON ignored", env: `{"demand_charge_per_kw": -1}`, hourly: map[int]float64{9: 10}, wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENERGY_RATE_PER_KWH", "0.2")
+			t.Setenv("COST_MODEL_JSON", tt.env)
+			hourly := make([]float64, 24)
+			for h, kwh := range tt.hourly {
+				hourly[h] = kwh
+			}
+			if got := priceDay(loadCostModel(), hourly); got.TotalCost != tt.wantTotal {
+				t.Errorf("TotalCost = %v, want %v", got.TotalCost, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/aggregator"
+)
+
+// TimeWeightedEnergy integrates power (kW) over time with the trapezoidal rule
+// and returns the energy in kWh. Unlike aggregator.Sum it weights each reading
+// by the interval it covers, so irregular sampling doesn't skew the total.
+// Points need not be sorted; fewer than two points yield 0.
+func TimeWeightedEnergy(points []aggregator.Point) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	sorted := make([]aggregator.Point, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var kwh float64
+	for i := 1; i < len(sorted); i++ {
+		hours := sorted[i].Timestamp.Sub(sorted[i-1].Timestamp).Hours()
+		kwh += (sorted[i].Value + sorted[i-1].Value) / 2 * hours
+	}
+	return kwh
+}
+
+// MeterEnergy is the energy in kWh of readings from any number of meters:
+// each meter's readings are integrated with TimeWeightedEnergy and the results
+// summed. Integrating the facility's interleaved readings as one series would
+// average the meters rather than add them up. A meter with a single reading
+// spans no interval and counts as one sampling interval (SampledEnergy).
+func MeterEnergy(readings []Reading, intervalSeconds float64) float64 {
+	byMeter := make(map[string][]aggregator.Point)
+	for _, r := range readings {
+		byMeter[r.MeterID] = append(byMeter[r.MeterID], aggregator.Point{Value: r.PowerKW, Timestamp: time.Unix(r.Timestamp, 0)})
+	}
+
+	var kwh float64
+	for _, points := range byMeter {
+		if len(points) < 2 {
+			kwh += SampledEnergy(aggregator.Sum(points), intervalSeconds)
+			continue
+		}
+		kwh += TimeWeightedEnergy(points)
+	}
+	return kwh
+}
+
+// SampledEnergy converts a sum of kW samples to kWh assuming each reading
+// represents intervalSeconds of constant power (SAMPLING_INTERVAL_SECONDS).
+// Non-positive intervals are treated as one hour.
+func SampledEnergy(sum, intervalSeconds float64) float64 {
+	if intervalSeconds <= 0 {
+		return sum
+	}
+	return sum * intervalSeconds / 3600
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// steady returns hourly readings of meter at kw from t0 through t0+hours
+func steady(meter string, kw float64, t0 int64, hours int) []Reading {
+	var rs []Reading
+	for h := 0; h <= hours; h++ {
+		rs = append(rs, Reading{MeterID: meter, Timestamp: t0 + int64(h)*3600, PowerKW: kw})
+	}
+	return rs
+}
+
+// interleave merges the readings oldest first, as a facility query returns them
+func interleave(series ...[]Reading) []Reading {
+	var out []Reading
+	for i := 0; ; i++ {
+		added := false
+		for _, s := range series {
+			if i < len(s) {
+				out = append(out, s[i])
+				added = true
+			}
+		}
+		if !added {
+			return out
+		}
+	}
+}
+
+func TestMeterEnergy(t *testing.T) {
+	const t0 = 1700000000
+	tests := []struct {
+		name     string
+		readings []Reading
+		interval float64
+		want     float64
+	}{
+		{"no readings", nil, 3600, 0},
+		{"one meter", steady("1", 10, t0, 24), 3600, 240},
+		{"two meters interleaved are summed", interleave(steady("1", 10, t0, 24), steady("2", 10, t0, 24)), 3600, 480},
+		{"three meters of different load", interleave(steady("1", 10, t0, 24), steady("2", 5, t0, 24), steady("3", 1, t0, 24)), 3600, 384},
+		{"meter offset by half an hour", interleave(steady("1", 10, t0, 24), steady("2", 10, t0+1800, 24)), 3600, 480},
+		{"single reading counts one interval", []Reading{{MeterID: "1", Timestamp: t0, PowerKW: 6}}, 900, 1.5},
+		{"single-reading meter beside a full one", append(steady("1", 10, t0, 24), Reading{MeterID: "2", Timestamp: t0, PowerKW: 4}), 3600, 244},
+		{"ramp is integrated by trapezoid", []Reading{{MeterID: "1", Timestamp: t0, PowerKW: 0}, {MeterID: "1", Timestamp: t0 + 7200, PowerKW: 10}}, 3600, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MeterEnergy(tt.readings, tt.interval); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MeterEnergy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateDailyAnalyticsSumsMeters(t *testing.T) {
+	t.Setenv("SAMPLING_INTERVAL_SECONDS", "3600")
+	readings := interleave(steady("1", 10, 1700000000, 24), steady("2", 10, 1700000000, 24))
+	a := calculateDailyAnalytics(readings, "2023-11-14", time.UTC, 60)
+	if math.Abs(a.TotalConsumption-480) > 1e-9 {
+		t.Errorf("TotalConsumption = %v, want 480", a.TotalConsumption)
+	}
+}
+
+func TestSampledEnergy(t *testing.T) {
+	tests := []struct {
+		name     string
+		sum      float64
+		interval float64
+		want     float64
+	}{
+		// A day of steady 10 kW is 240 kWh whatever the cadence
+		{"hourly", 24 * 10, 3600, 240},
+		{"1-minute", 1440 * 10, 60, 240},
+		{"15-minute", 96 * 10, 900, 240},
+		{"non-positive interval treated as hourly", 240, 0, 240},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SampledEnergy(tt.sum, tt.interval); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SampledEnergy(%v, %v) = %v, want %v", tt.sum, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// EventBridge source and detail-type of the event emitted after each run
+const (
+	eventSource             = "energy-grid.analytics"
+	analyticsGeneratedEvent = "AnalyticsGenerated"
+)
+
+// eventPublisher is the subset of the EventBridge client used here
+type eventPublisher interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// AnalyticsGeneratedDetail is the detail of the AnalyticsGenerated event
+type AnalyticsGeneratedDetail struct {
+	FacilityID       string  `json:"facility_id"`
+	Date             string  `json:"date"`
+	ReportURL        string  `json:"report_url,omitempty"`
+	ReadingCount     int     `json:"reading_count"`
+	TotalConsumption float64 `json:"total_consumption"`
+	PeakPower        float64 `json:"peak_power"`
+	GeneratedAt      string  `json:"generated_at"`
+}
+
+// publishAnalyticsGenerated emits an AnalyticsGenerated event on bus so other
+// systems can react to a new summary/report without polling S3
+func publishAnalyticsGenerated(ctx context.Context, client eventPublisher, bus, facilityID, date, reportURL string, analytics DailyAnalytics) error {
+	detail, err := json.Marshal(AnalyticsGeneratedDetail{
+		FacilityID:       facilityID,
+		Date:             date,
+		ReportURL:        reportURL,
+		ReadingCount:     analytics.ReadingCount,
+		TotalConsumption: analytics.TotalConsumption,
+		PeakPower:        analytics.PeakPower,
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal event detail: %w", err)
+	}
+
+	out, err := client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{{
+			EventBusName: aws.String(bus),
+			Source:       aws.String(eventSource),
+			DetailType:   aws.String(analyticsGeneratedEvent),
+			Detail:       aws.String(string(detail)),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("put events: %w", err)
+	}
+	// PutEvents reports per-entry failures in the response rather than as an error
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("put events: %s: %s", aws.ToString(out.Entries[0].ErrorCode), aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// fakeEvents records PutEvents input and answers with out or err
+type fakeEvents struct {
+	inputs []*eventbridge.PutEventsInput
+	out    *eventbridge.PutEventsOutput
+	err    error
+}
+
+func (f *fakeEvents) PutEvents(_ context.Context, in *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.out != nil {
+		return f.out, nil
+	}
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+func TestPublishAnalyticsGenerated(t *testing.T) {
+	analytics := DailyAnalytics{ReadingCount: 96, TotalConsumption: 240.5, PeakPower: 18.2}
+	tests := []struct {
+		name    string
+		client  *fakeEvents
+		wantErr string
+	}{
+		{name: "published", client: &fakeEvents{}},
+		{name: "request error", client: &fakeEvents{err: errors.New("access denied")}, wantErr: "access denied"},
+		{
+			name: "entry rejected",
+			client: &fakeEvents{out: &eventbridge.PutEventsOutput{
+				FailedEntryCount: 1,
+				Entries:          []ebtypes.PutEventsResultEntry{{ErrorCode: aws.String("NotAuthorized"), ErrorMessage: aws.String("no access to bus")}},
+			}},
+			wantErr: "NotAuthorized: no access to bus",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := publishAnalyticsGenerated(context.Background(), tt.client, "grid-events", "facility-001", "2024-03-01",
+				"s3://reports/facility-001/2024-03-01.html", analytics)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+
+			if len(tt.client.inputs) != 1 || len(tt.client.inputs[0].Entries) != 1 {
+				t.Fatalf("PutEvents inputs = %+v, want one entry", tt.client.inputs)
+			}
+			entry := tt.client.inputs[0].Entries[0]
+			if aws.ToString(entry.EventBusName) != "grid-events" || aws.ToString(entry.Source) != eventSource ||
+				aws.ToString(entry.DetailType) != analyticsGeneratedEvent {
+				t.Errorf("entry bus, source, type = %s, %s, %s", aws.ToString(entry.EventBusName), aws.ToString(entry.Source), aws.ToString(entry.DetailType))
+			}
+
+			var detail AnalyticsGeneratedDetail
+			if err := json.Unmarshal([]byte(aws.ToString(entry.Detail)), &detail); err != nil {
+				t.Fatalf("detail doesn't parse: %v", err)
+			}
+			if detail.FacilityID != "facility-001" || detail.Date != "2024-03-01" || detail.ReportURL != "s3://reports/facility-001/2024-03-01.html" ||
+				detail.ReadingCount != 96 || detail.TotalConsumption != 240.5 || detail.PeakPower != 18.2 {
+				t.Errorf("detail = %+v", detail)
+			}
+			if _, err := time.Parse(time.RFC3339, detail.GeneratedAt); err != nil {
+				t.Errorf("generated_at %q: %v", detail.GeneratedAt, err)
+			}
+		})
+	}
+}
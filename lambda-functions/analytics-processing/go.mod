@@ -8,11 +8,14 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.31.17
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.20.21
 	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.52.4
+	github.com/aws/aws-sdk-go-v2/service/eventbridge v1.45.12
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.90.0
+	github.com/go-pdf/fpdf v0.9.0
 )
 
 require (
 	github.com/ANIKETSHETTY47/energy-grid-analytics-go v1.0.0
+	github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg v0.0.0
 	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.7.3 // indirect
 	github.com/aws/aws-sdk-go-v2/credentials v1.18.21 // indirect
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.18.13 // indirect
@@ -31,3 +34,5 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sts v1.39.1 // indirect
 	github.com/aws/smithy-go v1.23.2 // indirect
 )
+
+replace github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg => ../../pkg
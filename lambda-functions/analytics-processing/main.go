@@ -9,18 +9,21 @@ import (
 	"math"
 	"net/url"
 	"os"
-	"sort"
 	"strconv"
 	"time"
 
 	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/aggregator"
 	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/converter"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/capacity"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/costmodel"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -31,6 +34,19 @@ var (
 	tableAnalytics string
 	s3Bucket       string
 	defaultCtx     = context.Background()
+
+	recommendationRules []Rule
+	capacities          capacity.Capacities
+
+	// Pricing for the report's cost figures (ENERGY_RATE_PER_KWH, COST_MODEL_JSON)
+	costs costmodel.Model
+
+	// Facility metadata (time zone); see facilityLocation
+	tableFacilities string
+
+	// Optional AnalyticsGenerated events; disabled unless EVENTBRIDGE_BUS is set
+	eventsClient eventPublisher
+	eventBus     string
 )
 
 type Reading struct {
@@ -40,6 +56,14 @@ type Reading struct {
 	Voltage    float64 `dynamodbav:"voltage"`
 	Current    float64 `dynamodbav:"current"`
 	PowerKW    float64 `dynamodbav:"powerKw"`
+
+	// Optional power-quality attributes from newer meters
+	ReactivePowerKVAR *float64 `dynamodbav:"reactivePowerKvar"`
+	FrequencyHz       *float64 `dynamodbav:"frequencyHz"`
+	THDPercent        *float64 `dynamodbav:"thdPercent"`
+
+	// Estimated marks a reading interpolated into a gap; see fillGaps
+	Estimated bool `dynamodbav:"-" json:"estimated,omitempty"`
 }
 
 type HourlyData struct {
@@ -50,28 +74,66 @@ type HourlyData struct {
 }
 
 type DailyAnalytics struct {
-	Date                string                `json:"date"`
-	ReadingCount        int                   `json:"reading_count"`
-	TotalConsumption    float64               `json:"total_consumption"`
-	TotalConsumptionMWh float64               `json:"total_consumption_mwh"`
-	AveragePower        float64               `json:"average_power"`
-	PeakPower           float64               `json:"peak_power"`
-	MinPower            float64               `json:"min_power"`
-	MovingAverage       []float64             `json:"moving_average"`
-	EstimatedCost       float64               `json:"estimated_cost"`
-	CostBreakdown       map[string]float64    `json:"cost_breakdown"`
-	AvgVoltage          float64               `json:"avg_voltage"`
-	VoltageStdDev       float64               `json:"voltage_stddev"`
-	AvgCurrent          float64               `json:"avg_current"`
-	PowerFactor         float64               `json:"power_factor"`
-	PeakHour            string                `json:"peak_hour"`
-	HourlyData          map[string]HourlyData `json:"hourly_data"`
-	CreatedAt           int64                 `dynamodbav:"createdAt" json:"created_at"`
+	Date                string                 `json:"date"`
+	Timezone            string                 `json:"timezone"`
+	ReadingCount        int                    `json:"reading_count"`
+	TotalConsumption    float64                `json:"total_consumption"`
+	TotalConsumptionMWh float64                `json:"total_consumption_mwh"`
+	PowerSampleSum      float64                `json:"power_sample_sum"`
+	SampledConsumption  float64                `json:"sampled_consumption"`
+	AveragePower        float64                `json:"average_power"`
+	PeakPower           float64                `json:"peak_power"`
+	MinPower            float64                `json:"min_power"`
+	MovingAverage       []float64              `json:"moving_average"`
+	EstimatedCost       float64                `json:"estimated_cost"`
+	CostBreakdown       map[string]float64     `json:"cost_breakdown"`
+	AvgVoltage          float64                `json:"avg_voltage"`
+	VoltageStdDev       float64                `json:"voltage_stddev"`
+	AvgCurrent          float64                `json:"avg_current"`
+	PowerFactor         float64                `json:"power_factor"`
+	PowerFactorMethod   string                 `json:"power_factor_method"`
+	AvgReactivePower    float64                `json:"avg_reactive_power_kvar,omitempty"`
+	AvgFrequency        float64                `json:"avg_frequency_hz,omitempty"`
+	AvgTHD              float64                `json:"avg_thd_percent,omitempty"`
+	PeakHour            string                 `json:"peak_hour"`
+	PeakHourCandidates  []PeakHourCandidate    `json:"peak_hour_candidates"`
+	PeakHourConfidence  string                 `json:"peak_hour_confidence"`
+	PeakHourMargin      float64                `json:"peak_hour_margin_percent"`
+	HourlyData          map[string]HourlyData  `json:"hourly_data"`
+	GranularityMinutes  int                    `json:"granularity_minutes"`
+	BucketData          map[string]BucketStats `json:"bucket_data"`
+	CapacityKW          float64                `json:"capacity_kw,omitempty"`
+	PeakLoadKW          float64                `json:"peak_load_kw,omitempty"`
+	HeadroomKW          float64                `json:"headroom_kw,omitempty"`
+	HeadroomPercent     float64                `json:"headroom_percent,omitempty"`
+	PeakUtilization     float64                `json:"peak_utilization_percent,omitempty"`
+	CoveragePercent     float64                `json:"coverage_percent"`
+	DataGaps            int                    `json:"data_gaps"`
+	FilteredReadings    int                    `json:"filtered_readings"`
+	Confidence          string                 `json:"confidence"`
+	RawPeakPower        float64                `json:"raw_peak_power,omitempty"`
+	WinsorizedReadings  int                    `json:"winsorized_readings,omitempty"`
+	Sampled             bool                   `json:"sampled,omitempty"`
+	SampleSize          int                    `json:"sample_size,omitempty"`
+	SampleRate          float64                `json:"sample_rate,omitempty"`
+	FilledGaps          int                    `json:"filled_gaps,omitempty"`
+	EstimatedReadings   int                    `json:"estimated_readings,omitempty"`
+	EstimatedKWh        float64                `json:"estimated_consumption,omitempty"`
+	Source              string                 `json:"source,omitempty"` // s3_archive when read from the archive
+	CreatedAt           int64                  `dynamodbav:"createdAt" json:"created_at"`
 }
 
 type LambdaEvent struct {
 	Date       string `json:"date"`        // YYYY-MM-DD (optional; defaults to yesterday)
 	FacilityID string `json:"facility_id"` // optional; defaults to facility-001
+	Format     string `json:"format"`      // report format: json (default) or pdf
+
+	// Interval of the bucket data: 15, 30 or 60 minutes (optional; see parseGranularity)
+	GranularityMinutes int `json:"granularity_minutes"`
+
+	// Analyse a stride sample of a large day instead of every reading (optional;
+	// defaults to ANALYTICS_SAMPLE, off). See strideSample.
+	Sample *bool `json:"sample"`
 }
 
 type LambdaResponse struct {
@@ -98,28 +160,61 @@ func init() {
 	// Env-driven names with safe defaults
 	tableReadings = getenv("DDB_TABLE_READINGS", "EnergyReadings")
 	tableAnalytics = getenv("DDB_TABLE_ANALYTICS", "AnalyticsSummaries")
+	tableFacilities = getenv("DDB_TABLE_FACILITIES", "Facilities")
 	s3Bucket = getenv("S3_BUCKET", "energy-grid-reports")
+	recommendationRules = loadRules()
+	if capacities, err = capacity.Parse(os.Getenv("FACILITY_CAPACITY_KW")); err != nil {
+		fmt.Printf("WARN: %v\n", err)
+	}
+	costs = loadCostModel()
+	if eventBus = os.Getenv("EVENTBRIDGE_BUS"); eventBus != "" {
+		eventsClient = eventbridge.NewFromConfig(cfg)
+	}
 
 	fmt.Printf("Cold start: ReadingsTable=%s AnalyticsTable=%s S3Bucket=%s\n",
 		tableReadings, tableAnalytics, s3Bucket)
 }
 
 func Handler(ctx context.Context, event LambdaEvent) (LambdaResponse, error) {
-	date := event.Date
-	if date == "" {
-		date = time.Now().AddDate(0, 0, -1).Format("2006-01-02") // default: yesterday
-	}
 	facilityID := event.FacilityID
 	if facilityID == "" {
 		facilityID = "facility-001"
 	}
+	// Days, hours and the peak hour are in the facility's local time
+	loc := facilityLocation(ctx, facilityID)
+	date := event.Date
+	if date == "" {
+		date = time.Now().In(loc).AddDate(0, 0, -1).Format("2006-01-02") // default: yesterday
+	}
+	dayStart, dayEnd, err := dayBounds(date, loc)
+	if err != nil {
+		return fail(400, err)
+	}
+	format, err := parseReportFormat(event.Format)
+	if err != nil {
+		return fail(400, err)
+	}
+	granularity, err := parseGranularity(event.GranularityMinutes)
+	if err != nil {
+		return fail(400, err)
+	}
 
-	fmt.Printf("Start daily aggregation: facility=%s date=%s\n", facilityID, date)
+	fmt.Printf("Start daily aggregation: facility=%s date=%s tz=%s\n", facilityID, date, loc)
 
-	readings, err := getReadingsForDate(ctx, facilityID, date, 2000) // sensible cap; paginate if needed
+	readings, err := getReadingsForDate(ctx, facilityID, dayStart, dayEnd, 2000) // sensible cap; paginate if needed
 	if err != nil {
 		return fail(500, err)
 	}
+	// Past retention, DynamoDB has expired the day's readings; the S3 archive may still have them
+	source := ""
+	if len(readings) == 0 && archiveFallbackEnabled() && beyondRetention(dayStart, time.Now()) {
+		fmt.Printf("No readings in DynamoDB for %s beyond retention; reading the S3 archive\n", date)
+		readings, err = getArchivedReadings(ctx, facilityID, dayStart, dayEnd)
+		if err != nil {
+			return fail(500, err)
+		}
+		source = sourceArchive
+	}
 	if len(readings) == 0 {
 		return ok(map[string]interface{}{
 			"message": "No data to process",
@@ -127,18 +222,69 @@ func Handler(ctx context.Context, event LambdaEvent) (LambdaResponse, error) {
 		})
 	}
 
-	analytics := calculateDailyAnalytics(readings, date)
+	// Corrupt readings are left out of the analytics but lower its confidence
+	readings, filtered := filterReadings(readings)
+	if len(readings) == 0 {
+		return ok(map[string]interface{}{
+			"message":           "No valid data to process",
+			"date":              date,
+			"filtered_readings": filtered,
+		})
+	}
+
+	// Optionally clamp glitch spikes to the 1st/99th percentile so the headline
+	// peak and average stay robust; the unclamped peak is reported separately
+	winsor := getenv("WINSORIZE", "false") == "true"
+	analyticsReadings, clamped := readings, 0
+	if winsor {
+		analyticsReadings, clamped = winsorize(readings, winsorLowerPercentile, winsorUpperPercentile)
+	}
+
+	// Optionally fill short gaps with interpolated readings; coverage and gaps
+	// below are still measured on the real readings
+	interval := envFloat("SAMPLING_INTERVAL_SECONDS", 3600)
+	var fill gapFill
+	if maxGap := gapFillMax(); maxGap > 0 {
+		analyticsReadings, fill = fillGaps(analyticsReadings, interval, maxGap)
+	}
+
+	// Sample mode bounds the work on very large days; coverage and gaps below
+	// still look at every reading
+	all, stride := analyticsReadings, 1
+	if sampleEnabled(event) {
+		analyticsReadings, stride = strideSample(analyticsReadings, envInt("ANALYTICS_SAMPLE_MAX_READINGS", defaultSampleMaxReadings))
+	}
+
+	analytics := calculateDailyAnalytics(analyticsReadings, date, loc, granularity)
+	applySampling(&analytics, all, stride)
+	applyGapFill(&analytics, fill)
+	if winsor {
+		analytics.RawPeakPower = maxPower(readings)
+		analytics.WinsorizedReadings = clamped
+	}
+	analytics.Source = source
+	// A meter that hasn't reported for two intervals no longer counts toward facility load
+	capacityStale := time.Duration(envFloat("CAPACITY_STALE_SECONDS", 2*interval) * float64(time.Second))
+	applyCapacity(&analytics, all, capacities.For(facilityID), capacityStale)
+	applyDataQuality(&analytics, readings, filtered, dayStart, dayEnd, interval)
 
 	if err := storeAnalyticsSummary(ctx, facilityID, analytics); err != nil {
 		// Non-fatal: continue to S3 report so the day isn’t lost
 		fmt.Printf("WARN storeAnalyticsSummary: %v\n", err)
 	}
 
-	reportURL, err := generateReport(ctx, facilityID, date, analytics)
+	reportURL, err := generateReport(ctx, facilityID, date, format, analytics)
 	if err != nil {
 		fmt.Printf("WARN generateReport: %v\n", err)
 	}
 
+	if eventsClient != nil {
+		if err := publishAnalyticsGenerated(ctx, eventsClient, eventBus, facilityID, date, reportURL, analytics); err != nil {
+			// Non-fatal: the summary and report are already stored
+			fmt.Printf("WARN publishAnalyticsGenerated: %v\n", err)
+		}
+	}
+
 	return ok(map[string]interface{}{
 		"message":    "Analytics processed successfully",
 		"date":       date,
@@ -160,16 +306,10 @@ func fail(code int, err error) (LambdaResponse, error) {
 
 // --- Data access ---
 
-// getReadingsForDate queries all readings for the facility within the day, handling pagination.
-func getReadingsForDate(ctx context.Context, facilityID, date string, pageLimit int32) ([]Reading, error) {
-	startOfDay, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return nil, fmt.Errorf("bad date format %q: %w", date, err)
-	}
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
+// getReadingsForDate queries all readings for the facility within [startOfDay, endOfDay), handling pagination.
+func getReadingsForDate(ctx context.Context, facilityID string, startOfDay, endOfDay time.Time, pageLimit int32) ([]Reading, error) {
 	startTS := startOfDay.Unix()
-	endTS := endOfDay.Unix()
+	endTS := endOfDay.Unix() - 1 // BETWEEN is inclusive
 
 	var (
 		all       []Reading
@@ -222,61 +362,130 @@ func getReadingsForDate(ctx context.Context, facilityID, date string, pageLimit
 
 // --- Analytics ---
 
-func calculateDailyAnalytics(readings []Reading, date string) DailyAnalytics {
+func calculateDailyAnalytics(readings []Reading, date string, loc *time.Location, granularity int) DailyAnalytics {
 	points := make([]aggregator.Point, len(readings))
 	for i, r := range readings {
 		points[i] = aggregator.Point{Value: r.PowerKW, Timestamp: time.Unix(r.Timestamp, 0)}
 	}
 
-	totalPower := aggregator.Sum(points)
+	// Consumption integrates each meter's power over time; the plain sum of kW
+	// samples is kept alongside for comparison with earlier reports
+	interval := envFloat("SAMPLING_INTERVAL_SECONDS", 3600)
+	totalPower := MeterEnergy(readings, interval)
+	sampleSum := aggregator.Sum(points)
+	sampledKWh := SampledEnergy(sampleSum, interval)
 	avgPower := safeAverage(points)
 	movingAvg := aggregator.MovingAverage(points, 12) // configurable if needed
 
 	conv := &converter.EnergyConverter{}
 	totalConsumptionMWh := conv.KWhToMWh(totalPower)
 
-	// Simple cost model—tune as needed
-	peakCost := conv.CalculateCost(totalPower*0.4, 0.20, "peak")
-	offPeakCost := conv.CalculateCost(totalPower*0.6, 0.20, "offpeak")
-	totalCost := peakCost + offPeakCost
-
 	peak, min := findMaxMin(points)
-	hourly := calculateHourlyData(readings)
-	peakHour := derivePeakHour(hourly)
+	hourly := calculateHourlyData(readings, loc)
+	cost := priceDay(costs, hourlyEnergy(hourly, totalPower))
+	// The peak hour ("HH") is the top candidate; the runner-up's gap says how clear it is
+	peakHours := rankPeakHours(hourly, peakHourCandidateCount)
+	peakHour := ""
+	if len(peakHours) > 0 {
+		peakHour = peakHours[0].Hour
+	}
+	peakConfidence, peakMargin := peakHourConfidence(peakHours, envFloat("PEAK_HOUR_MARGIN_PERCENT", 10))
 
 	avgV := averageFloat(func(i int) float64 { return readings[i].Voltage }, len(readings))
 	avgI := averageFloat(func(i int) float64 { return readings[i].Current }, len(readings))
 	voltageStd := stddevFloat(func(i int) float64 { return readings[i].Voltage }, len(readings), avgV)
 
-	// Apparent power ≈ V * I (average); avoid negative/NaN
-	apparent := max0(avgV * avgI)
-	powerFactor := 0.0
-	if apparent > 0 {
-		powerFactor = conv.CalculateEfficiency(apparent, avgPower)
-	}
+	// POWER_FACTOR_METHOD picks reported kVAR or a single/three-phase V * I estimate
+	pf := computePowerQuality(readings)
+	powerFactor, pfMethod := computePowerFactor(powerFactorMethod(), avgPower, avgV, avgI, pf)
 
 	return DailyAnalytics{
 		Date:                date,
+		Timezone:            loc.String(),
 		ReadingCount:        len(readings),
-		TotalConsumption:    round2(totalPower),
-		TotalConsumptionMWh: round3(totalConsumptionMWh),
-		AveragePower:        round2(avgPower),
-		PeakPower:           round2(peak),
-		MinPower:            round2(min),
-		MovingAverage:       roundSlice(movingAvg, 2),
-		EstimatedCost:       round2(totalCost),
+		TotalConsumption:    numfmt.SanitizeFloat(totalPower),
+		TotalConsumptionMWh: numfmt.SanitizeFloat(totalConsumptionMWh),
+		PowerSampleSum:      numfmt.SanitizeFloat(sampleSum),
+		SampledConsumption:  numfmt.SanitizeFloat(sampledKWh),
+		AveragePower:        numfmt.SanitizeFloat(avgPower),
+		PeakPower:           numfmt.SanitizeFloat(peak),
+		MinPower:            numfmt.SanitizeFloat(min),
+		MovingAverage:       movingAvg,
+		EstimatedCost:       cost.TotalCost,
 		CostBreakdown: map[string]float64{
-			"peak":    round2(peakCost),
-			"offpeak": round2(offPeakCost),
+			"peak":    cost.PeakCost,
+			"offpeak": cost.OffPeakCost,
+			"demand":  cost.DemandCharge,
+			"fixed":   cost.FixedTotal(),
+			"taxes":   cost.TaxTotal(),
 		},
-		AvgVoltage:    round2(avgV),
-		VoltageStdDev: round3(voltageStd),
-		AvgCurrent:    round2(avgI),
-		PowerFactor:   round3(powerFactor),
+		AvgVoltage:    numfmt.SanitizeFloat(avgV),
+		VoltageStdDev: numfmt.SanitizeFloat(voltageStd),
+		AvgCurrent:    numfmt.SanitizeFloat(avgI),
+		PowerFactor:   numfmt.SanitizeFloat(powerFactor),
 		PeakHour:      peakHour,
 		HourlyData:    hourly,
 		CreatedAt:     time.Now().Unix(),
+
+		PeakHourCandidates: peakHours,
+		PeakHourConfidence: peakConfidence,
+		PeakHourMargin:     numfmt.SanitizeFloat(peakMargin),
+
+		GranularityMinutes: granularity,
+		BucketData:         calculateBucketData(readings, loc, granularity),
+
+		PowerFactorMethod: pfMethod,
+		AvgReactivePower:  numfmt.SanitizeFloat(pf.AvgReactive),
+		AvgFrequency:      numfmt.SanitizeFloat(pf.AvgFrequency),
+		AvgTHD:            numfmt.SanitizeFloat(pf.AvgTHD),
+	}
+}
+
+// powerQuality summarizes the optional power-quality attributes of a day's readings
+type powerQuality struct {
+	HasReactive  bool
+	PowerFactor  float64
+	AvgReactive  float64
+	AvgFrequency float64
+	AvgTHD       float64
+}
+
+// computePowerQuality derives the true power factor P / sqrt(P² + Q²) from the
+// readings that report reactive power, and averages frequency and THD where present
+func computePowerQuality(readings []Reading) powerQuality {
+	var pq powerQuality
+	var sumP, sumQ, sumF, sumTHD float64
+	var nQ, nF, nTHD int
+	for _, r := range readings {
+		if r.ReactivePowerKVAR != nil {
+			sumP += r.PowerKW
+			sumQ += *r.ReactivePowerKVAR
+			nQ++
+		}
+		if r.FrequencyHz != nil {
+			sumF += *r.FrequencyHz
+			nF++
+		}
+		if r.THDPercent != nil {
+			sumTHD += *r.THDPercent
+			nTHD++
+		}
+	}
+
+	if nQ > 0 {
+		pq.HasReactive = true
+		pq.AvgReactive = sumQ / float64(nQ)
+		if s := math.Hypot(sumP, sumQ); s > 0 {
+			pq.PowerFactor = math.Abs(sumP) / s
+		}
+	}
+	if nF > 0 {
+		pq.AvgFrequency = sumF / float64(nF)
+	}
+	if nTHD > 0 {
+		pq.AvgTHD = sumTHD / float64(nTHD)
 	}
+	return pq
 }
 
 func safeAverage(points []aggregator.Point) float64 {
@@ -303,46 +512,11 @@ func findMaxMin(points []aggregator.Point) (maxVal, minVal float64) {
 	return
 }
 
-func calculateHourlyData(readings []Reading) map[string]HourlyData {
-	hourly := make(map[string]HourlyData, 24)
-	for _, r := range readings {
-		h := time.Unix(r.Timestamp, 0).Format("15") // "00".."23"
-		data := hourly[h]
-		data.Count++
-		data.TotalPower += r.PowerKW
-		if r.PowerKW > data.MaxPower {
-			data.MaxPower = r.PowerKW
-		}
-		hourly[h] = data
-	}
-	for h, d := range hourly {
-		if d.Count > 0 {
-			d.AvgPower = d.TotalPower / float64(d.Count)
-			hourly[h] = d
-		}
-	}
-	return hourly
-}
-
-func derivePeakHour(hourly map[string]HourlyData) string {
-	if len(hourly) == 0 {
-		return ""
-	}
-	type kv struct {
-		hour string
-		max  float64
-	}
-	var arr []kv
-	for h, d := range hourly {
-		arr = append(arr, kv{h, d.MaxPower})
-	}
-	sort.Slice(arr, func(i, j int) bool {
-		if arr[i].max == arr[j].max {
-			return arr[i].hour < arr[j].hour
-		}
-		return arr[i].max > arr[j].max
+// calculateHourlyData buckets readings by local hour of day in loc
+func calculateHourlyData(readings []Reading, loc *time.Location) map[string]HourlyData {
+	return bucketReadings(readings, func(t time.Time) string {
+		return t.In(loc).Format("15") // "00".."23"
 	})
-	return arr[0].hour // "HH"
 }
 
 func averageFloat(get func(i int) float64, n int) float64 {
@@ -368,29 +542,6 @@ func stddevFloat(get func(i int) float64, n int, mean float64) float64 {
 	return math.Sqrt(v / float64(n))
 }
 
-func round2(x float64) float64 { return math.Round(x*100) / 100 }
-func round3(x float64) float64 { return math.Round(x*1000) / 1000 }
-func roundSlice(xs []float64, places int) []float64 {
-	if xs == nil {
-		return nil
-	}
-	out := make([]float64, len(xs))
-	k := math.Pow10(places)
-	for i, v := range xs {
-		out[i] = math.Round(v*k) / k
-	}
-	return out
-}
-func max0(x float64) float64 {
-	if x < 0 {
-		return 0
-	}
-	if math.IsNaN(x) || math.IsInf(x, 0) {
-		return 0
-	}
-	return x
-}
-
 // --- Persistence & reporting ---
 
 func storeAnalyticsSummary(ctx context.Context, facilityID string, analytics DailyAnalytics) error {
@@ -405,6 +556,8 @@ func storeAnalyticsSummary(ctx context.Context, facilityID string, analytics Dai
 		"readingCount":        analytics.ReadingCount,
 		"totalConsumption":    analytics.TotalConsumption,
 		"totalConsumptionMWh": analytics.TotalConsumptionMWh,
+		"powerSampleSum":      analytics.PowerSampleSum,
+		"sampledConsumption":  analytics.SampledConsumption,
 		"averagePower":        analytics.AveragePower,
 		"peakPower":           analytics.PeakPower,
 		"minPower":            analytics.MinPower,
@@ -413,8 +566,26 @@ func storeAnalyticsSummary(ctx context.Context, facilityID string, analytics Dai
 		"avgCurrent":          analytics.AvgCurrent,
 		"powerFactor":         analytics.PowerFactor,
 		"peakHour":            analytics.PeakHour,
+		"peakHourCandidates":  analytics.PeakHourCandidates,
+		"peakHourConfidence":  analytics.PeakHourConfidence,
+		"peakHourMargin":      analytics.PeakHourMargin,
 		"hourlyData":          analytics.HourlyData,
+		"granularityMinutes":  analytics.GranularityMinutes,
+		"bucketData":          analytics.BucketData,
+		"coveragePercent":     analytics.CoveragePercent,
+		"confidence":          analytics.Confidence,
 		"createdAt":           analytics.CreatedAt,
+		"timezone":            analytics.Timezone,
+		"estimatedCost":       analytics.EstimatedCost,
+		"costBreakdown":       analytics.CostBreakdown,
+	}
+	// Estimated energy is stored only for days with filled gaps, so billing can exclude it
+	if analytics.EstimatedReadings > 0 {
+		item["estimatedReadings"] = analytics.EstimatedReadings
+		item["estimatedConsumption"] = analytics.EstimatedKWh
+	}
+	if analytics.Source != "" {
+		item["source"] = analytics.Source
 	}
 
 	marshalled, err := ddbattr.MarshalMap(item)
@@ -432,34 +603,126 @@ func storeAnalyticsSummary(ctx context.Context, facilityID string, analytics Dai
 	return nil
 }
 
-func generateReport(ctx context.Context, facilityID, date string, analytics DailyAnalytics) (string, error) {
+// roundForReport returns a copy of a rounded for people to read. Analytics
+// are computed, stored and returned at full precision so billing can be
+// reconciled from the summaries; only the report is rounded.
+func roundForReport(a DailyAnalytics) DailyAnalytics {
+	a.TotalConsumption = numfmt.Round(a.TotalConsumption, 2)
+	a.TotalConsumptionMWh = numfmt.Round(a.TotalConsumptionMWh, 3)
+	a.PowerSampleSum = numfmt.Round(a.PowerSampleSum, 2)
+	a.SampledConsumption = numfmt.Round(a.SampledConsumption, 2)
+	a.AveragePower = numfmt.Round(a.AveragePower, 2)
+	a.PeakPower = numfmt.Round(a.PeakPower, 2)
+	a.MinPower = numfmt.Round(a.MinPower, 2)
+	a.MovingAverage = numfmt.RoundSlice(a.MovingAverage, 2)
+	a.AvgVoltage = numfmt.Round(a.AvgVoltage, 2)
+	a.VoltageStdDev = numfmt.Round(a.VoltageStdDev, 3)
+	a.AvgCurrent = numfmt.Round(a.AvgCurrent, 2)
+	a.PowerFactor = numfmt.Round(a.PowerFactor, 3)
+	a.AvgReactivePower = numfmt.Round(a.AvgReactivePower, 2)
+	a.AvgFrequency = numfmt.Round(a.AvgFrequency, 3)
+	a.AvgTHD = numfmt.Round(a.AvgTHD, 2)
+	a.HourlyData = roundBuckets(a.HourlyData, 2)
+	a.PeakHourCandidates = roundPeakHours(a.PeakHourCandidates, 2)
+	a.PeakHourMargin = numfmt.Round(a.PeakHourMargin, 1)
+	a.BucketData = roundBuckets(a.BucketData, 2)
+	a.CapacityKW = numfmt.Round(a.CapacityKW, 2)
+	a.PeakLoadKW = numfmt.Round(a.PeakLoadKW, 2)
+	a.HeadroomKW = numfmt.Round(a.HeadroomKW, 2)
+	a.HeadroomPercent = numfmt.Round(a.HeadroomPercent, 2)
+	a.PeakUtilization = numfmt.Round(a.PeakUtilization, 2)
+	a.CoveragePercent = numfmt.Round(a.CoveragePercent, 2)
+	a.RawPeakPower = numfmt.Round(a.RawPeakPower, 2)
+	a.SampleRate = numfmt.Round(a.SampleRate, 4)
+	a.EstimatedKWh = numfmt.Round(a.EstimatedKWh, 2)
+	return a
+}
+
+// roundBuckets returns a copy of buckets with the power figures rounded
+func roundBuckets(buckets map[string]BucketStats, places int) map[string]BucketStats {
+	if buckets == nil {
+		return nil
+	}
+	out := make(map[string]BucketStats, len(buckets))
+	for k, d := range buckets {
+		d.TotalPower = numfmt.Round(d.TotalPower, places)
+		d.AvgPower = numfmt.Round(d.AvgPower, places)
+		d.MaxPower = numfmt.Round(d.MaxPower, places)
+		out[k] = d
+	}
+	return out
+}
+
+// roundPeakHours returns a copy of candidates with max power rounded
+func roundPeakHours(candidates []PeakHourCandidate, places int) []PeakHourCandidate {
+	if candidates == nil {
+		return nil
+	}
+	out := make([]PeakHourCandidate, len(candidates))
+	for i, c := range candidates {
+		out[i] = PeakHourCandidate{Hour: c.Hour, MaxPower: numfmt.Round(c.MaxPower, places)}
+	}
+	return out
+}
+
+func generateReport(ctx context.Context, facilityID, date, format string, analytics DailyAnalytics) (string, error) {
+	analytics = roundForReport(analytics)
+	recs := generateRecommendations(analytics)
+	if format == reportFormatPDF {
+		b, err := renderPDFReport(facilityID, date, analytics, recs)
+		if err != nil {
+			return "", err
+		}
+		return putReport(ctx, facilityID, date, "pdf", "application/pdf", b)
+	}
+
 	report := map[string]interface{}{
 		"title":       fmt.Sprintf("Daily Energy Report - %s", facilityID),
 		"date":        date,
 		"generatedAt": time.Now().Format(time.RFC3339),
 		"summary": map[string]interface{}{
-			"total_consumption": fmt.Sprintf("%.2f kWh", analytics.TotalConsumption),
-			"average_power":     fmt.Sprintf("%.2f kW", analytics.AveragePower),
-			"peak_power":        fmt.Sprintf("%.2f kW", analytics.PeakPower),
-			"peak_hour":         fmt.Sprintf("%s:00", analytics.PeakHour),
-			"power_factor":      analytics.PowerFactor,
-			"reading_count":     analytics.ReadingCount,
+			"total_consumption":    fmt.Sprintf("%.2f kWh", analytics.TotalConsumption),
+			"average_power":        fmt.Sprintf("%.2f kW", analytics.AveragePower),
+			"peak_power":           fmt.Sprintf("%.2f kW", analytics.PeakPower),
+			"peak_hour":            fmt.Sprintf("%s:00", analytics.PeakHour),
+			"peak_hour_confidence": peakHourSummary(analytics),
+			"power_factor":         analytics.PowerFactor,
+			"reading_count":        analytics.ReadingCount,
+			"coverage":             fmt.Sprintf("%.1f%%", analytics.CoveragePercent),
+			"confidence":           analytics.Confidence,
+		},
+		"peak_hour_candidates": analytics.PeakHourCandidates,
+		"hourly_breakdown":     analytics.HourlyData,
+		"bucket_breakdown": map[string]interface{}{
+			"granularity_minutes": analytics.GranularityMinutes,
+			"buckets":             analytics.BucketData,
 		},
-		"hourly_breakdown": analytics.HourlyData,
-		"recommendations":  generateRecommendations(analytics),
+		"recommendations": recs,
+	}
+	if analytics.CapacityKW > 0 {
+		report["summary"].(map[string]interface{})["capacity_headroom"] = fmt.Sprintf("%.2f kW (%.1f%% of %.2f kW at a %.2f kW peak facility load)",
+			analytics.HeadroomKW, analytics.HeadroomPercent, analytics.CapacityKW, analytics.PeakLoadKW)
+	}
+	if analytics.WinsorizedReadings > 0 {
+		report["summary"].(map[string]interface{})["raw_peak_power"] = fmt.Sprintf("%.2f kW (%d readings clamped)",
+			analytics.RawPeakPower, analytics.WinsorizedReadings)
 	}
 
 	b, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("marshal report: %w", err)
 	}
+	return putReport(ctx, facilityID, date, "json", "application/json", b)
+}
 
-	key := fmt.Sprintf("reports/%s/%s-analytics.json", safePath(facilityID), date)
-	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+// putReport stores a rendered report under reports/ and returns its URL
+func putReport(ctx context.Context, facilityID, date, ext, contentType string, b []byte) (string, error) {
+	key := fmt.Sprintf("reports/%s/%s-analytics.%s", safePath(facilityID), date, ext)
+	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s3Bucket),
 		Key:         aws.String(key),
 		Body:        bytes.NewReader(b),
-		ContentType: aws.String("application/json"),
+		ContentType: aws.String(contentType),
 		Metadata: map[string]string{
 			"facility-id":  facilityID,
 			"report-date":  date,
@@ -479,48 +742,6 @@ func safePath(s string) string {
 	return url.PathEscape(s)
 }
 
-// --- Recommendations ---
-
-func generateRecommendations(a DailyAnalytics) []map[string]string {
-	var recs []map[string]string
-
-	if a.AveragePower > 50 {
-		recs = append(recs, map[string]string{
-			"priority": "high",
-			"category": "consumption",
-			"message":  "Average power is high. Consider load shifting and efficiency measures.",
-		})
-	}
-
-	if a.PowerFactor < 0.85 && a.PowerFactor > 0 {
-		recs = append(recs, map[string]string{
-			"priority": "medium",
-			"category": "efficiency",
-			"message":  fmt.Sprintf("Low power factor (%.3f). Evaluate correction equipment.", a.PowerFactor),
-		})
-	}
-
-	if a.VoltageStdDev > 10 {
-		recs = append(recs, map[string]string{
-			"priority": "high",
-			"category": "quality",
-			"message":  "High voltage variability detected. Inspect electrical infrastructure.",
-		})
-	}
-
-	if a.PeakHour != "" {
-		if h, _ := strconv.Atoi(a.PeakHour); h >= 9 && h <= 17 {
-			recs = append(recs, map[string]string{
-				"priority": "low",
-				"category": "optimization",
-				"message":  fmt.Sprintf("Peak at %s:00. Shift non-critical loads to off-peak hours.", a.PeakHour),
-			})
-		}
-	}
-
-	return recs
-}
-
 func main() {
 	lambda.Start(Handler)
 }
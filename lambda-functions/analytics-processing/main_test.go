@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputePowerQuality(t *testing.T) {
+	f := func(x float64) *float64 { return &x }
+	tests := []struct {
+		name     string
+		readings []Reading
+		want     powerQuality
+	}{
+		{name: "no readings"},
+		{name: "no optional attributes", readings: []Reading{{PowerKW: 4}, {PowerKW: 6}}},
+		{
+			// 8 kW and 6 kVAR is a 10 kVA triangle
+			name:     "reactive power",
+			readings: []Reading{{PowerKW: 6, ReactivePowerKVAR: f(4)}, {PowerKW: 10, ReactivePowerKVAR: f(8)}},
+			want:     powerQuality{HasReactive: true, PowerFactor: 0.8, AvgReactive: 6},
+		},
+		{
+			name:     "only readings with kVAR count toward the power factor",
+			readings: []Reading{{PowerKW: 3, ReactivePowerKVAR: f(4)}, {PowerKW: 100}},
+			want:     powerQuality{HasReactive: true, PowerFactor: 0.6, AvgReactive: 4},
+		},
+		{
+			name:     "frequency and THD",
+			readings: []Reading{{FrequencyHz: f(49.9), THDPercent: f(2)}, {FrequencyHz: f(50.1)}, {THDPercent: f(4)}},
+			want:     powerQuality{AvgFrequency: 50, AvgTHD: 3},
+		},
+		{
+			name:     "zero power and kVAR",
+			readings: []Reading{{ReactivePowerKVAR: f(0)}},
+			want:     powerQuality{HasReactive: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computePowerQuality(tt.readings)
+			if got.HasReactive != tt.want.HasReactive ||
+				math.Abs(got.PowerFactor-tt.want.PowerFactor) > 1e-9 ||
+				math.Abs(got.AvgReactive-tt.want.AvgReactive) > 1e-9 ||
+				math.Abs(got.AvgFrequency-tt.want.AvgFrequency) > 1e-9 ||
+				math.Abs(got.AvgTHD-tt.want.AvgTHD) > 1e-9 {
+				t.Errorf("computePowerQuality = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateDailyAnalyticsPowerFactor(t *testing.T) {
+	t.Setenv("SAMPLING_INTERVAL_SECONDS", "3600")
+	kvar := 6.0
+	withKVAR := steady("1", 8, 1700000000, 3)
+	for i := range withKVAR {
+		withKVAR[i].Voltage, withKVAR[i].Current = 230, 50
+		withKVAR[i].ReactivePowerKVAR = &kvar
+	}
+	withoutKVAR := steady("1", 8, 1700000000, 3)
+	for i := range withoutKVAR {
+		withoutKVAR[i].Voltage, withoutKVAR[i].Current = 230, 50
+	}
+
+	tests := []struct {
+		name       string
+		readings   []Reading
+		want       float64
+		wantMethod string
+	}{
+		// The V * I estimate can't see the reactive share; kVAR gives the true 0.8
+		{name: "reported kVAR", readings: withKVAR, want: 0.8, wantMethod: pfReactive},
+		{name: "estimate without kVAR", readings: withoutKVAR, want: 8.0 / (230 * 50) * 100, wantMethod: pfEstimated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := calculateDailyAnalytics(tt.readings, "2023-11-14", time.UTC, 60)
+			if math.Abs(a.PowerFactor-tt.want) > 1e-3 || a.PowerFactorMethod != tt.wantMethod {
+				t.Errorf("power factor = %v (%s), want %v (%s)", a.PowerFactor, a.PowerFactorMethod, tt.want, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Report formats selectable with the event's format field
+const (
+	reportFormatJSON = "json"
+	reportFormatPDF  = "pdf"
+)
+
+// parseReportFormat normalises the event's format; empty means JSON
+func parseReportFormat(raw string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(raw)); f {
+	case "", reportFormatJSON:
+		return reportFormatJSON, nil
+	case reportFormatPDF:
+		return reportFormatPDF, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q (want json or pdf)", raw)
+	}
+}
+
+// Hourly chart raster size in pixels; it is scaled to the page width
+const (
+	chartImageWidth  = 960
+	chartImageHeight = 320
+	chartBarGap      = 8
+)
+
+var (
+	chartBackground = color.RGBA{0xf8, 0xfa, 0xfc, 0xff}
+	chartBar        = color.RGBA{0x3b, 0x82, 0xf6, 0xff}
+	chartPeakBar    = color.RGBA{0xef, 0x44, 0x44, 0xff}
+)
+
+// renderPDFReport lays out a one-page report: the summary table, an hourly
+// average power bar chart and the recommendations
+func renderPDFReport(facilityID, date string, a DailyAnalytics, recs []Recommendation) ([]byte, error) {
+	chart, err := hourlyChartPNG(a.HourlyData, a.PeakHour)
+	if err != nil {
+		return nil, fmt.Errorf("render chart: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Daily Energy Report - %s", facilityID), false)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AddPage()
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 30
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(contentW, 10, fmt.Sprintf("Daily Energy Report - %s", facilityID), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(100, 116, 139)
+	pdf.CellFormat(contentW, 6, fmt.Sprintf("%s (%s)  -  generated %s", date, a.Timezone, time.Now().UTC().Format(time.RFC3339)), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentW, 8, "Summary", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetFillColor(241, 245, 249)
+	for i, row := range pdfSummaryRows(a) {
+		fill := i%2 == 0
+		pdf.CellFormat(contentW*0.45, 6.5, row[0], "", 0, "L", fill, 0, "")
+		pdf.CellFormat(contentW*0.55, 6.5, row[1], "", 1, "L", fill, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentW, 8, "Hourly average power (kW)", "", 1, "L", false, 0, "")
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("hourly", opts, bytes.NewReader(chart))
+	chartH := contentW * chartImageHeight / chartImageWidth
+	x, y := pdf.GetXY()
+	pdf.ImageOptions("hourly", x, y, contentW, chartH, false, opts, 0, "")
+
+	// Hour labels under every third bar
+	pdf.SetFont("Helvetica", "", 7)
+	slot := contentW / 24
+	for h := 0; h < 24; h += 3 {
+		pdf.SetXY(x+float64(h)*slot, y+chartH+0.5)
+		pdf.CellFormat(slot, 4, fmt.Sprintf("%02d", h), "", 0, "C", false, 0, "")
+	}
+	pdf.SetXY(x, y+chartH+6)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentW, 8, "Recommendations", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	// Core fonts are cp1252; messages may carry UTF-8 punctuation
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	if len(recs) == 0 {
+		pdf.CellFormat(contentW, 6, "No recommendations for this day.", "", 1, "L", false, 0, "")
+	}
+	for _, r := range recs {
+		pdf.MultiCell(contentW, 5.5, tr(fmt.Sprintf("[%s] %s: %s", r.Priority, r.Category, r.Message)), "", "L", false)
+		pdf.Ln(1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("write pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfSummaryRows is the report's summary as label/value pairs, matching the
+// JSON report's summary fields
+func pdfSummaryRows(a DailyAnalytics) [][2]string {
+	rows := [][2]string{
+		{"Total consumption", fmt.Sprintf("%.2f kWh", a.TotalConsumption)},
+		{"Average power", fmt.Sprintf("%.2f kW", a.AveragePower)},
+		{"Peak power", fmt.Sprintf("%.2f kW", a.PeakPower)},
+		{"Peak hour", fmt.Sprintf("%s:00", a.PeakHour)},
+		{"Peak hour confidence", peakHourSummary(a)},
+		{"Power factor", fmt.Sprintf("%.3f", a.PowerFactor)},
+		{"Estimated cost", fmt.Sprintf("%.2f", a.EstimatedCost)},
+		{"Readings", fmt.Sprintf("%d", a.ReadingCount)},
+		{"Coverage", fmt.Sprintf("%.1f%% (%s confidence)", a.CoveragePercent, a.Confidence)},
+	}
+	if a.CapacityKW > 0 {
+		rows = append(rows, [2]string{"Capacity headroom", fmt.Sprintf("%.2f kW (%.1f%% of %.2f kW at a %.2f kW peak facility load)",
+			a.HeadroomKW, a.HeadroomPercent, a.CapacityKW, a.PeakLoadKW)})
+	}
+	if a.WinsorizedReadings > 0 {
+		rows = append(rows, [2]string{"Raw peak power", fmt.Sprintf("%.2f kW (%d readings clamped)",
+			a.RawPeakPower, a.WinsorizedReadings)})
+	}
+	return rows
+}
+
+// hourlyChartPNG draws one bar per local hour, scaled to the busiest hour;
+// the peak hour is highlighted
+func hourlyChartPNG(hourly map[string]HourlyData, peakHour string) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartImageWidth, chartImageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	var max float64
+	for _, d := range hourly {
+		if d.AvgPower > max {
+			max = d.AvgPower
+		}
+	}
+
+	slot := chartImageWidth / 24
+	for h := 0; h < 24; h++ {
+		key := fmt.Sprintf("%02d", h)
+		d := hourly[key]
+		if max <= 0 || d.AvgPower <= 0 {
+			continue
+		}
+		barH := int(d.AvgPower / max * float64(chartImageHeight-chartBarGap))
+		c := chartBar
+		if key == peakHour {
+			c = chartPeakBar
+		}
+		bar := image.Rect(h*slot+chartBarGap/2, chartImageHeight-barH, (h+1)*slot-chartBarGap/2, chartImageHeight)
+		draw.Draw(img, bar, &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
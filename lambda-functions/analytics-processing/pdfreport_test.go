@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+)
+
+func TestParseReportFormat(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: reportFormatJSON},
+		{raw: "json", want: reportFormatJSON},
+		{raw: " PDF ", want: reportFormatPDF},
+		{raw: "html", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseReportFormat(tt.raw)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseReportFormat(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPDFReport(t *testing.T) {
+	hourly := make(map[string]HourlyData)
+	for h := 0; h < 24; h++ {
+		hourly[fmt.Sprintf("%02d", h)] = HourlyData{AvgPower: 20 + float64(h%12)*3}
+	}
+	full := DailyAnalytics{
+		TotalConsumption: 812.4, AveragePower: 33.85, PeakPower: 61.2, PeakHour: "11",
+		PowerFactor: 0.91, EstimatedCost: 97.49, ReadingCount: 96, CoveragePercent: 100,
+		Confidence: "high", Timezone: "Europe/Berlin", HourlyData: hourly,
+		CapacityKW: 80, HeadroomKW: 18.8, HeadroomPercent: 23.5, PeakLoadKW: 61.2,
+		WinsorizedReadings: 2, RawPeakPower: 140,
+	}
+
+	tests := []struct {
+		name      string
+		analytics DailyAnalytics
+		recs      []Recommendation
+	}{
+		{name: "full day", analytics: full, recs: []Recommendation{
+			{Priority: "high", Category: "peak_demand", Message: "Shift load out of the 11:00 peak – it’s 23% of capacity"},
+			{Priority: "low", Category: "power_factor", Message: "Power factor is fine"},
+		}},
+		{name: "no readings", analytics: DailyAnalytics{PeakHour: "00", Timezone: "UTC", HourlyData: map[string]HourlyData{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := renderPDFReport("facility-001", "2024-03-01", tt.analytics, tt.recs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(b, []byte("%PDF-")) || !bytes.Contains(b[len(b)-16:], []byte("%%EOF")) {
+				t.Fatalf("not a PDF: %d bytes starting %q", len(b), b[:min(len(b), 8)])
+			}
+			// One page with the chart embedded
+			if n := bytes.Count(b, []byte("/Type /Page\n")); n != 1 {
+				t.Errorf("%d pages, want 1", n)
+			}
+			if !bytes.Contains(b, []byte("/Subtype /Image")) {
+				t.Error("no chart image embedded")
+			}
+		})
+	}
+}
+
+func TestHourlyChartPNG(t *testing.T) {
+	hourly := map[string]HourlyData{"03": {AvgPower: 10}, "18": {AvgPower: 40}}
+	b, err := hourlyChartPNG(hourly, "18")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got.X != chartImageWidth || got.Y != chartImageHeight {
+		t.Fatalf("chart is %v", got)
+	}
+
+	// Pixels just above the baseline in the middle of each hour's slot
+	slot := chartImageWidth / 24
+	at := func(h int) any { return img.At(h*slot+slot/2, chartImageHeight-1) }
+	if at(18) != chartPeakBar || at(3) != chartBar || at(10) != chartBackground {
+		t.Errorf("bars at 18h %v, 3h %v, 10h %v", at(18), at(3), at(10))
+	}
+	// The busiest hour fills the chart, a quarter of it a quarter of the height
+	top := func(h int) int {
+		y := chartImageHeight - 1
+		for y > 0 && img.At(h*slot+slot/2, y-1) != chartBackground {
+			y--
+		}
+		return chartImageHeight - y
+	}
+	if got, want := top(18), chartImageHeight-chartBarGap; got != want {
+		t.Errorf("peak bar %dpx high, want %d", got, want)
+	}
+	if got, want := top(3), (chartImageHeight-chartBarGap)/4; got != want {
+		t.Errorf("3h bar %dpx high, want %d", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Number of peak hour candidates reported
+const peakHourCandidateCount = 3
+
+// Peak hour confidence levels
+const (
+	peakHourDecisive = "decisive"
+	peakHourMarginal = "marginal"
+)
+
+// PeakHourCandidate is one of the hours with the highest max power
+type PeakHourCandidate struct {
+	Hour     string  `json:"hour"`
+	MaxPower float64 `json:"max_power"`
+}
+
+// rankPeakHours returns up to n hours by max power, highest first; ties go
+// to the earlier hour
+func rankPeakHours(hourly map[string]HourlyData, n int) []PeakHourCandidate {
+	ranked := make([]PeakHourCandidate, 0, len(hourly))
+	for h, d := range hourly {
+		ranked = append(ranked, PeakHourCandidate{Hour: h, MaxPower: d.MaxPower})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].MaxPower == ranked[j].MaxPower {
+			return ranked[i].Hour < ranked[j].Hour
+		}
+		return ranked[i].MaxPower > ranked[j].MaxPower
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// peakHourConfidence rates how clearly the top candidate beats the runner-up:
+// the margin is the runner-up's shortfall as a percentage of the top hour's
+// max power, and the peak is decisive when it is at least marginPercent
+// (PEAK_HOUR_MARGIN_PERCENT). A day with a single hour is decisive; one with
+// no positive peak is marginal.
+func peakHourConfidence(candidates []PeakHourCandidate, marginPercent float64) (string, float64) {
+	switch {
+	case len(candidates) == 0:
+		return "", 0
+	case len(candidates) == 1:
+		return peakHourDecisive, 100
+	case candidates[0].MaxPower <= 0:
+		return peakHourMarginal, 0
+	}
+	margin := (candidates[0].MaxPower - candidates[1].MaxPower) / candidates[0].MaxPower * 100
+	if margin >= marginPercent {
+		return peakHourDecisive, margin
+	}
+	return peakHourMarginal, margin
+}
+
+// peakHourSummary describes the peak hour's confidence for the report, e.g.
+// "marginal (2.1% above 18:00)"
+func peakHourSummary(a DailyAnalytics) string {
+	if len(a.PeakHourCandidates) < 2 {
+		return a.PeakHourConfidence
+	}
+	return fmt.Sprintf("%s (%.1f%% above %s:00)", a.PeakHourConfidence, a.PeakHourMargin, a.PeakHourCandidates[1].Hour)
+}
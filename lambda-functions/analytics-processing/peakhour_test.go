@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRankPeakHours(t *testing.T) {
+	hourly := func(maxes map[string]float64) map[string]HourlyData {
+		out := make(map[string]HourlyData, len(maxes))
+		for h, m := range maxes {
+			out[h] = HourlyData{Count: 1, MaxPower: m}
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		hourly map[string]HourlyData
+		want   []PeakHourCandidate
+	}{
+		{
+			name:   "top three by max power",
+			hourly: hourly(map[string]float64{"08": 20, "12": 55, "13": 48, "18": 61, "22": 5}),
+			want:   []PeakHourCandidate{{Hour: "18", MaxPower: 61}, {Hour: "12", MaxPower: 55}, {Hour: "13", MaxPower: 48}},
+		},
+		{
+			name:   "ties go to the earlier hour",
+			hourly: hourly(map[string]float64{"19": 40, "07": 40, "11": 40, "03": 40}),
+			want:   []PeakHourCandidate{{Hour: "03", MaxPower: 40}, {Hour: "07", MaxPower: 40}, {Hour: "11", MaxPower: 40}},
+		},
+		{
+			name:   "fewer hours than candidates",
+			hourly: hourly(map[string]float64{"09": 12}),
+			want:   []PeakHourCandidate{{Hour: "09", MaxPower: 12}},
+		},
+		{name: "no hours", hourly: map[string]HourlyData{}, want: []PeakHourCandidate{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rankPeakHours(tt.hourly, peakHourCandidateCount); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankPeakHours = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeakHourConfidence(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []PeakHourCandidate
+		want       string
+		wantMargin float64
+	}{
+		{name: "clear peak", candidates: []PeakHourCandidate{{"18", 80}, {"12", 50}, {"13", 48}}, want: peakHourDecisive, wantMargin: 37.5},
+		{name: "near tie", candidates: []PeakHourCandidate{{"18", 50}, {"12", 49}, {"13", 20}}, want: peakHourMarginal, wantMargin: 2},
+		{name: "exact tie", candidates: []PeakHourCandidate{{"12", 50}, {"18", 50}}, want: peakHourMarginal, wantMargin: 0},
+		{name: "at the margin", candidates: []PeakHourCandidate{{"18", 50}, {"12", 45}}, want: peakHourDecisive, wantMargin: 10},
+		{name: "single hour", candidates: []PeakHourCandidate{{"09", 12}}, want: peakHourDecisive, wantMargin: 100},
+		{name: "no load", candidates: []PeakHourCandidate{{"00", 0}, {"01", 0}}, want: peakHourMarginal},
+		{name: "no hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, margin := peakHourConfidence(tt.candidates, 10)
+			if got != tt.want || math.Abs(margin-tt.wantMargin) > 1e-9 {
+				t.Errorf("confidence %q, margin %v; want %q, %v", got, margin, tt.want, tt.wantMargin)
+			}
+		})
+	}
+}
+
+func TestDailyAnalyticsPeakHour(t *testing.T) {
+	day := time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+	// Hourly readings at 10 kW, with the given hours higher
+	readings := func(peaks map[int]float64) []Reading {
+		var out []Reading
+		for h := 0; h < 24; h++ {
+			kw := 10.0
+			if p, ok := peaks[h]; ok {
+				kw = p
+			}
+			out = append(out, Reading{MeterID: "1", Timestamp: day.Add(time.Duration(h) * time.Hour).Unix(), Voltage: 230, Current: 40, PowerKW: kw})
+		}
+		return out
+	}
+	tests := []struct {
+		name           string
+		peaks          map[int]float64
+		wantHour       string
+		wantConfidence string
+		wantSummary    string
+	}{
+		{name: "clear peak", peaks: map[int]float64{18: 40, 12: 20}, wantHour: "18", wantConfidence: peakHourDecisive, wantSummary: "decisive (50.0% above 12:00)"},
+		{name: "near tie", peaks: map[int]float64{18: 40, 12: 39.2}, wantHour: "18", wantConfidence: peakHourMarginal, wantSummary: "marginal (2.0% above 12:00)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := calculateDailyAnalytics(readings(tt.peaks), "2023-11-14", time.UTC, 60)
+			if a.PeakHour != tt.wantHour || a.PeakHourConfidence != tt.wantConfidence || len(a.PeakHourCandidates) != peakHourCandidateCount {
+				t.Errorf("peak hour %s (%s) of %v; want %s (%s)", a.PeakHour, a.PeakHourConfidence, a.PeakHourCandidates, tt.wantHour, tt.wantConfidence)
+			}
+			// The report states how clear the peak is
+			if got := peakHourSummary(roundForReport(a)); got != tt.wantSummary {
+				t.Errorf("report says %q, want %q", got, tt.wantSummary)
+			}
+		})
+	}
+}
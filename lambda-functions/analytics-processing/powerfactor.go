@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/converter"
+)
+
+// Power factor methods selectable with POWER_FACTOR_METHOD
+const (
+	// pfEstimate divides real power by single-phase apparent power V * I
+	pfEstimate = "estimate"
+	// pfReactive uses P / sqrt(P² + Q²) from reported kVAR, falling back to
+	// pfEstimate for days without reactive power readings
+	pfReactive = "reactive"
+	// pfThreePhase divides real power by balanced three-phase apparent power
+	// √3 * V_LL * I, treating the reported voltage as line-to-line
+	pfThreePhase = "three_phase"
+)
+
+// pfEstimated is the power_factor_method recorded for a single-phase estimate,
+// as stored before the method was configurable
+const pfEstimated = "estimated"
+
+// powerFactorMethod reads POWER_FACTOR_METHOD, defaulting to reactive
+func powerFactorMethod() string {
+	method := strings.ToLower(strings.TrimSpace(getenv("POWER_FACTOR_METHOD", pfReactive)))
+	switch method {
+	case pfEstimate, pfReactive, pfThreePhase:
+		return method
+	default:
+		fmt.Printf("WARN: unknown POWER_FACTOR_METHOD %q; using %s\n", method, pfReactive)
+		return pfReactive
+	}
+}
+
+// computePowerFactor returns the power factor for the day using method, and
+// the method actually applied. avgPowerKW is in kW, avgVoltage in V and
+// avgCurrent in A. Reported kVAR gives a 0–1 ratio; the V * I estimates keep
+// the scale stored days have always had, kW / VA × 100, so they stay comparable.
+func computePowerFactor(method string, avgPowerKW, avgVoltage, avgCurrent float64, pq powerQuality) (float64, string) {
+	switch method {
+	case pfReactive:
+		if pq.HasReactive {
+			return pq.PowerFactor, pfReactive
+		}
+		return estimatedPowerFactor(avgPowerKW, avgVoltage*avgCurrent), pfEstimated
+	case pfThreePhase:
+		return estimatedPowerFactor(avgPowerKW, math.Sqrt(3)*avgVoltage*avgCurrent), pfThreePhase
+	default:
+		return estimatedPowerFactor(avgPowerKW, avgVoltage*avgCurrent), pfEstimated
+	}
+}
+
+// estimatedPowerFactor is real power (kW) as a percentage of apparent power
+// (VA); a zero, negative or NaN apparent power yields 0
+func estimatedPowerFactor(realKW, apparentVA float64) float64 {
+	if apparentVA <= 0 || math.IsNaN(apparentVA) || math.IsInf(apparentVA, 0) {
+		return 0
+	}
+	conv := &converter.EnergyConverter{}
+	return conv.CalculateEfficiency(apparentVA, realKW)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputePowerFactor(t *testing.T) {
+	reactive := powerQuality{HasReactive: true, PowerFactor: 0.92}
+	tests := []struct {
+		name       string
+		method     string
+		kw, v, a   float64
+		pq         powerQuality
+		want       float64
+		wantMethod string
+	}{
+		// 2 kW over 230 V * 10 A = 2300 VA, on the stored kW / VA × 100 scale
+		{name: "estimate", method: pfEstimate, kw: 2, v: 230, a: 10, want: 2.0 / 2300 * 100, wantMethod: "estimated"},
+		{name: "estimate ignores kVAR", method: pfEstimate, kw: 2, v: 230, a: 10, pq: reactive, want: 2.0 / 2300 * 100, wantMethod: "estimated"},
+		{name: "reactive", method: pfReactive, kw: 2, v: 230, a: 10, pq: reactive, want: 0.92, wantMethod: pfReactive},
+		{name: "reactive without kVAR falls back", method: pfReactive, kw: 2, v: 230, a: 10, want: 2.0 / 2300 * 100, wantMethod: "estimated"},
+		{name: "three phase", method: pfThreePhase, kw: 2, v: 400, a: 10, want: 2.0 / (math.Sqrt(3) * 4000) * 100, wantMethod: pfThreePhase},
+		{name: "zero current", method: pfEstimate, kw: 2, v: 230, want: 0, wantMethod: "estimated"},
+		{name: "negative apparent power", method: pfThreePhase, kw: 2, v: -230, a: 10, want: 0, wantMethod: pfThreePhase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, method := computePowerFactor(tt.method, tt.kw, tt.v, tt.a, tt.pq)
+			if math.Abs(got-tt.want) > 1e-9 || method != tt.wantMethod {
+				t.Errorf("computePowerFactor = %v, %q; want %v, %q", got, method, tt.want, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestPowerFactorMethod(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", pfReactive},
+		{"estimate", pfEstimate},
+		{" Three_Phase ", pfThreePhase},
+		{"bogus", pfReactive},
+	}
+	for _, tt := range tests {
+		t.Setenv("POWER_FACTOR_METHOD", tt.env)
+		if got := powerFactorMethod(); got != tt.want {
+			t.Errorf("POWER_FACTOR_METHOD=%q: method = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
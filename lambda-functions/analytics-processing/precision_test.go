@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+func TestRoundForReport(t *testing.T) {
+	full := DailyAnalytics{
+		TotalConsumption: 123.456789, TotalConsumptionMWh: 0.123456789, AveragePower: 5.14403,
+		PeakPower: 10.123456, MinPower: 4.987654, PowerFactor: 0.9234567, AvgFrequency: 50.01234,
+		MovingAverage: []float64{5.5555, 6.6666},
+		HourlyData:    map[string]HourlyData{"01": {Count: 1, TotalPower: 10.123456, AvgPower: 10.123456, MaxPower: 10.123456}},
+	}
+	tests := []struct {
+		name string
+		got  func(a DailyAnalytics) interface{}
+		want interface{}
+	}{
+		{name: "consumption to two places", got: func(a DailyAnalytics) interface{} { return a.TotalConsumption }, want: 123.46},
+		{name: "MWh to three places", got: func(a DailyAnalytics) interface{} { return a.TotalConsumptionMWh }, want: 0.123},
+		{name: "peak", got: func(a DailyAnalytics) interface{} { return a.PeakPower }, want: 10.12},
+		{name: "minimum", got: func(a DailyAnalytics) interface{} { return a.MinPower }, want: 4.99},
+		{name: "power factor to three places", got: func(a DailyAnalytics) interface{} { return a.PowerFactor }, want: 0.923},
+		{name: "frequency to three places", got: func(a DailyAnalytics) interface{} { return a.AvgFrequency }, want: 50.012},
+		{name: "moving average", got: func(a DailyAnalytics) interface{} { return a.MovingAverage }, want: []float64{5.56, 6.67}},
+		{name: "hourly figures", got: func(a DailyAnalytics) interface{} { return a.HourlyData["01"] }, want: HourlyData{Count: 1, TotalPower: 10.12, AvgPower: 10.12, MaxPower: 10.12}},
+	}
+	rounded := roundForReport(full)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(rounded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rounded to %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// Rounding the report leaves the analytics it was given alone
+	if full.PeakPower != 10.123456 || full.MovingAverage[0] != 5.5555 || full.HourlyData["01"].MaxPower != 10.123456 {
+		t.Errorf("roundForReport changed its input: %+v", full)
+	}
+}
+
+func TestHandlerStoresFullPrecision(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	powers := []float64{10.123456, 4.987654}
+	var items []map[string]interface{}
+	for h, p := range powers {
+		items = append(items, map[string]interface{}{
+			"facilityId": map[string]string{"S": "facility-001"}, "meterId": map[string]string{"S": "1"},
+			"timestamp": map[string]string{"N": fmt.Sprint(day.Add(time.Duration(h+1) * time.Hour).Unix())},
+			"voltage":   map[string]string{"N": "230.0123"}, "current": map[string]string{"N": "20"}, "powerKw": map[string]string{"N": fmt.Sprint(p)},
+		})
+	}
+	f := &fakeAWS{dynamoItems: items}
+	useFakeAWS(t, f)
+
+	resp, err := Handler(context.Background(), LambdaEvent{FacilityID: "facility-001", Date: "2024-01-10"})
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("status %d, %v: %v", resp.StatusCode, err, resp.Body)
+	}
+	a := resp.Body["analytics"].(DailyAnalytics)
+
+	var report struct {
+		Summary map[string]interface{} `json:"summary"`
+		Hourly  map[string]HourlyData  `json:"hourly_breakdown"`
+	}
+	raw, ok := f.put["reports/facility-001/2024-01-10-analytics.json"]
+	if !ok {
+		t.Fatalf("no report among %d objects written", len(f.put))
+	}
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		stored     string // the summary attribute
+		returned   func(a DailyAnalytics) float64
+		full       float64
+		reported   string // the report's summary entry
+		wantReport string
+	}{
+		{name: "peak", stored: "peakPower", returned: func(a DailyAnalytics) float64 { return a.PeakPower }, full: 10.123456, reported: "peak_power", wantReport: "10.12 kW"},
+		{name: "average", stored: "averagePower", returned: func(a DailyAnalytics) float64 { return a.AveragePower }, full: (10.123456 + 4.987654) / 2, reported: "average_power", wantReport: "7.56 kW"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// DynamoDB keeps what was computed, so billing reconciles from it
+			n, _ := f.summary[tt.stored]["N"].(string)
+			var stored float64
+			fmt.Sscan(n, &stored)
+			if math.Abs(stored-tt.full) > 1e-9 || n == fmt.Sprintf("%.2f", tt.full) {
+				t.Errorf("stored %s = %q, want %v at full precision", tt.stored, n, tt.full)
+			}
+			if got := tt.returned(a); math.Abs(got-tt.full) > 1e-9 {
+				t.Errorf("returned %v, want %v", got, tt.full)
+			}
+			// People read the rounded report
+			if got := report.Summary[tt.reported]; got != tt.wantReport {
+				t.Errorf("report %s = %v, want %q", tt.reported, got, tt.wantReport)
+			}
+		})
+	}
+	if len(report.Hourly) != len(powers) {
+		t.Errorf("report has %d hours, want %d", len(report.Hourly), len(powers))
+	}
+	for hour, d := range report.Hourly {
+		if d.MaxPower != numfmt.Round(d.MaxPower, 2) || d.MaxPower == 0 {
+			t.Errorf("report hour %s peaks at %v, want two places", hour, d.MaxPower)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Confidence levels reported with the daily analytics
+const (
+	confidenceHigh   = "high"
+	confidenceMedium = "medium"
+	confidenceLow    = "low"
+)
+
+// Coverage thresholds (percent of expected sampling slots) for each confidence level
+const (
+	highConfidenceCoverage   = 90.0
+	mediumConfidenceCoverage = 60.0
+)
+
+// filterReadings drops corrupt readings (non-finite values or negative power)
+// and returns the valid ones with the number dropped
+func filterReadings(readings []Reading) ([]Reading, int) {
+	valid := make([]Reading, 0, len(readings))
+	for _, r := range readings {
+		if !finite(r.PowerKW) || !finite(r.Voltage) || !finite(r.Current) || r.PowerKW < 0 {
+			continue
+		}
+		valid = append(valid, r)
+	}
+	return valid, len(readings) - len(valid)
+}
+
+func finite(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+
+// applyDataQuality records coverage, gaps and filtered readings on the
+// analytics and derives its confidence. Coverage is the share of the day's
+// sampling slots (intervalSeconds wide) holding at least one reading; a gap is
+// a stretch of more than two intervals between consecutive readings.
+func applyDataQuality(a *DailyAnalytics, readings []Reading, filtered int, dayStart, dayEnd time.Time, intervalSeconds float64) {
+	a.FilteredReadings = filtered
+	if intervalSeconds <= 0 {
+		intervalSeconds = 3600
+	}
+
+	if len(readings) > 0 {
+		// Local days on DST changes are 23 or 25 hours long
+		slots := int(math.Ceil(dayEnd.Sub(dayStart).Seconds() / intervalSeconds))
+		covered := make(map[int]bool)
+		ts := make([]int64, 0, len(readings))
+		for _, r := range readings {
+			slot := int(float64(r.Timestamp-dayStart.Unix()) / intervalSeconds)
+			if slot >= 0 && slot < slots {
+				covered[slot] = true
+			}
+			ts = append(ts, r.Timestamp)
+		}
+		a.CoveragePercent = math.Min(100, float64(len(covered))/float64(slots)*100)
+
+		sort.Slice(ts, func(i, j int) bool { return ts[i] < ts[j] })
+		for i := 1; i < len(ts); i++ {
+			if float64(ts[i]-ts[i-1]) > 2*intervalSeconds {
+				a.DataGaps++
+			}
+		}
+	}
+
+	a.Confidence = confidenceFor(a.CoveragePercent, a.DataGaps > 0 || filtered > 0)
+}
+
+// confidenceFor maps coverage to a confidence level, downgraded one level when
+// the day has data gaps or corrupt readings were filtered out
+func confidenceFor(coveragePercent float64, degraded bool) string {
+	levels := []string{confidenceLow, confidenceMedium, confidenceHigh}
+	level := 0
+	switch {
+	case coveragePercent >= highConfidenceCoverage:
+		level = 2
+	case coveragePercent >= mediumConfidenceCoverage:
+		level = 1
+	}
+	if degraded && level > 0 {
+		level--
+	}
+	return levels[level]
+}
+
+// Percentile bounds used when WINSORIZE=true
+const (
+	winsorLowerPercentile = 1.0
+	winsorUpperPercentile = 99.0
+)
+
+// winsorize clamps each reading's power to the [lower, upper] percentile
+// range of the day's values so a single sensor glitch can't dominate the
+// peak and average. It returns a clamped copy and the number of readings changed.
+func winsorize(readings []Reading, lower, upper float64) ([]Reading, int) {
+	if len(readings) < 3 {
+		return readings, 0
+	}
+
+	values := make([]float64, len(readings))
+	for i, r := range readings {
+		values[i] = r.PowerKW
+	}
+	sort.Float64s(values)
+	lo, hi := percentile(values, lower), percentile(values, upper)
+
+	out := make([]Reading, len(readings))
+	clamped := 0
+	for i, r := range readings {
+		switch {
+		case r.PowerKW < lo:
+			r.PowerKW = lo
+			clamped++
+		case r.PowerKW > hi:
+			r.PowerKW = hi
+			clamped++
+		}
+		out[i] = r
+	}
+	return out, clamped
+}
+
+// percentile returns the p-th percentile (0–100) of sorted values, linearly
+// interpolating between the closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	i := int(math.Floor(rank))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (rank-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// minPower returns the lowest power among readings
+func minPower(readings []Reading) float64 {
+	low := 0.0
+	for i, r := range readings {
+		if i == 0 || r.PowerKW < low {
+			low = r.PowerKW
+		}
+	}
+	return low
+}
+
+// maxPower returns the highest power among readings
+func maxPower(readings []Reading) float64 {
+	peak := 0.0
+	for i, r := range readings {
+		if i == 0 || r.PowerKW > peak {
+			peak = r.PowerKW
+		}
+	}
+	return peak
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConfidenceFor(t *testing.T) {
+	tests := []struct {
+		coverage float64
+		degraded bool
+		want     string
+	}{
+		{100, false, confidenceHigh},
+		{90, false, confidenceHigh},
+		{89.9, false, confidenceMedium},
+		{60, false, confidenceMedium},
+		{59.9, false, confidenceLow},
+		{0, false, confidenceLow},
+		{100, true, confidenceMedium},
+		{75, true, confidenceLow},
+		{30, true, confidenceLow},
+	}
+	for _, tt := range tests {
+		if got := confidenceFor(tt.coverage, tt.degraded); got != tt.want {
+			t.Errorf("confidenceFor(%v, %v) = %q, want %q", tt.coverage, tt.degraded, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDataQuality(t *testing.T) {
+	dayStart := time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+	dayEnd := dayStart.Add(24 * time.Hour)
+	t0 := dayStart.Unix()
+	// hours returns one reading at the start of each listed hour
+	hours := func(hs ...int) []Reading {
+		var rs []Reading
+		for _, h := range hs {
+			rs = append(rs, Reading{MeterID: "1", Timestamp: t0 + int64(h)*3600, PowerKW: 5})
+		}
+		return rs
+	}
+	span := func(from, to int) []int {
+		var hs []int
+		for h := from; h < to; h++ {
+			hs = append(hs, h)
+		}
+		return hs
+	}
+
+	tests := []struct {
+		name           string
+		readings       []Reading
+		filtered       int
+		wantCoverage   float64
+		wantGaps       int
+		wantConfidence string
+	}{
+		{name: "full day", readings: hours(span(0, 24)...), wantCoverage: 100, wantConfidence: confidenceHigh},
+		{name: "full day with corrupt readings dropped", readings: hours(span(0, 24)...), filtered: 2, wantCoverage: 100, wantConfidence: confidenceMedium},
+		{name: "last hours missing", readings: hours(span(0, 18)...), wantCoverage: 75, wantConfidence: confidenceMedium},
+		{name: "midday gap", readings: hours(append(span(0, 10), span(14, 24)...)...), wantCoverage: 83.33, wantGaps: 1, wantConfidence: confidenceLow},
+		{name: "morning only", readings: hours(span(0, 6)...), wantCoverage: 25, wantConfidence: confidenceLow},
+		{name: "no readings", wantConfidence: confidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a DailyAnalytics
+			applyDataQuality(&a, tt.readings, tt.filtered, dayStart, dayEnd, 3600)
+			if math.Abs(a.CoveragePercent-tt.wantCoverage) > 0.01 || a.DataGaps != tt.wantGaps || a.FilteredReadings != tt.filtered {
+				t.Errorf("coverage %v, gaps %d, filtered %d; want %v, %d, %d", a.CoveragePercent, a.DataGaps, a.FilteredReadings, tt.wantCoverage, tt.wantGaps, tt.filtered)
+			}
+			if a.Confidence != tt.wantConfidence {
+				t.Errorf("confidence = %q, want %q", a.Confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestFilterReadings(t *testing.T) {
+	readings := []Reading{
+		{PowerKW: 5, Voltage: 230, Current: 20},
+		{PowerKW: math.NaN(), Voltage: 230},
+		{PowerKW: -1, Voltage: 230},
+		{PowerKW: 4, Voltage: math.Inf(1)},
+		{PowerKW: 0, Voltage: 230},
+	}
+	valid, dropped := filterReadings(readings)
+	if len(valid) != 2 || dropped != 3 {
+		t.Errorf("kept %d, dropped %d; want 2, 3", len(valid), dropped)
+	}
+}
+
+func TestWinsorize(t *testing.T) {
+	// A day of 5-minute readings at 10 kW with one 1000 kW sensor glitch
+	glitchy := func() []Reading {
+		rs := make([]Reading, 100)
+		for i := range rs {
+			rs[i] = Reading{MeterID: "1", Timestamp: 1700000000 + int64(i)*300, PowerKW: 10}
+		}
+		rs[40].PowerKW = 1000
+		return rs
+	}
+
+	tests := []struct {
+		name        string
+		winsor      bool
+		wantPeak    float64
+		wantAverage float64
+		wantClamped int
+	}{
+		{name: "raw", wantPeak: 1000, wantAverage: 19.9},
+		// The 99th percentile of 99 tens and a thousand is 19.9
+		{name: "winsorized", winsor: true, wantPeak: 19.9, wantAverage: 10.099, wantClamped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readings := glitchy()
+			used, clamped := readings, 0
+			if tt.winsor {
+				used, clamped = winsorize(readings, winsorLowerPercentile, winsorUpperPercentile)
+			}
+			a := calculateDailyAnalytics(used, "2023-11-14", time.UTC, 60)
+			if math.Abs(a.PeakPower-tt.wantPeak) > 1e-6 || math.Abs(a.AveragePower-tt.wantAverage) > 1e-6 || clamped != tt.wantClamped {
+				t.Errorf("peak %v, average %v, clamped %d; want %v, %v, %d", a.PeakPower, a.AveragePower, clamped, tt.wantPeak, tt.wantAverage, tt.wantClamped)
+			}
+			// The raw peak is still there to report alongside
+			if maxPower(readings) != 1000 {
+				t.Errorf("winsorize changed its input: raw peak %v", maxPower(readings))
+			}
+		})
+	}
+
+	short := []Reading{{PowerKW: 1}, {PowerKW: 500}}
+	if out, n := winsorize(short, 1, 99); n != 0 || out[1].PowerKW != 500 {
+		t.Errorf("two readings clamped to %v (%d), want them untouched", out, n)
+	}
+}
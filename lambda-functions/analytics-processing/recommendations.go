@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Recommendation is a single actionable suggestion in the daily report
+type Recommendation struct {
+	Priority string `json:"priority"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// Rule inspects daily analytics and returns a recommendation, or nil when it doesn't apply
+type Rule interface {
+	Name() string
+	Evaluate(a DailyAnalytics) *Recommendation
+}
+
+// HighAveragePowerRule flags days whose average power exceeds ThresholdKW
+type HighAveragePowerRule struct {
+	ThresholdKW float64
+}
+
+func (r HighAveragePowerRule) Name() string { return "high_average_power" }
+
+func (r HighAveragePowerRule) Evaluate(a DailyAnalytics) *Recommendation {
+	if a.AveragePower <= r.ThresholdKW {
+		return nil
+	}
+	return &Recommendation{
+		Priority: "high",
+		Category: "consumption",
+		Message:  "Average power is high. Consider load shifting and efficiency measures.",
+	}
+}
+
+// LowPowerFactorRule flags a measured power factor below MinPowerFactor
+type LowPowerFactorRule struct {
+	MinPowerFactor float64
+}
+
+func (r LowPowerFactorRule) Name() string { return "low_power_factor" }
+
+func (r LowPowerFactorRule) Evaluate(a DailyAnalytics) *Recommendation {
+	// Zero means no power factor could be computed, not a bad one
+	if a.PowerFactor <= 0 || a.PowerFactor >= r.MinPowerFactor {
+		return nil
+	}
+	return &Recommendation{
+		Priority: "medium",
+		Category: "efficiency",
+		Message:  fmt.Sprintf("Low power factor (%.3f). Evaluate correction equipment.", a.PowerFactor),
+	}
+}
+
+// VoltageVariabilityRule flags a voltage standard deviation above MaxStdDev
+type VoltageVariabilityRule struct {
+	MaxStdDev float64
+}
+
+func (r VoltageVariabilityRule) Name() string { return "voltage_variability" }
+
+func (r VoltageVariabilityRule) Evaluate(a DailyAnalytics) *Recommendation {
+	if a.VoltageStdDev <= r.MaxStdDev {
+		return nil
+	}
+	return &Recommendation{
+		Priority: "high",
+		Category: "quality",
+		Message:  "High voltage variability detected. Inspect electrical infrastructure.",
+	}
+}
+
+// BusinessHoursPeakRule suggests load shifting when the peak hour falls within [StartHour, EndHour]
+type BusinessHoursPeakRule struct {
+	StartHour int
+	EndHour   int
+}
+
+func (r BusinessHoursPeakRule) Name() string { return "business_hours_peak" }
+
+func (r BusinessHoursPeakRule) Evaluate(a DailyAnalytics) *Recommendation {
+	if a.PeakHour == "" {
+		return nil
+	}
+	h, err := strconv.Atoi(a.PeakHour)
+	if err != nil || h < r.StartHour || h > r.EndHour {
+		return nil
+	}
+	return &Recommendation{
+		Priority: "low",
+		Category: "optimization",
+		Message:  fmt.Sprintf("Peak at %s:00. Shift non-critical loads to off-peak hours.", a.PeakHour),
+	}
+}
+
+// loadRules assembles the rule set from env. RECOMMENDATION_RULES is a
+// comma-separated list of rule names to enable (default: all); thresholds
+// default to the values the report has always used.
+func loadRules() []Rule {
+	all := []Rule{
+		HighAveragePowerRule{ThresholdKW: envFloat("REC_HIGH_AVG_POWER_KW", 50)},
+		LowPowerFactorRule{MinPowerFactor: envFloat("REC_MIN_POWER_FACTOR", 0.85)},
+		VoltageVariabilityRule{MaxStdDev: envFloat("REC_MAX_VOLTAGE_STDDEV", 10)},
+		BusinessHoursPeakRule{
+			StartHour: envInt("REC_PEAK_START_HOUR", 9),
+			EndHour:   envInt("REC_PEAK_END_HOUR", 17),
+		},
+	}
+
+	enabled := strings.TrimSpace(os.Getenv("RECOMMENDATION_RULES"))
+	if enabled == "" {
+		return all
+	}
+
+	want := make(map[string]bool)
+	for _, name := range strings.Split(enabled, ",") {
+		want[strings.TrimSpace(name)] = true
+	}
+
+	var rules []Rule
+	for _, r := range all {
+		if want[r.Name()] {
+			rules = append(rules, r)
+			delete(want, r.Name())
+		}
+	}
+	for name := range want {
+		fmt.Printf("WARN: unknown recommendation rule %q ignored\n", name)
+	}
+	return rules
+}
+
+// generateRecommendations runs every configured rule against the day's analytics
+func generateRecommendations(a DailyAnalytics) []Recommendation {
+	var recs []Recommendation
+	for _, r := range recommendationRules {
+		if rec := r.Evaluate(a); rec != nil {
+			recs = append(recs, *rec)
+		}
+	}
+	return recs
+}
+
+func envFloat(key string, def float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return f
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		a        DailyAnalytics
+		wantRec  bool
+		category string
+	}{
+		{name: "average power above threshold", rule: HighAveragePowerRule{ThresholdKW: 50}, a: DailyAnalytics{AveragePower: 51}, wantRec: true, category: "consumption"},
+		{name: "average power at threshold", rule: HighAveragePowerRule{ThresholdKW: 50}, a: DailyAnalytics{AveragePower: 50}},
+		{name: "low power factor", rule: LowPowerFactorRule{MinPowerFactor: 0.85}, a: DailyAnalytics{PowerFactor: 0.7}, wantRec: true, category: "efficiency"},
+		{name: "no power factor computed", rule: LowPowerFactorRule{MinPowerFactor: 0.85}, a: DailyAnalytics{}},
+		{name: "voltage variability", rule: VoltageVariabilityRule{MaxStdDev: 10}, a: DailyAnalytics{VoltageStdDev: 12}, wantRec: true, category: "quality"},
+		{name: "peak in business hours", rule: BusinessHoursPeakRule{StartHour: 9, EndHour: 17}, a: DailyAnalytics{PeakHour: "14"}, wantRec: true, category: "optimization"},
+		{name: "peak overnight", rule: BusinessHoursPeakRule{StartHour: 9, EndHour: 17}, a: DailyAnalytics{PeakHour: "03"}},
+		{name: "no peak hour", rule: BusinessHoursPeakRule{StartHour: 9, EndHour: 17}, a: DailyAnalytics{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tt.rule.Evaluate(tt.a)
+			if (rec != nil) != tt.wantRec {
+				t.Fatalf("Evaluate = %+v, want recommendation %v", rec, tt.wantRec)
+			}
+			if rec != nil && rec.Category != tt.category {
+				t.Errorf("category = %q, want %q", rec.Category, tt.category)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled string
+		want    []string
+	}{
+		{name: "all by default", want: []string{"high_average_power", "low_power_factor", "voltage_variability", "business_hours_peak"}},
+		{name: "subset", enabled: "voltage_variability, high_average_power", want: []string{"high_average_power", "voltage_variability"}},
+		{name: "unknown rule ignored", enabled: "low_power_factor,bogus", want: []string{"low_power_factor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RECOMMENDATION_RULES", tt.enabled)
+			rules := loadRules()
+			if len(rules) != len(tt.want) {
+				t.Fatalf("got %d rules, want %v", len(rules), tt.want)
+			}
+			for i, r := range rules {
+				if r.Name() != tt.want[i] {
+					t.Errorf("rule %d = %s, want %s", i, r.Name(), tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Setenv("RECOMMENDATION_RULES", "")
+	t.Setenv("REC_HIGH_AVG_POWER_KW", "5")
+	if rec := loadRules()[0].Evaluate(DailyAnalytics{AveragePower: 6}); rec == nil {
+		t.Error("REC_HIGH_AVG_POWER_KW=5 didn't lower the threshold")
+	}
+}
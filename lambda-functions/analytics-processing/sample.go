@@ -0,0 +1,73 @@
+package main
+
+import "strings"
+
+// Default cap on the readings analysed in sample mode (ANALYTICS_SAMPLE_MAX_READINGS)
+const defaultSampleMaxReadings = 5000
+
+// sampleEnabled reports whether a day's analytics may be computed from a
+// sample: the event's sample flag, or ANALYTICS_SAMPLE=true when it is unset.
+// Full analysis is the default.
+func sampleEnabled(event LambdaEvent) bool {
+	if event.Sample != nil {
+		return *event.Sample
+	}
+	return strings.EqualFold(getenv("ANALYTICS_SAMPLE", "false"), "true")
+}
+
+// strideSample keeps every stride-th reading of each meter, starting with
+// each meter's first, at the smallest stride that leaves at most max.
+// Striding the facility's interleaved readings instead could keep a single
+// meter whenever the stride is a multiple of the meter count. Readings are
+// oldest first, so each meter's sample spreads evenly over the day. At or
+// under max, readings are returned unchanged with stride 1.
+func strideSample(readings []Reading, max int) ([]Reading, int) {
+	if max <= 0 || len(readings) <= max {
+		return readings, 1
+	}
+	byMeter := make(map[string]int)
+	for _, r := range readings {
+		byMeter[r.MeterID]++
+	}
+	// Each meter keeps ceil(n/stride), so the total can exceed len/stride
+	stride := (len(readings) + max - 1) / max
+	for ; ; stride++ {
+		kept := 0
+		for _, n := range byMeter {
+			kept += (n + stride - 1) / stride
+		}
+		if kept <= max || stride >= len(readings) {
+			break
+		}
+	}
+
+	sample := make([]Reading, 0, max)
+	seen := make(map[string]int, len(byMeter))
+	for _, r := range readings {
+		if seen[r.MeterID]%stride == 0 {
+			sample = append(sample, r)
+		}
+		seen[r.MeterID]++
+	}
+	return sample, stride
+}
+
+// applySampling flags analytics computed from a stride sample of all, the
+// readings before sampling. Time-weighted consumption and averages need no
+// correction; the plain sum of samples and the consumption derived from it
+// are scaled back up by the stride. A stride can skip the day's true peak and
+// minimum, so those are taken from all. Hourly and bucket figures remain those
+// of the sample.
+func applySampling(a *DailyAnalytics, all []Reading, stride int) {
+	if stride <= 1 {
+		return
+	}
+	a.Sampled = true
+	a.SampleSize = a.ReadingCount
+	a.SampleRate = float64(a.SampleSize) / float64(len(all))
+	a.ReadingCount = len(all)
+	a.PowerSampleSum *= float64(stride)
+	a.SampledConsumption *= float64(stride)
+	a.PeakPower = maxPower(all)
+	a.MinPower = minPower(all)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStrideSample(t *testing.T) {
+	const t0 = 1700000000
+	two := interleave(steady("1", 10, t0, 99), steady("2", 20, t0, 99)) // 200 readings
+	four := interleave(steady("1", 1, t0, 99), steady("2", 2, t0, 99), steady("3", 3, t0, 99), steady("4", 4, t0, 99))
+	three := interleave(steady("1", 1, t0, 9), steady("2", 2, t0, 9), steady("3", 3, t0, 9))
+	uneven := interleave(steady("1", 1, t0, 9), steady("2", 2, t0, 99))
+
+	tests := []struct {
+		name       string
+		readings   []Reading
+		max        int
+		wantStride int
+		wantMeters int
+	}{
+		{"under max is unchanged", two, 500, 1, 2},
+		{"stride equal to the meter count keeps both meters", two, 100, 2, 2},
+		{"stride a multiple of the meter count keeps every meter", four, 100, 4, 4},
+		{"uneven meters fit under max", uneven, 20, 6, 2},
+		{"stride is raised until every meter's share fits", three, 10, 4, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, stride := strideSample(tt.readings, tt.max)
+			if stride != tt.wantStride {
+				t.Errorf("stride = %d, want %d", stride, tt.wantStride)
+			}
+			if len(sample) > tt.max {
+				t.Errorf("kept %d readings, max %d", len(sample), tt.max)
+			}
+			meters := make(map[string]int)
+			for i, r := range sample {
+				meters[r.MeterID]++
+				if i > 0 && r.Timestamp < sample[i-1].Timestamp {
+					t.Fatalf("sample not oldest first at %d", i)
+				}
+			}
+			if len(meters) != tt.wantMeters {
+				t.Errorf("sample holds %d meters, want %d", len(meters), tt.wantMeters)
+			}
+		})
+	}
+}
+
+func TestApplySamplingKeepsTruePeak(t *testing.T) {
+	all := steady("1", 10, 1700000000, 9)
+	all[3].PowerKW = 95 // skipped by a stride of 2
+	all[5].PowerKW = 1
+	sample, stride := strideSample(all, 5)
+	if stride != 2 {
+		t.Fatalf("stride = %d, want 2", stride)
+	}
+	a := DailyAnalytics{ReadingCount: len(sample), PeakPower: maxPower(sample), MinPower: minPower(sample), PowerSampleSum: 50}
+	applySampling(&a, all, stride)
+
+	if a.PeakPower != 95 || a.MinPower != 1 {
+		t.Errorf("peak/min = %v/%v, want 95/1 from every reading", a.PeakPower, a.MinPower)
+	}
+	if !a.Sampled || a.SampleSize != 5 || a.ReadingCount != 10 || a.SampleRate != 0.5 || a.PowerSampleSum != 100 {
+		t.Errorf("sampling fields = %+v", a)
+	}
+}
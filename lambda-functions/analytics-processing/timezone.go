@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// facilityZoneTTL bounds how long a warm container reuses a facility's time zone
+const facilityZoneTTL = time.Hour
+
+type cachedZone struct {
+	loc     *time.Location
+	expires time.Time
+}
+
+var (
+	facilityZoneMu    sync.Mutex
+	facilityZoneCache = make(map[string]cachedZone)
+)
+
+// facilityLocation returns the facility's time zone from the `timezone`
+// attribute (an IANA name such as "America/Chicago") of its Facilities item.
+// Facilities without one, unknown names and failed lookups fall back to UTC.
+func facilityLocation(ctx context.Context, facilityID string) *time.Location {
+	facilityZoneMu.Lock()
+	if c, ok := facilityZoneCache[facilityID]; ok && time.Now().Before(c.expires) {
+		facilityZoneMu.Unlock()
+		return c.loc
+	}
+	facilityZoneMu.Unlock()
+
+	loc := time.UTC
+	name, err := facilityTimezone(ctx, facilityID)
+	switch {
+	case err != nil:
+		// Not cached, so the next invocation retries
+		fmt.Printf("WARN facility timezone lookup failed; using UTC: %v\n", err)
+		return loc
+	case name != "":
+		if l, err := time.LoadLocation(name); err == nil {
+			loc = l
+		} else {
+			fmt.Printf("WARN facility %s has unknown timezone %q; using UTC\n", facilityID, name)
+		}
+	}
+
+	facilityZoneMu.Lock()
+	facilityZoneCache[facilityID] = cachedZone{loc: loc, expires: time.Now().Add(facilityZoneTTL)}
+	facilityZoneMu.Unlock()
+	return loc
+}
+
+func facilityTimezone(ctx context.Context, facilityID string) (string, error) {
+	out, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableFacilities),
+		Key: map[string]types.AttributeValue{
+			"facilityId": &types.AttributeValueMemberS{Value: facilityID},
+		},
+		// timezone is a reserved word
+		ProjectionExpression:     aws.String("#tz"),
+		ExpressionAttributeNames: map[string]string{"#tz": "timezone"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("get facility failed: %w", err)
+	}
+	if tz, ok := out.Item["timezone"].(*types.AttributeValueMemberS); ok {
+		return tz.Value, nil
+	}
+	return "", nil
+}
+
+// dayBounds returns the start of the local day `date` (YYYY-MM-DD) in loc and
+// the start of the next one; across DST changes the day is 23 or 25 hours
+func dayBounds(date string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bad date format %q: %w", date, err)
+	}
+	return start, start.AddDate(0, 0, 1), nil
+}
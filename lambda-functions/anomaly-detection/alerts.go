@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// errAlertExists is returned when the alert for a reading is already stored,
+// i.e. the stream record is being processed again
+var errAlertExists = errors.New("alert already stored")
+
+// alertID derives the ID of the alert of alertType for a reading from the
+// facility, meter, reading timestamp and type, so a retried or replayed
+// stream record maps to the alert it raised the first time. The reading's
+// timestamp leads, keeping the "alert-<unix>-..." shape of manual alerts.
+func alertID(reading *Reading, alertType string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", reading.FacilityID, reading.MeterID, reading.Timestamp, alertType)))
+	return fmt.Sprintf("alert-%d-%s", reading.Timestamp, hex.EncodeToString(sum[:8]))
+}
+
+// writeAlert stores alert unless an alert with its ID exists, in which case
+// the stored one is left as is (it may have been acknowledged since) and
+// errAlertExists is returned
+func writeAlert(ctx context.Context, alert Alert) error {
+	item, err := ddbattr.MarshalMap(alert)
+	if err != nil {
+		return fmt.Errorf("marshal %s alert failed: %w", alert.Type, err)
+	}
+
+	_, err = dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(tableAlerts),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(alertId)"),
+	})
+	var exists *types.ConditionalCheckFailedException
+	if errors.As(err, &exists) {
+		return fmt.Errorf("%w: %s", errAlertExists, alert.AlertID)
+	}
+	if err != nil {
+		return fmt.Errorf("put %s alert failed: %w", alert.Type, err)
+	}
+	return nil
+}
+
+// putAlert stores an alert of the given type for the reading's meter and
+// returns its ID; see writeAlert for a reading that already has one
+func putAlert(ctx context.Context, reading *Reading, alertType, severity, message string, metadata map[string]interface{}) (string, error) {
+	id := alertID(reading, alertType)
+	return id, writeAlert(ctx, Alert{
+		AlertID:     id,
+		FacilityID:  reading.FacilityID,
+		EquipmentID: reading.MeterID,
+		Timestamp:   time.Now().Unix(),
+		Severity:    severity,
+		Type:        alertType,
+		Message:     message,
+		Metadata:    metadata,
+	})
+}
+
+// publishAlert sends a notification for a non-statistical alert type using the
+// configured subject template; fallback is used if the template fails to render
+func publishAlert(ctx context.Context, reading *Reading, alertType, severity, fallback, message string) error {
+	if topicArn == "" {
+		return nil
+	}
+
+	subject, err := renderSubject(subjectTmpl, map[string]string{
+		"severity": severity,
+		"facility": reading.FacilityID,
+		"meter":    reading.MeterID,
+		"type":     alertType,
+	})
+	if err != nil {
+		subject = truncateSubject(fallback)
+	}
+
+	_, err = snsClient.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(topicArn),
+		Subject:  aws.String(subject),
+		Message:  aws.String(message),
+	})
+	if err != nil {
+		return fmt.Errorf("sns publish failed: %w", err)
+	}
+	return nil
+}
+
+// raiseAlert stores an alert of alertType with store and, unless the meter is
+// in cooldown for the type and severity, notifies with send and marks the
+// alert notified. A retried or replayed record finds its alert stored; it is
+// notified only if the first attempt's notification never went out (held
+// back by a cooldown or failed). replayed reports whether the alert was
+// already stored.
+func raiseAlert(ctx context.Context, reading *Reading, alertType, severity string,
+	store func(context.Context) (string, error), send func(context.Context) error) (id string, replayed bool) {
+	now := time.Now()
+	id, err := store(ctx)
+	replayed = errors.Is(err, errAlertExists)
+	if err != nil && !replayed {
+		fmt.Printf("Error storing %s alert for meter %s: %v\n", alertType, reading.MeterID, err)
+	}
+
+	if replayed {
+		notified, err := alertNotified(ctx, id)
+		if err != nil {
+			// Fail open: a duplicate notification beats a missed one
+			fmt.Printf("WARN %v\n", err)
+		}
+		if notified {
+			fmt.Printf("%s alert %s already notified; not notifying again\n", alertType, id)
+			return id, replayed
+		}
+	}
+
+	if inCooldown(ctx, reading, alertType, severity, now) {
+		fmt.Printf("%s %s notification for meter %s suppressed by cooldown\n", severity, alertType, reading.MeterID)
+		return id, replayed
+	}
+	if err := send(ctx); err != nil {
+		fmt.Printf("Error sending %s SNS for meter %s: %v\n", alertType, reading.MeterID, err)
+		return id, replayed
+	}
+	markNotified(ctx, reading, alertType, severity, id, now)
+	return id, replayed
+}
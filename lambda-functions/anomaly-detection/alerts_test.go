@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRaiseAlert(t *testing.T) {
+	reading := &Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: time.Now().Unix()}
+
+	tests := []struct {
+		name string
+		// failFirst fails the first attempt's notification
+		failFirst bool
+		// cooldownFirst holds back the first attempt's notification
+		cooldownFirst bool
+		wantSent      int
+		wantReplayed  bool
+	}{
+		{name: "replay after notifying", wantSent: 1, wantReplayed: true},
+		{name: "replay after a failed send", failFirst: true, wantSent: 1, wantReplayed: true},
+		{name: "replay after a suppressed send, still in cooldown", cooldownFirst: true, wantSent: 0, wantReplayed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+			store := func(ctx context.Context) (string, error) {
+				return putAlert(ctx, reading, rapidChange, "high", "ramp", nil)
+			}
+			send := func(ctx context.Context) error {
+				return publishAlert(ctx, reading, rapidChange, "high", "ramp", "ramp")
+			}
+			if tt.cooldownFirst {
+				cooldowns.last[cooldownKey(reading.FacilityID, reading.MeterID, rapidChange, "high")] = time.Now()
+			}
+			pub.fail = tt.failFirst
+
+			id, replayed := raiseAlert(context.Background(), reading, rapidChange, "high", store, send)
+			if replayed {
+				t.Fatal("first attempt reported as replayed")
+			}
+			if id != alertID(reading, rapidChange) {
+				t.Errorf("id = %q, want %q", id, alertID(reading, rapidChange))
+			}
+
+			// The stream delivers the record again
+			if _, replayed = raiseAlert(context.Background(), reading, rapidChange, "high", store, send); replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if len(db.alerts) != 1 {
+				t.Errorf("%d alerts stored, want 1", len(db.alerts))
+			}
+			if pub.published != tt.wantSent {
+				t.Errorf("%d notifications sent, want %d", pub.published, tt.wantSent)
+			}
+			_, notified := attrN(db.alerts[id], "notifiedAt")
+			if notified != (tt.wantSent > 0) {
+				t.Errorf("notifiedAt set = %v, want %v", notified, tt.wantSent > 0)
+			}
+		})
+	}
+}
+
+func TestHandlerReplayDoesNotDuplicate(t *testing.T) {
+	db, pub := useFakes(t)
+	// Numbers as strings, which parseReading accepts like stream numbers
+	record := events.DynamoDBEventRecord{
+		EventName: "INSERT",
+		Change: events.DynamoDBStreamRecord{
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"facilityId":  events.NewStringAttribute("facility-001"),
+				"meterId":     events.NewStringAttribute("meter-1"),
+				"timestamp":   events.NewStringAttribute("1700000000"),
+				"powerKw":     events.NewStringAttribute("12.5"),
+				"frequencyHz": events.NewStringAttribute("47.0"),
+			},
+		},
+	}
+	event := events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{record}}
+
+	var alerts, sent int
+	for attempt := 1; attempt <= 3; attempt++ {
+		// Each attempt may land on a fresh container
+		cooldowns.last = make(map[string]time.Time)
+		if err := Handler(context.Background(), event); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if attempt == 1 {
+			alerts, sent = len(db.alerts), pub.published
+		}
+	}
+	if alerts == 0 || sent != alerts {
+		t.Fatalf("first attempt stored %d alerts and sent %d notifications", alerts, sent)
+	}
+	if len(db.alerts) != alerts {
+		t.Errorf("%d alerts stored after replays, want %d", len(db.alerts), alerts)
+	}
+	if pub.published != sent {
+		t.Errorf("%d notifications sent after replays, want %d", pub.published, sent)
+	}
+}
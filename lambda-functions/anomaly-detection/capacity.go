@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/capacity"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Capacity check statuses
+const (
+	capacityOK   = "ok"
+	capacityNear = "near_capacity"
+	capacityOver = "over_capacity"
+)
+
+// CapacityResult is the outcome of comparing sustained facility load with capacity
+type CapacityResult struct {
+	Status             string  `json:"status"`
+	CapacityKW         float64 `json:"capacity_kw"`
+	ThresholdKW        float64 `json:"threshold_kw"`
+	SustainedKW        float64 `json:"sustained_kw"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+}
+
+// Triggered reports whether the result should raise a near_capacity alert
+func (c CapacityResult) Triggered() bool { return c.Status == capacityNear || c.Status == capacityOver }
+
+// getFacilityReadings returns every meter's readings for the facility in
+// [start, end], oldest first
+func getFacilityReadings(ctx context.Context, facilityID string, start, end int64) ([]Reading, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableReadings),
+		KeyConditionExpression: aws.String("facilityId = :fid AND #ts BETWEEN :start AND :end"),
+		ExpressionAttributeNames: map[string]string{
+			"#ts": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fid":   &types.AttributeValueMemberS{Value: facilityID},
+			":start": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", start)},
+			":end":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", end)},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}
+
+	var all []Reading
+	for page := 0; page < historyMaxPages; page++ {
+		out, err := dynamoClient.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("dynamodb query failed: %w", err)
+		}
+		var items []Reading
+		if err := ddbattr.UnmarshalListOfMaps(out.Items, &items); err != nil {
+			return nil, fmt.Errorf("unmarshal readings failed: %w", err)
+		}
+		all = append(all, items...)
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	return all, nil
+}
+
+// capacityLookback is how far back checkCapacity needs the facility's
+// readings: the meter's last `sustained` intervals, plus stale before the
+// first of them so every other meter's reading at that instant is included
+func capacityLookback(sustained, intervalSeconds int, stale time.Duration) int64 {
+	return int64(sustained*intervalSeconds) + int64(stale.Seconds())
+}
+
+// checkCapacity compares the facility load against thresholdPct of capacity.
+// The load is summed over every meter (see capacity.Load) at the instants of
+// the current meter's last `sustained` readings, and the lowest of those is
+// the sustained load, so a single spike doesn't trigger the alert.
+func checkCapacity(current *Reading, facility []Reading, capacityKW, thresholdPct float64, sustained int, stale time.Duration) CapacityResult {
+	res := CapacityResult{Status: capacityOK, CapacityKW: capacityKW}
+	if capacityKW <= 0 {
+		return res
+	}
+	if sustained < 1 {
+		sustained = 1
+	}
+	res.ThresholdKW = capacityKW * thresholdPct / 100
+
+	samples := make([]capacity.Sample, 0, len(facility)+1)
+	var instants []int64
+	for _, r := range facility {
+		if r.Timestamp > current.Timestamp || (r.MeterID == current.MeterID && r.Timestamp == current.Timestamp) {
+			continue
+		}
+		samples = append(samples, capacity.Sample{MeterID: r.MeterID, Time: time.Unix(r.Timestamp, 0), PowerKW: r.PowerKW})
+		if r.MeterID == current.MeterID {
+			instants = append(instants, r.Timestamp)
+		}
+	}
+	samples = append(samples, capacity.Sample{MeterID: current.MeterID, Time: time.Unix(current.Timestamp, 0), PowerKW: current.PowerKW})
+	sort.Slice(instants, func(i, j int) bool { return instants[i] < instants[j] })
+	instants = append(instants, current.Timestamp)
+	if len(instants) < sustained {
+		return res
+	}
+
+	for i, ts := range instants[len(instants)-sustained:] {
+		load := capacity.Load(samples, time.Unix(ts, 0), stale)
+		if i == 0 || load < res.SustainedKW {
+			res.SustainedKW = load
+		}
+	}
+	res.UtilizationPercent = res.SustainedKW / capacityKW * 100
+
+	switch {
+	case res.SustainedKW >= capacityKW:
+		res.Status = capacityOver
+	case res.SustainedKW >= res.ThresholdKW:
+		res.Status = capacityNear
+	}
+	return res
+}
+
+func capacitySeverity(c CapacityResult) string {
+	if c.Status == capacityOver {
+		return "critical"
+	}
+	return "high"
+}
+
+func capacityMessage(c CapacityResult) string {
+	return fmt.Sprintf("Sustained facility load %.2f kW is %.1f%% of %.2f kW capacity (alert threshold %.2f kW)",
+		c.SustainedKW, c.UtilizationPercent, c.CapacityKW, c.ThresholdKW)
+}
+
+// storeCapacityAlert writes a near_capacity alert and returns its ID
+func storeCapacityAlert(ctx context.Context, reading *Reading, c CapacityResult) (string, error) {
+	return putAlert(ctx, reading, capacityNear, capacitySeverity(c), capacityMessage(c), map[string]interface{}{
+		"status":              c.Status,
+		"capacity_kw":         c.CapacityKW,
+		"threshold_kw":        c.ThresholdKW,
+		"sustained_kw":        c.SustainedKW,
+		"utilization_percent": c.UtilizationPercent,
+	})
+}
+
+func sendCapacityAlert(ctx context.Context, reading *Reading, c CapacityResult) error {
+	severity := capacitySeverity(c)
+	message := fmt.Sprintf("Facility Load Near Capacity\n\nFacility: %s\nMeter: %s\nSeverity: %s\n\n%s\nTime: %s",
+		reading.FacilityID, reading.MeterID, severity, capacityMessage(c), time.Now().Format(time.RFC3339))
+
+	return publishAlert(ctx, reading, capacityNear, severity,
+		fmt.Sprintf("[%s] Energy Grid Capacity - %s", severity, reading.FacilityID), message)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckCapacity(t *testing.T) {
+	const capKW = 1000
+	stale := 2 * time.Minute
+	// m1 reports every minute; the current reading is m1 at t=180
+	m1 := func(ts int64, kw float64) Reading { return Reading{MeterID: "m1", Timestamp: ts, PowerKW: kw} }
+	m2 := func(ts int64, kw float64) Reading { return Reading{MeterID: "m2", Timestamp: ts, PowerKW: kw} }
+
+	tests := []struct {
+		name       string
+		facility   []Reading
+		currentKW  float64
+		wantStatus string
+		wantKW     float64
+	}{
+		{
+			name:       "below threshold",
+			facility:   []Reading{m1(60, 500), m1(120, 500)},
+			currentKW:  500,
+			wantStatus: capacityOK,
+			wantKW:     500,
+		},
+		{
+			name:       "approaching capacity",
+			facility:   []Reading{m1(60, 920), m1(120, 950)},
+			currentKW:  930,
+			wantStatus: capacityNear,
+			wantKW:     920,
+		},
+		{
+			name:       "over capacity",
+			facility:   []Reading{m1(60, 1100), m1(120, 1050)},
+			currentKW:  1200,
+			wantStatus: capacityOver,
+			wantKW:     1050,
+		},
+		{
+			name:       "single spike isn't sustained",
+			facility:   []Reading{m1(60, 400), m1(120, 400)},
+			currentKW:  1200,
+			wantStatus: capacityOK,
+			wantKW:     400,
+		},
+		{
+			// Neither meter is near 1000 kW alone, but together they are
+			name:       "concurrent meters summed",
+			facility:   []Reading{m2(30, 500), m1(60, 450), m2(90, 500), m1(120, 450), m2(150, 500)},
+			currentKW:  450,
+			wantStatus: capacityNear,
+			wantKW:     950,
+		},
+		{
+			name:       "stale meter not counted",
+			facility:   []Reading{m2(-200, 500), m1(60, 450), m1(120, 450)},
+			currentKW:  450,
+			wantStatus: capacityOK,
+			wantKW:     450,
+		},
+		{
+			name:       "not enough readings",
+			facility:   []Reading{m1(120, 1200)},
+			currentKW:  1200,
+			wantStatus: capacityOK,
+		},
+		{
+			name:       "current reading already stored",
+			facility:   []Reading{m1(60, 1100), m1(120, 1100), m1(180, 1100)},
+			currentKW:  1100,
+			wantStatus: capacityOver,
+			wantKW:     1100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := m1(180, tt.currentKW)
+			got := checkCapacity(&current, tt.facility, capKW, 90, 3, stale)
+			if got.Status != tt.wantStatus || got.SustainedKW != tt.wantKW {
+				t.Errorf("checkCapacity = %s at %v kW, want %s at %v kW", got.Status, got.SustainedKW, tt.wantStatus, tt.wantKW)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Default notification cooldowns; critical re-notifies much sooner than low
+var defaultCooldowns = map[string]time.Duration{
+	"critical": 5 * time.Minute,
+	"high":     30 * time.Minute,
+	"low":      2 * time.Hour,
+}
+
+// loadCooldowns reads COOLDOWN_CRITICAL, COOLDOWN_HIGH and COOLDOWN_LOW as Go
+// durations ("15m") or plain seconds; 0 disables the cooldown for a severity
+func loadCooldowns() map[string]time.Duration {
+	out := make(map[string]time.Duration, len(defaultCooldowns))
+	for severity, def := range defaultCooldowns {
+		out[severity] = parseCooldown(getenv("COOLDOWN_"+strings.ToUpper(severity), ""), def)
+	}
+	return out
+}
+
+func parseCooldown(raw string, def time.Duration) time.Duration {
+	if raw == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return d
+	}
+	if n, err := strconv.Atoi(raw); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	fmt.Printf("WARN: invalid cooldown %q; using %s\n", raw, def)
+	return def
+}
+
+// cooldownFor returns the suppression window for a severity; unknown
+// severities use the high window
+func cooldownFor(cooldowns map[string]time.Duration, severity string) time.Duration {
+	if d, ok := cooldowns[strings.ToLower(severity)]; ok {
+		return d
+	}
+	return cooldowns["high"]
+}
+
+// cooldownTracker remembers when each meter/type/severity last notified.
+// The in-memory map covers a warm container; the notifiedAt marker on stored
+// alerts covers notifications sent by other concurrent or earlier containers.
+type cooldownTracker struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+var cooldowns = &cooldownTracker{last: make(map[string]time.Time)}
+
+func cooldownKey(facilityID, meterID, alertType, severity string) string {
+	return facilityID + "|" + meterID + "|" + alertType + "|" + strings.ToLower(severity)
+}
+
+// inCooldown reports whether a notification with this severity was sent for
+// the meter and alert type within the severity's window. Alerts stored while
+// their notification was suppressed don't count, so a condition that keeps
+// firing still re-notifies once per window.
+func inCooldown(ctx context.Context, reading *Reading, alertType, severity string, now time.Time) bool {
+	window := cooldownFor(cooldownWindows, severity)
+	if window <= 0 {
+		return false
+	}
+
+	key := cooldownKey(reading.FacilityID, reading.MeterID, alertType, severity)
+	cooldowns.mu.Lock()
+	last, ok := cooldowns.last[key]
+	cooldowns.mu.Unlock()
+	if ok && now.Sub(last) < window {
+		return true
+	}
+
+	recent, err := recentNotification(ctx, reading, alertType, severity, now.Add(-window))
+	if err != nil {
+		// Fail open: a duplicate notification beats a missed one
+		fmt.Printf("WARN cooldown lookup failed: %v\n", err)
+		return false
+	}
+	return recent
+}
+
+// markNotified starts the cooldown window for the meter, type and severity
+// and marks alertID as notified, for other containers and for replays of the
+// record
+func markNotified(ctx context.Context, reading *Reading, alertType, severity, alertID string, now time.Time) {
+	cooldowns.mu.Lock()
+	cooldowns.last[cooldownKey(reading.FacilityID, reading.MeterID, alertType, severity)] = now
+	cooldowns.mu.Unlock()
+
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableAlerts),
+		Key:                 map[string]types.AttributeValue{"alertId": &types.AttributeValueMemberS{Value: alertID}},
+		UpdateExpression:    aws.String("SET notifiedAt = :now"),
+		ConditionExpression: aws.String("attribute_exists(alertId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		fmt.Printf("WARN marking alert %s notified failed: %v\n", alertID, err)
+	}
+}
+
+// alertNotified reports whether the stored alert's notification went out
+func alertNotified(ctx context.Context, alertID string) (bool, error) {
+	out, err := dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(tableAlerts),
+		Key:                  map[string]types.AttributeValue{"alertId": &types.AttributeValueMemberS{Value: alertID}},
+		ProjectionExpression: aws.String("notifiedAt"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("get alert %s failed: %w", alertID, err)
+	}
+	_, ok := out.Item["notifiedAt"]
+	return ok, nil
+}
+
+// recentNotification looks for an alert of the same type and severity for
+// the meter whose notification went out since `since`. The filter applies
+// after DynamoDB reads each page, so every page is checked.
+func recentNotification(ctx context.Context, reading *Reading, alertType, severity string, since time.Time) (bool, error) {
+	in := &dynamodb.QueryInput{
+		TableName:              aws.String(tableAlerts),
+		IndexName:              aws.String("facilityId-timestamp-index"),
+		KeyConditionExpression: aws.String("facilityId = :fid AND #ts >= :since"),
+		FilterExpression:       aws.String("equipmentId = :eq AND #type = :type AND severity = :sev AND notifiedAt >= :since"),
+		ExpressionAttributeNames: map[string]string{
+			"#ts":   "timestamp",
+			"#type": "type",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fid":   &types.AttributeValueMemberS{Value: reading.FacilityID},
+			":since": &types.AttributeValueMemberN{Value: strconv.FormatInt(since.Unix(), 10)},
+			":eq":    &types.AttributeValueMemberS{Value: reading.MeterID},
+			":type":  &types.AttributeValueMemberS{Value: alertType},
+			":sev":   &types.AttributeValueMemberS{Value: severity},
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+	for {
+		out, err := dynamoClient.Query(ctx, in)
+		if err != nil {
+			return false, fmt.Errorf("query recent notifications failed: %w", err)
+		}
+		if len(out.Items) > 0 {
+			return true, nil
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return false, nil
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
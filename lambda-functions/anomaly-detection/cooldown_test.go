@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// fakeDynamo keeps the alerts table in memory. Queries on the alerts table
+// apply recentNotification's filter and return pageSize alerts per page
+// (before filtering, as DynamoDB does); queries on the equipment table return
+// the facility's equipment items; queries on the readings table page through
+// readings as DynamoDB would (see queryReadings). Other tables are empty.
+type fakeDynamo struct {
+	mu       sync.Mutex
+	alerts   map[string]map[string]types.AttributeValue
+	pageSize int
+	queries  int
+
+	equipment      []map[string]types.AttributeValue
+	equipmentReads int
+
+	readings     []Reading
+	readingPages int
+}
+
+func newFakeDynamo() *fakeDynamo {
+	return &fakeDynamo{alerts: make(map[string]map[string]types.AttributeValue), pageSize: 100}
+}
+
+func attrS(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func attrN(item map[string]types.AttributeValue, name string) (int64, bool) {
+	v, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, false
+	}
+	n, _ := strconv.ParseInt(v.Value, 10, 64)
+	return n, true
+}
+
+func (f *fakeDynamo) Query(ctx context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if aws.ToString(in.TableName) == tableEquipment {
+		f.equipmentReads++
+		out := &dynamodb.QueryOutput{}
+		for _, item := range f.equipment {
+			if attrS(item, "facilityId") == attrS(in.ExpressionAttributeValues, ":fid") {
+				out.Items = append(out.Items, item)
+			}
+		}
+		return out, nil
+	}
+	if aws.ToString(in.TableName) == tableReadings {
+		return f.queryReadings(in)
+	}
+	if aws.ToString(in.TableName) != tableAlerts {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	f.queries++
+
+	ids := make([]string, 0, len(f.alerts))
+	for id := range f.alerts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if start := attrS(in.ExclusiveStartKey, "alertId"); start != "" {
+		ids = ids[sort.SearchStrings(ids, start)+1:]
+	}
+
+	out := &dynamodb.QueryOutput{}
+	if len(ids) > f.pageSize {
+		ids = ids[:f.pageSize]
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"alertId": &types.AttributeValueMemberS{Value: ids[len(ids)-1]}}
+	}
+	v := in.ExpressionAttributeValues
+	since, _ := attrN(v, ":since")
+	for _, id := range ids {
+		item := f.alerts[id]
+		ts, _ := attrN(item, "timestamp")
+		notified, ok := attrN(item, "notifiedAt")
+		if attrS(item, "facilityId") != attrS(v, ":fid") || ts < since ||
+			attrS(item, "equipmentId") != attrS(v, ":eq") || attrS(item, "type") != attrS(v, ":type") ||
+			attrS(item, "severity") != attrS(v, ":sev") || !ok || notified < since {
+			continue
+		}
+		out.Items = append(out.Items, item)
+	}
+	return out, nil
+}
+
+// queryReadings returns the facility's readings in [:start, :end] in sort key
+// order, Limit items per page. The meter filter (:mid) is applied after the
+// limit, so a page can hold fewer matches than Limit.
+func (f *fakeDynamo) queryReadings(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	f.readingPages++
+	v := in.ExpressionAttributeValues
+	start, _ := attrN(v, ":start")
+	end, _ := attrN(v, ":end")
+
+	var matched []Reading
+	for _, r := range f.readings {
+		if r.FacilityID == attrS(v, ":fid") && r.Timestamp >= start && r.Timestamp <= end {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if aws.ToBool(in.ScanIndexForward) || in.ScanIndexForward == nil {
+			return matched[i].Timestamp < matched[j].Timestamp
+		}
+		return matched[i].Timestamp > matched[j].Timestamp
+	})
+
+	offset, _ := attrN(in.ExclusiveStartKey, "offset")
+	matched = matched[offset:]
+	out := &dynamodb.QueryOutput{}
+	if limit := int(aws.ToInt32(in.Limit)); limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"offset": &types.AttributeValueMemberN{Value: strconv.Itoa(int(offset) + limit)}}
+	}
+	for _, r := range matched {
+		if mid := attrS(v, ":mid"); mid != "" && r.MeterID != mid {
+			continue
+		}
+		item, err := ddbattr.MarshalMap(r)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = append(out.Items, item)
+	}
+	out.Count = int32(len(out.Items))
+	return out, nil
+}
+
+func (f *fakeDynamo) GetItem(ctx context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if aws.ToString(in.TableName) != tableAlerts {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: f.alerts[attrS(in.Key, "alertId")]}, nil
+}
+
+func (f *fakeDynamo) PutItem(ctx context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := attrS(in.Item, "alertId")
+	if _, ok := f.alerts[id]; ok && in.ConditionExpression != nil {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("exists")}
+	}
+	f.alerts[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamo) UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if aws.ToString(in.TableName) == tableEquipment {
+		return f.updateHealth(in)
+	}
+	item, ok := f.alerts[attrS(in.Key, "alertId")]
+	if !ok {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("missing")}
+	}
+	item["notifiedAt"] = in.ExpressionAttributeValues[":now"]
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// fakeSNS counts and keeps published notifications; fail makes the next publish fail
+type fakeSNS struct {
+	mu        sync.Mutex
+	published int
+	messages  []string
+	fail      bool
+}
+
+func (f *fakeSNS) Publish(ctx context.Context, in *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		f.fail = false
+		return nil, context.DeadlineExceeded
+	}
+	f.published++
+	f.messages = append(f.messages, aws.ToString(in.Message))
+	return &sns.PublishOutput{}, nil
+}
+
+// useFakes swaps in fake clients and an empty cooldown tracker for a test
+func useFakes(t *testing.T) (*fakeDynamo, *fakeSNS) {
+	t.Helper()
+	db, pub := newFakeDynamo(), &fakeSNS{}
+	prevDB, prevSNS, prevTopic := dynamoClient, snsClient, topicArn
+	dynamoClient, snsClient, topicArn = db, pub, "arn:aws:sns:us-east-1:000000000000:alerts"
+	cooldowns = &cooldownTracker{last: make(map[string]time.Time)}
+	t.Cleanup(func() {
+		dynamoClient, snsClient, topicArn = prevDB, prevSNS, prevTopic
+		cooldowns = &cooldownTracker{last: make(map[string]time.Time)}
+	})
+	return db, pub
+}
+
+func TestInCooldown(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reading := &Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now.Unix()}
+	other := &Reading{FacilityID: "facility-001", MeterID: "meter-2", Timestamp: now.Unix()}
+
+	tests := []struct {
+		name     string
+		stored   []Alert
+		pageSize int
+		severity string
+		want     bool
+	}{
+		{name: "nothing stored", severity: "high"},
+		{
+			name:     "notified within the window",
+			stored:   []Alert{{AlertID: "a1", FacilityID: "facility-001", EquipmentID: "meter-1", Type: "anomaly", Severity: "high", Timestamp: now.Unix() - 60, NotifiedAt: now.Unix() - 60}},
+			severity: "high",
+			want:     true,
+		},
+		{
+			name:     "stored while suppressed, never notified",
+			stored:   []Alert{{AlertID: "a1", FacilityID: "facility-001", EquipmentID: "meter-1", Type: "anomaly", Severity: "high", Timestamp: now.Unix() - 60}},
+			severity: "high",
+		},
+		{
+			name:     "notified before the window",
+			stored:   []Alert{{AlertID: "a1", FacilityID: "facility-001", EquipmentID: "meter-1", Type: "anomaly", Severity: "critical", Timestamp: now.Unix() - 600, NotifiedAt: now.Unix() - 600}},
+			severity: "critical",
+		},
+		{
+			name:     "other severity",
+			stored:   []Alert{{AlertID: "a1", FacilityID: "facility-001", EquipmentID: "meter-1", Type: "anomaly", Severity: "low", Timestamp: now.Unix() - 60, NotifiedAt: now.Unix() - 60}},
+			severity: "high",
+		},
+		{
+			name: "notification on a later page",
+			stored: []Alert{
+				{AlertID: "a1", FacilityID: "facility-001", EquipmentID: "meter-2", Type: "anomaly", Severity: "high", Timestamp: now.Unix() - 60, NotifiedAt: now.Unix() - 60},
+				{AlertID: "a2", FacilityID: "facility-001", EquipmentID: "meter-2", Type: "anomaly", Severity: "high", Timestamp: now.Unix() - 50, NotifiedAt: now.Unix() - 50},
+				{AlertID: "a3", FacilityID: "facility-001", EquipmentID: "meter-1", Type: "anomaly", Severity: "high", Timestamp: now.Unix() - 40, NotifiedAt: now.Unix() - 40},
+			},
+			pageSize: 1,
+			severity: "high",
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			if tt.pageSize > 0 {
+				db.pageSize = tt.pageSize
+			}
+			for _, a := range tt.stored {
+				if err := writeAlert(context.Background(), a); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := inCooldown(context.Background(), reading, "anomaly", tt.severity, now); got != tt.want {
+				t.Errorf("inCooldown = %v, want %v", got, tt.want)
+			}
+			if tt.pageSize == 1 && db.queries != 3 {
+				t.Errorf("queried %d pages, want 3", db.queries)
+			}
+		})
+	}
+
+	t.Run("marked notified", func(t *testing.T) {
+		db, _ := useFakes(t)
+		id, err := putAlert(context.Background(), reading, "anomaly", "high", "spike", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		markNotified(context.Background(), reading, "anomaly", "high", id, now)
+		if _, ok := attrN(db.alerts[id], "notifiedAt"); !ok {
+			t.Fatal("notifiedAt not stored")
+		}
+		if !inCooldown(context.Background(), reading, "anomaly", "high", now.Add(time.Minute)) {
+			t.Error("meter not in cooldown after notifying")
+		}
+		if inCooldown(context.Background(), other, "anomaly", "high", now.Add(time.Minute)) {
+			t.Error("other meter in cooldown")
+		}
+
+		// Another container only has the stored marker to go on
+		cooldowns = &cooldownTracker{last: make(map[string]time.Time)}
+		if !inCooldown(context.Background(), reading, "anomaly", "high", now.Add(time.Minute)) {
+			t.Error("stored marker not found by a cold container")
+		}
+	})
+}
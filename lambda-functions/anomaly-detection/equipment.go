@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// equipmentCacheTTL bounds how long a facility's equipment list is reused by a warm container
+const equipmentCacheTTL = 10 * time.Minute
+
+// Equipment is the subset of an Equipment table item used to give alerts context.
+// MeterID links the equipment to the meter that measures it.
+type Equipment struct {
+	EquipmentID  string `dynamodbav:"equipmentId"`
+	FacilityID   string `dynamodbav:"facilityId"`
+	MeterID      string `dynamodbav:"meterId"`
+	Type         string `dynamodbav:"type"`
+	Location     string `dynamodbav:"location"`
+	FacilityName string `dynamodbav:"facilityName"`
+	// SamplingIntervalSeconds overrides the meter's reporting cadence; 0 uses its type's
+	SamplingIntervalSeconds int `dynamodbav:"samplingIntervalSeconds"`
+}
+
+type cachedEquipment struct {
+	items   []Equipment
+	expires time.Time
+}
+
+var (
+	equipmentMu    sync.Mutex
+	equipmentCache = make(map[string]cachedEquipment)
+)
+
+// equipmentForMeter returns the equipment measured by the meter, matched on
+// meterId or, for items without one, on equipmentId equal to the meter ID.
+// It returns nil when nothing maps to the meter or the lookup fails.
+func equipmentForMeter(ctx context.Context, facilityID, meterID string) *Equipment {
+	items, err := facilityEquipment(ctx, facilityID)
+	if err != nil {
+		fmt.Printf("WARN equipment lookup failed: %v\n", err)
+		return nil
+	}
+	for i := range items {
+		if items[i].MeterID == meterID {
+			return &items[i]
+		}
+	}
+	for i := range items {
+		if items[i].MeterID == "" && (items[i].EquipmentID == meterID || items[i].EquipmentID == "meter-"+meterID) {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+func facilityEquipment(ctx context.Context, facilityID string) ([]Equipment, error) {
+	equipmentMu.Lock()
+	if c, ok := equipmentCache[facilityID]; ok && time.Now().Before(c.expires) {
+		equipmentMu.Unlock()
+		return c.items, nil
+	}
+	equipmentMu.Unlock()
+
+	out, err := dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(tableEquipment),
+		IndexName:              aws.String("facilityId-index"),
+		KeyConditionExpression: aws.String("facilityId = :fid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fid": &types.AttributeValueMemberS{Value: facilityID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query equipment failed: %w", err)
+	}
+
+	var items []Equipment
+	if err := ddbattr.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return nil, fmt.Errorf("unmarshal equipment failed: %w", err)
+	}
+
+	equipmentMu.Lock()
+	equipmentCache[facilityID] = cachedEquipment{items: items, expires: time.Now().Add(equipmentCacheTTL)}
+	equipmentMu.Unlock()
+	return items, nil
+}
+
+// equipmentMetadata returns the alert metadata fields for eq; empty fields are omitted
+func equipmentMetadata(eq *Equipment) map[string]interface{} {
+	if eq == nil {
+		return nil
+	}
+	md := map[string]interface{}{"equipment_id": eq.EquipmentID}
+	for k, v := range map[string]string{
+		"equipment_type": eq.Type,
+		"location":       eq.Location,
+		"facility_name":  eq.FacilityName,
+	} {
+		if v != "" {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+// equipmentLines renders the equipment context block of a notification
+func equipmentLines(eq *Equipment) string {
+	if eq == nil {
+		return "Equipment: not mapped to this meter\n"
+	}
+
+	var b strings.Builder
+	if eq.FacilityName != "" {
+		fmt.Fprintf(&b, "Facility Name: %s\n", eq.FacilityName)
+	}
+	if eq.Type != "" {
+		fmt.Fprintf(&b, "Equipment: %s (%s)\n", eq.EquipmentID, eq.Type)
+	} else {
+		fmt.Fprintf(&b, "Equipment: %s\n", eq.EquipmentID)
+	}
+	if eq.Location != "" {
+		fmt.Fprintf(&b, "Location: %s\n", eq.Location)
+	}
+	return b.String()
+}
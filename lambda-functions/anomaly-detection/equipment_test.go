@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func equipmentItem(fields map[string]string) map[string]types.AttributeValue {
+	item := make(map[string]types.AttributeValue, len(fields))
+	for k, v := range fields {
+		item[k] = &types.AttributeValueMemberS{Value: v}
+	}
+	return item
+}
+
+// useEquipment stocks the fake equipment table and empties the lookup cache
+func useEquipment(t *testing.T, db *fakeDynamo, items ...map[string]string) {
+	t.Helper()
+	for _, fields := range items {
+		db.equipment = append(db.equipment, equipmentItem(fields))
+	}
+	equipmentCache = make(map[string]cachedEquipment)
+	t.Cleanup(func() { equipmentCache = make(map[string]cachedEquipment) })
+}
+
+func TestEquipmentForMeter(t *testing.T) {
+	items := []map[string]string{
+		{"equipmentId": "pump-1", "facilityId": "facility-001", "meterId": "meter-1", "type": "pump", "location": "Basement", "facilityName": "North Plant"},
+		{"equipmentId": "meter-2", "facilityId": "facility-001", "type": "chiller"},
+		{"equipmentId": "fan-9", "facilityId": "facility-002", "meterId": "meter-3"},
+	}
+	tests := []struct {
+		name     string
+		facility string
+		meter    string
+		want     string // equipment ID, "" for none
+	}{
+		{name: "matched on meterId", facility: "facility-001", meter: "meter-1", want: "pump-1"},
+		{name: "matched on equipmentId", facility: "facility-001", meter: "meter-2", want: "meter-2"},
+		{name: "matched on meter- prefixed equipmentId", facility: "facility-001", meter: "2", want: "meter-2"},
+		{name: "other facility's equipment", facility: "facility-001", meter: "meter-3"},
+		{name: "unmapped meter", facility: "facility-001", meter: "meter-7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			useEquipment(t, db, items...)
+
+			eq := equipmentForMeter(context.Background(), tt.facility, tt.meter)
+			got := ""
+			if eq != nil {
+				got = eq.EquipmentID
+			}
+			if got != tt.want {
+				t.Errorf("equipment = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEquipmentLookupCached(t *testing.T) {
+	db, _ := useFakes(t)
+	useEquipment(t, db, map[string]string{"equipmentId": "pump-1", "facilityId": "facility-001", "meterId": "meter-1"})
+
+	for i := 0; i < 3; i++ {
+		equipmentForMeter(context.Background(), "facility-001", "meter-1")
+		equipmentForMeter(context.Background(), "facility-001", "meter-9")
+	}
+	if db.equipmentReads != 1 {
+		t.Errorf("equipment table read %d times, want 1", db.equipmentReads)
+	}
+}
+
+func TestAnomalyAlertEquipmentContext(t *testing.T) {
+	reading := &Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: 1_700_000_000, PowerKW: 40}
+	an := AnomalyResult{IsAnomaly: true, CurrentPower: 40, Mean: 20, Severity: "high"}
+	tests := []struct {
+		name         string
+		eq           *Equipment
+		wantLines    []string
+		wantMetadata map[string]string
+	}{
+		{
+			name:      "full context",
+			eq:        &Equipment{EquipmentID: "pump-1", Type: "pump", Location: "Basement", FacilityName: "North Plant"},
+			wantLines: []string{"Facility Name: North Plant\n", "Equipment: pump-1 (pump)\n", "Location: Basement\n"},
+			wantMetadata: map[string]string{
+				"equipment_id": "pump-1", "equipment_type": "pump", "location": "Basement", "facility_name": "North Plant",
+			},
+		},
+		{
+			name:         "partial context",
+			eq:           &Equipment{EquipmentID: "pump-1"},
+			wantLines:    []string{"Equipment: pump-1\n"},
+			wantMetadata: map[string]string{"equipment_id": "pump-1"},
+		},
+		{
+			name:      "no equipment",
+			wantLines: []string{"Equipment: not mapped to this meter\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+
+			if err := sendAlert(context.Background(), reading, an, tt.eq); err != nil {
+				t.Fatal(err)
+			}
+			if len(pub.messages) != 1 {
+				t.Fatalf("%d messages published, want 1", len(pub.messages))
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(pub.messages[0], line) {
+					t.Errorf("message lacks %q:\n%s", line, pub.messages[0])
+				}
+			}
+			if tt.eq == nil && strings.Contains(pub.messages[0], "Facility Name:") {
+				t.Errorf("message without equipment has a facility name:\n%s", pub.messages[0])
+			}
+
+			id, err := storeAlert(context.Background(), reading, an, tt.eq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			md, _ := db.alerts[id]["metadata"].(*types.AttributeValueMemberM)
+			for _, k := range []string{"equipment_id", "equipment_type", "location", "facility_name"} {
+				if got := attrS(md.Value, k); got != tt.wantMetadata[k] {
+					t.Errorf("metadata %s = %q, want %q", k, got, tt.wantMetadata[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// frequencyExcursion is the alert type for grid frequency outside tolerance
+const frequencyExcursion = "frequency_excursion"
+
+// FrequencyResult is the outcome of the absolute frequency band check
+type FrequencyResult struct {
+	Excursion   bool    `json:"excursion"`
+	FrequencyHz float64 `json:"frequency_hz"`
+	NominalHz   float64 `json:"nominal_hz"`
+	ToleranceHz float64 `json:"tolerance_hz"`
+	DeviationHz float64 `json:"deviation_hz"`
+}
+
+// checkFrequency flags a reading whose frequency is outside nominal ± tolerance
+// (e.g. 49.5–50.5 Hz or 59.5–60.5 Hz). It uses fixed limits rather than the
+// statistical window, since any excursion indicates grid instability.
+func checkFrequency(r *Reading, nominal, tolerance float64) FrequencyResult {
+	res := FrequencyResult{NominalHz: nominal, ToleranceHz: tolerance}
+	if r.FrequencyHz == nil || nominal <= 0 {
+		return res
+	}
+	res.FrequencyHz = *r.FrequencyHz
+	res.DeviationHz = res.FrequencyHz - nominal
+	res.Excursion = math.Abs(res.DeviationHz) > tolerance
+	return res
+}
+
+func frequencyMessage(f FrequencyResult) string {
+	return fmt.Sprintf("Grid frequency %.3f Hz is %+.3f Hz from nominal %.1f Hz (tolerance ±%.2f Hz)",
+		f.FrequencyHz, f.DeviationHz, f.NominalHz, f.ToleranceHz)
+}
+
+// storeFrequencyAlert writes a critical frequency_excursion alert and returns its ID
+func storeFrequencyAlert(ctx context.Context, reading *Reading, f FrequencyResult) (string, error) {
+	return putAlert(ctx, reading, frequencyExcursion, "critical", frequencyMessage(f), map[string]interface{}{
+		"frequency_hz": f.FrequencyHz,
+		"nominal_hz":   f.NominalHz,
+		"tolerance_hz": f.ToleranceHz,
+		"deviation_hz": f.DeviationHz,
+	})
+}
+
+func sendFrequencyAlert(ctx context.Context, reading *Reading, f FrequencyResult) error {
+	message := fmt.Sprintf("Grid Frequency Excursion\n\nFacility: %s\nMeter: %s\nSeverity: critical\n\n%s\nTime: %s\n\nAction Required: Investigate grid stability immediately.",
+		reading.FacilityID, reading.MeterID, frequencyMessage(f), time.Now().Format(time.RFC3339))
+
+	return publishAlert(ctx, reading, frequencyExcursion, "critical",
+		fmt.Sprintf("[critical] Energy Grid Frequency Excursion - %s", reading.FacilityID), message)
+}
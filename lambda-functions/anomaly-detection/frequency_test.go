@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestCheckFrequency(t *testing.T) {
+	hz := func(x float64) *float64 { return &x }
+	tests := []struct {
+		name          string
+		freq          *float64
+		nominal       float64
+		wantExcursion bool
+		wantDeviation float64
+	}{
+		{name: "nominal 50 Hz", freq: hz(50), nominal: 50},
+		{name: "slightly off, in band", freq: hz(50.3), nominal: 50, wantDeviation: 0.3},
+		{name: "at the band edge", freq: hz(49.5), nominal: 50, wantDeviation: -0.5},
+		{name: "just outside the band", freq: hz(50.51), nominal: 50, wantExcursion: true, wantDeviation: 0.51},
+		{name: "severe under-frequency", freq: hz(47.2), nominal: 50, wantExcursion: true, wantDeviation: -2.8},
+		{name: "60 Hz grid in band", freq: hz(59.8), nominal: 60, wantDeviation: -0.2},
+		{name: "50 Hz reading on a 60 Hz grid", freq: hz(50), nominal: 60, wantExcursion: true, wantDeviation: -10},
+		{name: "meter without frequency", nominal: 50},
+		{name: "check disabled", freq: hz(47), nominal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkFrequency(&Reading{FrequencyHz: tt.freq}, tt.nominal, 0.5)
+			if got.Excursion != tt.wantExcursion || math.Abs(got.DeviationHz-tt.wantDeviation) > 1e-9 {
+				t.Errorf("excursion, deviation = %v, %v; want %v, %v", got.Excursion, got.DeviationHz, tt.wantExcursion, tt.wantDeviation)
+			}
+		})
+	}
+}
+
+func TestHandlerFrequencyExcursion(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		wantAlert bool
+	}{
+		{name: "in band", frequency: "50.02"},
+		{name: "slightly off", frequency: "50.4"},
+		{name: "severe excursion", frequency: "48.7", wantAlert: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+			// No history at all: the band check doesn't wait for a statistical window
+			event := events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
+				EventName: "INSERT",
+				Change: events.DynamoDBStreamRecord{NewImage: map[string]events.DynamoDBAttributeValue{
+					"facilityId":  events.NewStringAttribute("facility-001"),
+					"meterId":     events.NewStringAttribute("meter-1"),
+					"timestamp":   events.NewNumberAttribute("1700000000"),
+					"powerKw":     events.NewNumberAttribute("12.5"),
+					"frequencyHz": events.NewNumberAttribute(tt.frequency),
+				}},
+			}}}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+
+			var found int
+			for _, item := range db.alerts {
+				if attrS(item, "type") == frequencyExcursion {
+					found++
+					if sev := attrS(item, "severity"); sev != "critical" {
+						t.Errorf("severity = %q, want critical", sev)
+					}
+				}
+			}
+			var sent int
+			for _, msg := range pub.messages {
+				if strings.HasPrefix(msg, "Grid Frequency Excursion") {
+					sent++
+				}
+			}
+			if want := map[bool]int{true: 1}[tt.wantAlert]; found != want || sent != want {
+				t.Errorf("%d frequency alerts stored, %d sent; want %d", found, sent, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Default health points an anomaly takes off its equipment, by severity
+var defaultHealthPenalties = map[string]float64{
+	"critical": 10,
+	"high":     5,
+	"low":      1,
+}
+
+// loadHealthPenalties reads HEALTH_PENALTY_CRITICAL, HEALTH_PENALTY_HIGH and
+// HEALTH_PENALTY_LOW; negative values fall back to the defaults
+func loadHealthPenalties() map[string]float64 {
+	out := make(map[string]float64, len(defaultHealthPenalties))
+	for severity, def := range defaultHealthPenalties {
+		p := mustAtof(getenv("HEALTH_PENALTY_"+strings.ToUpper(severity), ""), def)
+		if p < 0 {
+			fmt.Printf("WARN: HEALTH_PENALTY_%s=%g is negative; using %g\n", strings.ToUpper(severity), p, def)
+			p = def
+		}
+		out[severity] = p
+	}
+	return out
+}
+
+// healthPenalty returns the health points taken off for an anomaly of the
+// given severity; unknown severities count as low
+func healthPenalty(penalties map[string]float64, severity string) float64 {
+	if p, ok := penalties[strings.ToLower(severity)]; ok {
+		return p
+	}
+	return penalties["low"]
+}
+
+// degradeHealth lowers the equipment's healthScore by the severity's penalty,
+// stopping at 0, so repeated anomalies feed maintenance predictions. The
+// decrement is done in DynamoDB so concurrent invocations don't overwrite
+// each other; equipment without a healthScore is left alone.
+func degradeHealth(ctx context.Context, eq *Equipment, severity string) error {
+	penalty := healthPenalty(healthPenalties, severity)
+	if eq == nil || penalty == 0 {
+		return nil
+	}
+
+	now := &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())}
+	key := map[string]types.AttributeValue{
+		"equipmentId": &types.AttributeValueMemberS{Value: eq.EquipmentID},
+	}
+
+	_, err := dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableEquipment),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET healthScore = healthScore - :p, lastChecked = :time"),
+		ConditionExpression: aws.String("healthScore >= :p"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":p":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%g", penalty)},
+			":time": now,
+		},
+	})
+	if err == nil {
+		return nil
+	}
+	var failed *types.ConditionalCheckFailedException
+	if !errors.As(err, &failed) {
+		return fmt.Errorf("update equipment health failed: %w", err)
+	}
+
+	// Less health left than the penalty: floor it at 0
+	_, err = dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableEquipment),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET healthScore = :zero, lastChecked = :time"),
+		ConditionExpression: aws.String("attribute_exists(healthScore)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+			":time": now,
+		},
+	})
+	if errors.As(err, &failed) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("update equipment health failed: %w", err)
+	}
+	return nil
+}
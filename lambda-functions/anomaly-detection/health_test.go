@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// updateHealth applies degradeHealth's two updates to the fake equipment
+// table: the decrement, conditional on enough health being left, and the
+// floor at 0, conditional on the item having a healthScore
+func (f *fakeDynamo) updateHealth(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	for _, item := range f.equipment {
+		if attrS(item, "equipmentId") != attrS(in.Key, "equipmentId") {
+			continue
+		}
+		health, ok := healthOf(item)
+		if p, decrement := in.ExpressionAttributeValues[":p"].(*types.AttributeValueMemberN); decrement {
+			penalty, _ := strconv.ParseFloat(p.Value, 64)
+			if !ok || health < penalty {
+				break
+			}
+			health -= penalty
+		} else if !ok {
+			break
+		} else {
+			health = 0
+		}
+		item["healthScore"] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(health, 'f', -1, 64)}
+		item["lastChecked"] = in.ExpressionAttributeValues[":time"]
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+	return nil, &types.ConditionalCheckFailedException{Message: aws.String("condition failed")}
+}
+
+func healthOf(item map[string]types.AttributeValue) (float64, bool) {
+	v, ok := item["healthScore"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, false
+	}
+	h, _ := strconv.ParseFloat(v.Value, 64)
+	return h, true
+}
+
+// useHealthyEquipment stocks pump-1 on meter-1 with the given health; a
+// negative health leaves healthScore unset
+func useHealthyEquipment(t *testing.T, db *fakeDynamo, health float64) *Equipment {
+	t.Helper()
+	useEquipment(t, db, map[string]string{"equipmentId": "pump-1", "facilityId": "facility-001", "meterId": "meter-1"})
+	if health >= 0 {
+		db.equipment[0]["healthScore"] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(health, 'f', -1, 64)}
+	}
+	return &Equipment{EquipmentID: "pump-1", FacilityID: "facility-001", MeterID: "meter-1"}
+}
+
+func TestLoadHealthPenalties(t *testing.T) {
+	t.Setenv("HEALTH_PENALTY_CRITICAL", "20")
+	t.Setenv("HEALTH_PENALTY_HIGH", "-3")
+	got := loadHealthPenalties()
+	if got["critical"] != 20 || got["high"] != defaultHealthPenalties["high"] || got["low"] != defaultHealthPenalties["low"] {
+		t.Errorf("penalties %v", got)
+	}
+}
+
+func TestDegradeHealth(t *testing.T) {
+	prev := healthPenalties
+	t.Cleanup(func() { healthPenalties = prev })
+	healthPenalties = defaultHealthPenalties
+
+	tests := []struct {
+		name     string
+		health   float64 // -1 for equipment without a healthScore
+		severity string
+		want     float64
+	}{
+		{name: "critical", health: 80, severity: "critical", want: 70},
+		{name: "high", health: 80, severity: "high", want: 75},
+		{name: "low", health: 80, severity: "low", want: 79},
+		{name: "unknown severity counts as low", health: 80, severity: "medium", want: 79},
+		{name: "floored at zero", health: 4, severity: "critical", want: 0},
+		{name: "already at zero", health: 0, severity: "low", want: 0},
+		{name: "no health score", health: -1, severity: "critical", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			eq := useHealthyEquipment(t, db, tt.health)
+			if err := degradeHealth(context.Background(), eq, tt.severity); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := healthOf(db.equipment[0])
+			if !ok {
+				got = -1
+			}
+			if got != tt.want {
+				t.Errorf("health %v after a %s anomaly, want %v", got, tt.severity, tt.want)
+			}
+		})
+	}
+
+	// Repeated anomalies: critical ones wear equipment down faster than low ones
+	after := func(severity string, n int) float64 {
+		db, _ := useFakes(t)
+		eq := useHealthyEquipment(t, db, 100)
+		for i := 0; i < n; i++ {
+			if err := degradeHealth(context.Background(), eq, severity); err != nil {
+				t.Fatal(err)
+			}
+		}
+		h, _ := healthOf(db.equipment[0])
+		return h
+	}
+	if critical, low := after("critical", 3), after("low", 3); critical >= low {
+		t.Errorf("three critical anomalies leave %v health, three low ones %v", critical, low)
+	}
+}
+
+func TestHandlerAffectsHealth(t *testing.T) {
+	prevAffects, prevPenalties := affectsHealth, healthPenalties
+	t.Cleanup(func() { affectsHealth, healthPenalties = prevAffects, prevPenalties })
+	healthPenalties = defaultHealthPenalties
+
+	now := time.Now().Unix()
+	tests := []struct {
+		name    string
+		enabled bool
+		want    bool // health lowered
+	}{
+		{name: "enabled", enabled: true, want: true},
+		{name: "disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affectsHealth = tt.enabled
+			db, _ := useFakes(t)
+			useHealthyEquipment(t, db, 90)
+			for m := 60; m >= 5; m -= 5 {
+				db.readings = append(db.readings, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now - int64(m)*60, PowerKW: 10 + float64(m%3)*0.2})
+			}
+			reading := Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now, PowerKW: 60}
+			db.readings = append(db.readings, reading)
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+				t.Fatal(err)
+			}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+			if len(db.alerts) == 0 {
+				t.Fatal("spike not flagged")
+			}
+			if h, _ := healthOf(db.equipment[0]); (h < 90) != tt.want {
+				t.Errorf("health %v after an anomaly, want lowered %v", h, tt.want)
+			}
+		})
+	}
+}
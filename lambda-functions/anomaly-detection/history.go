@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// historyMaxPages bounds how many query pages are read to fill the window,
+// since the facility partition also holds other meters' readings
+const historyMaxPages = 10
+
+// historySettings are the historical-context knobs, resolved once at cold start
+type historySettings struct {
+	Hours           int   // HISTORICAL_HOURS: how far back to look
+	Window          int   // ANOMALY_WINDOW: readings the detector wants
+	Limit           int32 // HISTORICAL_LIMIT: items per query page
+	IntervalSeconds int   // SAMPLING_INTERVAL_SECONDS: expected spacing of a meter's readings
+}
+
+// expectedSamples is how many readings a meter should produce in the lookback
+func (h historySettings) expectedSamples() int {
+	if h.IntervalSeconds <= 0 {
+		return 0
+	}
+	return h.Hours * 3600 / h.IntervalSeconds
+}
+
+// loadHistorySettings reads the history env vars and checks they agree with
+// each other; see validate
+func loadHistorySettings() historySettings {
+	h := historySettings{
+		Hours:           mustAtoi(getenv("HISTORICAL_HOURS", "24"), 24),
+		Window:          mustAtoi(getenv("ANOMALY_WINDOW", "24"), 24),
+		Limit:           int32(mustAtoi(getenv("HISTORICAL_LIMIT", "200"), 200)),
+		IntervalSeconds: mustAtoi(getenv("SAMPLING_INTERVAL_SECONDS", "3600"), 3600),
+	}
+	for _, w := range h.validate() {
+		fmt.Printf("WARN: %s\n", w)
+	}
+	return h
+}
+
+// validate fixes settings that can't work and returns a warning for each
+// relationship that doesn't hold. The limit is raised to at least the window
+// so a single page can fill it.
+func (h *historySettings) validate() []string {
+	var warnings []string
+	if h.Hours <= 0 {
+		warnings = append(warnings, fmt.Sprintf("HISTORICAL_HOURS=%d is not positive; using 24", h.Hours))
+		h.Hours = 24
+	}
+	if h.Window <= 0 {
+		warnings = append(warnings, fmt.Sprintf("ANOMALY_WINDOW=%d is not positive; using 24", h.Window))
+		h.Window = 24
+	}
+	if int(h.Limit) < h.Window {
+		warnings = append(warnings, fmt.Sprintf("HISTORICAL_LIMIT=%d can't hold ANOMALY_WINDOW=%d readings; querying %d per page",
+			h.Limit, h.Window, h.Window))
+		h.Limit = int32(h.Window)
+	}
+	if expected := h.expectedSamples(); expected > 0 && expected < h.Window {
+		warnings = append(warnings, fmt.Sprintf("HISTORICAL_HOURS=%d at a %ds sampling interval yields ~%d readings, fewer than ANOMALY_WINDOW=%d",
+			h.Hours, h.IntervalSeconds, expected, h.Window))
+	}
+	return warnings
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHistorySettingsValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           historySettings
+		wantLimit    int32
+		wantWarnings []string
+	}{
+		{name: "consistent", in: historySettings{Hours: 24, Window: 24, Limit: 200, IntervalSeconds: 3600}, wantLimit: 200},
+		{
+			name:         "limit below the window is raised",
+			in:           historySettings{Hours: 24, Window: 96, Limit: 50, IntervalSeconds: 900},
+			wantLimit:    96,
+			wantWarnings: []string{"HISTORICAL_LIMIT=50 can't hold ANOMALY_WINDOW=96 readings; querying 96 per page"},
+		},
+		{
+			name:         "lookback too short for the window",
+			in:           historySettings{Hours: 6, Window: 24, Limit: 200, IntervalSeconds: 3600},
+			wantLimit:    200,
+			wantWarnings: []string{"HISTORICAL_HOURS=6 at a 3600s sampling interval yields ~6 readings, fewer than ANOMALY_WINDOW=24"},
+		},
+		{
+			name:         "non-positive settings fall back",
+			in:           historySettings{Hours: 0, Window: -1, Limit: 200, IntervalSeconds: 3600},
+			wantLimit:    200,
+			wantWarnings: []string{"HISTORICAL_HOURS=0 is not positive; using 24", "ANOMALY_WINDOW=-1 is not positive; using 24"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.in
+			warnings := h.validate()
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			for i := range warnings {
+				if warnings[i] != tt.wantWarnings[i] {
+					t.Errorf("warning %d = %q, want %q", i, warnings[i], tt.wantWarnings[i])
+				}
+			}
+			if h.Limit != tt.wantLimit || h.Hours <= 0 || h.Window <= 0 {
+				t.Errorf("settings after validate = %+v, want limit %d", h, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestGetHistoricalReadingsFillsWindow(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		meters    int // meters reporting every 15 minutes into the facility partition
+		hours     int
+		limit     int32
+		window    int
+		wantCount int
+		wantPages int
+	}{
+		{name: "one page holds the window", meters: 1, hours: 24, limit: 200, window: 24, wantCount: 96, wantPages: 1},
+		// Other meters share the partition, so a page holds a quarter of the meter's readings
+		{name: "pages until the window is filled", meters: 4, hours: 24, limit: 40, window: 24, wantCount: 30, wantPages: 3},
+		{name: "lookback exhausted before the window", meters: 4, hours: 2, limit: 40, window: 24, wantCount: 8, wantPages: 1},
+		{name: "page cap reached", meters: 8, hours: 24, limit: 8, window: 24, wantCount: historyMaxPages, wantPages: historyMaxPages},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			// Off the quarter hour so the lookback boundary never lands on a reading
+			for ts := now - 48*3600 + 450; ts < now; ts += 900 {
+				for m := 1; m <= tt.meters; m++ {
+					db.readings = append(db.readings, Reading{FacilityID: "facility-001", MeterID: fmt.Sprintf("meter-%d", m), Timestamp: ts, PowerKW: 10})
+				}
+			}
+
+			got, err := getHistoricalReadings(context.Background(), "facility-001", "meter-1", tt.hours, tt.limit, tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantCount || db.readingPages != tt.wantPages {
+				t.Errorf("%d readings from %d pages, want %d from %d", len(got), db.readingPages, tt.wantCount, tt.wantPages)
+			}
+			for i, r := range got {
+				if r.MeterID != "meter-1" {
+					t.Errorf("reading of %s returned", r.MeterID)
+				}
+				if i > 0 && r.Timestamp <= got[i-1].Timestamp {
+					t.Fatalf("readings not oldest first at %d", i)
+				}
+			}
+			if n := len(got); n > 0 && got[n-1].Timestamp != now-450 {
+				t.Errorf("newest reading at %d, want %d", got[n-1].Timestamp, now-450)
+			}
+		})
+	}
+}
@@ -7,10 +7,16 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/anomaly"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/capacity"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/timeutil"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -18,27 +24,97 @@ import (
 	ddbattr "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 )
 
+// dynamoAPI is the subset of the DynamoDB client used here
+type dynamoAPI interface {
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// snsAPI is the subset of the SNS client used here
+type snsAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// s3API is the subset of the S3 client used here
+type s3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
 var (
-	dynamoClient  *dynamodb.Client
-	snsClient     *sns.Client
-	topicArn      string
-	tableReadings string
-	tableAlerts   string
-	defaultCtx    = context.Background()
+	dynamoClient   dynamoAPI
+	snsClient      snsAPI
+	s3Client       s3API
+	topicArn       string
+	tableReadings  string
+	tableAlerts    string
+	tableEquipment string
+	subjectTmpl    string
+	defaultCtx     = context.Background()
+
+	// Training dataset logging (ANOMALY_TRAINING_LOG=true)
+	trainingLog    bool
+	trainingBucket string
+	trainingPrefix string
+
+	// Facility capacity alerting (FACILITY_CAPACITY_KW)
+	capacities           capacity.Capacities
+	capacityAlertPercent float64
+	capacitySustained    int
+	capacityStale        time.Duration
+
+	// Per-severity notification cooldowns (COOLDOWN_CRITICAL/HIGH/LOW)
+	cooldownWindows map[string]time.Duration
+
+	// Absolute frequency band (NOMINAL_FREQUENCY_HZ ± FREQUENCY_TOLERANCE_HZ)
+	nominalFrequency   float64
+	frequencyTolerance float64
+
+	// Steepest allowed power change between readings (MAX_RAMP_KW_PER_MIN; 0 disables)
+	maxRampKWPerMin float64
+
+	// Historical context for the detector (HISTORICAL_HOURS, ANOMALY_WINDOW, HISTORICAL_LIMIT)
+	history historySettings
+
+	// Reporting-rate band (RATE_WINDOW_MINUTES, RATE_LOW_RATIO, RATE_HIGH_RATIO)
+	rates rateSettings
+
+	// Per equipment type sampling intervals (SAMPLING_INTERVAL_BY_TYPE)
+	typeIntervals map[string]int
+
+	// Anomaly escalation (SEVERITY_CRITICAL_MULT, SEVERITY_HIGH_MULT)
+	severities severityMultipliers
+
+	// Equipment health degradation (ANOMALY_AFFECTS_HEALTH, HEALTH_PENALTY_CRITICAL/HIGH/LOW)
+	affectsHealth   bool
+	healthPenalties map[string]float64
 )
 
+// detectionMethod identifies the detector in training records
+const detectionMethod = "spike_outlier_sigma"
+
+// SNS rejects subjects of 100 characters or more
+const maxSubjectLen = 99
+
+var placeholderRe = regexp.MustCompile(`\{(\w+)\}`)
+
 type Reading struct {
-	FacilityID  string  `dynamodbav:"facilityId" json:"facility_id"`
-	MeterID     string  `dynamodbav:"meterId" json:"meter_id"`
-	Timestamp   int64   `dynamodbav:"timestamp" json:"timestamp"`
-	Voltage     float64 `dynamodbav:"voltage" json:"voltage"`
-	Current     float64 `dynamodbav:"current" json:"current"`
-	PowerKW     float64 `dynamodbav:"powerKw" json:"power_kw"`
-	Status      string  `dynamodbav:"status" json:"status"`
-	Temperature float64 `dynamodbav:"temperature" json:"temperature"`
+	FacilityID  string   `dynamodbav:"facilityId" json:"facility_id"`
+	MeterID     string   `dynamodbav:"meterId" json:"meter_id"`
+	Timestamp   int64    `dynamodbav:"timestamp" json:"timestamp"`
+	Voltage     float64  `dynamodbav:"voltage" json:"voltage"`
+	Current     float64  `dynamodbav:"current" json:"current"`
+	PowerKW     float64  `dynamodbav:"powerKw" json:"power_kw"`
+	Status      string   `dynamodbav:"status" json:"status"`
+	Temperature float64  `dynamodbav:"temperature" json:"temperature"`
+	FrequencyHz *float64 `dynamodbav:"frequencyHz" json:"frequency_hz,omitempty"`
+	// Set by the API for meters that are commissioned or decommissioned
+	MeterStatus string `dynamodbav:"meterStatus,omitempty" json:"meter_status,omitempty"`
 }
 
 type Alert struct {
@@ -51,6 +127,9 @@ type Alert struct {
 	Acknowledged bool                   `dynamodbav:"acknowledged"`
 	EquipmentID  string                 `dynamodbav:"equipmentId"`
 	Metadata     map[string]interface{} `dynamodbav:"metadata"`
+	// NotifiedAt is when the alert's notification went out; unset while it
+	// hasn't (suppressed by a cooldown, or the send failed)
+	NotifiedAt int64 `dynamodbav:"notifiedAt,omitempty"`
 }
 
 type AnomalyResult struct {
@@ -62,6 +141,11 @@ type AnomalyResult struct {
 	DeviationPercent float64 `json:"deviation_percent"`
 	Severity         string  `json:"severity"`
 	Reason           string  `json:"reason"`
+	Window           int     `json:"window"`
+	Sigma            float64 `json:"sigma"`
+	Method           string  `json:"method"`
+	Spikes           int     `json:"spikes"`
+	Outliers         int     `json:"outliers"`
 }
 
 func getenv(key, def string) string {
@@ -101,10 +185,34 @@ func init() {
 
 	dynamoClient = dynamodb.NewFromConfig(cfg)
 	snsClient = sns.NewFromConfig(cfg)
+	s3Client = s3.NewFromConfig(cfg)
 
 	topicArn = os.Getenv("SNS_TOPIC_ARN")
 	tableReadings = getenv("DDB_TABLE_READINGS", "EnergyReadings")
 	tableAlerts = getenv("DDB_TABLE_ALERTS", "Alerts")
+	tableEquipment = getenv("DDB_TABLE_EQUIPMENT", "Equipment")
+	// Placeholders: {severity}, {facility}, {type}, {meter}
+	subjectTmpl = getenv("ALERT_SUBJECT_TEMPLATE", "[{severity}] Energy Grid Anomaly - {facility}")
+	trainingLog = getenv("ANOMALY_TRAINING_LOG", "false") == "true"
+	trainingBucket = getenv("TRAINING_BUCKET", os.Getenv("S3_BUCKET"))
+	trainingPrefix = getenv("TRAINING_PREFIX", "training/anomaly")
+	if capacities, err = capacity.Parse(os.Getenv("FACILITY_CAPACITY_KW")); err != nil {
+		fmt.Printf("WARN: %v\n", err)
+	}
+	capacityAlertPercent = mustAtof(getenv("CAPACITY_ALERT_PERCENT", "90"), 90)
+	capacitySustained = mustAtoi(getenv("CAPACITY_SUSTAINED_READINGS", "3"), 3)
+	cooldownWindows = loadCooldowns()
+	nominalFrequency = mustAtof(getenv("NOMINAL_FREQUENCY_HZ", "50"), 50)
+	frequencyTolerance = mustAtof(getenv("FREQUENCY_TOLERANCE_HZ", "0.5"), 0.5)
+	maxRampKWPerMin = mustAtof(getenv("MAX_RAMP_KW_PER_MIN", "0"), 0)
+	history = loadHistorySettings()
+	// A meter that hasn't reported for two intervals no longer counts toward facility load
+	capacityStale = time.Duration(mustAtoi(getenv("CAPACITY_STALE_SECONDS", strconv.Itoa(2*history.IntervalSeconds)), 2*history.IntervalSeconds)) * time.Second
+	rates = loadRateSettings(history.IntervalSeconds)
+	typeIntervals = parseTypeIntervals(os.Getenv("SAMPLING_INTERVAL_BY_TYPE"))
+	severities = loadSeverityMultipliers()
+	affectsHealth = getenv("ANOMALY_AFFECTS_HEALTH", "false") == "true"
+	healthPenalties = loadHealthPenalties()
 
 	fmt.Printf("Lambda cold start. Region=%s ReadingsTable=%s AlertsTable=%s Topic=%s\n",
 		region, tableReadings, tableAlerts, topicArn)
@@ -114,6 +222,13 @@ func init() {
 func Handler(ctx context.Context, event events.DynamoDBEvent) error {
 	fmt.Printf("Received %d stream records\n", len(event.Records))
 
+	var training []TrainingRecord
+	defer func() {
+		if err := writeTrainingRecords(ctx, training); err != nil {
+			fmt.Printf("Error writing training records: %v\n", err)
+		}
+	}()
+
 	for i, record := range event.Records {
 		if record.EventName != "INSERT" && record.EventName != "MODIFY" {
 			continue
@@ -132,31 +247,106 @@ func Handler(ctx context.Context, event events.DynamoDBEvent) error {
 		fmt.Printf("Record %d: facility=%s meter=%s ts=%d power=%.3f kW\n",
 			i, reading.FacilityID, reading.MeterID, reading.Timestamp, reading.PowerKW)
 
-		// Tunables via env
-		hours := mustAtoi(getenv("HISTORICAL_HOURS", "24"), 24)
-		window := mustAtoi(getenv("ANOMALY_WINDOW", "24"), 24)
+		// Meters being installed or removed report test values and gaps, not faults
+		if reading.MeterStatus != "" && reading.MeterStatus != "active" {
+			fmt.Printf("Record %d: meter %s is %s, skipping checks\n", i, reading.MeterID, reading.MeterStatus)
+			continue
+		}
+
+		// Frequency excursions alert immediately, independent of the statistical window
+		if fr := checkFrequency(reading, nominalFrequency, frequencyTolerance); fr.Excursion {
+			fmt.Printf("Record %d: frequency: %+v\n", i, fr)
+			raiseAlert(ctx, reading, frequencyExcursion, "critical",
+				func(ctx context.Context) (string, error) { return storeFrequencyAlert(ctx, reading, fr) },
+				func(ctx context.Context) error { return sendFrequencyAlert(ctx, reading, fr) })
+		}
+
 		threshold := mustAtof(getenv("ANOMALY_THRESHOLD_SIGMA", "2.0"), 2.0)
-		maxItems := int32(mustAtoi(getenv("HISTORICAL_LIMIT", "200"), 200))
 
-		historical, err := getHistoricalReadings(ctx, reading.FacilityID, reading.MeterID, hours, maxItems)
+		historical, err := getHistoricalReadings(ctx, reading.FacilityID, reading.MeterID, history.Hours, history.Limit, history.Window)
 		if err != nil {
 			fmt.Printf("Record %d: error fetching historical readings: %v\n", i, err)
 			continue
 		}
 
-		an := detectAnomaly(reading, historical, window, threshold)
+		// Capacity is checked independently of the statistical detector, on the
+		// whole facility's load rather than this meter's
+		if capKW := capacities.For(reading.FacilityID); capKW > 0 {
+			start := reading.Timestamp - capacityLookback(capacitySustained, history.IntervalSeconds, capacityStale)
+			facility, err := getFacilityReadings(ctx, reading.FacilityID, start, reading.Timestamp)
+			if err != nil {
+				fmt.Printf("Record %d: error fetching facility readings: %v\n", i, err)
+			} else if cr := checkCapacity(reading, facility, capKW, capacityAlertPercent, capacitySustained, capacityStale); cr.Triggered() {
+				fmt.Printf("Record %d: capacity: %+v\n", i, cr)
+				raiseAlert(ctx, reading, capacityNear, capacitySeverity(cr),
+					func(ctx context.Context) (string, error) { return storeCapacityAlert(ctx, reading, cr) },
+					func(ctx context.Context) error { return sendCapacityAlert(ctx, reading, cr) })
+			}
+		}
+
+		// A sudden ramp stresses equipment even when both readings are in range
+		if rr := checkRamp(reading, previousReading(reading, historical), maxRampKWPerMin); rr.Rapid {
+			fmt.Printf("Record %d: ramp: %+v\n", i, rr)
+			raiseAlert(ctx, reading, rapidChange, rampSeverity(rr),
+				func(ctx context.Context) (string, error) { return storeRampAlert(ctx, reading, rr) },
+				func(ctx context.Context) error { return sendRampAlert(ctx, reading, rr) })
+		}
+
+		// A stuck publish loop or a dying meter shows in how often it reports, not what
+		if interval, ok := rateInterval(ctx, reading); ok {
+			start := reading.Timestamp - int64(rates.Window.Seconds())
+			count, err := countMeterReadings(ctx, reading.FacilityID, reading.MeterID, start, reading.Timestamp)
+			if err != nil {
+				fmt.Printf("Record %d: error counting readings: %v\n", i, err)
+			} else {
+				reportedBefore := len(historical) > 0 && historical[0].Timestamp < start
+				rr := checkRate(count, interval, rates, reportedBefore)
+				if rr.Triggered() {
+					fmt.Printf("Record %d: reporting rate: %+v\n", i, rr)
+					raiseAlert(ctx, reading, reportingRateAnomaly, rateSeverity(rr),
+						func(ctx context.Context) (string, error) { return storeRateAlert(ctx, reading, rr) },
+						func(ctx context.Context) error { return sendRateAlert(ctx, reading, rr) })
+				}
+			}
+		}
+
+		if len(historical) < history.Window {
+			fmt.Printf("Record %d: only %d of %d window readings in the last %dh\n",
+				i, len(historical), history.Window, history.Hours)
+		}
+
+		an := detectAnomaly(reading, historical, history.Window, threshold, severities)
 		if !an.IsAnomaly {
+			if trainingLog {
+				training = append(training, newTrainingRecord(reading, historical, an, ""))
+			}
 			continue
 		}
 
 		fmt.Printf("Record %d: anomaly: %+v\n", i, an)
 
-		if err := storeAlert(ctx, reading, an); err != nil {
-			fmt.Printf("Record %d: error storing alert: %v\n", i, err)
+		// On-call needs to know what the meter measures, not just its ID
+		eq := equipmentForMeter(ctx, reading.FacilityID, reading.MeterID)
+
+		id, replayed := raiseAlert(ctx, reading, "anomaly", an.Severity,
+			func(ctx context.Context) (string, error) { return storeAlert(ctx, reading, an, eq) },
+			func(ctx context.Context) error { return sendAlert(ctx, reading, an, eq) })
+		if replayed {
+			// A retried or replayed record: its health penalty and training
+			// record were handled the first time
+			continue
+		}
+		if trainingLog {
+			training = append(training, newTrainingRecord(reading, historical, an, id))
 		}
 
-		if err := sendAlert(ctx, reading, an); err != nil {
-			fmt.Printf("Record %d: error sending SNS: %v\n", i, err)
+		// Cooldowns only mute notifications; every anomaly wears the equipment down
+		if affectsHealth {
+			if eq == nil {
+				fmt.Printf("Record %d: meter %s has no equipment; health unchanged\n", i, reading.MeterID)
+			} else if err := degradeHealth(ctx, eq, an.Severity); err != nil {
+				fmt.Printf("Record %d: error updating equipment health: %v\n", i, err)
+			}
 		}
 	}
 
@@ -178,27 +368,36 @@ func parseReading(image map[string]events.DynamoDBAttributeValue) (*Reading, err
 	if v, ok := image["meterId"]; ok && v.DataType() == events.DataTypeString {
 		r.MeterID = v.String()
 	}
-	if v, ok := image["timestamp"]; ok && (v.DataType() == events.DataTypeNumber || v.DataType() == events.DataTypeString) {
-		// Streams can deliver numbers as strings; handle both
-		if ts, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
-			r.Timestamp = ts
+	if v, ok := scalar(image["timestamp"]); ok {
+		// Streams can deliver numbers as strings, and some producers write
+		// RFC3339 or epoch millis; normalize all of them to epoch seconds
+		if ts, err := timeutil.ParseTimestamp(v); err == nil && !ts.IsZero() {
+			r.Timestamp = ts.Unix()
 		}
 	}
-	if v, ok := image["voltage"]; ok && (v.DataType() == events.DataTypeNumber || v.DataType() == events.DataTypeString) {
-		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+	if v, ok := scalar(image["voltage"]); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			r.Voltage = f
 		}
 	}
-	if v, ok := image["current"]; ok && (v.DataType() == events.DataTypeNumber || v.DataType() == events.DataTypeString) {
-		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+	if v, ok := scalar(image["current"]); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			r.Current = f
 		}
 	}
-	if v, ok := image["powerKw"]; ok && (v.DataType() == events.DataTypeNumber || v.DataType() == events.DataTypeString) {
-		if f, err := strconv.ParseFloat(v.String(), 64); err == nil {
+	if v, ok := scalar(image["powerKw"]); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			r.PowerKW = f
 		}
 	}
+	if v, ok := image["meterStatus"]; ok && v.DataType() == events.DataTypeString {
+		r.MeterStatus = v.String()
+	}
+	if v, ok := scalar(image["frequencyHz"]); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			r.FrequencyHz = &f
+		}
+	}
 
 	if r.FacilityID == "" || r.MeterID == "" || r.Timestamp == 0 {
 		b, _ := json.Marshal(image)
@@ -207,7 +406,23 @@ func parseReading(image map[string]events.DynamoDBAttributeValue) (*Reading, err
 	return r, nil
 }
 
-func getHistoricalReadings(ctx context.Context, facilityID, meterID string, hours int, limit int32) ([]Reading, error) {
+// scalar is the text of a Number or String attribute. Streams deliver
+// numbers as Number attributes, but some producers write them as strings.
+func scalar(v events.DynamoDBAttributeValue) (string, bool) {
+	switch v.DataType() {
+	case events.DataTypeNumber:
+		return v.Number(), true
+	case events.DataTypeString:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// getHistoricalReadings returns up to the last `hours` of the meter's readings,
+// oldest first. Pages of `limit` items are read, newest first, until at least
+// `need` readings for the meter are found, the lookback is exhausted or
+// historyMaxPages is reached.
+func getHistoricalReadings(ctx context.Context, facilityID, meterID string, hours int, limit int32, need int) ([]Reading, error) {
 	now := time.Now().Unix()
 	start := now - int64(hours*3600)
 
@@ -229,25 +444,29 @@ func getHistoricalReadings(ctx context.Context, facilityID, meterID string, hour
 		Limit:            aws.Int32(limit),
 	}
 
-	out, err := dynamoClient.Query(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("dynamodb query failed: %w", err)
-	}
-
 	var all []Reading
-	if err := ddbattr.UnmarshalListOfMaps(out.Items, &all); err != nil {
-		return nil, fmt.Errorf("unmarshal readings failed: %w", err)
-	}
+	for page := 0; page < historyMaxPages; page++ {
+		out, err := dynamoClient.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("dynamodb query failed: %w", err)
+		}
 
-	// Optional: filter by meter when the table PK doesn’t include meterId
-	if meterID != "" {
-		filtered := all[:0]
-		for _, r := range all {
-			if r.MeterID == meterID {
-				filtered = append(filtered, r)
+		var items []Reading
+		if err := ddbattr.UnmarshalListOfMaps(out.Items, &items); err != nil {
+			return nil, fmt.Errorf("unmarshal readings failed: %w", err)
+		}
+
+		// Filter by meter: the table PK doesn’t include meterId
+		for _, r := range items {
+			if meterID == "" || r.MeterID == meterID {
+				all = append(all, r)
 			}
 		}
-		all = filtered
+
+		if len(all) >= need || len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
 	}
 
 	// We sorted desc; detector might not care, but stable ascending is nice
@@ -261,7 +480,7 @@ func reverseInPlace(a []Reading) {
 	}
 }
 
-func detectAnomaly(current *Reading, historical []Reading, window int, sigma float64) AnomalyResult {
+func detectAnomaly(current *Reading, historical []Reading, window int, sigma float64, sev severityMultipliers) AnomalyResult {
 	if window <= 0 {
 		window = 24
 	}
@@ -301,18 +520,9 @@ func detectAnomaly(current *Reading, historical []Reading, window int, sigma flo
 	}
 
 	isAnomaly := len(spikes) > 0 || len(outliers) > 0
-	severity := "low"
-	switch {
-	case mean > 0 && current.PowerKW >= mean*2.0:
-		severity = "critical"
-	case mean > 0 && current.PowerKW >= mean*1.5:
-		severity = "high"
-	}
+	severity := sev.severityFor(current.PowerKW, mean)
 
-	threshold := mean + std*sigma
-	if math.IsNaN(threshold) || math.IsInf(threshold, 0) {
-		threshold = 0
-	}
+	threshold := numfmt.SanitizeFloat(mean + std*sigma)
 
 	// If no history, treat large absolute power as low-severity anomaly to avoid silence.
 	if len(historical) == 0 && current.PowerKW > 0 {
@@ -326,9 +536,14 @@ func detectAnomaly(current *Reading, historical []Reading, window int, sigma flo
 		Mean:             mean,
 		StdDev:           std,
 		Threshold:        threshold,
-		DeviationPercent: devPct,
+		DeviationPercent: numfmt.SanitizeFloat(devPct),
 		Severity:         severity,
 		Reason:           fmt.Sprintf("Window=%d sigma=%.2f spikes=%d outliers=%d", window, sigma, len(spikes), len(outliers)),
+		Window:           window,
+		Sigma:            sigma,
+		Method:           detectionMethod,
+		Spikes:           len(spikes),
+		Outliers:         len(outliers),
 	}
 }
 
@@ -355,8 +570,9 @@ func calculateStdDev(readings []Reading, mean float64) float64 {
 	return math.Sqrt(v / float64(len(readings)))
 }
 
-func storeAlert(ctx context.Context, reading *Reading, an AnomalyResult) error {
-	id := fmt.Sprintf("alert-%d-%d", time.Now().Unix(), time.Now().Nanosecond())
+// storeAlert writes the anomaly alert, with equipment context when eq is set, and returns its ID
+func storeAlert(ctx context.Context, reading *Reading, an AnomalyResult, eq *Equipment) (string, error) {
+	id := alertID(reading, "anomaly")
 
 	msg := fmt.Sprintf("Abnormal power consumption: %.2f kW (%.1f%% above average)",
 		an.CurrentPower, an.DeviationPercent)
@@ -377,34 +593,34 @@ func storeAlert(ctx context.Context, reading *Reading, an AnomalyResult) error {
 			"threshold":         an.Threshold,
 			"deviation_percent": an.DeviationPercent,
 			"reason":            an.Reason,
+			"method":            an.Method,
+			"window":            an.Window,
+			"sigma":             an.Sigma,
+			"reading_timestamp": reading.Timestamp,
 		},
 	}
-
-	item, err := ddbattr.MarshalMap(alert)
-	if err != nil {
-		return fmt.Errorf("marshal alert failed: %w", err)
+	for k, v := range equipmentMetadata(eq) {
+		alert.Metadata[k] = v
 	}
 
-	_, err = dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(tableAlerts),
-		Item:      item,
-	})
-	if err != nil {
-		return fmt.Errorf("put alert failed: %w", err)
-	}
-
-	return nil
+	return id, writeAlert(ctx, alert)
 }
 
-func sendAlert(ctx context.Context, reading *Reading, an AnomalyResult) error {
+func sendAlert(ctx context.Context, reading *Reading, an AnomalyResult, eq *Equipment) error {
 	if topicArn == "" {
 		fmt.Println("SNS_TOPIC_ARN not set; skipping notification")
 		return nil
 	}
 
-	subject := fmt.Sprintf("[%s] Energy Grid Anomaly - %s", an.Severity, reading.FacilityID)
-	if len(subject) > 100 {
-		subject = subject[:100]
+	subject, err := renderSubject(subjectTmpl, map[string]string{
+		"severity": an.Severity,
+		"facility": reading.FacilityID,
+		"meter":    reading.MeterID,
+		"type":     "anomaly",
+	})
+	if err != nil {
+		fmt.Printf("WARN %v; using default subject\n", err)
+		subject = truncateSubject(fmt.Sprintf("[%s] Energy Grid Anomaly - %s", an.Severity, reading.FacilityID))
 	}
 
 	message := fmt.Sprintf(
@@ -412,7 +628,7 @@ func sendAlert(ctx context.Context, reading *Reading, an AnomalyResult) error {
 
 Facility: %s
 Meter: %s
-Severity: %s
+%sSeverity: %s
 
 Current Power: %.2f kW
 Average Power: %.2f kW
@@ -426,6 +642,7 @@ Reason: %s
 Action Required: Please investigate immediately.`,
 		reading.FacilityID,
 		reading.MeterID,
+		equipmentLines(eq),
 		an.Severity,
 		an.CurrentPower,
 		an.Mean,
@@ -435,7 +652,7 @@ Action Required: Please investigate immediately.`,
 		an.Reason,
 	)
 
-	_, err := snsClient.Publish(ctx, &sns.PublishInput{
+	_, err = snsClient.Publish(ctx, &sns.PublishInput{
 		TopicArn: aws.String(topicArn),
 		Subject:  aws.String(subject),
 		Message:  aws.String(message),
@@ -446,6 +663,34 @@ Action Required: Please investigate immediately.`,
 	return nil
 }
 
+// renderSubject expands {placeholder} tokens in tmpl; unknown placeholders render empty
+func renderSubject(tmpl string, fields map[string]string) (string, error) {
+	t, err := template.New("subject").Parse(placeholderRe.ReplaceAllString(tmpl, `{{index . "$1"}}`))
+	if err != nil {
+		return "", fmt.Errorf("bad ALERT_SUBJECT_TEMPLATE: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("render subject: %w", err)
+	}
+	return truncateSubject(b.String()), nil
+}
+
+// truncateSubject strips line breaks and cuts at a word boundary within the SNS limit
+func truncateSubject(subject string) string {
+	subject = strings.Join(strings.Fields(subject), " ")
+	runes := []rune(subject)
+	if len(runes) <= maxSubjectLen {
+		return subject
+	}
+	const ellipsis = "..."
+	cut := string(runes[:maxSubjectLen-len(ellipsis)])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " -:[(") + ellipsis
+}
+
 func main() {
 	lambda.Start(Handler)
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestParseReading(t *testing.T) {
+	const want = 1709294400 // 2024-03-01T12:00:00Z
+	image := func(ts events.DynamoDBAttributeValue) map[string]events.DynamoDBAttributeValue {
+		return map[string]events.DynamoDBAttributeValue{
+			"facilityId": events.NewStringAttribute("facility-001"),
+			"meterId":    events.NewStringAttribute("meter-1"),
+			"timestamp":  ts,
+			"powerKw":    events.NewNumberAttribute("12.5"),
+			"voltage":    events.NewStringAttribute("230"),
+		}
+	}
+	tests := []struct {
+		name    string
+		ts      events.DynamoDBAttributeValue
+		want    int64
+		wantErr bool
+	}{
+		{name: "epoch seconds number", ts: events.NewNumberAttribute("1709294400"), want: want},
+		{name: "epoch millis number", ts: events.NewNumberAttribute("1709294400000"), want: want},
+		{name: "epoch seconds string", ts: events.NewStringAttribute("1709294400"), want: want},
+		{name: "epoch millis string", ts: events.NewStringAttribute("1709294400123"), want: want},
+		{name: "RFC3339", ts: events.NewStringAttribute("2024-03-01T12:00:00Z"), want: want},
+		{name: "RFC3339 with offset", ts: events.NewStringAttribute("2024-03-01T07:00:00-05:00"), want: want},
+		{name: "unparseable", ts: events.NewStringAttribute("yesterday"), wantErr: true},
+		{name: "wrong type", ts: events.NewBooleanAttribute(true), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseReading(image(tt.ts))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.Timestamp != tt.want {
+				t.Errorf("timestamp = %d, want %d", r.Timestamp, tt.want)
+			}
+			if r.PowerKW != 12.5 || r.Voltage != 230 {
+				t.Errorf("power, voltage = %v, %v; want 12.5, 230", r.PowerKW, r.Voltage)
+			}
+		})
+	}
+}
+
+// replayedRecord is the event the API's admin reprocess endpoint invokes the
+// function with for a stored reading (cloud.ReplayReadingToAnomalyDetection)
+func replayedRecord(r Reading) string {
+	return fmt.Sprintf(`{"Records":[{"eventName":"INSERT","eventSource":"aws:dynamodb","dynamodb":{"StreamViewType":"NEW_IMAGE","NewImage":{`+
+		`"facilityId":{"S":%q},"meterId":{"S":%q},"timestamp":{"N":"%d"},"voltage":{"N":"230"},"current":{"N":"40"},"powerKw":{"N":"%g"}}}}]}`,
+		r.FacilityID, r.MeterID, r.Timestamp, r.PowerKW)
+}
+
+func TestHandlerReprocessedReading(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		powerKW   float64
+		wantAlert bool
+	}{
+		{name: "known spike", powerKW: 60, wantAlert: true},
+		{name: "ordinary reading", powerKW: 10.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+			// A day of hourly readings around 10 kW, then the reading being reprocessed
+			for h := 24; h >= 1; h-- {
+				db.readings = append(db.readings, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now - int64(h)*3600, PowerKW: 10 + float64(h%3)*0.2})
+			}
+			reading := Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now, PowerKW: tt.powerKW}
+			db.readings = append(db.readings, reading)
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+				t.Fatal(err)
+			}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+
+			var stored int
+			for _, item := range db.alerts {
+				if attrS(item, "type") == "anomaly" {
+					stored++
+					if ts, _ := attrN(item, "timestamp"); ts != now || attrS(item, "equipmentId") != "meter-1" {
+						t.Errorf("alert for %s at %d, want meter-1 at %d", attrS(item, "equipmentId"), ts, now)
+					}
+				}
+			}
+			if want := map[bool]int{true: 1}[tt.wantAlert]; stored != want || len(pub.messages) != want {
+				t.Errorf("%d anomaly alerts stored, %d notifications; want %d", stored, len(pub.messages), want)
+			}
+		})
+	}
+}
+
+func TestHandlerSkipsInactiveMeters(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		status    string
+		wantAlert bool
+	}{
+		{name: "active", status: "active", wantAlert: true},
+		{name: "no status", wantAlert: true},
+		{name: "commissioned", status: "commissioned"},
+		{name: "decommissioned", status: "decommissioned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+			// Hourly readings around 10 kW that stopped 12 hours ago, then a spike
+			for h := 24; h >= 12; h-- {
+				db.readings = append(db.readings, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now - int64(h)*3600, PowerKW: 10 + float64(h%3)*0.2})
+			}
+			reading := Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now, PowerKW: 60}
+			db.readings = append(db.readings, reading)
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+				t.Fatal(err)
+			}
+			if tt.status != "" {
+				event.Records[0].Change.NewImage["meterStatus"] = events.NewStringAttribute(tt.status)
+			}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+
+			types := make(map[string]int)
+			for _, item := range db.alerts {
+				types[attrS(item, "type")]++
+			}
+			if tt.wantAlert && (types["anomaly"] != 1 || types[reportingRateAnomaly] != 1 || len(pub.messages) == 0) {
+				t.Errorf("alerts %v, %d notifications; want the spike and the silence flagged", types, len(pub.messages))
+			}
+			if !tt.wantAlert && (len(db.alerts) != 0 || len(pub.messages) != 0) {
+				t.Errorf("alerts %v, %d notifications for a %s meter", types, len(pub.messages), tt.status)
+			}
+		})
+	}
+}
+
+func TestRenderSubject(t *testing.T) {
+	fields := map[string]string{"severity": "critical", "facility": "facility-001"}
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "placeholders", tmpl: "[{severity}] anomaly at {facility}", want: "[critical] anomaly at facility-001"},
+		{name: "99 characters kept", tmpl: strings.Repeat("x", 99), want: strings.Repeat("x", 99)},
+		{name: "100 characters truncated", tmpl: strings.Repeat("x", 100), want: strings.Repeat("x", 96) + "..."},
+		{name: "truncated at a word boundary", tmpl: strings.Repeat("overload ", 20), want: strings.TrimSpace(strings.Repeat("overload ", 10)) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderSubject(tt.tmpl, fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderSubject = %q, want %q", got, tt.want)
+			}
+			// SNS requires subjects under 100 characters
+			if len([]rune(got)) >= 100 {
+				t.Errorf("subject is %d characters", len([]rune(got)))
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// rapidChange is the alert type for power changing faster than the allowed slope
+const rapidChange = "rapid_change"
+
+// rampCriticalMult is how many times the allowed slope a ramp must reach to be critical
+const rampCriticalMult = 2
+
+// RampResult is the outcome of comparing the power change since the meter's
+// previous reading with the allowed slope
+type RampResult struct {
+	Rapid          bool    `json:"rapid"`
+	PreviousKW     float64 `json:"previous_kw"`
+	CurrentKW      float64 `json:"current_kw"`
+	DeltaKW        float64 `json:"delta_kw"`
+	ElapsedSeconds int64   `json:"elapsed_seconds"`
+	SlopeKWPerMin  float64 `json:"slope_kw_per_min"`
+	MaxKWPerMin    float64 `json:"max_kw_per_min"`
+}
+
+// previousReading returns the newest of historical (oldest first) taken
+// before current; the history may already hold current itself
+func previousReading(current *Reading, historical []Reading) *Reading {
+	for i := len(historical) - 1; i >= 0; i-- {
+		if historical[i].Timestamp < current.Timestamp {
+			return &historical[i]
+		}
+	}
+	return nil
+}
+
+// checkRamp flags a power change between prev and current steeper than
+// maxKWPerMin in either direction. Readings aren't evenly spaced, so the
+// change is divided by the minutes between them rather than compared per
+// reading. A non-positive limit, no previous reading or no time between them
+// skips the check.
+func checkRamp(current, prev *Reading, maxKWPerMin float64) RampResult {
+	res := RampResult{CurrentKW: current.PowerKW, MaxKWPerMin: maxKWPerMin}
+	if maxKWPerMin <= 0 || prev == nil || current.Timestamp <= prev.Timestamp {
+		return res
+	}
+	res.PreviousKW = prev.PowerKW
+	res.DeltaKW = current.PowerKW - prev.PowerKW
+	res.ElapsedSeconds = current.Timestamp - prev.Timestamp
+	res.SlopeKWPerMin = res.DeltaKW / (float64(res.ElapsedSeconds) / 60)
+	res.Rapid = math.Abs(res.SlopeKWPerMin) > maxKWPerMin
+	return res
+}
+
+func rampSeverity(r RampResult) string {
+	if math.Abs(r.SlopeKWPerMin) >= rampCriticalMult*r.MaxKWPerMin {
+		return "critical"
+	}
+	return "high"
+}
+
+func rampMessage(r RampResult) string {
+	direction := "rose"
+	if r.DeltaKW < 0 {
+		direction = "fell"
+	}
+	return fmt.Sprintf("Power %s from %.2f kW to %.2f kW in %ds (%+.2f kW/min, limit %.2f kW/min)",
+		direction, r.PreviousKW, r.CurrentKW, r.ElapsedSeconds, r.SlopeKWPerMin, r.MaxKWPerMin)
+}
+
+// storeRampAlert writes a rapid_change alert and returns its ID
+func storeRampAlert(ctx context.Context, reading *Reading, r RampResult) (string, error) {
+	return putAlert(ctx, reading, rapidChange, rampSeverity(r), rampMessage(r), map[string]interface{}{
+		"previous_kw":       r.PreviousKW,
+		"current_kw":        r.CurrentKW,
+		"delta_kw":          r.DeltaKW,
+		"elapsed_seconds":   r.ElapsedSeconds,
+		"slope_kw_per_min":  r.SlopeKWPerMin,
+		"max_kw_per_min":    r.MaxKWPerMin,
+		"reading_timestamp": reading.Timestamp,
+	})
+}
+
+func sendRampAlert(ctx context.Context, reading *Reading, r RampResult) error {
+	severity := rampSeverity(r)
+	message := fmt.Sprintf("Rapid Power Change\n\nFacility: %s\nMeter: %s\nSeverity: %s\n\n%s\nTime: %s\n\nAction Required: Check the equipment for inrush or fault conditions.",
+		reading.FacilityID, reading.MeterID, severity, rampMessage(r), time.Now().Format(time.RFC3339))
+
+	return publishAlert(ctx, reading, rapidChange, severity,
+		fmt.Sprintf("[%s] Energy Grid Rapid Change - %s", severity, reading.FacilityID), message)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestCheckRamp(t *testing.T) {
+	const limit = 5 // kW/min
+	tests := []struct {
+		name      string
+		prev      *Reading
+		current   Reading
+		limit     float64
+		wantRapid bool
+		wantSlope float64
+	}{
+		{name: "steep ramp", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1060, PowerKW: 40}, limit: limit, wantRapid: true, wantSlope: 30},
+		{name: "gradual change", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1060, PowerKW: 13}, limit: limit, wantSlope: 3},
+		{name: "at the limit", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1060, PowerKW: 15}, limit: limit, wantSlope: 5},
+		{name: "steep drop", prev: &Reading{Timestamp: 1000, PowerKW: 40}, current: Reading{Timestamp: 1030, PowerKW: 10}, limit: limit, wantRapid: true, wantSlope: -60},
+		// 30 kW is steep over a minute but gradual over ten: spacing is normalized per minute
+		{name: "same change over a long gap", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1600, PowerKW: 40}, limit: limit, wantSlope: 3},
+		{name: "same change over a short gap", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1015, PowerKW: 12}, limit: limit, wantRapid: true, wantSlope: 8},
+		{name: "no previous reading", current: Reading{Timestamp: 1060, PowerKW: 40}, limit: limit},
+		{name: "same timestamp", prev: &Reading{Timestamp: 1060, PowerKW: 10}, current: Reading{Timestamp: 1060, PowerKW: 40}, limit: limit},
+		{name: "check disabled", prev: &Reading{Timestamp: 1000, PowerKW: 10}, current: Reading{Timestamp: 1060, PowerKW: 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkRamp(&tt.current, tt.prev, tt.limit)
+			if got.Rapid != tt.wantRapid || math.Abs(got.SlopeKWPerMin-tt.wantSlope) > 1e-9 {
+				t.Errorf("rapid, slope = %v, %v; want %v, %v", got.Rapid, got.SlopeKWPerMin, tt.wantRapid, tt.wantSlope)
+			}
+		})
+	}
+}
+
+func TestPreviousReading(t *testing.T) {
+	history := []Reading{{Timestamp: 100, PowerKW: 1}, {Timestamp: 200, PowerKW: 2}, {Timestamp: 300, PowerKW: 3}}
+	tests := []struct {
+		name    string
+		at      int64
+		history []Reading
+		wantKW  float64 // 0 for none
+	}{
+		{name: "newest before", at: 350, history: history, wantKW: 3},
+		{name: "history holds the reading itself", at: 300, history: history, wantKW: 2},
+		{name: "before all history", at: 50, history: history},
+		{name: "no history", at: 350},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := previousReading(&Reading{Timestamp: tt.at}, tt.history)
+			if (got == nil) != (tt.wantKW == 0) || got != nil && got.PowerKW != tt.wantKW {
+				t.Errorf("previousReading = %+v, want the %v kW reading", got, tt.wantKW)
+			}
+		})
+	}
+}
+
+func TestRampSeverityAndMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		r            RampResult
+		wantSeverity string
+		wantMessage  string
+	}{
+		{
+			name:         "rising",
+			r:            RampResult{PreviousKW: 10, CurrentKW: 18, DeltaKW: 8, ElapsedSeconds: 60, SlopeKWPerMin: 8, MaxKWPerMin: 5},
+			wantSeverity: "high",
+			wantMessage:  "Power rose from 10.00 kW to 18.00 kW in 60s (+8.00 kW/min, limit 5.00 kW/min)",
+		},
+		{
+			name:         "falling twice the limit",
+			r:            RampResult{PreviousKW: 40, CurrentKW: 10, DeltaKW: -30, ElapsedSeconds: 180, SlopeKWPerMin: -10, MaxKWPerMin: 5},
+			wantSeverity: "critical",
+			wantMessage:  "Power fell from 40.00 kW to 10.00 kW in 180s (-10.00 kW/min, limit 5.00 kW/min)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rampSeverity(tt.r); got != tt.wantSeverity {
+				t.Errorf("severity = %q, want %q", got, tt.wantSeverity)
+			}
+			if got := rampMessage(tt.r); got != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandlerRapidChange(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		maxSlope  float64
+		powerKW   float64
+		wantAlert bool
+	}{
+		{name: "steep ramp", maxSlope: 5, powerKW: 30, wantAlert: true},
+		{name: "gradual change", maxSlope: 5, powerKW: 13},
+		{name: "check disabled", powerKW: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, pub := useFakes(t)
+			prev := maxRampKWPerMin
+			maxRampKWPerMin = tt.maxSlope
+			t.Cleanup(func() { maxRampKWPerMin = prev })
+
+			// Readings a minute apart around 10 kW, then the one being checked
+			for m := 30; m >= 1; m-- {
+				db.readings = append(db.readings, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now - int64(m)*60, PowerKW: 10 + float64(m%3)*0.2})
+			}
+			reading := Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now, PowerKW: tt.powerKW}
+			db.readings = append(db.readings, reading)
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+				t.Fatal(err)
+			}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+
+			var stored int
+			for _, item := range db.alerts {
+				if attrS(item, "type") == rapidChange {
+					stored++
+				}
+			}
+			var sent int
+			for _, msg := range pub.messages {
+				if strings.HasPrefix(msg, "Rapid Power Change") {
+					sent++
+				}
+			}
+			if want := map[bool]int{true: 1}[tt.wantAlert]; stored != want || sent != want {
+				t.Errorf("%d rapid_change alerts stored, %d sent; want %d", stored, sent, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// reportingRateAnomaly is the alert type for a meter reporting far more or
+// far less often than its sampling interval
+const reportingRateAnomaly = "reporting_rate_anomaly"
+
+// Reporting rate directions
+const (
+	rateOK      = "ok"
+	rateFlood   = "flood"
+	rateDrought = "drought"
+)
+
+// rateMinExpected is the fewest readings the window must be expected to hold;
+// below it a single late or duplicate reading swings the ratio too far
+const rateMinExpected = 4
+
+// rateSettings is the reporting-rate band, resolved once at cold start
+type rateSettings struct {
+	Window    time.Duration // RATE_WINDOW_MINUTES: how far back to count; 0 disables the check
+	LowRatio  float64       // RATE_LOW_RATIO: below expected*LowRatio is a drought
+	HighRatio float64       // RATE_HIGH_RATIO: above expected*HighRatio is a flood
+}
+
+// RateResult is the outcome of comparing a meter's reading count with its sampling interval
+type RateResult struct {
+	Direction       string  `json:"direction"`
+	Count           int     `json:"count"`
+	Expected        float64 `json:"expected"`
+	Ratio           float64 `json:"ratio"`
+	WindowMinutes   int     `json:"window_minutes"`
+	IntervalSeconds int     `json:"interval_seconds"`
+}
+
+// Triggered reports whether the result should raise a reporting_rate_anomaly alert
+func (r RateResult) Triggered() bool { return r.Direction == rateFlood || r.Direction == rateDrought }
+
+func loadRateSettings(intervalSeconds int) rateSettings {
+	r := rateSettings{
+		Window:    time.Duration(mustAtoi(getenv("RATE_WINDOW_MINUTES", "360"), 360)) * time.Minute,
+		LowRatio:  mustAtof(getenv("RATE_LOW_RATIO", "0.5"), 0.5),
+		HighRatio: mustAtof(getenv("RATE_HIGH_RATIO", "3"), 3),
+	}
+	if r.Window <= 0 {
+		return r
+	}
+	if r.LowRatio < 0 || r.HighRatio <= r.LowRatio {
+		fmt.Printf("WARN: RATE_LOW_RATIO=%g and RATE_HIGH_RATIO=%g don't form a band; using 0.5 and 3\n", r.LowRatio, r.HighRatio)
+		r.LowRatio, r.HighRatio = 0.5, 3
+	}
+	if expected := rateExpected(r.Window, intervalSeconds); expected < rateMinExpected {
+		fmt.Printf("WARN: RATE_WINDOW_MINUTES=%d at a %ds sampling interval expects ~%.1f readings; reporting-rate check skipped for meters at that interval\n",
+			int(r.Window.Minutes()), intervalSeconds, expected)
+	}
+	return r
+}
+
+// parseTypeIntervals parses SAMPLING_INTERVAL_BY_TYPE ("smart_meter=60,ct_logger=900"),
+// the reporting cadence in seconds of each equipment type
+func parseTypeIntervals(spec string) map[string]int {
+	out := make(map[string]int)
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return out
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		typ, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		secs, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || err != nil || secs <= 0 {
+			fmt.Printf("WARN: ignoring SAMPLING_INTERVAL_BY_TYPE entry %q\n", entry)
+			continue
+		}
+		out[strings.ToLower(strings.TrimSpace(typ))] = secs
+	}
+	return out
+}
+
+// meterInterval is the sampling interval a meter's reporting rate is judged
+// against: the equipment's own samplingIntervalSeconds, else its type's entry
+// in byType, else def
+func meterInterval(eq *Equipment, byType map[string]int, def int) int {
+	if eq == nil {
+		return def
+	}
+	if eq.SamplingIntervalSeconds > 0 {
+		return eq.SamplingIntervalSeconds
+	}
+	if secs, ok := byType[strings.ToLower(eq.Type)]; ok {
+		return secs
+	}
+	return def
+}
+
+// rateInterval resolves the reading's meter interval and reports whether the
+// rate check applies: it is enabled and the window expects enough readings
+func rateInterval(ctx context.Context, reading *Reading) (int, bool) {
+	if rates.Window <= 0 {
+		return 0, false
+	}
+	eq := equipmentForMeter(ctx, reading.FacilityID, reading.MeterID)
+	secs := meterInterval(eq, typeIntervals, history.IntervalSeconds)
+	return secs, rateExpected(rates.Window, secs) >= rateMinExpected
+}
+
+// rateExpected is how many readings a meter should report in window
+func rateExpected(window time.Duration, intervalSeconds int) float64 {
+	if intervalSeconds <= 0 {
+		return 0
+	}
+	return window.Seconds() / float64(intervalSeconds)
+}
+
+// checkRate compares count, the meter's readings in the window ending at the
+// current reading (including it), against the band around the expected count.
+// A drought is only reported for a meter that has history from before the
+// window, so a newly commissioned meter isn't flagged while it ramps up.
+func checkRate(count, intervalSeconds int, settings rateSettings, reportedBefore bool) RateResult {
+	expected := rateExpected(settings.Window, intervalSeconds)
+	res := RateResult{Direction: rateOK, Count: count, Expected: expected,
+		WindowMinutes: int(settings.Window.Minutes()), IntervalSeconds: intervalSeconds}
+	if expected <= 0 {
+		return res
+	}
+	res.Ratio = float64(count) / expected
+
+	switch {
+	case res.Ratio > settings.HighRatio:
+		res.Direction = rateFlood
+	case res.Ratio < settings.LowRatio && reportedBefore:
+		res.Direction = rateDrought
+	}
+	return res
+}
+
+// countMeterReadings counts the meter's readings with timestamps in [start, end]
+func countMeterReadings(ctx context.Context, facilityID, meterID string, start, end int64) (int, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableReadings),
+		KeyConditionExpression: aws.String("facilityId = :fid AND #ts BETWEEN :start AND :end"),
+		FilterExpression:       aws.String("meterId = :mid"),
+		ExpressionAttributeNames: map[string]string{
+			"#ts": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fid":   &types.AttributeValueMemberS{Value: facilityID},
+			":mid":   &types.AttributeValueMemberS{Value: meterID},
+			":start": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", start)},
+			":end":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", end)},
+		},
+		Select: types.SelectCount,
+	}
+
+	var count int
+	for page := 0; page < historyMaxPages; page++ {
+		out, err := dynamoClient.Query(ctx, input)
+		if err != nil {
+			return 0, fmt.Errorf("count readings failed: %w", err)
+		}
+		count += int(out.Count)
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	return count, nil
+}
+
+// rateSeverity treats a flooding meter as more urgent: it is usually a stuck
+// publish loop that also inflates consumption totals
+func rateSeverity(r RateResult) string {
+	if r.Direction == rateFlood {
+		return "high"
+	}
+	return "low"
+}
+
+func rateMessage(r RateResult) string {
+	return fmt.Sprintf("Meter reported %d readings in the last %d minutes, %.1fx the ~%.1f expected at a %ds interval (%s)",
+		r.Count, r.WindowMinutes, r.Ratio, r.Expected, r.IntervalSeconds, r.Direction)
+}
+
+// storeRateAlert writes a reporting_rate_anomaly alert and returns its ID
+func storeRateAlert(ctx context.Context, reading *Reading, r RateResult) (string, error) {
+	return putAlert(ctx, reading, reportingRateAnomaly, rateSeverity(r), rateMessage(r), map[string]interface{}{
+		"direction":        r.Direction,
+		"count":            r.Count,
+		"expected":         r.Expected,
+		"ratio":            r.Ratio,
+		"window_minutes":   r.WindowMinutes,
+		"interval_seconds": r.IntervalSeconds,
+	})
+}
+
+func sendRateAlert(ctx context.Context, reading *Reading, r RateResult) error {
+	severity := rateSeverity(r)
+	message := fmt.Sprintf("Meter Reporting Rate Anomaly\n\nFacility: %s\nMeter: %s\nSeverity: %s\n\n%s\nTime: %s",
+		reading.FacilityID, reading.MeterID, severity, rateMessage(r), time.Now().Format(time.RFC3339))
+
+	return publishAlert(ctx, reading, reportingRateAnomaly, severity,
+		fmt.Sprintf("[%s] Energy Grid Reporting Rate - %s", severity, reading.FacilityID), message)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCheckRate(t *testing.T) {
+	settings := rateSettings{Window: 6 * time.Hour, LowRatio: 0.5, HighRatio: 3}
+	tests := []struct {
+		name           string
+		count          int
+		interval       int
+		reportedBefore bool
+		want           string
+		wantRatio      float64
+	}{
+		{name: "on schedule", count: 6, interval: 3600, reportedBefore: true, want: rateOK, wantRatio: 1},
+		{name: "a few extra", count: 15, interval: 3600, reportedBefore: true, want: rateOK, wantRatio: 2.5},
+		{name: "stuck loop flood", count: 60, interval: 3600, reportedBefore: true, want: rateFlood, wantRatio: 10},
+		{name: "dying meter drought", count: 2, interval: 3600, reportedBefore: true, want: rateDrought, wantRatio: 1.0 / 3},
+		{name: "new meter ramping up", count: 2, interval: 3600, want: rateOK, wantRatio: 1.0 / 3},
+		{name: "flood from a new meter", count: 60, interval: 3600, want: rateFlood, wantRatio: 10},
+		{name: "1-minute meter on schedule", count: 350, interval: 60, reportedBefore: true, want: rateOK, wantRatio: 350.0 / 360},
+		{name: "no interval", count: 60, want: rateOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkRate(tt.count, tt.interval, settings, tt.reportedBefore)
+			if got.Direction != tt.want || got.Ratio != tt.wantRatio {
+				t.Errorf("direction, ratio = %s, %v; want %s, %v", got.Direction, got.Ratio, tt.want, tt.wantRatio)
+			}
+			if got.Triggered() != (tt.want != rateOK) {
+				t.Errorf("Triggered = %v for %s", got.Triggered(), got.Direction)
+			}
+		})
+	}
+}
+
+func TestHandlerReportingRate(t *testing.T) {
+	now := time.Now().Unix()
+	// hourly readings of meter-1 from 30h ago until 6h ago, the start of the rate window
+	before := func() []Reading {
+		var rs []Reading
+		for ts := now - 30*3600; ts < now-6*3600; ts += 3600 {
+			rs = append(rs, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: ts, PowerKW: 10})
+		}
+		return rs
+	}
+	// every returns meter-1 readings spaced step seconds over the rate window
+	every := func(step int64) []Reading {
+		var rs []Reading
+		for ts := now - 6*3600 + step; ts < now; ts += step {
+			rs = append(rs, Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: ts, PowerKW: 10})
+		}
+		return rs
+	}
+
+	tests := []struct {
+		name          string
+		readings      []Reading
+		wantDirection string
+	}{
+		{name: "on schedule", readings: append(before(), every(3600)...)},
+		{name: "flood", readings: append(before(), every(360)...), wantDirection: rateFlood},
+		{name: "drought", readings: before(), wantDirection: rateDrought},
+		{name: "new meter", readings: every(3 * 3600)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			reading := Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: now, PowerKW: 10}
+			db.readings = append(tt.readings, reading)
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+				t.Fatal(err)
+			}
+			if err := Handler(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, item := range db.alerts {
+				if attrS(item, "type") != reportingRateAnomaly {
+					continue
+				}
+				if m, ok := item["metadata"].(*types.AttributeValueMemberM); ok {
+					got = append(got, attrS(m.Value, "direction"))
+				}
+			}
+			if want := map[bool]int{true: 1}[tt.wantDirection != ""]; len(got) != want || want == 1 && got[0] != tt.wantDirection {
+				t.Errorf("reporting-rate alerts %v, want %q", got, tt.wantDirection)
+			}
+		})
+	}
+}
+
+func TestMeterInterval(t *testing.T) {
+	byType := parseTypeIntervals(" Smart_Meter=60, ct_logger = 900 ,pump=abc,fan=-5,chiller")
+	if len(byType) != 2 || byType["smart_meter"] != 60 || byType["ct_logger"] != 900 {
+		t.Fatalf("parseTypeIntervals = %v", byType)
+	}
+	tests := []struct {
+		name string
+		eq   *Equipment
+		want int
+	}{
+		{name: "unknown meter", want: 3600},
+		{name: "smart meter", eq: &Equipment{Type: "smart_meter"}, want: 60},
+		{name: "type is case-insensitive", eq: &Equipment{Type: "CT_Logger"}, want: 900},
+		{name: "type without an interval", eq: &Equipment{Type: "pump"}, want: 3600},
+		{name: "equipment's own interval wins", eq: &Equipment{Type: "smart_meter", SamplingIntervalSeconds: 300}, want: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := meterInterval(tt.eq, byType, 3600); got != tt.want {
+				t.Errorf("meterInterval = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerReportingRateByType(t *testing.T) {
+	now := time.Now().Unix()
+	// readings returns the meter's readings every step seconds over the rate
+	// window, plus one from before it so the meter has a reporting history
+	readings := func(meter string, step int64) []Reading {
+		rs := []Reading{{FacilityID: "facility-001", MeterID: meter, Timestamp: now - 7*3600, PowerKW: 10}}
+		for ts := now - 6*3600 + step; ts < now; ts += step {
+			rs = append(rs, Reading{FacilityID: "facility-001", MeterID: meter, Timestamp: ts, PowerKW: 10})
+		}
+		return rs
+	}
+
+	// meter-1 is a smart meter reporting every minute, meter-2 a CT logger every 15
+	tests := []struct {
+		name          string
+		smartStep     int64
+		loggerStep    int64
+		wantDirection map[string]string // by meter; absent for none
+	}{
+		{name: "each at its own cadence", smartStep: 60, loggerStep: 900},
+		{name: "smart meter at the logger's cadence", smartStep: 900, loggerStep: 900, wantDirection: map[string]string{"meter-1": rateDrought}},
+		{name: "logger at the smart meter's cadence", smartStep: 60, loggerStep: 60, wantDirection: map[string]string{"meter-2": rateFlood}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := useFakes(t)
+			useEquipment(t, db,
+				map[string]string{"equipmentId": "sm-1", "facilityId": "facility-001", "meterId": "meter-1", "type": "smart_meter"},
+				map[string]string{"equipmentId": "ct-1", "facilityId": "facility-001", "meterId": "meter-2", "type": "ct_logger"})
+			prev := typeIntervals
+			typeIntervals = map[string]int{"smart_meter": 60, "ct_logger": 900}
+			t.Cleanup(func() { typeIntervals = prev })
+
+			db.readings = append(readings("meter-1", tt.smartStep), readings("meter-2", tt.loggerStep)...)
+			got := make(map[string]string)
+			for _, meter := range []string{"meter-1", "meter-2"} {
+				reading := Reading{FacilityID: "facility-001", MeterID: meter, Timestamp: now, PowerKW: 10}
+				db.readings = append(db.readings, reading)
+				var event events.DynamoDBEvent
+				if err := json.Unmarshal([]byte(replayedRecord(reading)), &event); err != nil {
+					t.Fatal(err)
+				}
+				if err := Handler(context.Background(), event); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, item := range db.alerts {
+				if attrS(item, "type") != reportingRateAnomaly {
+					continue
+				}
+				if m, ok := item["metadata"].(*types.AttributeValueMemberM); ok {
+					got[attrS(item, "equipmentId")] = attrS(m.Value, "direction")
+				}
+			}
+			if len(got) != len(tt.wantDirection) {
+				t.Fatalf("reporting-rate alerts %v, want %v", got, tt.wantDirection)
+			}
+			for meter, want := range tt.wantDirection {
+				if got[meter] != want {
+					t.Errorf("%s alerted %q, want %q", meter, got[meter], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+
+// Default escalation: a reading at 2x the window mean is critical, at 1.5x high
+const (
+	defaultSeverityCriticalMult = 2.0
+	defaultSeverityHighMult     = 1.5
+)
+
+// severityMultipliers map a reading's ratio to the window mean onto a severity
+type severityMultipliers struct {
+	Critical float64 // SEVERITY_CRITICAL_MULT
+	High     float64 // SEVERITY_HIGH_MULT
+}
+
+// loadSeverityMultipliers reads SEVERITY_CRITICAL_MULT and SEVERITY_HIGH_MULT.
+// They must satisfy critical >= high > 1; otherwise both fall back to the
+// defaults with a warning, since fixing one alone could invert the mapping.
+func loadSeverityMultipliers() severityMultipliers {
+	m := severityMultipliers{
+		Critical: mustAtof(getenv("SEVERITY_CRITICAL_MULT", "2.0"), defaultSeverityCriticalMult),
+		High:     mustAtof(getenv("SEVERITY_HIGH_MULT", "1.5"), defaultSeverityHighMult),
+	}
+	if err := m.validate(); err != nil {
+		fmt.Printf("WARN: %v; using %.1fx and %.1fx\n", err, defaultSeverityCriticalMult, defaultSeverityHighMult)
+		return severityMultipliers{Critical: defaultSeverityCriticalMult, High: defaultSeverityHighMult}
+	}
+	return m
+}
+
+func (m severityMultipliers) validate() error {
+	if m.High <= 1 || m.Critical < m.High {
+		return fmt.Errorf("SEVERITY_CRITICAL_MULT=%g and SEVERITY_HIGH_MULT=%g must satisfy critical >= high > 1", m.Critical, m.High)
+	}
+	return nil
+}
+
+// severityFor escalates by how far power is above the window mean; readings
+// below the high multiplier, or without a positive mean, are low
+func (m severityMultipliers) severityFor(power, mean float64) string {
+	switch {
+	case mean > 0 && power >= mean*m.Critical:
+		return "critical"
+	case mean > 0 && power >= mean*m.High:
+		return "high"
+	}
+	return "low"
+}
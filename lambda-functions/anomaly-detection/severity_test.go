@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestSeverityFor(t *testing.T) {
+	defaults := severityMultipliers{Critical: defaultSeverityCriticalMult, High: defaultSeverityHighMult}
+	tests := []struct {
+		name  string
+		m     severityMultipliers
+		power float64
+		mean  float64
+		want  string
+	}{
+		{name: "just under high", m: defaults, power: 14.99, mean: 10, want: "low"},
+		{name: "exactly high", m: defaults, power: 15, mean: 10, want: "high"},
+		{name: "just under critical", m: defaults, power: 19.99, mean: 10, want: "high"},
+		{name: "exactly critical", m: defaults, power: 20, mean: 10, want: "critical"},
+		{name: "far above", m: defaults, power: 100, mean: 10, want: "critical"},
+		{name: "no mean", m: defaults, power: 100, mean: 0, want: "low"},
+		{name: "configured high", m: severityMultipliers{Critical: 3, High: 1.2}, power: 12, mean: 10, want: "high"},
+		{name: "configured critical", m: severityMultipliers{Critical: 3, High: 1.2}, power: 29.99, mean: 10, want: "high"},
+		{name: "configured exactly critical", m: severityMultipliers{Critical: 3, High: 1.2}, power: 30, mean: 10, want: "critical"},
+		{name: "critical equals high", m: severityMultipliers{Critical: 1.5, High: 1.5}, power: 15, mean: 10, want: "critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.severityFor(tt.power, tt.mean); got != tt.want {
+				t.Errorf("severityFor(%v, %v) = %s, want %s", tt.power, tt.mean, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSeverityMultipliers(t *testing.T) {
+	tests := []struct {
+		name     string
+		critical string
+		high     string
+		want     severityMultipliers
+	}{
+		{name: "defaults", want: severityMultipliers{Critical: 2, High: 1.5}},
+		{name: "configured", critical: "3", high: "1.25", want: severityMultipliers{Critical: 3, High: 1.25}},
+		{name: "equal", critical: "1.8", high: "1.8", want: severityMultipliers{Critical: 1.8, High: 1.8}},
+		{name: "inverted", critical: "1.4", high: "1.6", want: severityMultipliers{Critical: 2, High: 1.5}},
+		{name: "high not above the mean", critical: "2", high: "1", want: severityMultipliers{Critical: 2, High: 1.5}},
+		{name: "unparseable", critical: "double", high: "1.25", want: severityMultipliers{Critical: 2, High: 1.25}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SEVERITY_CRITICAL_MULT", tt.critical)
+			t.Setenv("SEVERITY_HIGH_MULT", tt.high)
+			if got := loadSeverityMultipliers(); got != tt.want {
+				t.Errorf("loadSeverityMultipliers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// TrainingRecord is one line of the anomaly training dataset: the features the
+// detector saw, its decision, and the alert it raised (for later labeling)
+type TrainingRecord struct {
+	FacilityID       string  `json:"facility_id"`
+	MeterID          string  `json:"meter_id"`
+	Timestamp        int64   `json:"timestamp"`
+	PowerKW          float64 `json:"power_kw"`
+	Voltage          float64 `json:"voltage"`
+	Current          float64 `json:"current"`
+	Temperature      float64 `json:"temperature"`
+	Mean             float64 `json:"mean"`
+	StdDev           float64 `json:"std_dev"`
+	DeviationPercent float64 `json:"deviation_percent"`
+	Threshold        float64 `json:"threshold"`
+	VoltageMean      float64 `json:"voltage_mean"`
+	VoltageStdDev    float64 `json:"voltage_std_dev"`
+	TemperatureMean  float64 `json:"temperature_mean"`
+	TemperatureStd   float64 `json:"temperature_std_dev"`
+	HistoryCount     int     `json:"history_count"`
+	Window           int     `json:"window"`
+	Sigma            float64 `json:"sigma"`
+	Method           string  `json:"method"`
+	Spikes           int     `json:"spikes"`
+	Outliers         int     `json:"outliers"`
+	IsAnomaly        bool    `json:"is_anomaly"`
+	Severity         string  `json:"severity"`
+	AlertID          string  `json:"alert_id,omitempty"`
+}
+
+// newTrainingRecord captures the feature vector behind a detection decision
+func newTrainingRecord(reading *Reading, historical []Reading, an AnomalyResult, alertID string) TrainingRecord {
+	voltages := make([]float64, len(historical))
+	temps := make([]float64, len(historical))
+	for i, r := range historical {
+		voltages[i] = r.Voltage
+		temps[i] = r.Temperature
+	}
+	vMean, vStd := meanStd(voltages)
+	tMean, tStd := meanStd(temps)
+
+	return TrainingRecord{
+		FacilityID:       reading.FacilityID,
+		MeterID:          reading.MeterID,
+		Timestamp:        reading.Timestamp,
+		PowerKW:          reading.PowerKW,
+		Voltage:          reading.Voltage,
+		Current:          reading.Current,
+		Temperature:      reading.Temperature,
+		Mean:             an.Mean,
+		StdDev:           an.StdDev,
+		DeviationPercent: an.DeviationPercent,
+		Threshold:        an.Threshold,
+		VoltageMean:      vMean,
+		VoltageStdDev:    vStd,
+		TemperatureMean:  tMean,
+		TemperatureStd:   tStd,
+		HistoryCount:     len(historical),
+		Window:           an.Window,
+		Sigma:            an.Sigma,
+		Method:           an.Method,
+		Spikes:           an.Spikes,
+		Outliers:         an.Outliers,
+		IsAnomaly:        an.IsAnomaly,
+		Severity:         an.Severity,
+		AlertID:          alertID,
+	}
+}
+
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var ss float64
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(values)))
+}
+
+// trainingKey partitions the dataset by date (Hive style) so it can be queried
+// with Athena/Glue; one object is written per invocation
+func trainingKey(now time.Time) string {
+	return fmt.Sprintf("%s/date=%s/%d.ndjson", trainingPrefix, now.UTC().Format("2006-01-02"), now.UnixNano())
+}
+
+// encodeTrainingRecords renders records as newline-delimited JSON
+func encodeTrainingRecords(records []TrainingRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return nil, fmt.Errorf("encode training record: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTrainingRecords uploads the invocation's records when ANOMALY_TRAINING_LOG is enabled
+func writeTrainingRecords(ctx context.Context, records []TrainingRecord) error {
+	if !trainingLog || len(records) == 0 {
+		return nil
+	}
+	if trainingBucket == "" {
+		return fmt.Errorf("ANOMALY_TRAINING_LOG is enabled but TRAINING_BUCKET is not set")
+	}
+
+	body, err := encodeTrainingRecords(records)
+	if err != nil {
+		return err
+	}
+
+	key := trainingKey(time.Now())
+	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(trainingBucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("put training records failed: %w", err)
+	}
+
+	fmt.Printf("Wrote %d training records to s3://%s/%s\n", len(records), trainingBucket, key)
+	return nil
+}
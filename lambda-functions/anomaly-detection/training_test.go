@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 keeps the objects PutObject is given
+type fakeS3 struct {
+	objects map[string][]byte
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key)] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestNewTrainingRecord(t *testing.T) {
+	reading := &Reading{FacilityID: "facility-001", MeterID: "meter-1", Timestamp: 1_700_000_000, PowerKW: 40, Voltage: 231, Current: 12, Temperature: 30}
+	historical := []Reading{
+		{Voltage: 228, Temperature: 20},
+		{Voltage: 232, Temperature: 24},
+	}
+	an := AnomalyResult{IsAnomaly: true, Mean: 20, StdDev: 4, DeviationPercent: 100, Threshold: 32, Severity: "high", Window: 2, Sigma: 3, Method: detectionMethod, Spikes: 1}
+
+	rec := newTrainingRecord(reading, historical, an, "alert-1")
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field string
+		want  any
+	}{
+		{"facility_id", "facility-001"},
+		{"meter_id", "meter-1"},
+		{"timestamp", 1_700_000_000.0},
+		{"power_kw", 40.0},
+		{"mean", 20.0},
+		{"std_dev", 4.0},
+		{"deviation_percent", 100.0},
+		{"threshold", 32.0},
+		{"voltage_mean", 230.0},
+		{"voltage_std_dev", 2.0},
+		{"temperature_mean", 22.0},
+		{"temperature_std_dev", 2.0},
+		{"history_count", 2.0},
+		{"window", 2.0},
+		{"sigma", 3.0},
+		{"method", detectionMethod},
+		{"is_anomaly", true},
+		{"severity", "high"},
+		{"alert_id", "alert-1"},
+	}
+	for _, tt := range tests {
+		v, ok := got[tt.field]
+		if !ok {
+			t.Errorf("record lacks %s", tt.field)
+			continue
+		}
+		if f, isNum := v.(float64); isNum && math.Abs(f-tt.want.(float64)) > 1e-9 || !isNum && v != tt.want {
+			t.Errorf("%s = %v, want %v", tt.field, v, tt.want)
+		}
+	}
+
+	// Decisions that raised no alert leave the id out
+	data, _ = json.Marshal(newTrainingRecord(reading, nil, AnomalyResult{}, ""))
+	if bytes.Contains(data, []byte("alert_id")) {
+		t.Errorf("record without an alert has alert_id: %s", data)
+	}
+}
+
+func TestWriteTrainingRecords(t *testing.T) {
+	records := []TrainingRecord{
+		{FacilityID: "facility-001", MeterID: "meter-1", IsAnomaly: true, AlertID: "alert-1"},
+		{FacilityID: "facility-001", MeterID: "meter-2"},
+	}
+	tests := []struct {
+		name        string
+		enabled     bool
+		bucket      string
+		records     []TrainingRecord
+		wantObjects int
+		wantErr     bool
+	}{
+		{name: "disabled writes nothing", bucket: "datasets", records: records},
+		{name: "enabled writes one object", enabled: true, bucket: "datasets", records: records, wantObjects: 1},
+		{name: "nothing to write", enabled: true, bucket: "datasets"},
+		{name: "enabled without a bucket", enabled: true, records: records, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeS3{objects: make(map[string][]byte)}
+			prevClient, prevLog, prevBucket, prevPrefix := s3Client, trainingLog, trainingBucket, trainingPrefix
+			s3Client, trainingLog, trainingBucket, trainingPrefix = store, tt.enabled, tt.bucket, "training/anomaly"
+			t.Cleanup(func() {
+				s3Client, trainingLog, trainingBucket, trainingPrefix = prevClient, prevLog, prevBucket, prevPrefix
+			})
+
+			err := writeTrainingRecords(context.Background(), tt.records)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(store.objects) != tt.wantObjects {
+				t.Fatalf("%d objects written, want %d", len(store.objects), tt.wantObjects)
+			}
+
+			today := time.Now().UTC().Format("2006-01-02")
+			keyRe := regexp.MustCompile(`^datasets/training/anomaly/date=` + today + `/\d+\.ndjson$`)
+			for key, body := range store.objects {
+				if !keyRe.MatchString(key) {
+					t.Errorf("key %q isn't partitioned by date", key)
+				}
+				var lines []TrainingRecord
+				sc := bufio.NewScanner(bytes.NewReader(body))
+				for sc.Scan() {
+					var rec TrainingRecord
+					if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+						t.Fatalf("line %d isn't a record: %v", len(lines)+1, err)
+					}
+					lines = append(lines, rec)
+				}
+				if len(lines) != len(tt.records) || lines[0] != tt.records[0] || lines[1] != tt.records[1] {
+					t.Errorf("dataset = %+v, want %+v", lines, tt.records)
+				}
+			}
+		})
+	}
+}
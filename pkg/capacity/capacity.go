@@ -0,0 +1,106 @@
+// Package capacity compares a facility's load with its transformer capacity.
+// Capacity is per facility, so readings are compared as facility load: the
+// sum of every meter's latest reading at an instant, not one meter's power.
+package capacity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Capacities maps facility IDs to capacity in kW; "*" is the default
+type Capacities map[string]float64
+
+// Parse reads FACILITY_CAPACITY_KW, either a single kW value applied to every
+// facility or a list like "facility-001=500,facility-002=750" (an entry
+// "*=400" sets the default). Malformed entries are left out and reported in
+// the error; the rest are still returned.
+func Parse(spec string) (Capacities, error) {
+	out := make(Capacities)
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return out, nil
+	}
+	if kw, err := strconv.ParseFloat(spec, 64); err == nil {
+		out["*"] = kw
+		return out, nil
+	}
+	var errs []error
+	for _, entry := range strings.Split(spec, ",") {
+		id, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		kw, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if !ok || err != nil || kw <= 0 {
+			errs = append(errs, fmt.Errorf("invalid FACILITY_CAPACITY_KW entry %q", entry))
+			continue
+		}
+		out[strings.TrimSpace(id)] = kw
+	}
+	return out, errors.Join(errs...)
+}
+
+// For returns the facility's capacity in kW, or 0 when unknown
+func (c Capacities) For(facilityID string) float64 {
+	if kw, ok := c[facilityID]; ok {
+		return kw
+	}
+	return c["*"]
+}
+
+// Sample is one meter's power at an instant
+type Sample struct {
+	MeterID string
+	Time    time.Time
+	PowerKW float64
+}
+
+// Load is the facility load at `at`: the sum over meters of each one's latest
+// sample at or before `at`. A sample older than stale no longer counts, so a
+// meter that stopped reporting doesn't hold the load up; stale <= 0 keeps
+// every meter's latest sample.
+func Load(samples []Sample, at time.Time, stale time.Duration) float64 {
+	latest := make(map[string]Sample)
+	for _, s := range samples {
+		if s.Time.After(at) {
+			continue
+		}
+		if prev, ok := latest[s.MeterID]; !ok || !s.Time.Before(prev.Time) {
+			latest[s.MeterID] = s
+		}
+	}
+	var kw float64
+	for _, s := range latest {
+		if stale <= 0 || at.Sub(s.Time) <= stale {
+			kw += s.PowerKW
+		}
+	}
+	return kw
+}
+
+// Peak is the highest facility load at any sample instant, and when it was
+func Peak(samples []Sample, stale time.Duration) (kw float64, at time.Time) {
+	sorted := append([]Sample(nil), samples...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	latest := make(map[string]Sample)
+	for i, s := range sorted {
+		latest[s.MeterID] = s
+		// Samples at the same instant are summed together
+		if i+1 < len(sorted) && sorted[i+1].Time.Equal(s.Time) {
+			continue
+		}
+		var load float64
+		for _, m := range latest {
+			if stale <= 0 || s.Time.Sub(m.Time) <= stale {
+				load += m.PowerKW
+			}
+		}
+		if at.IsZero() || load > kw {
+			kw, at = load, s.Time
+		}
+	}
+	return kw, at
+}
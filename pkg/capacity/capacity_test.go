@@ -0,0 +1,129 @@
+package capacity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Capacities
+		wantErr bool
+	}{
+		{name: "unset", spec: "", want: Capacities{}},
+		{name: "single value", spec: "500", want: Capacities{"*": 500}},
+		{name: "per facility", spec: "facility-001=500, facility-002=750,*=400", want: Capacities{"facility-001": 500, "facility-002": 750, "*": 400}},
+		{name: "malformed entries skipped", spec: "facility-001=500,facility-002,facility-003=-1", want: Capacities{"facility-001": 500}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	c := Capacities{"facility-001": 500, "*": 400}
+	if got := c.For("facility-001"); got != 500 {
+		t.Errorf("For(facility-001) = %v, want 500", got)
+	}
+	if got := c.For("facility-009"); got != 400 {
+		t.Errorf("For(facility-009) = %v, want the 400 default", got)
+	}
+	if got := (Capacities{}).For("facility-001"); got != 0 {
+		t.Errorf("For without capacities = %v, want 0", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+	samples := []Sample{
+		{MeterID: "m1", Time: at(0), PowerKW: 100},
+		{MeterID: "m2", Time: at(20), PowerKW: 200},
+		{MeterID: "m1", Time: at(60), PowerKW: 150},
+		{MeterID: "m3", Time: at(-600), PowerKW: 300},
+	}
+	tests := []struct {
+		name  string
+		at    time.Time
+		stale time.Duration
+		want  float64
+	}{
+		{name: "meters reporting at different times are summed", at: at(30), stale: 2 * time.Minute, want: 300},
+		{name: "latest sample of a meter counts", at: at(60), stale: 2 * time.Minute, want: 350},
+		{name: "later samples don't count", at: at(10), stale: 2 * time.Minute, want: 100},
+		{name: "stale meter dropped", at: at(130), stale: 2 * time.Minute, want: 350},
+		{name: "no staleness limit", at: at(60), want: 650},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Load(samples, tt.at, tt.stale); got != tt.want {
+				t.Errorf("Load = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeak(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+	tests := []struct {
+		name    string
+		samples []Sample
+		stale   time.Duration
+		wantKW  float64
+		wantAt  time.Time
+	}{
+		{name: "no samples"},
+		{
+			// No single meter exceeds 300 kW, but together they reach 450
+			name: "concurrent meters",
+			samples: []Sample{
+				{MeterID: "m2", Time: at(30), PowerKW: 150},
+				{MeterID: "m1", Time: at(0), PowerKW: 300},
+				{MeterID: "m1", Time: at(60), PowerKW: 100},
+			},
+			stale:  2 * time.Minute,
+			wantKW: 450,
+			wantAt: at(30),
+		},
+		{
+			name: "same instant",
+			samples: []Sample{
+				{MeterID: "m1", Time: at(0), PowerKW: 100},
+				{MeterID: "m2", Time: at(0), PowerKW: 100},
+			},
+			stale:  time.Minute,
+			wantKW: 200,
+			wantAt: at(0),
+		},
+		{
+			name: "stale meter doesn't add",
+			samples: []Sample{
+				{MeterID: "m1", Time: at(0), PowerKW: 300},
+				{MeterID: "m2", Time: at(600), PowerKW: 150},
+			},
+			stale:  2 * time.Minute,
+			wantKW: 300,
+			wantAt: at(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kw, when := Peak(tt.samples, tt.stale)
+			if kw != tt.wantKW || !when.Equal(tt.wantAt) {
+				t.Errorf("Peak = %v at %v, want %v at %v", kw, when, tt.wantKW, tt.wantAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package costmodel
+
+import (
+	"math"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// Cents is a currency amount in hundredths. Bill lines are rounded to cents
+// once and everything built from them is integer sums, so line items add up
+// to their totals exactly however many are summed.
+type Cents int64
+
+// ToCents rounds amount half away from zero to whole cents; NaN and ±Inf become 0
+func ToCents(amount float64) Cents {
+	return Cents(math.Round(numfmt.SanitizeFloat(amount) * 100))
+}
+
+// Percent is p percent of c, rounded to whole cents
+func (c Cents) Percent(p float64) Cents {
+	return Cents(math.Round(float64(c) * p / 100))
+}
+
+// Float64 is the amount in currency units, as it is rendered in JSON
+func (c Cents) Float64() float64 {
+	return float64(c) / 100
+}
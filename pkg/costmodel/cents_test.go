@@ -0,0 +1,91 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToCents(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   Cents
+	}{
+		{amount: 0, want: 0},
+		{amount: 1.234, want: 123},
+		{amount: 1.235, want: 124},
+		{amount: 0.005, want: 1},
+		{amount: -0.005, want: -1},
+		{amount: -2.675, want: -268},
+		{amount: math.NaN(), want: 0},
+		{amount: math.Inf(1), want: 0},
+	}
+	for _, tt := range tests {
+		if got := ToCents(tt.amount); got != tt.want {
+			t.Errorf("ToCents(%v) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+	if got := Cents(1999).Percent(7.5); got != 150 {
+		t.Errorf("7.5%% of 19.99 = %d cents, want 150", got)
+	}
+}
+
+func TestCentsReconcile(t *testing.T) {
+	tests := []struct {
+		name  string
+		cost  float64 // one small line, e.g. a meter's share of an hour
+		lines int
+		want  Cents
+	}{
+		{name: "ten cents", cost: 0.1, lines: 10_000, want: 100_000},
+		{name: "seven cents", cost: 0.07, lines: 30_000, want: 210_000},
+		{name: "a cent", cost: 0.01, lines: 99_999, want: 99_999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sum Cents
+			var float float64
+			for i := 0; i < tt.lines; i++ {
+				sum += ToCents(tt.cost)
+				float += tt.cost
+			}
+			if sum != tt.want || sum.Float64() != float64(tt.want)/100 {
+				t.Errorf("%d lines of %v sum to %d cents, want %d", tt.lines, tt.cost, sum, tt.want)
+			}
+			// The float path this replaced drifts off the billed total
+			if float == sum.Float64() {
+				t.Errorf("float sum %v reconciles; the case doesn't show drift", float)
+			}
+		})
+	}
+}
+
+func TestCostHourlyReconciles(t *testing.T) {
+	m := Model{
+		Schedule:          Schedule{RatePerKWh: 0.1337, PeakStartHour: 17, PeakEndHour: 20},
+		DemandChargePerKW: 0.4125,
+		FixedCharges:      []Charge{{Name: "service", Amount: 0.333}, {Name: "metering", Amount: 0.125}},
+		Taxes:             []Tax{{Name: "vat", Percent: 19}, {Name: "levy", Percent: 2.05}},
+	}
+	// Many awkward days; every one must add up exactly as billed
+	for day := 0; day < 200; day++ {
+		hourly := make([]float64, 24)
+		for h := range hourly {
+			hourly[h] = float64((day*37+h*11)%97)/7 + 0.013*float64(day)
+		}
+		b, err := m.CostHourly(hourly)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		energy := ToCents(b.PeakCost) + ToCents(b.OffPeakCost)
+		subtotal := energy + ToCents(b.DemandCharge) + ToCents(b.FixedTotal())
+		if ToCents(b.EnergyCost) != energy || ToCents(b.Subtotal) != subtotal || ToCents(b.TotalCost) != subtotal+ToCents(b.TaxTotal()) {
+			t.Fatalf("day %d doesn't reconcile: %+v", day, b)
+		}
+		for _, line := range []float64{b.PeakCost, b.OffPeakCost, b.DemandCharge, b.Subtotal, b.TotalCost, b.Taxes[0].Amount, b.Taxes[1].Amount} {
+			if line != ToCents(line).Float64() {
+				t.Fatalf("day %d: %v isn't whole cents", day, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Package costmodel prices a facility's day of readings: a time-of-use energy
+// rate, a demand charge on the day's peak, fixed daily charges and taxes.
+// The API reads the model from COST_MODEL_FILE and the analytics Lambda from
+// COST_MODEL_JSON, both with Parse.
+package costmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/energy"
+	"github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg/numfmt"
+)
+
+// Default peak window: business hours, matching the report's peak-hour rule
+const (
+	DefaultPeakStartHour = 9
+	DefaultPeakEndHour   = 17
+)
+
+// ErrInvalidModel is returned for a model that can't price readings
+var ErrInvalidModel = errors.New("invalid cost model")
+
+// Schedule is a time-of-use schedule: hours PeakStartHour..PeakEndHour
+// (inclusive, facility-local time) bill at the converter's peak tier and the rest off-peak
+type Schedule struct {
+	RatePerKWh    float64 `json:"rate_per_kwh"`
+	PeakStartHour int     `json:"peak_start_hour"`
+	PeakEndHour   int     `json:"peak_end_hour"`
+}
+
+// IsPeak reports whether the local hour bills at the peak tier
+func (s Schedule) IsPeak(hour int) bool {
+	return hour >= s.PeakStartHour && hour <= s.PeakEndHour
+}
+
+// Tariff is the converter tier the local hour bills at
+func (s Schedule) Tariff(hour int) energy.Tariff {
+	if s.IsPeak(hour) {
+		return energy.TariffPeak
+	}
+	return energy.TariffOffPeak
+}
+
+// Validate checks the rate is positive and the peak window is within the day
+func (s Schedule) Validate() error {
+	if s.RatePerKWh <= 0 {
+		return fmt.Errorf("%w: rate_per_kwh must be positive", ErrInvalidModel)
+	}
+	if s.PeakStartHour < 0 || s.PeakEndHour > 23 || s.PeakStartHour > s.PeakEndHour {
+		return fmt.Errorf("%w: peak hours must satisfy 0 <= peak_start_hour <= peak_end_hour <= 23", ErrInvalidModel)
+	}
+	return nil
+}
+
+// Charge is a named amount on the bill
+type Charge struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// Tax is a named percentage levied on the subtotal of energy, demand and fixed charges
+type Tax struct {
+	Name    string  `json:"name"`
+	Percent float64 `json:"percent"`
+}
+
+// Model is the full cost model. Demand and fixed charges are per day, since
+// costs are reported per facility day.
+type Model struct {
+	Schedule          Schedule `json:"schedule"`
+	DemandChargePerKW float64  `json:"demand_charge_per_kw"`
+	FixedCharges      []Charge `json:"fixed_charges"`
+	Taxes             []Tax    `json:"taxes"`
+
+	// IntervalSeconds is how long each reading stands for when kW samples
+	// are summed into kWh; it comes from SAMPLING_INTERVAL_SECONDS, not the JSON
+	IntervalSeconds float64 `json:"-"`
+}
+
+// Default is the energy-only model: rate at the default peak window, no
+// demand, fixed charges or taxes
+func Default(ratePerKWh, intervalSeconds float64) Model {
+	return Model{
+		Schedule: Schedule{
+			RatePerKWh:    ratePerKWh,
+			PeakStartHour: DefaultPeakStartHour,
+			PeakEndHour:   DefaultPeakEndHour,
+		},
+		IntervalSeconds: intervalSeconds,
+	}
+}
+
+// Load reads a JSON model from path over def, so fields the file omits keep
+// their defaults. An empty path returns def.
+func Load(path string, def Model) (Model, error) {
+	if path == "" {
+		return def, def.Validate()
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to read cost model: %w", err)
+	}
+	return Parse(raw, def)
+}
+
+// Parse reads a JSON model over def, so fields it omits keep their defaults,
+// and validates the result
+func Parse(raw []byte, def Model) (Model, error) {
+	m := def
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return Model{}, fmt.Errorf("failed to parse cost model: %w", err)
+	}
+	return m, m.Validate()
+}
+
+// Validate checks the schedule and that no charge or tax is negative
+func (m Model) Validate() error {
+	if err := m.Schedule.Validate(); err != nil {
+		return err
+	}
+	if m.DemandChargePerKW < 0 {
+		return fmt.Errorf("%w: demand_charge_per_kw must not be negative", ErrInvalidModel)
+	}
+	for _, c := range m.FixedCharges {
+		if c.Amount < 0 {
+			return fmt.Errorf("%w: fixed charge %q must not be negative", ErrInvalidModel, c.Name)
+		}
+	}
+	for _, t := range m.Taxes {
+		if t.Percent < 0 {
+			return fmt.Errorf("%w: tax %q must not be negative", ErrInvalidModel, t.Name)
+		}
+	}
+	return nil
+}
+
+// Breakdown is a day's consumption and bill, itemised
+type Breakdown struct {
+	HourlyKWh    []float64 `json:"hourly_kwh"`
+	PeakKWh      float64   `json:"peak_kwh"`
+	OffPeakKWh   float64   `json:"offpeak_kwh"`
+	TotalKWh     float64   `json:"total_kwh"`
+	PeakCost     float64   `json:"peak_cost"`
+	OffPeakCost  float64   `json:"offpeak_cost"`
+	EnergyCost   float64   `json:"energy_cost"`
+	PeakDemandKW float64   `json:"peak_demand_kw"`
+	DemandCharge float64   `json:"demand_charge"`
+	FixedCharges []Charge  `json:"fixed_charges"`
+	Subtotal     float64   `json:"subtotal"`
+	Taxes        []Charge  `json:"taxes"`
+	TotalCost    float64   `json:"total_cost"`
+}
+
+// Sample is a power reading at an instant, as Cost and HourlyKWh price it
+type Sample struct {
+	Time    time.Time
+	PowerKW float64
+}
+
+// Cost prices samples bucketed into zone's local hours
+func (m Model) Cost(samples []Sample, zone *time.Location) (Breakdown, error) {
+	return m.CostHourly(m.HourlyKWh(samples, zone))
+}
+
+// HourlyKWh sums samples into 24 local-hour kWh buckets, treating each kW
+// sample as covering IntervalSeconds (the daily summary's convention)
+func (m Model) HourlyKWh(samples []Sample, zone *time.Location) []float64 {
+	hoursPerSample := 1.0
+	if m.IntervalSeconds > 0 {
+		hoursPerSample = m.IntervalSeconds / 3600
+	}
+	hourly := make([]float64, 24)
+	for _, s := range samples {
+		hourly[s.Time.In(zone).Hour()] += s.PowerKW * hoursPerSample
+	}
+	return hourly
+}
+
+// CostHourly prices 24 local-hour kWh buckets; a negative bucket (e.g. from a
+// net-exporting meter) is an error rather than a credit. Peak demand is the highest
+// hourly average load, i.e. the largest bucket. Each bill line is rounded to
+// cents once and subtotal and total are sums of those cents, so the
+// breakdown reconciles exactly.
+func (m Model) CostHourly(hourly []float64) (Breakdown, error) {
+	var conv energy.Converter
+	var b Breakdown
+	var peakCost, offPeakCost float64
+	for h, kwh := range hourly {
+		tariff := m.Schedule.Tariff(h)
+		cost, err := conv.CostForBucket(kwh, m.Schedule.RatePerKWh, tariff)
+		if err != nil {
+			return Breakdown{}, fmt.Errorf("failed to price hour %02d: %w", h, err)
+		}
+		if tariff == energy.TariffPeak {
+			b.PeakKWh += kwh
+			peakCost += cost
+		} else {
+			b.OffPeakKWh += kwh
+			offPeakCost += cost
+		}
+		if kwh > b.PeakDemandKW {
+			b.PeakDemandKW = kwh
+		}
+	}
+	peak, offPeak := ToCents(peakCost), ToCents(offPeakCost)
+	demand := ToCents(b.PeakDemandKW * m.DemandChargePerKW)
+
+	subtotal := peak + offPeak + demand
+	b.FixedCharges = make([]Charge, len(m.FixedCharges))
+	for i, c := range m.FixedCharges {
+		amount := ToCents(c.Amount)
+		subtotal += amount
+		b.FixedCharges[i] = Charge{Name: c.Name, Amount: amount.Float64()}
+	}
+
+	// Taxes are levied on the subtotal as billed
+	total := subtotal
+	b.Taxes = make([]Charge, len(m.Taxes))
+	for i, t := range m.Taxes {
+		amount := subtotal.Percent(t.Percent)
+		total += amount
+		b.Taxes[i] = Charge{Name: t.Name, Amount: amount.Float64()}
+	}
+
+	b.HourlyKWh = numfmt.RoundSlice(hourly, 2)
+	b.TotalKWh = numfmt.Round(b.PeakKWh+b.OffPeakKWh, 2)
+	b.PeakKWh = numfmt.Round(b.PeakKWh, 2)
+	b.OffPeakKWh = numfmt.Round(b.OffPeakKWh, 2)
+	b.PeakCost = peak.Float64()
+	b.OffPeakCost = offPeak.Float64()
+	b.EnergyCost = (peak + offPeak).Float64()
+	b.PeakDemandKW = numfmt.Round(b.PeakDemandKW, 2)
+	b.DemandCharge = demand.Float64()
+	b.Subtotal = subtotal.Float64()
+	b.TotalCost = total.Float64()
+	return b, nil
+}
+
+// FixedTotal is the sum of the fixed charges
+func (b Breakdown) FixedTotal() float64 {
+	var total Cents
+	for _, c := range b.FixedCharges {
+		total += ToCents(c.Amount)
+	}
+	return total.Float64()
+}
+
+// TaxTotal is the sum of the taxes
+func (b Breakdown) TaxTotal() float64 {
+	var total Cents
+	for _, t := range b.Taxes {
+		total += ToCents(t.Amount)
+	}
+	return total.Float64()
+}
@@ -0,0 +1,111 @@
+package costmodel
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	def := Default(0.2, 60)
+	tests := []struct {
+		name    string
+		raw     string
+		want    Model
+		wantErr error
+	}{
+		{
+			name: "fields default",
+			raw:  `{"demand_charge_per_kw": 4}`,
+			want: Model{Schedule: def.Schedule, DemandChargePerKW: 4, IntervalSeconds: 60},
+		},
+		{
+			name: "schedule replaced",
+			raw:  `{"schedule": {"rate_per_kwh": 0.3, "peak_start_hour": 7, "peak_end_hour": 21}}`,
+			want: Model{Schedule: Schedule{RatePerKWh: 0.3, PeakStartHour: 7, PeakEndHour: 21}, IntervalSeconds: 60},
+		},
+		{name: "negative tax", raw: `{"taxes": [{"name": "vat", "percent": -5}]}`, wantErr: ErrInvalidModel},
+		{name: "peak window out of day", raw: `{"schedule": {"rate_per_kwh": 0.3, "peak_start_hour": 9, "peak_end_hour": 24}}`, wantErr: ErrInvalidModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.raw), def)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Schedule != tt.want.Schedule || got.DemandChargePerKW != tt.want.DemandChargePerKW || got.IntervalSeconds != tt.want.IntervalSeconds) {
+				t.Errorf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if _, err := Parse([]byte(`{`), def); err == nil {
+		t.Error("Parse accepted malformed JSON")
+	}
+}
+
+func TestCost(t *testing.T) {
+	m := Default(0.2, 3600)
+	m.DemandChargePerKW = 1
+	m.FixedCharges = []Charge{{Name: "meter", Amount: 0.333}, {Name: "service", Amount: 0.333}}
+	m.Taxes = []Tax{{Name: "state", Percent: 5}, {Name: "city", Percent: 2.5}}
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		samples     []Sample
+		wantPeakKWh float64
+		wantOffKWh  float64
+		wantErr     bool
+	}{
+		{name: "no samples"},
+		{
+			name: "peak and off-peak",
+			samples: []Sample{
+				{Time: day.Add(10 * time.Hour), PowerKW: 3},
+				{Time: day.Add(10*time.Hour + 30*time.Minute), PowerKW: 1},
+				{Time: day.Add(2 * time.Hour), PowerKW: 5},
+			},
+			wantPeakKWh: 4,
+			wantOffKWh:  5,
+		},
+		{name: "net export", samples: []Sample{{Time: day, PowerKW: -1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := m.Cost(tt.samples, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if b.PeakKWh != tt.wantPeakKWh || b.OffPeakKWh != tt.wantOffKWh {
+				t.Errorf("peak/off-peak kWh = %v/%v, want %v/%v", b.PeakKWh, b.OffPeakKWh, tt.wantPeakKWh, tt.wantOffKWh)
+			}
+			// The lines must reconcile to the cent
+			lines := ToCents(b.PeakCost) + ToCents(b.OffPeakCost) + ToCents(b.DemandCharge) + ToCents(b.FixedTotal())
+			if lines != ToCents(b.Subtotal) {
+				t.Errorf("lines sum to %v, subtotal is %v", lines.Float64(), b.Subtotal)
+			}
+			if ToCents(b.Subtotal)+ToCents(b.TaxTotal()) != ToCents(b.TotalCost) {
+				t.Errorf("subtotal %v + taxes %v != total %v", b.Subtotal, b.TaxTotal(), b.TotalCost)
+			}
+			if b.FixedTotal() != 0.66 {
+				t.Errorf("FixedTotal = %v, want 0.66", b.FixedTotal())
+			}
+		})
+	}
+}
+
+func TestHourlyKWhUsesZone(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+	m := Default(0.2, 1800)
+	// 15:00 UTC is 09:00 in Chicago in March (CST)
+	hourly := m.HourlyKWh([]Sample{{Time: time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC), PowerKW: 4}}, chicago)
+	if hourly[9] != 2 || hourly[15] != 0 {
+		t.Errorf("hourly[9], hourly[15] = %v, %v; want 2, 0", hourly[9], hourly[15])
+	}
+}
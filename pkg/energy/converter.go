@@ -0,0 +1,98 @@
+// Package energy wraps the analytics library's converter with typed tariffs
+// and input checks, so callers get an error instead of a silently wrong
+// figure for an unknown tier or negative energy.
+package energy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ANIKETSHETTY47/energy-grid-analytics-go/converter"
+)
+
+// Tariff is a converter pricing tier
+type Tariff string
+
+// Tariffs the converter prices: peak at 1.5x the rate, off-peak at 0.7x,
+// standard at the rate
+const (
+	TariffPeak     Tariff = "peak"
+	TariffOffPeak  Tariff = "offpeak"
+	TariffStandard Tariff = "standard"
+)
+
+var (
+	// ErrUnknownTariff is returned for a tariff the converter doesn't price
+	ErrUnknownTariff = errors.New("unknown tariff")
+	// ErrInvalidQuantity is returned for a negative or non-finite energy, rate or efficiency input
+	ErrInvalidQuantity = errors.New("invalid energy quantity")
+)
+
+// ParseTariff validates a tariff name
+func ParseTariff(name string) (Tariff, error) {
+	switch t := Tariff(name); t {
+	case TariffPeak, TariffOffPeak, TariffStandard:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownTariff, name)
+	}
+}
+
+// Converter is the checked counterpart of converter.EnergyConverter; the zero
+// value is ready to use
+type Converter struct {
+	conv converter.EnergyConverter
+}
+
+// CostForBucket prices kwh at rate in the tariff's tier
+func (c Converter) CostForBucket(kwh, rate float64, tariff Tariff) (float64, error) {
+	if _, err := ParseTariff(string(tariff)); err != nil {
+		return 0, err
+	}
+	if err := checkQuantity("kwh", kwh); err != nil {
+		return 0, err
+	}
+	if err := checkQuantity("rate", rate); err != nil {
+		return 0, err
+	}
+	return c.conv.CalculateCost(kwh, rate, string(tariff)), nil
+}
+
+// ToMWh converts kWh to MWh
+func (c Converter) ToMWh(kwh float64) (float64, error) {
+	if err := checkQuantity("kwh", kwh); err != nil {
+		return 0, err
+	}
+	return c.conv.KWhToMWh(kwh), nil
+}
+
+// ToGWh converts kWh to GWh
+func (c Converter) ToGWh(kwh float64) (float64, error) {
+	mwh, err := c.ToMWh(kwh)
+	if err != nil {
+		return 0, err
+	}
+	return mwh / 1000, nil
+}
+
+// Efficiency is output as a percentage of input; zero input yields 0
+func (c Converter) Efficiency(inputKWh, outputKWh float64) (float64, error) {
+	if err := checkQuantity("input", inputKWh); err != nil {
+		return 0, err
+	}
+	if err := checkQuantity("output", outputKWh); err != nil {
+		return 0, err
+	}
+	return c.conv.CalculateEfficiency(inputKWh, outputKWh), nil
+}
+
+func checkQuantity(name string, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("%w: %s is %g", ErrInvalidQuantity, name, v)
+	}
+	if v < 0 {
+		return fmt.Errorf("%w: %s must not be negative, got %g", ErrInvalidQuantity, name, v)
+	}
+	return nil
+}
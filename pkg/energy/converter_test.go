@@ -0,0 +1,88 @@
+package energy
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCostForBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		kwh     float64
+		rate    float64
+		tariff  Tariff
+		want    float64
+		wantErr error
+	}{
+		{name: "peak", kwh: 10, rate: 0.2, tariff: TariffPeak, want: 3},
+		{name: "off-peak", kwh: 10, rate: 0.2, tariff: TariffOffPeak, want: 1.4},
+		{name: "standard", kwh: 10, rate: 0.2, tariff: TariffStandard, want: 2},
+		{name: "unknown tariff", kwh: 10, rate: 0.2, tariff: "weekend", wantErr: ErrUnknownTariff},
+		{name: "negative energy", kwh: -1, rate: 0.2, tariff: TariffPeak, wantErr: ErrInvalidQuantity},
+		{name: "NaN energy", kwh: math.NaN(), rate: 0.2, tariff: TariffPeak, wantErr: ErrInvalidQuantity},
+		{name: "infinite rate", kwh: 1, rate: math.Inf(1), tariff: TariffPeak, wantErr: ErrInvalidQuantity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Converter{}.CostForBucket(tt.kwh, tt.rate, tt.tariff)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CostForBucket = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTariff(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Tariff
+		wantErr bool
+	}{
+		{name: "peak", want: TariffPeak},
+		{name: "offpeak", want: TariffOffPeak},
+		{name: "standard", want: TariffStandard},
+		{name: "off-peak", wantErr: true},
+		{name: "Peak", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTariff(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrUnknownTariff) {
+			t.Errorf("ParseTariff(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestConversions(t *testing.T) {
+	c := Converter{}
+	tests := []struct {
+		name    string
+		convert func() (float64, error)
+		want    float64
+		wantErr bool
+	}{
+		{name: "MWh", convert: func() (float64, error) { return c.ToMWh(2500) }, want: 2.5},
+		{name: "GWh", convert: func() (float64, error) { return c.ToGWh(3_000_000) }, want: 3},
+		{name: "negative MWh", convert: func() (float64, error) { return c.ToMWh(-1) }, wantErr: true},
+		{name: "negative GWh", convert: func() (float64, error) { return c.ToGWh(-1) }, wantErr: true},
+		{name: "efficiency", convert: func() (float64, error) { return c.Efficiency(200, 150) }, want: 75},
+		{name: "efficiency of nothing", convert: func() (float64, error) { return c.Efficiency(0, 0) }, want: 0},
+		{name: "negative output", convert: func() (float64, error) { return c.Efficiency(200, -5) }, wantErr: true},
+		{name: "NaN input", convert: func() (float64, error) { return c.Efficiency(math.NaN(), 5) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.convert()
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrInvalidQuantity) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
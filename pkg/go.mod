@@ -0,0 +1,10 @@
+module github.com/ANIKETSHETTY47/smart-energy-grid-management-system/pkg
+
+go 1.23
+
+require (
+	github.com/ANIKETSHETTY47/energy-grid-analytics-go v1.0.0
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.52.4
+)
+
+require github.com/aws/smithy-go v1.23.2 // indirect
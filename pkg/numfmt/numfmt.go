@@ -0,0 +1,39 @@
+// Package numfmt rounds figures for output and replaces the NaN and ±Inf that
+// encoding/json can't marshal, the same way in the API and the Lambdas.
+package numfmt
+
+import "math"
+
+// SanitizeFloat returns 0 for NaN and ±Inf, which encoding/json can't
+// marshal, and x otherwise
+func SanitizeFloat(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0
+	}
+	return x
+}
+
+// Round rounds x half away from zero to the given number of decimal places
+// (negative places round to tens, hundreds, ...). NaN and ±Inf become 0, and
+// values too large to scale are returned unchanged.
+func Round(x float64, places int) float64 {
+	x = SanitizeFloat(x)
+	k := math.Pow10(places)
+	scaled := x * k
+	if math.IsInf(scaled, 0) {
+		return x
+	}
+	return math.Round(scaled) / k
+}
+
+// RoundSlice returns a rounded copy of xs; nil stays nil
+func RoundSlice(xs []float64, places int) []float64 {
+	if xs == nil {
+		return nil
+	}
+	out := make([]float64, len(xs))
+	for i, v := range xs {
+		out[i] = Round(v, places)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package numfmt
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		x      float64
+		places int
+		want   float64
+	}{
+		{1.005, 2, 1},
+		{1.015, 1, 1},
+		{2.5, 0, 3},
+		{-2.5, 0, -3},
+		{1234.5678, 2, 1234.57},
+		{1234.5678, -2, 1200},
+		{math.NaN(), 2, 0},
+		{math.Inf(1), 2, 0},
+		{math.Inf(-1), 2, 0},
+		{math.MaxFloat64, 2, math.MaxFloat64},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.x, tt.places); got != tt.want {
+			t.Errorf("Round(%v, %d) = %v, want %v", tt.x, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFloat(t *testing.T) {
+	tests := []struct {
+		x    float64
+		want float64
+	}{
+		{1.5, 1.5},
+		{-3, -3},
+		{math.NaN(), 0},
+		{math.Inf(1), 0},
+		{math.Inf(-1), 0},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFloat(tt.x); got != tt.want {
+			t.Errorf("SanitizeFloat(%v) = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestRoundSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		xs   []float64
+		want []float64
+	}{
+		{name: "nil", xs: nil, want: nil},
+		{name: "empty", xs: []float64{}, want: []float64{}},
+		{name: "values", xs: []float64{1.234, math.NaN(), 5.678}, want: []float64{1.23, 0, 5.68}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundSlice(tt.xs, 2); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoundSlice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package timeutil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Timestamp is a point in time that travels as epoch seconds: a JSON number
+// and a DynamoDB N attribute. It is what readings and alerts carry between
+// the API, DynamoDB, the Lambdas and the dashboard, so every component agrees
+// on one wire format. Decoding is lenient and also accepts RFC3339 strings and
+// epoch milliseconds; the zero Timestamp encodes as 0 and 0 decodes to zero.
+type Timestamp struct {
+	time.Time
+}
+
+// FromTime wraps t. Sub-second precision is kept in memory and in Postgres
+// but dropped on the wire.
+func FromTime(t time.Time) Timestamp {
+	return Timestamp{t}
+}
+
+// FromUnix returns the Timestamp for epoch seconds sec; 0 is the zero Timestamp
+func FromUnix(sec int64) Timestamp {
+	if sec == 0 {
+		return Timestamp{}
+	}
+	return Timestamp{time.Unix(sec, 0).UTC()}
+}
+
+// Now is the current time as a Timestamp
+func Now() Timestamp {
+	return Timestamp{time.Now().UTC()}
+}
+
+// Unix returns the epoch seconds of ts; the zero Timestamp is 0
+func (ts Timestamp) Unix() int64 {
+	if ts.IsZero() {
+		return 0
+	}
+	return ts.Time.Unix()
+}
+
+// MarshalJSON encodes ts as epoch seconds
+func (ts Timestamp) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, ts.Unix(), 10), nil
+}
+
+// UnmarshalJSON accepts epoch seconds or milliseconds, as a number or a
+// quoted string, or an RFC3339 string
+func (ts *Timestamp) UnmarshalJSON(data []byte) error {
+	t, err := ParseJSONTimestamp(data)
+	if err != nil {
+		return err
+	}
+	*ts = fromParsed(t)
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue encodes ts as an N attribute of epoch seconds
+func (ts Timestamp) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(ts.Unix(), 10)}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue accepts an N attribute, or an S attribute
+// holding anything ParseTimestamp does; NULL leaves the zero Timestamp
+func (ts *Timestamp) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	var raw string
+	switch v := av.(type) {
+	case *types.AttributeValueMemberN:
+		raw = v.Value
+	case *types.AttributeValueMemberS:
+		raw = v.Value
+	case *types.AttributeValueMemberNULL:
+		*ts = Timestamp{}
+		return nil
+	default:
+		return fmt.Errorf("invalid timestamp attribute %T: expected N or S", av)
+	}
+	t, err := ParseTimestamp(raw)
+	if err != nil {
+		return err
+	}
+	*ts = fromParsed(t)
+	return nil
+}
+
+// Value stores ts in a SQL timestamp column; the zero Timestamp is NULL
+func (ts Timestamp) Value() (driver.Value, error) {
+	if ts.IsZero() {
+		return nil, nil
+	}
+	return ts.Time, nil
+}
+
+// Scan reads a SQL timestamp column
+func (ts *Timestamp) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*ts = Timestamp{}
+	case time.Time:
+		*ts = Timestamp{v}
+	case []byte:
+		return ts.Scan(string(v))
+	case string:
+		t, err := ParseTimestamp(v)
+		if err != nil {
+			return err
+		}
+		*ts = Timestamp{t}
+	default:
+		return fmt.Errorf("cannot scan %T into a timestamp", src)
+	}
+	return nil
+}
+
+// fromParsed maps the epoch 0 that encodes the zero Timestamp back to it
+func fromParsed(t time.Time) Timestamp {
+	if t.Unix() == 0 && t.Nanosecond() == 0 {
+		return Timestamp{}
+	}
+	return Timestamp{t}
+}
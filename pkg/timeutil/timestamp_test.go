@@ -0,0 +1,127 @@
+package timeutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		raw      string // a payload's "timestamp" field
+		want     Timestamp
+		wantJSON string // what it encodes back to
+		wantErr  bool
+	}{
+		{name: "epoch seconds", raw: `1709294400`, want: FromTime(at), wantJSON: `1709294400`},
+		{name: "epoch milliseconds", raw: `1709294400000`, want: FromTime(at), wantJSON: `1709294400`},
+		{name: "quoted epoch", raw: `"1709294400"`, want: FromTime(at), wantJSON: `1709294400`},
+		{name: "RFC3339 from the simulator", raw: `"2024-03-01T12:00:00Z"`, want: FromTime(at), wantJSON: `1709294400`},
+		{name: "sub-second dropped on the wire", raw: `"2024-03-01T12:00:00.75Z"`, want: FromTime(at.Add(750 * time.Millisecond)), wantJSON: `1709294400`},
+		{name: "zero", raw: `0`, wantJSON: `0`},
+		{name: "null", raw: `null`, wantJSON: `0`},
+		{name: "garbage", raw: `"soon"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload struct {
+				Timestamp Timestamp `json:"timestamp"`
+			}
+			err := json.Unmarshal([]byte(`{"timestamp":`+tt.raw+`}`), &payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !payload.Timestamp.Equal(tt.want.Time) || payload.Timestamp.IsZero() != tt.want.IsZero() {
+				t.Errorf("decoded %v, want %v", payload.Timestamp, tt.want)
+			}
+			out, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `{"timestamp":` + tt.wantJSON + `}`; string(out) != want {
+				t.Errorf("encoded %s, want %s", out, want)
+			}
+		})
+	}
+}
+
+func TestTimestampDynamoDB(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		av      types.AttributeValue
+		want    Timestamp
+		wantN   string // the N attribute it encodes back to
+		wantErr bool
+	}{
+		{name: "N epoch seconds", av: &types.AttributeValueMemberN{Value: "1709294400"}, want: FromTime(at), wantN: "1709294400"},
+		{name: "N epoch milliseconds", av: &types.AttributeValueMemberN{Value: "1709294400000"}, want: FromTime(at), wantN: "1709294400"},
+		{name: "S RFC3339 from older items", av: &types.AttributeValueMemberS{Value: "2024-03-01T12:00:00Z"}, want: FromTime(at), wantN: "1709294400"},
+		{name: "N zero", av: &types.AttributeValueMemberN{Value: "0"}, wantN: "0"},
+		{name: "NULL", av: &types.AttributeValueMemberNULL{Value: true}, wantN: "0"},
+		{name: "bad S", av: &types.AttributeValueMemberS{Value: "soon"}, wantErr: true},
+		{name: "BOOL", av: &types.AttributeValueMemberBOOL{Value: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			err := ts.UnmarshalDynamoDBAttributeValue(tt.av)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !ts.Equal(tt.want.Time) || ts.IsZero() != tt.want.IsZero() {
+				t.Errorf("decoded %v, want %v", ts, tt.want)
+			}
+			av, err := ts.MarshalDynamoDBAttributeValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, ok := av.(*types.AttributeValueMemberN); !ok || n.Value != tt.wantN {
+				t.Errorf("encoded %#v, want N %s", av, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestTimestampSQL(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Timestamp
+		wantErr bool
+	}{
+		{name: "time", src: at, want: FromTime(at)},
+		{name: "text", src: "2024-03-01T12:00:00Z", want: FromUnix(1709294400)},
+		{name: "bytes", src: []byte("1709294400"), want: FromUnix(1709294400)},
+		{name: "NULL", src: nil},
+		{name: "number", src: int64(1709294400), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			err := ts.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !ts.Equal(tt.want.Time) || ts.IsZero() != tt.want.IsZero() {
+				t.Errorf("scanned %v, want %v", ts, tt.want)
+			}
+			// Postgres keeps what it was given, sub-second included; zero is NULL
+			v, _ := ts.Value()
+			if tt.want.IsZero() && v != nil || !tt.want.IsZero() && !v.(time.Time).Equal(tt.want.Time) {
+				t.Errorf("Value() = %v, want %v", v, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package timeutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds. Epoch
+// seconds stay below it until the year 33658, while any millisecond timestamp
+// after early 2001 is above it.
+const epochMillisThreshold = 1e12
+
+// ParseTimestamp parses an RFC3339 string or an epoch timestamp in seconds or
+// milliseconds, telling the two epoch units apart by magnitude. Fractional
+// epoch values are accepted. An empty string yields the zero time.
+func ParseTimestamp(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return fromEpoch(n), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: expected RFC3339 or epoch seconds/milliseconds", raw)
+	}
+	return t, nil
+}
+
+// ParseJSONTimestamp parses a timestamp JSON value, either a string (RFC3339
+// or a quoted epoch) or a bare number. null and absent values yield the zero time.
+func ParseJSONTimestamp(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	var s string
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+	} else {
+		s = string(raw)
+	}
+	return ParseTimestamp(s)
+}
+
+func fromEpoch(n float64) time.Time {
+	if n >= epochMillisThreshold || n <= -epochMillisThreshold {
+		ms := int64(n)
+		return time.UnixMilli(ms).Add(time.Duration((n - float64(ms)) * float64(time.Millisecond))).UTC()
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*float64(time.Second))).UTC()
+}
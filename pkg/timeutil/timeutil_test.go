@@ -0,0 +1,71 @@
+package timeutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: time.Time{}},
+		{name: "RFC3339", raw: "2024-03-01T12:00:00Z", want: want},
+		{name: "RFC3339 with offset", raw: "2024-03-01T13:00:00+01:00", want: want},
+		{name: "epoch seconds", raw: "1709294400", want: want},
+		{name: "epoch milliseconds", raw: "1709294400000", want: want},
+		{name: "fractional seconds", raw: "1709294400.5", want: want.Add(500 * time.Millisecond)},
+		{name: "surrounding space", raw: " 1709294400 ", want: want},
+		{name: "garbage", raw: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONTimestamp(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "absent", raw: "", want: time.Time{}},
+		{name: "null", raw: "null", want: time.Time{}},
+		{name: "RFC3339 string", raw: `"2024-03-01T12:00:00Z"`, want: want},
+		{name: "bare epoch seconds", raw: `1709294400`, want: want},
+		{name: "bare epoch millis", raw: `1709294400000`, want: want},
+		{name: "quoted epoch", raw: `"1709294400"`, want: want},
+		// Either side of the threshold: the largest seconds value read as
+		// seconds, the smallest millis value read as millis
+		{name: "seconds below threshold", raw: `999999999999`, want: time.Unix(999999999999, 0).UTC()},
+		{name: "millis at threshold", raw: `1000000000000`, want: time.UnixMilli(1000000000000).UTC()},
+		{name: "bad string", raw: `"soon"`, wantErr: true},
+		{name: "malformed JSON string", raw: `"2024`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSONTimestamp(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseJSONTimestamp(%s) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
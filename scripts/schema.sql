@@ -3,11 +3,22 @@ CREATE TABLE IF NOT EXISTS facilities(
   id serial primary key,
   name text not null
 );
+-- IANA zone name for local-day analytics; empty means UTC
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS timezone text not null default '';
 CREATE TABLE IF NOT EXISTS meters(
   id serial primary key,
   facility_id int not null references facilities(id),
-  serial text not null
+  serial text not null,
+  zone text not null default ''
 );
+-- Zones group meters into buildings/areas for zone-level analytics
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS zone text not null default '';
+-- Per-meter calibration: calibrated = raw*gain + offset
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS voltage_gain double precision not null default 1;
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS power_gain double precision not null default 1;
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS power_offset double precision not null default 0;
+-- Lifecycle: commissioned, active or decommissioned; only active meters are checked
+ALTER TABLE meters ADD COLUMN IF NOT EXISTS status text not null default 'active';
 CREATE TABLE IF NOT EXISTS readings(
   id bigserial primary key,
   meter_id int not null references meters(id),
@@ -16,3 +27,30 @@ CREATE TABLE IF NOT EXISTS readings(
   current double precision not null,
   power_kw double precision not null
 );
+-- Optional power-quality attributes
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS reactive_power_kvar double precision;
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS frequency_hz double precision;
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS thd_percent double precision;
+-- Ingestion path: mqtt, http, batch or import
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS source text not null default '';
+-- Uncalibrated meter values, kept alongside the calibrated ones
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS raw_voltage double precision;
+ALTER TABLE readings ADD COLUMN IF NOT EXISTS raw_power_kw double precision;
+-- One reading per meter and instant, so MQTT redeliveries, retried batches and
+-- re-imported CSVs are skipped by InsertReading's ON CONFLICT DO NOTHING.
+-- Existing duplicates must be removed first (see README, MQTT reading payload).
+CREATE UNIQUE INDEX IF NOT EXISTS readings_meter_timestamp_idx ON readings(meter_id, timestamp);
+-- Maintenance prediction history written by POST /facilities/:id/maintenance/run
+CREATE TABLE IF NOT EXISTS maintenance_predictions(
+  id bigserial primary key,
+  facility_id text not null,
+  equipment_id text not null,
+  computed_at timestamptz not null,
+  health_score double precision not null,
+  failure_risk_30_days double precision not null,
+  failure_risk_90_days double precision not null,
+  next_service_date timestamptz not null,
+  recommendation text not null,
+  high_risk boolean not null
+);
+CREATE INDEX IF NOT EXISTS maintenance_predictions_equipment_idx ON maintenance_predictions(equipment_id, computed_at);
@@ -1,2 +1,2 @@
 insert into facilities(name) values ('Plant A') on conflict do nothing;
-insert into meters(facility_id, serial) values (1,'meter-001') on conflict do nothing;
+insert into meters(facility_id, serial, zone) values (1,'meter-001','main') on conflict do nothing;
@@ -78,9 +78,36 @@ aws dynamodb create-table \
   --billing-mode PAY_PER_REQUEST \
   --region $AWS_REGION 2>/dev/null || echo "Table exists"
 
+# Facilities (metadata such as the IANA timezone used for local-day analytics)
+aws dynamodb create-table \
+  --table-name Facilities \
+  --attribute-definitions \
+    AttributeName=facilityId,AttributeType=S \
+  --key-schema \
+    AttributeName=facilityId,KeyType=HASH \
+  --billing-mode PAY_PER_REQUEST \
+  --region $AWS_REGION 2>/dev/null || echo "Table exists"
+
+# MaintenancePredictions (one item per equipment per maintenance run)
+aws dynamodb create-table \
+  --table-name MaintenancePredictions \
+  --attribute-definitions \
+    AttributeName=equipmentId,AttributeType=S \
+    AttributeName=computedAt,AttributeType=N \
+  --key-schema \
+    AttributeName=equipmentId,KeyType=HASH \
+    AttributeName=computedAt,KeyType=RANGE \
+  --billing-mode PAY_PER_REQUEST \
+  --region $AWS_REGION 2>/dev/null || echo "Table exists"
+
 echo "Waiting for tables..."
 aws dynamodb wait table-exists --table-name EnergyReadings --region $AWS_REGION
 aws dynamodb wait table-exists --table-name Alerts --region $AWS_REGION
+# Closed alerts are archived to S3 and then expire on expiresAt
+aws dynamodb update-time-to-live \
+  --table-name Alerts \
+  --time-to-live-specification "Enabled=true, AttributeName=expiresAt" \
+  --region $AWS_REGION 2>/dev/null || echo "TTL already enabled"
 
 # 4. Deploy Lambda Functions
 echo "Deploying Lambda functions..."
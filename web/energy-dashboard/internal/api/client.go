@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"energy-dashboard-go/internal/models"
@@ -15,9 +16,19 @@ import (
 
 type Client struct {
 	baseURL string
+	token   string // bearer token from API_TOKEN, sent when set
 	http    *http.Client
+	retries int // extra attempts after a transient failure, from API_RETRIES
+	breaker *breaker
+
+	retried   atomic.Int64
+	lastRetry atomic.Int64 // unix seconds of the latest retry
 }
 
+// New creates a client for API_URL. API_TIMEOUT_SECONDS bounds each attempt,
+// API_RETRIES is how often transient failures are retried, and the breaker
+// opens after API_BREAKER_THRESHOLD consecutive failures for
+// API_BREAKER_COOLDOWN_SECONDS. API_TOKEN, when set, is sent as a bearer token.
 func New() *Client {
 	base := os.Getenv("API_URL")
 	if base == "" {
@@ -25,7 +36,13 @@ func New() *Client {
 	}
 	return &Client{
 		baseURL: base,
-		http: &http.Client{ Timeout: 10 * time.Second },
+		token:   os.Getenv("API_TOKEN"),
+		http: &http.Client{ Timeout: time.Duration(max(1, envInt("API_TIMEOUT_SECONDS", 10))) * time.Second },
+		retries: envInt("API_RETRIES", 2),
+		breaker: &breaker{
+			threshold: max(1, envInt("API_BREAKER_THRESHOLD", 5)),
+			cooldown:  time.Duration(envInt("API_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second,
+		},
 	}
 }
 
@@ -38,21 +55,38 @@ func (c *Client) Health(ctx context.Context) (*models.Health, error) {
 }
 
 func (c *Client) Facilities(ctx context.Context) ([]models.Facility, error) {
-	var out []models.Facility
+	var out models.FacilitiesResponse
 	if err := c.getJSON(ctx, "/facilities", &out, nil); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return out.Facilities, nil
 }
 
+// maxReadingPages bounds how many pages RecentReadings follows; the result is
+// marked truncated when the window holds more
+const maxReadingPages = 10
+
+// RecentReadings fetches the readings of the last hours with their quality
+// scores, following next_cursor across pages
 func (c *Client) RecentReadings(ctx context.Context, facilityID string, hours int) (*models.RecentReadingsResponse, error) {
 	params := url.Values{}
 	params.Set("facility_id", facilityID)
 	params.Set("hours", fmt.Sprintf("%d", hours))
+	params.Set("expand", "quality")
 	var out models.RecentReadingsResponse
 	if err := c.getJSON(ctx, "/readings/recent", &out, params); err != nil {
 		return nil, err
 	}
+
+	for pages := 1; out.NextCursor != "" && pages < maxReadingPages; pages++ {
+		params.Set("cursor", out.NextCursor)
+		var page models.RecentReadingsResponse
+		if err := c.getJSON(ctx, "/readings/recent", &page, params); err != nil {
+			return nil, err
+		}
+		out.Readings = append(out.Readings, page.Readings...)
+		out.Truncated, out.NextCursor = page.Truncated, page.NextCursor
+	}
 	return &out, nil
 }
 
@@ -69,12 +103,31 @@ func (c *Client) Alerts(ctx context.Context, facilityID, severity string) (*mode
 	return &out, nil
 }
 
+// AlertsPage fetches one page of limit alerts, starting at cursor (empty for
+// the first page)
+func (c *Client) AlertsPage(ctx context.Context, facilityID, severity, cursor string, limit int) (*models.AlertsResponse, error) {
+	params := url.Values{}
+	params.Set("facility_id", facilityID)
+	if severity != "" {
+		params.Set("severity", severity)
+	}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	var out models.AlertsResponse
+	if err := c.getJSON(ctx, "/alerts", &out, params); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *Client) AcknowledgeAlert(ctx context.Context, alertID string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/alerts/"+url.PathEscape(alertID)+"/acknowledge", nil)
 	if err != nil {
 		return err
 	}
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -91,7 +144,7 @@ func (c *Client) GenerateAnalytics(ctx context.Context, facilityID, date string)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/analytics/generate", bytes.NewReader(b))
 	if err != nil { return nil, err }
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req)
 	if err != nil { return nil, err }
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -102,6 +155,18 @@ func (c *Client) GenerateAnalytics(ctx context.Context, facilityID, date string)
 	return &out, nil
 }
 
+// AnalyticsChart fetches chart-ready series for a facility's day
+func (c *Client) AnalyticsChart(ctx context.Context, facilityID, date string) (*models.AnalyticsChart, error) {
+	params := url.Values{}
+	params.Set("facility_id", facilityID)
+	params.Set("date", date)
+	var out models.AnalyticsChart
+	if err := c.getJSON(ctx, "/analytics/chart", &out, params); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *Client) getJSON(ctx context.Context, path string, out any, params url.Values) error {
 	u := c.baseURL + path
 	if params != nil {
@@ -111,7 +176,7 @@ func (c *Client) getJSON(ctx context.Context, path string, out any, params url.V
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil { return err }
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req)
 	if err != nil { return err }
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
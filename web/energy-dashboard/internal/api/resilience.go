@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the API while the breaker is open
+var ErrCircuitOpen = errors.New("api circuit open: backend is failing, not retrying until cooldown ends")
+
+// Breaker states reported by Status
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// retryBackoff is the wait before the first retry; it doubles per attempt
+const retryBackoff = 200 * time.Millisecond
+
+// Status is the client's view of the backend's health, for /healthz and the
+// status badge
+type Status struct {
+	Breaker             string     `json:"breaker"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Retries             int64      `json:"retries"`
+	LastRetry           *time.Time `json:"last_retry,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// breaker stops calls to the API after threshold consecutive failures. Once
+// cooldown has passed it lets a single trial request through (half-open);
+// its success closes the breaker and its failure opens it again.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial request is in flight
+}
+
+func (b *breaker) state(now time.Time) string {
+	switch {
+	case b.failures < b.threshold:
+		return breakerClosed
+	case now.Before(b.openedAt.Add(b.cooldown)) || b.trial:
+		return breakerOpen
+	default:
+		return breakerHalfOpen
+	}
+}
+
+// allow reports whether a request may be sent now
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state(now) {
+	case breakerOpen:
+		return ErrCircuitOpen
+	case breakerHalfOpen:
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *breaker) record(ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
+
+// do sends req, retrying transient failures with exponential backoff and
+// consulting the circuit breaker before every attempt. Server errors count
+// against the breaker; 4xx responses don't, the API is up and answering.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.breaker.allow(time.Now()); err != nil {
+			return nil, err
+		}
+		resp, err := c.http.Do(req)
+		c.breaker.record(err == nil && resp.StatusCode < 500, time.Now())
+
+		if attempt >= c.retries || !retryable(req.Method, resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+
+		c.retried.Add(1)
+		c.lastRetry.Store(time.Now().Unix())
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryBackoff << attempt):
+		}
+	}
+}
+
+// retryable reports whether a failed attempt is worth repeating. A refused
+// connection never reached the API, so any request can be resent; timeouts
+// and 502/503/504 answers are only retried for GETs, since a POST may already
+// have taken effect.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		return method == http.MethodGet && !errors.Is(err, context.Canceled)
+	}
+	if method != http.MethodGet {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind returns a copy of req with a fresh body for resending
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+// Status reports the breaker state and retry counters
+func (c *Client) Status() Status {
+	now := time.Now()
+	c.breaker.mu.Lock()
+	st := Status{
+		Breaker:             c.breaker.state(now),
+		ConsecutiveFailures: c.breaker.failures,
+		Retries:             c.retried.Load(),
+	}
+	if st.Breaker == breakerOpen && !c.breaker.trial {
+		until := c.breaker.openedAt.Add(c.breaker.cooldown)
+		st.OpenUntil = &until
+	}
+	c.breaker.mu.Unlock()
+
+	if ts := c.lastRetry.Load(); ts > 0 {
+		last := time.Unix(ts, 0)
+		st.LastRetry = &last
+	}
+	return st
+}
+
+// envInt reads a non-negative integer setting, falling back to def
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n >= 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyAPI answers with statuses in turn, then 200 with an empty JSON object;
+// hits counts the requests it saw
+func flakyAPI(t *testing.T, statuses ...int) (url string, hits *atomic.Int32) {
+	t.Helper()
+	hits = new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1)) - 1
+		if n < len(statuses) {
+			w.WriteHeader(statuses[n])
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, hits
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		post        bool
+		wantErr     bool
+		wantHits    int32
+		wantRetries int64
+	}{
+		{name: "healthy", wantHits: 1},
+		{name: "recovers after a blip", statuses: []int{503, 502}, wantHits: 3, wantRetries: 2},
+		{name: "down longer than the retries", statuses: []int{503, 503, 503, 503}, wantErr: true, wantHits: 3, wantRetries: 2},
+		{name: "client errors aren't retried", statuses: []int{404}, wantErr: true, wantHits: 1},
+		{name: "posts aren't retried after reaching the API", statuses: []int{503}, post: true, wantErr: true, wantHits: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, hits := flakyAPI(t, tt.statuses...)
+			t.Setenv("API_URL", url)
+			t.Setenv("API_RETRIES", "2")
+			c := New()
+
+			var err error
+			if tt.post {
+				err = c.AcknowledgeAlert(context.Background(), "a-1")
+			} else {
+				_, err = c.Health(context.Background())
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			st := c.Status()
+			if hits.Load() != tt.wantHits || st.Retries != tt.wantRetries || (st.LastRetry != nil) != (tt.wantRetries > 0) {
+				t.Errorf("%d requests, status %+v; want %d requests, %d retries", hits.Load(), st, tt.wantHits, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestClientRetriesRefusedConnection(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	t.Setenv("API_URL", srv.URL)
+	t.Setenv("API_RETRIES", "1")
+	c := New()
+	// Nothing listens, so even a POST is safe to send again
+	if err := c.AcknowledgeAlert(context.Background(), "a-1"); err == nil {
+		t.Fatal("acknowledged against a closed server")
+	}
+	if st := c.Status(); st.Retries != 1 || st.ConsecutiveFailures != 2 {
+		t.Errorf("status %+v, want one retry and two failures", st)
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	url, hits := flakyAPI(t, 500, 500)
+	t.Setenv("API_URL", url)
+	t.Setenv("API_RETRIES", "0")
+	t.Setenv("API_BREAKER_THRESHOLD", "2")
+	c := New()
+	c.breaker.cooldown = 50 * time.Millisecond
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Health(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: err = %v, want the API's failure", i, err)
+		}
+	}
+	st := c.Status()
+	if st.Breaker != breakerOpen || st.OpenUntil == nil || st.ConsecutiveFailures != 2 {
+		t.Fatalf("status %+v after two failures, want open", st)
+	}
+
+	// While open the API isn't called at all
+	if _, err := c.Health(ctx); !errors.Is(err, ErrCircuitOpen) || hits.Load() != 2 {
+		t.Fatalf("err = %v after %d requests, want ErrCircuitOpen without a request", err, hits.Load())
+	}
+
+	// After the cooldown one trial goes through; the recovered API closes the breaker
+	time.Sleep(60 * time.Millisecond)
+	if st := c.Status(); st.Breaker != breakerHalfOpen {
+		t.Fatalf("breaker %s after the cooldown, want half-open", st.Breaker)
+	}
+	if _, err := c.Health(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := c.Status(); st.Breaker != breakerClosed || st.ConsecutiveFailures != 0 || hits.Load() != 3 {
+		t.Errorf("status %+v after %d requests, want closed", st, hits.Load())
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	resp := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	tests := []struct {
+		name   string
+		method string
+		resp   *http.Response
+		err    error
+		want   bool
+	}{
+		{name: "GET 503", method: http.MethodGet, resp: resp(503), want: true},
+		{name: "GET 504", method: http.MethodGet, resp: resp(504), want: true},
+		{name: "GET 500", method: http.MethodGet, resp: resp(500)},
+		{name: "POST 503", method: http.MethodPost, resp: resp(503)},
+		{name: "GET timeout", method: http.MethodGet, err: context.DeadlineExceeded, want: true},
+		{name: "POST timeout", method: http.MethodPost, err: context.DeadlineExceeded},
+		{name: "GET canceled", method: http.MethodGet, err: context.Canceled},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.method, tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,15 +5,39 @@ type Facility struct {
 	Name       string `json:"name,omitempty"`
 }
 
+type FacilitiesResponse struct {
+	Facilities []Facility `json:"facilities"`
+	Next       string     `json:"next"`
+}
+
 type Reading struct {
+	MeterID   int64   `json:"meter_id"`
 	Timestamp int64   `json:"timestamp"`
 	PowerKW   float64 `json:"power_kw"`
 	Voltage   float64 `json:"voltage"`
 	Current   float64 `json:"current"`
+
+	// QualityScore (0-100) is the API's power-quality score, nil when not
+	// requested; Class (normal, warning or critical) is set by the dashboard
+	QualityScore *int   `json:"quality_score,omitempty"`
+	Class        string `json:"class,omitempty"`
+
+	// Key identifies the reading within a stats snapshot; set by the
+	// dashboard, see keyReadings
+	Key string `json:"key,omitempty"`
 }
 
 type RecentReadingsResponse struct {
 	Readings []Reading `json:"readings"`
+
+	// Query window and paging; Truncated means readings in the window were left out
+	WindowStart string `json:"window_start,omitempty"`
+	WindowEnd   string `json:"window_end,omitempty"`
+	Truncated   bool   `json:"truncated"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+
+	// Timezone is the facility's IANA zone, used for local timestamps and days
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type Alert struct {
@@ -29,6 +53,9 @@ type Alert struct {
 
 type AlertsResponse struct {
 	Alerts []Alert `json:"alerts"`
+
+	// NextCursor is set on a paged listing when more alerts follow
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type Health struct {
@@ -45,6 +72,11 @@ type Analytics struct {
 	AveragePower     float64 `json:"average_power"`
 	PeakPower        float64 `json:"peak_power"`
 	PeakHour         string  `json:"peak_hour"`
+
+	// Data quality: share of expected samples present and the resulting
+	// confidence (high, medium or low)
+	CoveragePercent float64 `json:"coverage_percent"`
+	Confidence      string  `json:"confidence"`
 }
 
 type AnalyticsGenerateResponse struct {
@@ -54,6 +86,22 @@ type AnalyticsGenerateResponse struct {
 	Analytics *Analytics `json:"analytics,omitempty"`
 }
 
+// ChartSeries is a Chart.js {labels, datasets} series
+type ChartSeries struct {
+	Labels   []string `json:"labels"`
+	Datasets []struct {
+		Label string    `json:"label"`
+		Data  []float64 `json:"data"`
+	} `json:"datasets"`
+}
+
+type AnalyticsChart struct {
+	FacilityID    string      `json:"facility_id"`
+	Date          string      `json:"date"`
+	PowerCurve    ChartSeries `json:"power_curve"`
+	CostBreakdown ChartSeries `json:"cost_breakdown"`
+}
+
 type Equipment struct {
 	ID     string  `json:"id"`
 	Type   string  `json:"type"`
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionCookie holds the signed session of a logged-in operator
+const sessionCookie = "dashboard_session"
+
+// auth guards the dashboard's mutating routes with a username/password login
+// (DASHBOARD_USER / DASHBOARD_PASSWORD). Sessions are stateless cookies
+// carrying the user and expiry, signed with HMAC-SHA256 under SESSION_SECRET.
+// Without a password configured nobody can log in, so those routes are off.
+type auth struct {
+	user     string
+	password string
+	key      []byte
+	ttl      time.Duration
+	secure   bool // Secure cookie flag, for dashboards served over HTTPS
+}
+
+func newAuth() *auth {
+	a := &auth{
+		user:     os.Getenv("DASHBOARD_USER"),
+		password: os.Getenv("DASHBOARD_PASSWORD"),
+		key:      []byte(os.Getenv("SESSION_SECRET")),
+		ttl:      time.Duration(envInt("SESSION_TTL_HOURS", 12)) * time.Hour,
+		secure:   os.Getenv("SESSION_COOKIE_SECURE") == "true",
+	}
+	if a.user == "" {
+		a.user = "admin"
+	}
+	if a.password == "" {
+		slog.Warn("DASHBOARD_PASSWORD not set; acknowledging alerts and generating reports are disabled")
+	}
+	if len(a.key) == 0 {
+		// Sessions then don't survive a restart or span instances
+		a.key = make([]byte, 32)
+		if _, err := rand.Read(a.key); err != nil {
+			panic(err)
+		}
+		slog.Warn("SESSION_SECRET not set; using a random key, sessions end on restart")
+	}
+	return a
+}
+
+func (a *auth) enabled() bool { return a.password != "" }
+
+// checkCredentials compares in constant time so timing doesn't reveal a prefix match
+func (a *auth) checkCredentials(user, password string) bool {
+	if !a.enabled() {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.user)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1
+	return userOK && passOK
+}
+
+func (a *auth) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newSession returns the cookie value for user, valid until now+ttl
+func (a *auth) newSession(user string, now time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(user + "|" + strconv.FormatInt(now.Add(a.ttl).Unix(), 10)))
+	return payload + "." + a.sign(payload)
+}
+
+// sessionUser returns the user of a valid, unexpired session value
+func (a *auth) sessionUser(value string, now time.Time) (string, bool) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(a.sign(payload))) {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+	user, exp, ok := strings.Cut(string(raw), "|")
+	expiry, err := strconv.ParseInt(exp, 10, 64)
+	if !ok || err != nil || now.Unix() >= expiry {
+		return "", false
+	}
+	return user, true
+}
+
+// currentUser returns the logged-in user of the request, or "" when there is none
+func (a *auth) currentUser(r *http.Request) string {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	user, ok := a.sessionUser(c.Value, time.Now())
+	if !ok || !a.enabled() {
+		return ""
+	}
+	return user
+}
+
+func (a *auth) setSession(w http.ResponseWriter, user string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    a.newSession(user, time.Now()),
+		Path:     "/",
+		MaxAge:   int(a.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (a *auth) clearSession(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// requireLogin lets requests with a valid session through to next and sends
+// the rest to the login page, returning to the referring page afterwards.
+// With getOpen, GET and HEAD requests pass without a session.
+func (s *Server) requireLogin(next http.HandlerFunc, getOpen bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if getOpen && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			next(w, r)
+			return
+		}
+		if s.auth.currentUser(r) != "" {
+			next(w, r)
+			return
+		}
+
+		back := r.URL.Path
+		if r.Method != http.MethodGet {
+			// A form post can't be replayed by a redirect; return to its page
+			back = localPath(r.Referer(), r.URL.Path)
+		}
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(back), http.StatusSeeOther)
+	}
+}
+
+// isLocalPath reports whether p is a path on this site. Browsers treat a
+// backslash like a slash, so "/\evil.example" is as off-site as "//evil.example".
+func isLocalPath(p string) bool {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, "\\") {
+		return false
+	}
+	u, err := url.Parse(p)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// localPath returns the path and query of raw when it is on this site, and def otherwise
+func localPath(raw, def string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return def
+	}
+	p := u.Path
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	if !isLocalPath(p) {
+		return def
+	}
+	return p
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := r.URL.Query().Get("next")
+	if !isLocalPath(next) {
+		next = "/dashboard"
+	}
+
+	data := map[string]interface{}{
+		"Title":     "Log in",
+		"Next":      next,
+		"User":      s.auth.currentUser(r),
+		"Enabled":   s.auth.enabled(),
+		"CSRFToken": s.csrfToken(w, r),
+	}
+
+	if r.Method == http.MethodPost {
+		user := r.FormValue("username")
+		if s.auth.checkCredentials(user, r.FormValue("password")) {
+			s.auth.setSession(w, user)
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+		slog.Warn("dashboard login failed", "user", user, "remote", r.RemoteAddr)
+		data["Error"] = "Invalid username or password"
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	s.render(w, "login.html", data)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.auth.clearSession(w)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,47 @@
+package server
+
+import "testing"
+
+func TestIsLocalPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/dashboard", true},
+		{"/facilities/facility-001?range=24h", true},
+		{"/", true},
+		{"", false},
+		{"dashboard", false},
+		{"//evil.example", false},
+		{"/\\evil.example", false},
+		{"/\\/evil.example", false},
+		{"\\\\evil.example", false},
+		{"https://evil.example/", false},
+		{"/\t/evil.example", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalPath(tt.path); got != tt.want {
+			t.Errorf("isLocalPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"http://dashboard.local/facilities/facility-001?range=24h", "/facilities/facility-001?range=24h"},
+		{"/alerts", "/alerts"},
+		{"", "/def"},
+		{"http://dashboard.local//evil.example", "/def"},
+		{"http://dashboard.local/\\evil.example", "/def"},
+		{"http://dashboard.local/%5Cevil.example", "/def"},
+		{"/\\evil.example", "/def"},
+	}
+	for _, tt := range tests {
+		if got := localPath(tt.raw, "/def"); got != tt.want {
+			t.Errorf("localPath(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// What the broadcaster does when a client's send queue is full, from WS_SLOW_CLIENT_POLICY
+const (
+	// slowClientDrop skips the message for that client; a skipped stats
+	// update is folded into its next delta
+	slowClientDrop = "drop"
+	// slowClientDisconnect closes the connection so the browser reconnects
+	// and starts again from a fresh init snapshot
+	slowClientDisconnect = "disconnect"
+)
+
+// WebSocket keepalive: a write that doesn't finish within wsWriteWait fails
+// and closes the connection, and the writer pings every wsPingPeriod so a
+// client that stops answering with pongs misses its wsPongWait read deadline
+var (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+// wsClient tracks what a WebSocket connection has already been sent so
+// updates can carry only the changes. Messages are written by the client's
+// own writeLoop from send, so one slow connection never holds up the others.
+type wsClient struct {
+	facility string
+	last     snapshot
+	send     chan interface{}
+
+	// paused is set by the client's {"type":"pause"} message; broadcasts
+	// skip it, keeping last, so the first delta after resume covers the gap
+	paused atomic.Bool
+}
+
+// slowClientPolicy reads WS_SLOW_CLIENT_POLICY, defaulting to drop
+func slowClientPolicy() string {
+	policy := strings.ToLower(strings.TrimSpace(os.Getenv("WS_SLOW_CLIENT_POLICY")))
+	switch policy {
+	case "":
+		return slowClientDrop
+	case slowClientDrop, slowClientDisconnect:
+		return policy
+	default:
+		slog.Warn("unknown WS_SLOW_CLIENT_POLICY; using drop", "policy", policy)
+		return slowClientDrop
+	}
+}
+
+// addClient registers conn for facility with the snapshot it was initialized
+// from and starts its writer
+func (s *Server) addClient(conn *websocket.Conn, facility string, last snapshot) {
+	client := &wsClient{facility: facility, last: last, send: make(chan interface{}, s.clientBuffer)}
+
+	s.clientsMu.Lock()
+	s.clients[conn] = client
+	s.clientsMu.Unlock()
+
+	go writeLoop(conn, client.send)
+}
+
+// removeClient unregisters and closes conn. It's safe to call more than once:
+// the reader and the broadcaster may both decide a connection is done.
+func (s *Server) removeClient(conn *websocket.Conn) {
+	s.clientsMu.Lock()
+	client, ok := s.clients[conn]
+	if ok {
+		delete(s.clients, conn)
+		// Closed under the write lock, so the broadcaster (which sends under
+		// the read lock) can't send on it afterwards
+		close(client.send)
+	}
+	s.clientsMu.Unlock()
+	conn.Close()
+}
+
+// setPaused pauses or resumes updates to conn and confirms with a
+// {"type":"paused"} or {"type":"resumed"} message
+func (s *Server) setPaused(conn *websocket.Conn, paused bool) {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+	client, ok := s.clients[conn]
+	if !ok {
+		return
+	}
+	client.paused.Store(paused)
+
+	ack := "resumed"
+	if paused {
+		ack = "paused"
+	}
+	select {
+	case client.send <- map[string]string{"type": ack}:
+	default:
+	}
+}
+
+// keepAlive starts conn's read deadline and extends it on every pong, so its
+// reader fails once the client stops answering writeLoop's pings
+func keepAlive(conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+}
+
+// writeLoop writes queued messages to conn until the queue is closed, pinging
+// between them. After a failed or timed out write the connection is closed,
+// which ends its reader and removes it; the rest of the queue is drained unsent.
+func writeLoop(conn *websocket.Conn, send <-chan interface{}) {
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case msg, ok := <-send:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			err = conn.WriteJSON(msg)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+		if err != nil {
+			conn.Close()
+			for range send {
+			}
+			return
+		}
+	}
+}
+
+// watchedFacilities returns the facilities open in at least one WebSocket client
+func (s *Server) watchedFacilities() []string {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+	seen := make(map[string]bool)
+	var out []string
+	for _, client := range s.clients {
+		if !seen[client.facility] {
+			seen[client.facility] = true
+			out = append(out, client.facility)
+		}
+	}
+	return out
+}
+
+func (s *Server) handleBroadcast() {
+	for msg := range s.broadcast {
+		// The map is only read here; slow clients to disconnect are collected
+		// and removed under the write lock afterwards
+		var slow []*websocket.Conn
+
+		s.clientsMu.RLock()
+		for conn, client := range s.clients {
+			if client.paused.Load() {
+				continue
+			}
+			out := msg
+			var next snapshot
+			// Stats updates are sent as per-client deltas against the last snapshot
+			st, isStats := msg.(*stats)
+			if isStats && st.FacilityID != client.facility {
+				continue
+			}
+			if isStats {
+				next = newSnapshot(st)
+				delta := diffSnapshots(client.last, next)
+				if delta.empty() {
+					client.last = next
+					continue
+				}
+				delta.Timestamp = st.Timestamp
+				out = map[string]interface{}{
+					"type": "delta",
+					"data": delta,
+				}
+			}
+
+			select {
+			case client.send <- out:
+				if isStats {
+					client.last = next
+				}
+			default:
+				// Keep the old snapshot so the next delta still covers this one
+				if s.slowClientPolicy == slowClientDisconnect {
+					slow = append(slow, conn)
+				}
+			}
+		}
+		s.clientsMu.RUnlock()
+
+		for _, conn := range slow {
+			slog.Warn("disconnecting slow websocket client", "remote", conn.RemoteAddr().String())
+			s.removeClient(conn)
+		}
+	}
+}
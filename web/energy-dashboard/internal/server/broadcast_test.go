@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"energy-dashboard-go/internal/models"
+)
+
+func TestKeepAlive(t *testing.T) {
+	prevWrite, prevPong, prevPing := wsWriteWait, wsPongWait, wsPingPeriod
+	wsWriteWait, wsPongWait, wsPingPeriod = time.Second, 300*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() { wsWriteWait, wsPongWait, wsPingPeriod = prevWrite, prevPong, prevPing })
+
+	tests := []struct {
+		name string
+		// answers reads from the connection, which replies to pings
+		answers    bool
+		wantClosed bool
+	}{
+		{name: "client answering pings stays connected", answers: true},
+		{name: "client ignoring pings times out", wantClosed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				send := make(chan interface{})
+				keepAlive(conn)
+				go writeLoop(conn, send)
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						break
+					}
+				}
+				close(send)
+				conn.Close()
+				close(closed)
+			}))
+			defer srv.Close()
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if tt.answers {
+				go func() {
+					for {
+						if _, _, err := conn.ReadMessage(); err != nil {
+							return
+						}
+					}
+				}()
+			}
+
+			select {
+			case <-closed:
+				if !tt.wantClosed {
+					t.Error("server closed a client that answered its pings")
+				}
+			case <-time.After(4 * wsPongWait):
+				if tt.wantClosed {
+					t.Error("server kept a client that ignored its pings")
+				}
+			}
+		})
+	}
+}
+
+func TestPauseUpdates(t *testing.T) {
+	s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	s.auth = &auth{key: []byte("test key")}
+	s.clients = make(map[*websocket.Conn]*wsClient)
+	s.clientBuffer = 16
+	s.broadcast = make(chan interface{})
+	go s.handleBroadcast()
+	t.Cleanup(func() { close(s.broadcast) })
+
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	type message struct {
+		Type  string          `json:"type"`
+		Data  json.RawMessage `json:"data"`
+		delta statsDelta
+	}
+	// A read timeout breaks a gorilla connection, so messages are read here
+	// and next waits on them instead
+	received := make(chan message, 16)
+	go func() {
+		defer close(received)
+		for {
+			var msg message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type == "delta" {
+				if err := json.Unmarshal(msg.Data, &msg.delta); err != nil {
+					t.Error(err)
+				}
+			}
+			received <- msg
+		}
+	}()
+	// next returns the next message, or "" for its type if none comes soon
+	next := func() message {
+		select {
+		case msg := <-received:
+			return msg
+		case <-time.After(200 * time.Millisecond):
+			return message{}
+		}
+	}
+	if msg := next(); msg.Type != "init" {
+		t.Fatalf("first message %q, want init", msg.Type)
+	}
+
+	// Each update adds a reading to everything sent so far
+	var readings []models.Reading
+	update := func() {
+		ts := int64(1709294400 + len(readings))
+		readings = append(readings, models.Reading{MeterID: 1, Timestamp: ts, PowerKW: 2, Key: "1:" + time.Unix(ts, 0).UTC().Format("150405")})
+		s.broadcast <- &stats{
+			FacilityID: "facility-001",
+			Readings:   &models.RecentReadingsResponse{Readings: append([]models.Reading(nil), readings...)},
+			Timestamp:  ts,
+		}
+	}
+	send := func(typ string) {
+		t.Helper()
+		if err := conn.WriteJSON(map[string]string{"type": typ}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		send         string // a message from the client first
+		wantAck      string
+		updates      int
+		wantType     string // "" for nothing sent
+		wantReadings int
+	}{
+		{name: "live", updates: 1, wantType: "delta", wantReadings: 1},
+		{name: "paused", send: "pause", wantAck: "paused", updates: 3},
+		{name: "unknown message ignored", send: "rewind", updates: 1},
+		{name: "resumed with what was missed", send: "resume", wantAck: "resumed", updates: 1, wantType: "delta", wantReadings: 5},
+		{name: "live again", updates: 1, wantType: "delta", wantReadings: 1},
+	}
+	for _, tt := range tests {
+		if tt.send != "" {
+			send(tt.send)
+		}
+		if tt.wantAck != "" {
+			if msg := next(); msg.Type != tt.wantAck {
+				t.Fatalf("%s: acknowledged with %q, want %q", tt.name, msg.Type, tt.wantAck)
+			}
+		}
+		for i := 0; i < tt.updates; i++ {
+			update()
+		}
+		msg := next()
+		if msg.Type != tt.wantType || len(msg.delta.Readings) != tt.wantReadings {
+			t.Errorf("%s: got %q with %d readings, want %q with %d", tt.name, msg.Type, len(msg.delta.Readings), tt.wantType, tt.wantReadings)
+		}
+	}
+}
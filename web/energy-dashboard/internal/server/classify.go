@@ -0,0 +1,70 @@
+package server
+
+import (
+	"math"
+	"os"
+	"strconv"
+
+	"energy-dashboard-go/internal/models"
+)
+
+// Reading classes, for color-coding
+const (
+	classNormal   = "normal"
+	classWarning  = "warning"
+	classCritical = "critical"
+)
+
+// Voltage bands around NOMINAL_VOLTAGE: EN 50160 allows ±10% at the supply
+// terminals, so readings past that are critical and past half of it a warning
+const (
+	voltageWarnBand     = 0.05
+	voltageCriticalBand = 0.10
+)
+
+// Quality scores below these, from the API's power-quality scoring (each
+// flag takes 30-40 points), classify a reading
+const (
+	qualityWarnBelow     = 100
+	qualityCriticalBelow = 50
+)
+
+// nominalVoltage reads NOMINAL_VOLTAGE, defaulting to 230 V
+func nominalVoltage() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("NOMINAL_VOLTAGE"), 64); err == nil && v > 0 {
+		return v
+	}
+	return 230
+}
+
+// classifyReading rates a reading by how far its voltage is from nominal and
+// by its quality score, taking the worse of the two
+func classifyReading(r models.Reading, nominal float64) string {
+	class := classNormal
+	switch dev := math.Abs(r.Voltage-nominal) / nominal; {
+	case dev > voltageCriticalBand:
+		return classCritical
+	case dev > voltageWarnBand:
+		class = classWarning
+	}
+
+	if r.QualityScore != nil {
+		switch {
+		case *r.QualityScore < qualityCriticalBelow:
+			return classCritical
+		case *r.QualityScore < qualityWarnBelow:
+			class = classWarning
+		}
+	}
+	return class
+}
+
+// classifyReadings sets Class on each of the response's readings
+func (s *Server) classifyReadings(resp *models.RecentReadingsResponse) {
+	if resp == nil {
+		return
+	}
+	for i := range resp.Readings {
+		resp.Readings[i].Class = classifyReading(resp.Readings[i], s.nominalVoltage)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"energy-dashboard-go/internal/models"
+)
+
+func TestClassifyReading(t *testing.T) {
+	score := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		voltage float64
+		quality *int
+		nominal float64
+		want    string
+	}{
+		{name: "nominal", voltage: 230, nominal: 230, want: classNormal},
+		{name: "within 5%", voltage: 241, nominal: 230, want: classNormal},
+		{name: "over 5%", voltage: 243, nominal: 230, want: classWarning},
+		{name: "under 5%", voltage: 217, nominal: 230, want: classWarning},
+		{name: "over 10%", voltage: 254, nominal: 230, want: classCritical},
+		{name: "under 10%", voltage: 200, nominal: 230, want: classCritical},
+		{name: "no voltage", voltage: 0, nominal: 230, want: classCritical},
+		{name: "out of band for 120 V", voltage: 230, nominal: 120, want: classCritical},
+		{name: "in band for 120 V", voltage: 121, nominal: 120, want: classNormal},
+		{name: "perfect quality", voltage: 230, quality: score(100), nominal: 230, want: classNormal},
+		{name: "one quality flag", voltage: 230, quality: score(70), nominal: 230, want: classWarning},
+		{name: "poor quality", voltage: 230, quality: score(30), nominal: 230, want: classCritical},
+		{name: "worse of voltage and quality", voltage: 254, quality: score(70), nominal: 230, want: classCritical},
+		{name: "warning either way", voltage: 243, quality: score(70), nominal: 230, want: classWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyReading(models.Reading{Voltage: tt.voltage, QualityScore: tt.quality}, tt.nominal); got != tt.want {
+				t.Errorf("%v V (nominal %v), quality %v: %q, want %q", tt.voltage, tt.nominal, tt.quality, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNominalVoltage(t *testing.T) {
+	tests := []struct {
+		env  string
+		want float64
+	}{
+		{env: "", want: 230},
+		{env: "120", want: 120},
+		{env: "240.5", want: 240.5},
+		{env: "-230", want: 230},
+		{env: "volts", want: 230},
+	}
+	for _, tt := range tests {
+		t.Setenv("NOMINAL_VOLTAGE", tt.env)
+		if got := nominalVoltage(); got != tt.want {
+			t.Errorf("NOMINAL_VOLTAGE=%q: %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestStatsClassified(t *testing.T) {
+	var expand string
+	s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/readings/recent" {
+			expand = r.URL.Query().Get("expand")
+			w.Write([]byte(`{"readings":[
+				{"meter_id":1,"timestamp":1709294400,"voltage":231,"quality_score":100},
+				{"meter_id":2,"timestamp":1709294400,"voltage":246,"quality_score":100},
+				{"meter_id":3,"timestamp":1709294400,"voltage":231,"quality_score":40}]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	s.nominalVoltage = 230
+
+	st, err := s.getStats(context.Background(), "facility-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expand != "quality" {
+		t.Errorf("readings fetched with expand=%q, want quality scores", expand)
+	}
+	want := []string{classNormal, classWarning, classCritical}
+	for i, r := range st.Readings.Readings {
+		if r.Class != want[i] {
+			t.Errorf("meter %d: %q, want %q", r.MeterID, r.Class, want[i])
+		}
+	}
+}
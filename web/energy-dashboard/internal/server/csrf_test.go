@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequireCSRF(t *testing.T) {
+	var acks atomic.Int32
+	s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/alerts/a-1/acknowledge" {
+			acks.Add(1)
+		}
+	}))
+	s.auth = &auth{user: "admin", password: "secret", key: []byte("test key"), ttl: time.Hour}
+	acknowledge := s.requireCSRF(s.requireLogin(s.handleAcknowledge, false))
+
+	// Tokens as the alerts page would render them, one per browser
+	token := func() string {
+		return s.csrfToken(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/alerts", nil))
+	}
+	mine, theirs := token(), token()
+	session := s.auth.newSession("admin", time.Now())
+
+	tests := []struct {
+		name       string
+		cookie     string // CSRF cookie, "" for none
+		field      string // csrf_token form field, "" for none
+		wantStatus int
+	}{
+		{name: "valid token", cookie: mine, field: mine, wantStatus: http.StatusSeeOther},
+		{name: "missing token", cookie: mine, wantStatus: http.StatusForbidden},
+		{name: "no cookie", field: mine, wantStatus: http.StatusForbidden},
+		{name: "another browser's token", cookie: mine, field: theirs, wantStatus: http.StatusForbidden},
+		{name: "forged token", cookie: "abc.def", field: "abc.def", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acks.Store(0)
+			form := url.Values{}
+			if tt.field != "" {
+				form.Set(csrfField, tt.field)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/alerts/acknowledge?id=a-1", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			acknowledge(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			// Only a request with a valid token reaches the API
+			wantAcks := int32(0)
+			if tt.wantStatus == http.StatusSeeOther {
+				wantAcks = 1
+				if got := w.Header().Get("Location"); got != "/alerts?ack=ok" {
+					t.Errorf("redirected to %q", got)
+				}
+			}
+			if acks.Load() != wantAcks {
+				t.Errorf("%d acknowledgements sent, want %d", acks.Load(), wantAcks)
+			}
+		})
+	}
+}
+
+func TestCSRFToken(t *testing.T) {
+	s := &Server{auth: &auth{key: []byte("test key")}}
+	w := httptest.NewRecorder()
+	issued := s.csrfToken(w, httptest.NewRequest(http.MethodGet, "/alerts", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookie || cookies[0].Value != issued || !cookies[0].HttpOnly {
+		t.Fatalf("issued %q with cookies %v", issued, cookies)
+	}
+
+	tests := []struct {
+		name      string
+		cookie    string
+		wantReuse bool
+	}{
+		{name: "valid cookie is kept", cookie: issued, wantReuse: true},
+		{name: "tampered cookie is replaced", cookie: issued + "x"},
+		{name: "unsigned cookie is replaced", cookie: "planted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/alerts", nil)
+			r.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			w := httptest.NewRecorder()
+			got := s.csrfToken(w, r)
+			if (got == tt.cookie) != tt.wantReuse || (len(w.Result().Cookies()) == 0) != tt.wantReuse {
+				t.Errorf("token %q for cookie %q, want reuse %v", got, tt.cookie, tt.wantReuse)
+			}
+			if !s.validCSRF(got) {
+				t.Errorf("token %q doesn't validate", got)
+			}
+		})
+	}
+}
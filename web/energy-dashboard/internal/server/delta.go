@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"energy-dashboard-go/internal/models"
+)
+
+// stats is the payload of the init and update WebSocket messages and /api/stats
+type stats struct {
+	FacilityID string                         `json:"facility_id"`
+	Readings   *models.RecentReadingsResponse `json:"readings"`
+	Alerts     *models.AlertsResponse         `json:"alerts"`
+	Timestamp  int64                          `json:"timestamp"`
+	// Stale marks cached stats served because the live fetch failed;
+	// Timestamp is then when they were fetched
+	Stale bool `json:"stale,omitempty"`
+}
+
+// snapshot is the readings/alerts state last sent to a WebSocket client,
+// keyed so consecutive snapshots can be diffed cheaply
+type snapshot struct {
+	readings map[string]models.Reading
+	alerts   map[string]models.Alert
+	stale    bool
+}
+
+// statsDelta carries only what changed since the client's previous snapshot
+type statsDelta struct {
+	Readings        []models.Reading `json:"readings,omitempty"`
+	RemovedReadings []string         `json:"removed_readings,omitempty"`
+	Alerts          []models.Alert   `json:"alerts,omitempty"`
+	RemovedAlerts   []string         `json:"removed_alerts,omitempty"`
+	Timestamp       int64            `json:"timestamp"`
+	Stale           bool             `json:"stale,omitempty"`
+
+	// staleChanged makes a delta worth sending even without data changes
+	staleChanged bool
+}
+
+func (d statsDelta) empty() bool {
+	return len(d.Readings) == 0 && len(d.RemovedReadings) == 0 &&
+		len(d.Alerts) == 0 && len(d.RemovedAlerts) == 0 && !d.staleChanged
+}
+
+// keyReadings sets each reading's Key to "<meter>:<timestamp>". Timestamps are
+// whole seconds, so readings of different meters share them, and a meter
+// sampling faster than once a second repeats them; repeats get the count of
+// earlier ones appended ("<meter>:<timestamp>:1", ...) in the API's order.
+func keyReadings(resp *models.RecentReadingsResponse) {
+	if resp == nil {
+		return
+	}
+	seen := make(map[string]int, len(resp.Readings))
+	for i := range resp.Readings {
+		r := &resp.Readings[i]
+		key := fmt.Sprintf("%d:%d", r.MeterID, r.Timestamp)
+		if n := seen[key]; n > 0 {
+			r.Key = fmt.Sprintf("%s:%d", key, n)
+		} else {
+			r.Key = key
+		}
+		seen[key]++
+	}
+}
+
+func newSnapshot(st *stats) snapshot {
+	snap := snapshot{
+		readings: make(map[string]models.Reading),
+		alerts:   make(map[string]models.Alert),
+	}
+	if st == nil {
+		return snap
+	}
+	snap.stale = st.Stale
+	if st.Readings != nil {
+		for _, r := range st.Readings.Readings {
+			snap.readings[r.Key] = r
+		}
+	}
+	if st.Alerts != nil {
+		for _, a := range st.Alerts.Alerts {
+			snap.alerts[a.AlertID] = a
+		}
+	}
+	return snap
+}
+
+// diffSnapshots returns the new or changed readings/alerts in next and the
+// keys that disappeared from prev, in a stable order
+func diffSnapshots(prev, next snapshot) statsDelta {
+	d := statsDelta{Stale: next.stale, staleChanged: next.stale != prev.stale}
+
+	for key, r := range next.readings {
+		if old, ok := prev.readings[key]; !ok || !sameReading(old, r) {
+			d.Readings = append(d.Readings, r)
+		}
+	}
+	for key := range prev.readings {
+		if _, ok := next.readings[key]; !ok {
+			d.RemovedReadings = append(d.RemovedReadings, key)
+		}
+	}
+
+	for id, a := range next.alerts {
+		if old, ok := prev.alerts[id]; !ok || old != a {
+			d.Alerts = append(d.Alerts, a)
+		}
+	}
+	for id := range prev.alerts {
+		if _, ok := next.alerts[id]; !ok {
+			d.RemovedAlerts = append(d.RemovedAlerts, id)
+		}
+	}
+
+	sort.Slice(d.Readings, func(i, j int) bool {
+		if d.Readings[i].Timestamp != d.Readings[j].Timestamp {
+			return d.Readings[i].Timestamp < d.Readings[j].Timestamp
+		}
+		return d.Readings[i].Key < d.Readings[j].Key
+	})
+	sort.Strings(d.RemovedReadings)
+	sort.Slice(d.Alerts, func(i, j int) bool { return d.Alerts[i].Timestamp > d.Alerts[j].Timestamp })
+	sort.Strings(d.RemovedAlerts)
+	return d
+}
+
+// sameReading compares readings by value, including the quality score behind
+// its pointer
+func sameReading(a, b models.Reading) bool {
+	qa, qb := a.QualityScore, b.QualityScore
+	a.QualityScore, b.QualityScore = nil, nil
+	if a != b || (qa == nil) != (qb == nil) {
+		return false
+	}
+	return qa == nil || *qa == *qb
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"energy-dashboard-go/internal/models"
+)
+
+func statsOf(readings ...models.Reading) *stats {
+	resp := &models.RecentReadingsResponse{Readings: readings}
+	keyReadings(resp)
+	return &stats{Readings: resp}
+}
+
+func TestKeyReadings(t *testing.T) {
+	resp := &models.RecentReadingsResponse{Readings: []models.Reading{
+		{MeterID: 1, Timestamp: 100},
+		{MeterID: 2, Timestamp: 100},
+		{MeterID: 1, Timestamp: 100}, // second sample of meter 1 in the same second
+		{MeterID: 1, Timestamp: 101},
+	}}
+	keyReadings(resp)
+	var got []string
+	for _, r := range resp.Readings {
+		got = append(got, r.Key)
+	}
+	want := []string{"1:100", "2:100", "1:100:1", "1:101"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+	keyReadings(nil) // must not panic
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev, next  *stats
+		wantKeys    []string
+		wantRemoved []string
+	}{
+		{
+			name:     "meters reporting in the same second are all sent",
+			prev:     nil,
+			next:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1}, models.Reading{MeterID: 2, Timestamp: 100, PowerKW: 2}),
+			wantKeys: []string{"1:100", "2:100"},
+		},
+		{
+			name:     "sub-second samples of one meter are all sent",
+			prev:     nil,
+			next:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1}, models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1.5}),
+			wantKeys: []string{"1:100", "1:100:1"},
+		},
+		{
+			name:     "new meter in a known second",
+			prev:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1}),
+			next:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1}, models.Reading{MeterID: 2, Timestamp: 100, PowerKW: 2}),
+			wantKeys: []string{"2:100"},
+		},
+		{
+			name:     "changed reading",
+			prev:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 1}),
+			next:     statsOf(models.Reading{MeterID: 1, Timestamp: 100, PowerKW: 3}),
+			wantKeys: []string{"1:100"},
+		},
+		{
+			name:        "one meter's reading leaves the window",
+			prev:        statsOf(models.Reading{MeterID: 1, Timestamp: 100}, models.Reading{MeterID: 2, Timestamp: 100}),
+			next:        statsOf(models.Reading{MeterID: 2, Timestamp: 100}),
+			wantRemoved: []string{"1:100"},
+		},
+		{
+			name: "unchanged",
+			prev: statsOf(models.Reading{MeterID: 1, Timestamp: 100}),
+			next: statsOf(models.Reading{MeterID: 1, Timestamp: 100}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffSnapshots(newSnapshot(tt.prev), newSnapshot(tt.next))
+			var keys []string
+			for _, r := range d.Readings {
+				keys = append(keys, r.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("readings = %v, want %v", keys, tt.wantKeys)
+			}
+			if !reflect.DeepEqual(d.RemovedReadings, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", d.RemovedReadings, tt.wantRemoved)
+			}
+			if empty := len(tt.wantKeys) == 0 && len(tt.wantRemoved) == 0; d.empty() != empty {
+				t.Errorf("empty = %v, want %v", d.empty(), empty)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export columns per view
+var (
+	readingColumns = []string{"timestamp", "local_time", "power_kw", "voltage", "current", "class"}
+	alertColumns   = []string{"alert_id", "timestamp", "local_time", "facility_id", "equipment_id", "severity", "type", "message", "acknowledged"}
+)
+
+// handleExport streams the readings (?view=readings, the stats window) or
+// alerts (?view=alerts, honouring ?severity=) of the facility as CSV. Both are
+// fetched before anything is written, so an API failure is a clean 502.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	facility := s.facilityFor(w, r)
+	view := r.URL.Query().Get("view")
+	var rows [][]string
+	var err error
+	switch view {
+	case "readings":
+		rows, err = s.readingRows(ctx, facility)
+	case "alerts":
+		rows, err = s.alertRows(ctx, facility, r.URL.Query().Get("severity"))
+	default:
+		http.Error(w, "view must be readings or alerts", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("export fetch failed", "view", view, "err", err)
+		http.Error(w, "failed to load "+view+" from the API", http.StatusBadGateway)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s-%s.csv", facility, view, time.Now().In(s.location(facility)).Format("20060102-1504"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	cw := csv.NewWriter(w)
+	cw.WriteAll(rows)
+	if err := cw.Error(); err != nil {
+		slog.Error("export write failed", "view", view, "err", err)
+	}
+}
+
+func (s *Server) readingRows(ctx context.Context, facility string) ([][]string, error) {
+	resp, err := s.api.RecentReadings(ctx, facility, s.statsWindowHours)
+	if err != nil {
+		return nil, err
+	}
+	s.noteTimezone(facility, resp)
+	s.classifyReadings(resp)
+	loc := s.location(facility)
+
+	rows := [][]string{readingColumns}
+	for _, rd := range resp.Readings {
+		rows = append(rows, []string{
+			strconv.FormatInt(rd.Timestamp, 10),
+			localTime(rd.Timestamp, loc),
+			strconv.FormatFloat(rd.PowerKW, 'f', -1, 64),
+			strconv.FormatFloat(rd.Voltage, 'f', -1, 64),
+			strconv.FormatFloat(rd.Current, 'f', -1, 64),
+			rd.Class,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Server) alertRows(ctx context.Context, facility, severity string) ([][]string, error) {
+	resp, err := s.api.Alerts(ctx, facility, severity)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.location(facility)
+
+	rows := [][]string{alertColumns}
+	for _, a := range resp.Alerts {
+		rows = append(rows, []string{
+			csvText(a.AlertID),
+			strconv.FormatInt(a.Timestamp, 10),
+			localTime(a.Timestamp, loc),
+			csvText(a.FacilityID),
+			csvText(a.EquipmentID),
+			csvText(a.Severity),
+			csvText(a.Type),
+			csvText(a.Message),
+			strconv.FormatBool(a.Acknowledged),
+		})
+	}
+	return rows, nil
+}
+
+// localTime formats a Unix timestamp in the facility's time zone
+func localTime(ts int64, loc *time.Location) string {
+	return time.Unix(ts, 0).In(loc).Format(time.RFC3339)
+}
+
+// csvText guards free text against spreadsheet formula injection: a cell
+// starting with =, +, - or @ (or a tab or carriage return) is prefixed with '
+// so spreadsheets show it as text instead of evaluating it
+func csvText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
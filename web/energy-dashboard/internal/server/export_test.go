@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleExport(t *testing.T) {
+	// The backend's readings and alerts below, in each view's columns; the
+	// second reading's voltage is far enough off nominal to be critical
+	readings := [][]string{
+		readingColumns,
+		{"1709294400", "2024-03-01T12:00:00Z", "2.75", "231", "12.5", classNormal},
+		{"1709294460", "2024-03-01T12:01:00Z", "3", "180", "16", classCritical},
+	}
+	alerts := [][]string{
+		alertColumns,
+		{"a-1", "1709294400", "2024-03-01T12:00:00Z", "facility-001", "meter-7", "high", "anomaly", "Power 40 kW, 3σ above normal", "false"},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		message      string // the alert's, when not the default above
+		failing      bool
+		wantStatus   int
+		wantRows     [][]string
+		wantSeverity string
+		wantFile     string // filename prefix
+	}{
+		{name: "readings", query: "view=readings", wantStatus: http.StatusOK, wantRows: readings, wantFile: "facility-001-readings-"},
+		{name: "alerts", query: "view=alerts", wantStatus: http.StatusOK, wantRows: alerts, wantFile: "facility-001-alerts-"},
+		{name: "alerts by severity", query: "view=alerts&severity=high", wantStatus: http.StatusOK, wantRows: alerts, wantSeverity: "high", wantFile: "facility-001-alerts-"},
+		{
+			name: "formula in a message", query: "view=alerts", message: "=HYPERLINK(\"http://evil\")", wantStatus: http.StatusOK,
+			wantRows: [][]string{alertColumns, {"a-1", "1709294400", "2024-03-01T12:00:00Z", "facility-001", "meter-7", "high", "anomaly", "'=HYPERLINK(\"http://evil\")", "false"}},
+			wantFile: "facility-001-alerts-",
+		},
+		{
+			name: "leading minus", query: "view=alerts", message: "-1 kW", wantStatus: http.StatusOK,
+			wantRows: [][]string{alertColumns, {"a-1", "1709294400", "2024-03-01T12:00:00Z", "facility-001", "meter-7", "high", "anomaly", "'-1 kW", "false"}},
+			wantFile: "facility-001-alerts-",
+		},
+		{name: "chosen facility", query: "view=readings&facility_id=facility-002", wantStatus: http.StatusOK, wantRows: readings, wantFile: "facility-002-readings-"},
+		{name: "unknown view", query: "view=costs", wantStatus: http.StatusBadRequest},
+		{name: "no view", wantStatus: http.StatusBadRequest},
+		{name: "API failing", query: "view=readings", failing: true, wantStatus: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := "Power 40 kW, 3σ above normal"
+			if tt.message != "" {
+				message = tt.message
+			}
+			msgJSON, _ := json.Marshal(message)
+			var severity string
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.failing {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				switch r.URL.Path {
+				case "/readings/recent":
+					w.Write([]byte(`{"readings":[
+						{"meter_id":7,"timestamp":1709294400,"power_kw":2.75,"voltage":231,"current":12.5},
+						{"meter_id":7,"timestamp":1709294460,"power_kw":3,"voltage":180,"current":16}]}`))
+				case "/alerts":
+					severity = r.URL.Query().Get("severity")
+					w.Write([]byte(`{"alerts":[{"alertId":"a-1","facilityId":"facility-001","equipmentId":"meter-7",
+						"severity":"high","type":"anomaly","message":` + string(msgJSON) + `,"timestamp":1709294400}]}`))
+				default:
+					w.Write([]byte(`{}`))
+				}
+			}))
+			s.auth = &auth{key: []byte("test key")}
+			s.nominalVoltage = 230
+
+			w := httptest.NewRecorder()
+			s.handleExport(w, httptest.NewRequest(http.MethodGet, "/export?"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+				t.Errorf("Content-Type %q", ct)
+			}
+			cd := w.Header().Get("Content-Disposition")
+			if !strings.HasPrefix(cd, `attachment; filename="`+tt.wantFile) || !strings.HasSuffix(cd, `.csv"`) {
+				t.Errorf("Content-Disposition %q, want an attachment named %s*.csv", cd, tt.wantFile)
+			}
+			rows, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows %q, want %q", rows, tt.wantRows)
+			}
+			if severity != tt.wantSeverity {
+				t.Errorf("asked the API for severity %q, want %q", severity, tt.wantSeverity)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"energy-dashboard-go/internal/models"
+)
+
+// facilityCookie remembers the facility last picked in the browser
+const facilityCookie = "dashboard_facility"
+
+// facilityListTTL is how long the picker's facility list is cached
+const facilityListTTL = 5 * time.Minute
+
+// facilityIDPattern bounds what a query param or cookie may select; the API
+// decides whether the facility exists
+var facilityIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// facilityList caches the API's facilities for the picker
+type facilityList struct {
+	mu         sync.Mutex
+	facilities []models.Facility
+	fetchedAt  time.Time
+}
+
+// facilityFor returns the facility a request is for: ?facility_id=, which is
+// then remembered in a cookie, else the cookie, else the FACILITY_ID default
+func (s *Server) facilityFor(w http.ResponseWriter, r *http.Request) string {
+	if id := r.URL.Query().Get("facility_id"); facilityIDPattern.MatchString(id) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     facilityCookie,
+			Value:    id,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   s.auth.secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return id
+	}
+	if c, err := r.Cookie(facilityCookie); err == nil && facilityIDPattern.MatchString(c.Value) {
+		return c.Value
+	}
+	return s.facility
+}
+
+// facilityChoices returns the facilities for the picker, always including the
+// current one. A failed listing falls back to the last one fetched.
+func (s *Server) facilityChoices(ctx context.Context, current string) []models.Facility {
+	fl := &s.facilityList
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if time.Since(fl.fetchedAt) > facilityListTTL {
+		facilities, err := s.api.Facilities(ctx)
+		if err != nil {
+			slog.Warn("facility list fetch failed", "err", err)
+		} else {
+			fl.facilities, fl.fetchedAt = facilities, time.Now()
+		}
+	}
+
+	for _, f := range fl.facilities {
+		if f.FacilityID == current {
+			return fl.facilities
+		}
+	}
+	return append([]models.Facility{{FacilityID: current}}, fl.facilities...)
+}
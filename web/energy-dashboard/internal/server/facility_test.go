@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"energy-dashboard-go/internal/models"
+)
+
+func TestFacilityFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		cookie     string
+		want       string
+		wantCookie string // "" for none set
+	}{
+		{name: "default", want: "facility-001"},
+		{name: "query param", query: "facility_id=facility-002", want: "facility-002", wantCookie: "facility-002"},
+		{name: "remembered", cookie: "facility-003", want: "facility-003"},
+		{name: "query param beats the cookie", query: "facility_id=facility-002", cookie: "facility-003", want: "facility-002", wantCookie: "facility-002"},
+		{name: "malformed query param", query: "facility_id=../admin", cookie: "facility-003", want: "facility-003"},
+		{name: "malformed cookie", cookie: "a b", want: "facility-001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, http.NotFoundHandler())
+			s.auth = &auth{key: []byte("test key")}
+
+			r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: facilityCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			if got := s.facilityFor(w, r); got != tt.want {
+				t.Errorf("facility %q, want %q", got, tt.want)
+			}
+			var set string
+			for _, c := range w.Result().Cookies() {
+				if c.Name == facilityCookie {
+					set = c.Value
+				}
+			}
+			if set != tt.wantCookie {
+				t.Errorf("cookie set to %q, want %q", set, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestAPIStatsFacility(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		cookie string
+		want   string
+	}{
+		{name: "default", want: "facility-001"},
+		{name: "query param", query: "?facility_id=facility-002", want: "facility-002"},
+		{name: "cookie", cookie: "facility-003", want: "facility-003"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requested := make(map[string]string)
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested[r.URL.Path] = r.URL.Query().Get("facility_id")
+				mu.Unlock()
+				w.Write([]byte(`{}`))
+			}))
+			s.auth = &auth{key: []byte("test key")}
+
+			r := httptest.NewRequest(http.MethodGet, "/api/stats"+tt.query, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: facilityCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			s.handleAPIStats(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			want := map[string]string{"/readings/recent": tt.want, "/alerts": tt.want}
+			if !reflect.DeepEqual(requested, want) {
+				t.Errorf("requested %v, want %v", requested, want)
+			}
+			var st stats
+			if err := json.NewDecoder(w.Body).Decode(&st); err != nil || st.FacilityID != tt.want {
+				t.Errorf("stats for %q (%v), want %q", st.FacilityID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFacilityChoices(t *testing.T) {
+	var failing atomic.Bool
+	var fetches atomic.Int32
+	s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"facilities":[{"facility_id":"facility-001","name":"North"},{"facility_id":"facility-002"}]}`))
+	}))
+	listed := []models.Facility{{FacilityID: "facility-001", Name: "North"}, {FacilityID: "facility-002"}}
+
+	tests := []struct {
+		name        string
+		current     string
+		expire      bool // let the cached list age out first
+		failing     bool
+		want        []models.Facility
+		wantFetches int32
+	}{
+		{name: "listed", current: "facility-001", want: listed, wantFetches: 1},
+		{name: "cached", current: "facility-002", want: listed, wantFetches: 1},
+		{name: "unlisted current first", current: "facility-009", want: append([]models.Facility{{FacilityID: "facility-009"}}, listed...), wantFetches: 1},
+		{name: "refetched after the TTL", current: "facility-001", expire: true, want: listed, wantFetches: 2},
+		{name: "failed listing keeps the last", current: "facility-001", expire: true, failing: true, want: listed, wantFetches: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expire {
+				s.facilityList.fetchedAt = s.facilityList.fetchedAt.Add(-facilityListTTL - 1)
+			}
+			failing.Store(tt.failing)
+			got := s.facilityChoices(context.Background(), tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("choices %+v, want %+v", got, tt.want)
+			}
+			if n := fetches.Load(); n != tt.wantFetches {
+				t.Errorf("%d fetches, want %d", n, tt.wantFetches)
+			}
+		})
+	}
+}
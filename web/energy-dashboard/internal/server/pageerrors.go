@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"energy-dashboard-go/internal/api"
+)
+
+// pageErrors collects the failed API fetches of one page render so the page
+// can say why parts of it are empty instead of silently rendering blank
+type pageErrors struct {
+	warnings []string
+}
+
+// check records err, if any, against what the page was trying to load
+func (p *pageErrors) check(what string, err error) {
+	if err == nil {
+		return
+	}
+	slog.Warn("dashboard fetch failed", "what", what, "err", err)
+	msg := "Couldn't load " + what
+	if errors.Is(err, api.ErrCircuitOpen) {
+		msg += " (API failing, retrying shortly)"
+	}
+	p.warnings = append(p.warnings, msg)
+}
+
+// stale records that the page shows cached data fetched at since
+func (p *pageErrors) stale(since time.Time) {
+	p.warnings = append(p.warnings, "API unreachable, showing cached data from "+since.Format("2006-01-02 15:04:05"))
+}
+
+// apply adds Warnings, and an Error banner when the API is offline, to the
+// template data
+func (p *pageErrors) apply(data map[string]interface{}) {
+	if len(p.warnings) == 0 {
+		return
+	}
+	data["Warnings"] = p.warnings
+	if data["APIStatus"] == "offline" {
+		data["Error"] = "API unreachable: data on this page may be missing or out of date"
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"energy-dashboard-go/internal/api"
+)
+
+func TestPageErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		status       string
+		wantWarnings []string
+		wantError    bool
+	}{
+		{name: "no failures", errs: []error{nil, nil}, status: "online"},
+		{name: "one fetch failed", errs: []error{nil, errors.New("timeout")}, status: "online", wantWarnings: []string{"Couldn't load alerts"}},
+		{name: "breaker open", errs: []error{fmt.Errorf("get: %w", api.ErrCircuitOpen), nil}, status: "offline", wantWarnings: []string{"Couldn't load readings (API failing, retrying shortly)"}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p pageErrors
+			p.check("readings", tt.errs[0])
+			p.check("alerts", tt.errs[1])
+			data := map[string]interface{}{"APIStatus": tt.status}
+			p.apply(data)
+
+			got, _ := data["Warnings"].([]string)
+			if !reflect.DeepEqual(got, tt.wantWarnings) {
+				t.Errorf("warnings %q, want %q", got, tt.wantWarnings)
+			}
+			if _, ok := data["Error"]; ok != tt.wantError {
+				t.Errorf("error banner %v, want %v", data["Error"], tt.wantError)
+			}
+		})
+	}
+
+	var p pageErrors
+	p.stale(time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC))
+	if want := "API unreachable, showing cached data from 2024-03-01 12:30:00"; len(p.warnings) != 1 || p.warnings[0] != want {
+		t.Errorf("stale warning %q, want %q", p.warnings, want)
+	}
+}
+
+func TestHandleAlertsAPIFailure(t *testing.T) {
+	// The page shows the banner fields the layout renders
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		layoutFile:    `{{define "layout"}}{{template "content" .}}{{end}}`,
+		"alerts.html": `{{define "content"}}error={{.Error}} warnings={{range .Warnings}}{{.}};{{end}}{{end}}{{template "layout" .}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		healthStatus int
+		alertsStatus int
+		want         string
+	}{
+		{name: "healthy", healthStatus: 200, alertsStatus: 200, want: "error= warnings="},
+		{name: "alerts failing", healthStatus: 200, alertsStatus: 500, want: "error= warnings=Couldn&#39;t load alerts;"},
+		{
+			name: "API down", healthStatus: 503, alertsStatus: 503,
+			want: "error=API unreachable: data on this page may be missing or out of date warnings=Couldn&#39;t load alerts;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/health":
+					w.WriteHeader(tt.healthStatus)
+					w.Write([]byte(`{"status":"healthy"}`))
+				case "/alerts":
+					w.WriteHeader(tt.alertsStatus)
+					w.Write([]byte(`{"alerts":[]}`))
+				default:
+					w.Write([]byte(`{}`))
+				}
+			}))
+			s.auth = &auth{key: []byte("test key")}
+			s.tmpl = newTemplateSet(dir, template.FuncMap{}, false)
+
+			w := httptest.NewRecorder()
+			s.handleAlerts(w, httptest.NewRequest(http.MethodGet, "/alerts", nil))
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("status %d, page %q; want %q", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Alert page sizes offered on the alerts page
+var alertPageSizes = []int{25, 50, 100, 200}
+
+const defaultAlertPageSize = 50
+
+// alertPaging is the alerts page's position in the API's cursor-paged
+// listing. The API only pages forward, so the cursors that led to the current
+// page travel in ?trail= (comma-separated, oldest first) and Prev drops the last.
+type alertPaging struct {
+	Severity  string
+	Limit     int
+	PageSizes []int
+	Page      int // 1-based
+	trail     []string
+
+	NextURL string
+	PrevURL string
+}
+
+// parseAlertPaging reads ?severity=, ?limit= and ?trail=
+func parseAlertPaging(q url.Values) *alertPaging {
+	p := &alertPaging{Severity: q.Get("severity"), Limit: defaultAlertPageSize, PageSizes: alertPageSizes}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil {
+		for _, size := range alertPageSizes {
+			if n == size {
+				p.Limit = n
+			}
+		}
+	}
+	if trail := q.Get("trail"); trail != "" {
+		p.trail = strings.Split(trail, ",")
+	}
+	p.Page = len(p.trail) + 1
+	return p
+}
+
+// cursor is the API cursor of the current page, empty for the first
+func (p *alertPaging) cursor() string {
+	if len(p.trail) == 0 {
+		return ""
+	}
+	return p.trail[len(p.trail)-1]
+}
+
+// link builds an /alerts URL keeping the filter and page size
+func (p *alertPaging) link(trail []string) string {
+	q := url.Values{}
+	if p.Severity != "" {
+		q.Set("severity", p.Severity)
+	}
+	q.Set("limit", strconv.Itoa(p.Limit))
+	if len(trail) > 0 {
+		q.Set("trail", strings.Join(trail, ","))
+	}
+	return "/alerts?" + q.Encode()
+}
+
+// setLinks fills in NextURL and PrevURL given the API's next cursor
+func (p *alertPaging) setLinks(next string) {
+	if next != "" {
+		trail := append(append([]string{}, p.trail...), next)
+		p.NextURL = p.link(trail)
+	}
+	if len(p.trail) > 0 {
+		p.PrevURL = p.link(p.trail[:len(p.trail)-1])
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAlertPaging(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		next       string // the API's next cursor
+		wantLimit  int
+		wantPage   int
+		wantCursor string
+		wantNext   string
+		wantPrev   string
+	}{
+		{name: "first page", next: "c1", wantLimit: 50, wantPage: 1, wantNext: "/alerts?limit=50&trail=c1"},
+		{name: "only page", wantLimit: 50, wantPage: 1},
+		{name: "page size", query: "limit=25", next: "c1", wantLimit: 25, wantPage: 1, wantNext: "/alerts?limit=25&trail=c1"},
+		{name: "unoffered page size", query: "limit=30", wantLimit: 50, wantPage: 1},
+		{
+			name: "second page", query: "limit=25&trail=c1", next: "c2", wantLimit: 25, wantPage: 2, wantCursor: "c1",
+			wantNext: "/alerts?limit=25&trail=c1%2Cc2", wantPrev: "/alerts?limit=25",
+		},
+		{
+			name: "last page", query: "severity=high&limit=100&trail=c1,c2", wantLimit: 100, wantPage: 3, wantCursor: "c2",
+			wantPrev: "/alerts?limit=100&severity=high&trail=c1",
+		},
+		{
+			name: "severity kept", query: "severity=critical", next: "c1", wantLimit: 50, wantPage: 1,
+			wantNext: "/alerts?limit=50&severity=critical&trail=c1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := parseAlertPaging(q)
+			p.setLinks(tt.next)
+			if p.Limit != tt.wantLimit || p.Page != tt.wantPage || p.cursor() != tt.wantCursor {
+				t.Errorf("limit %d, page %d, cursor %q; want %d, %d, %q", p.Limit, p.Page, p.cursor(), tt.wantLimit, tt.wantPage, tt.wantCursor)
+			}
+			if p.NextURL != tt.wantNext || p.PrevURL != tt.wantPrev {
+				t.Errorf("next %q, prev %q; want %q, %q", p.NextURL, p.PrevURL, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestHandleAlertsPaging(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		next       string
+		wantParams url.Values // sent to the API, besides facility_id
+		wantPage   string
+		wantLinks  []string // hrefs on the page
+		noLinks    []string
+	}{
+		{
+			name: "first page", next: "cur-2",
+			wantParams: url.Values{"limit": {"50"}},
+			wantPage:   "Page 1", wantLinks: []string{"/alerts?limit=50&trail=cur-2"}, noLinks: []string{"← Previous"},
+		},
+		{
+			name: "next page with a filter", query: "severity=high&limit=25&trail=cur-2", next: "cur-3",
+			wantParams: url.Values{"limit": {"25"}, "severity": {"high"}, "cursor": {"cur-2"}},
+			wantPage:   "Page 2",
+			wantLinks:  []string{"/alerts?limit=25&severity=high&trail=cur-2%2Ccur-3", "/alerts?limit=25&severity=high"},
+		},
+		{
+			name: "last page", query: "limit=100&trail=cur-2", wantParams: url.Values{"limit": {"100"}, "cursor": {"cur-2"}},
+			wantPage: "Page 2", wantLinks: []string{"/alerts?limit=100"}, noLinks: []string{"Next →"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var params url.Values
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/alerts" {
+					mu.Lock()
+					params = r.URL.Query()
+					mu.Unlock()
+					w.Write([]byte(`{"alerts":[{"alertId":"a-1","severity":"high","type":"anomaly","message":"Power 40 kW","timestamp":1709294400}],"next_cursor":"` + tt.next + `"}`))
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			s.auth = &auth{key: []byte("test key")}
+			s.tmpl = newTemplateSet("../../templates", template.FuncMap{
+				"toJSON":     toJSON,
+				"formatTime": formatTime,
+			}, false)
+
+			w := httptest.NewRecorder()
+			s.handleAlerts(w, httptest.NewRequest(http.MethodGet, "/alerts?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			mu.Lock()
+			got := params
+			mu.Unlock()
+			want := url.Values{"facility_id": {"facility-001"}}
+			for k, v := range tt.wantParams {
+				want[k] = v
+			}
+			if got.Encode() != want.Encode() {
+				t.Errorf("API asked for %s, want %s", got.Encode(), want.Encode())
+			}
+
+			page := html.UnescapeString(w.Body.String())
+			if !strings.Contains(page, tt.wantPage) || !strings.Contains(page, "Power 40 kW") {
+				t.Errorf("page lacks %q or the alert:\n%s", tt.wantPage, page)
+			}
+			// Alert times are shown as dates, not raw epoch seconds
+			if !strings.Contains(page, `<div class="alert-time">2024-03-01 12:00:00</div>`) {
+				t.Errorf("alert time not formatted:\n%s", page)
+			}
+			if limit := want.Get("limit"); !strings.Contains(page, `<option value="`+limit+`" selected>`) {
+				t.Errorf("page size %s not selected", limit)
+			}
+			for _, link := range tt.wantLinks {
+				if !strings.Contains(page, `href="`+link+`"`) {
+					t.Errorf("no link to %s", link)
+				}
+			}
+			for _, text := range tt.noLinks {
+				if strings.Contains(page, text) {
+					t.Errorf("page has %q", text)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertTimesInFacilityZone(t *testing.T) {
+	s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/alerts" {
+			w.Write([]byte(`{"alerts":[{"alertId":"a-1","severity":"high","type":"anomaly","message":"Power 40 kW","timestamp":1709294400}]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	s.auth = &auth{key: []byte("test key")}
+	s.tmpl = newTemplateSet("../../templates", template.FuncMap{"toJSON": toJSON, "formatTime": formatTime}, false)
+	for facility, zone := range map[string]string{"facility-002": "America/New_York", "facility-003": "Asia/Tokyo"} {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.locs.Store(facility, loc)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "default facility without a known zone", query: "", want: "2024-03-01 12:00:00"},
+		{name: "selected facility", query: "facility_id=facility-002", want: "2024-03-01 07:00:00"},
+		{name: "another selected facility", query: "facility_id=facility-003", want: "2024-03-01 21:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleAlerts(w, httptest.NewRequest(http.MethodGet, "/alerts?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			if want := `<div class="alert-time">` + tt.want + `</div>`; !strings.Contains(w.Body.String(), want) {
+				t.Errorf("page lacks %s", want)
+			}
+		})
+	}
+}
@@ -3,11 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -21,44 +22,87 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
+	// Negotiate permessage-deflate with browsers that support it
+	EnableCompression: true,
 }
 
 type Server struct {
 	mux       *http.ServeMux
-	tmpl      *template.Template
+	tmpl      *templateSet
 	api       *api.Client
-	facility  string
-	clients   map[*websocket.Conn]bool
+	facility  string // FACILITY_ID, shown until a browser picks another
+	clients   map[*websocket.Conn]*wsClient
 	clientsMu sync.RWMutex
 	broadcast chan interface{}
+
+	// Per-client send queue length and what to do when it's full, from
+	// WS_CLIENT_BUFFER / WS_SLOW_CLIENT_POLICY
+	clientBuffer     int
+	slowClientPolicy string
+
+	// Stats window and push cadence, from STATS_WINDOW_HOURS / STATS_UPDATE_INTERVAL_SECONDS
+	statsWindowHours int
+	updateInterval   time.Duration
+
+	// statsCalls are the in-flight getStats fetches shared by concurrent
+	// callers; lastGood is each facility's latest complete fetch, served as
+	// stale for up to staleMax (STATS_STALE_MAX_MINUTES) while the API is failing
+	statsMu    sync.Mutex
+	statsCalls map[string]*statsCall
+	lastGood   map[string]*stats
+	staleMax   time.Duration
+
+	// nominalVoltage (NOMINAL_VOLTAGE) centres the bands readings are classified by
+	nominalVoltage float64
+
+	// auth protects the routes that change state; see requireLogin
+	auth *auth
+
+	// facilityList feeds the facility picker
+	facilityList facilityList
+
+	// locs holds each facility's time zone (*time.Location) as last reported by the API
+	locs sync.Map
+}
+
+// statsCall is a single backend fetch whose result is shared by every caller
+// that arrives while it is running
+type statsCall struct {
+	done chan struct{}
+	st   *stats
+	err  error
 }
 
 func New() *Server {
+	s := &Server{}
 	funcMap := template.FuncMap{
-		"toJSON": toJSON,
-		"formatTime": func(ts int64) string {
-			return time.Unix(ts, 0).Format("2006-01-02 15:04:05")
-		},
+		"toJSON":     toJSON,
+		"formatTime": formatTime,
 	}
 
-	tmpl := template.Must(template.New("base").Funcs(funcMap).ParseGlob("templates/*.html"))
-
-	if matches, _ := filepath.Glob("templates/partials/*.html"); len(matches) > 0 {
-		tmpl = template.Must(tmpl.ParseFiles(matches...))
-	}
+	tmpl := newTemplateSet("templates", funcMap, os.Getenv("DEV_MODE") == "true")
 
 	facility := os.Getenv("FACILITY_ID")
 	if facility == "" {
 		facility = "facility-001"
 	}
 
-	s := &Server{
-		mux:       http.NewServeMux(),
-		tmpl:      tmpl,
-		api:       api.New(),
-		facility:  facility,
-		clients:   make(map[*websocket.Conn]bool),
-		broadcast: make(chan interface{}, 256),
+	*s = Server{
+		mux:              http.NewServeMux(),
+		tmpl:             tmpl,
+		api:              api.New(),
+		facility:         facility,
+		clients:          make(map[*websocket.Conn]*wsClient),
+		broadcast:        make(chan interface{}, envInt("WS_BROADCAST_BUFFER", 256)),
+		clientBuffer:     envInt("WS_CLIENT_BUFFER", 16),
+		slowClientPolicy: slowClientPolicy(),
+		nominalVoltage:   nominalVoltage(),
+		auth:             newAuth(),
+		statsWindowHours: envInt("STATS_WINDOW_HOURS", 24),
+		updateInterval:   time.Duration(envInt("STATS_UPDATE_INTERVAL_SECONDS", 10)) * time.Second,
+		statsCalls:       make(map[string]*statsCall),
+		lastGood:         make(map[string]*stats),
+		staleMax:         time.Duration(envInt("STATS_STALE_MAX_MINUTES", 30)) * time.Minute,
 	}
 
 	s.routes()
@@ -75,10 +119,16 @@ func (s *Server) routes() {
 	s.mux.HandleFunc("/", s.handleDashboard)
 	s.mux.HandleFunc("/dashboard", s.handleDashboard)
 	s.mux.HandleFunc("/alerts", s.handleAlerts)
-	s.mux.HandleFunc("/alerts/acknowledge", s.handleAcknowledge)
-	s.mux.HandleFunc("/analytics", s.handleAnalytics)
+	// Every POST route checks the form's CSRF token before anything else
+	s.mux.HandleFunc("/login", s.requireCSRF(s.handleLogin))
+	s.mux.HandleFunc("/logout", s.requireCSRF(s.handleLogout))
+	s.mux.HandleFunc("/alerts/acknowledge", s.requireCSRF(s.requireLogin(s.handleAcknowledge, false)))
+	// Viewing analytics is open; generating a report needs a login
+	s.mux.HandleFunc("/analytics", s.requireCSRF(s.requireLogin(s.handleAnalytics, true)))
 	s.mux.HandleFunc("/equipment", s.handleEquipment)
+	s.mux.HandleFunc("/export", s.handleExport)
 	s.mux.HandleFunc("/api/stats", s.handleAPIStats)
+	s.mux.HandleFunc("/api/analytics/chart", s.handleAPIAnalyticsChart)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -86,109 +136,163 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	facility := s.facilityFor(w, r)
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("WebSocket upgrade error:", err)
+		slog.Error("websocket upgrade failed", "err", err)
 		return
 	}
 
-	s.clientsMu.Lock()
-	s.clients[conn] = true
-	s.clientsMu.Unlock()
-
-	defer func() {
-		s.clientsMu.Lock()
-		delete(s.clients, conn)
-		s.clientsMu.Unlock()
-		conn.Close()
-	}()
+	conn.EnableWriteCompression(true)
 
+	// Send the full snapshot before registering so it's written before any
+	// queued update
 	ctx := context.Background()
-	stats, _ := s.getStats(ctx)
+	st, _ := s.getStats(ctx, facility)
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
 	conn.WriteJSON(map[string]interface{}{
 		"type": "init",
-		"data": stats,
+		"data": st,
 	})
 
+	keepAlive(conn)
+	s.addClient(conn, facility, newSnapshot(st))
+	defer s.removeClient(conn)
+
+	// Clients send {"type":"pause"} and {"type":"resume"}; anything else is ignored
 	for {
-		if _, _, err := conn.ReadMessage(); err != nil {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
 			break
 		}
-	}
-}
-
-func (s *Server) handleBroadcast() {
-	for msg := range s.broadcast {
-		s.clientsMu.RLock()
-		for conn := range s.clients {
-			if err := conn.WriteJSON(msg); err != nil {
-				conn.Close()
-				delete(s.clients, conn)
-			}
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		switch msg.Type {
+		case "pause":
+			s.setPaused(conn, true)
+		case "resume":
+			s.setPaused(conn, false)
 		}
-		s.clientsMu.RUnlock()
 	}
 }
 
 func (s *Server) periodicUpdate() {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(s.updateInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		ctx := context.Background()
-		stats, err := s.getStats(ctx)
-		if err != nil {
-			continue
-		}
+		for _, facility := range s.watchedFacilities() {
+			st, err := s.getStats(ctx, facility)
+			if err != nil {
+				continue
+			}
 
-		s.broadcast <- map[string]interface{}{
-			"type": "update",
-			"data": stats,
+			s.broadcast <- st
 		}
 	}
 }
 
-func (s *Server) getStats(ctx context.Context) (map[string]interface{}, error) {
-	readings, _ := s.api.RecentReadings(ctx, s.facility, 24)
-	alerts, _ := s.api.Alerts(ctx, s.facility, "")
-
-	stats := map[string]interface{}{
-		"readings":  readings,
-		"alerts":    alerts,
-		"timestamp": time.Now().Unix(),
+// getStats returns the facility's current stats, collapsing concurrent calls
+// (e.g. a burst of WebSocket reconnects coinciding with a tick) into one
+// backend fetch
+func (s *Server) getStats(ctx context.Context, facility string) (*stats, error) {
+	s.statsMu.Lock()
+	if call := s.statsCalls[facility]; call != nil {
+		s.statsMu.Unlock()
+		select {
+		case <-call.done:
+			return call.st, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &statsCall{done: make(chan struct{})}
+	s.statsCalls[facility] = call
+	s.statsMu.Unlock()
+
+	// Detached from the caller's context so one caller going away doesn't
+	// fail the fetch for everyone waiting on it
+	fetchCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	call.st, call.err = s.fetchStats(fetchCtx, facility)
+	cancel()
+
+	s.statsMu.Lock()
+	delete(s.statsCalls, facility)
+	if last := s.lastGood[facility]; call.err == nil {
+		s.lastGood[facility] = call.st
+	} else if last != nil && time.Since(time.Unix(last.Timestamp, 0)) <= s.staleMax {
+		slog.Warn("stats fetch failed; serving cached stats", "facility_id", facility, "fetched_at", last.Timestamp, "err", call.err)
+		stale := *last
+		stale.Stale = true
+		call.st, call.err = &stale, nil
 	}
+	s.statsMu.Unlock()
+	close(call.done)
 
-	return stats, nil
+	return call.st, call.err
+}
+
+// fetchStats loads readings and alerts. When either fails it still returns
+// what did load, along with the error.
+func (s *Server) fetchStats(ctx context.Context, facility string) (*stats, error) {
+	readings, readErr := s.api.RecentReadings(ctx, facility, s.statsWindowHours)
+	s.noteTimezone(facility, readings)
+	s.classifyReadings(readings)
+	keyReadings(readings)
+	alerts, alertErr := s.api.Alerts(ctx, facility, "")
+
+	return &stats{
+		FacilityID: facility,
+		Readings:   readings,
+		Alerts:     alerts,
+		Timestamp:  time.Now().Unix(),
+	}, errors.Join(readErr, alertErr)
 }
 
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	health, err := s.api.Health(ctx)
-	status := "offline"
-	if err == nil && health != nil {
-		status = "online"
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": status})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": s.status(ctx),
+		"api":    s.api.Status(),
+	})
 }
 
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	readings, _ := s.api.RecentReadings(ctx, s.facility, 24)
-	alerts, _ := s.api.Alerts(ctx, s.facility, "")
+	// Shares the stats cache, so an outage shows the last good data marked stale
+	facility := s.facilityFor(w, r)
+	var errs pageErrors
+	st, err := s.getStats(ctx, facility)
+	errs.check("readings and alerts", err)
+	if st == nil {
+		st = &stats{}
+	}
+	if st.Stale {
+		errs.stale(time.Unix(st.Timestamp, 0).In(s.location(facility)))
+	}
 
+	apiStatus := s.status(ctx)
 	data := map[string]interface{}{
 		"Title":        "Energy Grid Dashboard",
-		"FacilityID":   s.facility,
-		"ReadingsJSON": toJSON(readings),
-		"Alerts":       alerts,
-		"APIStatus":    s.status(ctx),
+		"FacilityID":   facility,
+		"Facilities":   s.facilityChoices(ctx, facility),
+		"Timezone":     s.location(facility).String(),
+		"ReadingsJSON": toJSON(st.Readings),
+		"Alerts":       st.Alerts,
+		"Summary":      summarize(st, s.location(facility), time.Now(), apiStatus),
+		"APIStatus":    apiStatus,
 	}
+	errs.apply(data)
 
 	s.render(w, "dashboard.html", data)
 }
@@ -204,9 +308,11 @@ func (s *Server) handleEquipment(w http.ResponseWriter, r *http.Request) {
 		{ID: "eq-004", Type: "Switch", Status: "operational", Health: 98.1},
 	}
 
+	facility := s.facilityFor(w, r)
 	data := map[string]interface{}{
 		"Title":      "Equipment Monitoring",
-		"FacilityID": s.facility,
+		"FacilityID": facility,
+		"Facilities": s.facilityChoices(ctx, facility),
 		"Equipment":  equipment,
 		"APIStatus":  s.status(ctx),
 	}
@@ -218,16 +324,27 @@ func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	severity := r.URL.Query().Get("severity")
-	resp, _ := s.api.Alerts(ctx, s.facility, severity)
+	paging := parseAlertPaging(r.URL.Query())
+	facility := s.facilityFor(w, r)
+	var errs pageErrors
+	resp, err := s.api.AlertsPage(ctx, facility, paging.Severity, paging.cursor(), paging.Limit)
+	errs.check("alerts", err)
+	if resp != nil {
+		paging.setLinks(resp.NextCursor)
+	}
 
 	data := map[string]interface{}{
 		"Title":      "System Alerts",
-		"FacilityID": s.facility,
-		"Severity":   severity,
+		"FacilityID": facility,
+		"Facilities": s.facilityChoices(ctx, facility),
+		"Severity":   paging.Severity,
+		"Location":   s.location(facility),
 		"Alerts":     resp,
+		"Paging":     paging,
+		"CSRFToken":  s.csrfToken(w, r),
 		"APIStatus":  s.status(ctx),
 	}
+	errs.apply(data)
 
 	s.render(w, "alerts.html", data)
 }
@@ -248,7 +365,7 @@ func (s *Server) handleAcknowledge(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.api.AcknowledgeAlert(ctx, id); err != nil {
-		log.Println("ack error:", err)
+		slog.Error("alert acknowledge failed", "alert_id", id, "err", err)
 		http.Redirect(w, r, "/alerts?ack=fail", http.StatusSeeOther)
 		return
 	}
@@ -260,13 +377,16 @@ func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
 	defer cancel()
 
+	facility := s.facilityFor(w, r)
 	var report interface{}
+	chartDate := s.today(facility)
 	if r.Method == http.MethodPost {
 		date := r.FormValue("date")
 		if date == "" {
-			date = time.Now().Format("2006-01-02")
+			date = s.today(facility)
 		}
-		res, err := s.api.GenerateAnalytics(ctx, s.facility, date)
+		chartDate = date
+		res, err := s.api.GenerateAnalytics(ctx, facility, date)
 		if err != nil {
 			report = map[string]interface{}{"Error": "Failed to generate report"}
 		} else {
@@ -276,9 +396,12 @@ func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Title":      "Analytics & Reports",
-		"FacilityID": s.facility,
-		"Today":      time.Now().Format("2006-01-02"),
+		"FacilityID": facility,
+		"Facilities": s.facilityChoices(ctx, facility),
+		"Today":      s.today(facility),
+		"ChartDate":  chartDate,
 		"Report":     report,
+		"CSRFToken":  s.csrfToken(w, r),
 		"APIStatus":  s.status(ctx),
 	}
 
@@ -289,9 +412,9 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	stats, err := s.getStats(ctx)
+	stats, err := s.getStats(ctx, s.facilityFor(w, r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 
@@ -299,11 +422,48 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleAPIAnalyticsChart proxies the API's chart series for ?date= (default today)
+func (s *Server) handleAPIAnalyticsChart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	facility := s.facilityFor(w, r)
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = s.today(facility)
+	}
+
+	chart, err := s.api.AnalyticsChart(ctx, facility, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chart)
+}
+
+// recentRetryWindow is how long after a retried API call the status reads degraded
+const recentRetryWindow = time.Minute
+
+// status is online, degraded (the API answers but calls needed retries in the
+// last minute) or offline. An open breaker reads offline without a health call.
 func (s *Server) status(ctx context.Context) string {
-	if h, err := s.api.Health(ctx); err == nil && h != nil {
-		return "online"
+	if h, err := s.api.Health(ctx); err != nil || h == nil {
+		return "offline"
 	}
-	return "offline"
+	if st := s.api.Status(); st.LastRetry != nil && time.Since(*st.LastRetry) < recentRetryWindow {
+		return "degraded"
+	}
+	return "online"
+}
+
+// envInt reads a positive integer setting, falling back to def
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
 }
 
 func toJSON(v interface{}) template.JS {
@@ -311,10 +471,12 @@ func toJSON(v interface{}) template.JS {
 	return template.JS(b)
 }
 
+// formatTime shows epoch seconds as a date and time in loc, the selected
+// facility's zone
+func formatTime(ts int64, loc *time.Location) string {
+	return time.Unix(ts, 0).In(loc).Format("2006-01-02 15:04:05")
+}
+
 func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := s.tmpl.ExecuteTemplate(w, name, data); err != nil {
-		log.Println("render error:", err)
-		http.Error(w, "template error", http.StatusInternalServerError)
-	}
+	s.tmpl.render(w, name, data)
 }
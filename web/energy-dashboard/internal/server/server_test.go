@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"energy-dashboard-go/internal/api"
+)
+
+// newTestServer returns a Server whose API client talks to backend. Only the
+// stats fields are set; routes and the broadcast loops aren't started.
+func newTestServer(t *testing.T, backend http.Handler) *Server {
+	t.Helper()
+	srv := httptest.NewServer(backend)
+	t.Cleanup(srv.Close)
+	t.Setenv("API_URL", srv.URL)
+	t.Setenv("API_RETRIES", "0")
+	return &Server{
+		api:              api.New(),
+		facility:         "facility-001",
+		statsWindowHours: 24,
+		statsCalls:       make(map[string]*statsCall),
+		lastGood:         make(map[string]*stats),
+		staleMax:         30 * time.Minute,
+	}
+}
+
+func TestGetStatsSingleFlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		callers int
+		window  int
+	}{
+		{name: "one caller", callers: 1, window: 24},
+		{name: "simultaneous reconnects share a fetch", callers: 20, window: 24},
+		{name: "configured window", callers: 5, window: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			var hours atomic.Value
+			arrived := make(chan struct{}, 1)
+			release := make(chan struct{})
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/readings/recent" {
+					fetches.Add(1)
+					hours.Store(r.URL.Query().Get("hours"))
+					select {
+					case arrived <- struct{}{}:
+					default:
+					}
+					<-release
+				}
+				w.Write([]byte(`{}`))
+			}))
+			s.statsWindowHours = tt.window
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.callers)
+			for i := 0; i < tt.callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := s.getStats(context.Background(), "facility-001")
+					errs <- err
+				}()
+			}
+			// Hold the backend until the other callers have piled up behind the first fetch
+			<-arrived
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := fetches.Load(); n != 1 {
+				t.Errorf("%d callers made %d backend fetches, want 1", tt.callers, n)
+			}
+			if got, want := hours.Load(), strconv.Itoa(tt.window); got != want {
+				t.Errorf("hours = %v, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestGetStatsServesStale(t *testing.T) {
+	tests := []struct {
+		name      string
+		age       time.Duration // of the last good stats when the API fails
+		staleMax  time.Duration
+		wantStale bool
+	}{
+		{name: "fresh cache", age: time.Minute, staleMax: 30 * time.Minute, wantStale: true},
+		{name: "cache too old", age: time.Hour, staleMax: 30 * time.Minute},
+		{name: "stale serving off", age: time.Second, staleMax: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failing atomic.Bool
+			s := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if failing.Load() {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			s.staleMax = tt.staleMax
+
+			// A successful fetch populates the cache
+			good, err := s.getStats(context.Background(), "facility-001")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.lastGood["facility-001"] != good || good.Stale {
+				t.Fatalf("cached %+v after fetching %+v", s.lastGood["facility-001"], good)
+			}
+			good.Timestamp = time.Now().Add(-tt.age).Unix()
+
+			failing.Store(true)
+			st, err := s.getStats(context.Background(), "facility-001")
+			if !tt.wantStale {
+				if err == nil || st != nil && st.Stale {
+					t.Fatalf("got %+v, %v; want the fetch error", st, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !st.Stale || st.Timestamp != good.Timestamp || st == good {
+				t.Errorf("got %+v, want a stale copy of %+v", st, good)
+			}
+			// The cache itself stays unmarked, and is still the last good fetch
+			if good.Stale || s.lastGood["facility-001"] != good {
+				t.Errorf("cache changed to %+v", s.lastGood["facility-001"])
+			}
+
+			// Clients hear when stats go stale and when they recover
+			if d := diffSnapshots(newSnapshot(good), newSnapshot(st)); !d.Stale || !d.staleChanged {
+				t.Errorf("going stale: delta %+v", d)
+			}
+			failing.Store(false)
+			fresh, err := s.getStats(context.Background(), "facility-001")
+			if err != nil || fresh.Stale {
+				t.Fatalf("recovered with %+v, %v", fresh, err)
+			}
+			if d := diffSnapshots(newSnapshot(st), newSnapshot(fresh)); d.Stale || !d.staleChanged {
+				t.Errorf("recovering: delta %+v", d)
+			}
+		})
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{env: "", want: 10},
+		{env: "2", want: 2},
+		{env: "0", want: 10},
+		{env: "-5", want: 10},
+		{env: "1.5", want: 10},
+		{env: "soon", want: 10},
+	}
+	for _, tt := range tests {
+		t.Setenv("STATS_UPDATE_INTERVAL_SECONDS", tt.env)
+		if got := envInt("STATS_UPDATE_INTERVAL_SECONDS", 10); got != tt.want {
+			t.Errorf("STATS_UPDATE_INTERVAL_SECONDS=%q: %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
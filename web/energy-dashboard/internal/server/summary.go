@@ -0,0 +1,97 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"energy-dashboard-go/internal/models"
+)
+
+// severityOrder ranks alert severities for display, most urgent first
+var severityOrder = map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3}
+
+// severityCount is the number of open alerts of one severity
+type severityCount struct {
+	Severity string
+	Count    int
+}
+
+// kpiSummary is the dashboard's at-a-glance strip, computed from the stats
+// the page was rendered with
+type kpiSummary struct {
+	// CurrentPowerKW totals each meter's latest reading; CurrentAt is the
+	// latest of them, empty without readings
+	CurrentPowerKW float64
+	CurrentAt      string
+
+	// PeakTodayKW is the highest power since midnight in the facility's zone
+	PeakTodayKW float64
+	PeakTodayAt string
+
+	// OpenAlerts counts unacknowledged alerts, by severity most urgent first
+	OpenAlerts     int
+	OpenBySeverity []severityCount
+
+	APIStatus string
+}
+
+// summarize computes the KPI strip from st as of now in the facility's zone
+func summarize(st *stats, loc *time.Location, now time.Time, apiStatus string) kpiSummary {
+	k := kpiSummary{APIStatus: apiStatus}
+	if st == nil {
+		return k
+	}
+
+	if st.Readings != nil {
+		y, m, d := now.In(loc).Date()
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, loc).Unix()
+		var latest, peakAt int64
+		current := make(map[int64]models.Reading)
+		for _, r := range st.Readings.Readings {
+			if r.Timestamp > latest {
+				latest = r.Timestamp
+			}
+			if c, ok := current[r.MeterID]; !ok || r.Timestamp >= c.Timestamp {
+				current[r.MeterID] = r
+			}
+			if r.Timestamp >= midnight && (peakAt == 0 || r.PowerKW > k.PeakTodayKW) {
+				k.PeakTodayKW, peakAt = r.PowerKW, r.Timestamp
+			}
+		}
+		for _, r := range current {
+			k.CurrentPowerKW += r.PowerKW
+		}
+		if latest > 0 {
+			k.CurrentAt = time.Unix(latest, 0).In(loc).Format("15:04")
+		}
+		if peakAt > 0 {
+			k.PeakTodayAt = time.Unix(peakAt, 0).In(loc).Format("15:04")
+		}
+	}
+
+	if st.Alerts != nil {
+		counts := make(map[string]int)
+		for _, a := range st.Alerts.Alerts {
+			if !a.Acknowledged {
+				k.OpenAlerts++
+				counts[a.Severity]++
+			}
+		}
+		for sev, n := range counts {
+			k.OpenBySeverity = append(k.OpenBySeverity, severityCount{Severity: sev, Count: n})
+		}
+		sort.Slice(k.OpenBySeverity, func(i, j int) bool {
+			a, b := k.OpenBySeverity[i].Severity, k.OpenBySeverity[j].Severity
+			ra, oka := severityOrder[a]
+			rb, okb := severityOrder[b]
+			if oka != okb {
+				return oka
+			}
+			if ra != rb {
+				return ra < rb
+			}
+			return a < b
+		})
+	}
+	return k
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"energy-dashboard-go/internal/models"
+)
+
+func TestSummarize(t *testing.T) {
+	utc := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	at := func(hour, min int) int64 { return time.Date(2024, 3, 1, hour, min, 0, 0, time.UTC).Unix() }
+	// Two meters; yesterday's 40 kW is outside today in UTC but not 3 hours ahead
+	st := &stats{
+		Readings: &models.RecentReadingsResponse{Readings: []models.Reading{
+			{MeterID: 1, Timestamp: at(0, 0) - 3600, PowerKW: 40},
+			{MeterID: 1, Timestamp: at(9, 0), PowerKW: 12.5},
+			{MeterID: 2, Timestamp: at(9, 0), PowerKW: 30},
+			{MeterID: 1, Timestamp: at(13, 55), PowerKW: 8.5},
+			{MeterID: 2, Timestamp: at(13, 50), PowerKW: 4.25},
+		}},
+		Alerts: &models.AlertsResponse{Alerts: []models.Alert{
+			{AlertID: "a-1", Severity: "medium"},
+			{AlertID: "a-2", Severity: "critical"},
+			{AlertID: "a-3", Severity: "medium"},
+			{AlertID: "a-4", Severity: "critical", Acknowledged: true},
+			{AlertID: "a-5", Severity: "info"},
+			{AlertID: "a-6", Severity: "high"},
+		}},
+	}
+	open := []severityCount{{"critical", 1}, {"high", 1}, {"medium", 2}, {"info", 1}}
+
+	tests := []struct {
+		name string
+		st   *stats
+		loc  *time.Location
+		want kpiSummary
+	}{
+		{
+			name: "UTC", st: st, loc: time.UTC,
+			want: kpiSummary{
+				CurrentPowerKW: 12.75, CurrentAt: "13:55", PeakTodayKW: 30, PeakTodayAt: "09:00",
+				OpenAlerts: 5, OpenBySeverity: open, APIStatus: "healthy",
+			},
+		},
+		{
+			name: "three hours ahead", st: st, loc: time.FixedZone("UTC+3", 3*3600),
+			want: kpiSummary{
+				CurrentPowerKW: 12.75, CurrentAt: "16:55", PeakTodayKW: 40, PeakTodayAt: "02:00",
+				OpenAlerts: 5, OpenBySeverity: open, APIStatus: "healthy",
+			},
+		},
+		{
+			name: "no readings today", loc: time.UTC,
+			st:   &stats{Readings: &models.RecentReadingsResponse{Readings: []models.Reading{{MeterID: 1, Timestamp: at(0, 0) - 60, PowerKW: 7}}}},
+			want: kpiSummary{CurrentPowerKW: 7, CurrentAt: "23:59", APIStatus: "healthy"},
+		},
+		{name: "no stats", loc: time.UTC, want: kpiSummary{APIStatus: "healthy"}},
+		{name: "empty stats", st: &stats{}, loc: time.UTC, want: kpiSummary{APIStatus: "healthy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarize(tt.st, tt.loc, utc, "healthy"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summary %+v\nwant    %+v", got, tt.want)
+			}
+		})
+	}
+}
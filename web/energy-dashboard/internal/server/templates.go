@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+)
+
+// layoutFile holds the shared page chrome; with partials/*.html it is parsed
+// into every page
+const layoutFile = "layout.html"
+
+// templateSet holds the parsed page templates. In production they are parsed
+// once at startup; in dev mode (DEV_MODE=true) every render re-parses them so
+// edits show up without a restart. A parse error never crashes the process:
+// dev mode shows it on the page, production logs it and answers with a 500.
+//
+// Each page gets its own set, cloned from the layout, since every page
+// defines "content" and in one shared set the last definition would win.
+type templateSet struct {
+	dir     string
+	funcs   template.FuncMap
+	devMode bool
+
+	mu       sync.Mutex
+	pages    map[string]*template.Template // by file name
+	parseErr error
+}
+
+func newTemplateSet(dir string, funcs template.FuncMap, devMode bool) *templateSet {
+	t := &templateSet{dir: dir, funcs: funcs, devMode: devMode}
+	t.pages, t.parseErr = t.parse()
+	if t.parseErr != nil {
+		slog.Error("template parse failed", "err", t.parseErr)
+	}
+	return t
+}
+
+func (t *templateSet) parse() (map[string]*template.Template, error) {
+	shared := []string{filepath.Join(t.dir, layoutFile)}
+	partials, _ := filepath.Glob(filepath.Join(t.dir, "partials", "*.html"))
+	base, err := template.New("base").Funcs(t.funcs).ParseFiles(append(shared, partials...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(t.dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name == layoutFile {
+			continue
+		}
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if pages[name], err = page.ParseFiles(file); err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// current returns the named page's templates, re-parsing in dev mode
+func (t *templateSet) current(name string) (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.devMode {
+		t.pages, t.parseErr = t.parse()
+	}
+	if t.parseErr != nil {
+		return nil, t.parseErr
+	}
+	page, ok := t.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("no template page %q", name)
+	}
+	return page, nil
+}
+
+// render executes the named template into a buffer first, so a failure part
+// way through yields an error page instead of a truncated one
+func (t *templateSet) render(w http.ResponseWriter, name string, data interface{}) {
+	tmpl, err := t.current(name)
+	if err == nil {
+		var buf bytes.Buffer
+		if err = tmpl.ExecuteTemplate(&buf, name, data); err == nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			buf.WriteTo(w)
+			return
+		}
+	}
+
+	slog.Error("template render failed", "template", name, "err", err)
+	t.renderError(w, name, err)
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html><head><title>Dashboard error</title></head>
+<body style="font-family: sans-serif; margin: 2rem">
+<h1>The {{.Page}} page could not be rendered</h1>
+{{if .Detail}}<pre style="background: #fee; padding: 1rem; white-space: pre-wrap">{{.Detail}}</pre>
+{{else}}<p>The error has been logged. Please try again later.</p>{{end}}
+</body></html>
+`))
+
+// renderError answers with a 500 page; the error text is only shown in dev mode
+func (t *templateSet) renderError(w http.ResponseWriter, name string, err error) {
+	detail := ""
+	if t.devMode {
+		detail = err.Error()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	if execErr := errorPage.Execute(w, map[string]string{"Page": name, "Detail": detail}); execErr != nil {
+		fmt.Fprintf(w, "template error rendering %s", name)
+	}
+}
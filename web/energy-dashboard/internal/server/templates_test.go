@@ -0,0 +1,73 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testLayout   = `{{define "layout"}}<main>{{template "content" .}}</main>{{end}}`
+	goodPage     = `{{define "content"}}Hello {{.Name}}{{end}}{{template "layout" .}}`
+	brokenPage   = `{{define "content"}}Hello {{.Name}{{end}}{{template "layout" .}}`
+	failingPage  = `{{define "content"}}Hello {{call .Fail}}{{end}}{{template "layout" .}}`
+	renderedGood = `<main>Hello grid</main>`
+)
+
+// writeTemplates writes a layout and page.html into dir
+func writeTemplates(t *testing.T, dir, page string) {
+	t.Helper()
+	for name, body := range map[string]string{layoutFile: testLayout, "page.html": page} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTemplateSetRender(t *testing.T) {
+	data := map[string]interface{}{"Name": "grid", "Fail": func() (string, error) { return "", os.ErrPermission }}
+	tests := []struct {
+		name       string
+		devMode    bool
+		start      string // page.html when the set is created
+		edit       string // page.html before rendering, "" to leave it
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "production", start: goodPage, wantStatus: 200, wantBody: renderedGood},
+		{name: "production keeps the startup parse", start: goodPage, edit: brokenPage, wantStatus: 200, wantBody: renderedGood},
+		{name: "production with a broken template", start: brokenPage, wantStatus: 500, wantBody: "error has been logged"},
+		{name: "dev mode with a broken template", devMode: true, start: brokenPage, wantStatus: 500, wantBody: "bad character"},
+		{name: "dev mode picks up a broken edit", devMode: true, start: goodPage, edit: brokenPage, wantStatus: 500, wantBody: "page.html"},
+		{name: "dev mode picks up a fix", devMode: true, start: brokenPage, edit: goodPage, wantStatus: 200, wantBody: renderedGood},
+		{name: "failure part way through", start: failingPage, wantStatus: 500, wantBody: "could not be rendered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplates(t, dir, tt.start)
+			set := newTemplateSet(dir, template.FuncMap{}, tt.devMode)
+			if tt.edit != "" {
+				writeTemplates(t, dir, tt.edit)
+			}
+
+			w := httptest.NewRecorder()
+			set.render(w, "page.html", data)
+			body := w.Body.String()
+			if w.Code != tt.wantStatus || !strings.Contains(body, tt.wantBody) {
+				t.Errorf("status %d, body %q; want %d containing %q", w.Code, body, tt.wantStatus, tt.wantBody)
+			}
+			// An error page is a whole page, never the start of the failed one
+			if w.Code != http.StatusOK && strings.Contains(body, "<main>") {
+				t.Errorf("error page includes partial output: %q", body)
+			}
+			if !tt.devMode && w.Code != http.StatusOK && strings.Contains(body, "<pre") {
+				t.Errorf("production shows the error: %q", body)
+			}
+		})
+	}
+}
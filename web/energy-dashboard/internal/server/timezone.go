@@ -0,0 +1,37 @@
+package server
+
+import (
+	"log/slog"
+	"time"
+
+	"energy-dashboard-go/internal/models"
+)
+
+// location returns the facility's time zone, UTC until the API has reported one
+func (s *Server) location(facility string) *time.Location {
+	if loc, ok := s.locs.Load(facility); ok {
+		return loc.(*time.Location)
+	}
+	return time.UTC
+}
+
+// today is the current date in the facility's time zone, the default report and chart day
+func (s *Server) today(facility string) string {
+	return time.Now().In(s.location(facility)).Format("2006-01-02")
+}
+
+// noteTimezone records the facility zone carried by a readings response
+func (s *Server) noteTimezone(facility string, readings *models.RecentReadingsResponse) {
+	if readings == nil || readings.Timezone == "" {
+		return
+	}
+	if cur, ok := s.locs.Load(facility); ok && cur.(*time.Location).String() == readings.Timezone {
+		return
+	}
+	loc, err := time.LoadLocation(readings.Timezone)
+	if err != nil {
+		slog.Warn("ignoring unknown facility timezone", "facility_id", facility, "timezone", readings.Timezone, "err", err)
+		return
+	}
+	s.locs.Store(facility, loc)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// setupLogging installs the default slog logger from LOG_LEVEL (debug, info,
+// warn, error; default info) and LOG_FORMAT (json, the default, or console).
+// The standard log package is routed through it too. Unknown values fall back
+// with a warning.
+func setupLogging(level, format string) {
+	var lvl slog.Level
+	levelErr := lvl.UnmarshalText([]byte(strings.TrimSpace(level)))
+	if level == "" || levelErr != nil {
+		lvl = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "console":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h))
+
+	if level != "" && levelErr != nil {
+		slog.Warn("unknown LOG_LEVEL; using info", "log_level", level)
+	}
+	if f := strings.ToLower(strings.TrimSpace(format)); f != "" && f != "json" && f != "console" {
+		slog.Warn("unknown LOG_FORMAT; using json", "log_format", format)
+	}
+}
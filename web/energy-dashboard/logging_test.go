@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	tests := []struct {
+		level  string
+		format string
+		want   slog.Level // lowest level logged
+	}{
+		{level: "debug", format: "console", want: slog.LevelDebug},
+		{level: "WARN", format: "json", want: slog.LevelWarn},
+		{level: "", want: slog.LevelInfo},
+		{level: "loud", format: "xml", want: slog.LevelInfo},
+	}
+	for _, tt := range tests {
+		setupLogging(tt.level, tt.format)
+		h := slog.Default().Handler()
+		if !h.Enabled(context.Background(), tt.want) || h.Enabled(context.Background(), tt.want-1) {
+			t.Errorf("setupLogging(%q, %q) doesn't log from %v", tt.level, tt.format, tt.want)
+		}
+	}
+}
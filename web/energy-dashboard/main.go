@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 
@@ -9,11 +9,15 @@ import (
 )
 
 func main() {
+	setupLogging(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+
 	s := server.New()
 	addr := ":3002"
 	if v := os.Getenv("PORT"); v != "" {
 		addr = ":" + v
 	}
-	log.Println("Energy Dashboard (Go) listening on", addr)
-	log.Fatal(http.ListenAndServe(addr, s))
+	slog.Info("energy dashboard listening", "addr", addr)
+	err := http.ListenAndServe(addr, s)
+	slog.Error("server exit", "err", err)
+	os.Exit(1)
 }